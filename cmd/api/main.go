@@ -2,47 +2,130 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"example.com/go-yippi/internal/adapters/api/handlers"
+	"example.com/go-yippi/internal/adapters/api/middleware"
+	"example.com/go-yippi/internal/adapters/api/problem"
+	"example.com/go-yippi/internal/adapters/logging"
+	"example.com/go-yippi/internal/adapters/media"
+	"example.com/go-yippi/internal/adapters/metrics"
 	"example.com/go-yippi/internal/adapters/persistence"
-	"example.com/go-yippi/internal/adapters/persistence/db/ent"
+	"example.com/go-yippi/internal/adapters/persistence/migrations"
+	"example.com/go-yippi/internal/adapters/scanner"
+	"example.com/go-yippi/internal/adapters/security"
+	"example.com/go-yippi/internal/adapters/tracing"
 	"example.com/go-yippi/internal/application/services"
+	"example.com/go-yippi/internal/domain/entities"
+	"example.com/go-yippi/internal/domain/ports"
 	"example.com/go-yippi/internal/infrastructure/config"
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/danielgtaylor/huma/v2/adapters/humafiber"
 	"github.com/gofiber/fiber/v2"
-	"github.com/minio/minio-go/v7"
-	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 
 	_ "github.com/lib/pq"
 )
 
 func main() {
-	// Load configuration
-	cfg := config.Load()
+	// Load configuration from the defaults, the config file, the environment and flags, and refuse to
+	// start with settings the server cannot run with
+	flags := flag.NewFlagSet("api", flag.ExitOnError)
+	printConfig := flags.Bool("print-config", false, "print the configuration with secrets redacted and exit")
+	cfg, err := config.Load(flags, os.Args[1:])
+	if err != nil {
+		fatal(slog.Default(), "failed to load configuration", err)
+	}
+	if *printConfig {
+		if err := cfg.PrintRedacted(os.Stdout); err != nil {
+			fatal(slog.Default(), "failed to print configuration", err)
+		}
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		fatal(slog.Default(), "invalid configuration", err)
+	}
+
+	// Log structured lines with the request ID and tenant of their context; libraries that use the log
+	// package log through it as well
+	logger, err := logging.New(cfg.Log, os.Stdout)
+	if err != nil {
+		fatal(slog.Default(), "invalid logging configuration", err)
+	}
+	slog.SetDefault(logger)
+
+	// Initialize telemetry; metrics and traces are only collected if enabled
+	var appMetrics *metrics.Metrics
+	if cfg.Metrics.Enabled {
+		appMetrics = metrics.New()
+	}
+	tracer, err := tracing.New(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal(logger, "failed to initialize tracing", err)
+	}
 
-	// Initialize Ent client
-	client, err := ent.Open(cfg.Database.Driver, cfg.Database.DSN)
+	// Initialize Ent client; its statements are reported to the metrics and traces
+	var queryObservers []persistence.QueryObserver
+	if appMetrics != nil {
+		queryObservers = append(queryObservers, appMetrics)
+	}
+	if tracer != nil {
+		queryObservers = append(queryObservers, tracer.Queries(cfg.Database.Driver))
+	}
+	client, db, err := persistence.OpenObserved(cfg.Database.Driver, cfg.Database.DSN, queryObservers...)
 	if err != nil {
-		log.Fatalf("failed opening connection to database: %v", err)
+		fatal(logger, "failed opening connection to database", err)
+	}
+	if appMetrics != nil {
+		appMetrics.RegisterDB(db, cfg.Database.Driver)
 	}
-	defer client.Close()
 
-	// Run auto migration
-	if err := client.Schema.Create(context.Background()); err != nil {
-		log.Fatalf("failed creating schema resources: %v", err)
+	// Apply the pending migrations, waiting for replicas doing the same, or only verify there are none when
+	// migrations run in a separate deployment step
+	migrator, err := migrations.NewEmbedded(db, cfg.Database.Driver, cfg.Database.MigrationLockTimeout, logger)
+	if err != nil {
+		fatal(logger, "failed to read migrations", err)
 	}
+	if err := migrator.Ensure(context.Background(), cfg.Database.Migrations == "apply"); err != nil {
+		fatal(logger, "failed to migrate database", err)
+	}
+
+	// Initialize Fiber app; request bodies beyond the body limit are streamed so uploads are not held in memory
+	fiberConfig := handlers.FiberConfig(cfg.Server.BodyLimit)
+	fiberConfig.Concurrency = cfg.Server.Concurrency
+	fiberConfig.ReadTimeout = cfg.Server.ReadTimeout
+	fiberConfig.WriteTimeout = cfg.Server.WriteTimeout
+	fiberConfig.IdleTimeout = cfg.Server.IdleTimeout
+	fiberConfig.DisableStartupMessage = true
+	app := fiber.New(fiberConfig)
 
-	// Initialize Fiber app
-	app := fiber.New()
+	// Report all errors, including Huma's request validation, as problem details
+	huma.NewError = problem.New
 
 	// Initialize Huma API with custom config for Scalar docs
 	humaConfig := huma.DefaultConfig("Go Hexagonal API", "1.0.0")
 	humaConfig.DocsPath = "" // Disable default docs to use Scalar instead
+	// Problems carry the request ID, and the causes of server errors are logged
+	humaConfig.Transformers = append(humaConfig.Transformers, problem.NewTransformer(logger))
+	humaConfig.Components.SecuritySchemes = map[string]*huma.SecurityScheme{
+		middleware.BearerAuthScheme: {
+			Type:         "http",
+			Scheme:       "bearer",
+			BearerFormat: "opaque",
+		},
+	}
 	humaAPI := humafiber.New(app, humaConfig)
 
+	// Serve Prometheus metrics outside the API, so that scrapers need no token
+	if appMetrics != nil {
+		app.Get(cfg.Metrics.Path, adaptor.HTTPHandler(appMetrics.Handler()))
+	}
+
 	// Add custom /docs route for Scalar API documentation
 	app.Get("/docs", func(c *fiber.Ctx) error {
 		c.Set("Content-Type", "text/html")
@@ -61,64 +144,224 @@ func main() {
 		return c.SendString(html)
 	})
 
+	// Security adapters
+	passwordHasher := security.NewBcryptHasher()
+	tokenManager := security.NewHMACTokenManager(cfg.Auth.TokenSecret, cfg.Auth.Issuer)
+	totpProvider := security.NewTOTP(cfg.Auth.Issuer)
+
 	// Dependency injection
 	userRepo := persistence.NewUserRepository(client)
-	userService := services.NewUserService(userRepo)
+	userService := services.NewUserService(userRepo, passwordHasher)
 	userHandler := handlers.NewUserHandler(userService)
 
+	sensitiveRoles := make([]entities.Role, 0, len(cfg.Auth.SensitiveRoles))
+	for _, role := range cfg.Auth.SensitiveRoles {
+		sensitiveRoles = append(sensitiveRoles, entities.Role(role))
+	}
+	authService := services.NewAuthService(userRepo, passwordHasher, tokenManager, totpProvider, services.AuthPolicy{
+		AccessTokenTTL:    cfg.Auth.AccessTokenTTL,
+		ChallengeTokenTTL: cfg.Auth.ChallengeTokenTTL,
+		MaxTOTPAttempts:   cfg.Auth.TOTPMaxAttempts,
+		TOTPLockout:       cfg.Auth.TOTPLockout,
+		SensitiveRoles:    sensitiveRoles,
+	})
+	authHandler := handlers.NewAuthHandler(authService)
+
+	// Create the initial admin account if configured
+	if cfg.Auth.BootstrapAdminEmail != "" && cfg.Auth.BootstrapAdminPassword != "" {
+		if err := authService.BootstrapAdmin(context.Background(), cfg.Auth.BootstrapAdminEmail, cfg.Auth.BootstrapAdminPassword); err != nil {
+			fatal(logger, "failed to bootstrap admin account", err)
+		}
+	}
+
 	categoryRepo := persistence.NewCategoryRepository(client)
-	categoryService := services.NewCategoryService(categoryRepo)
+	var categoryService ports.CategoryService = services.NewCategoryService(categoryRepo)
+	if tracer != nil {
+		categoryService = tracer.Categories(categoryService)
+	}
 	categoryHandler := handlers.NewCategoryHandler(categoryService)
 
-	productRepo := persistence.NewProductRepository(client)
-	productService := services.NewProductService(productRepo, categoryRepo)
-	productHandler := handlers.NewProductHandler(productService)
-
 	brandRepo := persistence.NewBrandRepository(client)
-	brandService := services.NewBrandService(brandRepo)
+	var brandService ports.BrandService = services.NewBrandService(brandRepo)
+	if tracer != nil {
+		brandService = tracer.Brands(brandService)
+	}
 	brandHandler := handlers.NewBrandHandler(brandService)
 
-	// Initialize MinIO client (infrastructure)
-	minioClient, err := minio.New(cfg.MinIO.Endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(cfg.MinIO.AccessKeyID, cfg.MinIO.SecretAccessKey, ""),
-		Secure: cfg.MinIO.UseSSL,
-	})
-
+	// Initialize storage repository (adapter) for the configured backend
+	storageRepo, err := persistence.OpenStorage(cfg, client)
 	if err != nil {
-		log.Fatalf("failed to initialize MinIO client: %v", err)
+		fatal(logger, "failed to initialize storage", err)
+	}
+	if appMetrics != nil {
+		storageRepo = appMetrics.Storage(storageRepo, cfg.Storage.Backend)
+	}
+	if tracer != nil {
+		storageRepo = tracer.Storage(storageRepo, cfg.Storage.Backend)
 	}
 
 	// Ensure default bucket exists
-	ctx := context.Background()
-	exists, err := minioClient.BucketExists(ctx, cfg.MinIO.BucketName)
-	if err != nil {
-		log.Fatalf("failed to check if bucket exists: %v", err)
-	}
-	if !exists {
-		err = minioClient.MakeBucket(ctx, cfg.MinIO.BucketName, minio.MakeBucketOptions{})
-		if err != nil {
-			log.Fatalf("failed to create bucket: %v", err)
-		}
-		log.Printf("Created bucket: %s", cfg.MinIO.BucketName)
+	if err := storageRepo.EnsureBucket(context.Background(), cfg.MinIO.BucketName); err != nil {
+		fatal(logger, "failed to ensure bucket exists", err)
 	}
 
-	// Initialize storage repository (adapter)
-	storageRepo := persistence.NewMinIOStorageRepository(minioClient, cfg.MinIO.Endpoint, cfg.MinIO.UseSSL)
 	// Initialize storage service (application layer)
-	storageService := services.NewStorageService(storageRepo, cfg.MinIO.BucketName)
+	fileRepo := persistence.NewFileRepository(client)
+	productMediaRepo := persistence.NewProductMediaRepository(client)
+	// Uploaded images get derivatives rendered in the background
+	derivatives, err := services.ParseImageDerivatives(cfg.Image.Derivatives, cfg.Image.Formats)
+	if err != nil {
+		fatal(logger, "invalid image configuration", err)
+	}
+	imageService := services.NewImageService(storageRepo, fileRepo, media.NewProcessor(cfg.Image.JPEGQuality), derivatives, cfg.Image.Workers, cfg.Image.QueueSize, logger)
+	uploadLimits := services.UploadLimits{
+		Default:       cfg.Storage.MaxUploadSize,
+		ByBucket:      cfg.Storage.MaxUploadSizeBucket,
+		ByContentType: cfg.Storage.MaxUploadSizeType,
+	}
+	// Uploads are checked against the policy and scanned before they are recorded
+	fileScanner, err := newFileScanner(cfg)
+	if err != nil {
+		fatal(logger, "failed to initialize file scanner", err)
+	}
+	uploadPolicy := services.UploadPolicy{
+		Allowed:          cfg.Storage.AllowedTypes,
+		AllowedByBucket:  cfg.Storage.AllowedTypesBucket,
+		Blocked:          cfg.Storage.BlockedTypes,
+		QuarantineBucket: cfg.Storage.QuarantineBucket,
+	}
+	// Identical uploads share one stored object if deduplication is on
+	contentObjectRepo := persistence.NewContentObjectRepository(client)
+	var storageService ports.StorageService = services.NewStorageService(storageRepo, fileRepo, productMediaRepo, contentObjectRepo, imageService, fileScanner, services.StorageOptions{
+		DefaultBucket: cfg.MinIO.BucketName,
+		Limits:        uploadLimits,
+		Policy:        uploadPolicy,
+		PresignExpiry: cfg.Storage.PresignExpiry,
+		InUse:         services.InUsePolicy(cfg.Storage.DeleteInUse),
+		Dedup:         cfg.Storage.Dedup,
+	}, logger)
+	if appMetrics != nil {
+		storageService = appMetrics.Uploads(storageService)
+	}
+	if tracer != nil {
+		storageService = tracer.Files(storageService)
+	}
 	// Initialize file handler (API adapter)
-	fileHandler := handlers.NewFileHandler(storageService)
+	fileHandler := handlers.NewFileHandler(storageService, imageService, handlers.CacheControl{
+		Default:  cfg.Storage.CacheControl,
+		ByBucket: cfg.Storage.CacheControlBucket,
+	})
+
+	// Resumable uploads are stored through the storage service once complete
+	resumableUploadRepo := persistence.NewResumableUploadRepository(client)
+	resumableUploadService := services.NewResumableUploadService(storageRepo, resumableUploadRepo, storageService, cfg.MinIO.BucketName, uploadLimits, services.ResumableUploadPolicy{
+		PartSize: cfg.Storage.ResumablePartSize,
+		Expiry:   cfg.Storage.ResumableExpiry,
+	}, logger)
+	tusHandler := handlers.NewTusHandler(resumableUploadService, cfg.Storage.MaxUploadSize)
+
+	// Product galleries link to stored files
+	productRepo := persistence.NewProductRepository(client, db)
+	var productService ports.ProductService = services.NewProductService(productRepo, categoryRepo, brandRepo, productMediaRepo, storageService, services.ProductPolicy{
+		LeafCategoriesOnly:      cfg.Catalog.LeafCategoriesOnly,
+		PublishRequiresCategory: cfg.Catalog.PublishRequiresCategory,
+		PublishMinImages:        cfg.Catalog.PublishMinImages,
+	})
+	if appMetrics != nil {
+		productService = appMetrics.Products(productService)
+	}
+	if tracer != nil {
+		productService = tracer.Products(productService)
+	}
+	productHandler := handlers.NewProductHandler(productService)
+
+	// Readiness checks the database, whether it has been migrated, and the default bucket
+	healthService := services.NewHealthService(services.HealthPolicy{
+		Timeout:  cfg.Health.CheckTimeout,
+		CacheTTL: cfg.Health.CacheTTL,
+	}, logger)
+	healthService.Register(persistence.NewDatabaseCheck(db))
+	healthService.Register(persistence.NewMigrationCheck(migrator))
+	healthService.Register(persistence.NewStorageCheck(storageRepo, cfg.MinIO.BucketName))
+	healthHandler := handlers.NewHealthHandler(healthService)
+
+	// Give every request an ID, then trace, record and log it, including requests the other middleware rejects
+	humaAPI.UseMiddleware(middleware.NewRequestIDMiddleware(cfg.Log.RequestIDHeader))
+	if tracer != nil {
+		humaAPI.UseMiddleware(middleware.NewTracingMiddleware(tracer))
+	}
+	if appMetrics != nil {
+		humaAPI.UseMiddleware(middleware.NewMetricsMiddleware(appMetrics))
+	}
+	humaAPI.UseMiddleware(middleware.NewAccessLogMiddleware(logger))
+	// Authenticate requests and enforce operation permissions
+	humaAPI.UseMiddleware(middleware.NewAuthMiddleware(humaAPI, authService))
+	// Scope every request to a tenant (after auth, so the principal's tenant wins)
+	humaAPI.UseMiddleware(middleware.NewTenantMiddleware(humaAPI, cfg.Tenant.Header, cfg.Tenant.Default))
 
 	// Register Huma routes
+	authHandler.RegisterRoutes(humaAPI)
 	userHandler.RegisterRoutes(humaAPI)
 	categoryHandler.RegisterRoutes(humaAPI)
 	productHandler.RegisterRoutes(humaAPI)
 	brandHandler.RegisterRoutes(humaAPI)
 	fileHandler.RegisterRoutes(humaAPI)
-	// Start server
+	tusHandler.RegisterRoutes(humaAPI)
+	healthHandler.RegisterRoutes(humaAPI)
+	// Serve until SIGINT or SIGTERM
+	signals, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	addr := fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port)
-	log.Printf("Starting server on %s", addr)
-	if err := app.Listen(addr); err != nil {
-		log.Fatalf("failed to start server: %v", err)
+	listenErr := make(chan error, 1)
+	go func() {
+		logger.Info("starting server", "addr", addr)
+		listenErr <- app.Listen(addr)
+	}()
+	select {
+	case err := <-listenErr:
+		fatal(logger, "failed to start server", err)
+	case <-signals.Done():
+		stop() // a second signal ends the process at once
+	}
+
+	// Stop accepting connections and let in-flight requests finish, then stop what they may have started
+	// and release resources in reverse order of their creation, all within the shutdown timeout
+	logger.Info("shutting down", "timeout", cfg.Server.ShutdownTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := app.ShutdownWithContext(ctx); err != nil {
+		logger.Error("failed to finish in-flight requests", "error", err)
+	}
+	if err := imageService.Close(ctx); err != nil {
+		logger.Error("failed to finish image processing", "error", err)
+	}
+	if tracer != nil {
+		if err := tracer.Shutdown(ctx); err != nil {
+			logger.Error("failed to flush traces", "error", err)
+		}
+	}
+	if err := client.Close(); err != nil {
+		logger.Error("failed to close database", "error", err)
+	}
+	logger.Info("server stopped")
+}
+
+// fatal logs an error the server cannot run with and exits
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// newFileScanner creates the malware scanner selected by UPLOAD_SCANNER, or none
+func newFileScanner(cfg *config.Config) (ports.FileScanner, error) {
+	switch cfg.Storage.Scanner {
+	case "none":
+		return nil, nil
+	case "clamd":
+		return scanner.NewClamdScanner(cfg.Storage.ClamdAddress, cfg.Storage.ScanTimeout)
+	case "fake":
+		return scanner.NewFakeScanner(), nil
+	default:
+		return nil, fmt.Errorf("unknown scanner %q: expected none, clamd or fake", cfg.Storage.Scanner)
 	}
 }
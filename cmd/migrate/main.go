@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	stdsql "database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"example.com/go-yippi/internal/adapters/logging"
+	"example.com/go-yippi/internal/adapters/persistence/migrations"
+	"example.com/go-yippi/internal/infrastructure/config"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const usage = `Usage: migrate COMMAND [flags]
+
+Commands:
+  up [--dry-run]                apply the pending migrations
+  down [--steps N] [--dry-run]  revert the last applied migrations
+  status                        list the migrations and when they were applied
+  baseline VERSION              record the migrations up to VERSION as applied without running them
+  diff NAME                     plan a migration from the Ent schema for every dialect
+  hash                          rewrite atlas.sum after editing a migration
+
+With --dry-run, the statements are printed instead of executed. Run "migrate COMMAND -h" for the flags of
+a command, which include the configuration flags.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	command := os.Args[1]
+
+	flags := flag.NewFlagSet("migrate "+command, flag.ExitOnError)
+	var dryRun *bool
+	var steps *int
+	var dir, devDSN *string
+	switch command {
+	case "up":
+		dryRun = flags.Bool("dry-run", false, "print the statements instead of executing them")
+	case "down":
+		dryRun = flags.Bool("dry-run", false, "print the statements instead of executing them")
+		steps = flags.Int("steps", 1, "number of migrations to revert")
+	case "diff", "hash":
+		dir = flags.String("dir", filepath.Join("internal", "adapters", "persistence", "migrations"), "directory holding a migration directory per dialect")
+		devDSN = flags.String("postgres-dev-dsn", "", "clean PostgreSQL database to plan PostgreSQL migrations on (diff)")
+	case "status", "baseline":
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(flags, os.Args[2:])
+	if err != nil {
+		log.Fatalf("failed to load configuration: %v", err)
+	}
+	ctx := context.Background()
+
+	// Planning does not touch the configured database
+	switch command {
+	case "diff":
+		if flags.NArg() != 1 {
+			log.Fatal("usage: migrate diff NAME")
+		}
+		diff(ctx, *dir, *devDSN, flags.Arg(0))
+		return
+	case "hash":
+		for _, driver := range []string{"postgres", "sqlite3"} {
+			if err := migrations.Hash(filepath.Join(*dir, driver)); err != nil {
+				log.Fatalf("failed to hash %s migrations: %v", driver, err)
+			}
+		}
+		return
+	}
+
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
+	logger, err := logging.New(cfg.Log, os.Stderr)
+	if err != nil {
+		log.Fatalf("invalid logging configuration: %v", err)
+	}
+	db, err := stdsql.Open(cfg.Database.Driver, cfg.Database.DSN)
+	if err != nil {
+		log.Fatalf("failed opening connection to database: %v", err)
+	}
+	defer db.Close()
+	migrator, err := migrations.NewEmbedded(db, cfg.Database.Driver, cfg.Database.MigrationLockTimeout, logger)
+	if err != nil {
+		log.Fatalf("failed to read migrations: %v", err)
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx, *dryRun)
+		printMigrations(applied, *dryRun, "applied", func(m migrations.Migration) string { return m.Up })
+		if err != nil {
+			log.Fatalf("migration failed: %v", err)
+		}
+	case "down":
+		reverted, err := migrator.Down(ctx, *steps, *dryRun)
+		printMigrations(reverted, *dryRun, "reverted", func(m migrations.Migration) string { return m.Down })
+		if err != nil {
+			log.Fatalf("migration failed: %v", err)
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatalf("failed to look up migrations: %v", err)
+		}
+		printStatus(statuses)
+	case "baseline":
+		if flags.NArg() != 1 {
+			log.Fatal("usage: migrate baseline VERSION")
+		}
+		recorded, err := migrator.Baseline(ctx, flags.Arg(0))
+		if err != nil {
+			log.Fatalf("baseline failed: %v", err)
+		}
+		printMigrations(recorded, false, "recorded", nil)
+	}
+}
+
+// diff plans the migration called name for every dialect. SQLite migrations are replayed in memory;
+// PostgreSQL needs a clean database.
+func diff(ctx context.Context, dir, postgresDevDSN, name string) {
+	if postgresDevDSN == "" {
+		log.Fatal("planning PostgreSQL migrations needs a clean database, see --postgres-dev-dsn")
+	}
+	devDSNs := map[string]string{
+		"postgres": postgresDevDSN,
+		"sqlite3":  "file:dev?mode=memory&_fk=1",
+	}
+	for _, driver := range []string{"postgres", "sqlite3"} {
+		written, err := migrations.Diff(ctx, filepath.Join(dir, driver), driver, devDSNs[driver], name)
+		if err != nil {
+			log.Fatalf("failed to plan %s migration: %v", driver, err)
+		}
+		if !written {
+			fmt.Printf("%s: the migrations match the Ent schema\n", driver)
+			continue
+		}
+		fmt.Printf("%s: planned migration %s; review it before committing\n", driver, name)
+	}
+}
+
+// printMigrations lists the migrations a command ran, or their statements in a dry run
+func printMigrations(ran []migrations.Migration, dryRun bool, verb string, statements func(migrations.Migration) string) {
+	if len(ran) == 0 {
+		fmt.Println("No migrations to run")
+		return
+	}
+	for _, migration := range ran {
+		if dryRun {
+			fmt.Printf("-- %s\n%s\n", migration.ID(), statements(migration))
+			continue
+		}
+		fmt.Printf("%s %s\n", verb, migration.ID())
+	}
+}
+
+func printStatus(statuses []migrations.Status) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+	for _, status := range statuses {
+		applied := "pending"
+		switch {
+		case status.Unknown:
+			applied = status.AppliedAt.Format("2006-01-02 15:04:05") + " (unknown to this release)"
+		case !status.AppliedAt.IsZero():
+			applied = status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", status.Version, status.Name, applied)
+	}
+	w.Flush()
+}
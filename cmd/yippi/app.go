@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	stdsql "database/sql"
+	"flag"
+	"log"
+	"log/slog"
+	"os"
+
+	"example.com/go-yippi/internal/adapters/logging"
+	"example.com/go-yippi/internal/adapters/media"
+	"example.com/go-yippi/internal/adapters/persistence"
+	"example.com/go-yippi/internal/adapters/persistence/db/ent"
+	"example.com/go-yippi/internal/adapters/persistence/migrations"
+	"example.com/go-yippi/internal/adapters/security"
+	"example.com/go-yippi/internal/application/services"
+	"example.com/go-yippi/internal/domain/entities"
+	"example.com/go-yippi/internal/infrastructure/config"
+)
+
+// app holds what the commands working on the database share
+type app struct {
+	cfg    *config.Config
+	logger *slog.Logger
+	client *ent.Client
+	db     *stdsql.DB
+	// images is started by storage and stopped by Close
+	images *services.ImageService
+	// files is the storage service, opened by the first call to storage
+	files *services.StorageService
+}
+
+// open loads the configuration, with the flags of the command registered on flags, and connects to the
+// database after applying the pending migrations or verifying there are none, as DB_MIGRATIONS selects
+func open(flags *flag.FlagSet, args []string) *app {
+	cfg, err := config.Load(flags, args)
+	if err != nil {
+		log.Fatalf("failed to load configuration: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
+	// Logs go to stderr; stdout is left to the output of the command
+	logger, err := logging.New(cfg.Log, os.Stderr)
+	if err != nil {
+		log.Fatalf("invalid logging configuration: %v", err)
+	}
+
+	client, db, err := persistence.OpenObserved(cfg.Database.Driver, cfg.Database.DSN)
+	if err != nil {
+		log.Fatalf("failed opening connection to database: %v", err)
+	}
+	migrator, err := migrations.NewEmbedded(db, cfg.Database.Driver, cfg.Database.MigrationLockTimeout, logger)
+	if err != nil {
+		log.Fatalf("failed to read migrations: %v", err)
+	}
+	if err := migrator.Ensure(context.Background(), cfg.Database.Migrations == "apply"); err != nil {
+		log.Fatalf("failed to migrate database: %v", err)
+	}
+
+	return &app{
+		cfg:    cfg,
+		logger: logger,
+		client: client,
+		db:     db,
+	}
+}
+
+// tenantFlag registers the flag naming the tenant whose catalog a command works on
+func tenantFlag(flags *flag.FlagSet) *string {
+	return flags.String("tenant", "", "tenant whose catalog to work on (default TENANT_DEFAULT)")
+}
+
+// tenantContext returns a context scoped to tenant, or to the default tenant if it is empty
+func (a *app) tenantContext(tenant string) context.Context {
+	if tenant == "" {
+		tenant = a.cfg.Tenant.Default
+	}
+	if !entities.IsValidTenantID(tenant) {
+		log.Fatalf("invalid tenant %q", tenant)
+	}
+	return entities.ContextWithTenant(context.Background(), tenant)
+}
+
+func (a *app) Close() {
+	if a.images != nil {
+		a.images.Close(context.Background())
+	}
+	a.client.Close()
+}
+
+// products returns the product service under the configured catalog rules, with the storage service to
+// attach files to galleries and render their links
+func (a *app) products() *services.ProductService {
+	return services.NewProductService(
+		persistence.NewProductRepository(a.client, a.db),
+		persistence.NewCategoryRepository(a.client),
+		persistence.NewBrandRepository(a.client),
+		persistence.NewProductMediaRepository(a.client),
+		a.storage(),
+		services.ProductPolicy{
+			LeafCategoriesOnly:      a.cfg.Catalog.LeafCategoriesOnly,
+			PublishRequiresCategory: a.cfg.Catalog.PublishRequiresCategory,
+			PublishMinImages:        a.cfg.Catalog.PublishMinImages,
+		},
+	)
+}
+
+func (a *app) categories() *services.CategoryService {
+	return services.NewCategoryService(persistence.NewCategoryRepository(a.client))
+}
+
+func (a *app) brands() *services.BrandService {
+	return services.NewBrandService(persistence.NewBrandRepository(a.client))
+}
+
+// storage returns the storage service of the configured backend. Uploaded images are analysed but get no
+// derivatives, which are rendered on demand instead. Storage is opened once and shared by the services.
+func (a *app) storage() *services.StorageService {
+	if a.files != nil {
+		return a.files
+	}
+	storageRepo, err := persistence.OpenStorage(a.cfg, a.client)
+	if err != nil {
+		log.Fatalf("failed to initialize storage: %v", err)
+	}
+	if err := storageRepo.EnsureBucket(context.Background(), a.cfg.MinIO.BucketName); err != nil {
+		log.Fatalf("failed to ensure bucket: %v", err)
+	}
+	fileRepo := persistence.NewFileRepository(a.client)
+	a.images = services.NewImageService(storageRepo, fileRepo, media.NewProcessor(a.cfg.Image.JPEGQuality), nil, 1, 100, a.logger)
+	a.files = services.NewStorageService(storageRepo, fileRepo, persistence.NewProductMediaRepository(a.client), persistence.NewContentObjectRepository(a.client), a.images, nil, services.StorageOptions{
+		DefaultBucket: a.cfg.MinIO.BucketName,
+		PresignExpiry: a.cfg.Storage.PresignExpiry,
+		Dedup:         a.cfg.Storage.Dedup,
+	}, a.logger)
+	return a.files
+}
+
+func (a *app) users() *services.UserService {
+	return services.NewUserService(persistence.NewUserRepository(a.client), security.NewBcryptHasher())
+}
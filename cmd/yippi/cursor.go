@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"example.com/go-yippi/internal/adapters/persistence"
+)
+
+// cursor prints the position a pagination cursor of the API points at; it does not need the database
+func cursor(args []string) {
+	flags := flag.NewFlagSet("yippi cursor", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: yippi cursor CURSOR")
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	decoded, err := persistence.DecodeCursor(flags.Arg(0))
+	if err != nil {
+		log.Fatalf("invalid cursor: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID\t%d\n", decoded.ID)
+	if decoded.UUID != "" {
+		fmt.Fprintf(w, "UUID\t%s\n", decoded.UUID)
+	}
+	fmt.Fprintf(w, "CREATED AT\t%s\n", decoded.CreatedAt)
+	w.Flush()
+}
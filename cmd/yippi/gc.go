@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"example.com/go-yippi/internal/adapters/media"
+	"example.com/go-yippi/internal/adapters/persistence"
+	"example.com/go-yippi/internal/application/services"
+	"example.com/go-yippi/internal/domain/entities"
+)
+
+// bucketList collects the buckets of repeated -bucket flags
+type bucketList []string
+
+func (b *bucketList) String() string {
+	return strings.Join(*b, ",")
+}
+
+func (b *bucketList) Set(bucket string) error {
+	*b = append(*b, bucket)
+	return nil
+}
+
+func gc(args []string) {
+	flags := flag.NewFlagSet("yippi gc", flag.ExitOnError)
+	var buckets bucketList
+	flags.Var(&buckets, "bucket", "bucket to collect, repeatable (default the configured bucket)")
+	grace := flags.Duration("grace", 0, "leave objects modified more recently alone (default GC_GRACE_PERIOD)")
+	dryRun := flags.Bool("dry-run", false, "report orphaned objects without deleting them")
+	unreferenced := flags.Bool("unreferenced", false, "also delete recorded files that no product references")
+	a := open(flags, args)
+	defer a.Close()
+	if len(buckets) == 0 {
+		buckets = bucketList{a.cfg.MinIO.BucketName}
+	}
+	gracePeriod := a.cfg.Storage.GCGracePeriod
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "grace" {
+			gracePeriod = *grace
+		}
+	})
+
+	storageRepo, err := persistence.OpenStorage(a.cfg, a.client)
+	if err != nil {
+		log.Fatalf("failed to initialize storage: %v", err)
+	}
+
+	// Unreferenced files are deleted like through the API, which removes their variants and shared content.
+	// Nothing is uploaded, so images are not processed and files are not scanned.
+	fileRepo := persistence.NewFileRepository(a.client)
+	productMediaRepo := persistence.NewProductMediaRepository(a.client)
+	contentObjectRepo := persistence.NewContentObjectRepository(a.client)
+	imageService := services.NewImageService(storageRepo, fileRepo, media.NewProcessor(a.cfg.Image.JPEGQuality), nil, 0, 0, a.logger)
+	defer imageService.Close(context.Background())
+	storageService := services.NewStorageService(storageRepo, fileRepo, productMediaRepo, contentObjectRepo, imageService, nil, services.StorageOptions{
+		DefaultBucket: a.cfg.MinIO.BucketName,
+		PresignExpiry: a.cfg.Storage.PresignExpiry,
+		Dedup:         a.cfg.Storage.Dedup,
+	}, a.logger)
+	collector := services.NewGarbageCollector(storageRepo, fileRepo, productMediaRepo, persistence.NewProductRepository(a.client, a.db), persistence.NewResumableUploadRepository(a.client), contentObjectRepo, storageService, a.logger)
+
+	// The collector works across tenants, scoping each object to the tenant in its key
+	report, err := collector.Collect(context.Background(), entities.GCOptions{
+		Buckets:           buckets,
+		GracePeriod:       gracePeriod,
+		DryRun:            *dryRun,
+		UnreferencedFiles: *unreferenced,
+	})
+	if err != nil {
+		log.Fatalf("garbage collection failed: %v", err)
+	}
+
+	printReport(report)
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// printReport lists the orphans of a run and sums it up
+func printReport(report *entities.GCReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BUCKET\tKEY\tSIZE\tMODIFIED\tREASON\tDELETED")
+	for _, orphan := range report.Orphans {
+		key := orphan.Key
+		if orphan.FileID != nil {
+			key += " (file " + orphan.FileID.String() + ")"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%t\n", orphan.Bucket, key, orphan.Size, orphan.LastModified.Format("2006-01-02 15:04:05"), orphan.Reason, orphan.Deleted)
+	}
+	w.Flush()
+
+	if report.DryRun {
+		fmt.Printf("\nDry run: %d objects scanned, %d orphaned, %d within the grace period, %d outside the storage layout\n",
+			report.Scanned, len(report.Orphans), report.Recent, report.Ignored)
+		return
+	}
+	fmt.Printf("\n%d objects scanned, %d orphans deleted (%d bytes), %d failed, %d within the grace period, %d outside the storage layout\n",
+		report.Scanned, report.Deleted, report.FreedBytes, report.Failed, report.Recent, report.Ignored)
+}
@@ -0,0 +1,52 @@
+// Command yippi administers a go-yippi deployment: it seeds catalogs, reports on them, manages users and
+// collects unused stored objects. Commands go through the same services as the API, so its business rules
+// apply, and use the configuration of the API.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	_ "github.com/lib/pq"
+)
+
+const usage = `Usage: yippi COMMAND [flags]
+
+Commands:
+  seed                      generate categories, brands and products
+  stats                     count the products by status, category and brand
+  user list                 list the users
+  user create               create a user
+  user role EMAIL ROLE      change the role of a user
+  user password EMAIL       set the password of a user, read from stdin
+  user delete EMAIL         delete a user
+  roles                     list the roles and the permissions they grant
+  cursor CURSOR             decode a pagination cursor
+  gc                        delete stored objects that nothing references
+
+Catalog commands work on the default tenant unless --tenant names another. Run "yippi COMMAND -h" for the
+flags of a command, which include the configuration flags.
+`
+
+// commands are the commands by name; each parses its own arguments
+var commands = map[string]func(args []string){
+	"seed":   seed,
+	"stats":  stats,
+	"user":   user,
+	"roles":  roles,
+	"cursor": cursor,
+	"gc":     gc,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	command(os.Args[2:])
+}
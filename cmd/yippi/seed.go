@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"example.com/go-yippi/internal/application/services"
+	"example.com/go-yippi/internal/domain/entities"
+	"example.com/go-yippi/internal/domain/ports"
+)
+
+func seed(args []string) {
+	flags := flag.NewFlagSet("yippi seed", flag.ExitOnError)
+	products := flags.Int("products", 1000, "number of products to add")
+	depth := flags.Int("depth", 2, "levels of the category tree; 0 for no categories")
+	fanOut := flags.Int("fan-out", 5, "number of root categories and of subcategories of each category")
+	brands := flags.Int("brands", 10, "number of brands to spread the products over")
+	skew := flags.Float64("skew", 1.3, "how much a few categories and brands dominate, above 1; 0 spreads products evenly")
+	images := flags.Int("images", 10, "number of placeholder images stored and shared by the products; 0 to leave storage alone")
+	randomSeed := flags.Uint64("seed", 1, "random seed; the same seed generates the same catalog")
+	batchSize := flags.Int("batch-size", 1000, "number of products created at once")
+	tenant := tenantFlag(flags)
+	a := open(flags, args)
+	defer a.Close()
+	ctx := a.tenantContext(*tenant)
+
+	var storage ports.StorageService
+	if *images > 0 {
+		storage = a.storage()
+	}
+	seeder := services.NewCatalogSeeder(a.products(), a.categories(), a.brands(), storage, a.logger)
+	start := time.Now()
+	catalog, err := seeder.Seed(ctx, entities.SeedOptions{
+		Seed:           *randomSeed,
+		CategoryDepth:  *depth,
+		CategoryFanOut: *fanOut,
+		Brands:         *brands,
+		Products:       *products,
+		Skew:           *skew,
+		Images:         *images,
+		MinImages:      a.cfg.Catalog.PublishMinImages,
+		BatchSize:      *batchSize,
+	})
+	if err != nil {
+		log.Fatalf("failed to seed catalog: %v", err)
+	}
+
+	fmt.Printf("Added %d products in %s; the catalog has %d products, and the seeded tree %d categories (%d leaves), %d brands and %d placeholder images\n",
+		catalog.Added, time.Since(start).Round(time.Millisecond), catalog.Total, len(catalog.Categories), len(catalog.Leaves), len(catalog.Brands), len(catalog.Images))
+}
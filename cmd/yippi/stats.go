@@ -0,0 +1,88 @@
+package main
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/google/uuid"
+)
+
+func stats(args []string) {
+	flags := flag.NewFlagSet("yippi stats", flag.ExitOnError)
+	tenant := tenantFlag(flags)
+	a := open(flags, args)
+	defer a.Close()
+	ctx := a.tenantContext(*tenant)
+
+	counts, err := a.products().ProductStats(ctx)
+	if err != nil {
+		log.Fatalf("failed to count products: %v", err)
+	}
+	categories, err := a.categories().ListCategories(ctx)
+	if err != nil {
+		log.Fatalf("failed to list categories: %v", err)
+	}
+	brands, err := a.brands().ListBrands(ctx)
+	if err != nil {
+		log.Fatalf("failed to list brands: %v", err)
+	}
+
+	categoryNames := map[uuid.UUID]string{}
+	for _, category := range categories {
+		categoryNames[category.ID] = category.Name
+	}
+	brandNames := map[uuid.UUID]string{}
+	for _, brand := range brands {
+		brandNames[brand.ID] = brand.Name
+	}
+
+	fmt.Printf("%d products\n\n", counts.Total)
+	statuses := map[string]int{}
+	for status, count := range counts.ByStatus {
+		statuses[string(status)] = count
+	}
+	printCounts("STATUS", statuses)
+	fmt.Println()
+	printCounts("CATEGORY", named(counts.ByCategory, categoryNames))
+	fmt.Println()
+	printCounts("BRAND", named(counts.ByBrand, brandNames))
+}
+
+// named keys counts by the names of their IDs; products without a reference are counted as "(none)"
+func named(counts map[uuid.UUID]int, names map[uuid.UUID]string) map[string]int {
+	byName := make(map[string]int, len(counts))
+	for id, count := range counts {
+		name, ok := names[id]
+		switch {
+		case id == uuid.Nil:
+			name = "(none)"
+		case !ok:
+			name = id.String()
+		}
+		byName[name] += count
+	}
+	return byName
+}
+
+// printCounts lists counts from the largest to the smallest
+func printCounts(heading string, counts map[string]int) {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		return cmp.Or(cmp.Compare(counts[b], counts[a]), cmp.Compare(a, b))
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tPRODUCTS\n", heading)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s\t%d\n", key, counts[key])
+	}
+	w.Flush()
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"example.com/go-yippi/internal/domain/entities"
+	"example.com/go-yippi/internal/infrastructure/config"
+)
+
+const userUsage = `Usage: yippi user COMMAND [flags]
+
+Commands:
+  list                  list the users
+  create                create a user; see "yippi user create -h"
+  role EMAIL ROLE       change the role of a user
+  password EMAIL        set the password of a user, read from the first line of stdin
+  delete EMAIL          delete a user
+`
+
+func user(args []string) {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, userUsage)
+		os.Exit(2)
+	}
+	command := args[0]
+	flags := flag.NewFlagSet("yippi user "+command, flag.ExitOnError)
+	// Users are not scoped to a tenant, they belong to one
+	ctx := context.Background()
+
+	switch command {
+	case "list":
+		a := open(flags, args[1:])
+		defer a.Close()
+		users, err := a.users().ListUsers(ctx)
+		if err != nil {
+			log.Fatalf("failed to list users: %v", err)
+		}
+		printUsers(users)
+	case "create":
+		email := flags.String("email", "", "email the user logs in with (required)")
+		name := flags.String("name", "", "name of the user")
+		age := flags.Int("age", 1, "age of the user")
+		role := flags.String("role", string(entities.RoleViewer), "role of the user")
+		passwordStdin := flags.Bool("password-stdin", false, "read the password from the first line of stdin; without it, the user cannot log in")
+		tenant := flags.String("tenant", "", "tenant whose catalog the user works on (default TENANT_DEFAULT)")
+		a := open(flags, args[1:])
+		defer a.Close()
+		ctx = a.tenantContext(*tenant)
+		if *email == "" {
+			log.Fatal("usage: yippi user create --email EMAIL [--name NAME] [--role ROLE] [--password-stdin]")
+		}
+		service := a.users()
+		created := &entities.User{Email: *email, Name: *name, Age: *age, Role: entities.Role(*role)}
+		if err := service.CreateUser(ctx, created); err != nil {
+			log.Fatalf("failed to create user: %v", err)
+		}
+		if *passwordStdin {
+			if err := service.SetPassword(ctx, created.ID, readPassword()); err != nil {
+				log.Fatalf("failed to set password of user %d: %v", created.ID, err)
+			}
+		}
+		fmt.Printf("created user %d (%s) with role %s in tenant %s\n", created.ID, created.Email, created.Role, created.TenantID)
+	case "role":
+		a := open(flags, args[1:])
+		defer a.Close()
+		if flags.NArg() != 2 {
+			log.Fatal("usage: yippi user role EMAIL ROLE")
+		}
+		service := a.users()
+		found, err := service.GetUserByEmail(ctx, flags.Arg(0))
+		if err != nil {
+			log.Fatalf("failed to find user: %v", err)
+		}
+		found.Role = entities.Role(flags.Arg(1))
+		if err := service.UpdateUser(ctx, found); err != nil {
+			log.Fatalf("failed to change role: %v", err)
+		}
+		fmt.Printf("user %d (%s) now has role %s\n", found.ID, found.Email, found.Role)
+	case "password":
+		a := open(flags, args[1:])
+		defer a.Close()
+		if flags.NArg() != 1 {
+			log.Fatal("usage: yippi user password EMAIL < password-file")
+		}
+		service := a.users()
+		found, err := service.GetUserByEmail(ctx, flags.Arg(0))
+		if err != nil {
+			log.Fatalf("failed to find user: %v", err)
+		}
+		if err := service.SetPassword(ctx, found.ID, readPassword()); err != nil {
+			log.Fatalf("failed to set password: %v", err)
+		}
+		fmt.Printf("set the password of user %d (%s)\n", found.ID, found.Email)
+	case "delete":
+		a := open(flags, args[1:])
+		defer a.Close()
+		if flags.NArg() != 1 {
+			log.Fatal("usage: yippi user delete EMAIL")
+		}
+		service := a.users()
+		found, err := service.GetUserByEmail(ctx, flags.Arg(0))
+		if err != nil {
+			log.Fatalf("failed to find user: %v", err)
+		}
+		if err := service.DeleteUser(ctx, found.ID); err != nil {
+			log.Fatalf("failed to delete user: %v", err)
+		}
+		fmt.Printf("deleted user %d (%s)\n", found.ID, found.Email)
+	default:
+		fmt.Fprint(os.Stderr, userUsage)
+		os.Exit(2)
+	}
+}
+
+// readPassword reads a password from the first line of stdin, so that it stays out of the shell history
+func readPassword() string {
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			log.Fatalf("failed to read password: %v", err)
+		}
+		log.Fatal("no password on stdin")
+	}
+	return strings.TrimRight(scanner.Text(), "\r")
+}
+
+func printUsers(users []*entities.User) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tEMAIL\tNAME\tROLE\tTENANT\tCAN LOG IN\t2FA")
+	for _, u := range users {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%t\t%t\n", u.ID, u.Email, u.Name, u.Role, u.TenantID, u.CanLogin(), u.TOTPEnabled)
+	}
+	w.Flush()
+}
+
+// roles lists the roles with their permissions and whether they need 2FA, which is configured
+func roles(args []string) {
+	flags := flag.NewFlagSet("yippi roles", flag.ExitOnError)
+	cfg, err := config.Load(flags, args)
+	if err != nil {
+		log.Fatalf("failed to load configuration: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ROLE\tREQUIRES 2FA\tPERMISSIONS")
+	for _, role := range entities.Roles() {
+		permissions := make([]string, 0, len(role.Permissions()))
+		for _, permission := range role.Permissions() {
+			permissions = append(permissions, string(permission))
+		}
+		fmt.Fprintf(w, "%s\t%t\t%s\n", role, slices.Contains(cfg.Auth.SensitiveRoles, string(role)), strings.Join(permissions, ", "))
+	}
+	w.Flush()
+}
@@ -0,0 +1,81 @@
+package dto
+
+import "time"
+
+// LoginRequest defines the request body for the first authentication factor
+type LoginRequest struct {
+	Body struct {
+		Email    string `json:"email" minLength:"1" doc:"User email"`
+		Password string `json:"password" minLength:"1" doc:"User password"`
+	}
+}
+
+// LoginResponse defines the response of the first authentication factor
+type LoginResponse struct {
+	Body struct {
+		TwoFactorRequired bool       `json:"two_factor_required" doc:"True if a TOTP or recovery code must be submitted to /auth/login/2fa"`
+		AccessToken       string     `json:"access_token,omitempty" doc:"Bearer access token (set if no second factor is required)"`
+		ChallengeToken    string     `json:"challenge_token,omitempty" doc:"Short-lived challenge token for the 2FA step-up"`
+		ExpiresAt         *time.Time `json:"expires_at,omitempty" doc:"Expiry of the returned token"`
+	}
+}
+
+// LoginChallengeRequest defines the request body for the 2FA login step-up
+type LoginChallengeRequest struct {
+	Body struct {
+		ChallengeToken string `json:"challenge_token" minLength:"1" doc:"Challenge token returned by /auth/login"`
+		Code           string `json:"code" minLength:"1" doc:"Current TOTP code or an unused recovery code"`
+	}
+}
+
+// TokenResponse defines the response containing an access token
+type TokenResponse struct {
+	Body struct {
+		AccessToken string    `json:"access_token" doc:"Bearer access token"`
+		ExpiresAt   time.Time `json:"expires_at" doc:"Token expiry"`
+	}
+}
+
+// CurrentUserResponse defines the response describing the authenticated principal
+type CurrentUserResponse struct {
+	Body struct {
+		UserID            int      `json:"user_id" doc:"User ID"`
+		Email             string   `json:"email" doc:"User email"`
+		Role              string   `json:"role" doc:"User role"`
+		TenantID          string   `json:"tenant_id" doc:"Tenant the session is scoped to"`
+		TwoFactorVerified bool     `json:"two_factor_verified" doc:"True if the session was established with a second factor"`
+		TwoFactorRequired bool     `json:"two_factor_required" doc:"True if the role requires 2FA before its permissions take effect"`
+		Permissions       []string `json:"permissions" doc:"Effective permissions of the session"`
+	}
+}
+
+// TOTPEnrollResponse defines the response for starting TOTP enrolment
+type TOTPEnrollResponse struct {
+	Body struct {
+		Secret     string `json:"secret" doc:"Base32 TOTP secret for manual entry"`
+		OtpauthURI string `json:"otpauth_uri" doc:"otpauth:// URI; encode it as a QR code for authenticator apps"`
+	}
+}
+
+// TOTPCodeRequest defines a request carrying a TOTP or recovery code
+type TOTPCodeRequest struct {
+	Body struct {
+		Code string `json:"code" minLength:"1" doc:"Current TOTP code (or a recovery code where accepted)"`
+	}
+}
+
+// TOTPActivateResponse defines the response for confirming TOTP enrolment
+type TOTPActivateResponse struct {
+	Body struct {
+		RecoveryCodes []string  `json:"recovery_codes" doc:"One-time recovery codes; shown only once"`
+		AccessToken   string    `json:"access_token" doc:"New access token for the now 2FA-verified session"`
+		ExpiresAt     time.Time `json:"expires_at" doc:"Token expiry"`
+	}
+}
+
+// RecoveryCodesResponse defines the response containing newly generated recovery codes
+type RecoveryCodesResponse struct {
+	Body struct {
+		RecoveryCodes []string `json:"recovery_codes" doc:"One-time recovery codes; shown only once"`
+	}
+}
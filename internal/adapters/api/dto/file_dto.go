@@ -1,22 +1,42 @@
 package dto
 
 import (
+	"io"
 	"time"
 
 	"github.com/danielgtaylor/huma/v2"
+	"github.com/google/uuid"
 )
 
-// UploadFileFormData represents the multipart form data for file upload
-type UploadFileFormData struct {
-	File        huma.FormFile `form:"file" required:"true" doc:"File to upload"`
-	FileName    string        `form:"file_name" doc:"Custom filename (optional, uses uploaded filename if not provided)"`
-	Bucket      string        `form:"bucket" doc:"Target bucket name (optional, uses default if not specified)"`
-	ContentType string        `form:"content_type" doc:"Custom content type (optional, auto-detected if not provided)"`
+// UploadFileForm describes the multipart/form-data body of a file upload. The form is read in order and the
+// file streamed to storage as it arrives, so the other fields must precede the file; parts after it are ignored.
+var UploadFileForm = &huma.MediaType{
+	Schema: &huma.Schema{
+		Type:     huma.TypeObject,
+		Required: []string{"file"},
+		Properties: map[string]*huma.Schema{
+			"file_name":    {Type: huma.TypeString, Description: "Custom filename (optional, uses uploaded filename if not provided)"},
+			"bucket":       {Type: huma.TypeString, Description: "Target bucket name (optional, uses default if not specified)"},
+			"content_type": {Type: huma.TypeString, Description: "Content type (optional); detected from the content, which it must agree with"},
+			"file":         {Type: huma.TypeString, Format: "binary", Description: "File to upload, after the other fields"},
+		},
+	},
+	Encoding: map[string]*huma.Encoding{"file": {ContentType: "application/octet-stream"}},
 }
 
-// UploadFileRequest represents the request to upload a file using multipart/form-data
+// UploadFileRequest represents the request to upload a file using multipart/form-data. The form is not read by
+// Huma, which would spool the file to disk first, but part by part by the handler.
 type UploadFileRequest struct {
-	RawBody huma.MultipartFormFiles[UploadFileFormData]
+	ContentDigest string `header:"Content-Digest" doc:"Optional SHA-256 digest of the uploaded file (RFC 9530), e.g. sha-256=:<base64>:; the upload is rejected if it does not match"`
+	ContentType   string `header:"Content-Type" doc:"multipart/form-data with the boundary of the form"`
+
+	Form io.Reader `json:"-"`
+}
+
+// Resolve takes the request body as the form, so that the file is streamed to storage
+func (r *UploadFileRequest) Resolve(ctx huma.Context) []error {
+	r.Form = ctx.BodyReader()
+	return nil
 }
 
 // FileMetadataResponse represents the response containing file metadata
@@ -26,13 +46,36 @@ type FileMetadataResponse struct {
 
 // FileMetadataDTO represents file metadata in the response
 type FileMetadataDTO struct {
-	ID          string    `json:"id" doc:"Unique file identifier"`
-	FileName    string    `json:"file_name" doc:"Name of the uploaded file"`
-	Bucket      string    `json:"bucket" doc:"Bucket where the file is stored"`
-	Size        int64     `json:"size" doc:"File size in bytes"`
-	ContentType string    `json:"content_type" doc:"MIME type of the file"`
-	URL         string    `json:"url" doc:"Public URL to access the file"`
-	UploadedAt  time.Time `json:"uploaded_at" doc:"Timestamp when the file was uploaded"`
+	ID          string        `json:"id" doc:"Unique file identifier"`
+	FileName    string        `json:"file_name" doc:"Name of the uploaded file"`
+	Bucket      string        `json:"bucket" doc:"Bucket where the file is stored"`
+	Size        int64         `json:"size" doc:"File size in bytes"`
+	ContentType string        `json:"content_type" doc:"MIME type of the file"`
+	SHA256      string        `json:"sha256" doc:"Hex-encoded SHA-256 checksum of the file"`
+	UploaderID  *int          `json:"uploader_id,omitempty" doc:"ID of the user who uploaded the file"`
+	URL         string        `json:"url" doc:"Public URL to access the file"`
+	UploadedAt  time.Time     `json:"uploaded_at" doc:"Timestamp when the file was uploaded"`
+	UpdatedAt   time.Time     `json:"updated_at" doc:"Timestamp when the file was last replaced"`
+	Image       *ImageInfoDTO `json:"image,omitempty" doc:"Dimensions, placeholder and derivatives, once an image file has been processed"`
+}
+
+// ImageInfoDTO represents what is known about a processed image
+type ImageInfoDTO struct {
+	Width         int                  `json:"width" doc:"Width in pixels, after applying the EXIF orientation"`
+	Height        int                  `json:"height" doc:"Height in pixels, after applying the EXIF orientation"`
+	BlurHash      string               `json:"blurhash" doc:"BlurHash placeholder to render while the image loads"`
+	DominantColor string               `json:"dominant_color" doc:"Most common colour as #rrggbb"`
+	Derivatives   []ImageDerivativeDTO `json:"derivatives" doc:"Pre-rendered sizes of the image"`
+}
+
+// ImageDerivativeDTO represents a pre-rendered size of an image
+type ImageDerivativeDTO struct {
+	Name   string `json:"name" doc:"Derivative name, e.g. thumb"`
+	Format string `json:"format" doc:"Image format: jpeg, png or webp"`
+	Width  int    `json:"width" doc:"Width in pixels"`
+	Height int    `json:"height" doc:"Height in pixels"`
+	Size   int64  `json:"size" doc:"Size in bytes"`
+	URL    string `json:"url" doc:"Path of the transform that serves the derivative"`
 }
 
 // DeleteFileRequest represents the request to delete a file
@@ -63,6 +106,102 @@ type FileURLResponse struct {
 
 // DownloadFileRequest represents the request to download a file
 type DownloadFileRequest struct {
+	Bucket          string `query:"bucket" doc:"Bucket name (optional, uses default if not specified)"`
+	FileName        string `query:"file_name" required:"true" doc:"Name of the file to download"`
+	Disposition     string `query:"disposition" enum:"inline,attachment" doc:"inline to display the file in the browser, attachment to save it; only images, videos, audio and PDFs are displayed, and by default"`
+	Range           string `header:"Range" doc:"Single byte range to download, e.g. bytes=0-1023, bytes=1024- or bytes=-512"`
+	IfNoneMatch     string `header:"If-None-Match" doc:"ETags of cached copies; 304 Not Modified is returned if one is current"`
+	IfModifiedSince string `header:"If-Modified-Since" doc:"Modification time of a cached copy; 304 Not Modified is returned if the file has not changed since"`
+}
+
+// CreateUploadSlotRequest represents the request to reserve a direct upload to storage
+type CreateUploadSlotRequest struct {
+	Body struct {
+		FileName    string `json:"file_name" required:"true" minLength:"1" doc:"Name of the file to upload"`
+		Bucket      string `json:"bucket,omitempty" doc:"Target bucket name (optional, uses default if not specified)"`
+		ContentType string `json:"content_type" required:"true" minLength:"1" doc:"MIME type of the file; the upload must send it as Content-Type"`
+		Size        int64  `json:"size" required:"true" minimum:"1" doc:"Exact file size in bytes; the upload must send it as Content-Length"`
+		SHA256      string `json:"sha256,omitempty" doc:"Optional hex-encoded SHA-256 of the file; storage rejects content that does not match"`
+	}
+}
+
+// UploadSlotResponse represents the response containing a reserved direct upload
+type UploadSlotResponse struct {
+	Body UploadSlotDTO
+}
+
+// UploadSlotDTO represents a reserved direct upload in the response
+type UploadSlotDTO struct {
+	FileName    string              `json:"file_name" doc:"Name of the file to upload"`
+	Bucket      string              `json:"bucket" doc:"Bucket the file is uploaded to"`
+	ContentType string              `json:"content_type" doc:"MIME type of the file"`
+	Size        int64               `json:"size" doc:"File size in bytes"`
+	SHA256      string              `json:"sha256,omitempty" doc:"Expected hex-encoded SHA-256 of the file"`
+	Upload      PresignedRequestDTO `json:"upload" doc:"Request that uploads the file to storage; confirm the upload once it succeeds"`
+}
+
+// PresignedRequestDTO represents a request the client sends to storage directly
+type PresignedRequestDTO struct {
+	Method    string            `json:"method" doc:"HTTP method"`
+	URL       string            `json:"url" doc:"Presigned URL"`
+	Headers   map[string]string `json:"headers,omitempty" doc:"Headers that must be sent with exactly these values"`
+	ExpiresAt time.Time         `json:"expires_at" doc:"Time after which the URL is rejected"`
+}
+
+// ConfirmUploadRequest represents the request to confirm a completed direct upload
+type ConfirmUploadRequest struct {
+	Body struct {
+		FileName string `json:"file_name" required:"true" minLength:"1" doc:"Name of the uploaded file"`
+		Bucket   string `json:"bucket,omitempty" doc:"Bucket name (optional, uses default if not specified)"`
+	}
+}
+
+// PresignDownloadRequest represents the request for a direct download URL
+type PresignDownloadRequest struct {
 	Bucket   string `query:"bucket" doc:"Bucket name (optional, uses default if not specified)"`
 	FileName string `query:"file_name" required:"true" doc:"Name of the file to download"`
 }
+
+// PresignedRequestResponse represents the response containing a presigned request
+type PresignedRequestResponse struct {
+	Body PresignedRequestDTO
+}
+
+// ListFilesRequest represents the request to list the file catalog
+type ListFilesRequest struct {
+	Bucket         string    `query:"bucket" doc:"Only files in this bucket"`
+	ContentType    string    `query:"content_type" doc:"Only files of this content type; a wildcard such as image/* matches a type family"`
+	Name           string    `query:"name" doc:"Only files whose name contains this text (case-insensitive)"`
+	UploaderID     int       `query:"uploader_id" doc:"Only files uploaded by this user"`
+	UploadedAfter  time.Time `query:"uploaded_after" doc:"Only files uploaded at or after this time (RFC 3339)"`
+	UploadedBefore time.Time `query:"uploaded_before" doc:"Only files uploaded before this time (RFC 3339)"`
+	Cursor         string    `query:"cursor" doc:"Pagination cursor from previous response"`
+	Limit          int       `query:"limit" default:"20" minimum:"1" maximum:"100" doc:"Items per page (default: 20, max: 100)"`
+}
+
+// ListFilesResponse represents a page of the file catalog
+type ListFilesResponse struct {
+	Body struct {
+		Data     []FileMetadataDTO `json:"data" doc:"Files, newest first"`
+		PageInfo PageInfoDTO       `json:"page_info" doc:"Pagination metadata"`
+	}
+}
+
+// GetFileRequest represents the request to get a file by ID
+type GetFileRequest struct {
+	ID uuid.UUID `path:"id" doc:"File ID"`
+}
+
+// TransformFileRequest represents the request for a resized version of an image file
+type TransformFileRequest struct {
+	ID     uuid.UUID `path:"id" doc:"File ID"`
+	Width  int       `query:"w" minimum:"0" maximum:"4096" doc:"Width in pixels; 0 derives it from the height and the aspect ratio"`
+	Height int       `query:"h" minimum:"0" maximum:"4096" doc:"Height in pixels; 0 derives it from the width and the aspect ratio"`
+	Fit    string    `query:"fit" enum:"contain,cover,fill" default:"contain" doc:"contain fits the image within the box, cover crops it to fill the box, fill stretches it to the box"`
+	Format string    `query:"format" enum:"jpeg,png,webp" doc:"Output format (optional, defaults to the one closest to the original)"`
+}
+
+// DeleteFileByIDRequest represents the request to delete a file by ID
+type DeleteFileByIDRequest struct {
+	ID uuid.UUID `path:"id" doc:"File ID"`
+}
@@ -0,0 +1,30 @@
+package dto
+
+import "time"
+
+// ReadinessRequest represents the request for the readiness of the API
+type ReadinessRequest struct {
+	Verbose bool `query:"verbose" doc:"Include the status and latency of each check"`
+}
+
+// HealthResponse represents the health of the API; readiness is answered with 503 if it is down
+type HealthResponse struct {
+	Status       int
+	CacheControl string `header:"Cache-Control"`
+	Body         HealthDTO
+}
+
+// HealthDTO represents the health of the API in the response
+type HealthDTO struct {
+	Status string           `json:"status" enum:"up,down" doc:"up if the API can serve requests"`
+	Checks []HealthCheckDTO `json:"checks,omitempty" doc:"Result of each check, in verbose mode"`
+}
+
+// HealthCheckDTO represents the result of a single check in the response
+type HealthCheckDTO struct {
+	Name      string    `json:"name" doc:"Dependency checked" example:"database"`
+	Status    string    `json:"status" enum:"up,down" doc:"up if the dependency is usable"`
+	LatencyMs float64   `json:"latency_ms" doc:"Time the check took in milliseconds"`
+	Error     string    `json:"error,omitempty" doc:"Why the check failed: timed out or failed; the error itself is logged"`
+	CheckedAt time.Time `json:"checked_at" doc:"When the check ran; results are cached for a short while"`
+}
@@ -2,6 +2,8 @@ package dto
 
 import (
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // CreateProductRequest defines the request body for creating a product
@@ -40,11 +42,25 @@ type ProductResponse struct {
 		Status      string     `json:"status"`
 		CategoryID  *string    `json:"category_id,omitempty"`
 		BrandID     *string    `json:"brand_id,omitempty"`
+		Media       []ProductMediaDTO `json:"media"`
 		CreatedAt   time.Time  `json:"created_at"`
 		UpdatedAt   time.Time  `json:"updated_at"`
 	}
 }
 
+// ProductMediaDTO represents a stored file in a product's media gallery
+type ProductMediaDTO struct {
+	ID          string        `json:"id" doc:"Media ID"`
+	FileID      string        `json:"file_id" doc:"ID of the stored file"`
+	Position    int           `json:"position" doc:"Position in the gallery, starting at 0"`
+	AltText     string        `json:"alt_text" doc:"Alternative text for accessibility"`
+	IsPrimary   bool          `json:"is_primary" doc:"Whether this is the product's main media"`
+	MediaType   string        `json:"media_type" doc:"Media type: image, video or document"`
+	URL         string        `json:"url" doc:"URL to access the file"`
+	ContentType string        `json:"content_type" doc:"MIME type of the file"`
+	Image       *ImageInfoDTO `json:"image,omitempty" doc:"Dimensions, placeholder and derivatives of an image"`
+}
+
 // GetProductRequest defines the request for getting a single product
 type GetProductRequest struct {
 	ID int `path:"id" doc:"Product ID"`
@@ -76,6 +92,7 @@ type ProductListItem struct {
 	Status      string     `json:"status" doc:"Product status"`
 	CategoryID  *string    `json:"category_id,omitempty" doc:"Category ID (UUID)"`
 	BrandID     *string    `json:"brand_id,omitempty" doc:"Brand ID (UUID)"`
+	Media       []ProductMediaDTO `json:"media" doc:"Media gallery, in display order"`
 	CreatedAt   time.Time  `json:"created_at" doc:"Creation timestamp"`
 	UpdatedAt   time.Time  `json:"updated_at" doc:"Last update timestamp"`
 }
@@ -155,3 +172,50 @@ type PublishProductRequest struct {
 type ArchiveProductRequest struct {
 	ID int `path:"id" doc:"Product ID"`
 }
+
+// AttachProductMediaRequest defines the request for adding a stored file to a product's gallery
+type AttachProductMediaRequest struct {
+	ID   int `path:"id" doc:"Product ID"`
+	Body struct {
+		FileID    string `json:"file_id" minLength:"1" doc:"ID of the stored file (UUID)"`
+		AltText   string `json:"alt_text,omitempty" maxLength:"255" doc:"Alternative text for accessibility"`
+		IsPrimary bool   `json:"is_primary,omitempty" doc:"Make this the product's main media (the first media always is)"`
+		MediaType string `json:"media_type,omitempty" enum:"image,video,document" doc:"Media type (optional, derived from the file's content type)"`
+	}
+}
+
+// UpdateProductMediaRequest defines the request for updating a product media item
+type UpdateProductMediaRequest struct {
+	ID      int       `path:"id" doc:"Product ID"`
+	MediaID uuid.UUID `path:"media_id" doc:"Media ID"`
+	Body    struct {
+		AltText   string `json:"alt_text" maxLength:"255" doc:"Alternative text for accessibility"`
+		IsPrimary bool   `json:"is_primary" doc:"Make this the product's main media"`
+	}
+}
+
+// ReorderProductMediaRequest defines the request for reordering a product's gallery
+type ReorderProductMediaRequest struct {
+	ID   int `path:"id" doc:"Product ID"`
+	Body struct {
+		MediaIDs []string `json:"media_ids" doc:"Every media ID of the product, in the new order"`
+	}
+}
+
+// DetachProductMediaRequest defines the request for removing an item from a product's gallery
+type DetachProductMediaRequest struct {
+	ID      int       `path:"id" doc:"Product ID"`
+	MediaID uuid.UUID `path:"media_id" doc:"Media ID"`
+}
+
+// ProductMediaResponse defines the response for product media operations
+type ProductMediaResponse struct {
+	Body ProductMediaDTO
+}
+
+// ProductMediaListResponse defines the response containing a product's gallery
+type ProductMediaListResponse struct {
+	Body struct {
+		Media []ProductMediaDTO `json:"media" doc:"Media gallery, in display order"`
+	}
+}
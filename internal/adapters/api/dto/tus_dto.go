@@ -0,0 +1,79 @@
+package dto
+
+import (
+	"io"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/google/uuid"
+)
+
+// TusOptionsRequest represents the request for the capabilities of the tus server
+type TusOptionsRequest struct{}
+
+// TusOptionsResponse represents the capabilities of the tus server
+type TusOptionsResponse struct {
+	TusResumable string `header:"Tus-Resumable"`
+	TusVersion   string `header:"Tus-Version" doc:"Supported protocol versions"`
+	TusExtension string `header:"Tus-Extension" doc:"Supported protocol extensions"`
+	TusMaxSize   *int64 `header:"Tus-Max-Size" doc:"Largest upload in bytes, if limited"`
+}
+
+// CreateTusUploadRequest represents the request to create a resumable upload
+type CreateTusUploadRequest struct {
+	TusResumable   string `header:"Tus-Resumable" doc:"Protocol version, must be 1.0.0"`
+	UploadLength   int64  `header:"Upload-Length" doc:"Size of the file in bytes"`
+	UploadMetadata string `header:"Upload-Metadata" doc:"Comma-separated pairs of a key and a base64-encoded value: filename (required), filetype, bucket and sha256 (hex-encoded SHA-256 the file must match)"`
+}
+
+// CreateTusUploadResponse represents the response to the creation of a resumable upload
+type CreateTusUploadResponse struct {
+	TusResumable  string    `header:"Tus-Resumable"`
+	Location      string    `header:"Location" doc:"URL of the upload"`
+	UploadExpires time.Time `header:"Upload-Expires" doc:"When the upload is discarded unless more content is sent"`
+}
+
+// TusUploadRequest represents a request on a resumable upload
+type TusUploadRequest struct {
+	ID           uuid.UUID `path:"id" doc:"Upload ID"`
+	TusResumable string    `header:"Tus-Resumable" doc:"Protocol version, must be 1.0.0"`
+}
+
+// TusUploadResponse represents the state of a resumable upload
+type TusUploadResponse struct {
+	TusResumable   string    `header:"Tus-Resumable"`
+	UploadOffset   int64     `header:"Upload-Offset" doc:"Bytes received so far"`
+	UploadLength   int64     `header:"Upload-Length" doc:"Size of the file in bytes"`
+	UploadMetadata string    `header:"Upload-Metadata" doc:"Metadata sent when the upload was created"`
+	UploadExpires  time.Time `header:"Upload-Expires" doc:"When the upload is discarded unless more content is sent"`
+	CacheControl   string    `header:"Cache-Control"`
+}
+
+// PatchTusUploadRequest represents a chunk of a resumable upload. The content is streamed, not read by Huma.
+type PatchTusUploadRequest struct {
+	ID           uuid.UUID `path:"id" doc:"Upload ID"`
+	TusResumable string    `header:"Tus-Resumable" doc:"Protocol version, must be 1.0.0"`
+	ContentType  string    `header:"Content-Type" doc:"Must be application/offset+octet-stream"`
+	UploadOffset int64     `header:"Upload-Offset" required:"true" minimum:"0" doc:"Offset the chunk starts at, which must be the offset of the upload"`
+
+	Content io.Reader `json:"-"`
+}
+
+// Resolve takes the request body as the content of the chunk, so that it is streamed to storage
+func (r *PatchTusUploadRequest) Resolve(ctx huma.Context) []error {
+	r.Content = ctx.BodyReader()
+	return nil
+}
+
+// PatchTusUploadResponse represents the state of a resumable upload after a chunk
+type PatchTusUploadResponse struct {
+	TusResumable    string    `header:"Tus-Resumable"`
+	UploadOffset    int64     `header:"Upload-Offset" doc:"Bytes received so far"`
+	UploadExpires   time.Time `header:"Upload-Expires" doc:"When the upload is discarded unless more content is sent"`
+	ContentLocation string    `header:"Content-Location" doc:"URL of the stored file, once the upload is complete"`
+}
+
+// TerminateTusUploadResponse represents the response to the termination of a resumable upload
+type TerminateTusUploadResponse struct {
+	TusResumable string `header:"Tus-Resumable"`
+}
@@ -3,17 +3,25 @@ package dto
 // CreateUserRequest defines the request body for creating a user
 type CreateUserRequest struct {
 	Body struct {
-		Name  string `json:"name" minLength:"1" doc:"User name"`
-		Age int `json:"age" format:"age" doc:"User age"`
+		Name     string `json:"name" minLength:"1" doc:"User name"`
+		Age      int    `json:"age" format:"age" doc:"User age"`
+		Email    string `json:"email,omitempty" doc:"Login email"`
+		Role     string `json:"role,omitempty" enum:"admin,editor,viewer" doc:"User role (defaults to viewer)"`
+		Password string `json:"password,omitempty" doc:"Login password, at least 8 characters"`
+		TenantID string `json:"tenant_id,omitempty" doc:"Tenant the user works on; must be the tenant of the request if given"`
 	}
 }
 
 // UserResponse defines the response for user operations
 type UserResponse struct {
 	Body struct {
-		ID    int    `json:"id"`
-		Name  string `json:"name"`
-		Age int `json:"age"`
+		ID          int    `json:"id"`
+		Name        string `json:"name"`
+		Age         int    `json:"age"`
+		Email       string `json:"email,omitempty"`
+		Role        string `json:"role"`
+		TenantID    string `json:"tenant_id"`
+		TOTPEnabled bool   `json:"totp_enabled"`
 	}
 }
 
@@ -26,9 +34,13 @@ type GetUserRequest struct {
 type ListUsersResponse struct {
 	Body struct {
 		Users []struct {
-			ID    int    `json:"id"`
-			Name  string `json:"name"`
-			Age int `json:"age"`
+			ID          int    `json:"id"`
+			Name        string `json:"name"`
+			Age         int    `json:"age"`
+			Email       string `json:"email,omitempty"`
+			Role        string `json:"role"`
+			TenantID    string `json:"tenant_id"`
+			TOTPEnabled bool   `json:"totp_enabled"`
 		} `json:"users"`
 	}
 }
@@ -37,8 +49,11 @@ type ListUsersResponse struct {
 type UpdateUserRequest struct {
 	ID   int `path:"id" doc:"User ID"`
 	Body struct {
-		Name  string `json:"name" minLength:"1" doc:"User name"`
-		Age int `json:"age" format:"age" doc:"User age"`
+		Name     string `json:"name" minLength:"1" doc:"User name"`
+		Age      int    `json:"age" format:"age" doc:"User age"`
+		Email    string `json:"email,omitempty" doc:"Login email"`
+		Role     string `json:"role,omitempty" enum:"admin,editor,viewer" doc:"User role (defaults to viewer)"`
+		Password string `json:"password,omitempty" doc:"Login password, at least 8 characters"`
 	}
 }
 
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"example.com/go-yippi/internal/adapters/api/dto"
+	"example.com/go-yippi/internal/adapters/api/middleware"
+	"example.com/go-yippi/internal/adapters/api/problem"
+	"example.com/go-yippi/internal/domain/entities"
+	"example.com/go-yippi/internal/domain/ports"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// AuthHandler handles HTTP requests for authentication and two-factor enrolment
+type AuthHandler struct {
+	service ports.AuthService
+}
+
+func NewAuthHandler(service ports.AuthService) *AuthHandler {
+	return &AuthHandler{service: service}
+}
+
+// RegisterRoutes registers all auth routes with Huma
+func (h *AuthHandler) RegisterRoutes(api huma.API) {
+	// Login (first factor)
+	huma.Register(api, huma.Operation{
+		OperationID: "login",
+		Method:      http.MethodPost,
+		Path:        "/auth/login",
+		Summary:     "Log in",
+		Description: "Verifies email and password. Returns an access token, or a short-lived challenge token if the user has 2FA enabled",
+		Tags:        []string{"Auth"},
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError},
+	}, h.Login)
+
+	// Login (second factor)
+	huma.Register(api, huma.Operation{
+		OperationID: "login-2fa",
+		Method:      http.MethodPost,
+		Path:        "/auth/login/2fa",
+		Summary:     "Complete a 2FA login",
+		Description: "Exchanges a challenge token and a TOTP or recovery code for an access token",
+		Tags:        []string{"Auth"},
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError},
+	}, h.VerifyLoginChallenge)
+
+	// Current principal
+	huma.Register(api, huma.Operation{
+		OperationID: "get-current-user",
+		Method:      http.MethodGet,
+		Path:        "/auth/me",
+		Summary:     "Get the current user",
+		Description: "Returns the authenticated user, role and effective permissions",
+		Tags:        []string{"Auth"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequireAuthentication(),
+		Errors:      []int{http.StatusUnauthorized},
+	}, h.GetCurrentUser)
+
+	// Start TOTP enrolment
+	huma.Register(api, huma.Operation{
+		OperationID: "enroll-totp",
+		Method:      http.MethodPost,
+		Path:        "/auth/2fa/enroll",
+		Summary:     "Start TOTP enrolment",
+		Description: "Generates a new TOTP secret and returns it with an otpauth:// URI for QR codes",
+		Tags:        []string{"Auth"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequireAuthentication(),
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError},
+	}, h.EnrollTOTP)
+
+	// Confirm TOTP enrolment
+	huma.Register(api, huma.Operation{
+		OperationID: "activate-totp",
+		Method:      http.MethodPost,
+		Path:        "/auth/2fa/activate",
+		Summary:     "Activate TOTP",
+		Description: "Verifies a code from the authenticator app, enables 2FA and returns recovery codes",
+		Tags:        []string{"Auth"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequireAuthentication(),
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError},
+	}, h.ActivateTOTP)
+
+	// Disable TOTP
+	huma.Register(api, huma.Operation{
+		OperationID:   "disable-totp",
+		Method:        http.MethodPost,
+		Path:          "/auth/2fa/disable",
+		Summary:       "Disable TOTP",
+		Description:   "Disables 2FA after verifying a TOTP or recovery code",
+		Tags:          []string{"Auth"},
+		Security:      middleware.BearerAuth,
+		Metadata:      middleware.RequireAuthentication(),
+		DefaultStatus: http.StatusNoContent,
+		Errors:        []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError},
+	}, h.DisableTOTP)
+
+	// Regenerate recovery codes
+	huma.Register(api, huma.Operation{
+		OperationID: "regenerate-recovery-codes",
+		Method:      http.MethodPost,
+		Path:        "/auth/2fa/recovery-codes",
+		Summary:     "Regenerate recovery codes",
+		Description: "Replaces all recovery codes after verifying a TOTP code",
+		Tags:        []string{"Auth"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequireAuthentication(),
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError},
+	}, h.RegenerateRecoveryCodes)
+}
+
+func (h *AuthHandler) Login(ctx context.Context, input *dto.LoginRequest) (*dto.LoginResponse, error) {
+	result, err := h.service.Login(ctx, input.Body.Email, input.Body.Password)
+	if err != nil {
+		return nil, problem.FromError(err)
+	}
+
+	resp := &dto.LoginResponse{}
+	resp.Body.TwoFactorRequired = result.TwoFactorRequired
+	if result.AccessToken != nil {
+		resp.Body.AccessToken = result.AccessToken.Token
+		resp.Body.ExpiresAt = &result.AccessToken.ExpiresAt
+	}
+	if result.ChallengeToken != nil {
+		resp.Body.ChallengeToken = result.ChallengeToken.Token
+		resp.Body.ExpiresAt = &result.ChallengeToken.ExpiresAt
+	}
+
+	return resp, nil
+}
+
+func (h *AuthHandler) VerifyLoginChallenge(ctx context.Context, input *dto.LoginChallengeRequest) (*dto.TokenResponse, error) {
+	token, err := h.service.VerifyLoginChallenge(ctx, input.Body.ChallengeToken, input.Body.Code)
+	if err != nil {
+		return nil, problem.FromError(err)
+	}
+
+	resp := &dto.TokenResponse{}
+	resp.Body.AccessToken = token.Token
+	resp.Body.ExpiresAt = token.ExpiresAt
+	return resp, nil
+}
+
+func (h *AuthHandler) GetCurrentUser(ctx context.Context, input *struct{}) (*dto.CurrentUserResponse, error) {
+	principal, ok := entities.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, huma.Error401Unauthorized("Authentication required")
+	}
+
+	resp := &dto.CurrentUserResponse{}
+	resp.Body.UserID = principal.UserID
+	resp.Body.Email = principal.Email
+	resp.Body.Role = string(principal.Role)
+	resp.Body.TenantID = principal.TenantID
+	resp.Body.TwoFactorVerified = principal.TwoFactorVerified
+	resp.Body.TwoFactorRequired = principal.TwoFactorRequired
+	resp.Body.Permissions = make([]string, len(principal.Permissions))
+	for i, permission := range principal.Permissions {
+		resp.Body.Permissions[i] = string(permission)
+	}
+
+	return resp, nil
+}
+
+func (h *AuthHandler) EnrollTOTP(ctx context.Context, input *struct{}) (*dto.TOTPEnrollResponse, error) {
+	principal, ok := entities.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, huma.Error401Unauthorized("Authentication required")
+	}
+
+	enrollment, err := h.service.EnrollTOTP(ctx, principal.UserID)
+	if err != nil {
+		return nil, problem.FromError(err)
+	}
+
+	resp := &dto.TOTPEnrollResponse{}
+	resp.Body.Secret = enrollment.Secret
+	resp.Body.OtpauthURI = enrollment.URI
+	return resp, nil
+}
+
+func (h *AuthHandler) ActivateTOTP(ctx context.Context, input *dto.TOTPCodeRequest) (*dto.TOTPActivateResponse, error) {
+	principal, ok := entities.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, huma.Error401Unauthorized("Authentication required")
+	}
+
+	activation, err := h.service.ActivateTOTP(ctx, principal.UserID, input.Body.Code)
+	if err != nil {
+		return nil, problem.FromError(err)
+	}
+
+	resp := &dto.TOTPActivateResponse{}
+	resp.Body.RecoveryCodes = activation.RecoveryCodes
+	resp.Body.AccessToken = activation.AccessToken.Token
+	resp.Body.ExpiresAt = activation.AccessToken.ExpiresAt
+	return resp, nil
+}
+
+func (h *AuthHandler) DisableTOTP(ctx context.Context, input *dto.TOTPCodeRequest) (*struct{}, error) {
+	principal, ok := entities.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, huma.Error401Unauthorized("Authentication required")
+	}
+
+	if err := h.service.DisableTOTP(ctx, principal.UserID, input.Body.Code); err != nil {
+		return nil, problem.FromError(err)
+	}
+
+	return &struct{}{}, nil
+}
+
+func (h *AuthHandler) RegenerateRecoveryCodes(ctx context.Context, input *dto.TOTPCodeRequest) (*dto.RecoveryCodesResponse, error) {
+	principal, ok := entities.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, huma.Error401Unauthorized("Authentication required")
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(ctx, principal.UserID, input.Body.Code)
+	if err != nil {
+		return nil, problem.FromError(err)
+	}
+
+	resp := &dto.RecoveryCodesResponse{}
+	resp.Body.RecoveryCodes = codes
+	return resp, nil
+}
@@ -2,12 +2,12 @@ package handlers
 
 import (
 	"context"
-	"errors"
 	"net/http"
 
 	"example.com/go-yippi/internal/adapters/api/dto"
+	"example.com/go-yippi/internal/adapters/api/middleware"
+	"example.com/go-yippi/internal/adapters/api/problem"
 	"example.com/go-yippi/internal/domain/entities"
-	domainErrors "example.com/go-yippi/internal/domain/errors"
 	"example.com/go-yippi/internal/domain/ports"
 	"github.com/danielgtaylor/huma/v2"
 )
@@ -31,6 +31,8 @@ func (h *BrandHandler) RegisterRoutes(api huma.API) {
 		Summary:     "Create a new brand",
 		Description: "Creates a new brand with a unique name",
 		Tags:        []string{"Brands"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequirePermission(entities.PermissionCatalogWrite),
 		Errors:      []int{http.StatusBadRequest, http.StatusConflict, http.StatusInternalServerError},
 	}, h.CreateBrand)
 
@@ -75,6 +77,8 @@ func (h *BrandHandler) RegisterRoutes(api huma.API) {
 		Summary:     "Update a brand",
 		Description: "Updates an existing brand's information",
 		Tags:        []string{"Brands"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequirePermission(entities.PermissionCatalogWrite),
 		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
 	}, h.UpdateBrand)
 
@@ -86,6 +90,8 @@ func (h *BrandHandler) RegisterRoutes(api huma.API) {
 		Summary:     "Delete a brand",
 		Description: "Deletes a brand by its unique identifier",
 		Tags:        []string{"Brands"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequirePermission(entities.PermissionCatalogDelete),
 		Errors:      []int{http.StatusNotFound, http.StatusInternalServerError},
 	}, h.DeleteBrand)
 }
@@ -98,13 +104,7 @@ func (h *BrandHandler) CreateBrand(ctx context.Context, input *dto.CreateBrandRe
 
 	err := h.service.CreateBrand(ctx, brand)
 	if err != nil {
-		if errors.Is(err, domainErrors.ErrInvalidInput) {
-			return nil, huma.Error400BadRequest("Invalid input", err)
-		}
-		if errors.Is(err, domainErrors.ErrDuplicateEntry) {
-			return nil, huma.Error409Conflict("Brand with this name already exists")
-		}
-		return nil, huma.Error500InternalServerError("Failed to create brand", err)
+		return nil, problem.FromError(err)
 	}
 
 	return h.mapToResponse(brand), nil
@@ -114,7 +114,7 @@ func (h *BrandHandler) CreateBrand(ctx context.Context, input *dto.CreateBrandRe
 func (h *BrandHandler) ListBrands(ctx context.Context, _ *struct{}) (*dto.ListBrandsResponse, error) {
 	brands, err := h.service.ListBrands(ctx)
 	if err != nil {
-		return nil, huma.Error500InternalServerError("Failed to list brands", err)
+		return nil, problem.FromError(err)
 	}
 
 	response := &dto.ListBrandsResponse{}
@@ -136,10 +136,7 @@ func (h *BrandHandler) ListBrands(ctx context.Context, _ *struct{}) (*dto.ListBr
 func (h *BrandHandler) GetBrand(ctx context.Context, input *dto.GetBrandRequest) (*dto.BrandResponse, error) {
 	brand, err := h.service.GetBrand(ctx, input.ID)
 	if err != nil {
-		if errors.Is(err, domainErrors.ErrNotFound) {
-			return nil, huma.Error404NotFound("Brand not found")
-		}
-		return nil, huma.Error500InternalServerError("Failed to get brand", err)
+		return nil, problem.FromError(err)
 	}
 
 	return h.mapToResponse(brand), nil
@@ -149,13 +146,7 @@ func (h *BrandHandler) GetBrand(ctx context.Context, input *dto.GetBrandRequest)
 func (h *BrandHandler) GetBrandByName(ctx context.Context, input *dto.GetBrandByNameRequest) (*dto.BrandResponse, error) {
 	brand, err := h.service.GetBrandByName(ctx, input.Name)
 	if err != nil {
-		if errors.Is(err, domainErrors.ErrInvalidInput) {
-			return nil, huma.Error400BadRequest("Invalid input", err)
-		}
-		if errors.Is(err, domainErrors.ErrNotFound) {
-			return nil, huma.Error404NotFound("Brand not found")
-		}
-		return nil, huma.Error500InternalServerError("Failed to get brand", err)
+		return nil, problem.FromError(err)
 	}
 
 	return h.mapToResponse(brand), nil
@@ -170,16 +161,7 @@ func (h *BrandHandler) UpdateBrand(ctx context.Context, input *dto.UpdateBrandRe
 
 	err := h.service.UpdateBrand(ctx, brand)
 	if err != nil {
-		if errors.Is(err, domainErrors.ErrInvalidInput) {
-			return nil, huma.Error400BadRequest("Invalid input", err)
-		}
-		if errors.Is(err, domainErrors.ErrNotFound) {
-			return nil, huma.Error404NotFound("Brand not found")
-		}
-		if errors.Is(err, domainErrors.ErrDuplicateEntry) {
-			return nil, huma.Error409Conflict("Brand with this name already exists")
-		}
-		return nil, huma.Error500InternalServerError("Failed to update brand", err)
+		return nil, problem.FromError(err)
 	}
 
 	return h.mapToResponse(brand), nil
@@ -189,10 +171,7 @@ func (h *BrandHandler) UpdateBrand(ctx context.Context, input *dto.UpdateBrandRe
 func (h *BrandHandler) DeleteBrand(ctx context.Context, input *dto.DeleteBrandRequest) (*struct{}, error) {
 	err := h.service.DeleteBrand(ctx, input.ID)
 	if err != nil {
-		if errors.Is(err, domainErrors.ErrNotFound) {
-			return nil, huma.Error404NotFound("Brand not found")
-		}
-		return nil, huma.Error500InternalServerError("Failed to delete brand", err)
+		return nil, problem.FromError(err)
 	}
 
 	return &struct{}{}, nil
@@ -101,7 +101,7 @@ func TestCreateBrand_ValidationError(t *testing.T) {
 	input := &dto.CreateBrandRequest{}
 	input.Body.Name = "" // Empty name
 
-	validationErr := domainErrors.NewValidationError("name", "Name is required")
+	validationErr := domainErrors.NewValidationError("name", "required", "Name is required")
 	mockService.On("CreateBrand", ctx, mock.Anything).Return(validationErr)
 
 	// Act
@@ -339,7 +339,7 @@ func TestUpdateBrand_ValidationError(t *testing.T) {
 	input := &dto.UpdateBrandRequest{ID: brandID}
 	input.Body.Name = "" // Empty name
 
-	validationErr := domainErrors.NewValidationError("name", "Name is required")
+	validationErr := domainErrors.NewValidationError("name", "required", "Name is required")
 	mockService.On("UpdateBrand", ctx, mock.Anything).Return(validationErr)
 
 	// Act
@@ -2,10 +2,11 @@ package handlers
 
 import (
 	"context"
-	"errors"
 	"net/http"
 
 	"example.com/go-yippi/internal/adapters/api/dto"
+	"example.com/go-yippi/internal/adapters/api/middleware"
+	"example.com/go-yippi/internal/adapters/api/problem"
 	"example.com/go-yippi/internal/domain/entities"
 	domainErrors "example.com/go-yippi/internal/domain/errors"
 	"example.com/go-yippi/internal/domain/ports"
@@ -32,6 +33,8 @@ func (h *CategoryHandler) RegisterRoutes(api huma.API) {
 		Summary:     "Create a new category",
 		Description: "Creates a new category with a unique name and optional parent",
 		Tags:        []string{"Categories"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequirePermission(entities.PermissionCatalogWrite),
 		Errors:      []int{http.StatusBadRequest, http.StatusConflict, http.StatusInternalServerError},
 	}, h.CreateCategory)
 
@@ -87,6 +90,8 @@ func (h *CategoryHandler) RegisterRoutes(api huma.API) {
 		Summary:     "Update a category",
 		Description: "Updates an existing category's information",
 		Tags:        []string{"Categories"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequirePermission(entities.PermissionCatalogWrite),
 		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
 	}, h.UpdateCategory)
 
@@ -98,6 +103,8 @@ func (h *CategoryHandler) RegisterRoutes(api huma.API) {
 		Summary:       "Delete a category",
 		Description:   "Permanently deletes a category from the system (only if it has no children)",
 		Tags:          []string{"Categories"},
+		Security:      middleware.BearerAuth,
+		Metadata:      middleware.RequirePermission(entities.PermissionCatalogDelete),
 		DefaultStatus: http.StatusNoContent,
 		Errors:        []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
 	}, h.DeleteCategory)
@@ -112,20 +119,14 @@ func (h *CategoryHandler) CreateCategory(ctx context.Context, input *dto.CreateC
 	if input.Body.ParentID != nil && *input.Body.ParentID != "" {
 		parentUUID, err := uuid.Parse(*input.Body.ParentID)
 		if err != nil {
-			return nil, huma.Error400BadRequest("Invalid parent_id UUID format", err)
+			return nil, problem.FromError(domainErrors.NewValidationError("parent_id", "invalid_uuid", "Invalid parent_id UUID format"))
 		}
 		category.ParentID = &parentUUID
 	}
 
 	err := h.service.CreateCategory(ctx, category)
 	if err != nil {
-		if errors.Is(err, domainErrors.ErrInvalidInput) {
-			return nil, huma.Error400BadRequest("Invalid input", err)
-		}
-		if errors.Is(err, domainErrors.ErrDuplicateEntry) {
-			return nil, huma.Error409Conflict("Category with this name already exists")
-		}
-		return nil, huma.Error500InternalServerError("Failed to create category", err)
+		return nil, problem.FromError(err)
 	}
 
 	return h.mapToResponse(category), nil
@@ -134,15 +135,12 @@ func (h *CategoryHandler) CreateCategory(ctx context.Context, input *dto.CreateC
 func (h *CategoryHandler) GetCategory(ctx context.Context, input *dto.GetCategoryRequest) (*dto.CategoryResponse, error) {
 	categoryID, err := uuid.Parse(input.ID)
 	if err != nil {
-		return nil, huma.Error400BadRequest("Invalid category ID UUID format", err)
+		return nil, problem.FromError(domainErrors.NewValidationError("id", "invalid_uuid", "Invalid category ID UUID format"))
 	}
 
 	category, err := h.service.GetCategory(ctx, categoryID)
 	if err != nil {
-		if errors.Is(err, domainErrors.ErrNotFound) {
-			return nil, huma.Error404NotFound("Category not found")
-		}
-		return nil, huma.Error500InternalServerError("Failed to retrieve category", err)
+		return nil, problem.FromError(err)
 	}
 
 	return h.mapToResponse(category), nil
@@ -151,13 +149,7 @@ func (h *CategoryHandler) GetCategory(ctx context.Context, input *dto.GetCategor
 func (h *CategoryHandler) GetCategoryByName(ctx context.Context, input *dto.GetCategoryByNameRequest) (*dto.CategoryResponse, error) {
 	category, err := h.service.GetCategoryByName(ctx, input.Name)
 	if err != nil {
-		if errors.Is(err, domainErrors.ErrNotFound) {
-			return nil, huma.Error404NotFound("Category not found")
-		}
-		if errors.Is(err, domainErrors.ErrInvalidInput) {
-			return nil, huma.Error400BadRequest("Invalid input", err)
-		}
-		return nil, huma.Error500InternalServerError("Failed to retrieve category", err)
+		return nil, problem.FromError(err)
 	}
 
 	return h.mapToResponse(category), nil
@@ -166,7 +158,7 @@ func (h *CategoryHandler) GetCategoryByName(ctx context.Context, input *dto.GetC
 func (h *CategoryHandler) ListCategories(ctx context.Context, input *struct{}) (*dto.ListCategoriesResponse, error) {
 	categories, err := h.service.ListCategories(ctx)
 	if err != nil {
-		return nil, huma.Error500InternalServerError("Failed to list categories", err)
+		return nil, problem.FromError(err)
 	}
 
 	response := &dto.ListCategoriesResponse{}
@@ -184,17 +176,14 @@ func (h *CategoryHandler) ListCategoriesByParent(ctx context.Context, input *dto
 	if input.ParentID != "" {
 		parsedID, err := uuid.Parse(input.ParentID)
 		if err != nil {
-			return nil, huma.Error400BadRequest("Invalid parent_id UUID format", err)
+			return nil, problem.FromError(domainErrors.NewValidationError("parent_id", "invalid_uuid", "Invalid parent_id UUID format"))
 		}
 		parentID = &parsedID
 	}
 
 	categories, err := h.service.ListCategoriesByParentID(ctx, parentID)
 	if err != nil {
-		if errors.Is(err, domainErrors.ErrInvalidInput) {
-			return nil, huma.Error400BadRequest("Invalid input", err)
-		}
-		return nil, huma.Error500InternalServerError("Failed to list categories", err)
+		return nil, problem.FromError(err)
 	}
 
 	response := &dto.ListCategoriesResponse{}
@@ -209,7 +198,7 @@ func (h *CategoryHandler) ListCategoriesByParent(ctx context.Context, input *dto
 func (h *CategoryHandler) UpdateCategory(ctx context.Context, input *dto.UpdateCategoryRequest) (*dto.CategoryResponse, error) {
 	categoryID, err := uuid.Parse(input.ID)
 	if err != nil {
-		return nil, huma.Error400BadRequest("Invalid category ID UUID format", err)
+		return nil, problem.FromError(domainErrors.NewValidationError("id", "invalid_uuid", "Invalid category ID UUID format"))
 	}
 
 	category := &entities.Category{
@@ -221,23 +210,14 @@ func (h *CategoryHandler) UpdateCategory(ctx context.Context, input *dto.UpdateC
 	if input.Body.ParentID != nil && *input.Body.ParentID != "" {
 		parentUUID, err := uuid.Parse(*input.Body.ParentID)
 		if err != nil {
-			return nil, huma.Error400BadRequest("Invalid parent_id UUID format", err)
+			return nil, problem.FromError(domainErrors.NewValidationError("parent_id", "invalid_uuid", "Invalid parent_id UUID format"))
 		}
 		category.ParentID = &parentUUID
 	}
 
 	err = h.service.UpdateCategory(ctx, category)
 	if err != nil {
-		if errors.Is(err, domainErrors.ErrInvalidInput) {
-			return nil, huma.Error400BadRequest("Invalid input", err)
-		}
-		if errors.Is(err, domainErrors.ErrNotFound) {
-			return nil, huma.Error404NotFound("Category not found")
-		}
-		if errors.Is(err, domainErrors.ErrDuplicateEntry) {
-			return nil, huma.Error409Conflict("Category with this name already exists")
-		}
-		return nil, huma.Error500InternalServerError("Failed to update category", err)
+		return nil, problem.FromError(err)
 	}
 
 	return h.mapToResponse(category), nil
@@ -246,18 +226,12 @@ func (h *CategoryHandler) UpdateCategory(ctx context.Context, input *dto.UpdateC
 func (h *CategoryHandler) DeleteCategory(ctx context.Context, input *dto.DeleteCategoryRequest) (*struct{}, error) {
 	categoryID, err := uuid.Parse(input.ID)
 	if err != nil {
-		return nil, huma.Error400BadRequest("Invalid category ID UUID format", err)
+		return nil, problem.FromError(domainErrors.NewValidationError("id", "invalid_uuid", "Invalid category ID UUID format"))
 	}
 
 	err = h.service.DeleteCategory(ctx, categoryID)
 	if err != nil {
-		if errors.Is(err, domainErrors.ErrInvalidInput) {
-			return nil, huma.Error400BadRequest("Invalid input", err)
-		}
-		if errors.Is(err, domainErrors.ErrNotFound) {
-			return nil, huma.Error404NotFound("Category not found")
-		}
-		return nil, huma.Error500InternalServerError("Failed to delete category", err)
+		return nil, problem.FromError(err)
 	}
 
 	return nil, nil
@@ -148,7 +148,7 @@ func TestCreateCategory_ValidationError(t *testing.T) {
 	input := &dto.CreateCategoryRequest{}
 	input.Body.Name = ""
 
-	validationErr := domainErrors.NewValidationError("name", "Name is required")
+	validationErr := domainErrors.NewValidationError("name", "required", "Name is required")
 	mockService.On("CreateCategory", ctx, mock.Anything).Return(validationErr)
 
 	// Act
@@ -327,7 +327,7 @@ func TestDeleteCategory_WithChildren(t *testing.T) {
 
 	testID := uuid.New()
 	input := &dto.DeleteCategoryRequest{ID: testID.String()}
-	validationErr := domainErrors.NewValidationError("category", "Cannot delete category with children")
+	validationErr := domainErrors.NewValidationError("category", "has_children", "Cannot delete category with children")
 	mockService.On("DeleteCategory", ctx, testID).Return(validationErr)
 
 	// Act
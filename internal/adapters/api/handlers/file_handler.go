@@ -1,28 +1,55 @@
 package handlers
 
 import (
-	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
 
 	"example.com/go-yippi/internal/adapters/api/dto"
+	"example.com/go-yippi/internal/adapters/api/middleware"
+	"example.com/go-yippi/internal/adapters/api/problem"
 	"example.com/go-yippi/internal/domain/entities"
 	domainErrors "example.com/go-yippi/internal/domain/errors"
 	"example.com/go-yippi/internal/domain/ports"
 	"github.com/danielgtaylor/huma/v2"
+	"github.com/google/uuid"
 )
 
+// CacheControl holds the Cache-Control header of downloads, by bucket
+type CacheControl struct {
+	Default  string
+	ByBucket map[string]string
+}
+
+// For returns the Cache-Control header of downloads from the bucket
+func (c CacheControl) For(bucket string) string {
+	if value, ok := c.ByBucket[bucket]; ok {
+		return value
+	}
+	return c.Default
+}
+
 // FileHandler handles HTTP requests for file storage operations
 type FileHandler struct {
-	service ports.StorageService
+	service      ports.StorageService
+	images       ports.ImageService
+	cacheControl CacheControl
 }
 
 // NewFileHandler creates a new file handler
-func NewFileHandler(service ports.StorageService) *FileHandler {
-	return &FileHandler{service: service}
+func NewFileHandler(service ports.StorageService, images ports.ImageService, cacheControl CacheControl) *FileHandler {
+	return &FileHandler{service: service, images: images, cacheControl: cacheControl}
 }
 
 // RegisterRoutes registers all file storage routes with Huma
@@ -35,7 +62,13 @@ func (h *FileHandler) RegisterRoutes(api huma.API) {
 		Summary:     "Upload a file to storage",
 		Description: "Uploads a file to MinIO storage with custom filename and bucket selection",
 		Tags:        []string{"Files"},
-		Errors:      []int{http.StatusBadRequest, http.StatusInternalServerError},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequirePermission(entities.PermissionFilesWrite),
+		RequestBody: &huma.RequestBody{
+			Required: true,
+			Content:  map[string]*huma.MediaType{"multipart/form-data": dto.UploadFileForm},
+		},
+		Errors: []int{http.StatusBadRequest, http.StatusUnsupportedMediaType, http.StatusInternalServerError},
 	}, h.UploadFile)
 
 	// Delete file
@@ -46,6 +79,8 @@ func (h *FileHandler) RegisterRoutes(api huma.API) {
 		Summary:     "Delete a file from storage",
 		Description: "Deletes a file from MinIO storage by filename and bucket",
 		Tags:        []string{"Files"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequirePermission(entities.PermissionFilesDelete),
 		Errors:      []int{http.StatusBadRequest, http.StatusInternalServerError},
 	}, h.DeleteFile)
 
@@ -66,79 +101,165 @@ func (h *FileHandler) RegisterRoutes(api huma.API) {
 		Method:      http.MethodGet,
 		Path:        "/files/download",
 		Summary:     "Download a file",
-		Description: "Downloads a file from storage and streams it to the client",
+		Description: "Downloads a file from storage and streams it to the client. Supports a single byte range (206 Partial Content) and conditional requests with the returned ETag and Last-Modified (304 Not Modified).",
 		Tags:        []string{"Files"},
-		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusRequestedRangeNotSatisfiable, http.StatusInternalServerError},
 	}, h.DownloadFile)
+
+	// Reserve a direct upload
+	huma.Register(api, huma.Operation{
+		OperationID: "create-upload-slot",
+		Method:      http.MethodPost,
+		Path:        "/files/uploads",
+		Summary:     "Create a direct upload",
+		Description: "Returns a presigned request that uploads a file directly to storage. The content type, size and checksum declared here are enforced by storage. Confirm the upload once it completes.",
+		Tags:        []string{"Files"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequirePermission(entities.PermissionFilesWrite),
+		Errors:      []int{http.StatusBadRequest, http.StatusInternalServerError},
+	}, h.CreateUploadSlot)
+
+	// Confirm a direct upload
+	huma.Register(api, huma.Operation{
+		OperationID: "confirm-upload",
+		Method:      http.MethodPost,
+		Path:        "/files/uploads/confirm",
+		Summary:     "Confirm a direct upload",
+		Description: "Verifies that a direct upload completed and returns the metadata of the stored file",
+		Tags:        []string{"Files"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequirePermission(entities.PermissionFilesWrite),
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	}, h.ConfirmUpload)
+
+	// Get a direct download URL
+	huma.Register(api, huma.Operation{
+		OperationID: "presign-download",
+		Method:      http.MethodGet,
+		Path:        "/files/download-url",
+		Summary:     "Get a direct download URL",
+		Description: "Returns a presigned URL that downloads a file directly from storage",
+		Tags:        []string{"Files"},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	}, h.PresignDownload)
+
+	// List files
+	huma.Register(api, huma.Operation{
+		OperationID: "list-files",
+		Method:      http.MethodGet,
+		Path:        "/files",
+		Summary:     "List files",
+		Description: "Lists the stored files, newest first, with cursor-based pagination and filters on bucket, content type, name, uploader and upload time",
+		Tags:        []string{"Files"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequireAuthentication(),
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError},
+	}, h.ListFiles)
+
+	// Get file by ID; registered after the fixed /files/... paths so they take precedence
+	huma.Register(api, huma.Operation{
+		OperationID: "get-file",
+		Method:      http.MethodGet,
+		Path:        "/files/{id}",
+		Summary:     "Get file metadata",
+		Description: "Retrieves the metadata of a stored file by ID",
+		Tags:        []string{"Files"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequireAuthentication(),
+		Errors:      []int{http.StatusUnauthorized, http.StatusNotFound, http.StatusInternalServerError},
+	}, h.GetFile)
+
+	// Transform an image file
+	huma.Register(api, huma.Operation{
+		OperationID: "transform-file",
+		Method:      http.MethodGet,
+		Path:        "/files/{id}/transform",
+		Summary:     "Get a resized image",
+		Description: "Returns an image file resized to the requested box and re-encoded without metadata. Results are cached, and the derivatives listed with the file are served from the cache.",
+		Tags:        []string{"Files"},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	}, h.TransformFile)
+
+	// Delete file by ID
+	huma.Register(api, huma.Operation{
+		OperationID: "delete-file-by-id",
+		Method:      http.MethodDelete,
+		Path:        "/files/{id}",
+		Summary:     "Delete a file by ID",
+		Description: "Deletes a stored file and its record",
+		Tags:        []string{"Files"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequirePermission(entities.PermissionFilesDelete),
+		Errors:      []int{http.StatusNotFound, http.StatusInternalServerError},
+	}, h.DeleteFileByID)
 }
 
-// UploadFile handles file upload requests using multipart/form-data
-func (h *FileHandler) UploadFile(ctx context.Context, input *dto.UploadFileRequest) (*dto.FileMetadataResponse, error) {
-	// Get form data from multipart request
-	formData := input.RawBody.Data()
+// maxFormFieldSize is the longest value of a form field other than the file
+const maxFormFieldSize = 4096
 
-	// Check if file was provided
-	if !formData.File.IsSet {
-		return nil, huma.Error400BadRequest("file is required")
+// UploadFile handles file upload requests using multipart/form-data. The fields are read up to the file part,
+// which is streamed to storage as it arrives; nothing is spooled to memory or disk first.
+func (h *FileHandler) UploadFile(ctx context.Context, input *dto.UploadFileRequest) (*dto.FileMetadataResponse, error) {
+	mediaType, params, _ := mime.ParseMediaType(input.ContentType)
+	if mediaType != "multipart/form-data" || params["boundary"] == "" {
+		return nil, problem.New(http.StatusUnsupportedMediaType, "Content-Type must be multipart/form-data with a boundary")
 	}
 
-	// Read file content
-	fileData, err := io.ReadAll(formData.File.File)
+	checksum, err := parseContentDigest(input.ContentDigest)
 	if err != nil {
-		return nil, huma.Error400BadRequest("failed to read file content: " + err.Error())
-	}
-
-	// Determine filename: use custom file_name if provided, otherwise use uploaded filename
-	fileName := formData.FileName
-	if fileName == "" {
-		fileName = formData.File.Filename
+		return nil, problem.FromError(err)
 	}
 
-	if fileName == "" {
-		return nil, huma.Error400BadRequest("file_name is required (either as form field or from uploaded file)")
-	}
+	upload := &entities.FileUpload{Size: -1, SHA256: checksum}
+	form := multipart.NewReader(input.Form, params["boundary"])
+	for upload.Content == nil {
+		part, err := form.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, problem.FromError(domainErrors.NewValidationError("file", "required", "file is required"))
+		}
+		if err != nil {
+			return nil, problem.FromError(domainErrors.NewValidationError("file", "invalid_form", "request body is not a valid multipart form"))
+		}
 
-	// Determine content type: use custom content_type if provided, otherwise auto-detect
-	contentType := formData.ContentType
-	if contentType == "" {
-		contentType = http.DetectContentType(fileData)
+		switch name := part.FormName(); name {
+		case "file":
+			// Use the custom file_name if provided, otherwise the uploaded filename
+			if upload.FileName == "" {
+				upload.FileName = part.FileName()
+			}
+			upload.Content = part
+		case "file_name", "bucket", "content_type":
+			value, err := io.ReadAll(io.LimitReader(part, maxFormFieldSize+1))
+			if err != nil {
+				return nil, problem.FromError(domainErrors.NewValidationError(name, "invalid_form", "request body is not a valid multipart form"))
+			}
+			if len(value) > maxFormFieldSize {
+				return nil, problem.FromError(domainErrors.NewValidationError(name, "too_long", fmt.Sprintf("%s must not exceed %d bytes", name, maxFormFieldSize)))
+			}
+			switch name {
+			case "file_name":
+				upload.FileName = string(value)
+			case "bucket":
+				upload.Bucket = string(value)
+			default:
+				upload.ContentType = string(value)
+			}
+		}
 	}
 
-	// Default content type if still not detected
-	if contentType == "" {
-		contentType = "application/octet-stream"
+	if upload.FileName == "" {
+		return nil, problem.FromError(domainErrors.NewValidationError("file_name", "required", "file_name is required (either as form field or from uploaded file)"))
 	}
 
-	// Use provided bucket or empty string for default
-	bucket := formData.Bucket
-
-	// Create reader from file bytes
-	fileReader := bytes.NewReader(fileData)
-	size := int64(len(fileData))
-
-	// Upload file
-	metadata, err := h.service.UploadFile(ctx, bucket, fileName, fileReader, size, contentType)
+	// Stream the file to storage; the service rejects empty and oversized files while reading, detects the
+	// content type and checks the declared one against it
+	metadata, err := h.service.UploadFile(ctx, upload)
 	if err != nil {
-		// Handle domain errors
-		if errors.Is(err, domainErrors.ErrInvalidInput) {
-			return nil, huma.Error400BadRequest(err.Error())
-		}
-
-		return nil, huma.Error500InternalServerError("failed to upload file", err)
+		return nil, problem.FromError(err)
 	}
 
 	// Map to DTO
-	response := &dto.FileMetadataResponse{
-		Body: dto.FileMetadataDTO{
-			ID:          metadata.ID,
-			FileName:    metadata.FileName,
-			Bucket:      metadata.Bucket,
-			Size:        metadata.Size,
-			ContentType: metadata.ContentType,
-			URL:         metadata.URL,
-			UploadedAt:  metadata.UploadedAt,
-		},
-	}
+	response := &dto.FileMetadataResponse{Body: mapToFileMetadataDTO(metadata)}
 
 	return response, nil
 }
@@ -147,13 +268,13 @@ func (h *FileHandler) UploadFile(ctx context.Context, input *dto.UploadFileReque
 func (h *FileHandler) DeleteFile(ctx context.Context, input *dto.DeleteFileRequest) (*dto.DeleteFileResponse, error) {
 	// Validate filename
 	if input.FileName == "" {
-		return nil, huma.Error400BadRequest("file_name is required")
+		return nil, problem.FromError(domainErrors.NewValidationError("file_name", "required", "file_name is required"))
 	}
 
 	// Delete file
 	err := h.service.DeleteFile(ctx, input.Bucket, input.FileName)
 	if err != nil {
-		return nil, huma.Error500InternalServerError("failed to delete file", err)
+		return nil, problem.FromError(err)
 	}
 
 	response := &dto.DeleteFileResponse{}
@@ -166,13 +287,13 @@ func (h *FileHandler) DeleteFile(ctx context.Context, input *dto.DeleteFileReque
 func (h *FileHandler) GetFileURL(ctx context.Context, input *dto.GetFileURLRequest) (*dto.FileURLResponse, error) {
 	// Validate filename
 	if input.FileName == "" {
-		return nil, huma.Error400BadRequest("file_name is required")
+		return nil, problem.FromError(domainErrors.NewValidationError("file_name", "required", "file_name is required"))
 	}
 
 	// Get file URL
 	url, err := h.service.GetFileURL(ctx, input.Bucket, input.FileName)
 	if err != nil {
-		return nil, huma.Error500InternalServerError("failed to get file URL", err)
+		return nil, problem.FromError(err)
 	}
 
 	response := &dto.FileURLResponse{}
@@ -181,49 +302,385 @@ func (h *FileHandler) GetFileURL(ctx context.Context, input *dto.GetFileURLReque
 	return response, nil
 }
 
-// DownloadFile handles file download requests and streams the file to the client
+// DownloadFile handles file download requests and streams the file, or the requested range of it, to the client
 func (h *FileHandler) DownloadFile(ctx context.Context, input *dto.DownloadFileRequest) (*huma.StreamResponse, error) {
 	// Validate filename
 	if input.FileName == "" {
-		return nil, huma.Error400BadRequest("file_name is required")
+		return nil, problem.FromError(domainErrors.NewValidationError("file_name", "required", "file_name is required"))
+	}
+
+	opts := entities.DownloadOptions{
+		IfNoneMatch: parseETags(input.IfNoneMatch),
+		Range:       parseRange(input.Range),
+	}
+	// Invalid dates are ignored
+	if modifiedSince, err := http.ParseTime(input.IfModifiedSince); err == nil {
+		opts.IfModifiedSince = modifiedSince
 	}
 
 	// Download file
-	reader, size, contentType, err := h.service.DownloadFile(ctx, input.Bucket, input.FileName)
+	download, err := h.service.DownloadFile(ctx, input.Bucket, input.FileName, opts)
 	if err != nil {
-		// Handle domain errors
-		if errors.Is(err, domainErrors.ErrInvalidInput) {
-			return nil, huma.Error400BadRequest(err.Error())
+		var rangeErr *domainErrors.RangeNotSatisfiableError
+		if errors.As(err, &rangeErr) {
+			return nil, huma.ErrorWithHeaders(problem.FromError(err), http.Header{
+				"Content-Range": {fmt.Sprintf("bytes */%d", rangeErr.Size)},
+			})
 		}
-
-		return nil, huma.Error500InternalServerError("failed to download file", err)
+		return nil, problem.FromError(err)
 	}
 
 	// Return stream response
+	return &huma.StreamResponse{
+		Body: func(ctx huma.Context) {
+			// Validators and caching headers are sent with 304 responses too
+			ctx.SetHeader("ETag", download.ETag)
+			ctx.SetHeader("Last-Modified", download.LastModified.UTC().Format(http.TimeFormat))
+			if cacheControl := h.cacheControl.For(download.File.Bucket); cacheControl != "" {
+				ctx.SetHeader("Cache-Control", cacheControl)
+			}
+			if download.NotModified {
+				ctx.SetStatus(http.StatusNotModified)
+				return
+			}
+			defer download.Content.Close()
+
+			// Set content type and content length headers; browsers must not second-guess the checked type
+			ctx.SetHeader("Content-Type", download.File.ContentType)
+			ctx.SetHeader("X-Content-Type-Options", "nosniff")
+			ctx.SetHeader("Content-Length", fmt.Sprintf("%d", download.Size))
+			ctx.SetHeader("Content-Disposition", contentDisposition(input.Disposition, input.FileName, download.File.ContentType))
+			ctx.SetHeader("Accept-Ranges", "bytes")
+			if download.Range != nil {
+				ctx.SetHeader("Content-Range", fmt.Sprintf("bytes %d-%d/%d", download.Range.Start, download.Range.End, download.File.Size))
+				ctx.SetStatus(http.StatusPartialContent)
+			}
+
+			// Stream file content to response
+			io.Copy(ctx.BodyWriter(), download.Content)
+		},
+	}, nil
+}
+
+// CreateUploadSlot handles requests to reserve a direct upload to storage
+func (h *FileHandler) CreateUploadSlot(ctx context.Context, input *dto.CreateUploadSlotRequest) (*dto.UploadSlotResponse, error) {
+	slot := &entities.UploadSlot{
+		Bucket:      input.Body.Bucket,
+		FileName:    input.Body.FileName,
+		ContentType: input.Body.ContentType,
+		Size:        input.Body.Size,
+		SHA256:      input.Body.SHA256,
+	}
+
+	err := h.service.CreateUploadSlot(ctx, slot)
+	if err != nil {
+		return nil, problem.FromError(err)
+	}
+
+	return &dto.UploadSlotResponse{
+		Body: dto.UploadSlotDTO{
+			FileName:    slot.FileName,
+			Bucket:      slot.Bucket,
+			ContentType: slot.ContentType,
+			Size:        slot.Size,
+			SHA256:      slot.SHA256,
+			Upload:      mapToPresignedRequestDTO(slot.Upload),
+		},
+	}, nil
+}
+
+// ConfirmUpload handles requests to confirm a completed direct upload
+func (h *FileHandler) ConfirmUpload(ctx context.Context, input *dto.ConfirmUploadRequest) (*dto.FileMetadataResponse, error) {
+	metadata, err := h.service.ConfirmUpload(ctx, input.Body.Bucket, input.Body.FileName)
+	if err != nil {
+		return nil, problem.FromError(err)
+	}
+
+	return &dto.FileMetadataResponse{Body: mapToFileMetadataDTO(metadata)}, nil
+}
+
+// PresignDownload handles requests for a direct download URL
+func (h *FileHandler) PresignDownload(ctx context.Context, input *dto.PresignDownloadRequest) (*dto.PresignedRequestResponse, error) {
+	// Validate filename
+	if input.FileName == "" {
+		return nil, problem.FromError(domainErrors.NewValidationError("file_name", "required", "file_name is required"))
+	}
+
+	presigned, err := h.service.PresignDownload(ctx, input.Bucket, input.FileName)
+	if err != nil {
+		return nil, problem.FromError(err)
+	}
+
+	return &dto.PresignedRequestResponse{Body: mapToPresignedRequestDTO(presigned)}, nil
+}
+
+// ListFiles handles GET /files
+func (h *FileHandler) ListFiles(ctx context.Context, input *dto.ListFilesRequest) (*dto.ListFilesResponse, error) {
+	query := &entities.FileQuery{
+		Bucket:      input.Bucket,
+		ContentType: input.ContentType,
+		Name:        input.Name,
+		Limit:       input.Limit,
+	}
+	if input.UploaderID != 0 {
+		query.UploaderID = &input.UploaderID
+	}
+	if !input.UploadedAfter.IsZero() {
+		query.UploadedAfter = &input.UploadedAfter
+	}
+	if !input.UploadedBefore.IsZero() {
+		query.UploadedBefore = &input.UploadedBefore
+	}
+	if input.Cursor != "" {
+		query.Cursor = &input.Cursor
+	}
+
+	result, err := h.service.ListFiles(ctx, query)
+	if err != nil {
+		return nil, problem.FromError(err)
+	}
+
+	response := &dto.ListFilesResponse{}
+	response.Body.Data = make([]dto.FileMetadataDTO, len(result.Files))
+	for i, file := range result.Files {
+		response.Body.Data[i] = mapToFileMetadataDTO(file)
+	}
+	response.Body.PageInfo = dto.PageInfoDTO{
+		HasNextPage:     result.PageInfo.HasNextPage,
+		HasPreviousPage: result.PageInfo.HasPreviousPage,
+		PreviousCursor:  result.PageInfo.PreviousCursor,
+		NextCursor:      result.PageInfo.NextCursor,
+	}
+
+	return response, nil
+}
+
+// GetFile handles GET /files/{id}
+func (h *FileHandler) GetFile(ctx context.Context, input *dto.GetFileRequest) (*dto.FileMetadataResponse, error) {
+	metadata, err := h.service.GetFile(ctx, input.ID)
+	if err != nil {
+		return nil, problem.FromError(err)
+	}
+
+	return &dto.FileMetadataResponse{Body: mapToFileMetadataDTO(metadata)}, nil
+}
+
+// TransformFile handles GET /files/{id}/transform
+func (h *FileHandler) TransformFile(ctx context.Context, input *dto.TransformFileRequest) (*huma.StreamResponse, error) {
+	reader, size, contentType, err := h.images.Transform(ctx, input.ID, entities.ImageTransform{
+		Width:  input.Width,
+		Height: input.Height,
+		Fit:    entities.ImageFit(input.Fit),
+		Format: entities.ImageFormat(input.Format),
+	})
+	if err != nil {
+		return nil, problem.FromError(err)
+	}
+
 	return &huma.StreamResponse{
 		Body: func(ctx huma.Context) {
 			defer reader.Close()
 
-			// Set content type and content length headers
 			ctx.SetHeader("Content-Type", contentType)
 			ctx.SetHeader("Content-Length", fmt.Sprintf("%d", size))
-			ctx.SetHeader("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", input.FileName))
 
-			// Stream file content to response
 			io.Copy(ctx.BodyWriter(), reader)
 		},
 	}, nil
 }
 
+// DeleteFileByID handles DELETE /files/{id}
+func (h *FileHandler) DeleteFileByID(ctx context.Context, input *dto.DeleteFileByIDRequest) (*struct{}, error) {
+	err := h.service.DeleteFileByID(ctx, input.ID)
+	if err != nil {
+		return nil, problem.FromError(err)
+	}
+
+	return &struct{}{}, nil
+}
+
 // mapToFileMetadataDTO maps domain entity to DTO
 func mapToFileMetadataDTO(metadata *entities.FileMetadata) dto.FileMetadataDTO {
 	return dto.FileMetadataDTO{
-		ID:          metadata.ID,
+		ID:          metadata.ID.String(),
 		FileName:    metadata.FileName,
 		Bucket:      metadata.Bucket,
 		Size:        metadata.Size,
 		ContentType: metadata.ContentType,
+		SHA256:      metadata.SHA256,
+		UploaderID:  metadata.UploaderID,
 		URL:         metadata.URL,
 		UploadedAt:  metadata.UploadedAt,
+		UpdatedAt:   metadata.UpdatedAt,
+		Image:       mapToImageInfoDTO(metadata.ID, metadata.Image),
+	}
+}
+
+// mapToImageInfoDTO maps the image info of a file to its DTO; derivatives link to the transform serving them
+func mapToImageInfoDTO(id uuid.UUID, info *entities.ImageInfo) *dto.ImageInfoDTO {
+	if info == nil {
+		return nil
+	}
+
+	result := &dto.ImageInfoDTO{
+		Width:         info.Width,
+		Height:        info.Height,
+		BlurHash:      info.BlurHash,
+		DominantColor: info.DominantColor,
+		Derivatives:   make([]dto.ImageDerivativeDTO, len(info.Derivatives)),
+	}
+	for i, derivative := range info.Derivatives {
+		query := url.Values{}
+		query.Set("w", strconv.Itoa(derivative.Transform.Width))
+		query.Set("h", strconv.Itoa(derivative.Transform.Height))
+		query.Set("fit", string(derivative.Transform.Fit))
+		query.Set("format", string(derivative.Transform.Format))
+
+		result.Derivatives[i] = dto.ImageDerivativeDTO{
+			Name:   derivative.Name,
+			Format: string(derivative.Transform.Format),
+			Width:  derivative.Width,
+			Height: derivative.Height,
+			Size:   derivative.Size,
+			URL:    "/files/" + id.String() + "/transform?" + query.Encode(),
+		}
+	}
+	return result
+}
+
+// mapToPresignedRequestDTO maps domain entity to DTO
+func mapToPresignedRequestDTO(req *entities.PresignedRequest) dto.PresignedRequestDTO {
+	return dto.PresignedRequestDTO{
+		Method:    req.Method,
+		URL:       req.URL,
+		Headers:   req.Headers,
+		ExpiresAt: req.ExpiresAt,
+	}
+}
+
+// parseContentDigest returns the hex-encoded SHA-256 from a Content-Digest header (RFC 9530),
+// or an empty string if the header is not set
+func parseContentDigest(header string) (string, error) {
+	if strings.TrimSpace(header) == "" {
+		return "", nil
+	}
+
+	for _, member := range strings.Split(header, ",") {
+		algorithm, value, ok := strings.Cut(strings.TrimSpace(member), "=")
+		if !ok || !strings.EqualFold(strings.TrimSpace(algorithm), "sha-256") {
+			continue
+		}
+
+		// Byte sequences are base64 enclosed in colons
+		value = strings.TrimSpace(value)
+		if len(value) < 2 || value[0] != ':' || value[len(value)-1] != ':' {
+			break
+		}
+		sum, err := base64.StdEncoding.DecodeString(value[1 : len(value)-1])
+		if err != nil || len(sum) != sha256.Size {
+			break
+		}
+		return hex.EncodeToString(sum), nil
+	}
+
+	return "", domainErrors.NewValidationError("content_digest", "invalid", "Content-Digest must contain a sha-256 digest, e.g. sha-256=:<base64>:")
+}
+
+// parseRange parses a Range header with a single byte range. Other units, multiple ranges and malformed
+// headers yield nil, so the whole file is sent, as RFC 9110 allows.
+func parseRange(header string) *entities.RangeRequest {
+	spec, ok := strings.CutPrefix(strings.TrimSpace(header), "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return nil
+	}
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return nil
+	}
+
+	// Suffix range: the last N bytes
+	if first == "" {
+		length, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || length <= 0 {
+			return nil
+		}
+		return &entities.RangeRequest{SuffixLength: length}
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return nil
+	}
+	if last == "" {
+		return &entities.RangeRequest{Start: start, End: -1}
+	}
+	end, err := strconv.ParseInt(last, 10, 64)
+	if err != nil || end < start {
+		return nil
+	}
+	return &entities.RangeRequest{Start: start, End: end}
+}
+
+// parseETags splits an If-None-Match header into its entity tags
+func parseETags(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// contentDisposition formats a Content-Disposition header (RFC 6266) for the base name of a file. The name
+// is sent as UTF-8 in filename*, with an ASCII fallback in filename for clients that do not support it.
+// Only media are displayed inline, by default; browsers could run scripts in other documents.
+func contentDisposition(disposition, fileName, contentType string) string {
+	if !displayable(contentType) {
+		disposition = "attachment"
+	} else if disposition == "" {
+		disposition = "inline"
+	}
+	name := path.Base(fileName)
+
+	fallback := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' || r == '%' {
+			return '_'
+		}
+		return r
+	}, name)
+	header := disposition + `; filename="` + fallback + `"`
+	if fallback != name {
+		header += "; filename*=UTF-8''" + encodeExtValue(name)
+	}
+	return header
+}
+
+// displayable reports whether files of contentType may be displayed in the browser: images other than SVG,
+// which can hold scripts, videos, audio and PDFs
+func displayable(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	major, _, _ := strings.Cut(mediaType, "/")
+	switch {
+	case mediaType == "image/svg+xml":
+		return false
+	case mediaType == "application/pdf", major == "image", major == "video", major == "audio":
+		return true
+	}
+	return false
+}
+
+// encodeExtValue percent-encodes the bytes of s that are not attr-chars (RFC 8187)
+func encodeExtValue(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("!#$&+-.^_`|~", c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
 	}
+	return b.String()
 }
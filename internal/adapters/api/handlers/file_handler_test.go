@@ -3,10 +3,13 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
-	"fmt"
 	"io"
 	"mime/multipart"
+	"net/http"
 	"testing"
 	"time"
 
@@ -14,6 +17,8 @@ import (
 	"example.com/go-yippi/internal/domain/entities"
 	domainErrors "example.com/go-yippi/internal/domain/errors"
 	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -24,8 +29,8 @@ type MockStorageService struct {
 	mock.Mock
 }
 
-func (m *MockStorageService) UploadFile(ctx context.Context, bucket, fileName string, reader io.Reader, size int64, contentType string) (*entities.FileMetadata, error) {
-	args := m.Called(ctx, bucket, fileName, reader, size, contentType)
+func (m *MockStorageService) UploadFile(ctx context.Context, upload *entities.FileUpload) (*entities.FileMetadata, error) {
+	args := m.Called(ctx, upload)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -42,46 +47,125 @@ func (m *MockStorageService) GetFileURL(ctx context.Context, bucket, fileName st
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockStorageService) DownloadFile(ctx context.Context, bucket, fileName string) (io.ReadCloser, int64, string, error) {
-	args := m.Called(ctx, bucket, fileName)
+func (m *MockStorageService) DownloadFile(ctx context.Context, bucket, fileName string, opts entities.DownloadOptions) (*entities.FileDownload, error) {
+	args := m.Called(ctx, bucket, fileName, opts)
 	if args.Get(0) == nil {
-		return nil, args.Get(1).(int64), args.String(2), args.Error(3)
+		return nil, args.Error(1)
 	}
-	return args.Get(0).(io.ReadCloser), args.Get(1).(int64), args.String(2), args.Error(3)
+	return args.Get(0).(*entities.FileDownload), args.Error(1)
 }
 
-// createMultipartFormData creates multipart form data for testing
-func createMultipartFormData(file []byte, fileName, bucket, contentType string) []byte {
-	var buf bytes.Buffer
-	writer := multipart.NewWriter(&buf)
+func (m *MockStorageService) CreateUploadSlot(ctx context.Context, slot *entities.UploadSlot) error {
+	args := m.Called(ctx, slot)
+	return args.Error(0)
+}
 
-	// Add file field
-	part, _ := writer.CreateFormFile("file", fileName)
-	part.Write(file)
+func (m *MockStorageService) ComposeFile(ctx context.Context, upload *entities.FileUpload, parts []entities.UploadPart) (*entities.FileMetadata, error) {
+	args := m.Called(ctx, upload, parts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.FileMetadata), args.Error(1)
+}
+
+func (m *MockStorageService) ConfirmUpload(ctx context.Context, bucket, fileName string) (*entities.FileMetadata, error) {
+	args := m.Called(ctx, bucket, fileName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.FileMetadata), args.Error(1)
+}
 
-	// Add file_name field if provided
-	if fileName != "" {
-		writer.WriteField("file_name", fileName)
+func (m *MockStorageService) PresignDownload(ctx context.Context, bucket, fileName string) (*entities.PresignedRequest, error) {
+	args := m.Called(ctx, bucket, fileName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
 	}
+	return args.Get(0).(*entities.PresignedRequest), args.Error(1)
+}
 
-	// Add bucket field if provided
-	if bucket != "" {
-		writer.WriteField("bucket", bucket)
+func (m *MockStorageService) GetFile(ctx context.Context, id uuid.UUID) (*entities.FileMetadata, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.FileMetadata), args.Error(1)
+}
+
+func (m *MockStorageService) ListFiles(ctx context.Context, query *entities.FileQuery) (*entities.FileQueryResult, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
 	}
+	return args.Get(0).(*entities.FileQueryResult), args.Error(1)
+}
 
-	// Add content_type field if provided
-	if contentType != "" {
-		writer.WriteField("content_type", contentType)
+func (m *MockStorageService) DeleteFileByID(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// testFileID is the ID of the file records used in the tests
+var testFileID = uuid.MustParse("6f1c2b9e-3d4a-4e5f-9a1b-2c3d4e5f6a7b")
+
+// MockImageService is a mock implementation of ImageService
+type MockImageService struct {
+	mock.Mock
+}
+
+func (m *MockImageService) Process(ctx context.Context, file *entities.FileMetadata) error {
+	args := m.Called(ctx, file)
+	return args.Error(0)
+}
+
+func (m *MockImageService) Transform(ctx context.Context, id uuid.UUID, transform entities.ImageTransform) (io.ReadCloser, int64, string, error) {
+	args := m.Called(ctx, id, transform)
+	if args.Get(0) == nil {
+		return nil, 0, "", args.Error(3)
 	}
+	return args.Get(0).(io.ReadCloser), args.Get(1).(int64), args.String(2), args.Error(3)
+}
 
+func (m *MockImageService) RemoveVariants(ctx context.Context, file *entities.FileMetadata) error {
+	args := m.Called(ctx, file)
+	return args.Error(0)
+}
+
+// uploadRequest creates an upload request whose form holds the fields that are set, followed by the file
+func uploadRequest(file []byte, fileName, bucket, contentType string) *dto.UploadFileRequest {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for _, field := range []struct{ name, value string }{
+		{"file_name", fileName},
+		{"bucket", bucket},
+		{"content_type", contentType},
+	} {
+		if field.value != "" {
+			writer.WriteField(field.name, field.value)
+		}
+	}
+	part, _ := writer.CreateFormFile("file", fileName)
+	part.Write(file)
 	writer.Close()
-	return buf.Bytes()
+
+	return &dto.UploadFileRequest{ContentType: writer.FormDataContentType(), Form: &buf}
+}
+
+// uploadMatching matches uploads of the given file streamed from the form, whose size is not known upfront
+func uploadMatching(bucket, fileName string, contentType string) any {
+	return mock.MatchedBy(func(u *entities.FileUpload) bool {
+		return u.Bucket == bucket &&
+			u.FileName == fileName &&
+			u.Size == -1 &&
+			u.ContentType == contentType &&
+			u.Content != nil
+	})
 }
 
 func TestUploadFile_Success(t *testing.T) {
 	// Arrange
 	mockService := new(MockStorageService)
-	handler := NewFileHandler(mockService)
+	handler := NewFileHandler(mockService, new(MockImageService), CacheControl{})
 	ctx := context.Background()
 
 	fileContent := []byte("test file content")
@@ -89,12 +173,10 @@ func TestUploadFile_Success(t *testing.T) {
 	bucket := "test-bucket"
 	contentType := "text/plain"
 
-	input := &dto.UploadFileRequest{
-		RawBody: createMultipartFormData(fileContent, fileName, bucket, contentType),
-	}
+	input := uploadRequest(fileContent, fileName, bucket, contentType)
 
 	expectedMetadata := &entities.FileMetadata{
-		ID:          "test-id-123",
+		ID:          testFileID,
 		FileName:    fileName,
 		Bucket:      bucket,
 		Size:        int64(len(fileContent)),
@@ -103,7 +185,7 @@ func TestUploadFile_Success(t *testing.T) {
 		UploadedAt:  time.Now(),
 	}
 
-	mockService.On("UploadFile", ctx, bucket, fileName, mock.AnythingOfType("*bytes.Reader"), int64(len(fileContent)), contentType).Return(expectedMetadata, nil)
+	mockService.On("UploadFile", ctx, uploadMatching(bucket, fileName, contentType)).Return(expectedMetadata, nil)
 
 	// Act
 	response, err := handler.UploadFile(ctx, input)
@@ -111,7 +193,7 @@ func TestUploadFile_Success(t *testing.T) {
 	// Assert
 	require.NoError(t, err)
 	require.NotNil(t, response)
-	assert.Equal(t, expectedMetadata.ID, response.Body.ID)
+	assert.Equal(t, expectedMetadata.ID.String(), response.Body.ID)
 	assert.Equal(t, expectedMetadata.FileName, response.Body.FileName)
 	assert.Equal(t, expectedMetadata.Bucket, response.Body.Bucket)
 	assert.Equal(t, expectedMetadata.Size, response.Body.Size)
@@ -120,10 +202,11 @@ func TestUploadFile_Success(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
-func TestUploadFile_AutoDetectContentType(t *testing.T) {
+// TestUploadFile_ContentTypeDetectedByService tests that uploads without a content type are passed on for sniffing
+func TestUploadFile_ContentTypeDetectedByService(t *testing.T) {
 	// Arrange
 	mockService := new(MockStorageService)
-	handler := NewFileHandler(mockService)
+	handler := NewFileHandler(mockService, new(MockImageService), CacheControl{})
 	ctx := context.Background()
 
 	// PNG file signature
@@ -131,12 +214,10 @@ func TestUploadFile_AutoDetectContentType(t *testing.T) {
 	fileName := "test.png"
 	bucket := "test-bucket"
 
-	input := &dto.UploadFileRequest{
-		RawBody: createMultipartFormData(fileContent, fileName, bucket, ""),
-	}
+	input := uploadRequest(fileContent, fileName, bucket, "")
 
 	expectedMetadata := &entities.FileMetadata{
-		ID:          "test-id-123",
+		ID:          testFileID,
 		FileName:    fileName,
 		Bucket:      bucket,
 		Size:        int64(len(fileContent)),
@@ -145,8 +226,8 @@ func TestUploadFile_AutoDetectContentType(t *testing.T) {
 		UploadedAt:  time.Now(),
 	}
 
-	// Content type should be auto-detected as image/png
-	mockService.On("UploadFile", ctx, bucket, fileName, mock.AnythingOfType("*bytes.Reader"), int64(len(fileContent)), "image/png").Return(expectedMetadata, nil)
+	// The handler does not read the content; the service detects image/png from its first bytes
+	mockService.On("UploadFile", ctx, uploadMatching(bucket, fileName, "")).Return(expectedMetadata, nil)
 
 	// Act
 	response, err := handler.UploadFile(ctx, input)
@@ -161,7 +242,7 @@ func TestUploadFile_AutoDetectContentType(t *testing.T) {
 func TestUploadFile_UseOriginalFilename(t *testing.T) {
 	// Arrange
 	mockService := new(MockStorageService)
-	handler := NewFileHandler(mockService)
+	handler := NewFileHandler(mockService, new(MockImageService), CacheControl{})
 	ctx := context.Background()
 
 	fileContent := []byte("test content")
@@ -173,12 +254,10 @@ func TestUploadFile_UseOriginalFilename(t *testing.T) {
 	part.Write(fileContent)
 	writer.Close()
 
-	input := &dto.UploadFileRequest{
-		RawBody: buf.Bytes(),
-	}
+	input := &dto.UploadFileRequest{ContentType: writer.FormDataContentType(), Form: &buf}
 
 	expectedMetadata := &entities.FileMetadata{
-		ID:          "test-id-123",
+		ID:          testFileID,
 		FileName:    originalFileName,
 		Bucket:      "",
 		Size:        int64(len(fileContent)),
@@ -187,7 +266,7 @@ func TestUploadFile_UseOriginalFilename(t *testing.T) {
 		UploadedAt:  time.Now(),
 	}
 
-	mockService.On("UploadFile", ctx, "", originalFileName, mock.AnythingOfType("*bytes.Reader"), int64(len(fileContent)), mock.Anything).Return(expectedMetadata, nil)
+	mockService.On("UploadFile", ctx, uploadMatching("", originalFileName, "")).Return(expectedMetadata, nil)
 
 	// Act
 	response, err := handler.UploadFile(ctx, input)
@@ -199,15 +278,18 @@ func TestUploadFile_UseOriginalFilename(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
-func TestUploadFile_EmptyFile(t *testing.T) {
+// TestUploadFile_MissingFile tests that a form without a file part is rejected
+func TestUploadFile_MissingFile(t *testing.T) {
 	// Arrange
 	mockService := new(MockStorageService)
-	handler := NewFileHandler(mockService)
+	handler := NewFileHandler(mockService, new(MockImageService), CacheControl{})
 	ctx := context.Background()
 
-	input := &dto.UploadFileRequest{
-		RawBody: createMultipartFormData([]byte{}, "test.txt", "", ""),
-	}
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	writer.WriteField("file_name", "test.txt")
+	writer.Close()
+	input := &dto.UploadFileRequest{ContentType: writer.FormDataContentType(), Form: &buf}
 
 	// Act
 	response, err := handler.UploadFile(ctx, input)
@@ -221,20 +303,71 @@ func TestUploadFile_EmptyFile(t *testing.T) {
 	mockService.AssertNotCalled(t, "UploadFile")
 }
 
+// TestUploadFile_NotMultipart tests that bodies other than multipart forms are rejected
+func TestUploadFile_NotMultipart(t *testing.T) {
+	// Arrange
+	mockService := new(MockStorageService)
+	handler := NewFileHandler(mockService, new(MockImageService), CacheControl{})
+	input := &dto.UploadFileRequest{ContentType: "application/octet-stream", Form: bytes.NewReader([]byte("content"))}
+
+	// Act
+	response, err := handler.UploadFile(context.Background(), input)
+
+	// Assert
+	assert.Nil(t, response)
+	var humaErr huma.StatusError
+	require.True(t, errors.As(err, &humaErr))
+	assert.Equal(t, http.StatusUnsupportedMediaType, humaErr.GetStatus())
+	mockService.AssertNotCalled(t, "UploadFile")
+}
+
+// TestUploadFile_StreamsFilePart tests that the file part is passed on as it is read from the form, with the
+// fields before it and without the parts after it
+func TestUploadFile_StreamsFilePart(t *testing.T) {
+	// Arrange
+	mockService := new(MockStorageService)
+	handler := NewFileHandler(mockService, new(MockImageService), CacheControl{})
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	writer.WriteField("bucket", "media")
+	part, _ := writer.CreateFormFile("file", "photo.png")
+	part.Write([]byte("file content"))
+	writer.WriteField("file_name", "ignored.png")
+	writer.Close()
+	input := &dto.UploadFileRequest{ContentType: writer.FormDataContentType(), Form: &buf}
+
+	var received string
+	mockService.On("UploadFile", ctx, uploadMatching("media", "photo.png", "")).
+		Run(func(args mock.Arguments) {
+			content, _ := io.ReadAll(args.Get(1).(*entities.FileUpload).Content)
+			received = string(content)
+		}).
+		Return(&entities.FileMetadata{FileName: "photo.png", Bucket: "media"}, nil)
+
+	// Act
+	response, err := handler.UploadFile(ctx, input)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "photo.png", response.Body.FileName)
+	assert.Equal(t, "file content", received)
+	mockService.AssertExpectations(t)
+}
+
 func TestUploadFile_ServiceError(t *testing.T) {
 	// Arrange
 	mockService := new(MockStorageService)
-	handler := NewFileHandler(mockService)
+	handler := NewFileHandler(mockService, new(MockImageService), CacheControl{})
 	ctx := context.Background()
 
 	fileContent := []byte("test file content")
 	fileName := "test.txt"
 
-	input := &dto.UploadFileRequest{
-		RawBody: createMultipartFormData(fileContent, fileName, "", ""),
-	}
+	input := uploadRequest(fileContent, fileName, "", "")
 
-	mockService.On("UploadFile", ctx, "", fileName, mock.AnythingOfType("*bytes.Reader"), int64(len(fileContent)), mock.Anything).Return(nil, errors.New("storage error"))
+	mockService.On("UploadFile", ctx, uploadMatching("", fileName, "")).Return(nil, errors.New("storage error"))
 
 	// Act
 	response, err := handler.UploadFile(ctx, input)
@@ -251,18 +384,16 @@ func TestUploadFile_ServiceError(t *testing.T) {
 func TestUploadFile_ValidationError(t *testing.T) {
 	// Arrange
 	mockService := new(MockStorageService)
-	handler := NewFileHandler(mockService)
+	handler := NewFileHandler(mockService, new(MockImageService), CacheControl{})
 	ctx := context.Background()
 
 	fileContent := []byte("test file content")
 	fileName := "test.txt"
 
-	input := &dto.UploadFileRequest{
-		RawBody: createMultipartFormData(fileContent, fileName, "", ""),
-	}
+	input := uploadRequest(fileContent, fileName, "", "")
 
-	validationErr := domainErrors.NewValidationError("file_name", "invalid filename")
-	mockService.On("UploadFile", ctx, "", fileName, mock.AnythingOfType("*bytes.Reader"), int64(len(fileContent)), mock.Anything).Return(nil, validationErr)
+	validationErr := domainErrors.NewValidationError("file_name", "invalid", "invalid filename")
+	mockService.On("UploadFile", ctx, uploadMatching("", fileName, "")).Return(nil, validationErr)
 
 	// Act
 	response, err := handler.UploadFile(ctx, input)
@@ -276,10 +407,120 @@ func TestUploadFile_ValidationError(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+// TestUploadFile_ContentDigest tests that the Content-Digest header is passed on as the expected checksum
+func TestUploadFile_ContentDigest(t *testing.T) {
+	// Arrange
+	mockService := new(MockStorageService)
+	handler := NewFileHandler(mockService, new(MockImageService), CacheControl{})
+	ctx := context.Background()
+
+	fileContent := []byte("test file content")
+	sum := sha256.Sum256(fileContent)
+
+	input := uploadRequest(fileContent, "test.txt", "", "")
+	input.ContentDigest = "sha-512=:bm90IHVzZWQ=:, sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+
+	mockService.On("UploadFile", ctx, mock.MatchedBy(func(u *entities.FileUpload) bool {
+		return u.SHA256 == hex.EncodeToString(sum[:])
+	})).Return(&entities.FileMetadata{FileName: "test.txt", SHA256: hex.EncodeToString(sum[:])}, nil)
+
+	// Act
+	response, err := handler.UploadFile(ctx, input)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(sum[:]), response.Body.SHA256)
+	mockService.AssertExpectations(t)
+}
+
+// TestUploadFile_InvalidContentDigest tests that unusable Content-Digest headers are rejected
+func TestUploadFile_InvalidContentDigest(t *testing.T) {
+	// Arrange
+	mockService := new(MockStorageService)
+	handler := NewFileHandler(mockService, new(MockImageService), CacheControl{})
+	ctx := context.Background()
+
+	for _, header := range []string{"md5=:AAAA:", "sha-256=not-a-byte-sequence", "sha-256=:c2hvcnQ=:"} {
+		input := uploadRequest([]byte("test file content"), "test.txt", "", "")
+		input.ContentDigest = header
+
+		// Act
+		response, err := handler.UploadFile(ctx, input)
+
+		// Assert
+		assert.Nil(t, response, header)
+		var humaErr huma.StatusError
+		require.True(t, errors.As(err, &humaErr), header)
+		assert.Equal(t, 400, humaErr.GetStatus(), header)
+	}
+	mockService.AssertNotCalled(t, "UploadFile")
+}
+
+// TestCreateUploadSlot_Success tests that the presigned upload is returned with the slot
+func TestCreateUploadSlot_Success(t *testing.T) {
+	// Arrange
+	mockService := new(MockStorageService)
+	handler := NewFileHandler(mockService, new(MockImageService), CacheControl{})
+	ctx := context.Background()
+
+	input := &dto.CreateUploadSlotRequest{}
+	input.Body.FileName = "video.mp4"
+	input.Body.ContentType = "video/mp4"
+	input.Body.Size = 1 << 30
+
+	expiresAt := time.Now().Add(15 * time.Minute)
+	mockService.On("CreateUploadSlot", ctx, mock.MatchedBy(func(slot *entities.UploadSlot) bool {
+		return slot.FileName == "video.mp4" && slot.ContentType == "video/mp4" && slot.Size == 1<<30
+	})).Run(func(args mock.Arguments) {
+		slot := args.Get(1).(*entities.UploadSlot)
+		slot.Bucket = "default"
+		slot.Upload = &entities.PresignedRequest{
+			Method:    "PUT",
+			URL:       "http://storage/default/tenants/acme/video.mp4?X-Amz-Signature=abc",
+			Headers:   map[string]string{"Content-Type": "video/mp4", "Content-Length": "1073741824"},
+			ExpiresAt: expiresAt,
+		}
+	}).Return(nil)
+
+	// Act
+	response, err := handler.CreateUploadSlot(ctx, input)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "default", response.Body.Bucket)
+	assert.Equal(t, "PUT", response.Body.Upload.Method)
+	assert.Equal(t, "video/mp4", response.Body.Upload.Headers["Content-Type"])
+	assert.Equal(t, expiresAt, response.Body.Upload.ExpiresAt)
+	mockService.AssertExpectations(t)
+}
+
+// TestConfirmUpload_NotUploaded tests that confirming a missing upload is reported as not found
+func TestConfirmUpload_NotUploaded(t *testing.T) {
+	// Arrange
+	mockService := new(MockStorageService)
+	handler := NewFileHandler(mockService, new(MockImageService), CacheControl{})
+	ctx := context.Background()
+
+	input := &dto.ConfirmUploadRequest{}
+	input.Body.FileName = "video.mp4"
+
+	mockService.On("ConfirmUpload", ctx, "", "video.mp4").Return(nil, domainErrors.NewNotFoundError("File", "video.mp4"))
+
+	// Act
+	response, err := handler.ConfirmUpload(ctx, input)
+
+	// Assert
+	assert.Nil(t, response)
+	var humaErr huma.StatusError
+	require.True(t, errors.As(err, &humaErr))
+	assert.Equal(t, 404, humaErr.GetStatus())
+	mockService.AssertExpectations(t)
+}
+
 func TestDeleteFile_Success(t *testing.T) {
 	// Arrange
 	mockService := new(MockStorageService)
-	handler := NewFileHandler(mockService)
+	handler := NewFileHandler(mockService, new(MockImageService), CacheControl{})
 	ctx := context.Background()
 
 	input := &dto.DeleteFileRequest{
@@ -302,7 +543,7 @@ func TestDeleteFile_Success(t *testing.T) {
 func TestDeleteFile_EmptyFileName(t *testing.T) {
 	// Arrange
 	mockService := new(MockStorageService)
-	handler := NewFileHandler(mockService)
+	handler := NewFileHandler(mockService, new(MockImageService), CacheControl{})
 	ctx := context.Background()
 
 	input := &dto.DeleteFileRequest{
@@ -325,7 +566,7 @@ func TestDeleteFile_EmptyFileName(t *testing.T) {
 func TestGetFileURL_Success(t *testing.T) {
 	// Arrange
 	mockService := new(MockStorageService)
-	handler := NewFileHandler(mockService)
+	handler := NewFileHandler(mockService, new(MockImageService), CacheControl{})
 	ctx := context.Background()
 
 	input := &dto.GetFileURLRequest{
@@ -346,11 +587,263 @@ func TestGetFileURL_Success(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+// TestListFiles_MapsFiltersAndPage tests that list filters reach the service and the page is mapped
+func TestListFiles_MapsFiltersAndPage(t *testing.T) {
+	// Arrange
+	mockService := new(MockStorageService)
+	handler := NewFileHandler(mockService, new(MockImageService), CacheControl{})
+	ctx := context.Background()
+
+	input := &dto.ListFilesRequest{ContentType: "image/*", UploaderID: 7, Limit: 1}
+	result := &entities.FileQueryResult{
+		Files:    []*entities.FileMetadata{{ID: testFileID, FileName: "logo.png", ContentType: "image/png"}},
+		PageInfo: entities.PageInfo{HasNextPage: true, NextCursor: "next"},
+	}
+
+	mockService.On("ListFiles", ctx, mock.MatchedBy(func(q *entities.FileQuery) bool {
+		return q.ContentType == "image/*" &&
+			q.UploaderID != nil && *q.UploaderID == 7 &&
+			q.UploadedAfter == nil &&
+			q.Cursor == nil &&
+			q.Limit == 1
+	})).Return(result, nil)
+
+	// Act
+	response, err := handler.ListFiles(ctx, input)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, response.Body.Data, 1)
+	assert.Equal(t, testFileID.String(), response.Body.Data[0].ID)
+	assert.True(t, response.Body.PageInfo.HasNextPage)
+	assert.Equal(t, "next", response.Body.PageInfo.NextCursor)
+	mockService.AssertExpectations(t)
+}
+
+// TestGetFile_NotFound tests that unknown file IDs are reported as not found
+func TestGetFile_NotFound(t *testing.T) {
+	// Arrange
+	mockService := new(MockStorageService)
+	handler := NewFileHandler(mockService, new(MockImageService), CacheControl{})
+	ctx := context.Background()
+
+	mockService.On("GetFile", ctx, testFileID).Return(nil, domainErrors.NewNotFoundError("File", testFileID))
+
+	// Act
+	response, err := handler.GetFile(ctx, &dto.GetFileRequest{ID: testFileID})
+
+	// Assert
+	assert.Nil(t, response)
+	var humaErr huma.StatusError
+	require.True(t, errors.As(err, &humaErr))
+	assert.Equal(t, 404, humaErr.GetStatus())
+	mockService.AssertExpectations(t)
+}
+
+// newDownloadTestAPI registers the file routes on a test API
+func newDownloadTestAPI(t *testing.T, service *MockStorageService, cacheControl CacheControl) humatest.TestAPI {
+	_, api := humatest.New(t)
+	NewFileHandler(service, new(MockImageService), cacheControl).RegisterRoutes(api)
+	return api
+}
+
+// TestDownloadFile_Range tests that a byte range is passed on and answered with 206 Partial Content
+func TestDownloadFile_Range(t *testing.T) {
+	// Arrange
+	mockService := new(MockStorageService)
+	api := newDownloadTestAPI(t, mockService, CacheControl{Default: "no-cache", ByBucket: map[string]string{"assets": "public, max-age=86400"}})
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	mockService.On("DownloadFile", mock.Anything, "assets", "video.mp4", entities.DownloadOptions{Range: &entities.RangeRequest{Start: 100, End: 199}}).
+		Return(&entities.FileDownload{
+			File:         &entities.FileMetadata{Bucket: "assets", Size: 1000, ContentType: "video/mp4"},
+			ETag:         `"abc"`,
+			LastModified: modified,
+			Content:      io.NopCloser(bytes.NewReader(make([]byte, 100))),
+			Size:         100,
+			Range:        &entities.ByteRange{Start: 100, End: 199},
+		}, nil)
+
+	// Act
+	resp := api.Get("/files/download?bucket=assets&file_name=video.mp4", "Range: bytes=100-199")
+
+	// Assert
+	assert.Equal(t, http.StatusPartialContent, resp.Code)
+	assert.Equal(t, "bytes 100-199/1000", resp.Header().Get("Content-Range"))
+	assert.Equal(t, "100", resp.Header().Get("Content-Length"))
+	assert.Equal(t, "video/mp4", resp.Header().Get("Content-Type"))
+	assert.Equal(t, "nosniff", resp.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, `"abc"`, resp.Header().Get("ETag"))
+	assert.Equal(t, "Wed, 01 May 2024 12:00:00 GMT", resp.Header().Get("Last-Modified"))
+	assert.Equal(t, "public, max-age=86400", resp.Header().Get("Cache-Control"))
+	assert.Equal(t, `inline; filename="video.mp4"`, resp.Header().Get("Content-Disposition"))
+	assert.Equal(t, 100, resp.Body.Len())
+	mockService.AssertExpectations(t)
+}
+
+// TestDownloadFile_NotModified tests that validators are passed on and a current copy gets 304 Not Modified
+func TestDownloadFile_NotModified(t *testing.T) {
+	// Arrange
+	mockService := new(MockStorageService)
+	api := newDownloadTestAPI(t, mockService, CacheControl{Default: "no-cache"})
+	since := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	mockService.On("DownloadFile", mock.Anything, "", "logo.png", entities.DownloadOptions{IfNoneMatch: []string{`"abc"`, `W/"def"`}, IfModifiedSince: since}).
+		Return(&entities.FileDownload{
+			File:         &entities.FileMetadata{Bucket: "uploads", Size: 10, ContentType: "image/png"},
+			ETag:         `"abc"`,
+			LastModified: since,
+			NotModified:  true,
+		}, nil)
+
+	// Act
+	resp := api.Get("/files/download?file_name=logo.png", `If-None-Match: "abc", W/"def"`, "If-Modified-Since: Wed, 01 May 2024 12:00:00 GMT")
+
+	// Assert
+	assert.Equal(t, http.StatusNotModified, resp.Code)
+	assert.Equal(t, `"abc"`, resp.Header().Get("ETag"))
+	assert.Equal(t, "no-cache", resp.Header().Get("Cache-Control"))
+	assert.Zero(t, resp.Body.Len())
+	mockService.AssertExpectations(t)
+}
+
+// TestDownloadFile_RangeNotSatisfiable tests that 416 responses state the size of the file
+func TestDownloadFile_RangeNotSatisfiable(t *testing.T) {
+	// Arrange
+	mockService := new(MockStorageService)
+	api := newDownloadTestAPI(t, mockService, CacheControl{})
+
+	mockService.On("DownloadFile", mock.Anything, "", "logo.png", mock.Anything).Return(nil, domainErrors.NewRangeNotSatisfiableError(10))
+
+	// Act
+	resp := api.Get("/files/download?file_name=logo.png", "Range: bytes=50-")
+
+	// Assert
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, resp.Code)
+	assert.Equal(t, "bytes */10", resp.Header().Get("Content-Range"))
+}
+
+// TestParseRange tests the Range headers that are honoured and those that are ignored
+func TestParseRange(t *testing.T) {
+	cases := []struct {
+		header string
+		want   *entities.RangeRequest
+	}{
+		{header: "bytes=0-499", want: &entities.RangeRequest{Start: 0, End: 499}},
+		{header: "bytes=500-", want: &entities.RangeRequest{Start: 500, End: -1}},
+		{header: "bytes=-200", want: &entities.RangeRequest{SuffixLength: 200}},
+		{header: "", want: nil},
+		{header: "items=0-5", want: nil},
+		{header: "bytes=0-1,5-6", want: nil},
+		{header: "bytes=5-1", want: nil},
+		{header: "bytes=-0", want: nil},
+		{header: "bytes=abc", want: nil},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, parseRange(tc.header), tc.header)
+	}
+}
+
+// TestContentDisposition tests that file names are encoded safely (RFC 6266) and that only media are
+// displayed inline
+func TestContentDisposition(t *testing.T) {
+	cases := []struct {
+		disposition string
+		fileName    string
+		contentType string
+		want        string
+	}{
+		{disposition: "inline", fileName: "report.pdf", contentType: "application/pdf", want: `inline; filename="report.pdf"`},
+		{disposition: "attachment", fileName: "docs/2024/report.pdf", contentType: "application/pdf", want: `attachment; filename="report.pdf"`},
+		{disposition: "attachment", fileName: `say "hi".txt`, contentType: "text/plain", want: `attachment; filename="say _hi_.txt"; filename*=UTF-8''say%20%22hi%22.txt`},
+		{disposition: "attachment", fileName: "naïve;café.png", contentType: "image/png", want: `attachment; filename="na_ve;caf_.png"; filename*=UTF-8''na%C3%AFve%3Bcaf%C3%A9.png`},
+		{disposition: "", fileName: "a\r\nb.png", contentType: "image/png", want: `inline; filename="a__b.png"; filename*=UTF-8''a%0D%0Ab.png`},
+		{disposition: "", fileName: "clip.mp4", contentType: "video/mp4; codecs=avc1", want: `inline; filename="clip.mp4"`},
+		{disposition: "", fileName: "notes.txt", contentType: "text/plain", want: `attachment; filename="notes.txt"`},
+		{disposition: "inline", fileName: "style.xsl", contentType: "text/xsl", want: `attachment; filename="style.xsl"`},
+		{disposition: "inline", fileName: "logo.svg", contentType: "image/svg+xml", want: `attachment; filename="logo.svg"`},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, contentDisposition(tc.disposition, tc.fileName, tc.contentType), tc.fileName)
+	}
+}
+
+// TestTransformFile_PassesTransform tests that the query is passed on as a transform
+func TestTransformFile_PassesTransform(t *testing.T) {
+	// Arrange
+	mockImages := new(MockImageService)
+	handler := NewFileHandler(new(MockStorageService), mockImages, CacheControl{})
+	ctx := context.Background()
+	transform := entities.ImageTransform{Width: 200, Height: 100, Fit: entities.ImageFitCover, Format: entities.ImageFormatWebP}
+
+	mockImages.On("Transform", ctx, testFileID, transform).Return(io.NopCloser(bytes.NewReader([]byte("webp"))), int64(4), "image/webp", nil)
+
+	// Act
+	response, err := handler.TransformFile(ctx, &dto.TransformFileRequest{ID: testFileID, Width: 200, Height: 100, Fit: "cover", Format: "webp"})
+
+	// Assert
+	require.NoError(t, err)
+	require.NotNil(t, response)
+	mockImages.AssertExpectations(t)
+}
+
+// TestTransformFile_NotAnImage tests that transforming a file that is not an image is a bad request
+func TestTransformFile_NotAnImage(t *testing.T) {
+	// Arrange
+	mockImages := new(MockImageService)
+	handler := NewFileHandler(new(MockStorageService), mockImages, CacheControl{})
+	ctx := context.Background()
+
+	mockImages.On("Transform", ctx, testFileID, mock.Anything).
+		Return(nil, int64(0), "", domainErrors.NewValidationError("id", "not_an_image", "file is not an image that can be transformed"))
+
+	// Act
+	response, err := handler.TransformFile(ctx, &dto.TransformFileRequest{ID: testFileID, Width: 200, Fit: "contain"})
+
+	// Assert
+	assert.Nil(t, response)
+	var humaErr huma.StatusError
+	require.True(t, errors.As(err, &humaErr))
+	assert.Equal(t, 400, humaErr.GetStatus())
+}
+
+// TestMapToImageInfoDTO tests that derivatives link to the transform that serves them
+func TestMapToImageInfoDTO(t *testing.T) {
+	// Arrange
+	info := &entities.ImageInfo{
+		Width:         1200,
+		Height:        800,
+		BlurHash:      "LKO2?U%2Tw=w",
+		DominantColor: "#aa3311",
+		Derivatives: []entities.ImageDerivative{{
+			Name:      "thumb",
+			Transform: entities.ImageTransform{Width: 200, Height: 200, Fit: entities.ImageFitCover, Format: entities.ImageFormatWebP},
+			Width:     200,
+			Height:    200,
+			Size:      5120,
+		}},
+	}
+
+	// Act
+	result := mapToImageInfoDTO(testFileID, info)
+
+	// Assert
+	require.NotNil(t, result)
+	assert.Equal(t, 1200, result.Width)
+	assert.Equal(t, "#aa3311", result.DominantColor)
+	require.Len(t, result.Derivatives, 1)
+	assert.Equal(t, "webp", result.Derivatives[0].Format)
+	assert.Equal(t, "/files/"+testFileID.String()+"/transform?fit=cover&format=webp&h=200&w=200", result.Derivatives[0].URL)
+	assert.Nil(t, mapToImageInfoDTO(testFileID, nil))
+}
+
 func TestMapToFileMetadataDTO(t *testing.T) {
 	// Arrange
 	uploadedAt := time.Now()
 	metadata := &entities.FileMetadata{
-		ID:          "test-id-123",
+		ID:          testFileID,
 		FileName:    "test.txt",
 		Bucket:      "test-bucket",
 		Size:        1024,
@@ -363,7 +856,7 @@ func TestMapToFileMetadataDTO(t *testing.T) {
 	result := mapToFileMetadataDTO(metadata)
 
 	// Assert
-	assert.Equal(t, metadata.ID, result.ID)
+	assert.Equal(t, metadata.ID.String(), result.ID)
 	assert.Equal(t, metadata.FileName, result.FileName)
 	assert.Equal(t, metadata.Bucket, result.Bucket)
 	assert.Equal(t, metadata.Size, result.Size)
@@ -371,55 +864,3 @@ func TestMapToFileMetadataDTO(t *testing.T) {
 	assert.Equal(t, metadata.URL, result.URL)
 	assert.Equal(t, metadata.UploadedAt, result.UploadedAt)
 }
-
-// TestExtractBoundary tests the boundary extraction helper
-func TestExtractBoundary(t *testing.T) {
-	tests := []struct {
-		name     string
-		data     string
-		expected string
-	}{
-		{
-			name:     "Valid boundary",
-			data:     "--WebKitFormBoundary123\r\nContent-Disposition: form-data",
-			expected: "WebKitFormBoundary123",
-		},
-		{
-			name:     "Empty data",
-			data:     "",
-			expected: "",
-		},
-		{
-			name:     "No boundary",
-			data:     "some random data",
-			expected: "",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := extractBoundary([]byte(tt.data))
-			assert.Equal(t, tt.expected, result)
-		})
-	}
-}
-
-// Helper function to create a complete multipart request for debugging
-func createCompleteMultipartRequest(file []byte, fileName, bucket string) string {
-	var buf bytes.Buffer
-	writer := multipart.NewWriter(&buf)
-
-	part, _ := writer.CreateFormFile("file", fileName)
-	part.Write(file)
-
-	if fileName != "" {
-		writer.WriteField("file_name", fileName)
-	}
-	if bucket != "" {
-		writer.WriteField("bucket", bucket)
-	}
-
-	writer.Close()
-
-	return fmt.Sprintf("Content-Type: %s\r\n\r\n%s", writer.FormDataContentType(), buf.String())
-}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"example.com/go-yippi/internal/adapters/api/dto"
+	"example.com/go-yippi/internal/domain/entities"
+	"example.com/go-yippi/internal/domain/ports"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// HealthHandler handles the liveness and readiness probes of orchestrators
+type HealthHandler struct {
+	service ports.HealthService
+}
+
+func NewHealthHandler(service ports.HealthService) *HealthHandler {
+	return &HealthHandler{service: service}
+}
+
+// RegisterRoutes registers the health routes with Huma
+func (h *HealthHandler) RegisterRoutes(api huma.API) {
+	// Liveness does not depend on anything, so that instances are not restarted while a dependency is down
+	huma.Register(api, huma.Operation{
+		OperationID: "liveness",
+		Method:      http.MethodGet,
+		Path:        "/healthz",
+		Summary:     "Check liveness",
+		Description: "Answers as long as the process can serve requests; dependencies are not checked",
+		Tags:        []string{"Health"},
+	}, h.Liveness)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "readiness",
+		Method:        http.MethodGet,
+		Path:          "/readyz",
+		Summary:       "Check readiness",
+		Description:   "Checks the database, its migrations and storage, and answers with 503 if any of them is down",
+		Tags:          []string{"Health"},
+		DefaultStatus: http.StatusOK,
+		Responses: map[string]*huma.Response{
+			"503": {Description: "A dependency is down"},
+		},
+	}, h.Readiness)
+}
+
+// Liveness handles GET /healthz
+func (h *HealthHandler) Liveness(ctx context.Context, input *struct{}) (*dto.HealthResponse, error) {
+	return &dto.HealthResponse{
+		Status:       http.StatusOK,
+		CacheControl: "no-store",
+		Body:         dto.HealthDTO{Status: string(entities.HealthUp)},
+	}, nil
+}
+
+// Readiness handles GET /readyz
+func (h *HealthHandler) Readiness(ctx context.Context, input *dto.ReadinessRequest) (*dto.HealthResponse, error) {
+	report := h.service.Ready(ctx)
+
+	resp := &dto.HealthResponse{
+		Status:       http.StatusOK,
+		CacheControl: "no-store",
+		Body:         dto.HealthDTO{Status: string(report.Status)},
+	}
+	if report.Status != entities.HealthUp {
+		resp.Status = http.StatusServiceUnavailable
+	}
+	if input.Verbose {
+		for _, check := range report.Checks {
+			resp.Body.Checks = append(resp.Body.Checks, dto.HealthCheckDTO{
+				Name:      check.Name,
+				Status:    string(check.Status),
+				LatencyMs: float64(check.Latency) / float64(time.Millisecond),
+				Error:     checkError(check),
+				CheckedAt: check.CheckedAt,
+			})
+		}
+	}
+	return resp, nil
+}
+
+// checkError is the message listed for a failed check. Probes are not authenticated, so the error itself,
+// which may name hosts, buckets or users, is only logged by the health service.
+func checkError(check entities.HealthCheckResult) string {
+	switch {
+	case check.Status == entities.HealthUp:
+		return ""
+	case check.TimedOut:
+		return "timed out"
+	default:
+		return "failed"
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"example.com/go-yippi/internal/adapters/api/dto"
+	"example.com/go-yippi/internal/domain/entities"
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubHealthService reports the same readiness every time
+type stubHealthService struct {
+	report *entities.HealthReport
+}
+
+func (s *stubHealthService) Ready(ctx context.Context) *entities.HealthReport {
+	return s.report
+}
+
+// TestHealthHandler tests that readiness is answered with 503 while a dependency is down, that checks are
+// only listed in verbose mode, without the errors they failed with, and that liveness does not depend on them
+func TestHealthHandler(t *testing.T) {
+	// Arrange
+	checkedAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	service := &stubHealthService{report: &entities.HealthReport{
+		Status: entities.HealthDown,
+		Checks: []entities.HealthCheckResult{
+			{Name: "database", Status: entities.HealthUp, Latency: 1500 * time.Microsecond, CheckedAt: checkedAt},
+			{Name: "storage", Status: entities.HealthDown, Latency: 2 * time.Second, Error: "context deadline exceeded", TimedOut: true, CheckedAt: checkedAt},
+			{Name: "migrations", Status: entities.HealthDown, Error: "dial tcp db.internal:5432: connection refused", CheckedAt: checkedAt},
+		},
+	}}
+	_, api := humatest.New(t)
+	NewHealthHandler(service).RegisterRoutes(api)
+
+	// Act
+	live := api.Get("/healthz")
+	ready := api.Get("/readyz")
+	verbose := api.Get("/readyz?verbose=true")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, live.Code)
+	assert.JSONEq(t, `{"status":"up"}`, withoutSchema(t, live.Body.Bytes()))
+
+	assert.Equal(t, http.StatusServiceUnavailable, ready.Code)
+	assert.Equal(t, "no-store", ready.Header().Get("Cache-Control"))
+	assert.JSONEq(t, `{"status":"down"}`, withoutSchema(t, ready.Body.Bytes()))
+
+	var body dto.HealthDTO
+	require.NoError(t, json.Unmarshal(verbose.Body.Bytes(), &body))
+	require.Len(t, body.Checks, 3)
+	assert.Equal(t, 1.5, body.Checks[0].LatencyMs)
+	assert.Equal(t, "storage", body.Checks[1].Name)
+	assert.Equal(t, "down", body.Checks[1].Status)
+	assert.Equal(t, "timed out", body.Checks[1].Error)
+	assert.Equal(t, checkedAt, body.Checks[1].CheckedAt)
+	assert.Equal(t, "failed", body.Checks[2].Error)
+	assert.NotContains(t, verbose.Body.String(), "db.internal")
+}
+
+// withoutSchema drops the $schema link Huma adds to response bodies
+func withoutSchema(t *testing.T, body []byte) string {
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(body, &fields))
+	delete(fields, "$schema")
+	out, err := json.Marshal(fields)
+	require.NoError(t, err)
+	return string(out)
+}
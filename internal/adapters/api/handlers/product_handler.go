@@ -2,10 +2,11 @@ package handlers
 
 import (
 	"context"
-	"errors"
 	"net/http"
 
 	"example.com/go-yippi/internal/adapters/api/dto"
+	"example.com/go-yippi/internal/adapters/api/middleware"
+	"example.com/go-yippi/internal/adapters/api/problem"
 	"example.com/go-yippi/internal/domain/entities"
 	domainErrors "example.com/go-yippi/internal/domain/errors"
 	"example.com/go-yippi/internal/domain/ports"
@@ -32,6 +33,8 @@ func (h *ProductHandler) RegisterRoutes(api huma.API) {
 		Summary:     "Create a new product",
 		Description: "Creates a new product with SKU, name, price, and shipping details",
 		Tags:        []string{"Products"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequirePermission(entities.PermissionCatalogWrite),
 		Errors:      []int{http.StatusBadRequest, http.StatusConflict, http.StatusInternalServerError},
 	}, h.CreateProduct)
 
@@ -98,6 +101,8 @@ func (h *ProductHandler) RegisterRoutes(api huma.API) {
 		Summary:     "Update a product",
 		Description: "Updates an existing product's information",
 		Tags:        []string{"Products"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequirePermission(entities.PermissionCatalogWrite),
 		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
 	}, h.UpdateProduct)
 
@@ -109,6 +114,8 @@ func (h *ProductHandler) RegisterRoutes(api huma.API) {
 		Summary:     "Publish a product",
 		Description: "Changes product status from draft to published",
 		Tags:        []string{"Products"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequirePermission(entities.PermissionCatalogPublish),
 		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
 	}, h.PublishProduct)
 
@@ -120,6 +127,8 @@ func (h *ProductHandler) RegisterRoutes(api huma.API) {
 		Summary:     "Archive a product",
 		Description: "Changes product status to archived",
 		Tags:        []string{"Products"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequirePermission(entities.PermissionCatalogPublish),
 		Errors:      []int{http.StatusNotFound, http.StatusInternalServerError},
 	}, h.ArchiveProduct)
 
@@ -131,9 +140,64 @@ func (h *ProductHandler) RegisterRoutes(api huma.API) {
 		Summary:       "Delete a product",
 		Description:   "Permanently deletes a product from the system",
 		Tags:          []string{"Products"},
+		Security:      middleware.BearerAuth,
+		Metadata:      middleware.RequirePermission(entities.PermissionCatalogDelete),
 		DefaultStatus: http.StatusNoContent,
 		Errors:        []int{http.StatusNotFound, http.StatusInternalServerError},
 	}, h.DeleteProduct)
+
+	// Attach media
+	huma.Register(api, huma.Operation{
+		OperationID: "attach-product-media",
+		Method:      http.MethodPost,
+		Path:        "/products/{id}/media",
+		Summary:     "Attach media to a product",
+		Description: "Adds a stored file to the end of the product's media gallery",
+		Tags:        []string{"Products"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequirePermission(entities.PermissionCatalogWrite),
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
+	}, h.AttachMedia)
+
+	// Reorder media
+	huma.Register(api, huma.Operation{
+		OperationID: "reorder-product-media",
+		Method:      http.MethodPost,
+		Path:        "/products/{id}/media/reorder",
+		Summary:     "Reorder a product's media",
+		Description: "Puts the product's media gallery in the given order",
+		Tags:        []string{"Products"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequirePermission(entities.PermissionCatalogWrite),
+		Errors:      []int{http.StatusBadRequest, http.StatusInternalServerError},
+	}, h.ReorderMedia)
+
+	// Update media
+	huma.Register(api, huma.Operation{
+		OperationID: "update-product-media",
+		Method:      http.MethodPut,
+		Path:        "/products/{id}/media/{media_id}",
+		Summary:     "Update a product media item",
+		Description: "Updates the alt text and primary flag of a media item",
+		Tags:        []string{"Products"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequirePermission(entities.PermissionCatalogWrite),
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError},
+	}, h.UpdateMedia)
+
+	// Detach media
+	huma.Register(api, huma.Operation{
+		OperationID:   "detach-product-media",
+		Method:        http.MethodDelete,
+		Path:          "/products/{id}/media/{media_id}",
+		Summary:       "Detach media from a product",
+		Description:   "Removes a media item from the product's gallery; the stored file is kept",
+		Tags:          []string{"Products"},
+		Security:      middleware.BearerAuth,
+		Metadata:      middleware.RequirePermission(entities.PermissionCatalogWrite),
+		DefaultStatus: http.StatusNoContent,
+		Errors:        []int{http.StatusNotFound, http.StatusInternalServerError},
+	}, h.DetachMedia)
 }
 
 func (h *ProductHandler) CreateProduct(ctx context.Context, input *dto.CreateProductRequest) (*dto.ProductResponse, error) {
@@ -177,7 +241,7 @@ func (h *ProductHandler) CreateProduct(ctx context.Context, input *dto.CreatePro
 	if input.Body.CategoryID != nil && *input.Body.CategoryID != "" {
 		categoryUUID, err := uuid.Parse(*input.Body.CategoryID)
 		if err != nil {
-			return nil, huma.Error400BadRequest("Invalid category_id UUID format", err)
+			return nil, problem.FromError(domainErrors.NewValidationError("category_id", "invalid_uuid", "Invalid category_id UUID format"))
 		}
 		product.CategoryID = &categoryUUID
 	}
@@ -186,20 +250,14 @@ func (h *ProductHandler) CreateProduct(ctx context.Context, input *dto.CreatePro
 	if input.Body.BrandID != nil && *input.Body.BrandID != "" {
 		brandUUID, err := uuid.Parse(*input.Body.BrandID)
 		if err != nil {
-			return nil, huma.Error400BadRequest("Invalid brand_id UUID format", err)
+			return nil, problem.FromError(domainErrors.NewValidationError("brand_id", "invalid_uuid", "Invalid brand_id UUID format"))
 		}
 		product.BrandID = &brandUUID
 	}
 
 	err := h.service.CreateProduct(ctx, product)
 	if err != nil {
-		if errors.Is(err, domainErrors.ErrInvalidInput) {
-			return nil, huma.Error400BadRequest("Invalid input", err)
-		}
-		if errors.Is(err, domainErrors.ErrDuplicateEntry) {
-			return nil, huma.Error409Conflict("Product with this SKU or slug already exists")
-		}
-		return nil, huma.Error500InternalServerError("Failed to create product", err)
+		return nil, problem.FromError(err)
 	}
 
 	return h.mapToResponse(product), nil
@@ -243,10 +301,7 @@ func (h *ProductHandler) QueryProducts(ctx context.Context, input *dto.QueryProd
 	// Call service
 	result, err := h.service.QueryProducts(ctx, params)
 	if err != nil {
-		if errors.Is(err, domainErrors.ErrInvalidInput) {
-			return nil, huma.Error400BadRequest("Invalid query parameters", err)
-		}
-		return nil, huma.Error500InternalServerError("Failed to query products", err)
+		return nil, problem.FromError(err)
 	}
 
 	// Convert to DTO response
@@ -267,6 +322,7 @@ func (h *ProductHandler) QueryProducts(ctx context.Context, input *dto.QueryProd
 			Height:      product.Height,
 			ImageURLs:   product.ImageURLs,
 			Status:      string(product.Status),
+			Media:       mapToProductMediaDTOs(product.Media),
 			CreatedAt:   product.CreatedAt,
 			UpdatedAt:   product.UpdatedAt,
 		}
@@ -299,10 +355,7 @@ func (h *ProductHandler) QueryProducts(ctx context.Context, input *dto.QueryProd
 func (h *ProductHandler) GetProduct(ctx context.Context, input *dto.GetProductRequest) (*dto.ProductResponse, error) {
 	product, err := h.service.GetProduct(ctx, input.ID)
 	if err != nil {
-		if errors.Is(err, domainErrors.ErrNotFound) {
-			return nil, huma.Error404NotFound("Product not found")
-		}
-		return nil, huma.Error500InternalServerError("Failed to get product", err)
+		return nil, problem.FromError(err)
 	}
 
 	return h.mapToResponse(product), nil
@@ -311,13 +364,7 @@ func (h *ProductHandler) GetProduct(ctx context.Context, input *dto.GetProductRe
 func (h *ProductHandler) GetProductBySKU(ctx context.Context, input *dto.GetProductBySKURequest) (*dto.ProductResponse, error) {
 	product, err := h.service.GetProductBySKU(ctx, input.SKU)
 	if err != nil {
-		if errors.Is(err, domainErrors.ErrNotFound) {
-			return nil, huma.Error404NotFound("Product not found")
-		}
-		if errors.Is(err, domainErrors.ErrInvalidInput) {
-			return nil, huma.Error400BadRequest("Invalid SKU", err)
-		}
-		return nil, huma.Error500InternalServerError("Failed to get product", err)
+		return nil, problem.FromError(err)
 	}
 
 	return h.mapToResponse(product), nil
@@ -326,13 +373,7 @@ func (h *ProductHandler) GetProductBySKU(ctx context.Context, input *dto.GetProd
 func (h *ProductHandler) GetProductBySlug(ctx context.Context, input *dto.GetProductBySlugRequest) (*dto.ProductResponse, error) {
 	product, err := h.service.GetProductBySlug(ctx, input.Slug)
 	if err != nil {
-		if errors.Is(err, domainErrors.ErrNotFound) {
-			return nil, huma.Error404NotFound("Product not found")
-		}
-		if errors.Is(err, domainErrors.ErrInvalidInput) {
-			return nil, huma.Error400BadRequest("Invalid slug", err)
-		}
-		return nil, huma.Error500InternalServerError("Failed to get product", err)
+		return nil, problem.FromError(err)
 	}
 
 	return h.mapToResponse(product), nil
@@ -341,7 +382,7 @@ func (h *ProductHandler) GetProductBySlug(ctx context.Context, input *dto.GetPro
 func (h *ProductHandler) ListProductsByStatus(ctx context.Context, input *dto.ListProductsByStatusRequest) (*dto.ListProductsResponse, error) {
 	products, err := h.service.ListProductsByStatus(ctx, entities.ProductStatus(input.Status))
 	if err != nil {
-		return nil, huma.Error500InternalServerError("Failed to list products", err)
+		return nil, problem.FromError(err)
 	}
 
 	resp := &dto.ListProductsResponse{}
@@ -361,6 +402,7 @@ func (h *ProductHandler) ListProductsByStatus(ctx context.Context, input *dto.Li
 			Height:      product.Height,
 			ImageURLs:   product.ImageURLs,
 			Status:      string(product.Status),
+			Media:       mapToProductMediaDTOs(product.Media),
 			CreatedAt:   product.CreatedAt,
 			UpdatedAt:   product.UpdatedAt,
 		}
@@ -411,7 +453,7 @@ func (h *ProductHandler) UpdateProduct(ctx context.Context, input *dto.UpdatePro
 	if input.Body.CategoryID != nil && *input.Body.CategoryID != "" {
 		categoryUUID, err := uuid.Parse(*input.Body.CategoryID)
 		if err != nil {
-			return nil, huma.Error400BadRequest("Invalid category_id UUID format", err)
+			return nil, problem.FromError(domainErrors.NewValidationError("category_id", "invalid_uuid", "Invalid category_id UUID format"))
 		}
 		product.CategoryID = &categoryUUID
 	}
@@ -420,23 +462,14 @@ func (h *ProductHandler) UpdateProduct(ctx context.Context, input *dto.UpdatePro
 	if input.Body.BrandID != nil && *input.Body.BrandID != "" {
 		brandUUID, err := uuid.Parse(*input.Body.BrandID)
 		if err != nil {
-			return nil, huma.Error400BadRequest("Invalid brand_id UUID format", err)
+			return nil, problem.FromError(domainErrors.NewValidationError("brand_id", "invalid_uuid", "Invalid brand_id UUID format"))
 		}
 		product.BrandID = &brandUUID
 	}
 
 	err := h.service.UpdateProduct(ctx, product)
 	if err != nil {
-		if errors.Is(err, domainErrors.ErrNotFound) {
-			return nil, huma.Error404NotFound("Product not found")
-		}
-		if errors.Is(err, domainErrors.ErrInvalidInput) {
-			return nil, huma.Error400BadRequest("Invalid input", err)
-		}
-		if errors.Is(err, domainErrors.ErrDuplicateEntry) {
-			return nil, huma.Error409Conflict("Product with this SKU or slug already exists")
-		}
-		return nil, huma.Error500InternalServerError("Failed to update product", err)
+		return nil, problem.FromError(err)
 	}
 
 	return h.mapToResponse(product), nil
@@ -445,18 +478,12 @@ func (h *ProductHandler) UpdateProduct(ctx context.Context, input *dto.UpdatePro
 func (h *ProductHandler) PublishProduct(ctx context.Context, input *dto.PublishProductRequest) (*dto.ProductResponse, error) {
 	err := h.service.PublishProduct(ctx, input.ID)
 	if err != nil {
-		if errors.Is(err, domainErrors.ErrNotFound) {
-			return nil, huma.Error404NotFound("Product not found")
-		}
-		if errors.Is(err, domainErrors.ErrInvalidInput) {
-			return nil, huma.Error400BadRequest("Cannot publish this product", err)
-		}
-		return nil, huma.Error500InternalServerError("Failed to publish product", err)
+		return nil, problem.FromError(err)
 	}
 
 	product, err := h.service.GetProduct(ctx, input.ID)
 	if err != nil {
-		return nil, huma.Error500InternalServerError("Failed to get updated product", err)
+		return nil, problem.FromError(err)
 	}
 
 	return h.mapToResponse(product), nil
@@ -465,15 +492,12 @@ func (h *ProductHandler) PublishProduct(ctx context.Context, input *dto.PublishP
 func (h *ProductHandler) ArchiveProduct(ctx context.Context, input *dto.ArchiveProductRequest) (*dto.ProductResponse, error) {
 	err := h.service.ArchiveProduct(ctx, input.ID)
 	if err != nil {
-		if errors.Is(err, domainErrors.ErrNotFound) {
-			return nil, huma.Error404NotFound("Product not found")
-		}
-		return nil, huma.Error500InternalServerError("Failed to archive product", err)
+		return nil, problem.FromError(err)
 	}
 
 	product, err := h.service.GetProduct(ctx, input.ID)
 	if err != nil {
-		return nil, huma.Error500InternalServerError("Failed to get updated product", err)
+		return nil, problem.FromError(err)
 	}
 
 	return h.mapToResponse(product), nil
@@ -482,10 +506,7 @@ func (h *ProductHandler) ArchiveProduct(ctx context.Context, input *dto.ArchiveP
 func (h *ProductHandler) DeleteProduct(ctx context.Context, input *dto.DeleteProductRequest) (*struct{}, error) {
 	err := h.service.DeleteProduct(ctx, input.ID)
 	if err != nil {
-		if errors.Is(err, domainErrors.ErrNotFound) {
-			return nil, huma.Error404NotFound("Product not found")
-		}
-		return nil, huma.Error500InternalServerError("Failed to delete product", err)
+		return nil, problem.FromError(err)
 	}
 
 	return &struct{}{}, nil
@@ -516,7 +537,100 @@ func (h *ProductHandler) mapToResponse(product *entities.Product) *dto.ProductRe
 		brandIDStr := product.BrandID.String()
 		resp.Body.BrandID = &brandIDStr
 	}
+	resp.Body.Media = mapToProductMediaDTOs(product.Media)
 	resp.Body.CreatedAt = product.CreatedAt
 	resp.Body.UpdatedAt = product.UpdatedAt
 	return resp
 }
+
+func (h *ProductHandler) AttachMedia(ctx context.Context, input *dto.AttachProductMediaRequest) (*dto.ProductMediaResponse, error) {
+	fileID, err := uuid.Parse(input.Body.FileID)
+	if err != nil {
+		return nil, problem.FromError(domainErrors.NewValidationError("file_id", "invalid_uuid", "Invalid file_id UUID format"))
+	}
+
+	media := &entities.ProductMedia{
+		FileID:    fileID,
+		AltText:   input.Body.AltText,
+		IsPrimary: input.Body.IsPrimary,
+		Type:      entities.MediaType(input.Body.MediaType),
+	}
+
+	err = h.service.AttachMedia(ctx, input.ID, media)
+	if err != nil {
+		return nil, problem.FromError(err)
+	}
+
+	return &dto.ProductMediaResponse{Body: mapToProductMediaDTO(media)}, nil
+}
+
+func (h *ProductHandler) UpdateMedia(ctx context.Context, input *dto.UpdateProductMediaRequest) (*dto.ProductMediaResponse, error) {
+	media := &entities.ProductMedia{
+		ID:        input.MediaID,
+		AltText:   input.Body.AltText,
+		IsPrimary: input.Body.IsPrimary,
+	}
+
+	err := h.service.UpdateMedia(ctx, input.ID, media)
+	if err != nil {
+		return nil, problem.FromError(err)
+	}
+
+	return &dto.ProductMediaResponse{Body: mapToProductMediaDTO(media)}, nil
+}
+
+func (h *ProductHandler) ReorderMedia(ctx context.Context, input *dto.ReorderProductMediaRequest) (*dto.ProductMediaListResponse, error) {
+	mediaIDs := make([]uuid.UUID, len(input.Body.MediaIDs))
+	for i, id := range input.Body.MediaIDs {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return nil, problem.FromError(domainErrors.NewValidationError("media_ids", "invalid_uuid", "Invalid media_ids UUID format"))
+		}
+		mediaIDs[i] = parsed
+	}
+
+	gallery, err := h.service.ReorderMedia(ctx, input.ID, mediaIDs)
+	if err != nil {
+		return nil, problem.FromError(err)
+	}
+
+	resp := &dto.ProductMediaListResponse{}
+	resp.Body.Media = mapToProductMediaDTOs(gallery)
+	return resp, nil
+}
+
+func (h *ProductHandler) DetachMedia(ctx context.Context, input *dto.DetachProductMediaRequest) (*struct{}, error) {
+	err := h.service.DetachMedia(ctx, input.ID, input.MediaID)
+	if err != nil {
+		return nil, problem.FromError(err)
+	}
+
+	return &struct{}{}, nil
+}
+
+// mapToProductMediaDTOs converts a product's gallery to DTOs
+func mapToProductMediaDTOs(gallery []*entities.ProductMedia) []dto.ProductMediaDTO {
+	media := make([]dto.ProductMediaDTO, len(gallery))
+	for i, m := range gallery {
+		media[i] = mapToProductMediaDTO(m)
+	}
+	return media
+}
+
+// mapToProductMediaDTO converts a product media item to its DTO
+func mapToProductMediaDTO(media *entities.ProductMedia) dto.ProductMediaDTO {
+	result := dto.ProductMediaDTO{
+		ID:        media.ID.String(),
+		FileID:    media.FileID.String(),
+		Position:  media.Position,
+		AltText:   media.AltText,
+		IsPrimary: media.IsPrimary,
+		MediaType: string(media.Type),
+	}
+	if media.File != nil {
+		result.URL = media.File.URL
+		result.ContentType = media.File.ContentType
+		result.Image = mapToImageInfoDTO(media.FileID, media.File.Image)
+	}
+	return result
+}
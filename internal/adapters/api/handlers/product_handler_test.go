@@ -6,9 +6,11 @@ import (
 	"testing"
 
 	"example.com/go-yippi/internal/adapters/api/dto"
+	"example.com/go-yippi/internal/adapters/persistence/catalogtest"
 	"example.com/go-yippi/internal/domain/entities"
 	domainErrors "example.com/go-yippi/internal/domain/errors"
 	"github.com/danielgtaylor/huma/v2"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -100,6 +102,42 @@ func (m *MockProductService) QueryProducts(ctx context.Context, params *entities
 	return args.Get(0).(*entities.QueryResult), args.Error(1)
 }
 
+func (m *MockProductService) ImportProducts(ctx context.Context, products []*entities.Product) error {
+	args := m.Called(ctx, products)
+	return args.Error(0)
+}
+
+func (m *MockProductService) ProductStats(ctx context.Context) (*entities.ProductStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.ProductStats), args.Error(1)
+}
+
+func (m *MockProductService) AttachMedia(ctx context.Context, productID int, media *entities.ProductMedia) error {
+	args := m.Called(ctx, productID, media)
+	return args.Error(0)
+}
+
+func (m *MockProductService) UpdateMedia(ctx context.Context, productID int, media *entities.ProductMedia) error {
+	args := m.Called(ctx, productID, media)
+	return args.Error(0)
+}
+
+func (m *MockProductService) ReorderMedia(ctx context.Context, productID int, mediaIDs []uuid.UUID) ([]*entities.ProductMedia, error) {
+	args := m.Called(ctx, productID, mediaIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.ProductMedia), args.Error(1)
+}
+
+func (m *MockProductService) DetachMedia(ctx context.Context, productID int, mediaID uuid.UUID) error {
+	args := m.Called(ctx, productID, mediaID)
+	return args.Error(0)
+}
+
 // TestCreateProduct_Success tests successful product creation with all required fields
 func TestCreateProduct_Success(t *testing.T) {
 	// Arrange
@@ -210,7 +248,7 @@ func TestCreateProduct_ValidationError(t *testing.T) {
 	input.Body.Name = "Test Product"
 	input.Body.Price = 99.99
 
-	validationErr := domainErrors.NewValidationError("sku", "SKU is required")
+	validationErr := domainErrors.NewValidationError("sku", "required", "SKU is required")
 	mockService.On("CreateProduct", ctx, mock.Anything).Return(validationErr)
 
 	// Act
@@ -253,7 +291,7 @@ func TestCreateProduct_DuplicateError(t *testing.T) {
 	var humaErr huma.StatusError
 	require.True(t, errors.As(err, &humaErr), "Error should be a Huma status error")
 	assert.Equal(t, 409, humaErr.GetStatus(), "Should return 409 Conflict")
-	assert.Contains(t, humaErr.Error(), "Product with this SKU or slug already exists")
+	assert.Contains(t, humaErr.Error(), "Product with sku 'DUPLICATE-SKU' already exists")
 	mockService.AssertExpectations(t)
 }
 
@@ -284,7 +322,7 @@ func TestCreateProduct_InternalServerError(t *testing.T) {
 	var humaErr huma.StatusError
 	require.True(t, errors.As(err, &humaErr), "Error should be a Huma status error")
 	assert.Equal(t, 500, humaErr.GetStatus(), "Should return 500 Internal Server Error")
-	assert.Contains(t, humaErr.Error(), "Failed to create product")
+	assert.NotContains(t, humaErr.Error(), "database connection failed", "Should not leak the cause")
 	mockService.AssertExpectations(t)
 }
 
@@ -387,3 +425,101 @@ func TestCreateProduct_EntityMappingComplete(t *testing.T) {
 	assert.Equal(t, 299.99, response.Body.Price)
 	mockService.AssertExpectations(t)
 }
+
+// TestGetProduct_RendersMedia tests that the product's gallery is part of the response
+func TestGetProduct_RendersMedia(t *testing.T) {
+	// Arrange
+	mockService := new(MockProductService)
+	handler := NewProductHandler(mockService)
+	ctx := context.Background()
+
+	media := &entities.ProductMedia{
+		ID:        uuid.New(),
+		FileID:    uuid.New(),
+		IsPrimary: true,
+		Type:      entities.MediaTypeImage,
+		AltText:   "Front view",
+		File:      &entities.FileMetadata{ContentType: "image/png", URL: "/files/download?file_name=shirt.png"},
+	}
+	product := &entities.Product{ID: 1, SKU: "TEST-001", Media: []*entities.ProductMedia{media}}
+	mockService.On("GetProduct", ctx, 1).Return(product, nil)
+
+	// Act
+	response, err := handler.GetProduct(ctx, &dto.GetProductRequest{ID: 1})
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, response.Body.Media, 1)
+	assert.Equal(t, dto.ProductMediaDTO{
+		ID:          media.ID.String(),
+		FileID:      media.FileID.String(),
+		AltText:     "Front view",
+		IsPrimary:   true,
+		MediaType:   "image",
+		URL:         "/files/download?file_name=shirt.png",
+		ContentType: "image/png",
+	}, response.Body.Media[0])
+}
+
+// TestAttachMedia_InvalidFileID tests that malformed file IDs are rejected before reaching the service
+func TestAttachMedia_InvalidFileID(t *testing.T) {
+	// Arrange
+	mockService := new(MockProductService)
+	handler := NewProductHandler(mockService)
+	ctx := context.Background()
+
+	input := &dto.AttachProductMediaRequest{ID: 1}
+	input.Body.FileID = "not-a-uuid"
+
+	// Act
+	response, err := handler.AttachMedia(ctx, input)
+
+	// Assert
+	assert.Nil(t, response)
+	var humaErr huma.StatusError
+	require.True(t, errors.As(err, &humaErr))
+	assert.Equal(t, 400, humaErr.GetStatus())
+	mockService.AssertNotCalled(t, "AttachMedia")
+}
+
+// TestQueryProducts_CategoryIncludesDescendants tests that filtering on a category returns the products of
+// its subcategories too, against a seeded catalog
+func TestQueryProducts_CategoryIncludesDescendants(t *testing.T) {
+	catalog := catalogtest.New(t, catalogtest.Options())
+	handler := NewProductHandler(catalog.ProductService)
+	root, otherRoot, leaf := catalog.Categories[0], catalog.Categories[1], catalog.Leaves[0]
+
+	tests := []struct {
+		name     string
+		filter   dto.FilterDTO
+		expected []*entities.Product
+	}{
+		{"root", dto.FilterDTO{Field: "category_id", Operator: "eq", Value: root.ID.String()}, catalog.ProductsUnder(root.ID)},
+		{"leaf", dto.FilterDTO{Field: "category_id", Operator: "eq", Value: leaf.ID.String()}, catalog.ProductsUnder(leaf.ID)},
+		{"roots", dto.FilterDTO{Field: "category_id", Operator: "in", Value: []interface{}{root.ID.String(), otherRoot.ID.String()}},
+			append(catalog.ProductsUnder(root.ID), catalog.ProductsUnder(otherRoot.ID)...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			input := &dto.QueryProductsRequest{Filters: []dto.FilterDTO{tt.filter}, Limit: 100}
+
+			// Act
+			response, err := handler.QueryProducts(catalog.Ctx, input)
+
+			// Assert
+			require.NoError(t, err)
+			require.NotEmpty(t, tt.expected)
+			expected := make([]string, len(tt.expected))
+			for i, product := range tt.expected {
+				expected[i] = product.SKU
+			}
+			actual := make([]string, len(response.Body.Data))
+			for i, item := range response.Body.Data {
+				actual[i] = item.SKU
+			}
+			assert.ElementsMatch(t, expected, actual)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package handlers
+
+import "github.com/gofiber/fiber/v2"
+
+// FiberConfig returns the Fiber configuration the handlers are written for. Request bodies larger than
+// bodyLimit are streamed to the handlers instead of being held in memory, and multipart forms are not parsed
+// upfront, which would spool their files to disk, so uploads are only bounded by the upload limits of the
+// storage service.
+func FiberConfig(bodyLimit int64) fiber.Config {
+	return fiber.Config{
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
+		BodyLimit:                    int(bodyLimit),
+	}
+}
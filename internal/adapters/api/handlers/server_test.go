@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"example.com/go-yippi/internal/domain/entities"
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humafiber"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFiberConfig_StreamsUploads tests that uploads reach the handler in full as a stream, whether they are
+// larger than the body limit or not
+func TestFiberConfig_StreamsUploads(t *testing.T) {
+	const bodyLimit = 4 << 20
+	for _, size := range []int{1 << 10, bodyLimit + 1<<20} {
+		// Arrange
+		mockService := new(MockStorageService)
+		app := fiber.New(FiberConfig(bodyLimit))
+		NewFileHandler(mockService, new(MockImageService), CacheControl{}).RegisterRoutes(humafiber.New(app, huma.DefaultConfig("Test API", "1.0.0")))
+
+		fileContent := bytes.Repeat([]byte("x"), size)
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, err := writer.CreateFormFile("file", "large.bin")
+		require.NoError(t, err)
+		_, err = part.Write(fileContent)
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		var received int64
+		mockService.On("UploadFile", mock.Anything, uploadMatching("", "large.bin", "")).
+			Run(func(args mock.Arguments) {
+				received, _ = io.Copy(io.Discard, args.Get(1).(*entities.FileUpload).Content)
+			}).
+			Return(&entities.FileMetadata{FileName: "large.bin", Size: int64(len(fileContent))}, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/files/upload", &body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+
+		// Act
+		resp, err := app.Test(req, -1)
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, size)
+		assert.Equal(t, int64(len(fileContent)), received, size)
+		mockService.AssertExpectations(t)
+	}
+}
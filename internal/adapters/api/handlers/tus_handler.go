@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"example.com/go-yippi/internal/adapters/api/dto"
+	"example.com/go-yippi/internal/adapters/api/middleware"
+	"example.com/go-yippi/internal/adapters/api/problem"
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"example.com/go-yippi/internal/domain/ports"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+const (
+	// tusVersion is the version of the tus protocol the handler speaks
+	tusVersion = "1.0.0"
+	// tusExtensions are the protocol extensions the handler supports
+	tusExtensions = "creation,expiration,termination"
+	// tusContentType is the content type of chunks
+	tusContentType = "application/offset+octet-stream"
+)
+
+// TusHandler handles resumable uploads with the tus protocol (https://tus.io/protocols/resumable-upload)
+type TusHandler struct {
+	service ports.ResumableUploadService
+	// maxSize is the largest upload announced to clients, 0 if unlimited
+	maxSize int64
+}
+
+// NewTusHandler creates a new tus handler
+func NewTusHandler(service ports.ResumableUploadService, maxSize int64) *TusHandler {
+	return &TusHandler{service: service, maxSize: maxSize}
+}
+
+// RegisterRoutes registers all tus routes with Huma
+func (h *TusHandler) RegisterRoutes(api huma.API) {
+	// Get server capabilities
+	huma.Register(api, huma.Operation{
+		OperationID:   "tus-options",
+		Method:        http.MethodOptions,
+		Path:          "/files/tus",
+		Summary:       "Get resumable upload capabilities",
+		Description:   "Returns the tus protocol version, extensions and maximum upload size the server supports",
+		Tags:          []string{"Resumable uploads"},
+		DefaultStatus: http.StatusNoContent,
+	}, h.Options)
+
+	// Create upload
+	huma.Register(api, huma.Operation{
+		OperationID:   "create-tus-upload",
+		Method:        http.MethodPost,
+		Path:          "/files/tus",
+		Summary:       "Create a resumable upload",
+		Description:   "Creates a tus upload for a file of Upload-Length bytes, named by the filename in Upload-Metadata. Send its content with PATCH requests to the returned Location.",
+		Tags:          []string{"Resumable uploads"},
+		Security:      middleware.BearerAuth,
+		Metadata:      middleware.RequirePermission(entities.PermissionFilesWrite),
+		DefaultStatus: http.StatusCreated,
+		Errors:        []int{http.StatusBadRequest, http.StatusPreconditionFailed, http.StatusInternalServerError},
+	}, h.CreateUpload)
+
+	// Get upload offset
+	huma.Register(api, huma.Operation{
+		OperationID: "get-tus-upload",
+		Method:      http.MethodHead,
+		Path:        "/files/tus/{id}",
+		Summary:     "Get the offset of a resumable upload",
+		Description: "Returns how many bytes of the upload have been received, i.e. the offset to resume at",
+		Tags:        []string{"Resumable uploads"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequirePermission(entities.PermissionFilesWrite),
+		Errors:      []int{http.StatusNotFound, http.StatusPreconditionFailed, http.StatusInternalServerError},
+	}, h.GetUpload)
+
+	// Send a chunk
+	huma.Register(api, huma.Operation{
+		OperationID: "patch-tus-upload",
+		Method:      http.MethodPatch,
+		Path:        "/files/tus/{id}",
+		Summary:     "Send a chunk of a resumable upload",
+		Description: "Appends the request body at Upload-Offset, which must be the offset of the upload. Once all bytes have been received the file is stored like any other upload and Content-Location points at it.",
+		Tags:        []string{"Resumable uploads"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequirePermission(entities.PermissionFilesWrite),
+		RequestBody: &huma.RequestBody{
+			Required: true,
+			Content: map[string]*huma.MediaType{
+				tusContentType: {Schema: &huma.Schema{Type: huma.TypeString, Format: "binary"}},
+			},
+		},
+		DefaultStatus: http.StatusNoContent,
+		Errors:        []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed, http.StatusUnsupportedMediaType, http.StatusInternalServerError},
+	}, h.PatchUpload)
+
+	// Terminate upload
+	huma.Register(api, huma.Operation{
+		OperationID:   "terminate-tus-upload",
+		Method:        http.MethodDelete,
+		Path:          "/files/tus/{id}",
+		Summary:       "Terminate a resumable upload",
+		Description:   "Discards the upload and the content received for it",
+		Tags:          []string{"Resumable uploads"},
+		Security:      middleware.BearerAuth,
+		Metadata:      middleware.RequirePermission(entities.PermissionFilesWrite),
+		DefaultStatus: http.StatusNoContent,
+		Errors:        []int{http.StatusNotFound, http.StatusPreconditionFailed, http.StatusInternalServerError},
+	}, h.TerminateUpload)
+}
+
+// Options handles requests for the server's capabilities
+func (h *TusHandler) Options(ctx context.Context, input *dto.TusOptionsRequest) (*dto.TusOptionsResponse, error) {
+	response := &dto.TusOptionsResponse{
+		TusResumable: tusVersion,
+		TusVersion:   tusVersion,
+		TusExtension: tusExtensions,
+	}
+	if h.maxSize > 0 {
+		response.TusMaxSize = &h.maxSize
+	}
+	return response, nil
+}
+
+// CreateUpload handles requests to create an upload
+func (h *TusHandler) CreateUpload(ctx context.Context, input *dto.CreateTusUploadRequest) (*dto.CreateTusUploadResponse, error) {
+	if err := checkTusResumable(input.TusResumable); err != nil {
+		return nil, err
+	}
+
+	metadata, err := parseUploadMetadata(input.UploadMetadata)
+	if err != nil {
+		return nil, tusError(err)
+	}
+
+	upload := &entities.ResumableUpload{
+		Bucket:      metadata["bucket"],
+		FileName:    metadata["filename"],
+		ContentType: metadata["filetype"],
+		SHA256:      metadata["sha256"],
+		Length:      input.UploadLength,
+		Metadata:    metadata,
+	}
+	err = h.service.CreateUpload(ctx, upload)
+	if err != nil {
+		return nil, tusError(err)
+	}
+
+	return &dto.CreateTusUploadResponse{
+		TusResumable:  tusVersion,
+		Location:      "/files/tus/" + upload.ID.String(),
+		UploadExpires: upload.ExpiresAt,
+	}, nil
+}
+
+// GetUpload handles requests for the offset of an upload
+func (h *TusHandler) GetUpload(ctx context.Context, input *dto.TusUploadRequest) (*dto.TusUploadResponse, error) {
+	if err := checkTusResumable(input.TusResumable); err != nil {
+		return nil, err
+	}
+
+	upload, err := h.service.GetUpload(ctx, input.ID)
+	if err != nil {
+		return nil, tusError(err)
+	}
+
+	return &dto.TusUploadResponse{
+		TusResumable:   tusVersion,
+		UploadOffset:   upload.Offset,
+		UploadLength:   upload.Length,
+		UploadMetadata: formatUploadMetadata(upload.Metadata),
+		UploadExpires:  upload.ExpiresAt,
+		// The offset changes with every chunk
+		CacheControl: "no-store",
+	}, nil
+}
+
+// PatchUpload handles chunks of an upload and streams them to storage
+func (h *TusHandler) PatchUpload(ctx context.Context, input *dto.PatchTusUploadRequest) (*dto.PatchTusUploadResponse, error) {
+	if err := checkTusResumable(input.TusResumable); err != nil {
+		return nil, err
+	}
+	if mediaType, _, _ := mime.ParseMediaType(input.ContentType); mediaType != tusContentType {
+		return nil, huma.ErrorWithHeaders(
+			problem.New(http.StatusUnsupportedMediaType, "Content-Type must be "+tusContentType),
+			http.Header{"Tus-Resumable": {tusVersion}},
+		)
+	}
+
+	upload, file, err := h.service.WriteChunk(ctx, input.ID, input.UploadOffset, input.Content)
+	if err != nil {
+		return nil, tusError(err)
+	}
+
+	response := &dto.PatchTusUploadResponse{
+		TusResumable: tusVersion,
+		UploadOffset: upload.Offset,
+	}
+	if file != nil {
+		response.ContentLocation = "/files/" + file.ID.String()
+	} else {
+		response.UploadExpires = upload.ExpiresAt
+	}
+	return response, nil
+}
+
+// TerminateUpload handles requests to discard an upload
+func (h *TusHandler) TerminateUpload(ctx context.Context, input *dto.TusUploadRequest) (*dto.TerminateTusUploadResponse, error) {
+	if err := checkTusResumable(input.TusResumable); err != nil {
+		return nil, err
+	}
+
+	err := h.service.TerminateUpload(ctx, input.ID)
+	if err != nil {
+		return nil, tusError(err)
+	}
+
+	return &dto.TerminateTusUploadResponse{TusResumable: tusVersion}, nil
+}
+
+// checkTusResumable rejects requests for a protocol version other than the one the handler speaks
+func checkTusResumable(version string) error {
+	if version == tusVersion {
+		return nil
+	}
+	return huma.ErrorWithHeaders(
+		problem.New(http.StatusPreconditionFailed, "Tus-Resumable must be "+tusVersion),
+		http.Header{"Tus-Resumable": {tusVersion}, "Tus-Version": {tusVersion}},
+	)
+}
+
+// tusError maps an error to a problem with the tus headers; offset conflicts include the upload's offset
+func tusError(err error) error {
+	headers := http.Header{"Tus-Resumable": {tusVersion}}
+	var offsetErr *domainErrors.UploadOffsetError
+	if errors.As(err, &offsetErr) {
+		headers.Set("Upload-Offset", strconv.FormatInt(offsetErr.Offset, 10))
+	}
+	return huma.ErrorWithHeaders(problem.FromError(err), headers)
+}
+
+// parseUploadMetadata parses an Upload-Metadata header: comma-separated pairs of a key and an optional
+// base64-encoded value, separated by a space
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := map[string]string{}
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, encoded, _ := strings.Cut(pair, " ")
+		value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, domainErrors.NewValidationError("upload_metadata", "invalid", fmt.Sprintf("value of %s is not base64-encoded", key))
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
+
+// formatUploadMetadata formats metadata as an Upload-Metadata header, with the keys sorted
+func formatUploadMetadata(metadata map[string]string) string {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key
+		if value := metadata[key]; value != "" {
+			pairs[i] += " " + base64.StdEncoding.EncodeToString([]byte(value))
+		}
+	}
+	return strings.Join(pairs, ",")
+}
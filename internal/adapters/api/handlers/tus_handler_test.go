@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockResumableUploadService is a mock implementation of ResumableUploadService
+type MockResumableUploadService struct {
+	mock.Mock
+}
+
+func (m *MockResumableUploadService) CreateUpload(ctx context.Context, upload *entities.ResumableUpload) error {
+	args := m.Called(ctx, upload)
+	return args.Error(0)
+}
+
+func (m *MockResumableUploadService) GetUpload(ctx context.Context, id uuid.UUID) (*entities.ResumableUpload, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.ResumableUpload), args.Error(1)
+}
+
+func (m *MockResumableUploadService) WriteChunk(ctx context.Context, id uuid.UUID, offset int64, content io.Reader) (*entities.ResumableUpload, *entities.FileMetadata, error) {
+	args := m.Called(ctx, id, offset, content)
+	var upload *entities.ResumableUpload
+	if args.Get(0) != nil {
+		upload = args.Get(0).(*entities.ResumableUpload)
+	}
+	var file *entities.FileMetadata
+	if args.Get(1) != nil {
+		file = args.Get(1).(*entities.FileMetadata)
+	}
+	return upload, file, args.Error(2)
+}
+
+func (m *MockResumableUploadService) TerminateUpload(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func newTusTestAPI(t *testing.T, service *MockResumableUploadService) humatest.TestAPI {
+	_, api := humatest.New(t)
+	NewTusHandler(service, 1000).RegisterRoutes(api)
+	return api
+}
+
+// TestTusOptions tests that the server announces its version, extensions and maximum size
+func TestTusOptions(t *testing.T) {
+	// Arrange
+	api := newTusTestAPI(t, new(MockResumableUploadService))
+
+	// Act
+	resp := api.Do(http.MethodOptions, "/files/tus")
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.Equal(t, "1.0.0", resp.Header().Get("Tus-Version"))
+	assert.Equal(t, "creation,expiration,termination", resp.Header().Get("Tus-Extension"))
+	assert.Equal(t, "1000", resp.Header().Get("Tus-Max-Size"))
+}
+
+// TestTusCreateUpload tests that the metadata is decoded and the upload's location returned
+func TestTusCreateUpload(t *testing.T) {
+	// Arrange
+	mockService := new(MockResumableUploadService)
+	api := newTusTestAPI(t, mockService)
+	id := uuid.New()
+	expires := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	mockService.On("CreateUpload", mock.Anything, mock.MatchedBy(func(u *entities.ResumableUpload) bool {
+		return u.FileName == "videos/intro.mp4" && u.ContentType == "video/mp4" && u.Bucket == "" && u.Length == 500 &&
+			u.Metadata["filename"] == "videos/intro.mp4" && u.Metadata["draft"] == ""
+	})).
+		Run(func(args mock.Arguments) {
+			upload := args.Get(1).(*entities.ResumableUpload)
+			upload.ID = id
+			upload.ExpiresAt = expires
+		}).
+		Return(nil)
+
+	// Act
+	resp := api.Post("/files/tus",
+		"Tus-Resumable: 1.0.0",
+		"Upload-Length: 500",
+		"Upload-Metadata: filename dmlkZW9zL2ludHJvLm1wNA==,filetype dmlkZW8vbXA0,draft",
+	)
+
+	// Assert
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(t, "/files/tus/"+id.String(), resp.Header().Get("Location"))
+	assert.Equal(t, "Wed, 01 May 2024 12:00:00 GMT", resp.Header().Get("Upload-Expires"))
+	assert.Equal(t, "1.0.0", resp.Header().Get("Tus-Resumable"))
+	mockService.AssertExpectations(t)
+}
+
+// TestTusCreateUpload_UnsupportedVersion tests that requests for another protocol version fail with 412
+func TestTusCreateUpload_UnsupportedVersion(t *testing.T) {
+	// Arrange
+	mockService := new(MockResumableUploadService)
+	api := newTusTestAPI(t, mockService)
+
+	// Act
+	resp := api.Post("/files/tus", "Tus-Resumable: 0.2.2", "Upload-Length: 500")
+
+	// Assert
+	assert.Equal(t, http.StatusPreconditionFailed, resp.Code)
+	assert.Equal(t, "1.0.0", resp.Header().Get("Tus-Version"))
+	mockService.AssertNotCalled(t, "CreateUpload", mock.Anything, mock.Anything)
+}
+
+// TestTusGetUpload tests that the offset and metadata of an upload are returned
+func TestTusGetUpload(t *testing.T) {
+	// Arrange
+	mockService := new(MockResumableUploadService)
+	api := newTusTestAPI(t, mockService)
+	id := uuid.New()
+
+	mockService.On("GetUpload", mock.Anything, id).Return(&entities.ResumableUpload{
+		ID:        id,
+		Length:    500,
+		Offset:    200,
+		Metadata:  map[string]string{"filetype": "video/mp4", "filename": "intro.mp4"},
+		ExpiresAt: time.Now().Add(time.Hour),
+	}, nil)
+
+	// Act
+	resp := api.Do(http.MethodHead, "/files/tus/"+id.String(), "Tus-Resumable: 1.0.0")
+
+	// Assert
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "200", resp.Header().Get("Upload-Offset"))
+	assert.Equal(t, "500", resp.Header().Get("Upload-Length"))
+	assert.Equal(t, "filename aW50cm8ubXA0,filetype dmlkZW8vbXA0", resp.Header().Get("Upload-Metadata"))
+	assert.Equal(t, "no-store", resp.Header().Get("Cache-Control"))
+}
+
+// TestTusPatchUpload tests that a chunk is streamed to the service and the completed file is linked
+func TestTusPatchUpload(t *testing.T) {
+	// Arrange
+	mockService := new(MockResumableUploadService)
+	api := newTusTestAPI(t, mockService)
+	id := uuid.New()
+	fileID := uuid.New()
+	var received string
+
+	mockService.On("WriteChunk", mock.Anything, id, int64(200), mock.Anything).
+		Run(func(args mock.Arguments) {
+			data, _ := io.ReadAll(args.Get(3).(io.Reader))
+			received = string(data)
+		}).
+		Return(&entities.ResumableUpload{ID: id, Length: 205, Offset: 205}, &entities.FileMetadata{ID: fileID}, nil)
+
+	// Act
+	resp := api.Patch("/files/tus/"+id.String(),
+		"Tus-Resumable: 1.0.0",
+		"Upload-Offset: 200",
+		"Content-Type: application/offset+octet-stream",
+		strings.NewReader("hello"),
+	)
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.Equal(t, "205", resp.Header().Get("Upload-Offset"))
+	assert.Equal(t, "/files/"+fileID.String(), resp.Header().Get("Content-Location"))
+	assert.Equal(t, "hello", received)
+}
+
+// TestTusPatchUpload_Errors tests the protocol errors of chunks
+func TestTusPatchUpload_Errors(t *testing.T) {
+	// Arrange
+	mockService := new(MockResumableUploadService)
+	api := newTusTestAPI(t, mockService)
+	id := uuid.New()
+	mockService.On("WriteChunk", mock.Anything, id, int64(0), mock.Anything).Return(nil, nil, domainErrors.NewUploadOffsetError(200))
+
+	// Act
+	wrongType := api.Patch("/files/tus/"+id.String(), "Tus-Resumable: 1.0.0", "Upload-Offset: 0", "Content-Type: application/octet-stream", strings.NewReader("x"))
+	conflict := api.Patch("/files/tus/"+id.String(), "Tus-Resumable: 1.0.0", "Upload-Offset: 0", "Content-Type: application/offset+octet-stream", strings.NewReader("x"))
+
+	// Assert
+	assert.Equal(t, http.StatusUnsupportedMediaType, wrongType.Code)
+	assert.Equal(t, http.StatusConflict, conflict.Code)
+	assert.Equal(t, "200", conflict.Header().Get("Upload-Offset"))
+	assert.Equal(t, "1.0.0", conflict.Header().Get("Tus-Resumable"))
+	mockService.AssertNumberOfCalls(t, "WriteChunk", 1)
+}
+
+// TestTusTerminateUpload tests that uploads are terminated
+func TestTusTerminateUpload(t *testing.T) {
+	// Arrange
+	mockService := new(MockResumableUploadService)
+	api := newTusTestAPI(t, mockService)
+	id := uuid.New()
+	mockService.On("TerminateUpload", mock.Anything, id).Return(nil)
+
+	// Act
+	resp := api.Delete("/files/tus/"+id.String(), "Tus-Resumable: 1.0.0")
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	mockService.AssertExpectations(t)
+}
+
+// TestParseUploadMetadata tests the decoding of Upload-Metadata headers
+func TestParseUploadMetadata(t *testing.T) {
+	// Act
+	metadata, err := parseUploadMetadata("filename d29ybGRfZG9taW5hdGlvbl9wbGFuLnBkZg==, is_confidential")
+	_, invalidErr := parseUploadMetadata("filename not-base64!")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"filename": "world_domination_plan.pdf", "is_confidential": ""}, metadata)
+	assert.Equal(t, "filename d29ybGRfZG9taW5hdGlvbl9wbGFuLnBkZg==,is_confidential", formatUploadMetadata(metadata))
+	assert.Error(t, invalidErr)
+}
@@ -2,13 +2,13 @@ package handlers
 
 import (
 	"context"
-	"errors"
 	"net/http"
 
 	"example.com/go-yippi/internal/adapters/api/dto"
+	"example.com/go-yippi/internal/adapters/api/middleware"
+	"example.com/go-yippi/internal/adapters/api/problem"
 	"example.com/go-yippi/internal/application/services"
 	"example.com/go-yippi/internal/domain/entities"
-	domainErrors "example.com/go-yippi/internal/domain/errors"
 	"github.com/danielgtaylor/huma/v2"
 )
 
@@ -31,7 +31,9 @@ func (h *UserHandler) RegisterRoutes(api huma.API) {
 		Summary:     "Create a new user",
 		Description: "Creates a new user with the provided name and age",
 		Tags:        []string{"Users"},
-		Errors:      []int{http.StatusBadRequest, http.StatusInternalServerError},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequirePermission(entities.PermissionUsersManage),
+		Errors:      []int{http.StatusBadRequest, http.StatusConflict, http.StatusInternalServerError},
 	}, h.CreateUser)
 
 	huma.Register(api, huma.Operation{
@@ -41,6 +43,8 @@ func (h *UserHandler) RegisterRoutes(api huma.API) {
 		Summary:     "List all users",
 		Description: "Retrieves a list of all users in the system",
 		Tags:        []string{"Users"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequirePermission(entities.PermissionUsersManage),
 		Errors:      []int{http.StatusInternalServerError},
 	}, h.GetUsers)
 
@@ -51,6 +55,8 @@ func (h *UserHandler) RegisterRoutes(api huma.API) {
 		Summary:     "Get a user by ID",
 		Description: "Retrieves a user by their ID",
 		Tags:        []string{"Users"},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequirePermission(entities.PermissionUsersManage),
 		Errors:      []int{http.StatusNotFound, http.StatusInternalServerError},
 	}, h.GetUser)
 
@@ -61,7 +67,9 @@ func (h *UserHandler) RegisterRoutes(api huma.API) {
 		Summary:     "Update a user",
 		Description: "Updates an existing user's information",
 		Tags:        []string{"Users"},
-		Errors:      []int{http.StatusNotFound, http.StatusInternalServerError},
+		Security:    middleware.BearerAuth,
+		Metadata:    middleware.RequirePermission(entities.PermissionUsersManage),
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError},
 	}, h.UpdateUser)
 
 	huma.Register(api, huma.Operation{
@@ -71,6 +79,8 @@ func (h *UserHandler) RegisterRoutes(api huma.API) {
 		Summary:       "Delete a user",
 		Description:   "Deletes a user from the system",
 		Tags:          []string{"Users"},
+		Security:      middleware.BearerAuth,
+		Metadata:      middleware.RequirePermission(entities.PermissionUsersManage),
 		DefaultStatus: http.StatusNoContent,
 		Errors:        []int{http.StatusNotFound, http.StatusInternalServerError},
 	}, h.DeleteUser)
@@ -78,40 +88,53 @@ func (h *UserHandler) RegisterRoutes(api huma.API) {
 
 func (h *UserHandler) CreateUser(ctx context.Context, input *dto.CreateUserRequest) (*dto.UserResponse, error) {
 	user := &entities.User{
-		Name:  input.Body.Name,
-		Age: input.Body.Age,
+		Name:     input.Body.Name,
+		Age:      input.Body.Age,
+		Email:    input.Body.Email,
+		Role:     entities.Role(input.Body.Role),
+		TenantID: input.Body.TenantID,
 	}
 
 	err := h.service.CreateUser(ctx, user)
 	if err != nil {
-		return nil, huma.Error500InternalServerError("Failed to create user", err)
+		return nil, problem.FromError(err)
 	}
 
-	resp := &dto.UserResponse{}
-	resp.Body.ID = user.ID
-	resp.Body.Name = user.Name
-	resp.Body.Age = user.Age
+	// Set the login password if provided
+	if input.Body.Password != "" {
+		if err := h.service.SetPassword(ctx, user.ID, input.Body.Password); err != nil {
+			return nil, problem.FromError(err)
+		}
+	}
 
-	return resp, nil
+	return toUserResponse(user), nil
 }
 
 func (h *UserHandler) GetUsers(ctx context.Context, input *struct{}) (*dto.ListUsersResponse, error) {
 	users, err := h.service.ListUsers(ctx)
 	if err != nil {
-		return nil, huma.Error500InternalServerError("Failed to list users", err)
+		return nil, problem.FromError(err)
 	}
 
 	resp := &dto.ListUsersResponse{}
 	resp.Body.Users = make([]struct {
-		ID    int    `json:"id"`
-		Name  string `json:"name"`
-		Age int `json:"age"`
+		ID          int    `json:"id"`
+		Name        string `json:"name"`
+		Age         int    `json:"age"`
+		Email       string `json:"email,omitempty"`
+		Role        string `json:"role"`
+		TenantID    string `json:"tenant_id"`
+		TOTPEnabled bool   `json:"totp_enabled"`
 	}, len(users))
 
 	for i, user := range users {
 		resp.Body.Users[i].ID = user.ID
 		resp.Body.Users[i].Name = user.Name
 		resp.Body.Users[i].Age = user.Age
+		resp.Body.Users[i].Email = user.Email
+		resp.Body.Users[i].Role = string(user.Role)
+		resp.Body.Users[i].TenantID = user.TenantID
+		resp.Body.Users[i].TOTPEnabled = user.TOTPEnabled
 	}
 
 	return resp, nil
@@ -120,51 +143,54 @@ func (h *UserHandler) GetUsers(ctx context.Context, input *struct{}) (*dto.ListU
 func (h *UserHandler) GetUser(ctx context.Context, input *dto.GetUserRequest) (*dto.UserResponse, error) {
 	user, err := h.service.GetUser(ctx, input.ID)
 	if err != nil {
-		if errors.Is(err, domainErrors.ErrNotFound) {
-			return nil, huma.Error404NotFound("User not found")
-		}
-		return nil, huma.Error500InternalServerError("Failed to get user", err)
+		return nil, problem.FromError(err)
 	}
 
-	resp := &dto.UserResponse{}
-	resp.Body.ID = user.ID
-	resp.Body.Name = user.Name
-	resp.Body.Age = user.Age
-
-	return resp, nil
+	return toUserResponse(user), nil
 }
 
 func (h *UserHandler) UpdateUser(ctx context.Context, input *dto.UpdateUserRequest) (*dto.UserResponse, error) {
 	user := &entities.User{
 		ID:    input.ID,
 		Name:  input.Body.Name,
-		Age: input.Body.Age,
+		Age:   input.Body.Age,
+		Email: input.Body.Email,
+		Role:  entities.Role(input.Body.Role),
 	}
 
 	err := h.service.UpdateUser(ctx, user)
 	if err != nil {
-		if errors.Is(err, domainErrors.ErrNotFound) {
-			return nil, huma.Error404NotFound("User not found")
-		}
-		return nil, huma.Error500InternalServerError("Failed to update user", err)
+		return nil, problem.FromError(err)
 	}
 
-	resp := &dto.UserResponse{}
-	resp.Body.ID = user.ID
-	resp.Body.Name = user.Name
-	resp.Body.Age = user.Age
+	// Replace the login password if provided
+	if input.Body.Password != "" {
+		if err := h.service.SetPassword(ctx, user.ID, input.Body.Password); err != nil {
+			return nil, problem.FromError(err)
+		}
+	}
 
-	return resp, nil
+	return toUserResponse(user), nil
 }
 
 func (h *UserHandler) DeleteUser(ctx context.Context, input *dto.DeleteUserRequest) (*struct{}, error) {
 	err := h.service.DeleteUser(ctx, input.ID)
 	if err != nil {
-		if errors.Is(err, domainErrors.ErrNotFound) {
-			return nil, huma.Error404NotFound("User not found")
-		}
-		return nil, huma.Error500InternalServerError("Failed to delete user", err)
+		return nil, problem.FromError(err)
 	}
 
 	return &struct{}{}, nil
 }
+
+// toUserResponse maps a domain user to the response DTO
+func toUserResponse(user *entities.User) *dto.UserResponse {
+	resp := &dto.UserResponse{}
+	resp.Body.ID = user.ID
+	resp.Body.Name = user.Name
+	resp.Body.Age = user.Age
+	resp.Body.Email = user.Email
+	resp.Body.Role = string(user.Role)
+	resp.Body.TenantID = user.TenantID
+	resp.Body.TOTPEnabled = user.TOTPEnabled
+	return resp
+}
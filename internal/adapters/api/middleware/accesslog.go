@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type accessLogContextKey struct{}
+
+// accessLogEntry collects what later middleware learns about a request, since the access log is written
+// with the context the request started with
+type accessLogEntry struct {
+	principal *entities.Principal
+	tenantID  string
+}
+
+// annotateAccessLog updates the access log entry of the request ctx handles, if it is logged
+func annotateAccessLog(ctx context.Context, annotate func(entry *accessLogEntry)) {
+	if entry, ok := ctx.Value(accessLogContextKey{}).(*accessLogEntry); ok {
+		annotate(entry)
+	}
+}
+
+// NewAccessLogMiddleware logs each request once it has been handled, with its operation, status, latency
+// and the principal and tenant it was handled for. Server errors are logged at error level. The query
+// string is left out, since it may hold signatures of presigned URLs. It should run after the request ID
+// middleware and before the auth and tenant middleware.
+func NewAccessLogMiddleware(logger *slog.Logger) func(ctx huma.Context, next func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		start := time.Now()
+		entry := &accessLogEntry{}
+		next(huma.WithContext(ctx, context.WithValue(ctx.Context(), accessLogContextKey{}, entry)))
+
+		status := responseStatus(ctx)
+		attrs := []slog.Attr{
+			slog.String("operation", ctx.Operation().OperationID),
+			slog.String("method", ctx.Method()),
+			slog.String("path", ctx.URL().Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("remote_addr", ctx.RemoteAddr()),
+		}
+		if entry.principal != nil {
+			attrs = append(attrs, slog.Group("principal",
+				slog.Int("user_id", entry.principal.UserID),
+				slog.String("role", string(entry.principal.Role))))
+		}
+		if entry.tenantID != "" {
+			attrs = append(attrs, slog.String("tenant", entry.tenantID))
+		}
+
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		logger.LogAttrs(ctx.Context(), level, "request", attrs...)
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"example.com/go-yippi/internal/domain/entities"
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAccessLogMiddleware tests that requests are logged with the principal and tenant resolved by later
+// middleware, and that server errors are logged at error level
+func TestAccessLogMiddleware(t *testing.T) {
+	// Arrange
+	var out bytes.Buffer
+	mockAuth := new(MockAuthService)
+	mockAuth.On("Authenticate", mock.Anything, "valid-token").
+		Return(&entities.Principal{UserID: 3, Role: entities.RoleAdmin, TenantID: "acme"}, nil)
+	_, api := humatest.New(t)
+	api.UseMiddleware(
+		NewAccessLogMiddleware(slog.New(slog.NewJSONHandler(&out, nil))),
+		NewAuthMiddleware(api, mockAuth),
+		NewTenantMiddleware(api, "X-Tenant-ID", "default"),
+	)
+	huma.Register(api, huma.Operation{
+		OperationID: "get-thing",
+		Method:      http.MethodGet,
+		Path:        "/things/{id}",
+	}, func(ctx context.Context, input *struct {
+		ID string `path:"id"`
+	}) (*struct{}, error) {
+		if input.ID == "broken" {
+			return nil, huma.Error500InternalServerError("failed")
+		}
+		return nil, nil
+	})
+
+	// Act
+	api.Get("/things/1?signature=secret", "Authorization: Bearer valid-token")
+	api.Get("/things/broken")
+
+	// Assert
+	decoder := json.NewDecoder(&out)
+	var first, second map[string]any
+	require.NoError(t, decoder.Decode(&first))
+	require.NoError(t, decoder.Decode(&second))
+
+	assert.Equal(t, "INFO", first["level"])
+	assert.Equal(t, "get-thing", first["operation"])
+	assert.Equal(t, "/things/1", first["path"])
+	assert.EqualValues(t, http.StatusNoContent, first["status"])
+	assert.Contains(t, first, "latency")
+	assert.Equal(t, map[string]any{"user_id": float64(3), "role": string(entities.RoleAdmin)}, first["principal"])
+	assert.Equal(t, "acme", first["tenant"])
+
+	assert.Equal(t, "ERROR", second["level"])
+	assert.EqualValues(t, http.StatusInternalServerError, second["status"])
+	assert.NotContains(t, second, "principal")
+	assert.Equal(t, "default", second["tenant"])
+}
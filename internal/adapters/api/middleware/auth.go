@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"example.com/go-yippi/internal/domain/ports"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+const (
+	// PermissionKey is the operation metadata key holding the entities.Permission an operation requires
+	PermissionKey = "permission"
+	// AuthenticatedKey is the operation metadata key marking operations that only require a logged-in user
+	AuthenticatedKey = "authenticated"
+
+	// BearerAuthScheme is the name of the OpenAPI security scheme for access tokens
+	BearerAuthScheme = "bearerAuth"
+)
+
+// BearerAuth is the OpenAPI security requirement for operations that need an access token
+var BearerAuth = []map[string][]string{{BearerAuthScheme: {}}}
+
+// RequirePermission returns operation metadata requiring the given permission
+func RequirePermission(permission entities.Permission) map[string]any {
+	return map[string]any{PermissionKey: permission}
+}
+
+// RequireAuthentication returns operation metadata requiring any authenticated user
+func RequireAuthentication() map[string]any {
+	return map[string]any{AuthenticatedKey: true}
+}
+
+// NewAuthMiddleware resolves the bearer token into a principal and enforces operation permissions
+func NewAuthMiddleware(api huma.API, auth ports.AuthService) func(ctx huma.Context, next func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		var principal *entities.Principal
+
+		if token, ok := bearerToken(ctx.Header("Authorization")); ok {
+			p, err := auth.Authenticate(ctx.Context(), token)
+			if err != nil {
+				if errors.Is(err, domainErrors.ErrUnauthorized) {
+					huma.WriteErr(api, ctx, http.StatusUnauthorized, "Invalid or expired access token")
+					return
+				}
+				huma.WriteErr(api, ctx, http.StatusInternalServerError, "Failed to authenticate request")
+				return
+			}
+			principal = p
+			ctx = huma.WithContext(ctx, entities.ContextWithPrincipal(ctx.Context(), principal))
+			annotateAccessLog(ctx.Context(), func(entry *accessLogEntry) { entry.principal = principal })
+		}
+
+		permission, authenticated := requirements(ctx.Operation())
+		if permission == "" && !authenticated {
+			next(ctx)
+			return
+		}
+
+		if principal == nil {
+			ctx.SetHeader("WWW-Authenticate", "Bearer")
+			huma.WriteErr(api, ctx, http.StatusUnauthorized, "Authentication required")
+			return
+		}
+
+		if permission != "" && !principal.Can(permission) {
+			if principal.TwoFactorRequired {
+				huma.WriteErr(api, ctx, http.StatusForbidden, "Two-factor authentication must be enabled and verified for this role")
+				return
+			}
+			huma.WriteErr(api, ctx, http.StatusForbidden, "Missing permission "+string(permission))
+			return
+		}
+
+		next(ctx)
+	}
+}
+
+// requirements reads the permission and authentication requirements of an operation
+func requirements(op *huma.Operation) (entities.Permission, bool) {
+	if op == nil || op.Metadata == nil {
+		return "", false
+	}
+
+	permission, _ := op.Metadata[PermissionKey].(entities.Permission)
+	authenticated, _ := op.Metadata[AuthenticatedKey].(bool)
+	return permission, authenticated
+}
+
+// bearerToken extracts the token from an Authorization header
+func bearerToken(header string) (string, bool) {
+	scheme, token, found := strings.Cut(strings.TrimSpace(header), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
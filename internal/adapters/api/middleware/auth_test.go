@@ -0,0 +1,199 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAuthService is a mock implementation of ports.AuthService
+type MockAuthService struct {
+	mock.Mock
+}
+
+func (m *MockAuthService) Login(ctx context.Context, email, password string) (*entities.LoginResult, error) {
+	args := m.Called(ctx, email, password)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.LoginResult), args.Error(1)
+}
+
+func (m *MockAuthService) VerifyLoginChallenge(ctx context.Context, challengeToken, code string) (*entities.AuthToken, error) {
+	args := m.Called(ctx, challengeToken, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.AuthToken), args.Error(1)
+}
+
+func (m *MockAuthService) Authenticate(ctx context.Context, accessToken string) (*entities.Principal, error) {
+	args := m.Called(ctx, accessToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.Principal), args.Error(1)
+}
+
+func (m *MockAuthService) EnrollTOTP(ctx context.Context, userID int) (*entities.TOTPEnrollment, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.TOTPEnrollment), args.Error(1)
+}
+
+func (m *MockAuthService) ActivateTOTP(ctx context.Context, userID int, code string) (*entities.TOTPActivation, error) {
+	args := m.Called(ctx, userID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.TOTPActivation), args.Error(1)
+}
+
+func (m *MockAuthService) DisableTOTP(ctx context.Context, userID int, code string) error {
+	args := m.Called(ctx, userID, code)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error) {
+	args := m.Called(ctx, userID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// newTestAPI registers a public operation and one requiring catalog:delete behind the auth middleware
+func newTestAPI(t *testing.T, auth *MockAuthService) humatest.TestAPI {
+	_, api := humatest.New(t)
+	api.UseMiddleware(NewAuthMiddleware(api, auth))
+
+	huma.Register(api, huma.Operation{
+		OperationID: "public",
+		Method:      http.MethodGet,
+		Path:        "/public",
+	}, func(ctx context.Context, input *struct{}) (*struct{}, error) {
+		return &struct{}{}, nil
+	})
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "protected",
+		Method:        http.MethodDelete,
+		Path:          "/protected",
+		Security:      BearerAuth,
+		Metadata:      RequirePermission(entities.PermissionCatalogDelete),
+		DefaultStatus: http.StatusNoContent,
+	}, func(ctx context.Context, input *struct{}) (*struct{}, error) {
+		if _, ok := entities.PrincipalFromContext(ctx); !ok {
+			return nil, fmt.Errorf("principal missing from context")
+		}
+		return &struct{}{}, nil
+	})
+
+	return api
+}
+
+// TestAuthMiddleware_PublicOperation tests that operations without requirements need no token
+func TestAuthMiddleware_PublicOperation(t *testing.T) {
+	// Arrange
+	api := newTestAPI(t, new(MockAuthService))
+
+	// Act
+	resp := api.Get("/public")
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+}
+
+// TestAuthMiddleware_MissingToken tests that protected operations require a bearer token
+func TestAuthMiddleware_MissingToken(t *testing.T) {
+	// Arrange
+	api := newTestAPI(t, new(MockAuthService))
+
+	// Act
+	resp := api.Delete("/protected")
+
+	// Assert
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.Equal(t, "Bearer", resp.Header().Get("WWW-Authenticate"))
+}
+
+// TestAuthMiddleware_InvalidToken tests that rejected tokens return 401
+func TestAuthMiddleware_InvalidToken(t *testing.T) {
+	// Arrange
+	auth := new(MockAuthService)
+	auth.On("Authenticate", mock.Anything, "bad-token").Return(nil, domainErrors.ErrUnauthorized)
+	api := newTestAPI(t, auth)
+
+	// Act
+	resp := api.Delete("/protected", "Authorization: Bearer bad-token")
+
+	// Assert
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+}
+
+// TestAuthMiddleware_TwoFactorRequired tests that sensitive roles without 2FA are forbidden
+func TestAuthMiddleware_TwoFactorRequired(t *testing.T) {
+	// Arrange
+	auth := new(MockAuthService)
+	auth.On("Authenticate", mock.Anything, "admin-token").Return(&entities.Principal{
+		UserID:            1,
+		Role:              entities.RoleAdmin,
+		TwoFactorRequired: true,
+	}, nil)
+	api := newTestAPI(t, auth)
+
+	// Act
+	resp := api.Delete("/protected", "Authorization: Bearer admin-token")
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	assert.Contains(t, resp.Body.String(), "Two-factor authentication")
+}
+
+// TestAuthMiddleware_MissingPermission tests that principals without the permission are forbidden
+func TestAuthMiddleware_MissingPermission(t *testing.T) {
+	// Arrange
+	auth := new(MockAuthService)
+	auth.On("Authenticate", mock.Anything, "editor-token").Return(&entities.Principal{
+		UserID:      2,
+		Role:        entities.RoleEditor,
+		Permissions: entities.RoleEditor.Permissions(),
+	}, nil)
+	api := newTestAPI(t, auth)
+
+	// Act
+	resp := api.Delete("/protected", "Authorization: Bearer editor-token")
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	assert.Contains(t, resp.Body.String(), "catalog:delete")
+}
+
+// TestAuthMiddleware_Allowed tests that a permitted principal reaches the handler with the principal in context
+func TestAuthMiddleware_Allowed(t *testing.T) {
+	// Arrange
+	auth := new(MockAuthService)
+	auth.On("Authenticate", mock.Anything, "admin-token").Return(&entities.Principal{
+		UserID:            1,
+		Role:              entities.RoleAdmin,
+		TwoFactorVerified: true,
+		Permissions:       entities.RoleAdmin.Permissions(),
+	}, nil)
+	api := newTestAPI(t, auth)
+
+	// Act
+	resp := api.Delete("/protected", "Authorization: Bearer admin-token")
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// RequestMetrics records the requests of each operation
+type RequestMetrics interface {
+	// RequestStarted is called as a request of an operation starts; done is called with its status once it
+	// has been handled
+	RequestStarted(operationID, method string) (done func(status int))
+}
+
+// NewMetricsMiddleware records the latency, status and concurrency of requests by operation ID.
+// It should run first, so that requests rejected by other middleware are recorded too.
+func NewMetricsMiddleware(metrics RequestMetrics) func(ctx huma.Context, next func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		done := metrics.RequestStarted(ctx.Operation().OperationID, ctx.Method())
+		defer func() { done(responseStatus(ctx)) }()
+		next(ctx)
+	}
+}
+
+// responseStatus returns the status a request was answered with; handlers that write their response
+// without setting one answer with 200
+func responseStatus(ctx huma.Context) int {
+	if status := ctx.Status(); status != 0 {
+		return status
+	}
+	return http.StatusOK
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordedRequest is a request a recordingMetrics was told about
+type recordedRequest struct {
+	operationID string
+	method      string
+	status      int
+}
+
+// recordingMetrics collects the requests it is told about
+type recordingMetrics struct {
+	requests []recordedRequest
+	inFlight int
+}
+
+func (m *recordingMetrics) RequestStarted(operationID, method string) func(status int) {
+	m.inFlight++
+	return func(status int) {
+		m.inFlight--
+		m.requests = append(m.requests, recordedRequest{operationID: operationID, method: method, status: status})
+	}
+}
+
+// TestMetricsMiddleware tests that requests are recorded with their operation ID and the status they were
+// answered with, including errors
+func TestMetricsMiddleware(t *testing.T) {
+	// Arrange
+	metrics := &recordingMetrics{}
+	_, api := humatest.New(t)
+	api.UseMiddleware(NewMetricsMiddleware(metrics))
+	huma.Register(api, huma.Operation{
+		OperationID:   "create-thing",
+		Method:        http.MethodPost,
+		Path:          "/things",
+		DefaultStatus: http.StatusCreated,
+	}, func(ctx context.Context, input *struct{}) (*struct{}, error) {
+		return nil, nil
+	})
+	huma.Register(api, huma.Operation{
+		OperationID: "get-thing",
+		Method:      http.MethodGet,
+		Path:        "/things/{id}",
+	}, func(ctx context.Context, input *struct {
+		ID string `path:"id"`
+	}) (*struct{}, error) {
+		return nil, huma.Error404NotFound("thing not found")
+	})
+
+	// Act
+	api.Post("/things")
+	api.Get("/things/1")
+
+	// Assert
+	assert.Equal(t, []recordedRequest{
+		{operationID: "create-thing", method: http.MethodPost, status: http.StatusCreated},
+		{operationID: "get-thing", method: http.MethodGet, status: http.StatusNotFound},
+	}, metrics.requests)
+	assert.Zero(t, metrics.inFlight)
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"regexp"
+
+	"example.com/go-yippi/internal/domain/entities"
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/google/uuid"
+)
+
+// requestIDPattern restricts request IDs sent by callers to short tokens, since they end up in log lines
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// NewRequestIDMiddleware gives each request an ID, taken from the header if the caller (e.g. a proxy) sent
+// a valid one and generated otherwise. The ID is echoed in the header of the response and carried by the
+// request context, so that log lines and problem responses of the request can be correlated. It should
+// run first, so that requests rejected by other middleware have an ID too.
+func NewRequestIDMiddleware(header string) func(ctx huma.Context, next func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		requestID := ctx.Header(header)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+		ctx.SetHeader(header, requestID)
+		next(huma.WithContext(ctx, entities.ContextWithRequestID(ctx.Context(), requestID)))
+	}
+}
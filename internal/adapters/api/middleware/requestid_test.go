@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"example.com/go-yippi/internal/domain/entities"
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRequestIDMiddleware tests that valid request IDs of callers are kept, that others are replaced by a
+// generated one and that handlers see the ID the response carries
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		sent   string
+		keptAs string
+	}{
+		{name: "propagated", sent: "edge-7f3a:42", keptAs: "edge-7f3a:42"},
+		{name: "missing"},
+		{name: "invalid", sent: "bad id\twith spaces"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			_, api := humatest.New(t)
+			api.UseMiddleware(NewRequestIDMiddleware("X-Request-ID"))
+			var seen string
+			huma.Get(api, "/things", func(ctx context.Context, input *struct{}) (*struct{}, error) {
+				seen, _ = entities.RequestIDFromContext(ctx)
+				return nil, nil
+			})
+			var args []any
+			if tt.sent != "" {
+				args = append(args, "X-Request-ID: "+tt.sent)
+			}
+
+			// Act
+			resp := api.Get("/things", args...)
+
+			// Assert
+			assert.Equal(t, http.StatusNoContent, resp.Code)
+			returned := resp.Header().Get("X-Request-ID")
+			assert.Equal(t, returned, seen)
+			if tt.keptAs != "" {
+				assert.Equal(t, tt.keptAs, returned)
+			} else {
+				assert.NoError(t, uuid.Validate(returned))
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+
+	"example.com/go-yippi/internal/domain/entities"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// NewTenantMiddleware scopes the request context to a tenant.
+// Authenticated requests use the tenant of the principal, so it must run after the auth middleware;
+// other requests use the tenant header, falling back to defaultTenant.
+func NewTenantMiddleware(api huma.API, header, defaultTenant string) func(ctx huma.Context, next func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		requested := ctx.Header(header)
+		if requested != "" && !entities.IsValidTenantID(requested) {
+			huma.WriteErr(api, ctx, http.StatusBadRequest, "Invalid tenant ID in "+header+" header")
+			return
+		}
+
+		tenantID := defaultTenant
+		if principal, ok := entities.PrincipalFromContext(ctx.Context()); ok && principal.TenantID != "" {
+			// Users cannot reach into other tenants by setting the header
+			if requested != "" && requested != principal.TenantID {
+				huma.WriteErr(api, ctx, http.StatusForbidden, "Access to tenant "+requested+" is not allowed")
+				return
+			}
+			tenantID = principal.TenantID
+		} else if requested != "" {
+			tenantID = requested
+		}
+
+		ctx = huma.WithContext(ctx, entities.ContextWithTenant(ctx.Context(), tenantID))
+		annotateAccessLog(ctx.Context(), func(entry *accessLogEntry) { entry.tenantID = tenantID })
+		next(ctx)
+	}
+}
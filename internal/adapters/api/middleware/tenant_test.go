@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"example.com/go-yippi/internal/domain/entities"
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// tenantOutput echoes the tenant the request was scoped to
+type tenantOutput struct {
+	Body struct {
+		TenantID string `json:"tenant_id"`
+	}
+}
+
+// newTenantTestAPI registers an operation that reports its tenant behind the auth and tenant middleware
+func newTenantTestAPI(t *testing.T, auth *MockAuthService) humatest.TestAPI {
+	_, api := humatest.New(t)
+	api.UseMiddleware(NewAuthMiddleware(api, auth))
+	api.UseMiddleware(NewTenantMiddleware(api, "X-Tenant-ID", "default"))
+
+	huma.Register(api, huma.Operation{
+		OperationID: "tenant",
+		Method:      http.MethodGet,
+		Path:        "/tenant",
+	}, func(ctx context.Context, input *struct{}) (*tenantOutput, error) {
+		out := &tenantOutput{}
+		out.Body.TenantID, _ = entities.TenantFromContext(ctx)
+		return out, nil
+	})
+
+	return api
+}
+
+// TestTenantMiddleware_Resolution tests how the tenant is resolved from the default, header and principal
+func TestTenantMiddleware_Resolution(t *testing.T) {
+	// Arrange
+	auth := new(MockAuthService)
+	auth.On("Authenticate", mock.Anything, "acme-token").Return(&entities.Principal{UserID: 1, TenantID: "acme"}, nil)
+	api := newTenantTestAPI(t, auth)
+
+	cases := []struct {
+		name    string
+		headers []any
+		status  int
+		tenant  string
+	}{
+		{"default", nil, http.StatusOK, "default"},
+		{"header", []any{"X-Tenant-ID: globex"}, http.StatusOK, "globex"},
+		{"invalid header", []any{"X-Tenant-ID: ../etc"}, http.StatusBadRequest, ""},
+		{"principal", []any{"Authorization: Bearer acme-token"}, http.StatusOK, "acme"},
+		{"principal with matching header", []any{"Authorization: Bearer acme-token", "X-Tenant-ID: acme"}, http.StatusOK, "acme"},
+		{"principal with foreign header", []any{"Authorization: Bearer acme-token", "X-Tenant-ID: globex"}, http.StatusForbidden, ""},
+	}
+
+	for _, tc := range cases {
+		// Act
+		resp := api.Get("/tenant", tc.headers...)
+
+		// Assert
+		assert.Equal(t, tc.status, resp.Code, tc.name)
+		if tc.tenant != "" {
+			assert.Contains(t, resp.Body.String(), `"tenant_id":"`+tc.tenant+`"`, tc.name)
+		}
+	}
+}
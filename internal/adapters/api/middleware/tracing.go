@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// RequestTracer traces the requests of each operation
+type RequestTracer interface {
+	// StartRequest starts the trace of a request to an operation, reading the trace context of the caller
+	// with header. The request is handled with the returned context, and done is called with its status
+	// once it has been handled.
+	StartRequest(ctx context.Context, operationID, method, route string, header func(string) string) (context.Context, func(status int))
+}
+
+// NewTracingMiddleware traces requests by operation, so that the spans of services, storage and SQL
+// statements below are grouped under their request. The span covers writing the response, which the spans
+// below do not. It should run first, so that requests rejected by other middleware are traced too.
+func NewTracingMiddleware(tracer RequestTracer) func(ctx huma.Context, next func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		op := ctx.Operation()
+		traced, done := tracer.StartRequest(ctx.Context(), op.OperationID, ctx.Method(), op.Path, ctx.Header)
+		defer func() { done(responseStatus(ctx)) }()
+		next(huma.WithContext(ctx, traced))
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/stretchr/testify/assert"
+)
+
+// traceKey marks contexts a recordingTracer started a trace in
+type traceKey struct{}
+
+// recordingTracer collects the routes and statuses of the requests it traces
+type recordingTracer struct {
+	routes   []string
+	statuses []int
+}
+
+func (r *recordingTracer) StartRequest(ctx context.Context, operationID, method, route string, header func(string) string) (context.Context, func(status int)) {
+	r.routes = append(r.routes, method+" "+route+" "+header("Traceparent"))
+	return context.WithValue(ctx, traceKey{}, operationID), func(status int) {
+		r.statuses = append(r.statuses, status)
+	}
+}
+
+// TestTracingMiddleware tests that requests are handled in the context of their trace and that the trace is
+// ended with the status of the response
+func TestTracingMiddleware(t *testing.T) {
+	// Arrange
+	tracer := &recordingTracer{}
+	_, api := humatest.New(t)
+	api.UseMiddleware(NewTracingMiddleware(tracer))
+	var traced any
+	huma.Register(api, huma.Operation{
+		OperationID: "get-thing",
+		Method:      http.MethodGet,
+		Path:        "/things/{id}",
+	}, func(ctx context.Context, input *struct {
+		ID string `path:"id"`
+	}) (*struct{}, error) {
+		traced = ctx.Value(traceKey{})
+		return nil, huma.Error404NotFound("thing not found")
+	})
+
+	// Act
+	api.Get("/things/1", "Traceparent: 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	// Assert
+	assert.Equal(t, "get-thing", traced)
+	assert.Equal(t, []string{"GET /things/{id} 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}, tracer.routes)
+	assert.Equal(t, []int{http.StatusNotFound}, tracer.statuses)
+}
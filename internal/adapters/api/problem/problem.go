@@ -0,0 +1,197 @@
+package problem
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// ContentType is the media type of problem details responses (RFC 7807)
+const ContentType = "application/problem+json"
+
+// Problem codes identify the kind of problem for clients
+const (
+	CodeValidationFailed    = "validation_failed"
+	CodeNotFound            = "not_found"
+	CodeDuplicate           = "duplicate"
+	CodeUnauthorized        = "unauthorized"
+	CodeForbidden           = "forbidden"
+	CodeRangeNotSatisfiable = "range_not_satisfiable"
+	CodeNotSupported        = "not_supported"
+	CodeConflict            = "conflict"
+	CodeInternal            = "internal_error"
+	CodeRequestFailed       = "request_failed"
+)
+
+// FieldError describes why a single field of the request is invalid
+type FieldError struct {
+	Field   string `json:"field" doc:"Name of the invalid field" example:"price"`
+	Code    string `json:"code" doc:"Machine-readable reason" example:"price.must_be_positive"`
+	Message string `json:"message" doc:"Human-readable reason" example:"Price must be greater than 0"`
+}
+
+// Problem is an RFC 7807 problem details body
+type Problem struct {
+	Type      string       `json:"type" doc:"URI reference identifying the problem type" example:"about:blank"`
+	Title     string       `json:"title" doc:"Short summary of the problem type" example:"Bad Request"`
+	Status    int          `json:"status" doc:"HTTP status code" example:"400"`
+	Detail    string       `json:"detail,omitempty" doc:"Explanation specific to this occurrence" example:"Request validation failed"`
+	Instance  string       `json:"instance,omitempty" doc:"URI reference identifying this occurrence"`
+	Code      string       `json:"code" doc:"Machine-readable problem code" example:"validation_failed"`
+	Errors    []FieldError `json:"errors,omitempty" doc:"Field errors, if the request was invalid"`
+	RequestID string       `json:"request_id,omitempty" doc:"ID of the request, to find it in the logs" example:"3f2c5b1e-8a4d-4c36-9d0e-2b7f6a1c9e55"`
+
+	// cause holds the errors behind a server error, which are logged but never sent
+	cause error
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+	return p.Title
+}
+
+// GetStatus returns the HTTP status code of the problem
+func (p *Problem) GetStatus() int {
+	return p.Status
+}
+
+// ContentType serves problems as application/problem+json instead of plain JSON
+func (p *Problem) ContentType(ct string) string {
+	if ct == "application/json" {
+		return ContentType
+	}
+	return ct
+}
+
+// New creates a problem for the status. Domain validation errors and Huma error details
+// among errs become field errors; other errors are never sent to the client (5xx causes are kept for the
+// transformer to log).
+// It has the signature of huma.NewError so that Huma's own errors use the same format.
+func New(status int, detail string, errs ...error) huma.StatusError {
+	p := &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   codeForStatus(status),
+	}
+
+	for _, err := range errs {
+		var verr *domainErrors.ValidationError
+		var detailer huma.ErrorDetailer
+
+		switch {
+		case errors.As(err, &verr):
+			for _, fe := range verr.Errors {
+				p.Errors = append(p.Errors, FieldError{Field: fe.Field, Code: fe.Code, Message: fe.Message})
+			}
+		case errors.As(err, &detailer):
+			d := detailer.ErrorDetail()
+			field := strings.TrimPrefix(d.Location, "body.")
+			p.Errors = append(p.Errors, FieldError{Field: field, Code: field + ".invalid", Message: d.Message})
+		case err != nil && status >= http.StatusInternalServerError:
+			// Keep the cause (e.g. a database message) in the logs only
+			p.cause = errors.Join(p.cause, err)
+		}
+	}
+
+	return p
+}
+
+// NewTransformer returns a Huma transformer that adds the request ID to problems and logs the causes of
+// server errors with the context of their request
+func NewTransformer(logger *slog.Logger) func(ctx huma.Context, status string, v any) (any, error) {
+	return func(ctx huma.Context, status string, v any) (any, error) {
+		p, ok := v.(*Problem)
+		if !ok {
+			return v, nil
+		}
+		if requestID, ok := entities.RequestIDFromContext(ctx.Context()); ok {
+			p.RequestID = requestID
+		}
+		if p.cause != nil {
+			logger.ErrorContext(ctx.Context(), p.Detail,
+				slog.Int("status", p.Status),
+				slog.String("operation", ctx.Operation().OperationID),
+				slog.Any("error", p.cause))
+		}
+		return p, nil
+	}
+}
+
+// FromError maps an error returned by the application layer to a problem.
+// Errors that already carry an HTTP status are returned unchanged.
+func FromError(err error) error {
+	var statusErr huma.StatusError
+	var verr *domainErrors.ValidationError
+	var notFound *domainErrors.NotFoundError
+	var duplicate *domainErrors.DuplicateError
+
+	switch {
+	case errors.As(err, &statusErr):
+		return err
+	case errors.As(err, &verr):
+		return New(http.StatusBadRequest, "Request validation failed", verr)
+	case errors.As(err, &notFound):
+		return New(http.StatusNotFound, notFound.Resource+" not found")
+	case errors.As(err, &duplicate):
+		p := New(http.StatusConflict, duplicate.Error()).(*Problem)
+		p.Errors = []FieldError{{
+			Field:   duplicate.Field,
+			Code:    duplicate.Field + ".duplicate",
+			Message: duplicate.Resource + " with this " + duplicate.Field + " already exists",
+		}}
+		return p
+	case errors.Is(err, domainErrors.ErrInvalidInput):
+		return New(http.StatusBadRequest, err.Error())
+	case errors.Is(err, domainErrors.ErrNotFound):
+		return New(http.StatusNotFound, "Resource not found")
+	case errors.Is(err, domainErrors.ErrDuplicateEntry):
+		return New(http.StatusConflict, "Resource already exists")
+	case errors.Is(err, domainErrors.ErrUnauthorized):
+		return New(http.StatusUnauthorized, err.Error())
+	case errors.Is(err, domainErrors.ErrForbidden):
+		return New(http.StatusForbidden, err.Error())
+	case errors.Is(err, domainErrors.ErrRangeNotSatisfiable):
+		return New(http.StatusRequestedRangeNotSatisfiable, err.Error())
+	case errors.Is(err, domainErrors.ErrNotSupported):
+		return New(http.StatusNotImplemented, err.Error())
+	case errors.Is(err, domainErrors.ErrConflict):
+		p := New(http.StatusConflict, err.Error()).(*Problem)
+		p.Code = CodeConflict
+		return p
+	default:
+		return New(http.StatusInternalServerError, "An internal error occurred", err)
+	}
+}
+
+// codeForStatus returns the problem code for a status without a more specific cause
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return CodeValidationFailed
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeDuplicate
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusRequestedRangeNotSatisfiable:
+		return CodeRangeNotSatisfiable
+	case http.StatusNotImplemented:
+		return CodeNotSupported
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeRequestFailed
+}
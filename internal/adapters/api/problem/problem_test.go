@@ -0,0 +1,187 @@
+package problem
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFromError_StatusMapping tests that domain errors map to the expected status and problem code
+func TestFromError_StatusMapping(t *testing.T) {
+	cases := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"validation", domainErrors.NewValidationError("name", "required", "Name is required"), http.StatusBadRequest, CodeValidationFailed},
+		{"invalid input", domainErrors.ErrInvalidInput, http.StatusBadRequest, CodeValidationFailed},
+		{"not found", domainErrors.NewNotFoundError("Product", 1), http.StatusNotFound, CodeNotFound},
+		{"duplicate", domainErrors.NewDuplicateError("Product", "sku", "SKU-1"), http.StatusConflict, CodeDuplicate},
+		{"unauthorized", domainErrors.ErrUnauthorized, http.StatusUnauthorized, CodeUnauthorized},
+		{"forbidden", domainErrors.ErrForbidden, http.StatusForbidden, CodeForbidden},
+		{"not supported", fmt.Errorf("direct uploads: %w", domainErrors.ErrNotSupported), http.StatusNotImplemented, CodeNotSupported},
+		{"range not satisfiable", domainErrors.NewRangeNotSatisfiableError(10), http.StatusRequestedRangeNotSatisfiable, CodeRangeNotSatisfiable},
+		{"upload offset", domainErrors.NewUploadOffsetError(5), http.StatusConflict, CodeConflict},
+		{"internal", errors.New("pq: connection refused"), http.StatusInternalServerError, CodeInternal},
+	}
+
+	for _, tc := range cases {
+		// Act
+		err := FromError(tc.err)
+
+		// Assert
+		var p *Problem
+		require.True(t, errors.As(err, &p), tc.name)
+		assert.Equal(t, tc.status, p.Status, tc.name)
+		assert.Equal(t, tc.code, p.Code, tc.name)
+		assert.Equal(t, http.StatusText(tc.status), p.Title, tc.name)
+	}
+}
+
+// TestFromError_FieldErrors tests that every field error of a validation error is reported
+func TestFromError_FieldErrors(t *testing.T) {
+	// Arrange
+	verr := &domainErrors.ValidationError{}
+	verr.Add("sku", "required", "SKU is required")
+	verr.Add("price", "must_be_positive", "Price must be greater than 0")
+
+	// Act
+	err := FromError(verr)
+
+	// Assert
+	var p *Problem
+	require.True(t, errors.As(err, &p))
+	assert.Equal(t, []FieldError{
+		{Field: "sku", Code: "sku.required", Message: "SKU is required"},
+		{Field: "price", Code: "price.must_be_positive", Message: "Price must be greater than 0"},
+	}, p.Errors)
+}
+
+// TestFromError_DuplicateFieldError tests that duplicates name the conflicting field
+func TestFromError_DuplicateFieldError(t *testing.T) {
+	// Act
+	err := FromError(domainErrors.NewDuplicateError("Brand", "name", "Acme"))
+
+	// Assert
+	var p *Problem
+	require.True(t, errors.As(err, &p))
+	require.Len(t, p.Errors, 1)
+	assert.Equal(t, "name.duplicate", p.Errors[0].Code)
+}
+
+// TestFromError_DoesNotLeakInternalErrors tests that unexpected errors are reported without their message
+func TestFromError_DoesNotLeakInternalErrors(t *testing.T) {
+	// Act
+	err := FromError(errors.New(`pq: duplicate key value violates unique constraint "products_sku_key"`))
+
+	// Assert
+	assert.NotContains(t, err.Error(), "pq:")
+	assert.NotContains(t, err.Error(), "products_sku_key")
+}
+
+// TestFromError_PassesThroughStatusErrors tests that errors which already carry a status are kept
+func TestFromError_PassesThroughStatusErrors(t *testing.T) {
+	// Arrange
+	original := New(http.StatusTeapot, "short and stout")
+
+	// Act
+	err := FromError(original)
+
+	// Assert
+	assert.Same(t, original, err)
+}
+
+// TestNew_ResponseFormat tests that errors, including Huma's request validation, are written as problem+json
+func TestNew_ResponseFormat(t *testing.T) {
+	// Arrange
+	previous := huma.NewError
+	huma.NewError = New
+	t.Cleanup(func() { huma.NewError = previous })
+
+	_, api := humatest.New(t)
+	huma.Register(api, huma.Operation{
+		OperationID: "create-thing",
+		Method:      http.MethodPost,
+		Path:        "/things",
+	}, func(ctx context.Context, input *struct {
+		Body struct {
+			Price float64 `json:"price" minimum:"0"`
+		}
+	}) (*struct{}, error) {
+		return nil, FromError(domainErrors.NewValidationError("price", "must_be_positive", "Price must be greater than 0"))
+	})
+
+	cases := []struct {
+		name  string
+		body  map[string]any
+		code  string
+		field string
+	}{
+		{"domain validation", map[string]any{"price": 0}, "price.must_be_positive", "price"},
+		{"schema validation", map[string]any{"price": -1}, "price.invalid", "price"},
+	}
+
+	for _, tc := range cases {
+		// Act
+		resp := api.Post("/things", tc.body)
+
+		// Assert
+		assert.Equal(t, ContentType, resp.Header().Get("Content-Type"), tc.name)
+
+		var p Problem
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &p), tc.name)
+		assert.Equal(t, resp.Code, p.Status, tc.name)
+		require.NotEmpty(t, p.Errors, tc.name)
+		assert.Equal(t, tc.field, p.Errors[0].Field, tc.name)
+		assert.Equal(t, tc.code, p.Errors[0].Code, tc.name)
+	}
+}
+
+// TestNewTransformer tests that problems carry the ID of their request and that the causes of server
+// errors are logged with it without being sent
+func TestNewTransformer(t *testing.T) {
+	// Arrange
+	var logs bytes.Buffer
+	previous := huma.NewError
+	huma.NewError = New
+	t.Cleanup(func() { huma.NewError = previous })
+
+	config := huma.DefaultConfig("Test API", "1.0.0")
+	config.Transformers = append(config.Transformers, NewTransformer(slog.New(slog.NewJSONHandler(&logs, nil))))
+	_, api := humatest.New(t, config)
+	api.UseMiddleware(func(ctx huma.Context, next func(huma.Context)) {
+		next(huma.WithContext(ctx, entities.ContextWithRequestID(ctx.Context(), "req-42")))
+	})
+	huma.Get(api, "/things", func(ctx context.Context, input *struct{}) (*struct{}, error) {
+		return nil, FromError(errors.New("pq: connection refused"))
+	})
+
+	// Act
+	resp := api.Get("/things")
+
+	// Assert
+	var body Problem
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Equal(t, http.StatusInternalServerError, body.Status)
+	assert.Equal(t, "req-42", body.RequestID)
+	assert.NotContains(t, resp.Body.String(), "connection refused")
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &line))
+	assert.Equal(t, "ERROR", line["level"])
+	assert.Equal(t, "pq: connection refused", line["error"])
+	assert.EqualValues(t, http.StatusInternalServerError, line["status"])
+}
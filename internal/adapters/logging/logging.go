@@ -0,0 +1,81 @@
+// Package logging creates the structured logger of the API. Log lines carry the request ID and tenant of
+// the context they are logged with, and attributes whose key names a credential are redacted.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"example.com/go-yippi/internal/domain/entities"
+	"example.com/go-yippi/internal/infrastructure/config"
+)
+
+// Redacted replaces the values of sensitive attributes
+const Redacted = "[REDACTED]"
+
+// New creates a logger writing to w in the configured format and level
+func New(cfg config.LogConfig, w io.Writer) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, fmt.Errorf("unknown log level %q: expected debug, info, warn or error", cfg.Level)
+	}
+
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redact(cfg.RedactKeys)}
+	var handler slog.Handler
+	switch cfg.Format {
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q: expected text or json", cfg.Format)
+	}
+	return slog.New(&contextHandler{handler}), nil
+}
+
+// redact returns a ReplaceAttr function hiding the values of attributes whose key contains one of keys,
+// ignoring case
+func redact(keys []string) func(groups []string, a slog.Attr) slog.Attr {
+	lowered := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lowered = append(lowered, strings.ToLower(key))
+	}
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if a.Value.Kind() == slog.KindGroup {
+			return a
+		}
+		key := strings.ToLower(a.Key)
+		for _, sensitive := range lowered {
+			if strings.Contains(key, sensitive) {
+				return slog.String(a.Key, Redacted)
+			}
+		}
+		return a
+	}
+}
+
+// contextHandler adds the request ID and tenant of the context to each record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if requestID, ok := entities.RequestIDFromContext(ctx); ok {
+		r.AddAttrs(slog.String("request_id", requestID))
+	}
+	if tenantID, ok := entities.TenantFromContext(ctx); ok {
+		r.AddAttrs(slog.String("tenant", tenantID))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{h.Handler.WithGroup(name)}
+}
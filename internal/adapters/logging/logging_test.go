@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"example.com/go-yippi/internal/domain/entities"
+	"example.com/go-yippi/internal/infrastructure/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNew_JSON tests that log lines carry the request ID and tenant of their context and that sensitive
+// attributes are redacted, also inside groups
+func TestNew_JSON(t *testing.T) {
+	// Arrange
+	var out bytes.Buffer
+	logger, err := New(config.LogConfig{Format: "json", Level: "info", RedactKeys: []string{"password", "Token"}}, &out)
+	require.NoError(t, err)
+	ctx := entities.ContextWithTenant(entities.ContextWithRequestID(context.Background(), "req-1"), "acme")
+
+	// Act
+	logger.InfoContext(ctx, "user created",
+		slog.String("email", "a@b.c"),
+		slog.String("password", "hunter2"),
+		slog.Group("auth", slog.String("access_token", "abc")))
+
+	// Assert
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	assert.Equal(t, "user created", line["msg"])
+	assert.Equal(t, "req-1", line["request_id"])
+	assert.Equal(t, "acme", line["tenant"])
+	assert.Equal(t, "a@b.c", line["email"])
+	assert.Equal(t, Redacted, line["password"])
+	assert.Equal(t, map[string]any{"access_token": Redacted}, line["auth"])
+}
+
+// TestNew_Level tests that text output leaves out records below the configured level
+func TestNew_Level(t *testing.T) {
+	// Arrange
+	var out bytes.Buffer
+	logger, err := New(config.LogConfig{Format: "text", Level: "warn"}, &out)
+	require.NoError(t, err)
+
+	// Act
+	logger.With("component", "gc").Info("skipped")
+	logger.With("component", "gc").Warn("kept")
+
+	// Assert
+	assert.NotContains(t, out.String(), "skipped")
+	assert.True(t, strings.Contains(out.String(), "level=WARN msg=kept component=gc"), out.String())
+}
+
+// TestNew_InvalidConfig tests that unknown formats and levels are rejected
+func TestNew_InvalidConfig(t *testing.T) {
+	// Act
+	_, formatErr := New(config.LogConfig{Format: "xml", Level: "info"}, &bytes.Buffer{})
+	_, levelErr := New(config.LogConfig{Format: "json", Level: "verbose"}, &bytes.Buffer{})
+
+	// Assert
+	assert.ErrorContains(t, formatErr, "unknown log format")
+	assert.ErrorContains(t, levelErr, "unknown log level")
+}
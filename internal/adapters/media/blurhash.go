@@ -0,0 +1,143 @@
+package media
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+)
+
+// BlurHash (https://blurha.sh) encodes the low frequencies of an image's colours as a short string,
+// from which clients render a blurred placeholder while the image loads.
+
+const base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// blurHash encodes img with xComponents by yComponents components (1 to 9 each)
+func blurHash(img *image.NRGBA, xComponents, yComponents int) string {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+
+	// Cosine transform of the linear colours
+	factors := make([][3]float64, 0, xComponents*yComponents)
+	for j := 0; j < yComponents; j++ {
+		for i := 0; i < xComponents; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+
+			var factor [3]float64
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					basis := normalisation *
+						math.Cos(math.Pi*float64(i)*float64(x)/float64(width)) *
+						math.Cos(math.Pi*float64(j)*float64(y)/float64(height))
+					p := img.PixOffset(x, y)
+					for c := 0; c < 3; c++ {
+						factor[c] += basis * sRGBToLinear(img.Pix[p+c])
+					}
+				}
+			}
+			scale := 1 / float64(width*height)
+			for c := range factor {
+				factor[c] *= scale
+			}
+			factors = append(factors, factor)
+		}
+	}
+
+	var hash strings.Builder
+	hash.WriteString(encodeBase83((xComponents-1)+(yComponents-1)*9, 1))
+
+	dc, ac := factors[0], factors[1:]
+	maximumValue := 1.0
+	if len(ac) > 0 {
+		actualMax := 0.0
+		for _, factor := range ac {
+			for _, v := range factor {
+				actualMax = math.Max(actualMax, math.Abs(v))
+			}
+		}
+		quantisedMax := int(math.Max(0, math.Min(82, math.Floor(actualMax*166-0.5))))
+		maximumValue = float64(quantisedMax+1) / 166
+		hash.WriteString(encodeBase83(quantisedMax, 1))
+	} else {
+		hash.WriteString(encodeBase83(0, 1))
+	}
+
+	hash.WriteString(encodeBase83(linearToSRGB(dc[0])<<16|linearToSRGB(dc[1])<<8|linearToSRGB(dc[2]), 4))
+	for _, factor := range ac {
+		quantised := 0
+		for _, v := range factor {
+			q := int(math.Max(0, math.Min(18, math.Floor(signPow(v/maximumValue, 0.5)*9+9.5))))
+			quantised = quantised*19 + q
+		}
+		hash.WriteString(encodeBase83(quantised, 2))
+	}
+
+	return hash.String()
+}
+
+// dominantColor returns the most common colour of img as #rrggbb. Colours are grouped into buckets of
+// similar colours and the average of the largest bucket is returned; mostly transparent pixels are ignored.
+func dominantColor(img *image.NRGBA) string {
+	type bucket struct {
+		count   int
+		r, g, b int
+	}
+	buckets := map[int]*bucket{}
+	var largest *bucket
+
+	for p := 0; p < len(img.Pix); p += 4 {
+		if img.Pix[p+3] < 128 {
+			continue
+		}
+		r, g, b := int(img.Pix[p]), int(img.Pix[p+1]), int(img.Pix[p+2])
+		key := r>>3<<10 | g>>3<<5 | b>>3
+		bk := buckets[key]
+		if bk == nil {
+			bk = &bucket{}
+			buckets[key] = bk
+		}
+		bk.count++
+		bk.r += r
+		bk.g += g
+		bk.b += b
+		if largest == nil || bk.count > largest.count {
+			largest = bk
+		}
+	}
+
+	if largest == nil {
+		return ""
+	}
+	return fmt.Sprintf("#%02x%02x%02x", largest.r/largest.count, largest.g/largest.count, largest.b/largest.count)
+}
+
+func encodeBase83(value, length int) string {
+	out := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		out[i] = base83Chars[value%83]
+		value /= 83
+	}
+	return string(out)
+}
+
+func sRGBToLinear(v uint8) float64 {
+	f := float64(v) / 255
+	if f <= 0.04045 {
+		return f / 12.92
+	}
+	return math.Pow((f+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(v float64) int {
+	v = math.Max(0, math.Min(1, v))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(v, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(v), exp), v)
+}
@@ -0,0 +1,171 @@
+// Package media processes image files with pure Go codecs: decoding with the EXIF orientation applied,
+// resizing, re-encoding without metadata and computing placeholders.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+
+	"example.com/go-yippi/internal/domain/entities"
+	"github.com/disintegration/imaging"
+
+	// The WebP codec, libwebp compiled to WebAssembly, registers its decoder with image.Decode; imaging
+	// registers the other formats
+	"github.com/gen2brain/webp"
+)
+
+const (
+	// defaultMaxPixels bounds the size of decoded images, which take 4 bytes per pixel in memory
+	defaultMaxPixels = 50_000_000
+	// analysisSize is the size images are scaled down to before they are analysed
+	analysisSize = 64
+	// blurHash components; 4x3 suits the usual landscape and square product shots
+	blurHashX = 4
+	blurHashY = 3
+)
+
+// supportedTypes are the content types Processor decodes
+var supportedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// Processor implements ImageProcessor
+type Processor struct {
+	quality   int
+	maxPixels int
+}
+
+// NewProcessor creates an image processor that writes lossy JPEG and WebP images with the given quality (1-100)
+func NewProcessor(quality int) *Processor {
+	return &Processor{quality: quality, maxPixels: defaultMaxPixels}
+}
+
+// Supports reports whether images of the content type can be decoded
+func (p *Processor) Supports(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return supportedTypes[strings.ToLower(strings.TrimSpace(mediaType))]
+}
+
+// Decode decodes an image and applies its EXIF orientation. Images above the pixel limit are rejected
+// before they are decoded. Only the header read to check the limit is buffered; the rest is decoded as
+// it is read.
+func (p *Processor) Decode(r io.Reader) (image.Image, error) {
+	var header bytes.Buffer
+	config, _, err := image.DecodeConfig(io.TeeReader(r, &header))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	if config.Width*config.Height > p.maxPixels {
+		return nil, fmt.Errorf("image of %dx%d pixels exceeds the limit of %d pixels", config.Width, config.Height, p.maxPixels)
+	}
+
+	img, err := imaging.Decode(io.MultiReader(&header, r), imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, nil
+}
+
+// Analyze returns the dimensions, blurhash and dominant colour of an image
+func (p *Processor) Analyze(img image.Image) *entities.ImageInfo {
+	bounds := img.Bounds()
+	small := imaging.Fit(img, analysisSize, analysisSize, imaging.Box)
+
+	return &entities.ImageInfo{
+		Width:         bounds.Dx(),
+		Height:        bounds.Dy(),
+		BlurHash:      blurHash(small, blurHashX, blurHashY),
+		DominantColor: dominantColor(small),
+	}
+}
+
+// Transform resizes an image and writes it in the format of the transform. The encoders write no
+// metadata, so EXIF and other embedded data of the original are dropped.
+func (p *Processor) Transform(img image.Image, transform entities.ImageTransform, w io.Writer) (int, int, error) {
+	resized := resize(img, transform)
+
+	var err error
+	switch transform.Format {
+	case entities.ImageFormatJPEG:
+		err = jpeg.Encode(w, flatten(resized), &jpeg.Options{Quality: p.quality})
+	case entities.ImageFormatPNG:
+		err = png.Encode(w, resized)
+	case entities.ImageFormatWebP:
+		err = webp.Encode(w, resized, webp.Options{Quality: p.quality})
+	default:
+		err = errors.New("unsupported image format " + string(transform.Format))
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	bounds := resized.Bounds()
+	return bounds.Dx(), bounds.Dy(), nil
+}
+
+// resize scales an image to the box of a transform. Images are only enlarged to fill the box.
+func resize(img image.Image, t entities.ImageTransform) image.Image {
+	bounds := img.Bounds()
+	width, height := float64(bounds.Dx()), float64(bounds.Dy())
+	if t.Width == 0 && t.Height == 0 {
+		return img
+	}
+
+	if t.Fit == entities.ImageFitFill {
+		w, h := t.Width, t.Height
+		if w == 0 {
+			w = bounds.Dx()
+		}
+		if h == 0 {
+			h = bounds.Dy()
+		}
+		return imaging.Resize(img, w, h, imaging.Lanczos)
+	}
+
+	// The scale at which the image fits within the box; a missing side does not constrain it
+	fit := math.Inf(1)
+	if t.Width > 0 {
+		fit = float64(t.Width) / width
+	}
+	if t.Height > 0 {
+		fit = math.Min(fit, float64(t.Height)/height)
+	}
+
+	if t.Fit == entities.ImageFitCover && t.Width > 0 && t.Height > 0 {
+		cover := math.Max(float64(t.Width)/width, float64(t.Height)/height)
+		// Without enlarging, cover the largest box of the requested aspect ratio the image allows
+		w, h := float64(t.Width), float64(t.Height)
+		if cover > 1 {
+			w, h = w/cover, h/cover
+		}
+		return imaging.Fill(img, max(int(math.Round(w)), 1), max(int(math.Round(h)), 1), imaging.Center, imaging.Lanczos)
+	}
+
+	if fit >= 1 {
+		return img
+	}
+	w := max(int(math.Round(width*fit)), 1)
+	h := max(int(math.Round(height*fit)), 1)
+	return imaging.Resize(img, w, h, imaging.Lanczos)
+}
+
+// flatten draws an image with transparency onto white, as JPEG has no alpha channel
+func flatten(img image.Image) image.Image {
+	bounds := img.Bounds()
+	flat := image.NewRGBA(bounds)
+	draw.Draw(flat, bounds, &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(flat, bounds, img, bounds.Min, draw.Over)
+	return flat
+}
@@ -0,0 +1,273 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"example.com/go-yippi/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/webp"
+)
+
+// withEXIFOrientation inserts an EXIF segment with the given orientation after the SOI marker of a JPEG
+func withEXIFOrientation(t *testing.T, data []byte, orientation uint16) []byte {
+	t.Helper()
+
+	tiff := []byte("II*\x00")
+	tiff = binary.LittleEndian.AppendUint32(tiff, 8)     // offset of the first IFD
+	tiff = binary.LittleEndian.AppendUint16(tiff, 1)     // one entry
+	tiff = binary.LittleEndian.AppendUint16(tiff, 0x112) // orientation tag
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3)     // SHORT
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0)
+	tiff = binary.LittleEndian.AppendUint32(tiff, 0) // no next IFD
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xff, 0xe1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	require.Equal(t, []byte{0xff, 0xd8}, data[:2])
+	return append(append([]byte{0xff, 0xd8}, segment...), data[2:]...)
+}
+
+// testImage returns an image of the given size with the pixels drawn by pixel
+func testImage(width, height int, pixel func(x, y int) color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, pixel(x, y))
+		}
+	}
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+	return buf.Bytes()
+}
+
+// TestProcessor_DecodeAppliesOrientation tests that images are rotated as their EXIF orientation says
+// and that transformed images carry no EXIF
+func TestProcessor_DecodeAppliesOrientation(t *testing.T) {
+	// Arrange: a landscape photo taken with the camera rotated 90° clockwise
+	processor := NewProcessor(85)
+	original := testImage(40, 20, func(x, y int) color.NRGBA { return color.NRGBA{uint8(x * 6), 100, 50, 255} })
+	data := withEXIFOrientation(t, encodeJPEG(t, original), 6)
+
+	// Act
+	img, err := processor.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	var out bytes.Buffer
+	width, height, transformErr := processor.Transform(img, entities.ImageTransform{Width: 10, Fit: entities.ImageFitContain, Format: entities.ImageFormatJPEG}, &out)
+
+	// Assert
+	assert.Equal(t, image.Rect(0, 0, 20, 40), img.Bounds())
+	require.NoError(t, transformErr)
+	assert.Equal(t, 10, width)
+	assert.Equal(t, 20, height)
+	assert.False(t, bytes.Contains(out.Bytes(), []byte("Exif")))
+}
+
+// TestProcessor_Decode_Invalid tests that content that is not an image is rejected
+func TestProcessor_Decode_Invalid(t *testing.T) {
+	// Arrange
+	processor := NewProcessor(85)
+
+	// Act
+	_, err := processor.Decode(strings.NewReader("not an image"))
+
+	// Assert
+	assert.Error(t, err)
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+// TestProcessor_Decode_TooLarge tests that images above the pixel limit are rejected after reading
+// their header only
+func TestProcessor_Decode_TooLarge(t *testing.T) {
+	// Arrange: noise does not compress, so the image is far larger than its header
+	processor := NewProcessor(85)
+	processor.maxPixels = 100
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, testImage(200, 200, func(x, y int) color.NRGBA {
+		return color.NRGBA{uint8(x * y), uint8(x ^ y), uint8(x*7 + y*13), 255}
+	})))
+	reader := &countingReader{r: bytes.NewReader(buf.Bytes())}
+
+	// Act
+	_, err := processor.Decode(reader)
+
+	// Assert
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds the limit")
+	assert.Less(t, reader.n, buf.Len()/2)
+}
+
+// TestProcessor_Transform tests the fits and output formats
+func TestProcessor_Transform(t *testing.T) {
+	// Arrange: a 400x200 image
+	processor := NewProcessor(85)
+	img := testImage(400, 200, func(x, y int) color.NRGBA { return color.NRGBA{uint8(x), uint8(y), 0, 255} })
+
+	cases := []struct {
+		name          string
+		transform     entities.ImageTransform
+		width, height int
+	}{
+		{name: "contain", transform: entities.ImageTransform{Width: 100, Height: 100, Fit: entities.ImageFitContain}, width: 100, height: 50},
+		{name: "contain by height", transform: entities.ImageTransform{Height: 50, Fit: entities.ImageFitContain}, width: 100, height: 50},
+		{name: "contain never enlarges", transform: entities.ImageTransform{Width: 800, Height: 800, Fit: entities.ImageFitContain}, width: 400, height: 200},
+		{name: "cover crops", transform: entities.ImageTransform{Width: 100, Height: 100, Fit: entities.ImageFitCover}, width: 100, height: 100},
+		{name: "cover keeps aspect without enlarging", transform: entities.ImageTransform{Width: 600, Height: 600, Fit: entities.ImageFitCover}, width: 200, height: 200},
+		{name: "fill stretches", transform: entities.ImageTransform{Width: 50, Height: 80, Fit: entities.ImageFitFill}, width: 50, height: 80},
+		{name: "original size", transform: entities.ImageTransform{Fit: entities.ImageFitContain}, width: 400, height: 200},
+	}
+
+	for _, format := range []entities.ImageFormat{entities.ImageFormatJPEG, entities.ImageFormatPNG, entities.ImageFormatWebP} {
+		for _, tc := range cases {
+			tc.transform.Format = format
+
+			// Act
+			var out bytes.Buffer
+			width, height, err := processor.Transform(img, tc.transform, &out)
+
+			// Assert
+			require.NoError(t, err, tc.name)
+			assert.Equal(t, tc.width, width, "%s %s", format, tc.name)
+			assert.Equal(t, tc.height, height, "%s %s", format, tc.name)
+			decoded, name, err := image.Decode(&out)
+			require.NoError(t, err, tc.name)
+			assert.Equal(t, string(format), name)
+			assert.Equal(t, image.Pt(tc.width, tc.height), decoded.Bounds().Size(), "%s %s", format, tc.name)
+		}
+	}
+}
+
+// TestProcessor_TransformFormats tests that transparency is kept in PNG and WebP and flattened onto white in JPEG
+func TestProcessor_TransformFormats(t *testing.T) {
+	// Arrange
+	processor := NewProcessor(100)
+	img := testImage(8, 8, func(x, y int) color.NRGBA { return color.NRGBA{0, 0, 0, 0} })
+	transform := entities.ImageTransform{Fit: entities.ImageFitContain}
+
+	// Act
+	var jpegOut, pngOut, webpOut bytes.Buffer
+	transform.Format = entities.ImageFormatJPEG
+	_, _, jpegErr := processor.Transform(img, transform, &jpegOut)
+	transform.Format = entities.ImageFormatPNG
+	_, _, pngErr := processor.Transform(img, transform, &pngOut)
+	transform.Format = entities.ImageFormatWebP
+	_, _, webpErr := processor.Transform(img, transform, &webpOut)
+
+	// Assert
+	require.NoError(t, jpegErr)
+	decodedJPEG, err := jpeg.Decode(&jpegOut)
+	require.NoError(t, err)
+	r, g, b, _ := decodedJPEG.At(4, 4).RGBA()
+	assert.Greater(t, r>>8, uint32(250))
+	assert.Greater(t, g>>8, uint32(250))
+	assert.Greater(t, b>>8, uint32(250))
+
+	require.NoError(t, pngErr)
+	decodedPNG, err := png.Decode(&pngOut)
+	require.NoError(t, err)
+	_, _, _, a := decodedPNG.At(4, 4).RGBA()
+	assert.Zero(t, a)
+
+	require.NoError(t, webpErr)
+	decodedWebP, err := webp.Decode(&webpOut)
+	require.NoError(t, err)
+	_, _, _, a = decodedWebP.At(4, 4).RGBA()
+	assert.Zero(t, a)
+}
+
+// TestProcessor_TransformWebP tests that WebP output is lossy and smaller than PNG for photo-like images
+func TestProcessor_TransformWebP(t *testing.T) {
+	// Arrange: a gradient with grain, like a product shot
+	processor := NewProcessor(85)
+	rng := rand.New(rand.NewSource(1))
+	img := testImage(400, 300, func(x, y int) color.NRGBA {
+		grain := uint8(rng.Intn(8))
+		return color.NRGBA{uint8(x/2) + grain, uint8(y/2) + grain, uint8(x+y) / 3, 255}
+	})
+	transform := entities.ImageTransform{Fit: entities.ImageFitContain}
+
+	// Act
+	var pngOut, webpOut bytes.Buffer
+	transform.Format = entities.ImageFormatPNG
+	_, _, pngErr := processor.Transform(img, transform, &pngOut)
+	transform.Format = entities.ImageFormatWebP
+	_, _, webpErr := processor.Transform(img, transform, &webpOut)
+
+	// Assert
+	require.NoError(t, pngErr)
+	require.NoError(t, webpErr)
+	assert.Less(t, webpOut.Len(), pngOut.Len()/2)
+	decoded, err := webp.Decode(&webpOut)
+	require.NoError(t, err)
+	r, g, _, _ := decoded.At(200, 150).RGBA()
+	assert.InDelta(t, 100, int(r>>8), 12)
+	assert.InDelta(t, 75, int(g>>8), 12)
+}
+
+// TestProcessor_Analyze tests the dimensions, blurhash and dominant colour of an image
+func TestProcessor_Analyze(t *testing.T) {
+	// Arrange: mostly red with a blue stripe
+	processor := NewProcessor(85)
+	img := testImage(200, 100, func(x, y int) color.NRGBA {
+		if x < 40 {
+			return color.NRGBA{0, 0, 255, 255}
+		}
+		return color.NRGBA{255, 0, 0, 255}
+	})
+	solid := testImage(10, 10, func(x, y int) color.NRGBA { return color.NRGBA{255, 0, 0, 255} })
+
+	// Act
+	info := processor.Analyze(img)
+	solidInfo := processor.Analyze(solid)
+
+	// Assert
+	assert.Equal(t, 200, info.Width)
+	assert.Equal(t, 100, info.Height)
+	assert.Equal(t, "#ff0000", info.DominantColor)
+	assert.Len(t, info.BlurHash, 28)
+	assert.NotEqual(t, solidInfo.BlurHash, info.BlurHash)
+	// A size flag of 4x3 components, then the maximum AC value and the average colour
+	assert.Equal(t, "L", solidInfo.BlurHash[:1])
+	assert.Equal(t, encodeBase83(0xff0000, 4), solidInfo.BlurHash[2:6])
+}
+
+// TestProcessor_Supports tests the content types that can be decoded
+func TestProcessor_Supports(t *testing.T) {
+	processor := NewProcessor(85)
+
+	assert.True(t, processor.Supports("image/jpeg"))
+	assert.True(t, processor.Supports("image/PNG; charset=binary"))
+	assert.True(t, processor.Supports("image/webp"))
+	assert.False(t, processor.Supports("image/svg+xml"))
+	assert.False(t, processor.Supports("application/pdf"))
+}
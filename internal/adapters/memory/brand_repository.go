@@ -0,0 +1,169 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/google/uuid"
+)
+
+// brandNameMaxLen is the length limit of brand names in the brand schema
+const brandNameMaxLen = 255
+
+// BrandRepository implements the BrandRepository interface in memory
+type BrandRepository struct {
+	store *Store
+}
+
+// NewBrandRepository returns a brand repository on store
+func NewBrandRepository(store *Store) *BrandRepository {
+	return &BrandRepository{store: store}
+}
+
+func (r *BrandRepository) Create(ctx context.Context, b *entities.Brand) error {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return err
+	}
+	if err := validateBrand(b); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if err := r.checkName(tenantID, b, uuid.Nil); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	b.ID = uuid.New()
+	b.CreatedAt = now
+	b.UpdatedAt = now
+	r.store.brands[b.ID] = &brandRow{tenantID: tenantID, seq: r.store.nextSeq(), brand: *b}
+	return nil
+}
+
+func (r *BrandRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.Brand, error) {
+	brands, err := r.list(ctx, func(b *entities.Brand) bool { return b.ID == id })
+	if err != nil {
+		return nil, err
+	}
+	if len(brands) == 0 {
+		return nil, domainErrors.NewNotFoundError("Brand", id)
+	}
+	return brands[0], nil
+}
+
+func (r *BrandRepository) GetByName(ctx context.Context, name string) (*entities.Brand, error) {
+	brands, err := r.list(ctx, func(b *entities.Brand) bool { return b.Name == name })
+	if err != nil {
+		return nil, err
+	}
+	if len(brands) == 0 {
+		return nil, domainErrors.NewNotFoundError("Brand", name)
+	}
+	return brands[0], nil
+}
+
+func (r *BrandRepository) List(ctx context.Context) ([]*entities.Brand, error) {
+	return r.list(ctx, func(*entities.Brand) bool { return true })
+}
+
+func (r *BrandRepository) Update(ctx context.Context, b *entities.Brand) error {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return err
+	}
+	if err := validateBrand(b); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	row, ok := r.store.brands[b.ID]
+	if !ok || row.tenantID != tenantID {
+		return domainErrors.NewNotFoundError("Brand", b.ID)
+	}
+	if err := r.checkName(tenantID, b, b.ID); err != nil {
+		return err
+	}
+
+	row.brand.Name = b.Name
+	row.brand.UpdatedAt = time.Now()
+	b.UpdatedAt = row.brand.UpdatedAt
+	return nil
+}
+
+// Delete removes a brand; its products lose their brand
+func (r *BrandRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	row, ok := r.store.brands[id]
+	if !ok || row.tenantID != tenantID {
+		return domainErrors.NewNotFoundError("Brand", id)
+	}
+	delete(r.store.brands, id)
+
+	// References are cleared in every tenant, like foreign keys
+	for _, prod := range r.store.products {
+		if prod.product.BrandID != nil && *prod.product.BrandID == id {
+			prod.product.BrandID = nil
+		}
+	}
+	return nil
+}
+
+// list returns the brands of the tenant that match, in the order they were created
+func (r *BrandRepository) list(ctx context.Context, match func(*entities.Brand) bool) ([]*entities.Brand, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	rows := make([]*brandRow, 0)
+	for _, row := range r.store.brands {
+		if row.tenantID == tenantID && match(&row.brand) {
+			rows = append(rows, row)
+		}
+	}
+	slices.SortFunc(rows, func(a, b *brandRow) int { return a.seq - b.seq })
+
+	brands := make([]*entities.Brand, len(rows))
+	for i, row := range rows {
+		b := row.brand
+		brands[i] = &b
+	}
+	return brands, nil
+}
+
+// checkName checks that the name of a brand is unique within its tenant; id is the brand being updated,
+// uuid.Nil for new brands. The caller holds the lock.
+func (r *BrandRepository) checkName(tenantID string, b *entities.Brand, id uuid.UUID) error {
+	for _, row := range r.store.brands {
+		if row.tenantID == tenantID && row.brand.ID != id && row.brand.Name == b.Name {
+			return domainErrors.NewDuplicateError("Brand", "name", b.Name)
+		}
+	}
+	return nil
+}
+
+// validateBrand applies the field validators of the brand schema
+func validateBrand(b *entities.Brand) error {
+	switch {
+	case b.Name == "":
+		return invalid("name", msgTooShort)
+	case len(b.Name) > brandNameMaxLen:
+		return invalid("name", msgTooLong)
+	}
+	return nil
+}
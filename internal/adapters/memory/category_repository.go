@@ -0,0 +1,210 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/google/uuid"
+)
+
+// CategoryRepository implements the CategoryRepository interface in memory
+type CategoryRepository struct {
+	store *Store
+}
+
+// NewCategoryRepository returns a category repository on store
+func NewCategoryRepository(store *Store) *CategoryRepository {
+	return &CategoryRepository{store: store}
+}
+
+func (r *CategoryRepository) Create(ctx context.Context, cat *entities.Category) error {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return err
+	}
+	if cat.Name == "" {
+		return invalid("name", msgTooShort)
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if err := r.checkConstraints(tenantID, cat, uuid.Nil); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	cat.ID = uuid.New()
+	cat.CreatedAt = now
+	cat.UpdatedAt = now
+	r.store.categories[cat.ID] = &categoryRow{tenantID: tenantID, seq: r.store.nextSeq(), category: storedCategory(cat)}
+	return nil
+}
+
+func (r *CategoryRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.Category, error) {
+	categories, err := r.list(ctx, func(c *entities.Category) bool { return c.ID == id })
+	if err != nil {
+		return nil, err
+	}
+	if len(categories) == 0 {
+		return nil, domainErrors.NewNotFoundError("Category", id)
+	}
+	return categories[0], nil
+}
+
+func (r *CategoryRepository) GetByName(ctx context.Context, name string) (*entities.Category, error) {
+	categories, err := r.list(ctx, func(c *entities.Category) bool { return c.Name == name })
+	if err != nil {
+		return nil, err
+	}
+	if len(categories) == 0 {
+		return nil, domainErrors.NewNotFoundError("Category", name)
+	}
+	return categories[0], nil
+}
+
+func (r *CategoryRepository) List(ctx context.Context) ([]*entities.Category, error) {
+	return r.list(ctx, func(*entities.Category) bool { return true })
+}
+
+func (r *CategoryRepository) ListByParentID(ctx context.Context, parentID *uuid.UUID) ([]*entities.Category, error) {
+	if parentID == nil {
+		// Get root categories (no parent)
+		return r.list(ctx, func(c *entities.Category) bool { return c.ParentID == nil })
+	}
+	return r.list(ctx, func(c *entities.Category) bool { return c.ParentID != nil && *c.ParentID == *parentID })
+}
+
+func (r *CategoryRepository) Update(ctx context.Context, cat *entities.Category) error {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return err
+	}
+	if cat.Name == "" {
+		return invalid("name", msgTooShort)
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	row, ok := r.store.categories[cat.ID]
+	if !ok || row.tenantID != tenantID {
+		return domainErrors.NewNotFoundError("Category", cat.ID)
+	}
+	if err := r.checkConstraints(tenantID, cat, cat.ID); err != nil {
+		return err
+	}
+
+	row.category.Name = cat.Name
+	row.category.ParentID = cloneUUID(cat.ParentID)
+	row.category.UpdatedAt = time.Now()
+	return nil
+}
+
+// Delete removes a category; its children become roots and its products lose their category
+func (r *CategoryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	row, ok := r.store.categories[id]
+	if !ok || row.tenantID != tenantID {
+		return domainErrors.NewNotFoundError("Category", id)
+	}
+	delete(r.store.categories, id)
+
+	// References are cleared in every tenant, like foreign keys
+	for _, child := range r.store.categories {
+		if child.category.ParentID != nil && *child.category.ParentID == id {
+			child.category.ParentID = nil
+		}
+	}
+	for _, prod := range r.store.products {
+		if prod.product.CategoryID != nil && *prod.product.CategoryID == id {
+			prod.product.CategoryID = nil
+		}
+	}
+	return nil
+}
+
+// GetDescendantIDs returns all descendant category IDs for the given category IDs (including the given IDs)
+func (r *CategoryRepository) GetDescendantIDs(ctx context.Context, categoryIDs []uuid.UUID) ([]uuid.UUID, error) {
+	if len(categoryIDs) == 0 {
+		return []uuid.UUID{}, nil
+	}
+	categories, err := r.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[uuid.UUID]bool, len(categoryIDs))
+	ids := make([]uuid.UUID, 0, len(categoryIDs))
+	for _, id := range categoryIDs {
+		if !result[id] {
+			result[id] = true
+			ids = append(ids, id)
+		}
+	}
+	// The IDs found so far are appended to, so the loop also visits the descendants
+	for i := 0; i < len(ids); i++ {
+		for _, c := range categories {
+			if c.ParentID != nil && *c.ParentID == ids[i] && !result[c.ID] {
+				result[c.ID] = true
+				ids = append(ids, c.ID)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// list returns the categories of the tenant that match, in the order they were created
+func (r *CategoryRepository) list(ctx context.Context, match func(*entities.Category) bool) ([]*entities.Category, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	rows := make([]*categoryRow, 0)
+	for _, row := range r.store.categories {
+		if row.tenantID == tenantID && match(&row.category) {
+			rows = append(rows, row)
+		}
+	}
+	slices.SortFunc(rows, func(a, b *categoryRow) int { return a.seq - b.seq })
+
+	categories := make([]*entities.Category, len(rows))
+	for i, row := range rows {
+		c := storedCategory(&row.category)
+		categories[i] = &c
+	}
+	return categories, nil
+}
+
+// checkConstraints checks the unique name of a category within its tenant and its parent; id is the
+// category being updated, uuid.Nil for new categories. The caller holds the lock.
+func (r *CategoryRepository) checkConstraints(tenantID string, cat *entities.Category, id uuid.UUID) error {
+	for _, row := range r.store.categories {
+		if row.tenantID == tenantID && row.category.ID != id && row.category.Name == cat.Name {
+			return domainErrors.NewDuplicateError("Category", "name", cat.Name)
+		}
+	}
+	if cat.ParentID != nil {
+		if _, ok := r.store.categories[*cat.ParentID]; !ok {
+			return missingReference("parent_id")
+		}
+	}
+	return nil
+}
+
+// storedCategory copies a category, so that callers cannot change stored rows through it
+func storedCategory(cat *entities.Category) entities.Category {
+	stored := *cat
+	stored.ParentID = cloneUUID(cat.ParentID)
+	return stored
+}
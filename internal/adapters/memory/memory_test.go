@@ -0,0 +1,33 @@
+package memory
+
+import (
+	"testing"
+
+	"example.com/go-yippi/internal/adapters/persistence/repositorytest"
+	"example.com/go-yippi/internal/adapters/persistence/storagetest"
+	"example.com/go-yippi/internal/domain/ports"
+	"github.com/google/uuid"
+)
+
+// TestRepositoryContract runs the repository contract tests against the in-memory repositories
+func TestRepositoryContract(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repositories {
+		store := NewStore()
+		return repositorytest.Repositories{
+			Products:   NewProductRepository(store),
+			Categories: NewCategoryRepository(store),
+			Brands:     NewBrandRepository(store),
+			Media:      NewProductMediaRepository(store),
+			Users:      NewUserRepository(store),
+			// The store holds no files and media do not check their references to them
+			NewFile: func(t *testing.T) uuid.UUID { return uuid.New() },
+		}
+	})
+}
+
+// TestStorageConformance runs the storage conformance tests against the in-memory storage
+func TestStorageConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) ports.StorageRepository {
+		return NewStorageRepository()
+	})
+}
@@ -0,0 +1,215 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/google/uuid"
+)
+
+// altTextMaxLen is the length limit of alternative texts in the product media schema
+const altTextMaxLen = 255
+
+// ProductMediaRepository implements the ProductMediaRepository interface in memory. The store holds no
+// files, so references to files are not checked and media keep the file they were created with.
+type ProductMediaRepository struct {
+	store *Store
+}
+
+// NewProductMediaRepository returns a product media repository on store
+func NewProductMediaRepository(store *Store) *ProductMediaRepository {
+	return &ProductMediaRepository{store: store}
+}
+
+func (r *ProductMediaRepository) Create(ctx context.Context, m *entities.ProductMedia) error {
+	return r.CreateBulk(ctx, []*entities.ProductMedia{m})
+}
+
+// CreateBulk creates the media after checking all of them, so that either all or none are created
+func (r *ProductMediaRepository) CreateBulk(ctx context.Context, media []*entities.ProductMedia) error {
+	if len(media) == 0 {
+		return nil
+	}
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return err
+	}
+	for _, m := range media {
+		if err := validateMedia(m); err != nil {
+			return err
+		}
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	// Media of the batch take their product and file pairs in turn
+	taken := make(map[mediaKey]bool)
+	for _, row := range r.store.media {
+		taken[mediaKey{row.media.ProductID, row.media.FileID}] = true
+	}
+	for _, m := range media {
+		if prod, ok := r.store.products[m.ProductID]; !ok || prod.tenantID != tenantID {
+			return missingReference("product_id")
+		}
+		key := mediaKey{m.ProductID, m.FileID}
+		if taken[key] {
+			return domainErrors.NewDuplicateError("ProductMedia", "product_id,file_id", fmt.Sprintf("%d,%s", m.ProductID, m.FileID))
+		}
+		taken[key] = true
+	}
+
+	now := time.Now()
+	for _, m := range media {
+		m.ID = uuid.New()
+		m.CreatedAt = now
+		m.UpdatedAt = now
+		r.store.media[m.ID] = &mediaRow{tenantID: tenantID, seq: r.store.nextSeq(), media: storedMedia(m)}
+	}
+	return nil
+}
+
+func (r *ProductMediaRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.ProductMedia, error) {
+	media, err := r.list(ctx, func(m *entities.ProductMedia) bool { return m.ID == id })
+	if err != nil {
+		return nil, err
+	}
+	if len(media) == 0 {
+		return nil, domainErrors.NewNotFoundError("ProductMedia", id)
+	}
+	return media[0], nil
+}
+
+func (r *ProductMediaRepository) ListByProduct(ctx context.Context, productID int) ([]*entities.ProductMedia, error) {
+	return r.ListByProducts(ctx, []int{productID})
+}
+
+func (r *ProductMediaRepository) ListByProducts(ctx context.Context, productIDs []int) ([]*entities.ProductMedia, error) {
+	return r.list(ctx, func(m *entities.ProductMedia) bool { return slices.Contains(productIDs, m.ProductID) })
+}
+
+func (r *ProductMediaRepository) Update(ctx context.Context, m *entities.ProductMedia) error {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return err
+	}
+	if err := validateMedia(m); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	row, ok := r.store.media[m.ID]
+	if !ok || row.tenantID != tenantID {
+		return domainErrors.NewNotFoundError("ProductMedia", m.ID)
+	}
+
+	row.media.Position = m.Position
+	row.media.AltText = m.AltText
+	row.media.IsPrimary = m.IsPrimary
+	row.media.Type = m.Type
+	row.media.UpdatedAt = time.Now()
+	m.UpdatedAt = row.media.UpdatedAt
+	return nil
+}
+
+func (r *ProductMediaRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	row, ok := r.store.media[id]
+	if !ok || row.tenantID != tenantID {
+		return domainErrors.NewNotFoundError("ProductMedia", id)
+	}
+	delete(r.store.media, id)
+	return nil
+}
+
+func (r *ProductMediaRepository) CountByFile(ctx context.Context, fileID uuid.UUID) (int, error) {
+	media, err := r.list(ctx, func(m *entities.ProductMedia) bool { return m.FileID == fileID })
+	return len(media), err
+}
+
+func (r *ProductMediaRepository) DeleteByFile(ctx context.Context, fileID uuid.UUID) error {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for id, row := range r.store.media {
+		if row.tenantID == tenantID && row.media.FileID == fileID {
+			delete(r.store.media, id)
+		}
+	}
+	return nil
+}
+
+// list returns the media of the tenant that match, by product and position
+func (r *ProductMediaRepository) list(ctx context.Context, match func(*entities.ProductMedia) bool) ([]*entities.ProductMedia, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	rows := make([]*mediaRow, 0)
+	for _, row := range r.store.media {
+		if row.tenantID == tenantID && match(&row.media) {
+			rows = append(rows, row)
+		}
+	}
+	slices.SortFunc(rows, func(a, b *mediaRow) int {
+		return cmp.Or(a.media.ProductID-b.media.ProductID, a.media.Position-b.media.Position, a.seq-b.seq)
+	})
+
+	media := make([]*entities.ProductMedia, len(rows))
+	for i, row := range rows {
+		m := storedMedia(&row.media)
+		media[i] = &m
+	}
+	return media, nil
+}
+
+// mediaKey is the product and file pair that identifies a media
+type mediaKey struct {
+	productID int
+	fileID    uuid.UUID
+}
+
+// validateMedia checks the fields of a media like the validators of the Ent schema
+func validateMedia(m *entities.ProductMedia) error {
+	switch {
+	case m.Position < 0:
+		return invalid("position", msgOutOfRange)
+	case len(m.AltText) > altTextMaxLen:
+		return invalid("alt_text", msgTooLong)
+	}
+
+	switch m.Type {
+	case entities.MediaTypeImage, entities.MediaTypeVideo, entities.MediaTypeDocument:
+		return nil
+	default:
+		return invalid("media_type", fmt.Sprintf("productmedia: invalid enum value for media_type field: %q", m.Type))
+	}
+}
+
+// storedMedia copies a media, with a copy of its file so that callers cannot change stored rows through it
+func storedMedia(m *entities.ProductMedia) entities.ProductMedia {
+	stored := *m
+	if m.File != nil {
+		file := *m.File
+		stored.File = &file
+	}
+	return stored
+}
@@ -0,0 +1,338 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/google/uuid"
+)
+
+// ProductRepository implements the ProductRepository interface in memory
+type ProductRepository struct {
+	store *Store
+}
+
+// NewProductRepository returns a product repository on store
+func NewProductRepository(store *Store) *ProductRepository {
+	return &ProductRepository{store: store}
+}
+
+func (r *ProductRepository) Create(ctx context.Context, prod *entities.Product) error {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return err
+	}
+	if err := validateProduct(prod); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	skus, slugs := r.uniqueValues(tenantID, 0)
+	if err := r.checkConstraints(prod, skus, slugs); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	r.store.lastProductID++
+	prod.ID = r.store.lastProductID
+	prod.CreatedAt = now
+	prod.UpdatedAt = now
+	r.store.products[prod.ID] = &productRow{tenantID: tenantID, product: storedProduct(prod)}
+	return nil
+}
+
+// CreateBulk creates the products after checking all of them, so that either all or none are created.
+// Timestamps that are set are kept; an unset update time defaults to the creation time.
+func (r *ProductRepository) CreateBulk(ctx context.Context, products []*entities.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return err
+	}
+	for _, prod := range products {
+		if err := validateProduct(prod); err != nil {
+			return err
+		}
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	// Products of the batch take their SKUs and slugs in turn
+	skus, slugs := r.uniqueValues(tenantID, 0)
+	for _, prod := range products {
+		if err := r.checkConstraints(prod, skus, slugs); err != nil {
+			return err
+		}
+		skus[prod.SKU] = true
+		slugs[prod.Slug] = true
+	}
+
+	now := time.Now()
+	for _, prod := range products {
+		if prod.CreatedAt.IsZero() {
+			prod.CreatedAt = now
+		}
+		if prod.UpdatedAt.IsZero() {
+			prod.UpdatedAt = prod.CreatedAt
+		}
+		r.store.lastProductID++
+		prod.ID = r.store.lastProductID
+		r.store.products[prod.ID] = &productRow{tenantID: tenantID, product: storedProduct(prod)}
+	}
+	return nil
+}
+
+func (r *ProductRepository) GetByID(ctx context.Context, id int) (*entities.Product, error) {
+	return r.find(ctx, id, func(p *entities.Product) bool { return p.ID == id })
+}
+
+func (r *ProductRepository) GetBySKU(ctx context.Context, sku string) (*entities.Product, error) {
+	return r.find(ctx, sku, func(p *entities.Product) bool { return p.SKU == sku })
+}
+
+func (r *ProductRepository) IDsBySKU(ctx context.Context, skus []string) (map[string]int, error) {
+	products, err := r.list(ctx, func(p *entities.Product) bool { return slices.Contains(skus, p.SKU) })
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]int, len(products))
+	for _, p := range products {
+		ids[p.SKU] = p.ID
+	}
+	return ids, nil
+}
+
+func (r *ProductRepository) GetBySlug(ctx context.Context, slug string) (*entities.Product, error) {
+	return r.find(ctx, slug, func(p *entities.Product) bool { return p.Slug == slug })
+}
+
+func (r *ProductRepository) List(ctx context.Context) ([]*entities.Product, error) {
+	return r.list(ctx, func(*entities.Product) bool { return true })
+}
+
+func (r *ProductRepository) ListByStatus(ctx context.Context, status entities.ProductStatus) ([]*entities.Product, error) {
+	return r.list(ctx, func(p *entities.Product) bool { return p.Status == status })
+}
+
+func (r *ProductRepository) ImageURLs(ctx context.Context) ([]string, error) {
+	products, err := r.list(ctx, func(p *entities.Product) bool { return len(p.ImageURLs) > 0 })
+	if err != nil {
+		return nil, err
+	}
+
+	var urls []string
+	for _, p := range products {
+		urls = append(urls, p.ImageURLs...)
+	}
+	return urls, nil
+}
+
+func (r *ProductRepository) Update(ctx context.Context, prod *entities.Product) error {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return err
+	}
+	if err := validateProduct(prod); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	row, ok := r.store.products[prod.ID]
+	if !ok || row.tenantID != tenantID {
+		return domainErrors.NewNotFoundError("Product", prod.ID)
+	}
+	skus, slugs := r.uniqueValues(tenantID, prod.ID)
+	if err := r.checkConstraints(prod, skus, slugs); err != nil {
+		return err
+	}
+
+	updated := storedProduct(prod)
+	// Image URLs are only replaced when given
+	if updated.ImageURLs == nil {
+		updated.ImageURLs = row.product.ImageURLs
+	}
+	updated.CreatedAt = row.product.CreatedAt
+	updated.UpdatedAt = time.Now()
+	row.product = updated
+	return nil
+}
+
+func (r *ProductRepository) Delete(ctx context.Context, id int) error {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	row, ok := r.store.products[id]
+	if !ok || row.tenantID != tenantID {
+		return domainErrors.NewNotFoundError("Product", id)
+	}
+	delete(r.store.products, id)
+
+	// Media go with their product, like the cascading foreign key
+	for mediaID, row := range r.store.media {
+		if row.media.ProductID == id {
+			delete(r.store.media, mediaID)
+		}
+	}
+	return nil
+}
+
+// Stats counts the products of the tenant by status, category and brand
+func (r *ProductRepository) Stats(ctx context.Context) (*entities.ProductStats, error) {
+	products, err := r.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &entities.ProductStats{
+		Total:      len(products),
+		ByStatus:   map[entities.ProductStatus]int{},
+		ByCategory: map[uuid.UUID]int{},
+		ByBrand:    map[uuid.UUID]int{},
+	}
+	for _, p := range products {
+		stats.ByStatus[p.Status]++
+		stats.ByCategory[uuidOrNil(p.CategoryID)]++
+		stats.ByBrand[uuidOrNil(p.BrandID)]++
+	}
+	return stats, nil
+}
+
+// find returns the product of the tenant that matches; key identifies it in the not-found error
+func (r *ProductRepository) find(ctx context.Context, key any, match func(*entities.Product) bool) (*entities.Product, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	for _, row := range r.store.products {
+		if row.tenantID == tenantID && match(&row.product) {
+			return row.entity(), nil
+		}
+	}
+	return nil, domainErrors.NewNotFoundError("Product", key)
+}
+
+// list returns the products of the tenant that match, in the order they were created
+func (r *ProductRepository) list(ctx context.Context, match func(*entities.Product) bool) ([]*entities.Product, error) {
+	tenantID, err := tenantOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	products := make([]*entities.Product, 0)
+	for _, row := range r.store.products {
+		if row.tenantID == tenantID && match(&row.product) {
+			products = append(products, row.entity())
+		}
+	}
+	slices.SortFunc(products, func(a, b *entities.Product) int { return a.ID - b.ID })
+	return products, nil
+}
+
+// uniqueValues returns the SKUs and slugs taken in a tenant, leaving out those of the product with id.
+// The caller holds the lock.
+func (r *ProductRepository) uniqueValues(tenantID string, id int) (map[string]bool, map[string]bool) {
+	skus := make(map[string]bool)
+	slugs := make(map[string]bool)
+	for _, row := range r.store.products {
+		if row.tenantID == tenantID && row.product.ID != id {
+			skus[row.product.SKU] = true
+			slugs[row.product.Slug] = true
+		}
+	}
+	return skus, slugs
+}
+
+// checkConstraints checks that the SKU and slug of a product are not taken and that its references exist.
+// The caller holds the lock.
+func (r *ProductRepository) checkConstraints(prod *entities.Product, skus, slugs map[string]bool) error {
+	if skus[prod.SKU] {
+		return domainErrors.NewDuplicateError("Product", "sku", prod.SKU)
+	}
+	if slugs[prod.Slug] {
+		return domainErrors.NewDuplicateError("Product", "slug", prod.Slug)
+	}
+
+	// References are checked against every tenant, like foreign keys
+	if prod.CategoryID != nil {
+		if _, ok := r.store.categories[*prod.CategoryID]; !ok {
+			return missingReference("category_id")
+		}
+	}
+	if prod.BrandID != nil {
+		if _, ok := r.store.brands[*prod.BrandID]; !ok {
+			return missingReference("brand_id")
+		}
+	}
+	return nil
+}
+
+// validateProduct applies the field validators of the product schema
+func validateProduct(prod *entities.Product) error {
+	switch {
+	case prod.SKU == "":
+		return invalid("sku", msgTooShort)
+	case prod.Slug == "":
+		return invalid("slug", msgTooShort)
+	case prod.Name == "":
+		return invalid("name", msgTooShort)
+	case prod.Price <= 0:
+		return invalid("price", msgOutOfRange)
+	case prod.Weight < 0:
+		return invalid("weight", msgOutOfRange)
+	case prod.Length < 0:
+		return invalid("length", msgOutOfRange)
+	case prod.Width < 0:
+		return invalid("width", msgOutOfRange)
+	case prod.Height < 0:
+		return invalid("height", msgOutOfRange)
+	}
+
+	switch prod.Status {
+	case entities.ProductStatusDraft, entities.ProductStatusPublished, entities.ProductStatusArchived:
+		return nil
+	default:
+		return invalid("status", fmt.Sprintf("product: invalid enum value for status field: %q", prod.Status))
+	}
+}
+
+// storedProduct copies the columns of a product, leaving out what the table does not hold
+func storedProduct(prod *entities.Product) entities.Product {
+	stored := *prod
+	stored.ImageURLs = slices.Clone(prod.ImageURLs)
+	stored.CategoryID = cloneUUID(prod.CategoryID)
+	stored.BrandID = cloneUUID(prod.BrandID)
+	stored.Media = nil
+	return stored
+}
+
+// entity returns a copy of the stored product
+func (row *productRow) entity() *entities.Product {
+	p := storedProduct(&row.product)
+	return &p
+}
+
+// uuidOrNil returns id, or uuid.Nil if it is unset
+func uuidOrNil(id *uuid.UUID) uuid.UUID {
+	if id == nil {
+		return uuid.Nil
+	}
+	return *id
+}
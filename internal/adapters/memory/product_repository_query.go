@@ -0,0 +1,440 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"example.com/go-yippi/internal/adapters/persistence"
+	"example.com/go-yippi/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// productPredicate reports whether a product matches a filter
+type productPredicate func(*entities.Product) bool
+
+// intFields are the integer fields products can be filtered by
+var intFields = map[string]func(*entities.Product) int{
+	"id":     func(p *entities.Product) int { return p.ID },
+	"weight": func(p *entities.Product) int { return p.Weight },
+	"length": func(p *entities.Product) int { return p.Length },
+	"width":  func(p *entities.Product) int { return p.Width },
+	"height": func(p *entities.Product) int { return p.Height },
+}
+
+// stringFields are the text fields products can be filtered by
+var stringFields = map[string]func(*entities.Product) string{
+	"sku":         func(p *entities.Product) string { return p.SKU },
+	"slug":        func(p *entities.Product) string { return p.Slug },
+	"name":        func(p *entities.Product) string { return p.Name },
+	"description": func(p *entities.Product) string { return p.Description },
+}
+
+// Query performs a flexible query with filters, sorting, and pagination, with the semantics of the query
+// engine of the Ent adapter: the same filters are accepted, and pages are cut at the cursor by creation
+// time and ID whatever the sort order.
+func (r *ProductRepository) Query(ctx context.Context, params *entities.QueryParams) (*entities.QueryResult, error) {
+	predicates := make([]productPredicate, 0, len(params.Filters))
+	for _, filter := range params.Filters {
+		pred, err := buildFilterPredicate(filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build filter predicates: %w", err)
+		}
+		predicates = append(predicates, pred)
+	}
+
+	limit := 20 // default
+	var cursor *entities.Cursor
+	if params.Pagination != nil {
+		limit = params.Pagination.Limit
+		if params.Pagination.Cursor != nil {
+			var err error
+			cursor, err = persistence.DecodeCursor(*params.Pagination.Cursor)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cursor: %w", err)
+			}
+			predicates = append(predicates, cursorPredicate(cursor, params.Pagination.Direction))
+		}
+	}
+
+	products, err := r.list(ctx, func(p *entities.Product) bool {
+		for _, pred := range predicates {
+			if !pred(p) {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	slices.SortStableFunc(products, productOrder(params.Sort))
+
+	hasNextPage := len(products) > limit
+	if hasNextPage {
+		products = products[:max(limit, 0)]
+	}
+
+	return &entities.QueryResult{
+		Products: products,
+		PageInfo: buildPageInfo(products, hasNextPage, params.Pagination),
+	}, nil
+}
+
+// buildFilterPredicate builds the predicate of a filter
+func buildFilterPredicate(filter entities.Filter) (productPredicate, error) {
+	if field, ok := intFields[filter.Field]; ok {
+		return buildIntFilter(filter, field)
+	}
+	if field, ok := stringFields[filter.Field]; ok {
+		return buildStringFilter(filter, field)
+	}
+
+	switch filter.Field {
+	case "price":
+		return buildPriceFilter(filter)
+	case "status":
+		return buildStatusFilter(filter)
+	case "category_id":
+		return buildUUIDFilter(filter, func(p *entities.Product) *uuid.UUID { return p.CategoryID })
+	case "brand_id":
+		return buildUUIDFilter(filter, func(p *entities.Product) *uuid.UUID { return p.BrandID })
+	case "created_at":
+		return buildTimeFilter(filter, func(p *entities.Product) time.Time { return p.CreatedAt })
+	case "updated_at":
+		return buildTimeFilter(filter, func(p *entities.Product) time.Time { return p.UpdatedAt })
+	default:
+		return nil, fmt.Errorf("unsupported filter field: %s", filter.Field)
+	}
+}
+
+// buildIntFilter builds predicates for integer fields
+func buildIntFilter(filter entities.Filter, field func(*entities.Product) int) (productPredicate, error) {
+	if filter.Operator == entities.OpIn {
+		vals, ok := filter.Value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("in operator requires array value")
+		}
+		set := make(map[int]bool, len(vals))
+		for _, v := range vals {
+			f, ok := v.(float64)
+			if !ok {
+				return nil, fmt.Errorf("invalid value in array: %T", v)
+			}
+			set[int(f)] = true
+		}
+		return func(p *entities.Product) bool { return set[field(p)] }, nil
+	}
+
+	val, ok := filter.Value.(float64) // JSON numbers are float64
+	if !ok {
+		return nil, fmt.Errorf("invalid value type for int filter: %T", filter.Value)
+	}
+	compare, err := comparison(filter.Operator, "int")
+	if err != nil {
+		return nil, err
+	}
+	intVal := int(val)
+	return func(p *entities.Product) bool { return compare(cmp.Compare(field(p), intVal)) }, nil
+}
+
+// buildPriceFilter builds predicates for the price
+func buildPriceFilter(filter entities.Filter) (productPredicate, error) {
+	val, ok := filter.Value.(float64)
+	if !ok {
+		return nil, fmt.Errorf("invalid value type for float filter: %T", filter.Value)
+	}
+	compare, err := comparison(filter.Operator, "float")
+	if err != nil {
+		return nil, err
+	}
+	return func(p *entities.Product) bool { return compare(cmp.Compare(p.Price, val)) }, nil
+}
+
+// buildStringFilter builds predicates for text fields
+func buildStringFilter(filter entities.Filter, field func(*entities.Product) string) (productPredicate, error) {
+	if filter.Operator == entities.OpIn {
+		vals, err := stringList(filter.Value, "string")
+		if err != nil {
+			return nil, err
+		}
+		return func(p *entities.Product) bool { return slices.Contains(vals, field(p)) }, nil
+	}
+
+	val, ok := filter.Value.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid value type for string filter: %T", filter.Value)
+	}
+
+	switch filter.Operator {
+	case entities.OpEqual:
+		return func(p *entities.Product) bool { return field(p) == val }, nil
+	case entities.OpNotEqual:
+		return func(p *entities.Product) bool { return field(p) != val }, nil
+	case entities.OpLike:
+		pattern := likePattern(val)
+		return func(p *entities.Product) bool { return pattern.MatchString(field(p)) }, nil
+	case entities.OpILike:
+		pattern := likePattern(strings.ToLower(val))
+		return func(p *entities.Product) bool { return pattern.MatchString(strings.ToLower(field(p))) }, nil
+	case entities.OpStartsWith:
+		return func(p *entities.Product) bool { return strings.HasPrefix(field(p), val) }, nil
+	case entities.OpEndsWith:
+		return func(p *entities.Product) bool { return strings.HasSuffix(field(p), val) }, nil
+	default:
+		return nil, fmt.Errorf("unsupported operator %s for string field", filter.Operator)
+	}
+}
+
+// buildStatusFilter builds predicates for the status
+func buildStatusFilter(filter entities.Filter) (productPredicate, error) {
+	switch filter.Operator {
+	case entities.OpEqual, entities.OpNotEqual:
+		val, ok := filter.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid value type for status filter: %T", filter.Value)
+		}
+		equal := filter.Operator == entities.OpEqual
+		return func(p *entities.Product) bool { return (string(p.Status) == val) == equal }, nil
+	case entities.OpIn:
+		vals, err := stringList(filter.Value, "status in")
+		if err != nil {
+			return nil, err
+		}
+		return func(p *entities.Product) bool { return slices.Contains(vals, string(p.Status)) }, nil
+	default:
+		return nil, fmt.Errorf("unsupported operator %s for status field", filter.Operator)
+	}
+}
+
+// buildUUIDFilter builds predicates for the optional references of products; products without the
+// reference match no operator, as in SQL
+func buildUUIDFilter(filter entities.Filter, field func(*entities.Product) *uuid.UUID) (productPredicate, error) {
+	switch filter.Operator {
+	case entities.OpEqual, entities.OpNotEqual:
+		val, ok := filter.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid value type for %s filter: %T", filter.Field, filter.Value)
+		}
+		id, err := uuid.Parse(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UUID for %s: %w", filter.Field, err)
+		}
+		equal := filter.Operator == entities.OpEqual
+		return func(p *entities.Product) bool {
+			ref := field(p)
+			return ref != nil && (*ref == id) == equal
+		}, nil
+	case entities.OpIn:
+		vals, err := stringList(filter.Value, filter.Field+" in")
+		if err != nil {
+			return nil, err
+		}
+		ids := make(map[uuid.UUID]bool, len(vals))
+		for _, v := range vals {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				continue // Skip invalid UUIDs
+			}
+			ids[id] = true
+		}
+		if len(ids) == 0 {
+			return nil, fmt.Errorf("no valid UUIDs found in %ss filter", filter.Field)
+		}
+		return func(p *entities.Product) bool {
+			ref := field(p)
+			return ref != nil && ids[*ref]
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported operator %s for %s field", filter.Operator, filter.Field)
+	}
+}
+
+// buildTimeFilter builds predicates for the timestamps
+func buildTimeFilter(filter entities.Filter, field func(*entities.Product) time.Time) (productPredicate, error) {
+	val, ok := filter.Value.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid value type for time filter: %T", filter.Value)
+	}
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time format: %w", err)
+	}
+	compare, err := comparison(filter.Operator, "time")
+	if err != nil {
+		return nil, err
+	}
+	return func(p *entities.Product) bool { return compare(field(p).Compare(t)) }, nil
+}
+
+// comparison returns whether the result of comparing a field to a value satisfies a comparison operator;
+// kind names the type of the field in errors
+func comparison(op entities.FilterOperator, kind string) (func(int) bool, error) {
+	switch op {
+	case entities.OpEqual:
+		return func(c int) bool { return c == 0 }, nil
+	case entities.OpNotEqual:
+		return func(c int) bool { return c != 0 }, nil
+	case entities.OpGreaterThan:
+		return func(c int) bool { return c > 0 }, nil
+	case entities.OpGreaterThanOrEqual:
+		return func(c int) bool { return c >= 0 }, nil
+	case entities.OpLessThan:
+		return func(c int) bool { return c < 0 }, nil
+	case entities.OpLessThanOrEqual:
+		return func(c int) bool { return c <= 0 }, nil
+	default:
+		return nil, fmt.Errorf("unsupported operator %s for %s field", op, kind)
+	}
+}
+
+// stringList returns the values of an in filter, given as an array or a comma-separated string; kind
+// names the filter in errors
+func stringList(value any, kind string) ([]string, error) {
+	vals, ok := value.([]interface{})
+	if !ok {
+		// Try comma-separated string
+		val, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid value type for %s filter: %T", kind, value)
+		}
+		strVals := strings.Split(val, ",")
+		for i, s := range strVals {
+			strVals[i] = strings.TrimSpace(s)
+		}
+		return strVals, nil
+	}
+
+	strVals := make([]string, len(vals))
+	for i, v := range vals {
+		str, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid value in array: %T", v)
+		}
+		strVals[i] = str
+	}
+	return strVals, nil
+}
+
+// likePattern compiles an SQL LIKE pattern, in which % matches any text and _ any character
+func likePattern(pattern string) *regexp.Regexp {
+	var expr strings.Builder
+	expr.WriteString(`(?s)^`)
+	for _, c := range pattern {
+		switch c {
+		case '%':
+			expr.WriteString(`.*`)
+		case '_':
+			expr.WriteString(`.`)
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString(`$`)
+	return regexp.MustCompile(expr.String())
+}
+
+// productOrder returns the order of the sort parameters, newest first by default; unknown fields are
+// skipped and ties are broken by descending ID
+func productOrder(sortParams []entities.SortParam) func(a, b *entities.Product) int {
+	if len(sortParams) == 0 {
+		return func(a, b *entities.Product) int {
+			return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.ID, a.ID))
+		}
+	}
+
+	return func(a, b *entities.Product) int {
+		for _, sort := range sortParams {
+			c := compareField(sort.Field, a, b)
+			if sort.Order == entities.SortDesc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(b.ID, a.ID)
+	}
+}
+
+// compareField compares two products by a sortable field, 0 for fields that cannot be sorted by
+func compareField(field string, a, b *entities.Product) int {
+	if value, ok := intFields[field]; ok {
+		return cmp.Compare(value(a), value(b))
+	}
+	if field != "description" {
+		if value, ok := stringFields[field]; ok {
+			return strings.Compare(value(a), value(b))
+		}
+	}
+
+	switch field {
+	case "price":
+		return cmp.Compare(a.Price, b.Price)
+	case "status":
+		return strings.Compare(string(a.Status), string(b.Status))
+	case "created_at":
+		return a.CreatedAt.Compare(b.CreatedAt)
+	case "updated_at":
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	default:
+		return 0
+	}
+}
+
+// cursorPredicate keeps the products after the cursor in the default order, or before it when paging
+// backward. Cursors whose time cannot be parsed fall back to comparing IDs.
+func cursorPredicate(cursor *entities.Cursor, direction string) productPredicate {
+	// Parse cursor timestamp - try RFC3339Nano first, then RFC3339 for backward compatibility
+	cursorTime, err := time.Parse(time.RFC3339Nano, cursor.CreatedAt)
+	if err != nil {
+		cursorTime, err = time.Parse(time.RFC3339, cursor.CreatedAt)
+		if err != nil {
+			if direction == "backward" {
+				return func(p *entities.Product) bool { return p.ID < cursor.ID }
+			}
+			return func(p *entities.Product) bool { return p.ID > cursor.ID }
+		}
+	}
+
+	if direction == "backward" {
+		return func(p *entities.Product) bool {
+			return cmp.Or(p.CreatedAt.Compare(cursorTime), cmp.Compare(p.ID, cursor.ID)) > 0
+		}
+	}
+	return func(p *entities.Product) bool {
+		return cmp.Or(p.CreatedAt.Compare(cursorTime), cmp.Compare(p.ID, cursor.ID)) < 0
+	}
+}
+
+// buildPageInfo builds pagination metadata; there is a previous page when paging forward from a cursor
+func buildPageInfo(products []*entities.Product, hasNextPage bool, pagination *entities.PaginationParams) entities.PageInfo {
+	pageInfo := entities.PageInfo{
+		HasNextPage:     hasNextPage,
+		HasPreviousPage: pagination != nil && pagination.Cursor != nil && pagination.Direction != "backward",
+	}
+	if len(products) == 0 {
+		return pageInfo
+	}
+
+	if pageInfo.HasPreviousPage {
+		pageInfo.PreviousCursor = productCursor(products[0])
+	}
+	if hasNextPage {
+		pageInfo.NextCursor = productCursor(products[len(products)-1])
+	}
+	return pageInfo
+}
+
+// productCursor returns the cursor of a product
+func productCursor(p *entities.Product) string {
+	cursor, _ := persistence.EncodeCursor(entities.Cursor{
+		ID:        p.ID,
+		CreatedAt: p.CreatedAt.Format(time.RFC3339Nano),
+	})
+	return cursor
+}
@@ -0,0 +1,219 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+)
+
+// errPresignNotSupported is returned for presigned requests, as clients cannot reach the memory of the service
+var errPresignNotSupported = fmt.Errorf("direct uploads and downloads are %w by this storage backend; transfer files through the API", domainErrors.ErrNotSupported)
+
+// storedFile is the content of a file and what is recorded about it
+type storedFile struct {
+	content     []byte
+	contentType string
+	sha256      string
+	uploadedAt  time.Time
+}
+
+// StorageRepository implements StorageRepository in memory. Buckets must be ensured before files are
+// stored in them, like with an object store.
+type StorageRepository struct {
+	mu      sync.RWMutex
+	buckets map[string]map[string]*storedFile
+}
+
+// NewStorageRepository creates an empty in-memory storage
+func NewStorageRepository() *StorageRepository {
+	return &StorageRepository{buckets: make(map[string]map[string]*storedFile)}
+}
+
+// Store reads a file into memory, replacing any file of the same name
+func (r *StorageRepository) Store(ctx context.Context, bucket, fileName string, reader io.Reader, size int64, contentType string) (*entities.FileMetadata, error) {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if size >= 0 && int64(len(content)) != size {
+		return nil, fmt.Errorf("file has %d bytes, expected %d", len(content), size)
+	}
+	sum := sha256.Sum256(content)
+	file := &storedFile{
+		content:     content,
+		contentType: contentType,
+		sha256:      hex.EncodeToString(sum[:]),
+		uploadedAt:  time.Now(),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	files, ok := r.buckets[bucket]
+	if !ok {
+		return nil, fmt.Errorf("bucket %q does not exist", bucket)
+	}
+	files[fileName] = file
+	return file.metadata(bucket, fileName), nil
+}
+
+// Remove deletes a file; removing a file that does not exist is not an error
+func (r *StorageRepository) Remove(ctx context.Context, bucket, fileName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.buckets[bucket], fileName)
+	return nil
+}
+
+// RemovePrefix deletes every file whose name starts with prefix
+func (r *StorageRepository) RemovePrefix(ctx context.Context, bucket, prefix string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name := range r.buckets[bucket] {
+		if strings.HasPrefix(name, prefix) {
+			delete(r.buckets[bucket], name)
+		}
+	}
+	return nil
+}
+
+// Copy copies a file within a bucket; files are never modified in place, so the copy shares the content
+func (r *StorageRepository) Copy(ctx context.Context, bucket, src, dst string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	file, ok := r.buckets[bucket][src]
+	if !ok {
+		return domainErrors.NewNotFoundError("File", src)
+	}
+	copied := *file
+	copied.uploadedAt = time.Now()
+	r.buckets[bucket][dst] = &copied
+	return nil
+}
+
+// Compose concatenates the parts into a file, replacing any file of the same name
+func (r *StorageRepository) Compose(ctx context.Context, bucket, fileName string, parts []entities.UploadPart, contentType string) (*entities.FileMetadata, error) {
+	var content []byte
+	for _, part := range parts {
+		file, err := r.file(bucket, part.Name)
+		if err != nil {
+			return nil, err
+		}
+		content = append(content, file.content...)
+	}
+
+	return r.Store(ctx, bucket, fileName, bytes.NewReader(content), int64(len(content)), contentType)
+}
+
+// List lists the files whose name starts with prefix. fn is called without holding the lock, so that it
+// may use the repository.
+func (r *StorageRepository) List(ctx context.Context, bucket, prefix string, fn func(*entities.FileMetadata) error) error {
+	r.mu.RLock()
+	var listed []*entities.FileMetadata
+	for name, file := range r.buckets[bucket] {
+		if strings.HasPrefix(name, prefix) {
+			listed = append(listed, file.metadata(bucket, name))
+		}
+	}
+	r.mu.RUnlock()
+
+	for _, metadata := range listed {
+		if err := fn(metadata); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetURL generates a relative URL for the file that will be proxied through the API
+func (r *StorageRepository) GetURL(ctx context.Context, bucket, fileName string) (string, error) {
+	return downloadURL(bucket, fileName), nil
+}
+
+// GetFile returns the content of a file, or of a range of it, its size and content type
+func (r *StorageRepository) GetFile(ctx context.Context, bucket, fileName string, rng *entities.ByteRange) (io.ReadCloser, int64, string, error) {
+	file, err := r.file(bucket, fileName)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	content := file.content
+	if rng != nil {
+		// Ranges past the end are cut short, like reads of a file
+		start := min(rng.Start, int64(len(content)))
+		content = content[start:min(start+rng.Length(), int64(len(content)))]
+	}
+	return io.NopCloser(bytes.NewReader(content)), int64(len(content)), file.contentType, nil
+}
+
+// EnsureBucket creates a bucket if it doesn't exist
+func (r *StorageRepository) EnsureBucket(ctx context.Context, bucket string) error {
+	if bucket == "" {
+		return fmt.Errorf("invalid bucket name %q", bucket)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.buckets[bucket]; !ok {
+		r.buckets[bucket] = make(map[string]*storedFile)
+	}
+	return nil
+}
+
+// Stat returns the metadata of a file
+func (r *StorageRepository) Stat(ctx context.Context, bucket, fileName string) (*entities.FileMetadata, error) {
+	file, err := r.file(bucket, fileName)
+	if err != nil {
+		return nil, err
+	}
+	return file.metadata(bucket, fileName), nil
+}
+
+// PresignUpload is not supported, as clients cannot reach the memory of the service
+func (r *StorageRepository) PresignUpload(ctx context.Context, bucket, fileName, contentType string, size int64, sha256 string, expiry time.Duration) (*entities.PresignedRequest, error) {
+	return nil, errPresignNotSupported
+}
+
+// PresignDownload is not supported, as clients cannot reach the memory of the service
+func (r *StorageRepository) PresignDownload(ctx context.Context, bucket, fileName string, expiry time.Duration) (*entities.PresignedRequest, error) {
+	return nil, errPresignNotSupported
+}
+
+// file returns a stored file, or a NotFoundError if there is none
+func (r *StorageRepository) file(bucket, fileName string) (*storedFile, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	file, ok := r.buckets[bucket][fileName]
+	if !ok {
+		return nil, domainErrors.NewNotFoundError("File", fileName)
+	}
+	return file, nil
+}
+
+// metadata returns the metadata of the file stored under fileName
+func (f *storedFile) metadata(bucket, fileName string) *entities.FileMetadata {
+	return &entities.FileMetadata{
+		FileName:    fileName,
+		Bucket:      bucket,
+		Key:         fileName,
+		Size:        int64(len(f.content)),
+		ContentType: f.contentType,
+		SHA256:      f.sha256,
+		URL:         downloadURL(bucket, fileName),
+		UploadedAt:  f.uploadedAt,
+	}
+}
+
+// downloadURL returns a relative URL for a file that will be proxied through the API, in the form the
+// storage adapters of package persistence use
+func downloadURL(bucket, fileName string) string {
+	return fmt.Sprintf("/files/download?bucket=%s&file_name=%s", bucket, fileName)
+}
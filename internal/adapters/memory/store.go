@@ -0,0 +1,113 @@
+// Package memory implements the repository ports in memory, for tests and demos that need no database.
+//
+// The repositories behave like the Ent adapters in package persistence: products, their media, categories
+// and brands are scoped to the tenant in the context, constraints are reported with the same domain errors,
+// deleting a category or brand clears the references to it and deleting a product deletes its media. The
+// contract tests in repositorytest hold both implementations to that. Nothing is persisted; the data lives
+// as long as the Store.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/google/uuid"
+)
+
+// Validator messages, worded like the field validators of the Ent schema
+const (
+	msgTooShort   = "value is less than the required length"
+	msgTooLong    = "value is greater than the required length"
+	msgOutOfRange = "value out of range"
+)
+
+// Store holds the tables of the in-memory repositories. Repositories created on the same store see each
+// other's data, like repositories on the same Ent client, so that references between them are checked.
+type Store struct {
+	mu sync.RWMutex
+
+	products      map[int]*productRow
+	lastProductID int
+	categories    map[uuid.UUID]*categoryRow
+	brands        map[uuid.UUID]*brandRow
+	media         map[uuid.UUID]*mediaRow
+	users         map[int]*entities.User
+	lastUserID    int
+	// seq numbers inserted rows, so that rows keyed by UUID are listed in insertion order
+	seq int
+}
+
+// productRow is a stored product and its tenant
+type productRow struct {
+	tenantID string
+	product  entities.Product
+}
+
+// categoryRow is a stored category, its tenant and its insertion number
+type categoryRow struct {
+	tenantID string
+	seq      int
+	category entities.Category
+}
+
+// brandRow is a stored brand, its tenant and its insertion number
+type brandRow struct {
+	tenantID string
+	seq      int
+	brand    entities.Brand
+}
+
+// mediaRow is a stored product media, its tenant and its insertion number
+type mediaRow struct {
+	tenantID string
+	seq      int
+	media    entities.ProductMedia
+}
+
+// NewStore creates an empty store
+func NewStore() *Store {
+	return &Store{
+		products:   make(map[int]*productRow),
+		categories: make(map[uuid.UUID]*categoryRow),
+		brands:     make(map[uuid.UUID]*brandRow),
+		media:      make(map[uuid.UUID]*mediaRow),
+		users:      make(map[int]*entities.User),
+	}
+}
+
+// nextSeq returns the insertion number of a new row; the caller holds the write lock
+func (s *Store) nextSeq() int {
+	s.seq++
+	return s.seq
+}
+
+// tenantOf returns the tenant in the context, which operations on tenant data require
+func tenantOf(ctx context.Context) (string, error) {
+	tenantID, ok := entities.TenantFromContext(ctx)
+	if !ok {
+		return "", domainErrors.ErrTenantRequired
+	}
+	return tenantID, nil
+}
+
+// invalid returns the error of a field that fails a validator
+func invalid(field, message string) error {
+	return domainErrors.NewValidationError(field, "invalid", message)
+}
+
+// missingReference returns the error of a foreign key field that references no record
+func missingReference(field string) error {
+	return domainErrors.NewValidationError(field, "not_found", fmt.Sprintf("%s does not reference an existing record", field))
+}
+
+// cloneUUID returns a copy of an optional ID, so that callers cannot change stored rows through it
+func cloneUUID(id *uuid.UUID) *uuid.UUID {
+	if id == nil {
+		return nil
+	}
+	c := *id
+	return &c
+}
@@ -0,0 +1,157 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+)
+
+// UserRepository implements the UserRepository interface in memory. Users are not scoped to a tenant;
+// they belong to one.
+type UserRepository struct {
+	store *Store
+}
+
+// NewUserRepository returns a user repository on store
+func NewUserRepository(store *Store) *UserRepository {
+	return &UserRepository{store: store}
+}
+
+func (r *UserRepository) Create(ctx context.Context, u *entities.User) error {
+	if err := validateUser(u); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if err := r.checkEmail(u); err != nil {
+		return err
+	}
+
+	// Role and tenant default like the columns do
+	if u.Role == "" {
+		u.Role = entities.RoleViewer
+	}
+	if u.TenantID == "" {
+		u.TenantID = entities.DefaultTenantID
+	}
+	r.store.lastUserID++
+	u.ID = r.store.lastUserID
+	r.store.users[u.ID] = storedUser(u)
+	return nil
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id int) (*entities.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	u, ok := r.store.users[id]
+	if !ok {
+		return nil, domainErrors.NewNotFoundError("User", id)
+	}
+	return storedUser(u), nil
+}
+
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*entities.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	for _, u := range r.store.users {
+		if u.Email != "" && u.Email == email {
+			return storedUser(u), nil
+		}
+	}
+	return nil, domainErrors.NewNotFoundError("User", email)
+}
+
+func (r *UserRepository) List(ctx context.Context) ([]*entities.User, error) {
+	return r.list(func(*entities.User) bool { return true }), nil
+}
+
+func (r *UserRepository) ListByTenant(ctx context.Context, tenantID string) ([]*entities.User, error) {
+	return r.list(func(u *entities.User) bool { return u.TenantID == tenantID }), nil
+}
+
+// Update saves a user; the role is kept if it is empty and the tenant of a user never changes
+func (r *UserRepository) Update(ctx context.Context, u *entities.User) error {
+	if err := validateUser(u); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stored, ok := r.store.users[u.ID]
+	if !ok {
+		return domainErrors.NewNotFoundError("User", u.ID)
+	}
+	if err := r.checkEmail(u); err != nil {
+		return err
+	}
+
+	updated := storedUser(u)
+	if updated.Role == "" {
+		updated.Role = stored.Role
+	}
+	updated.TenantID = stored.TenantID
+	r.store.users[u.ID] = updated
+	return nil
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if _, ok := r.store.users[id]; !ok {
+		return domainErrors.NewNotFoundError("User", id)
+	}
+	delete(r.store.users, id)
+	return nil
+}
+
+// list returns copies of the users that match, in the order they were created
+func (r *UserRepository) list(match func(*entities.User) bool) []*entities.User {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	users := make([]*entities.User, 0, len(r.store.users))
+	for _, u := range r.store.users {
+		if match(u) {
+			users = append(users, storedUser(u))
+		}
+	}
+	slices.SortFunc(users, func(a, b *entities.User) int { return a.ID - b.ID })
+	return users
+}
+
+// checkEmail checks that the email of a user is not taken by another user; users without an email do not
+// clash. The caller holds the lock.
+func (r *UserRepository) checkEmail(u *entities.User) error {
+	if u.Email == "" {
+		return nil
+	}
+	for _, other := range r.store.users {
+		if other.ID != u.ID && other.Email == u.Email {
+			return domainErrors.NewDuplicateError("User", "email", u.Email)
+		}
+	}
+	return nil
+}
+
+// validateUser applies the field validators of the user schema
+func validateUser(u *entities.User) error {
+	if u.Age <= 0 {
+		return invalid("age", msgOutOfRange)
+	}
+	switch u.Role {
+	case "", entities.RoleAdmin, entities.RoleEditor, entities.RoleViewer:
+		return nil
+	default:
+		return invalid("role", fmt.Sprintf("user: invalid enum value for role field: %q", u.Role))
+	}
+}
+
+// storedUser copies a user, so that callers cannot change stored rows through it
+func storedUser(u *entities.User) *entities.User {
+	stored := *u
+	stored.RecoveryCodes = slices.Clone(u.RecoveryCodes)
+	return &stored
+}
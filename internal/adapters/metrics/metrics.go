@@ -0,0 +1,163 @@
+// Package metrics collects the Prometheus metrics of the API: requests per operation, database statements
+// per repository method, storage calls per method and business events.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes the names of the metrics
+const namespace = "yippi"
+
+// Metrics holds the collectors of the API in a registry of its own
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	inFlight        *prometheus.GaugeVec
+
+	queries       *prometheus.CounterVec
+	queryDuration *prometheus.HistogramVec
+
+	storageDuration *prometheus.HistogramVec
+	storageErrors   *prometheus.CounterVec
+	storedBytes     *prometheus.CounterVec
+
+	products      *prometheus.CounterVec
+	uploads       prometheus.Counter
+	uploadedBytes prometheus.Counter
+}
+
+// New creates the metrics along with those of the Go runtime and the process
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Requests handled, by operation ID, method and status code.",
+		}, []string{"operation", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time taken to handle requests, by operation ID and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation", "method"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "Requests being handled, by operation ID.",
+		}, []string{"operation"}),
+
+		queries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "db_queries_total",
+			Help:      "Database statements run, by repository method and result (ok or error).",
+		}, []string{"method", "result"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Time taken by database statements, by repository method.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"method"}),
+
+		storageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "storage_operation_duration_seconds",
+			Help:      "Time taken by storage calls, by backend and StorageRepository method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"backend", "method"}),
+		storageErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "storage_operation_errors_total",
+			Help:      "Storage calls that failed, by backend and StorageRepository method. Missing objects are not counted.",
+		}, []string{"backend", "method"}),
+		storedBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "storage_stored_bytes_total",
+			Help:      "Bytes written to storage, by backend.",
+		}, []string{"backend"}),
+
+		products: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "products_total",
+			Help:      "Products created, published and archived, by event.",
+		}, []string{"event"}),
+		uploads: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "uploads_total",
+			Help:      "Files uploaded, directly, through the API or in chunks.",
+		}),
+		uploadedBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "upload_bytes_total",
+			Help:      "Bytes of the files uploaded, including uploads whose content was already stored.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests, m.requestDuration, m.inFlight,
+		m.queries, m.queryDuration,
+		m.storageDuration, m.storageErrors, m.storedBytes,
+		m.products, m.uploads, m.uploadedBytes,
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// RegisterDB reports the stats of a connection pool, e.g. open and idle connections and waits
+func (m *Metrics) RegisterDB(db *sql.DB, name string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RequestStarted counts a request of an operation as in flight; done records it once it is answered
+func (m *Metrics) RequestStarted(operationID, method string) (done func(status int)) {
+	start := time.Now()
+	inFlight := m.inFlight.WithLabelValues(operationID)
+	inFlight.Inc()
+
+	return func(status int) {
+		inFlight.Dec()
+		m.requests.WithLabelValues(operationID, method, strconv.Itoa(status)).Inc()
+		m.requestDuration.WithLabelValues(operationID, method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// StartQuery times a database statement of a repository method
+func (m *Metrics) StartQuery(ctx context.Context, method, query string) (context.Context, func(err error)) {
+	start := time.Now()
+	return ctx, func(err error) {
+		result := "ok"
+		if err != nil {
+			result = "error"
+		}
+		m.queries.WithLabelValues(method, result).Inc()
+		m.queryDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// observeStorage records a storage call; objects that do not exist are an answer, not a failure
+func (m *Metrics) observeStorage(backend, method string, start time.Time, err error) {
+	m.storageDuration.WithLabelValues(backend, method).Observe(time.Since(start).Seconds())
+	if err != nil && !errors.Is(err, domainErrors.ErrNotFound) {
+		m.storageErrors.WithLabelValues(backend, method).Inc()
+	}
+}
@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/go-yippi/internal/adapters/persistence"
+	"example.com/go-yippi/internal/domain/entities"
+	"example.com/go-yippi/internal/domain/ports"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubProductService publishes every product but fails to archive any
+type stubProductService struct {
+	ports.ProductService
+}
+
+func (s *stubProductService) CreateProduct(ctx context.Context, product *entities.Product) error {
+	return nil
+}
+
+func (s *stubProductService) PublishProduct(ctx context.Context, id int) error {
+	return nil
+}
+
+func (s *stubProductService) ArchiveProduct(ctx context.Context, id int) error {
+	return errors.New("database unavailable")
+}
+
+// stubStorageService stores every upload with the size it declares
+type stubStorageService struct {
+	ports.StorageService
+}
+
+func (s *stubStorageService) UploadFile(ctx context.Context, upload *entities.FileUpload) (*entities.FileMetadata, error) {
+	return &entities.FileMetadata{Size: upload.Size}, nil
+}
+
+// TestMetrics_Storage tests that storage calls are timed per method and that only failures other than
+// missing files count as errors
+func TestMetrics_Storage(t *testing.T) {
+	// Arrange
+	m := New()
+	fsRepo, err := persistence.NewFilesystemStorageRepository(t.TempDir())
+	require.NoError(t, err)
+	repo := m.Storage(fsRepo, "filesystem")
+	ctx := context.Background()
+	require.NoError(t, repo.EnsureBucket(ctx, "media"))
+
+	// Act
+	_, storeErr := repo.Store(ctx, "media", "logo.png", strings.NewReader("content"), 7, "image/png")
+	_, statErr := repo.Stat(ctx, "media", "missing.png")
+	_, presignErr := repo.PresignDownload(ctx, "media", "logo.png", time.Minute)
+
+	// Assert
+	require.NoError(t, storeErr)
+	assert.Error(t, statErr)
+	assert.Error(t, presignErr)
+	assert.Equal(t, 4, testutil.CollectAndCount(m.storageDuration), "EnsureBucket, Store, Stat and PresignDownload")
+	assert.Equal(t, float64(0), testutil.ToFloat64(m.storageErrors.WithLabelValues("filesystem", "Stat")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.storageErrors.WithLabelValues("filesystem", "PresignDownload")))
+	assert.Equal(t, float64(7), testutil.ToFloat64(m.storedBytes.WithLabelValues("filesystem")))
+}
+
+// TestMetrics_BusinessEvents tests that products and uploads are counted when they succeed
+func TestMetrics_BusinessEvents(t *testing.T) {
+	// Arrange
+	m := New()
+	products := m.Products(&stubProductService{})
+	uploads := m.Uploads(&stubStorageService{})
+	ctx := context.Background()
+
+	// Act
+	require.NoError(t, products.CreateProduct(ctx, &entities.Product{}))
+	require.NoError(t, products.PublishProduct(ctx, 1))
+	require.Error(t, products.ArchiveProduct(ctx, 1))
+	_, err := uploads.UploadFile(ctx, &entities.FileUpload{Size: 42})
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.products.WithLabelValues("created")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.products.WithLabelValues("published")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(m.products.WithLabelValues("archived")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.uploads))
+	assert.Equal(t, float64(42), testutil.ToFloat64(m.uploadedBytes))
+}
+
+// TestMetrics_Handler tests that requests and statements are exposed once recorded
+func TestMetrics_Handler(t *testing.T) {
+	// Arrange
+	m := New()
+	done := m.RequestStarted("list-products", "GET")
+	done(200)
+	_, queried := m.StartQuery(context.Background(), "ProductRepository.List", "SELECT 1")
+	queried(nil)
+	_, queried = m.StartQuery(context.Background(), "ProductRepository.List", "SELECT 1")
+	queried(errors.New("connection reset"))
+
+	// Act
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+
+	// Assert
+	assert.Equal(t, 200, rec.Code)
+	assert.Contains(t, string(body), `yippi_http_requests_total{method="GET",operation="list-products",status="200"} 1`)
+	assert.Contains(t, string(body), `yippi_http_requests_in_flight{operation="list-products"} 0`)
+	assert.Contains(t, string(body), `yippi_db_queries_total{method="ProductRepository.List",result="error"} 1`)
+	assert.Contains(t, string(body), `yippi_db_query_duration_seconds_count{method="ProductRepository.List"} 2`)
+	assert.Contains(t, string(body), "go_goroutines")
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"context"
+
+	"example.com/go-yippi/internal/domain/entities"
+	"example.com/go-yippi/internal/domain/ports"
+)
+
+// productService counts the products created, published and archived through a product service
+type productService struct {
+	ports.ProductService
+	m *Metrics
+}
+
+// Products wraps a product service so that products created, published and archived are counted
+func (m *Metrics) Products(service ports.ProductService) ports.ProductService {
+	return &productService{ProductService: service, m: m}
+}
+
+func (s *productService) CreateProduct(ctx context.Context, product *entities.Product) error {
+	err := s.ProductService.CreateProduct(ctx, product)
+	s.count("created", err)
+	return err
+}
+
+func (s *productService) PublishProduct(ctx context.Context, id int) error {
+	err := s.ProductService.PublishProduct(ctx, id)
+	s.count("published", err)
+	return err
+}
+
+func (s *productService) ArchiveProduct(ctx context.Context, id int) error {
+	err := s.ProductService.ArchiveProduct(ctx, id)
+	s.count("archived", err)
+	return err
+}
+
+func (s *productService) count(event string, err error) {
+	if err == nil {
+		s.m.products.WithLabelValues(event).Inc()
+	}
+}
+
+// storageService counts the files stored through a storage service
+type storageService struct {
+	ports.StorageService
+	m *Metrics
+}
+
+// Uploads wraps a storage service so that the files uploaded and their bytes are counted. Resumable uploads
+// are counted once complete, if they are stored through the wrapped service.
+func (m *Metrics) Uploads(service ports.StorageService) ports.StorageService {
+	return &storageService{StorageService: service, m: m}
+}
+
+func (s *storageService) UploadFile(ctx context.Context, upload *entities.FileUpload) (*entities.FileMetadata, error) {
+	file, err := s.StorageService.UploadFile(ctx, upload)
+	s.count(file, err)
+	return file, err
+}
+
+func (s *storageService) ConfirmUpload(ctx context.Context, bucket, fileName string) (*entities.FileMetadata, error) {
+	file, err := s.StorageService.ConfirmUpload(ctx, bucket, fileName)
+	s.count(file, err)
+	return file, err
+}
+
+func (s *storageService) count(file *entities.FileMetadata, err error) {
+	if err == nil {
+		s.m.uploads.Inc()
+		s.m.uploadedBytes.Add(float64(file.Size))
+	}
+}
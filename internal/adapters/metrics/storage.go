@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	"example.com/go-yippi/internal/domain/ports"
+)
+
+// storageRepository times the calls of a storage repository
+type storageRepository struct {
+	repo    ports.StorageRepository
+	backend string
+	m       *Metrics
+}
+
+// Storage wraps a storage repository of a backend, e.g. minio, so that the latency and failures of its
+// calls are recorded per method. GetFile is timed until the content is opened, not while it is read, and
+// List while the files are listed, including fn.
+func (m *Metrics) Storage(repo ports.StorageRepository, backend string) ports.StorageRepository {
+	return &storageRepository{repo: repo, backend: backend, m: m}
+}
+
+func (s *storageRepository) Store(ctx context.Context, bucket, fileName string, reader io.Reader, size int64, contentType string) (*entities.FileMetadata, error) {
+	start := time.Now()
+	metadata, err := s.repo.Store(ctx, bucket, fileName, reader, size, contentType)
+	s.m.observeStorage(s.backend, "Store", start, err)
+	if err == nil {
+		s.m.storedBytes.WithLabelValues(s.backend).Add(float64(metadata.Size))
+	}
+	return metadata, err
+}
+
+func (s *storageRepository) Remove(ctx context.Context, bucket, fileName string) error {
+	start := time.Now()
+	err := s.repo.Remove(ctx, bucket, fileName)
+	s.m.observeStorage(s.backend, "Remove", start, err)
+	return err
+}
+
+func (s *storageRepository) RemovePrefix(ctx context.Context, bucket, prefix string) error {
+	start := time.Now()
+	err := s.repo.RemovePrefix(ctx, bucket, prefix)
+	s.m.observeStorage(s.backend, "RemovePrefix", start, err)
+	return err
+}
+
+func (s *storageRepository) Copy(ctx context.Context, bucket, src, dst string) error {
+	start := time.Now()
+	err := s.repo.Copy(ctx, bucket, src, dst)
+	s.m.observeStorage(s.backend, "Copy", start, err)
+	return err
+}
+
+func (s *storageRepository) Compose(ctx context.Context, bucket, fileName string, parts []entities.UploadPart, contentType string) (*entities.FileMetadata, error) {
+	start := time.Now()
+	metadata, err := s.repo.Compose(ctx, bucket, fileName, parts, contentType)
+	s.m.observeStorage(s.backend, "Compose", start, err)
+	if err == nil {
+		s.m.storedBytes.WithLabelValues(s.backend).Add(float64(metadata.Size))
+	}
+	return metadata, err
+}
+
+func (s *storageRepository) List(ctx context.Context, bucket, prefix string, fn func(*entities.FileMetadata) error) error {
+	start := time.Now()
+	err := s.repo.List(ctx, bucket, prefix, fn)
+	s.m.observeStorage(s.backend, "List", start, err)
+	return err
+}
+
+func (s *storageRepository) GetURL(ctx context.Context, bucket, fileName string) (string, error) {
+	start := time.Now()
+	url, err := s.repo.GetURL(ctx, bucket, fileName)
+	s.m.observeStorage(s.backend, "GetURL", start, err)
+	return url, err
+}
+
+func (s *storageRepository) GetFile(ctx context.Context, bucket, fileName string, rng *entities.ByteRange) (io.ReadCloser, int64, string, error) {
+	start := time.Now()
+	content, size, contentType, err := s.repo.GetFile(ctx, bucket, fileName, rng)
+	s.m.observeStorage(s.backend, "GetFile", start, err)
+	return content, size, contentType, err
+}
+
+func (s *storageRepository) EnsureBucket(ctx context.Context, bucket string) error {
+	start := time.Now()
+	err := s.repo.EnsureBucket(ctx, bucket)
+	s.m.observeStorage(s.backend, "EnsureBucket", start, err)
+	return err
+}
+
+func (s *storageRepository) Stat(ctx context.Context, bucket, fileName string) (*entities.FileMetadata, error) {
+	start := time.Now()
+	metadata, err := s.repo.Stat(ctx, bucket, fileName)
+	s.m.observeStorage(s.backend, "Stat", start, err)
+	return metadata, err
+}
+
+func (s *storageRepository) PresignUpload(ctx context.Context, bucket, fileName, contentType string, size int64, sha256 string, expiry time.Duration) (*entities.PresignedRequest, error) {
+	start := time.Now()
+	request, err := s.repo.PresignUpload(ctx, bucket, fileName, contentType, size, sha256, expiry)
+	s.m.observeStorage(s.backend, "PresignUpload", start, err)
+	return request, err
+}
+
+func (s *storageRepository) PresignDownload(ctx context.Context, bucket, fileName string, expiry time.Duration) (*entities.PresignedRequest, error) {
+	start := time.Now()
+	request, err := s.repo.PresignDownload(ctx, bucket, fileName, expiry)
+	s.m.observeStorage(s.backend, "PresignDownload", start, err)
+	return request, err
+}
@@ -25,11 +25,7 @@ func (r *BrandRepositoryImpl) Create(ctx context.Context, b *entities.Brand) err
 		SetName(b.Name).
 		Save(ctx)
 	if err != nil {
-		// Check for unique constraint violation
-		if ent.IsConstraintError(err) {
-			return domainErrors.NewDuplicateError("Brand", "name", b.Name)
-		}
-		return err
+		return mapWriteError("Brand", err, r.writeFields(b))
 	}
 
 	b.ID = created.ID
@@ -103,11 +99,7 @@ func (r *BrandRepositoryImpl) Update(ctx context.Context, b *entities.Brand) err
 		if ent.IsNotFound(err) {
 			return domainErrors.NewNotFoundError("Brand", b.ID)
 		}
-		// Check for unique constraint violation
-		if ent.IsConstraintError(err) {
-			return domainErrors.NewDuplicateError("Brand", "name", b.Name)
-		}
-		return err
+		return mapWriteError("Brand", err, r.writeFields(b))
 	}
 
 	b.UpdatedAt = updated.UpdatedAt
@@ -120,7 +112,12 @@ func (r *BrandRepositoryImpl) Delete(ctx context.Context, id uuid.UUID) error {
 		if ent.IsNotFound(err) {
 			return domainErrors.NewNotFoundError("Brand", id)
 		}
-		return err
+		return mapWriteError("Brand", err, nil)
 	}
 	return nil
 }
+
+// writeFields returns the constrained fields of a brand for mapping write errors
+func (r *BrandRepositoryImpl) writeFields(b *entities.Brand) map[string]any {
+	return map[string]any{"name": b.Name}
+}
@@ -0,0 +1,121 @@
+// Package catalogtest provides catalog fixtures for tests: a database and storage of their own, seeded with
+// a known catalog and served by the same services as the API.
+//
+// Catalogs are generated by the catalog seeder, so a test knows the categories, brands, images and products
+// it works with, while the data has the shape of a real catalog: a category tree with products in its leaves
+// only, and a few categories and brands holding most of the products.
+package catalogtest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"example.com/go-yippi/internal/adapters/media"
+	"example.com/go-yippi/internal/adapters/persistence"
+	"example.com/go-yippi/internal/adapters/persistence/db/ent"
+	"example.com/go-yippi/internal/adapters/persistence/migrations"
+	"example.com/go-yippi/internal/application/services"
+	"example.com/go-yippi/internal/domain/entities"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	// Tenant is the tenant catalogs are seeded for
+	Tenant = "catalogtest"
+	// Bucket is the default bucket of the storage of catalogs
+	Bucket = "catalogtest"
+)
+
+// databases numbers the in-memory databases, so that every catalog has its own
+var databases atomic.Int64
+
+// Catalog is a seeded catalog and the services to work on it with
+type Catalog struct {
+	*entities.SeededCatalog
+	// Ctx is scoped to Tenant
+	Ctx             context.Context
+	Client          *ent.Client
+	ProductService  *services.ProductService
+	CategoryService *services.CategoryService
+	BrandService    *services.BrandService
+	StorageService  *services.StorageService
+}
+
+// Options returns the options of a small catalog: 3 root categories with 3 children each, 4 brands, 3
+// placeholder images and 60 products
+func Options() entities.SeedOptions {
+	return entities.SeedOptions{
+		Seed:           1,
+		CategoryDepth:  2,
+		CategoryFanOut: 3,
+		Brands:         4,
+		Products:       60,
+		Skew:           1.5,
+		Images:         3,
+		MinImages:      1,
+		Now:            time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// New seeds a catalog in an in-memory database, migrated like production ones, and in storage in a
+// temporary directory; both are discarded when the test ends. Products are published only with a category
+// and opts.MinImages images, and the products seeded are always kept.
+func New(t testing.TB, opts entities.SeedOptions) *Catalog {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	dsn := fmt.Sprintf("file:catalogtest-%d?mode=memory&cache=shared&_fk=1", databases.Add(1))
+	client, db, err := persistence.OpenObserved("sqlite3", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	// Connections to a shared in-memory database lock each other's tables instead of waiting, which fails the
+	// seeder while images are processed in the background; one connection makes them take turns
+	db.SetMaxOpenConns(1)
+	migrator, err := migrations.NewEmbedded(db, "sqlite3", time.Minute, logger)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background(), false)
+	require.NoError(t, err)
+
+	storageRepo, err := persistence.NewFilesystemStorageRepository(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, storageRepo.EnsureBucket(context.Background(), Bucket))
+	fileRepo := persistence.NewFileRepository(client)
+	categoryRepo := persistence.NewCategoryRepository(client)
+	brandRepo := persistence.NewBrandRepository(client)
+	productMediaRepo := persistence.NewProductMediaRepository(client)
+	imageService := services.NewImageService(storageRepo, fileRepo, media.NewProcessor(85), nil, 1, max(opts.Images, 1), logger)
+	t.Cleanup(func() { imageService.Close(context.Background()) })
+	storageService := services.NewStorageService(storageRepo, fileRepo, productMediaRepo, persistence.NewContentObjectRepository(client), imageService, nil, services.StorageOptions{
+		DefaultBucket: Bucket,
+		PresignExpiry: time.Hour,
+	}, logger)
+	productService := services.NewProductService(persistence.NewProductRepository(client, db), categoryRepo, brandRepo, productMediaRepo, storageService, services.ProductPolicy{
+		LeafCategoriesOnly:      true,
+		PublishRequiresCategory: true,
+		PublishMinImages:        opts.MinImages,
+	})
+	categoryService := services.NewCategoryService(categoryRepo)
+	brandService := services.NewBrandService(brandRepo)
+
+	ctx := entities.ContextWithTenant(context.Background(), Tenant)
+	opts.KeepProducts = true
+	seeded, err := services.NewCatalogSeeder(productService, categoryService, brandService, storageService, logger).Seed(ctx, opts)
+	require.NoError(t, err)
+
+	return &Catalog{
+		SeededCatalog:   seeded,
+		Ctx:             ctx,
+		Client:          client,
+		ProductService:  productService,
+		CategoryService: categoryService,
+		BrandService:    brandService,
+		StorageService:  storageService,
+	}
+}
@@ -0,0 +1,157 @@
+package catalogtest
+
+import (
+	"bytes"
+	"image/png"
+	"io"
+	"testing"
+
+	"example.com/go-yippi/internal/application/services"
+	"example.com/go-yippi/internal/domain/entities"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNew_CategoryTree tests that the catalog has a tree of the configured shape with products in its leaves only
+func TestNew_CategoryTree(t *testing.T) {
+	// Arrange & Act
+	catalog := New(t, Options())
+
+	// Assert
+	require.Len(t, catalog.Categories, 3+3*3)
+	require.Len(t, catalog.Leaves, 3*3)
+	roots := make(map[uuid.UUID]bool)
+	for _, category := range catalog.Categories[:3] {
+		assert.Nil(t, category.ParentID)
+		roots[category.ID] = true
+	}
+	leaves := make(map[uuid.UUID]bool)
+	for _, leaf := range catalog.Leaves {
+		require.NotNil(t, leaf.ParentID)
+		assert.True(t, roots[*leaf.ParentID])
+		leaves[leaf.ID] = true
+	}
+	assert.Equal(t, "Laptops", catalog.Categories[0].Name)
+	assert.Equal(t, "Laptops Pro", catalog.Leaves[0].Name)
+
+	require.Len(t, catalog.Products, 60)
+	for _, product := range catalog.Products {
+		require.NotNil(t, product.CategoryID)
+		assert.True(t, leaves[*product.CategoryID], "product %s is not in a leaf", product.SKU)
+		assert.NotNil(t, product.BrandID)
+	}
+	stored, err := catalog.ProductService.ListProducts(catalog.Ctx)
+	require.NoError(t, err)
+	assert.Len(t, stored, 60)
+}
+
+// TestNew_SkewedPopularity tests that a few categories and brands hold most of the products
+func TestNew_SkewedPopularity(t *testing.T) {
+	// Arrange & Act
+	catalog := New(t, Options())
+
+	// Assert
+	stats, err := catalog.ProductService.ProductStats(catalog.Ctx)
+	require.NoError(t, err)
+	topCategory := 0
+	for _, count := range stats.ByCategory {
+		topCategory = max(topCategory, count)
+	}
+	assert.Greater(t, topCategory, 2*60/len(catalog.Leaves))
+	topBrand := 0
+	for _, count := range stats.ByBrand {
+		topBrand = max(topBrand, count)
+	}
+	assert.Greater(t, topBrand, 60/len(catalog.Brands)*3/2)
+}
+
+// TestNew_PlaceholderImages tests that products show placeholder images kept in storage in their galleries
+func TestNew_PlaceholderImages(t *testing.T) {
+	// Arrange & Act
+	catalog := New(t, Options())
+
+	// Assert
+	require.Len(t, catalog.Images, 3)
+	placeholders := make(map[uuid.UUID]bool)
+	for _, image := range catalog.Images {
+		assert.Equal(t, "image/png", image.ContentType)
+		placeholders[image.ID] = true
+
+		download, err := catalog.StorageService.DownloadFile(catalog.Ctx, "", image.FileName, entities.DownloadOptions{})
+		require.NoError(t, err)
+		content, err := io.ReadAll(download.Content)
+		download.Content.Close()
+		require.NoError(t, err)
+		_, err = png.Decode(bytes.NewReader(content))
+		assert.NoError(t, err)
+	}
+	for _, product := range catalog.Products {
+		assert.Empty(t, product.ImageURLs)
+		found, err := catalog.ProductService.GetProduct(catalog.Ctx, product.ID)
+		require.NoError(t, err)
+		require.NotEmpty(t, found.Media, "product %s has no gallery", product.SKU)
+		for i, media := range found.Media {
+			assert.True(t, placeholders[media.FileID], "product %s shows file %s", product.SKU, media.FileID)
+			assert.Equal(t, i, media.Position)
+			assert.Equal(t, i == 0, media.IsPrimary)
+		}
+	}
+}
+
+// TestNew_Repeatable tests that the same options seed the same catalog, each in a database of its own
+func TestNew_Repeatable(t *testing.T) {
+	// Arrange & Act
+	first := New(t, Options())
+	second := New(t, Options())
+
+	// Assert
+	require.Len(t, second.Products, len(first.Products))
+	for i, product := range first.Products {
+		other := second.Products[i]
+		assert.Equal(t, product.SKU, other.SKU)
+		assert.Equal(t, product.Name, other.Name)
+		assert.Equal(t, product.Price, other.Price)
+		assert.Equal(t, product.Status, other.Status)
+		assert.Equal(t, product.CreatedAt, other.CreatedAt)
+		assert.Equal(t, categoryName(t, first, product.CategoryID), categoryName(t, second, other.CategoryID))
+	}
+
+	require.NoError(t, first.ProductService.DeleteProduct(first.Ctx, first.Products[0].ID))
+	_, err := second.ProductService.GetProductBySKU(second.Ctx, first.Products[0].SKU)
+	assert.NoError(t, err)
+}
+
+// TestNew_SeedingAgain tests that seeding a catalog again reuses its categories, brands and images and
+// numbers the new products after the existing ones
+func TestNew_SeedingAgain(t *testing.T) {
+	// Arrange
+	catalog := New(t, Options())
+	seeder := services.NewCatalogSeeder(catalog.ProductService, catalog.CategoryService, catalog.BrandService, catalog.StorageService, nil)
+	opts := Options()
+	opts.Products = 10
+	opts.BatchSize = 3
+
+	// Act
+	again, err := seeder.Seed(catalog.Ctx, opts)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 10, again.Added)
+	assert.Equal(t, 70, again.Total)
+	assert.Equal(t, catalog.Categories[4].ID, again.Categories[4].ID)
+	assert.Equal(t, catalog.Brands[1].ID, again.Brands[1].ID)
+	assert.Equal(t, catalog.Images[2].ID, again.Images[2].ID)
+	assert.Nil(t, again.Products)
+	product, err := catalog.ProductService.GetProductBySKU(catalog.Ctx, "SKU-00000070")
+	require.NoError(t, err)
+	assert.Contains(t, product.Slug, "-70")
+}
+
+// categoryName returns the name of a category of a catalog
+func categoryName(t *testing.T, catalog *Catalog, id *uuid.UUID) string {
+	require.NotNil(t, id)
+	category, err := catalog.CategoryService.GetCategory(catalog.Ctx, *id)
+	require.NoError(t, err)
+	return category.Name
+}
@@ -31,10 +31,7 @@ func (r *CategoryRepositoryImpl) Create(ctx context.Context, cat *entities.Categ
 
 	created, err := builder.Save(ctx)
 	if err != nil {
-		if ent.IsConstraintError(err) {
-			return domainErrors.NewDuplicateError("Category", "name", cat.Name)
-		}
-		return err
+		return mapWriteError("Category", err, r.writeFields(cat))
 	}
 
 	cat.ID = created.ID
@@ -125,10 +122,7 @@ func (r *CategoryRepositoryImpl) Update(ctx context.Context, cat *entities.Categ
 		if ent.IsNotFound(err) {
 			return domainErrors.NewNotFoundError("Category", cat.ID)
 		}
-		if ent.IsConstraintError(err) {
-			return domainErrors.NewDuplicateError("Category", "name", cat.Name)
-		}
-		return err
+		return mapWriteError("Category", err, r.writeFields(cat))
 	}
 	return nil
 }
@@ -139,11 +133,16 @@ func (r *CategoryRepositoryImpl) Delete(ctx context.Context, id uuid.UUID) error
 		if ent.IsNotFound(err) {
 			return domainErrors.NewNotFoundError("Category", id)
 		}
-		return err
+		return mapWriteError("Category", err, nil)
 	}
 	return nil
 }
 
+// writeFields returns the constrained fields of a category for mapping write errors
+func (r *CategoryRepositoryImpl) writeFields(cat *entities.Category) map[string]any {
+	return map[string]any{"name": cat.Name, "parent_id": cat.ParentID}
+}
+
 // toEntity converts Ent Category to domain entity
 func (r *CategoryRepositoryImpl) toEntity(c *ent.Category) *entities.Category {
 	cat := &entities.Category{
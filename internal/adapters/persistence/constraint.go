@@ -0,0 +1,210 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"example.com/go-yippi/internal/adapters/persistence/db/ent"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/lib/pq"
+)
+
+// violationKind is the kind of integrity constraint a write violated
+type violationKind int
+
+const (
+	violationUnique violationKind = iota + 1
+	violationForeignKey
+	violationCheck
+	violationNotNull
+)
+
+// violation describes a constraint violation reported by the database
+type violation struct {
+	kind violationKind
+	// columns are the offending columns, if the database reports them
+	columns []string
+	// values are the offending values by column, if the database reports them
+	values map[string]string
+	// constraint is the name of the violated constraint, if the database reports it
+	constraint string
+	// referenced is set for foreign key violations caused by removing a row that is still referenced
+	referenced bool
+}
+
+var (
+	// pgKeyDetail matches Postgres details such as `Key (tenant_id, sku)=(acme, SKU-1) already exists.`
+	pgKeyDetail = regexp.MustCompile(`Key \(([^)]+)\)=\((.*)\)`)
+	// sqliteConstraint matches SQLite messages such as `UNIQUE constraint failed: products.tenant_id, products.sku`
+	sqliteConstraint = regexp.MustCompile(`(UNIQUE|FOREIGN KEY|CHECK|NOT NULL) constraint failed(?:: (.+))?`)
+)
+
+// mapWriteError translates a failed insert, update or delete of resource into a domain error.
+// fields holds the values that were written by field name (nil for deletes); it names the offending
+// value of duplicates and resolves foreign key violations when the database does not report the column.
+// Not-found errors and errors that are not constraint violations are returned unchanged.
+func mapWriteError(resource string, err error, fields map[string]any) error {
+	var validationErr *ent.ValidationError
+	if errors.As(err, &validationErr) {
+		return domainErrors.NewValidationError(validationErr.Name, "invalid", validatorMessage(validationErr))
+	}
+
+	if !ent.IsConstraintError(err) {
+		return err
+	}
+
+	v, ok := parseViolation(err)
+	if !ok {
+		// Report unknown constraint violations without leaking the database message
+		return fmt.Errorf("%s violates a database constraint: %w", resource, domainErrors.ErrInvalidInput)
+	}
+
+	return v.domainError(resource, fields)
+}
+
+// parseViolation extracts the constraint violation from a Postgres or SQLite error
+func parseViolation(err error) (*violation, bool) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return parsePostgresViolation(pqErr)
+	}
+
+	m := sqliteConstraint.FindStringSubmatch(err.Error())
+	if m == nil {
+		return nil, false
+	}
+
+	v := &violation{}
+	switch m[1] {
+	case "UNIQUE":
+		v.kind = violationUnique
+	case "FOREIGN KEY":
+		v.kind = violationForeignKey
+	case "CHECK":
+		v.kind = violationCheck
+		v.constraint = m[2]
+		return v, true
+	case "NOT NULL":
+		v.kind = violationNotNull
+	}
+
+	// Columns are reported as "table.column"
+	if m[2] != "" {
+		for _, col := range strings.Split(m[2], ", ") {
+			if i := strings.LastIndex(col, "."); i >= 0 {
+				col = col[i+1:]
+			}
+			v.columns = append(v.columns, col)
+		}
+	}
+	return v, true
+}
+
+// parsePostgresViolation maps Postgres integrity constraint violations (SQLSTATE class 23)
+func parsePostgresViolation(pqErr *pq.Error) (*violation, bool) {
+	v := &violation{constraint: pqErr.Constraint}
+	switch pqErr.Code.Name() {
+	case "unique_violation":
+		v.kind = violationUnique
+	case "foreign_key_violation":
+		v.kind = violationForeignKey
+		v.referenced = strings.Contains(pqErr.Detail, "is still referenced")
+	case "check_violation":
+		v.kind = violationCheck
+	case "not_null_violation":
+		v.kind = violationNotNull
+	default:
+		return nil, false
+	}
+
+	if pqErr.Column != "" {
+		v.columns = []string{pqErr.Column}
+	}
+	if m := pgKeyDetail.FindStringSubmatch(pqErr.Detail); m != nil {
+		v.columns = strings.Split(m[1], ", ")
+		vals := strings.Split(m[2], ", ")
+		if len(vals) == len(v.columns) {
+			v.values = make(map[string]string, len(vals))
+			for i, col := range v.columns {
+				v.values[col] = vals[i]
+			}
+		}
+	}
+	return v, true
+}
+
+// domainError converts the violation to the domain error for resource
+func (v *violation) domainError(resource string, fields map[string]any) error {
+	field := v.field()
+	switch v.kind {
+	case violationUnique:
+		value, ok := fields[field]
+		if !ok {
+			value = v.values[field]
+		}
+		return domainErrors.NewDuplicateError(resource, field, value)
+	case violationForeignKey:
+		if v.referenced || fields == nil {
+			return domainErrors.NewValidationError("id", "in_use", resource+" is still referenced by other records")
+		}
+		if field == "" {
+			field = referenceField(fields)
+		}
+		return domainErrors.NewValidationError(field, "not_found", fmt.Sprintf("%s does not reference an existing record", field))
+	case violationNotNull:
+		return domainErrors.NewValidationError(field, "required", fmt.Sprintf("%s is required", field))
+	default:
+		if field == "" {
+			field = v.constraint
+		}
+		return domainErrors.NewValidationError(field, "invalid", fmt.Sprintf("%s has an invalid value", field))
+	}
+}
+
+// field returns the offending field, leaving out the tenant that scopes composite unique indexes
+func (v *violation) field() string {
+	var cols []string
+	for _, col := range v.columns {
+		if col != tenantIDColumn {
+			cols = append(cols, col)
+		}
+	}
+	return strings.Join(cols, ",")
+}
+
+// referenceField guesses the foreign key field when the database does not report it (SQLite)
+func referenceField(fields map[string]any) string {
+	var refs []string
+	for name, value := range fields {
+		if strings.HasSuffix(name, "_id") && !isNil(value) {
+			refs = append(refs, name)
+		}
+	}
+	if len(refs) == 0 {
+		return "reference"
+	}
+	sort.Strings(refs)
+	return strings.Join(refs, ",")
+}
+
+// isNil reports whether v is nil or a nil pointer
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
+
+// validatorMessage returns the reason an Ent field validator failed, without the "ent:" prefix
+func validatorMessage(err *ent.ValidationError) string {
+	var cause error = err
+	for next := errors.Unwrap(cause); next != nil; next = errors.Unwrap(cause) {
+		cause = next
+	}
+	return cause.Error()
+}
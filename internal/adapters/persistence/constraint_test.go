@@ -0,0 +1,142 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMapWriteError_UniqueViolation tests that duplicates name the offending field
+func TestMapWriteError_UniqueViolation(t *testing.T) {
+	// Arrange
+	client, db := newTestDatabase(t)
+	repo := NewProductRepository(client, db)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	require.NoError(t, repo.Create(ctx, &entities.Product{SKU: "SKU-1", Slug: "shirt", Name: "Shirt", Price: 10, Status: entities.ProductStatusDraft}))
+
+	cases := []struct {
+		name    string
+		product *entities.Product
+		field   string
+		value   any
+	}{
+		{"sku", &entities.Product{SKU: "SKU-1", Slug: "other", Name: "Other", Price: 10, Status: entities.ProductStatusDraft}, "sku", "SKU-1"},
+		{"slug", &entities.Product{SKU: "SKU-2", Slug: "shirt", Name: "Other", Price: 10, Status: entities.ProductStatusDraft}, "slug", "shirt"},
+	}
+
+	for _, tc := range cases {
+		// Act
+		err := repo.Create(ctx, tc.product)
+
+		// Assert
+		var dupErr *domainErrors.DuplicateError
+		require.True(t, errors.As(err, &dupErr), tc.name)
+		assert.Equal(t, "Product", dupErr.Resource, tc.name)
+		assert.Equal(t, tc.field, dupErr.Field, tc.name)
+		assert.Equal(t, tc.value, dupErr.Value, tc.name)
+	}
+}
+
+// TestMapWriteError_ForeignKeyViolation tests that references to missing rows are reported on the reference field
+func TestMapWriteError_ForeignKeyViolation(t *testing.T) {
+	// Arrange
+	client, db := newTestDatabase(t)
+	repo := NewProductRepository(client, db)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	missing := uuid.New()
+	prod := &entities.Product{SKU: "SKU-1", Slug: "shirt", Name: "Shirt", Price: 10, Status: entities.ProductStatusDraft, CategoryID: &missing}
+
+	// Act
+	err := repo.Create(ctx, prod)
+
+	// Assert
+	var validationErr *domainErrors.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, "category_id", validationErr.Errors[0].Field)
+	assert.Equal(t, "category_id.not_found", validationErr.Errors[0].Code)
+}
+
+// TestMapWriteError_FieldValidator tests that Ent field validators are reported as validation errors
+func TestMapWriteError_FieldValidator(t *testing.T) {
+	// Arrange
+	repo := NewBrandRepository(newTestClient(t))
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	// Act
+	err := repo.Create(ctx, &entities.Brand{Name: ""})
+
+	// Assert
+	var validationErr *domainErrors.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, "name.invalid", validationErr.Errors[0].Code)
+	assert.NotContains(t, err.Error(), "ent:")
+}
+
+// TestMapWriteError_Postgres tests the translation of Postgres integrity constraint violations
+func TestMapWriteError_Postgres(t *testing.T) {
+	cases := []struct {
+		name      string
+		pqErr     *pq.Error
+		duplicate bool
+		code      string
+		field     string
+	}{
+		{
+			name:      "unique",
+			pqErr:     &pq.Error{Code: "23505", Constraint: "product_tenant_id_sku", Detail: "Key (tenant_id, sku)=(acme, SKU-1) already exists."},
+			duplicate: true,
+			field:     "sku",
+		},
+		{
+			name:  "foreign key",
+			pqErr: &pq.Error{Code: "23503", Constraint: "products_brands_products", Detail: `Key (brand_id)=(6f1c) is not present in table "brands".`},
+			code:  "brand_id.not_found",
+			field: "brand_id",
+		},
+		{
+			name:  "still referenced",
+			pqErr: &pq.Error{Code: "23503", Constraint: "products_brands_products", Detail: `Key (id)=(6f1c) is still referenced from table "products".`},
+			code:  "id.in_use",
+			field: "id",
+		},
+		{
+			name:  "not null",
+			pqErr: &pq.Error{Code: "23502", Column: "name"},
+			code:  "name.required",
+			field: "name",
+		},
+		{
+			name:  "check",
+			pqErr: &pq.Error{Code: "23514", Constraint: "price_positive"},
+			code:  "price_positive.invalid",
+			field: "price_positive",
+		},
+	}
+
+	for _, tc := range cases {
+		// Act
+		v, ok := parseViolation(tc.pqErr)
+		require.True(t, ok, tc.name)
+		err := v.domainError("Product", map[string]any{"sku": "SKU-1", "brand_id": nil})
+
+		// Assert
+		if tc.duplicate {
+			var dupErr *domainErrors.DuplicateError
+			require.True(t, errors.As(err, &dupErr), tc.name)
+			assert.Equal(t, tc.field, dupErr.Field, tc.name)
+			assert.Equal(t, "SKU-1", dupErr.Value, tc.name)
+			continue
+		}
+		var validationErr *domainErrors.ValidationError
+		require.True(t, errors.As(err, &validationErr), tc.name)
+		assert.Equal(t, tc.field, validationErr.Errors[0].Field, tc.name)
+		assert.Equal(t, tc.code, validationErr.Errors[0].Code, tc.name)
+	}
+}
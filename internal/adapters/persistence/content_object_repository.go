@@ -0,0 +1,127 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"example.com/go-yippi/internal/adapters/persistence/db/ent"
+	"example.com/go-yippi/internal/adapters/persistence/db/ent/contentobject"
+	"example.com/go-yippi/internal/adapters/persistence/db/ent/predicate"
+)
+
+// ContentObjectRepositoryImpl implements the ContentObjectRepository interface using Ent. Counts are
+// changed with UPDATE statements, whose row locks serialise an acquisition behind a pending release.
+type ContentObjectRepositoryImpl struct {
+	client *ent.Client
+}
+
+func NewContentObjectRepository(client *ent.Client) *ContentObjectRepositoryImpl {
+	return &ContentObjectRepositoryImpl{client: client}
+}
+
+// Acquire increments the count of an object, creating it at one if the object has none
+func (r *ContentObjectRepositoryImpl) Acquire(ctx context.Context, bucket, key string) error {
+	for attempt := 0; ; attempt++ {
+		updated, err := r.client.ContentObject.
+			Update().
+			Where(contentObjectAt(bucket, key)).
+			AddRefs(1).
+			Save(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to acquire object: %w", err)
+		}
+		if updated > 0 {
+			return nil
+		}
+
+		err = r.client.ContentObject.
+			Create().
+			SetBucket(bucket).
+			SetKey(key).
+			SetRefs(1).
+			Exec(ctx)
+		// Lost a race to create the count; increment the winner's
+		if ent.IsConstraintError(err) && attempt == 0 {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to acquire object: %w", err)
+		}
+		return nil
+	}
+}
+
+// Release decrements the count of an object and, at zero, removes the object and its count in one
+// transaction, which holds the row lock while remove runs
+func (r *ContentObjectRepositoryImpl) Release(ctx context.Context, bucket, key string, remove func() error) error {
+	tx, err := r.client.Tx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	updated, err := tx.ContentObject.
+		Update().
+		Where(contentObjectAt(bucket, key), contentobject.RefsGT(0)).
+		AddRefs(-1).
+		Save(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to release object: %w", err)
+	}
+	if updated == 0 {
+		return nil
+	}
+
+	object, err := tx.ContentObject.Query().Where(contentObjectAt(bucket, key)).Only(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to release object: %w", err)
+	}
+	if object.Refs == 0 {
+		if err := remove(); err != nil {
+			return err
+		}
+		if err := tx.ContentObject.DeleteOne(object).Exec(ctx); err != nil {
+			return fmt.Errorf("failed to release object: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Delete removes an object and its count unless the count changed since idleSince. Like Release, it holds
+// the row lock while remove runs.
+func (r *ContentObjectRepositoryImpl) Delete(ctx context.Context, bucket, key string, idleSince time.Time, remove func() error) (bool, error) {
+	tx, err := r.client.Tx(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ContentObject.
+		Delete().
+		Where(contentObjectAt(bucket, key), contentobject.UpdatedAtLT(idleSince)).
+		Exec(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete object: %w", err)
+	}
+
+	// A count that is left was acquired recently
+	recent, err := tx.ContentObject.Query().Where(contentObjectAt(bucket, key)).Exist(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete object: %w", err)
+	}
+	if recent {
+		return false, nil
+	}
+
+	if err := remove(); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// contentObjectAt selects the count of an object
+func contentObjectAt(bucket, key string) predicate.ContentObject {
+	return contentobject.And(contentobject.BucketEQ(bucket), contentobject.KeyEQ(key))
+}
@@ -0,0 +1,110 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestContentObjectRepository_Release tests that an object is only removed with its last reference
+func TestContentObjectRepository_Release(t *testing.T) {
+	// Arrange
+	repo := NewContentObjectRepository(newTestClient(t))
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	require.NoError(t, repo.Acquire(ctx, "uploads", "content/acme/ab/abc"))
+	require.NoError(t, repo.Acquire(ctx, "uploads", "content/acme/ab/abc"))
+	removed := 0
+	remove := func() error {
+		removed++
+		return nil
+	}
+
+	// Act
+	firstErr := repo.Release(ctx, "uploads", "content/acme/ab/abc", remove)
+	removedFirst := removed
+	lastErr := repo.Release(ctx, "uploads", "content/acme/ab/abc", remove)
+	extraErr := repo.Release(ctx, "uploads", "content/acme/ab/abc", remove)
+
+	// Assert
+	require.NoError(t, firstErr)
+	assert.Zero(t, removedFirst)
+	require.NoError(t, lastErr)
+	require.NoError(t, extraErr)
+	assert.Equal(t, 1, removed)
+}
+
+// TestContentObjectRepository_ReleaseFails tests that the last reference is kept if the object cannot be removed
+func TestContentObjectRepository_ReleaseFails(t *testing.T) {
+	// Arrange
+	repo := NewContentObjectRepository(newTestClient(t))
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	require.NoError(t, repo.Acquire(ctx, "uploads", "content/acme/ab/abc"))
+	storageErr := errors.New("storage unavailable")
+
+	// Act
+	err := repo.Release(ctx, "uploads", "content/acme/ab/abc", func() error { return storageErr })
+	removed := false
+	retryErr := repo.Release(ctx, "uploads", "content/acme/ab/abc", func() error {
+		removed = true
+		return nil
+	})
+
+	// Assert
+	assert.ErrorIs(t, err, storageErr)
+	require.NoError(t, retryErr)
+	assert.True(t, removed)
+}
+
+// TestTenantIsolation_ContentObjects tests that references are counted per tenant
+func TestTenantIsolation_ContentObjects(t *testing.T) {
+	// Arrange
+	repo := NewContentObjectRepository(newTestClient(t))
+	tenantA := entities.ContextWithTenant(context.Background(), "tenant-a")
+	tenantB := entities.ContextWithTenant(context.Background(), "tenant-b")
+	require.NoError(t, repo.Acquire(tenantA, "uploads", "content/shared"))
+	require.NoError(t, repo.Acquire(tenantB, "uploads", "content/shared"))
+
+	// Act
+	removed := false
+	err := repo.Release(tenantB, "uploads", "content/shared", func() error {
+		removed = true
+		return nil
+	})
+	keptErr := repo.Release(tenantA, "uploads", "content/shared", func() error { return errors.New("kept") })
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, removed)
+	assert.EqualError(t, keptErr, "kept")
+}
+
+// TestContentObjectRepository_Delete tests that only objects whose references are idle are deleted
+func TestContentObjectRepository_Delete(t *testing.T) {
+	// Arrange
+	repo := NewContentObjectRepository(newTestClient(t))
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	require.NoError(t, repo.Acquire(ctx, "uploads", "content/leaked"))
+	removed := 0
+	remove := func() error {
+		removed++
+		return nil
+	}
+
+	// Act
+	recent, recentErr := repo.Delete(ctx, "uploads", "content/leaked", time.Now().Add(-time.Hour), remove)
+	idle, idleErr := repo.Delete(ctx, "uploads", "content/leaked", time.Now().Add(time.Second), remove)
+	releaseErr := repo.Release(ctx, "uploads", "content/leaked", remove)
+
+	// Assert
+	require.NoError(t, recentErr)
+	assert.False(t, recent)
+	require.NoError(t, idleErr)
+	assert.True(t, idle)
+	require.NoError(t, releaseErr)
+	assert.Equal(t, 1, removed)
+}
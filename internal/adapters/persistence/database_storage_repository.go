@@ -0,0 +1,215 @@
+package persistence
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+
+	"example.com/go-yippi/internal/adapters/persistence/db/ent"
+	"example.com/go-yippi/internal/adapters/persistence/db/ent/blob"
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+)
+
+// DatabaseStorageRepository implements StorageRepository in the database, for deployments that have
+// no object store. Whole files are loaded into memory, so it only suits small files.
+type DatabaseStorageRepository struct {
+	client *ent.Client
+}
+
+// NewDatabaseStorageRepository creates a storage repository that keeps files in the database
+func NewDatabaseStorageRepository(client *ent.Client) *DatabaseStorageRepository {
+	return &DatabaseStorageRepository{client: client}
+}
+
+// Store saves a file in the database, replacing any file of the same name
+func (r *DatabaseStorageRepository) Store(ctx context.Context, bucket, fileName string, reader io.Reader, size int64, contentType string) (*entities.FileMetadata, error) {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if size >= 0 && int64(len(content)) != size {
+		return nil, fmt.Errorf("file has %d bytes, expected %d", len(content), size)
+	}
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+
+	tx, err := r.client.Tx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	updated, err := tx.Blob.Update().
+		Where(blob.Bucket(bucket), blob.Key(fileName)).
+		SetContent(content).
+		SetContentType(contentType).
+		SetSize(int64(len(content))).
+		SetSha256(checksum).
+		Save(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+	if updated == 0 {
+		err = tx.Blob.Create().
+			SetBucket(bucket).
+			SetKey(fileName).
+			SetContent(content).
+			SetContentType(contentType).
+			SetSize(int64(len(content))).
+			SetSha256(checksum).
+			Exec(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store file: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+
+	return &entities.FileMetadata{
+		FileName:    fileName,
+		Bucket:      bucket,
+		Key:         fileName,
+		Size:        int64(len(content)),
+		ContentType: contentType,
+		SHA256:      checksum,
+		URL:         downloadURL(bucket, fileName),
+		UploadedAt:  time.Now(),
+	}, nil
+}
+
+// Remove deletes a file; removing a file that does not exist is not an error
+func (r *DatabaseStorageRepository) Remove(ctx context.Context, bucket, fileName string) error {
+	_, err := r.client.Blob.Delete().
+		Where(blob.Bucket(bucket), blob.Key(fileName)).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+// RemovePrefix deletes every file whose name starts with prefix
+func (r *DatabaseStorageRepository) RemovePrefix(ctx context.Context, bucket, prefix string) error {
+	_, err := r.client.Blob.Delete().
+		Where(blob.Bucket(bucket), blob.KeyHasPrefix(prefix)).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete files: %w", err)
+	}
+	return nil
+}
+
+// Copy copies a file within a bucket, replacing any file of the destination name
+func (r *DatabaseStorageRepository) Copy(ctx context.Context, bucket, src, dst string) error {
+	reader, size, contentType, err := r.GetFile(ctx, bucket, src, nil)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	_, err = r.Store(ctx, bucket, dst, reader, size, contentType)
+	return err
+}
+
+// Compose concatenates the parts into a file, which is stored like any other
+func (r *DatabaseStorageRepository) Compose(ctx context.Context, bucket, fileName string, parts []entities.UploadPart, contentType string) (*entities.FileMetadata, error) {
+	return composeByCopy(ctx, r, bucket, fileName, parts, contentType)
+}
+
+// List lists the files whose name starts with prefix, without loading their content
+func (r *DatabaseStorageRepository) List(ctx context.Context, bucket, prefix string, fn func(*entities.FileMetadata) error) error {
+	found, err := r.client.Blob.Query().
+		Where(blob.Bucket(bucket), blob.KeyHasPrefix(prefix)).
+		Order(ent.Asc(blob.FieldKey)).
+		Select(blob.FieldKey, blob.FieldSize, blob.FieldUpdatedAt).
+		All(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list files: %w", err)
+	}
+
+	for _, b := range found {
+		err := fn(&entities.FileMetadata{
+			FileName:   b.Key,
+			Bucket:     bucket,
+			Key:        b.Key,
+			Size:       b.Size,
+			UploadedAt: b.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetURL generates a relative URL for the file that will be proxied through the API
+func (r *DatabaseStorageRepository) GetURL(ctx context.Context, bucket, fileName string) (string, error) {
+	return downloadURL(bucket, fileName), nil
+}
+
+// GetFile loads a file, or a range of it, and returns its content, size, and content type
+func (r *DatabaseStorageRepository) GetFile(ctx context.Context, bucket, fileName string, rng *entities.ByteRange) (io.ReadCloser, int64, string, error) {
+	found, err := r.client.Blob.Query().
+		Where(blob.Bucket(bucket), blob.Key(fileName)).
+		Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, 0, "", domainErrors.NewNotFoundError("File", fileName)
+		}
+		return nil, 0, "", fmt.Errorf("failed to get file: %w", err)
+	}
+
+	content := found.Content
+	if rng != nil {
+		// The file may have been replaced since the range was resolved against its size
+		end := min(rng.End+1, int64(len(content)))
+		content = content[min(rng.Start, end):end]
+	}
+	return io.NopCloser(bytes.NewReader(content)), int64(len(content)), found.ContentType, nil
+}
+
+// EnsureBucket does nothing, as buckets are only a column of the stored files
+func (r *DatabaseStorageRepository) EnsureBucket(ctx context.Context, bucket string) error {
+	return nil
+}
+
+// Stat returns the metadata of a file without loading its content
+func (r *DatabaseStorageRepository) Stat(ctx context.Context, bucket, fileName string) (*entities.FileMetadata, error) {
+	found, err := r.client.Blob.Query().
+		Where(blob.Bucket(bucket), blob.Key(fileName)).
+		Select(blob.FieldContentType, blob.FieldSize, blob.FieldSha256, blob.FieldUpdatedAt).
+		Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, domainErrors.NewNotFoundError("File", fileName)
+		}
+		return nil, fmt.Errorf("failed to get file stats: %w", err)
+	}
+
+	return &entities.FileMetadata{
+		FileName:    fileName,
+		Bucket:      bucket,
+		Key:         fileName,
+		Size:        found.Size,
+		ContentType: found.ContentType,
+		SHA256:      found.Sha256,
+		URL:         downloadURL(bucket, fileName),
+		UploadedAt:  found.UpdatedAt,
+	}, nil
+}
+
+// PresignUpload is not supported, as clients cannot reach the database
+func (r *DatabaseStorageRepository) PresignUpload(ctx context.Context, bucket, fileName, contentType string, size int64, sha256 string, expiry time.Duration) (*entities.PresignedRequest, error) {
+	return nil, errPresignNotSupported
+}
+
+// PresignDownload is not supported, as clients cannot reach the database
+func (r *DatabaseStorageRepository) PresignDownload(ctx context.Context, bucket, fileName string, expiry time.Duration) (*entities.PresignedRequest, error) {
+	return nil, errPresignNotSupported
+}
@@ -0,0 +1,54 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+)
+
+// Blob holds the schema definition for the Blob entity, the content of a file kept in the database
+// by the database storage backend. Blobs are not tenant scoped: like objects in a bucket, their keys
+// already carry the tenant prefix.
+type Blob struct {
+	ent.Schema
+}
+
+// Fields of the Blob.
+func (Blob) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("bucket").
+			NotEmpty().
+			MaxLen(63).
+			Comment("Bucket holding the blob"),
+		field.String("key").
+			NotEmpty().
+			MaxLen(1024).
+			Comment("Object key in the bucket, including the tenant prefix"),
+		field.Bytes("content").
+			Comment("File content"),
+		field.String("content_type").
+			NotEmpty().
+			MaxLen(255),
+		field.Int64("size").
+			NonNegative().
+			Comment("Size in bytes"),
+		field.String("sha256").
+			MaxLen(64).
+			Comment("Hex-encoded SHA-256 of the content"),
+		field.Time("created_at").
+			Default(time.Now).
+			Immutable(),
+		field.Time("updated_at").
+			Default(time.Now).
+			UpdateDefault(time.Now),
+	}
+}
+
+// Indexes of the Blob.
+func (Blob) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("bucket", "key").Unique(),
+	}
+}
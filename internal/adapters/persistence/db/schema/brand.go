@@ -6,6 +6,7 @@ import (
 	"entgo.io/ent"
 	"entgo.io/ent/schema/edge"
 	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
 	"github.com/google/uuid"
 )
 
@@ -14,6 +15,13 @@ type Brand struct {
 	ent.Schema
 }
 
+// Mixin of the Brand.
+func (Brand) Mixin() []ent.Mixin {
+	return []ent.Mixin{
+		TenantMixin{},
+	}
+}
+
 // Fields of the Brand.
 func (Brand) Fields() []ent.Field {
 	return []ent.Field{
@@ -23,9 +31,8 @@ func (Brand) Fields() []ent.Field {
 			Comment("Brand unique identifier"),
 		field.String("name").
 			NotEmpty().
-			Unique().
 			MaxLen(255).
-			Comment("Brand name"),
+			Comment("Brand name, unique per tenant"),
 		field.Time("created_at").
 			Default(time.Now).
 			Immutable(),
@@ -41,3 +48,10 @@ func (Brand) Edges() []ent.Edge {
 		edge.To("products", Product.Type),
 	}
 }
+
+// Indexes of the Brand.
+func (Brand) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("tenant_id", "name").Unique(),
+	}
+}
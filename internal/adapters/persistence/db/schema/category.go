@@ -6,6 +6,7 @@ import (
 	"entgo.io/ent"
 	"entgo.io/ent/schema/edge"
 	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
 	"github.com/google/uuid"
 )
 
@@ -14,6 +15,13 @@ type Category struct {
 	ent.Schema
 }
 
+// Mixin of the Category.
+func (Category) Mixin() []ent.Mixin {
+	return []ent.Mixin{
+		TenantMixin{},
+	}
+}
+
 // Fields of the Category.
 func (Category) Fields() []ent.Field {
 	return []ent.Field{
@@ -23,8 +31,7 @@ func (Category) Fields() []ent.Field {
 		Comment("Category unique identifier"),
 		field.String("name").
 			NotEmpty().
-			Unique().
-			Comment("Category name"),
+			Comment("Category name, unique per tenant"),
 
 		field.Time("created_at").
 			Default(time.Now).
@@ -57,3 +64,10 @@ func (Category) Edges() []ent.Edge {
 		edge.To("products", Product.Type),
 	}
 }
+
+// Indexes of the Category.
+func (Category) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("tenant_id", "name").Unique(),
+	}
+}
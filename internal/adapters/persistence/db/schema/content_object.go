@@ -0,0 +1,52 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+)
+
+// ContentObject holds the schema definition for the ContentObject entity, the reference count of a
+// content-addressed object that the files with identical content share.
+type ContentObject struct {
+	ent.Schema
+}
+
+// Mixin of the ContentObject.
+func (ContentObject) Mixin() []ent.Mixin {
+	return []ent.Mixin{
+		TenantMixin{},
+	}
+}
+
+// Fields of the ContentObject.
+func (ContentObject) Fields() []ent.Field {
+	return []ent.Field{
+		field.String("bucket").
+			NotEmpty().
+			MaxLen(63).
+			Comment("Bucket holding the object"),
+		field.String("key").
+			NotEmpty().
+			MaxLen(1024).
+			Comment("Object key in the bucket, derived from the SHA-256 of the content"),
+		field.Int("refs").
+			NonNegative().
+			Comment("Number of files stored in the object"),
+		field.Time("created_at").
+			Default(time.Now).
+			Immutable(),
+		field.Time("updated_at").
+			Default(time.Now).
+			UpdateDefault(time.Now),
+	}
+}
+
+// Indexes of the ContentObject.
+func (ContentObject) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("tenant_id", "bucket", "key").Unique(),
+	}
+}
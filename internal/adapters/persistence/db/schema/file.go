@@ -0,0 +1,109 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/dialect/entsql"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"example.com/go-yippi/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// File holds the schema definition for the File entity, the record of an object in storage.
+type File struct {
+	ent.Schema
+}
+
+// Mixin of the File.
+func (File) Mixin() []ent.Mixin {
+	return []ent.Mixin{
+		TenantMixin{},
+	}
+}
+
+// Fields of the File.
+func (File) Fields() []ent.Field {
+	return []ent.Field{
+		field.UUID("id", uuid.UUID{}).
+			Default(uuid.New).
+			StorageKey("id").
+			Comment("File unique identifier"),
+		field.String("bucket").
+			NotEmpty().
+			MaxLen(63).
+			Comment("Bucket holding the object"),
+		field.String("key").
+			NotEmpty().
+			MaxLen(1024).
+			Comment("Object key in the bucket, including the tenant prefix or content address"),
+		field.String("file_name").
+			NotEmpty().
+			MaxLen(1024).
+			Comment("Name of the file within the tenant's namespace"),
+		field.Int64("size").
+			NonNegative().
+			Comment("Size in bytes"),
+		field.String("content_type").
+			NotEmpty().
+			MaxLen(255),
+		field.String("sha256").
+			Optional().
+			MaxLen(64).
+			Comment("Hex-encoded SHA-256 of the content, if known"),
+		field.Int("uploader_id").
+			Optional().
+			Nillable().
+			Comment("User who uploaded the file"),
+		field.Int("width").
+			Optional().
+			Nillable().
+			Comment("Width in pixels of a processed image"),
+		field.Int("height").
+			Optional().
+			Nillable().
+			Comment("Height in pixels of a processed image"),
+		field.String("blurhash").
+			Optional().
+			MaxLen(64).
+			Comment("BlurHash placeholder of a processed image"),
+		field.String("dominant_color").
+			Optional().
+			MaxLen(7).
+			Comment("Dominant colour of a processed image as #rrggbb"),
+		field.JSON("derivatives", []entities.ImageDerivative{}).
+			Optional().
+			Comment("Versions of a processed image rendered on upload"),
+		field.Time("created_at").
+			Default(time.Now).
+			Immutable(),
+		field.Time("updated_at").
+			Default(time.Now).
+			UpdateDefault(time.Now),
+	}
+}
+
+// Edges of the File.
+func (File) Edges() []ent.Edge {
+	return []ent.Edge{
+		edge.From("uploader", User.Type).
+			Ref("files").
+			Field("uploader_id").
+			Unique(),
+		// Files used by products cannot be removed until they are detached
+		edge.To("product_media", ProductMedia.Type).
+			Annotations(entsql.OnDelete(entsql.Restrict)),
+	}
+}
+
+// Indexes of the File.
+func (File) Indexes() []ent.Index {
+	return []ent.Index{
+		// Files are addressed by name; with deduplication, files of identical content share a key
+		index.Fields("tenant_id", "bucket", "file_name").Unique(),
+		index.Fields("tenant_id", "bucket", "key"),
+		index.Fields("tenant_id", "created_at"),
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"time"
 
 	"entgo.io/ent"
+	"entgo.io/ent/dialect/entsql"
 	"entgo.io/ent/schema/edge"
 	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
 	"github.com/google/uuid"
 )
 
@@ -14,18 +16,23 @@ type Product struct {
 	ent.Schema
 }
 
+// Mixin of the Product.
+func (Product) Mixin() []ent.Mixin {
+	return []ent.Mixin{
+		TenantMixin{},
+	}
+}
+
 // Fields of the Product.
 func (Product) Fields() []ent.Field {
 	return []ent.Field{
 		field.String("sku").
 			NotEmpty().
-			Unique().
-			Comment("Stock Keeping Unit"),
+			Comment("Stock Keeping Unit, unique per tenant"),
 
 		field.String("slug").
 			NotEmpty().
-			Unique().
-			Comment("URL-friendly identifier"),
+			Comment("URL-friendly identifier, unique per tenant"),
 
 		field.String("name").
 			NotEmpty().
@@ -105,5 +112,17 @@ func (Product) Edges() []ent.Edge {
 			Ref("products").
 			Unique().
 			Field("brand_id"),
+
+		// Media gallery, removed together with the product
+		edge.To("media", ProductMedia.Type).
+			Annotations(entsql.OnDelete(entsql.Cascade)),
+	}
+}
+
+// Indexes of the Product.
+func (Product) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("tenant_id", "sku").Unique(),
+		index.Fields("tenant_id", "slug").Unique(),
 	}
 }
@@ -0,0 +1,80 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/edge"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"github.com/google/uuid"
+)
+
+// ProductMedia holds the schema definition for the ProductMedia entity, a stored file in a product's gallery.
+type ProductMedia struct {
+	ent.Schema
+}
+
+// Mixin of the ProductMedia.
+func (ProductMedia) Mixin() []ent.Mixin {
+	return []ent.Mixin{
+		TenantMixin{},
+	}
+}
+
+// Fields of the ProductMedia.
+func (ProductMedia) Fields() []ent.Field {
+	return []ent.Field{
+		field.UUID("id", uuid.UUID{}).
+			Default(uuid.New).
+			StorageKey("id").
+			Comment("Product media unique identifier"),
+		field.Int("product_id").
+			Comment("Product ID"),
+		field.UUID("file_id", uuid.UUID{}).
+			Comment("File ID"),
+		field.Int("position").
+			NonNegative().
+			Default(0).
+			Comment("Position in the gallery, starting at 0"),
+		field.String("alt_text").
+			Optional().
+			MaxLen(255).
+			Comment("Alternative text for accessibility"),
+		field.Bool("is_primary").
+			Default(false).
+			Comment("Whether this is the product's main media"),
+		field.Enum("media_type").
+			Values("image", "video", "document"),
+		field.Time("created_at").
+			Default(time.Now).
+			Immutable(),
+		field.Time("updated_at").
+			Default(time.Now).
+			UpdateDefault(time.Now),
+	}
+}
+
+// Edges of the ProductMedia.
+func (ProductMedia) Edges() []ent.Edge {
+	return []ent.Edge{
+		edge.From("product", Product.Type).
+			Ref("media").
+			Field("product_id").
+			Unique().
+			Required(),
+		edge.From("file", File.Type).
+			Ref("product_media").
+			Field("file_id").
+			Unique().
+			Required(),
+	}
+}
+
+// Indexes of the ProductMedia.
+func (ProductMedia) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("product_id", "file_id").Unique(),
+		index.Fields("file_id"),
+	}
+}
@@ -0,0 +1,79 @@
+package schema
+
+import (
+	"time"
+
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"example.com/go-yippi/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// ResumableUpload holds the schema definition for the ResumableUpload entity, the state of a file
+// uploaded in chunks. The received bytes are kept as parts in storage.
+type ResumableUpload struct {
+	ent.Schema
+}
+
+// Mixin of the ResumableUpload.
+func (ResumableUpload) Mixin() []ent.Mixin {
+	return []ent.Mixin{
+		TenantMixin{},
+	}
+}
+
+// Fields of the ResumableUpload.
+func (ResumableUpload) Fields() []ent.Field {
+	return []ent.Field{
+		field.UUID("id", uuid.UUID{}).
+			Default(uuid.New).
+			StorageKey("id").
+			Comment("Upload unique identifier"),
+		field.String("bucket").
+			NotEmpty().
+			MaxLen(63).
+			Comment("Bucket the parts and the completed file are stored in"),
+		field.String("file_name").
+			NotEmpty().
+			MaxLen(1024).
+			Comment("Name of the file within the tenant's namespace"),
+		field.String("content_type").
+			Optional().
+			MaxLen(255).
+			Comment("Content type of the file, detected from the content if empty"),
+		field.String("sha256").
+			Optional().
+			MaxLen(64).
+			Comment("Expected hex-encoded SHA-256 of the content"),
+		field.Int64("upload_length").
+			Positive().
+			Immutable().
+			Comment("Total size in bytes"),
+		field.Int64("upload_offset").
+			NonNegative().
+			Default(0).
+			Comment("Bytes received so far"),
+		field.JSON("metadata", map[string]string{}).
+			Optional().
+			Comment("Metadata sent by the client"),
+		field.JSON("parts", []entities.UploadPart{}).
+			Optional().
+			Comment("Stored parts, in order"),
+		field.Time("expires_at").
+			Comment("When the upload is discarded unless more content is received"),
+		field.Time("created_at").
+			Default(time.Now).
+			Immutable(),
+		field.Time("updated_at").
+			Default(time.Now).
+			UpdateDefault(time.Now),
+	}
+}
+
+// Indexes of the ResumableUpload.
+func (ResumableUpload) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("tenant_id", "expires_at"),
+	}
+}
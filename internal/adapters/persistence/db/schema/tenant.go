@@ -0,0 +1,74 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+
+	"entgo.io/ent"
+	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+	"entgo.io/ent/schema/mixin"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+)
+
+// tenantIDField is the column holding the owning tenant
+const tenantIDField = "tenant_id"
+
+// TenantMixin scopes a schema to the tenant carried in the context.
+// Creates are stamped with the tenant and updates/deletes only touch rows of that tenant;
+// mutations without a tenant in the context fail. Reads are filtered by the interceptor
+// installed by persistence.Open, since schemas cannot import the generated intercept package.
+type TenantMixin struct {
+	mixin.Schema
+}
+
+// Fields of the TenantMixin.
+func (TenantMixin) Fields() []ent.Field {
+	return []ent.Field{
+		field.String(tenantIDField).
+			NotEmpty().
+			Immutable().
+			Default(entities.DefaultTenantID).
+			Comment("Owning tenant"),
+	}
+}
+
+// Indexes of the TenantMixin.
+func (TenantMixin) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields(tenantIDField),
+	}
+}
+
+// Hooks of the TenantMixin.
+func (TenantMixin) Hooks() []ent.Hook {
+	return []ent.Hook{
+		func(next ent.Mutator) ent.Mutator {
+			return ent.MutateFunc(func(ctx context.Context, m ent.Mutation) (ent.Value, error) {
+				tenantID, ok := entities.TenantFromContext(ctx)
+				if !ok {
+					return nil, domainErrors.ErrTenantRequired
+				}
+
+				// Stamp new rows with the tenant
+				if m.Op().Is(ent.OpCreate) {
+					if err := m.SetField(tenantIDField, tenantID); err != nil {
+						return nil, err
+					}
+					return next.Mutate(ctx, m)
+				}
+
+				// Restrict updates and deletes to rows of the tenant
+				w, ok := m.(interface{ WhereP(...func(*sql.Selector)) })
+				if !ok {
+					return nil, fmt.Errorf("unexpected mutation type %T", m)
+				}
+				w.WhereP(sql.FieldEQ(tenantIDField, tenantID))
+				return next.Mutate(ctx, m)
+			})
+		},
+	}
+}
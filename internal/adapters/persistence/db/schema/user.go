@@ -4,7 +4,11 @@ import (
 	"time"
 
 	"entgo.io/ent"
+	"entgo.io/ent/dialect/entsql"
+	"entgo.io/ent/schema/edge"
 	"entgo.io/ent/schema/field"
+
+	"example.com/go-yippi/internal/domain/entities"
 )
 
 // User holds the schema definition for the User entity.
@@ -15,16 +19,61 @@ type User struct {
 // Fields of the User.
 func (User) Fields() []ent.Field {
 	return []ent.Field{
-        field.Int("age").
-            Positive(),
-        field.String("name").
-            Default("unknown"),
+		field.Int("age").
+			Positive(),
+		field.String("name").
+			Default("unknown"),
+		field.String("email").
+			Optional().
+			Nillable().
+			Unique().
+			Comment("Login email"),
+		field.String("password_hash").
+			Optional().
+			Sensitive().
+			Comment("Password hash for the first authentication factor"),
+		field.Enum("role").
+			Values("admin", "editor", "viewer").
+			Default("viewer").
+			Comment("User role"),
+		field.String("tenant_id").
+			NotEmpty().
+			Default(entities.DefaultTenantID).
+			Comment("Tenant whose catalog the user works on"),
+		field.String("totp_secret").
+			Optional().
+			Sensitive().
+			Comment("RFC 6238 TOTP shared secret (base32)"),
+		field.Bool("totp_enabled").
+			Default(false).
+			Comment("Whether TOTP two-factor authentication is active"),
+		field.Int64("totp_last_step").
+			Default(0).
+			Comment("Last accepted TOTP time step, guards against replay"),
+		field.JSON("recovery_codes", []string{}).
+			Optional().
+			Sensitive().
+			Comment("Hashes of unused 2FA recovery codes"),
+		field.String("totp_challenge_id").
+			Optional().
+			Sensitive().
+			Comment("ID of the login challenge that may still be answered, once"),
+		field.Int("totp_failed_attempts").
+			Default(0).
+			Comment("Second factors rejected at login since the last accepted one"),
+		field.Time("totp_locked_until").
+			Optional().
+			Nillable().
+			Comment("Login challenges are refused until then after too many rejected second factors"),
 		field.Time("created_at").Default(time.Now),
 		field.Time("updated_at").Default(time.Now),
-    }
+	}
 }
 
 // Edges of the User.
 func (User) Edges() []ent.Edge {
-	return nil
+	return []ent.Edge{
+		edge.To("files", File.Type).
+			Annotations(entsql.OnDelete(entsql.SetNull)),
+	}
 }
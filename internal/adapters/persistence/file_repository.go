@@ -0,0 +1,315 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"entgo.io/ent/dialect/sql"
+	"example.com/go-yippi/internal/adapters/persistence/db/ent"
+	"example.com/go-yippi/internal/adapters/persistence/db/ent/file"
+	"example.com/go-yippi/internal/adapters/persistence/db/ent/predicate"
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/google/uuid"
+)
+
+// defaultFileQueryLimit is the page size of file queries that do not set a limit
+const defaultFileQueryLimit = 20
+
+// FileRepositoryImpl implements the FileRepository interface using Ent
+type FileRepositoryImpl struct {
+	client *ent.Client
+}
+
+func NewFileRepository(client *ent.Client) *FileRepositoryImpl {
+	return &FileRepositoryImpl{client: client}
+}
+
+func (r *FileRepositoryImpl) Create(ctx context.Context, f *entities.FileMetadata) error {
+	create := r.client.File.
+		Create().
+		SetBucket(f.Bucket).
+		SetKey(f.Key).
+		SetFileName(f.FileName).
+		SetSize(f.Size).
+		SetContentType(f.ContentType).
+		SetSha256(f.SHA256).
+		SetNillableUploaderID(f.UploaderID)
+
+	if f.Image != nil {
+		create.
+			SetWidth(f.Image.Width).
+			SetHeight(f.Image.Height).
+			SetBlurhash(f.Image.BlurHash).
+			SetDominantColor(f.Image.DominantColor).
+			SetDerivatives(f.Image.Derivatives)
+	}
+
+	// Keep the identity of records that are restored
+	if f.ID != uuid.Nil {
+		create.SetID(f.ID)
+	}
+	if !f.UploadedAt.IsZero() {
+		create.SetCreatedAt(f.UploadedAt)
+	}
+
+	created, err := create.Save(ctx)
+	if err != nil {
+		return mapWriteError("File", err, r.writeFields(f))
+	}
+
+	f.ID = created.ID
+	f.UploadedAt = created.CreatedAt
+	f.UpdatedAt = created.UpdatedAt
+	return nil
+}
+
+func (r *FileRepositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*entities.FileMetadata, error) {
+	found, err := r.client.File.Get(ctx, id)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, domainErrors.NewNotFoundError("File", id)
+		}
+		return nil, err
+	}
+
+	return r.toEntity(found), nil
+}
+
+func (r *FileRepositoryImpl) GetByName(ctx context.Context, bucket, fileName string) (*entities.FileMetadata, error) {
+	found, err := r.client.File.
+		Query().
+		Where(file.BucketEQ(bucket), file.FileNameEQ(fileName)).
+		Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, domainErrors.NewNotFoundError("File", fileName)
+		}
+		return nil, err
+	}
+
+	return r.toEntity(found), nil
+}
+
+// ListByKey returns the files stored in an object, oldest first
+func (r *FileRepositoryImpl) ListByKey(ctx context.Context, bucket, key string) ([]*entities.FileMetadata, error) {
+	found, err := r.client.File.
+		Query().
+		Where(file.BucketEQ(bucket), file.KeyEQ(key)).
+		Order(file.ByCreatedAt()).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]*entities.FileMetadata, len(found))
+	for i, f := range found {
+		files[i] = r.toEntity(f)
+	}
+	return files, nil
+}
+
+func (r *FileRepositoryImpl) Update(ctx context.Context, f *entities.FileMetadata) error {
+	update := r.client.File.
+		UpdateOneID(f.ID).
+		SetKey(f.Key).
+		SetFileName(f.FileName).
+		SetSize(f.Size).
+		SetContentType(f.ContentType).
+		SetSha256(f.SHA256).
+		SetNillableUploaderID(f.UploaderID)
+	setFileImage(update, f.Image)
+
+	updated, err := update.Save(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return domainErrors.NewNotFoundError("File", f.ID)
+		}
+		return mapWriteError("File", err, r.writeFields(f))
+	}
+
+	f.UpdatedAt = updated.UpdatedAt
+	return nil
+}
+
+// UpdateImage records the image info of a file. The update time is kept, as the content did not change,
+// and the record is only updated if the checksum still matches the one the image was processed from.
+func (r *FileRepositoryImpl) UpdateImage(ctx context.Context, f *entities.FileMetadata) error {
+	update := r.client.File.
+		UpdateOneID(f.ID).
+		Where(file.Sha256EQ(f.SHA256)).
+		SetUpdatedAt(f.UpdatedAt)
+	setFileImage(update, f.Image)
+
+	err := update.Exec(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return domainErrors.NewNotFoundError("File", f.ID)
+		}
+		return mapWriteError("File", err, r.writeFields(f))
+	}
+	return nil
+}
+
+// setFileImage sets the image fields of a file update, or clears them if the file has no image info
+func setFileImage(update *ent.FileUpdateOne, image *entities.ImageInfo) {
+	if image == nil {
+		update.
+			ClearWidth().
+			ClearHeight().
+			ClearBlurhash().
+			ClearDominantColor().
+			ClearDerivatives()
+		return
+	}
+	update.
+		SetWidth(image.Width).
+		SetHeight(image.Height).
+		SetBlurhash(image.BlurHash).
+		SetDominantColor(image.DominantColor).
+		SetDerivatives(image.Derivatives)
+}
+
+func (r *FileRepositoryImpl) Delete(ctx context.Context, id uuid.UUID) error {
+	err := r.client.File.DeleteOneID(id).Exec(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return domainErrors.NewNotFoundError("File", id)
+		}
+		return mapWriteError("File", err, nil)
+	}
+	return nil
+}
+
+// Query returns a page of files matching the query, newest first
+func (r *FileRepositoryImpl) Query(ctx context.Context, q *entities.FileQuery) (*entities.FileQueryResult, error) {
+	query := r.client.File.Query()
+
+	if q.Bucket != "" {
+		query = query.Where(file.BucketEQ(q.Bucket))
+	}
+	if q.ContentType != "" {
+		// "image/*" matches every image type
+		if major, ok := strings.CutSuffix(q.ContentType, "/*"); ok {
+			query = query.Where(file.ContentTypeHasPrefix(major + "/"))
+		} else {
+			query = query.Where(file.ContentTypeEQ(q.ContentType))
+		}
+	}
+	if q.Name != "" {
+		query = query.Where(file.FileNameContainsFold(q.Name))
+	}
+	if q.UploaderID != nil {
+		query = query.Where(file.UploaderIDEQ(*q.UploaderID))
+	}
+	if q.UploadedAfter != nil {
+		query = query.Where(file.CreatedAtGTE(*q.UploadedAfter))
+	}
+	if q.UploadedBefore != nil {
+		query = query.Where(file.CreatedAtLT(*q.UploadedBefore))
+	}
+
+	if q.Cursor != nil {
+		cursor, err := DecodeCursor(*q.Cursor)
+		if err != nil {
+			return nil, domainErrors.NewValidationError("cursor", "invalid", "cursor is invalid")
+		}
+		after, err := fileCursorPredicate(cursor)
+		if err != nil {
+			return nil, domainErrors.NewValidationError("cursor", "invalid", "cursor is invalid")
+		}
+		query = query.Where(after)
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultFileQueryLimit
+	}
+
+	// Fetch limit + 1 to determine if there's a next page
+	files, err := query.
+		Order(file.ByCreatedAt(sql.OrderDesc()), file.ByID(sql.OrderDesc())).
+		Limit(limit + 1).
+		All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	hasNextPage := len(files) > limit
+	if hasNextPage {
+		files = files[:limit]
+	}
+
+	result := &entities.FileQueryResult{
+		Files: make([]*entities.FileMetadata, len(files)),
+		PageInfo: entities.PageInfo{
+			HasNextPage:     hasNextPage,
+			HasPreviousPage: q.Cursor != nil,
+		},
+	}
+	for i, f := range files {
+		result.Files[i] = r.toEntity(f)
+	}
+
+	if hasNextPage {
+		last := files[len(files)-1]
+		result.PageInfo.NextCursor, err = EncodeCursor(entities.Cursor{
+			UUID:      last.ID.String(),
+			CreatedAt: last.CreatedAt.Format(time.RFC3339Nano),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// fileCursorPredicate selects the files after the cursor in created_at DESC, id DESC order
+func fileCursorPredicate(cursor *entities.Cursor) (predicate.File, error) {
+	id, err := uuid.Parse(cursor.UUID)
+	if err != nil {
+		return nil, err
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, cursor.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return file.Or(
+		file.CreatedAtLT(createdAt),
+		file.And(file.CreatedAtEQ(createdAt), file.IDLT(id)),
+	), nil
+}
+
+// writeFields returns the constrained fields of a file for mapping write errors
+func (r *FileRepositoryImpl) writeFields(f *entities.FileMetadata) map[string]any {
+	return map[string]any{"bucket": f.Bucket, "file_name": f.FileName, "uploader_id": f.UploaderID}
+}
+
+func (r *FileRepositoryImpl) toEntity(f *ent.File) *entities.FileMetadata {
+	metadata := &entities.FileMetadata{
+		ID:          f.ID,
+		FileName:    f.FileName,
+		Bucket:      f.Bucket,
+		Key:         f.Key,
+		Size:        f.Size,
+		ContentType: f.ContentType,
+		SHA256:      f.Sha256,
+		UploaderID:  f.UploaderID,
+		UploadedAt:  f.CreatedAt,
+		UpdatedAt:   f.UpdatedAt,
+	}
+	if f.Width != nil && f.Height != nil {
+		metadata.Image = &entities.ImageInfo{
+			Width:         *f.Width,
+			Height:        *f.Height,
+			BlurHash:      f.Blurhash,
+			DominantColor: f.DominantColor,
+			Derivatives:   f.Derivatives,
+		}
+	}
+	return metadata
+}
@@ -0,0 +1,192 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestFiles records files named after their keys, one second apart and oldest first
+func createTestFiles(t *testing.T, repo *FileRepositoryImpl, ctx context.Context, files ...*entities.FileMetadata) {
+	t.Helper()
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for i, f := range files {
+		if f.Bucket == "" {
+			f.Bucket = "uploads"
+		}
+		if f.ContentType == "" {
+			f.ContentType = "text/plain"
+		}
+		f.FileName = f.Key
+		f.UploadedAt = start.Add(time.Duration(i) * time.Second)
+		require.NoError(t, repo.Create(ctx, f))
+	}
+}
+
+// TestFileRepository_Query tests the catalog filters
+func TestFileRepository_Query(t *testing.T) {
+	// Arrange
+	repo := NewFileRepository(newTestClient(t))
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	uploader := 7
+	createTestFiles(t, repo, ctx,
+		&entities.FileMetadata{Key: "Logo.png", ContentType: "image/png"},
+		&entities.FileMetadata{Key: "banner.jpg", ContentType: "image/jpeg"},
+		&entities.FileMetadata{Key: "manual.pdf", ContentType: "application/pdf"},
+		&entities.FileMetadata{Key: "logo.svg", ContentType: "image/svg+xml", Bucket: "assets"},
+	)
+
+	cases := []struct {
+		name  string
+		query entities.FileQuery
+		want  []string
+	}{
+		{name: "all, newest first", want: []string{"logo.svg", "manual.pdf", "banner.jpg", "Logo.png"}},
+		{name: "bucket", query: entities.FileQuery{Bucket: "assets"}, want: []string{"logo.svg"}},
+		{name: "exact content type", query: entities.FileQuery{ContentType: "image/png"}, want: []string{"Logo.png"}},
+		{name: "content type family", query: entities.FileQuery{ContentType: "image/*"}, want: []string{"logo.svg", "banner.jpg", "Logo.png"}},
+		{name: "name ignores case", query: entities.FileQuery{Name: "LOGO"}, want: []string{"logo.svg", "Logo.png"}},
+		{name: "uploader", query: entities.FileQuery{UploaderID: &uploader}, want: []string{}},
+	}
+
+	for _, tc := range cases {
+		// Act
+		result, err := repo.Query(ctx, &tc.query)
+
+		// Assert
+		require.NoError(t, err, tc.name)
+		names := make([]string, len(result.Files))
+		for i, f := range result.Files {
+			names[i] = f.FileName
+		}
+		assert.Equal(t, tc.want, names, tc.name)
+	}
+}
+
+// TestFileRepository_QueryPaging tests that cursors walk the catalog without gaps or repeats
+func TestFileRepository_QueryPaging(t *testing.T) {
+	// Arrange
+	repo := NewFileRepository(newTestClient(t))
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	createTestFiles(t, repo, ctx,
+		&entities.FileMetadata{Key: "a.txt"},
+		&entities.FileMetadata{Key: "b.txt"},
+		&entities.FileMetadata{Key: "c.txt"},
+	)
+
+	// Act
+	first, err := repo.Query(ctx, &entities.FileQuery{Limit: 2})
+	require.NoError(t, err)
+	second, err := repo.Query(ctx, &entities.FileQuery{Limit: 2, Cursor: &first.PageInfo.NextCursor})
+	require.NoError(t, err)
+	invalid := "not-a-cursor"
+	_, invalidErr := repo.Query(ctx, &entities.FileQuery{Cursor: &invalid})
+
+	// Assert
+	require.Len(t, first.Files, 2)
+	assert.Equal(t, "c.txt", first.Files[0].Key)
+	assert.Equal(t, "b.txt", first.Files[1].Key)
+	assert.True(t, first.PageInfo.HasNextPage)
+	require.Len(t, second.Files, 1)
+	assert.Equal(t, "a.txt", second.Files[0].Key)
+	assert.False(t, second.PageInfo.HasNextPage)
+	assert.True(t, second.PageInfo.HasPreviousPage)
+	var validationErr *domainErrors.ValidationError
+	assert.True(t, errors.As(invalidErr, &validationErr))
+}
+
+// TestFileRepository_Names tests that names are unique in a bucket while stored objects may be shared
+func TestFileRepository_Names(t *testing.T) {
+	// Arrange
+	repo := NewFileRepository(newTestClient(t))
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	first := &entities.FileMetadata{Bucket: "uploads", Key: "content/acme/ab/abc", FileName: "a.png", ContentType: "image/png"}
+	second := &entities.FileMetadata{Bucket: "uploads", Key: "content/acme/ab/abc", FileName: "b.png", ContentType: "image/png"}
+	duplicate := &entities.FileMetadata{Bucket: "uploads", Key: "tenants/acme/a.png", FileName: "a.png", ContentType: "image/png"}
+	require.NoError(t, repo.Create(ctx, first))
+	require.NoError(t, repo.Create(ctx, second))
+
+	// Act
+	duplicateErr := repo.Create(ctx, duplicate)
+	found, getErr := repo.GetByName(ctx, "uploads", "b.png")
+	shared, listErr := repo.ListByKey(ctx, "uploads", "content/acme/ab/abc")
+
+	// Assert
+	assert.True(t, errors.Is(duplicateErr, domainErrors.ErrDuplicateEntry))
+	require.NoError(t, getErr)
+	assert.Equal(t, second.ID, found.ID)
+	require.NoError(t, listErr)
+	require.Len(t, shared, 2)
+	assert.Equal(t, first.ID, shared[0].ID)
+	assert.Equal(t, second.ID, shared[1].ID)
+}
+
+// TestTenantIsolation_Files tests that file records are only visible to their tenant
+func TestTenantIsolation_Files(t *testing.T) {
+	// Arrange
+	repo := NewFileRepository(newTestClient(t))
+	tenantA := entities.ContextWithTenant(context.Background(), "tenant-a")
+	tenantB := entities.ContextWithTenant(context.Background(), "tenant-b")
+	file := &entities.FileMetadata{Bucket: "uploads", Key: "tenants/tenant-a/logo.png", FileName: "logo.png", ContentType: "image/png"}
+	require.NoError(t, repo.Create(tenantA, file))
+
+	// Act
+	_, getErr := repo.GetByID(tenantB, file.ID)
+	_, nameErr := repo.GetByName(tenantB, "uploads", file.FileName)
+	deleteErr := repo.Delete(tenantB, file.ID)
+	result, queryErr := repo.Query(tenantB, &entities.FileQuery{})
+
+	// Assert
+	assert.True(t, errors.Is(getErr, domainErrors.ErrNotFound))
+	assert.True(t, errors.Is(nameErr, domainErrors.ErrNotFound))
+	assert.True(t, errors.Is(deleteErr, domainErrors.ErrNotFound))
+	require.NoError(t, queryErr)
+	assert.Empty(t, result.Files)
+	_, err := repo.GetByID(tenantA, file.ID)
+	assert.NoError(t, err)
+}
+
+// TestFileRepository_UpdateImage tests that image info is recorded, and not recorded for replaced content
+func TestFileRepository_UpdateImage(t *testing.T) {
+	// Arrange
+	repo := NewFileRepository(newTestClient(t))
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	photo := &entities.FileMetadata{Key: "photo.jpg", ContentType: "image/jpeg", SHA256: "aaa"}
+	createTestFiles(t, repo, ctx, photo)
+
+	processed := *photo
+	processed.UpdatedAt = time.Now().Truncate(time.Second)
+	processed.Image = &entities.ImageInfo{
+		Width:         1200,
+		Height:        800,
+		BlurHash:      "LKO2?U%2Tw=w",
+		DominantColor: "#aa3311",
+		Derivatives: []entities.ImageDerivative{{
+			Name:      "thumb",
+			Transform: entities.ImageTransform{Width: 200, Height: 200, Fit: entities.ImageFitCover, Format: entities.ImageFormatWebP},
+			Width:     200,
+			Height:    200,
+			Size:      5120,
+		}},
+	}
+	stale := processed
+	stale.SHA256 = "bbb"
+
+	// Act
+	err := repo.UpdateImage(ctx, &processed)
+	staleErr := repo.UpdateImage(ctx, &stale)
+	stored, getErr := repo.GetByID(ctx, photo.ID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, errors.Is(staleErr, domainErrors.ErrNotFound))
+	require.NoError(t, getErr)
+	assert.Equal(t, processed.Image, stored.Image)
+}
@@ -0,0 +1,386 @@
+package persistence
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+)
+
+const (
+	// fsMetaDir holds the content type and checksum of each file, mirroring the bucket directories
+	fsMetaDir = ".meta"
+	// fsTempDir holds files while they are written, so that readers never see partial content
+	fsTempDir = ".tmp"
+)
+
+// fsFileMeta is what the filesystem does not record about a file
+type fsFileMeta struct {
+	ContentType string `json:"content_type"`
+	SHA256      string `json:"sha256"`
+}
+
+// FilesystemStorageRepository implements StorageRepository in a local directory, so that development
+// and tests need no object store. Buckets are directories of the root and file names are paths in them.
+type FilesystemStorageRepository struct {
+	root string
+}
+
+// NewFilesystemStorageRepository creates a storage repository in the root directory, creating it if needed
+func NewFilesystemStorageRepository(root string) (*FilesystemStorageRepository, error) {
+	for _, dir := range []string{root, filepath.Join(root, fsMetaDir), filepath.Join(root, fsTempDir)} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create storage directory: %w", err)
+		}
+	}
+	return &FilesystemStorageRepository{root: root}, nil
+}
+
+// Store writes a file to a temporary file and moves it into place once it is complete
+func (r *FilesystemStorageRepository) Store(ctx context.Context, bucket, fileName string, reader io.Reader, size int64, contentType string) (*entities.FileMetadata, error) {
+	path, metaPath, err := r.paths(bucket, fileName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(r.root, bucket)); err != nil {
+		return nil, fmt.Errorf("bucket %q does not exist: %w", bucket, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Join(r.root, fsTempDir), "upload-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(tmp, hash), reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+	if size >= 0 && written != size {
+		return nil, fmt.Errorf("file has %d bytes, expected %d", written, size)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	meta, err := json.Marshal(fsFileMeta{ContentType: contentType, SHA256: hex.EncodeToString(hash.Sum(nil))})
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range []string{filepath.Dir(path), filepath.Dir(metaPath)} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create directory: %w", err)
+		}
+	}
+	metaTmp, err := r.writeTemp("meta-*", meta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write file metadata: %w", err)
+	}
+	defer os.Remove(metaTmp)
+
+	// The metadata is moved into place only after the content, so that a failure leaves neither the new
+	// checksum on the old content nor the new content with the old one: a replaced file is put back
+	// from a link kept to it, a new one is removed
+	backup := filepath.Join(r.root, fsTempDir, filepath.Base(tmp.Name())+".old")
+	kept := os.Link(path, backup) == nil
+	if kept {
+		defer os.Remove(backup)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(metaTmp, metaPath); err != nil {
+		if kept {
+			os.Rename(backup, path)
+		} else {
+			os.Remove(path)
+		}
+		return nil, fmt.Errorf("failed to write file metadata: %w", err)
+	}
+
+	return &entities.FileMetadata{
+		FileName:    fileName,
+		Bucket:      bucket,
+		Key:         fileName,
+		Size:        written,
+		ContentType: contentType,
+		URL:         downloadURL(bucket, fileName),
+		UploadedAt:  time.Now(),
+	}, nil
+}
+
+// writeTemp writes data to a new temporary file named after pattern and returns its path
+func (r *FilesystemStorageRepository) writeTemp(pattern string, data []byte) (string, error) {
+	f, err := os.CreateTemp(filepath.Join(r.root, fsTempDir), pattern)
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Chmod(0o644)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// Remove deletes a file; removing a file that does not exist is not an error
+func (r *FilesystemStorageRepository) Remove(ctx context.Context, bucket, fileName string) error {
+	path, metaPath, err := r.paths(bucket, fileName)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range []string{path, metaPath} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to delete file: %w", err)
+		}
+	}
+
+	// Drop the directories the file leaves empty
+	r.removeEmptyDirs(filepath.Dir(path), filepath.Join(r.root, bucket))
+	r.removeEmptyDirs(filepath.Dir(metaPath), filepath.Join(r.root, fsMetaDir, bucket))
+	return nil
+}
+
+// RemovePrefix deletes every file whose name starts with prefix
+func (r *FilesystemStorageRepository) RemovePrefix(ctx context.Context, bucket, prefix string) error {
+	bucketDir := filepath.Join(r.root, bucket)
+
+	var names []string
+	err := filepath.WalkDir(bucketDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(bucketDir, path)
+		if err != nil {
+			return err
+		}
+		if name := filepath.ToSlash(rel); strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list files: %w", err)
+	}
+
+	for _, name := range names {
+		if err := r.Remove(ctx, bucket, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Copy copies a file and its metadata within a bucket
+func (r *FilesystemStorageRepository) Copy(ctx context.Context, bucket, src, dst string) error {
+	srcPath, srcMetaPath, err := r.paths(bucket, src)
+	if err != nil {
+		return err
+	}
+	source, err := os.Open(srcPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return domainErrors.NewNotFoundError("File", src)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer source.Close()
+
+	meta := fsFileMeta{ContentType: "application/octet-stream"}
+	if data, err := os.ReadFile(srcMetaPath); err == nil {
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return fmt.Errorf("failed to read file metadata: %w", err)
+		}
+	}
+
+	// Store hashes the copy anew, which also covers files without metadata
+	_, err = r.Store(ctx, bucket, dst, source, -1, meta.ContentType)
+	return err
+}
+
+// Compose concatenates the parts into a file, which is written like any other
+func (r *FilesystemStorageRepository) Compose(ctx context.Context, bucket, fileName string, parts []entities.UploadPart, contentType string) (*entities.FileMetadata, error) {
+	return composeByCopy(ctx, r, bucket, fileName, parts, contentType)
+}
+
+// List lists the files whose name starts with prefix
+func (r *FilesystemStorageRepository) List(ctx context.Context, bucket, prefix string, fn func(*entities.FileMetadata) error) error {
+	bucketDir := filepath.Join(r.root, bucket)
+
+	err := filepath.WalkDir(bucketDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(bucketDir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if !strings.HasPrefix(name, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(&entities.FileMetadata{
+			FileName:   name,
+			Bucket:     bucket,
+			Key:        name,
+			Size:       info.Size(),
+			UploadedAt: info.ModTime(),
+		})
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// GetURL generates a relative URL for the file that will be proxied through the API
+func (r *FilesystemStorageRepository) GetURL(ctx context.Context, bucket, fileName string) (string, error) {
+	return downloadURL(bucket, fileName), nil
+}
+
+// GetFile opens a file, or a range of it, and returns its content, size, and content type
+func (r *FilesystemStorageRepository) GetFile(ctx context.Context, bucket, fileName string, rng *entities.ByteRange) (io.ReadCloser, int64, string, error) {
+	metadata, err := r.Stat(ctx, bucket, fileName)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	path, _, err := r.paths(bucket, fileName)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("failed to open file: %w", err)
+	}
+	if rng == nil {
+		return file, metadata.Size, metadata.ContentType, nil
+	}
+
+	if _, err := file.Seek(rng.Start, io.SeekStart); err != nil {
+		file.Close()
+		return nil, 0, "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return readCloser{Reader: io.LimitReader(file, rng.Length()), Closer: file}, rng.Length(), metadata.ContentType, nil
+}
+
+// EnsureBucket creates the directory of a bucket if it doesn't exist
+func (r *FilesystemStorageRepository) EnsureBucket(ctx context.Context, bucket string) error {
+	if err := validateBucketDir(bucket); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(r.root, bucket), 0o755); err != nil {
+		return fmt.Errorf("failed to create bucket: %w", err)
+	}
+	return nil
+}
+
+// Stat returns the metadata of a file
+func (r *FilesystemStorageRepository) Stat(ctx context.Context, bucket, fileName string) (*entities.FileMetadata, error) {
+	path, metaPath, err := r.paths(bucket, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) || err == nil && info.IsDir() {
+		return nil, domainErrors.NewNotFoundError("File", fileName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file stats: %w", err)
+	}
+
+	// Files copied into the directory by hand have no metadata
+	meta := fsFileMeta{ContentType: "application/octet-stream"}
+	if data, err := os.ReadFile(metaPath); err == nil {
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return nil, fmt.Errorf("failed to read file metadata: %w", err)
+		}
+	}
+
+	return &entities.FileMetadata{
+		FileName:    fileName,
+		Bucket:      bucket,
+		Key:         fileName,
+		Size:        info.Size(),
+		ContentType: meta.ContentType,
+		SHA256:      meta.SHA256,
+		URL:         downloadURL(bucket, fileName),
+		UploadedAt:  info.ModTime(),
+	}, nil
+}
+
+// PresignUpload is not supported, as clients cannot reach the directory
+func (r *FilesystemStorageRepository) PresignUpload(ctx context.Context, bucket, fileName, contentType string, size int64, sha256 string, expiry time.Duration) (*entities.PresignedRequest, error) {
+	return nil, errPresignNotSupported
+}
+
+// PresignDownload is not supported, as clients cannot reach the directory
+func (r *FilesystemStorageRepository) PresignDownload(ctx context.Context, bucket, fileName string, expiry time.Duration) (*entities.PresignedRequest, error) {
+	return nil, errPresignNotSupported
+}
+
+// paths returns the path of a file and of its metadata, making sure both lie within the bucket
+func (r *FilesystemStorageRepository) paths(bucket, fileName string) (string, string, error) {
+	if err := validateBucketDir(bucket); err != nil {
+		return "", "", err
+	}
+	name := filepath.FromSlash(fileName)
+	if !filepath.IsLocal(name) {
+		return "", "", fmt.Errorf("invalid file name %q", fileName)
+	}
+	return filepath.Join(r.root, bucket, name), filepath.Join(r.root, fsMetaDir, bucket, name+".json"), nil
+}
+
+// removeEmptyDirs removes dir and its parents up to, but not including, stop while they are empty
+func (r *FilesystemStorageRepository) removeEmptyDirs(dir, stop string) {
+	for dir != stop && strings.HasPrefix(dir, stop) {
+		// Fails once a directory is not empty
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// validateBucketDir rejects bucket names that are not a single directory of the root or that clash with
+// the directories of the repository
+func validateBucketDir(bucket string) error {
+	if bucket == "" || strings.HasPrefix(bucket, ".") || strings.ContainsAny(bucket, `/\`) {
+		return fmt.Errorf("invalid bucket name %q", bucket)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package persistence
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFilesystemStorage_RejectsPathsOutsideBucket tests that file and bucket names cannot escape the root
+func TestFilesystemStorage_RejectsPathsOutsideBucket(t *testing.T) {
+	// Arrange
+	root := t.TempDir()
+	repo, err := NewFilesystemStorageRepository(filepath.Join(root, "storage"))
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, repo.EnsureBucket(ctx, "uploads"))
+
+	// Act
+	_, parentErr := repo.Store(ctx, "uploads", "../../escaped.txt", strings.NewReader("x"), 1, "text/plain")
+	_, absoluteErr := repo.Store(ctx, "uploads", "/etc/escaped.txt", strings.NewReader("x"), 1, "text/plain")
+	_, bucketErr := repo.Store(ctx, "..", "escaped.txt", strings.NewReader("x"), 1, "text/plain")
+	_, metaErr := repo.Store(ctx, ".meta", "escaped.txt", strings.NewReader("x"), 1, "text/plain")
+
+	// Assert
+	assert.Error(t, parentErr)
+	assert.Error(t, absoluteErr)
+	assert.Error(t, bucketErr)
+	assert.Error(t, metaErr)
+	_, statErr := os.Stat(filepath.Join(root, "escaped.txt"))
+	assert.True(t, os.IsNotExist(statErr))
+}
+
+// TestFilesystemStorage_StoreSizeMismatch tests that a file shorter than declared is not stored
+func TestFilesystemStorage_StoreSizeMismatch(t *testing.T) {
+	// Arrange
+	repo, err := NewFilesystemStorageRepository(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, repo.EnsureBucket(ctx, "uploads"))
+
+	// Act
+	_, storeErr := repo.Store(ctx, "uploads", "short.txt", strings.NewReader("abc"), 10, "text/plain")
+	_, statErr := repo.Stat(ctx, "uploads", "short.txt")
+
+	// Assert
+	assert.Error(t, storeErr)
+	assert.Error(t, statErr)
+}
+
+// TestFilesystemStorage_StoreContentFailure tests that the metadata of a file is left alone when its new
+// content cannot be moved into place
+func TestFilesystemStorage_StoreContentFailure(t *testing.T) {
+	// Arrange
+	root := t.TempDir()
+	repo, err := NewFilesystemStorageRepository(root)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, repo.EnsureBucket(ctx, "uploads"))
+	_, err = repo.Store(ctx, "uploads", "kept.txt", strings.NewReader("old"), 3, "text/plain")
+	require.NoError(t, err)
+	metaPath := filepath.Join(root, fsMetaDir, "uploads", "kept.txt.json")
+	meta, err := os.ReadFile(metaPath)
+	require.NoError(t, err)
+	// A directory in place of the content cannot be replaced by a file
+	path := filepath.Join(root, "uploads", "kept.txt")
+	require.NoError(t, os.Remove(path))
+	require.NoError(t, os.MkdirAll(filepath.Join(path, "blocked"), 0o755))
+
+	// Act
+	_, storeErr := repo.Store(ctx, "uploads", "kept.txt", strings.NewReader("new"), 3, "text/html")
+
+	// Assert
+	assert.Error(t, storeErr)
+	after, err := os.ReadFile(metaPath)
+	require.NoError(t, err)
+	assert.Equal(t, meta, after)
+	leftovers, err := os.ReadDir(filepath.Join(root, fsTempDir))
+	require.NoError(t, err)
+	assert.Empty(t, leftovers)
+}
+
+// TestFilesystemStorage_StoreMetadataFailure tests that a file whose metadata cannot be moved into place
+// keeps its former content, and that a new one is not stored
+func TestFilesystemStorage_StoreMetadataFailure(t *testing.T) {
+	// Arrange
+	root := t.TempDir()
+	repo, err := NewFilesystemStorageRepository(root)
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, repo.EnsureBucket(ctx, "uploads"))
+	_, err = repo.Store(ctx, "uploads", "kept.txt", strings.NewReader("old"), 3, "text/plain")
+	require.NoError(t, err)
+	// A directory in place of the metadata cannot be replaced by a file
+	for _, name := range []string{"kept.txt", "new.txt"} {
+		metaPath := filepath.Join(root, fsMetaDir, "uploads", name+".json")
+		require.NoError(t, os.RemoveAll(metaPath))
+		require.NoError(t, os.MkdirAll(filepath.Join(metaPath, "blocked"), 0o755))
+	}
+
+	// Act
+	_, replaceErr := repo.Store(ctx, "uploads", "kept.txt", strings.NewReader("new"), 3, "text/plain")
+	_, newErr := repo.Store(ctx, "uploads", "new.txt", strings.NewReader("new"), 3, "text/plain")
+
+	// Assert
+	assert.Error(t, replaceErr)
+	assert.Error(t, newErr)
+	reader, _, _, err := repo.GetFile(ctx, "uploads", "kept.txt", nil)
+	require.NoError(t, err)
+	content, err := io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(content))
+	_, statErr := os.Stat(filepath.Join(root, "uploads", "new.txt"))
+	assert.True(t, os.IsNotExist(statErr))
+	leftovers, err := os.ReadDir(filepath.Join(root, fsTempDir))
+	require.NoError(t, err)
+	assert.Empty(t, leftovers)
+}
@@ -0,0 +1,76 @@
+package persistence
+
+import (
+	"context"
+	stdsql "database/sql"
+	"errors"
+	"fmt"
+
+	"example.com/go-yippi/internal/adapters/persistence/migrations"
+	"example.com/go-yippi/internal/domain/entities"
+	"example.com/go-yippi/internal/domain/ports"
+)
+
+// errListed stops a listing at its first object
+var errListed = errors.New("listed")
+
+// DatabaseCheck checks that the database accepts connections
+type DatabaseCheck struct {
+	db *stdsql.DB
+}
+
+// NewDatabaseCheck creates a check pinging the database behind db
+func NewDatabaseCheck(db *stdsql.DB) *DatabaseCheck {
+	return &DatabaseCheck{db: db}
+}
+
+func (c *DatabaseCheck) Name() string {
+	return "database"
+}
+
+func (c *DatabaseCheck) Check(ctx context.Context) error {
+	return c.db.PingContext(ctx)
+}
+
+// MigrationCheck checks that the database has every migration applied, so that instances of a release
+// whose migrations have not run yet are not ready
+type MigrationCheck struct {
+	migrator *migrations.Migrator
+}
+
+// NewMigrationCheck creates a check failing while migrator has pending migrations
+func NewMigrationCheck(migrator *migrations.Migrator) *MigrationCheck {
+	return &MigrationCheck{migrator: migrator}
+}
+
+func (c *MigrationCheck) Name() string {
+	return "migrations"
+}
+
+func (c *MigrationCheck) Check(ctx context.Context) error {
+	return c.migrator.Ensure(ctx, false)
+}
+
+// StorageCheck checks that a bucket can be listed
+type StorageCheck struct {
+	repo   ports.StorageRepository
+	bucket string
+}
+
+// NewStorageCheck creates a check listing the bucket through repo, which fails if the backend cannot be
+// reached or, on MinIO, the bucket does not exist; the other backends list missing buckets as empty.
+func NewStorageCheck(repo ports.StorageRepository, bucket string) *StorageCheck {
+	return &StorageCheck{repo: repo, bucket: bucket}
+}
+
+func (c *StorageCheck) Name() string {
+	return "storage"
+}
+
+func (c *StorageCheck) Check(ctx context.Context) error {
+	err := c.repo.List(ctx, c.bucket, "", func(*entities.FileMetadata) error { return errListed })
+	if err != nil && !errors.Is(err, errListed) {
+		return fmt.Errorf("failed to list bucket %s: %w", c.bucket, err)
+	}
+	return nil
+}
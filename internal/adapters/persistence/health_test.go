@@ -0,0 +1,82 @@
+package persistence
+
+import (
+	"context"
+	stdsql "database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/go-yippi/internal/adapters/persistence/migrations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMigrationCheck tests that a database is reported as down until its migrations have been applied
+func TestMigrationCheck(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	db, err := stdsql.Open("sqlite3", "file:"+t.Name()+"?mode=memory&cache=shared&_fk=1")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migrations.NewEmbedded(db, "sqlite3", time.Second, nil)
+	require.NoError(t, err)
+	check := NewMigrationCheck(migrator)
+
+	// Act
+	pendingErr := check.Check(ctx)
+	_, upErr := migrator.Up(ctx, false)
+	migratedErr := check.Check(ctx)
+
+	// Assert
+	assert.ErrorContains(t, pendingErr, "migrations are pending")
+	require.NoError(t, upErr)
+	assert.NoError(t, migratedErr)
+}
+
+// TestDatabaseCheck tests that a closed database is reported as down
+func TestDatabaseCheck(t *testing.T) {
+	// Arrange
+	client, db, err := OpenObserved("sqlite3", "file:"+t.Name()+"?mode=memory&cache=shared&_fk=1")
+	require.NoError(t, err)
+	check := NewDatabaseCheck(db)
+
+	// Act
+	upErr := check.Check(context.Background())
+	client.Close()
+	downErr := check.Check(context.Background())
+
+	// Assert
+	assert.NoError(t, upErr)
+	assert.Error(t, downErr)
+}
+
+// TestStorageCheck tests that buckets pass whether or not they hold files, and that storage which cannot
+// be read fails
+func TestStorageCheck(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	root := filepath.Join(t.TempDir(), "storage")
+	repo, err := NewFilesystemStorageRepository(root)
+	require.NoError(t, err)
+	require.NoError(t, repo.EnsureBucket(ctx, "empty"))
+	require.NoError(t, repo.EnsureBucket(ctx, "full"))
+	for _, name := range []string{"a.txt", "b.txt"} {
+		_, err := repo.Store(ctx, "full", name, strings.NewReader("x"), 1, "text/plain")
+		require.NoError(t, err)
+	}
+
+	// Act
+	emptyErr := NewStorageCheck(repo, "empty").Check(ctx)
+	fullErr := NewStorageCheck(repo, "full").Check(ctx)
+	require.NoError(t, os.RemoveAll(root))
+	require.NoError(t, os.WriteFile(root, nil, 0o644))
+	brokenErr := NewStorageCheck(repo, "full").Check(ctx)
+
+	// Assert
+	assert.NoError(t, emptyErr)
+	assert.NoError(t, fullErr)
+	assert.ErrorContains(t, brokenErr, "failed to list bucket full")
+}
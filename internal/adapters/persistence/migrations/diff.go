@@ -0,0 +1,64 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ariga.io/atlas/sql/migrate"
+	"ariga.io/atlas/sql/sqltool"
+	entsql "entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/schema"
+	entmigrate "example.com/go-yippi/internal/adapters/persistence/db/ent/migrate"
+)
+
+// Diff plans the migration from the schema the migrations in dir build to the Ent schema and writes it to
+// dir as a new migration called name, along with the statements reverting it. The migrations are replayed on
+// a clean development database of driver at devDSN first. Dropped columns and indexes are part of the plan;
+// renames show up as a drop and an add, which should be edited into a rename before the files are committed.
+// Diff reports false if the schemas match and nothing was written.
+func Diff(ctx context.Context, dir, driver, devDSN, name string) (bool, error) {
+	migrationDir, err := sqltool.NewGolangMigrateDir(dir)
+	if err != nil {
+		return false, err
+	}
+	dev, err := entsql.Open(driver, devDSN)
+	if err != nil {
+		return false, fmt.Errorf("failed to open development database: %w", err)
+	}
+	defer dev.Close()
+
+	planner, err := schema.NewMigrate(dev,
+		schema.WithDir(migrationDir),
+		schema.WithFormatter(sqltool.GolangMigrateFormatter),
+		schema.WithMigrationMode(schema.ModeReplay),
+		schema.WithDialect(driver),
+		schema.WithDropColumn(true),
+		schema.WithDropIndex(true),
+		schema.WithErrNoPlan(true),
+	)
+	if err != nil {
+		return false, err
+	}
+	err = planner.NamedDiff(ctx, name, entmigrate.Tables...)
+	switch {
+	case errors.Is(err, migrate.ErrNoPlan):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("failed to plan migration: %w", err)
+	}
+	return true, nil
+}
+
+// Hash rewrites the atlas.sum file of dir, e.g. after a migration was edited
+func Hash(dir string) error {
+	migrationDir, err := sqltool.NewGolangMigrateDir(dir)
+	if err != nil {
+		return err
+	}
+	sum, err := migrationDir.Checksum()
+	if err != nil {
+		return err
+	}
+	return migrate.WriteSumFile(migrationDir, sum)
+}
@@ -0,0 +1,117 @@
+// Package migrations holds the versioned migrations of the database schema and applies them. Migrations are
+// planned from the difference between the Ent schema and the schema the previous migrations build, one
+// directory per dialect, and may be edited before they are committed, e.g. to rename a column instead of
+// dropping and adding it.
+//
+// Each migration is a pair of files named VERSION_NAME.up.sql and VERSION_NAME.down.sql, the format of
+// golang-migrate. The atlas.sum file of a directory holds the checksums of its files, so that edited
+// migrations are noticed; run the hash command after editing one.
+package migrations
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"ariga.io/atlas/sql/migrate"
+)
+
+//go:embed postgres/* sqlite3/*
+var files embed.FS
+
+// Migration is a versioned change of the schema
+type Migration struct {
+	Version string // timestamp the migration was planned at, e.g. 20261018120000
+	Name    string
+	Up      string // statements applying the change
+	Down    string // statements reverting the change, empty if it cannot be reverted
+}
+
+// ID returns the version and name of the migration, as in its file names
+func (m Migration) ID() string {
+	return m.Version + "_" + m.Name
+}
+
+// Embedded returns the migrations of a database driver, "postgres" or "sqlite3", in order
+func Embedded(driver string) ([]Migration, error) {
+	dir, err := fs.Sub(files, driver)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fs.Stat(dir, "."); err != nil {
+		return nil, fmt.Errorf("no migrations for database driver %q", driver)
+	}
+	return Read(dir)
+}
+
+// Read returns the migrations of a directory in order, after checking its files against atlas.sum
+func Read(dir fs.FS) ([]Migration, error) {
+	if err := verifySum(dir); err != nil {
+		return nil, err
+	}
+
+	ups, err := fs.Glob(dir, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(ups)
+	migrations := make([]Migration, 0, len(ups))
+	for _, name := range ups {
+		id := strings.TrimSuffix(name, ".up.sql")
+		version, label, ok := strings.Cut(id, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s is not named VERSION_NAME.up.sql", name)
+		}
+		up, err := fs.ReadFile(dir, name)
+		if err != nil {
+			return nil, err
+		}
+		down, err := fs.ReadFile(dir, id+".down.sql")
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: label, Up: string(up), Down: string(down)})
+	}
+	return migrations, nil
+}
+
+// verifySum compares the SQL files of dir with atlas.sum, computed like Atlas does for a local directory
+func verifySum(dir fs.FS) error {
+	names, err := fs.Glob(dir, "*.sql")
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	sqlFiles := make([]migrate.File, 0, len(names))
+	for _, name := range names {
+		content, err := fs.ReadFile(dir, name)
+		if err != nil {
+			return err
+		}
+		sqlFiles = append(sqlFiles, migrate.NewLocalFile(name, content))
+	}
+	sum, err := migrate.NewHashFile(sqlFiles)
+	if err != nil {
+		return err
+	}
+	want, err := sum.MarshalText()
+	if err != nil {
+		return err
+	}
+
+	got, err := fs.ReadFile(dir, migrate.HashFileName)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", migrate.HashFileName, err)
+	}
+	if !bytes.Equal(got, want) {
+		return fmt.Errorf("migration files do not match %s; run the hash command after editing a migration", migrate.HashFileName)
+	}
+	return nil
+}
@@ -0,0 +1,429 @@
+package migrations
+
+import (
+	"context"
+	stdsql "database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// postgresLockKey identifies the advisory lock migrations hold on PostgreSQL
+	postgresLockKey int64 = 0x79697070 // "yipp"
+	// sqliteLockTTL bounds how long the lock row of a migration run that crashed keeps others waiting
+	sqliteLockTTL = 15 * time.Minute
+)
+
+// ErrLocked is returned when another process holds the migration lock for longer than the lock timeout
+var ErrLocked = errors.New("migrations are locked by another process")
+
+// Status is a migration and when it was applied
+type Status struct {
+	Migration
+	AppliedAt time.Time // zero while the migration is pending
+	Unknown   bool      // applied to the database but missing from the migrations, e.g. by a newer release
+}
+
+// Migrator applies and reverts migrations, recording the applied versions in the schema_migrations table.
+// Runs that change the schema hold a lock, an advisory lock on PostgreSQL and a lock row on SQLite, so that
+// replicas starting together migrate one after the other. Every migration runs in a transaction.
+type Migrator struct {
+	db          *stdsql.DB
+	driver      string
+	migrations  []Migration
+	lockTimeout time.Duration
+	logger      *slog.Logger
+	now         func() time.Time
+}
+
+// New creates a migrator for a database of driver, "postgres" or "sqlite3", that waits up to lockTimeout
+// for the lock. A nil logger logs with slog.Default.
+func New(db *stdsql.DB, driver string, migrations []Migration, lockTimeout time.Duration, logger *slog.Logger) *Migrator {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Migrator{
+		db:          db,
+		driver:      driver,
+		migrations:  migrations,
+		lockTimeout: lockTimeout,
+		logger:      logger,
+		now:         time.Now,
+	}
+}
+
+// NewEmbedded creates a migrator like New for the embedded migrations of driver
+func NewEmbedded(db *stdsql.DB, driver string, lockTimeout time.Duration, logger *slog.Logger) (*Migrator, error) {
+	migrations, err := Embedded(driver)
+	if err != nil {
+		return nil, err
+	}
+	return New(db, driver, migrations, lockTimeout, logger), nil
+}
+
+// Status returns every migration with when it was applied, followed by the applied versions that are not
+// among the migrations
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		statuses = append(statuses, Status{Migration: migration, AppliedAt: applied[migration.Version].AppliedAt})
+		delete(applied, migration.Version)
+	}
+	for _, status := range sortedStatuses(applied) {
+		status.Unknown = true
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Pending returns the migrations that have not been applied, in order
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+	return m.pending(applied), nil
+}
+
+// Up applies the pending migrations in order and returns them. With dryRun it only returns them.
+func (m *Migrator) Up(ctx context.Context, dryRun bool) ([]Migration, error) {
+	if dryRun {
+		return m.Pending(ctx)
+	}
+
+	var done []Migration
+	err := m.locked(ctx, func(conn *stdsql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.pending(applied) {
+			start := m.now()
+			err := m.run(ctx, conn, migration.Up, func(tx *stdsql.Tx) error {
+				return m.record(ctx, tx, migration)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to apply migration %s: %w", migration.ID(), err)
+			}
+			m.logger.InfoContext(ctx, "migration applied", "version", migration.Version, "name", migration.Name, "duration", m.now().Sub(start))
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the last steps applied migrations, latest first, and returns them. With dryRun it only
+// returns them.
+func (m *Migrator) Down(ctx context.Context, steps int, dryRun bool) ([]Migration, error) {
+	revert := func(q querier) ([]Migration, error) {
+		applied, err := m.applied(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		var migrations []Migration
+		for i := len(m.migrations) - 1; i >= 0 && len(migrations) < steps; i-- {
+			if _, ok := applied[m.migrations[i].Version]; ok {
+				migrations = append(migrations, m.migrations[i])
+			}
+		}
+		for _, migration := range migrations {
+			if strings.TrimSpace(migration.Down) == "" {
+				return nil, fmt.Errorf("migration %s cannot be reverted", migration.ID())
+			}
+		}
+		return migrations, nil
+	}
+	if dryRun {
+		return revert(m.db)
+	}
+
+	var done []Migration
+	err := m.locked(ctx, func(conn *stdsql.Conn) error {
+		migrations, err := revert(conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range migrations {
+			err := m.run(ctx, conn, migration.Down, func(tx *stdsql.Tx) error {
+				_, err := tx.ExecContext(ctx, m.rebind("DELETE FROM schema_migrations WHERE version = ?"), migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to revert migration %s: %w", migration.ID(), err)
+			}
+			m.logger.InfoContext(ctx, "migration reverted", "version", migration.Version, "name", migration.Name)
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Baseline records the migrations up to and including version as applied without running them, for
+// databases whose schema was created before migrations were versioned. It returns the recorded migrations.
+func (m *Migrator) Baseline(ctx context.Context, version string) ([]Migration, error) {
+	found := false
+	for _, migration := range m.migrations {
+		found = found || migration.Version == version
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown migration version %s", version)
+	}
+
+	var done []Migration
+	err := m.locked(ctx, func(conn *stdsql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.pending(applied) {
+			if migration.Version > version {
+				break
+			}
+			err := m.run(ctx, conn, "", func(tx *stdsql.Tx) error { return m.record(ctx, tx, migration) })
+			if err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Ensure prepares the schema for the application: with apply it applies the pending migrations, and
+// otherwise it fails if any are pending
+func (m *Migrator) Ensure(ctx context.Context, apply bool) error {
+	if apply {
+		_, err := m.Up(ctx, false)
+		return err
+	}
+
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%d migrations are pending, starting with %s", len(pending), pending[0].ID())
+	}
+	return nil
+}
+
+func (m *Migrator) pending(applied map[string]Status) []Migration {
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending
+}
+
+func (m *Migrator) record(ctx context.Context, tx *stdsql.Tx, migration Migration) error {
+	_, err := tx.ExecContext(ctx, m.rebind("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)"),
+		migration.Version, migration.Name, m.now().UTC())
+	return err
+}
+
+// querier is a database, connection or transaction
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (stdsql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*stdsql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *stdsql.Row
+}
+
+// applied returns the applied migrations by version; a database without the schema_migrations table has none
+func (m *Migrator) applied(ctx context.Context, q querier) (map[string]Status, error) {
+	exists := "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'"
+	if m.driver == "postgres" {
+		exists = "SELECT COUNT(*) FROM pg_tables WHERE schemaname = current_schema() AND tablename = 'schema_migrations'"
+	}
+	var tables int
+	if err := q.QueryRowContext(ctx, exists).Scan(&tables); err != nil {
+		return nil, fmt.Errorf("failed to look up applied migrations: %w", err)
+	}
+	applied := map[string]Status{}
+	if tables == 0 {
+		return applied, nil
+	}
+
+	rows, err := q.QueryContext(ctx, "SELECT version, name, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up applied migrations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status Status
+		if err := rows.Scan(&status.Version, &status.Name, &status.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied[status.Version] = status
+	}
+	return applied, rows.Err()
+}
+
+// locked runs fn on a connection holding the migration lock, after creating the schema_migrations table
+func (m *Migrator) locked(ctx context.Context, fn func(*stdsql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	unlock, err := m.lock(ctx, conn)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version VARCHAR(32) NOT NULL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	applied_at TIMESTAMP NOT NULL
+)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return fn(conn)
+}
+
+// lock takes the migration lock, waiting for other processes to release it
+func (m *Migrator) lock(ctx context.Context, conn *stdsql.Conn) (func(), error) {
+	acquire := func() (bool, error) {
+		var locked bool
+		err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", postgresLockKey).Scan(&locked)
+		return locked, err
+	}
+	release := func() {
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", postgresLockKey); err != nil {
+			m.logger.WarnContext(ctx, "failed to release migration lock", "error", err)
+		}
+	}
+	if m.driver != "postgres" {
+		// SQLite has no advisory locks, so the lock is a row that expires if its holder crashes
+		_, err := conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations_lock (id INTEGER NOT NULL PRIMARY KEY, expires_at INTEGER NOT NULL)")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create schema_migrations_lock: %w", err)
+		}
+		acquire = func() (bool, error) {
+			now := m.now()
+			if _, err := conn.ExecContext(ctx, "DELETE FROM schema_migrations_lock WHERE expires_at < ?", now.Unix()); err != nil {
+				return false, err
+			}
+			result, err := conn.ExecContext(ctx, "INSERT OR IGNORE INTO schema_migrations_lock (id, expires_at) VALUES (1, ?)", now.Add(sqliteLockTTL).Unix())
+			if err != nil {
+				return false, err
+			}
+			inserted, err := result.RowsAffected()
+			return inserted == 1, err
+		}
+		release = func() {
+			if _, err := conn.ExecContext(context.WithoutCancel(ctx), "DELETE FROM schema_migrations_lock"); err != nil {
+				m.logger.WarnContext(ctx, "failed to release migration lock", "error", err)
+			}
+		}
+	}
+
+	deadline := m.now().Add(m.lockTimeout)
+	wait := 50 * time.Millisecond
+	for {
+		locked, err := acquire()
+		if err != nil {
+			return nil, fmt.Errorf("failed to take migration lock: %w", err)
+		}
+		if locked {
+			return release, nil
+		}
+		if !m.now().Before(deadline) {
+			return nil, ErrLocked
+		}
+		m.logger.InfoContext(ctx, "waiting for migration lock")
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		wait = min(2*wait, time.Second)
+	}
+}
+
+// run executes statements and then record in a transaction. On SQLite, foreign keys are not enforced while
+// the statements run, so that tables can be rebuilt, and are checked before the transaction commits.
+func (m *Migrator) run(ctx context.Context, conn *stdsql.Conn, statements string, record func(*stdsql.Tx) error) error {
+	if m.driver != "postgres" {
+		var enforced bool
+		if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&enforced); err != nil {
+			return err
+		}
+		if enforced {
+			if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = off"); err != nil {
+				return err
+			}
+			defer conn.ExecContext(context.WithoutCancel(ctx), "PRAGMA foreign_keys = on")
+		}
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if strings.TrimSpace(statements) != "" {
+		if _, err := tx.ExecContext(ctx, statements); err != nil {
+			return err
+		}
+	}
+	if m.driver != "postgres" {
+		rows, err := tx.QueryContext(ctx, "PRAGMA foreign_key_check")
+		if err != nil {
+			return err
+		}
+		violated := rows.Next()
+		rows.Close()
+		if violated {
+			return errors.New("foreign key constraints are violated")
+		}
+	}
+	if err := record(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// rebind replaces the ? placeholders of query with those of PostgreSQL if needed
+func (m *Migrator) rebind(query string) string {
+	if m.driver != "postgres" {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func sortedStatuses(byVersion map[string]Status) []Status {
+	statuses := make([]Status, 0, len(byVersion))
+	for _, status := range byVersion {
+		statuses = append(statuses, status)
+	}
+	slices.SortFunc(statuses, func(a, b Status) int { return strings.Compare(a.Version, b.Version) })
+	return statuses
+}
@@ -0,0 +1,227 @@
+package migrations
+
+import (
+	"context"
+	stdsql "database/sql"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	atlasmigrate "ariga.io/atlas/sql/migrate"
+	"entgo.io/ent/dialect"
+	entsql "entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/schema"
+	"example.com/go-yippi/internal/adapters/persistence/db/ent"
+	entmigrate "example.com/go-yippi/internal/adapters/persistence/db/ent/migrate"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openTestDB(t *testing.T) *stdsql.DB {
+	t.Helper()
+	db, err := stdsql.Open("sqlite3", "file:"+t.Name()+"?mode=memory&cache=shared&_fk=1")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+var testMigrations = []Migration{
+	{Version: "1", Name: "widgets", Up: "CREATE TABLE widgets (id INTEGER PRIMARY KEY);", Down: "DROP TABLE widgets;"},
+	{Version: "2", Name: "widget_name", Up: "ALTER TABLE widgets ADD COLUMN name TEXT;", Down: "ALTER TABLE widgets DROP COLUMN name;"},
+}
+
+func tableColumns(t *testing.T, db *stdsql.DB) []string {
+	t.Helper()
+	rows, err := db.Query("SELECT name FROM pragma_table_info('widgets')")
+	require.NoError(t, err)
+	defer rows.Close()
+	var columns []string
+	for rows.Next() {
+		var column string
+		require.NoError(t, rows.Scan(&column))
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// TestEmbedded_MatchEntSchema tests that the SQLite migrations build the schema Ent expects, so that
+// changes of the Ent schema come with a migration
+func TestEmbedded_MatchEntSchema(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	db := openTestDB(t)
+	embedded, err := Embedded("sqlite3")
+	require.NoError(t, err)
+	_, err = New(db, "sqlite3", embedded, time.Second, nil).Up(ctx, false)
+	require.NoError(t, err)
+	client := ent.NewClient(ent.Driver(entsql.OpenDB(dialect.SQLite, db)))
+
+	// Act
+	var pending []*atlasmigrate.Change
+	dryRun := schema.WithApplyHook(func(schema.Applier) schema.Applier {
+		return schema.ApplyFunc(func(_ context.Context, _ dialect.ExecQuerier, plan *atlasmigrate.Plan) error {
+			pending = plan.Changes
+			return nil
+		})
+	})
+	err = client.Schema.Create(ctx, entmigrate.WithDropIndex(true), entmigrate.WithDropColumn(true), dryRun)
+
+	// Assert
+	require.NoError(t, err)
+	for _, change := range pending {
+		t.Errorf("Ent schema differs from the migrations: %s", change.Comment)
+	}
+}
+
+// TestEmbedded_Dialects tests that every dialect has the same migrations
+func TestEmbedded_Dialects(t *testing.T) {
+	// Act
+	postgres, postgresErr := Embedded("postgres")
+	sqlite, sqliteErr := Embedded("sqlite3")
+	_, unknownErr := Embedded("mysql")
+
+	// Assert
+	require.NoError(t, postgresErr)
+	require.NoError(t, sqliteErr)
+	require.NotEmpty(t, sqlite)
+	require.Len(t, postgres, len(sqlite))
+	for i := range sqlite {
+		assert.Equal(t, sqlite[i].ID(), postgres[i].ID())
+		assert.NotEmpty(t, postgres[i].Down)
+	}
+	assert.Error(t, unknownErr)
+}
+
+// TestRead_Checksum tests that edited migrations are rejected until atlas.sum is rewritten
+func TestRead_Checksum(t *testing.T) {
+	// Arrange
+	dir := fstest.MapFS{
+		"1_widgets.up.sql":   {Data: []byte("CREATE TABLE widgets (id INTEGER PRIMARY KEY);\n")},
+		"1_widgets.down.sql": {Data: []byte("DROP TABLE widgets;\n")},
+	}
+	files := []atlasmigrate.File{
+		atlasmigrate.NewLocalFile("1_widgets.down.sql", dir["1_widgets.down.sql"].Data),
+		atlasmigrate.NewLocalFile("1_widgets.up.sql", dir["1_widgets.up.sql"].Data),
+	}
+	sum, err := atlasmigrate.NewHashFile(files)
+	require.NoError(t, err)
+	sumText, err := sum.MarshalText()
+	require.NoError(t, err)
+	dir[atlasmigrate.HashFileName] = &fstest.MapFile{Data: sumText}
+
+	// Act
+	migrations, readErr := Read(dir)
+	dir["1_widgets.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE gadgets (id INTEGER PRIMARY KEY);\n")}
+	_, editedErr := Read(dir)
+
+	// Assert
+	require.NoError(t, readErr)
+	assert.Equal(t, []Migration{{Version: "1", Name: "widgets", Up: "CREATE TABLE widgets (id INTEGER PRIMARY KEY);\n", Down: "DROP TABLE widgets;\n"}}, migrations)
+	assert.ErrorContains(t, editedErr, "do not match atlas.sum")
+}
+
+// TestMigrator_UpDown tests applying, reverting and the dry runs of both
+func TestMigrator_UpDown(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	db := openTestDB(t)
+	migrator := New(db, "sqlite3", testMigrations, time.Second, nil)
+
+	// Act
+	planned, planErr := migrator.Up(ctx, true)
+	columnsBefore := tableColumns(t, db)
+	applied, upErr := migrator.Up(ctx, false)
+	again, againErr := migrator.Up(ctx, false)
+	columnsUp := tableColumns(t, db)
+	reverting, revertPlanErr := migrator.Down(ctx, 1, true)
+	reverted, downErr := migrator.Down(ctx, 1, false)
+	columnsDown := tableColumns(t, db)
+	statuses, statusErr := migrator.Status(ctx)
+
+	// Assert
+	require.NoError(t, planErr)
+	assert.Equal(t, testMigrations, planned)
+	assert.Empty(t, columnsBefore)
+	require.NoError(t, upErr)
+	assert.Equal(t, testMigrations, applied)
+	require.NoError(t, againErr)
+	assert.Empty(t, again)
+	assert.Equal(t, []string{"id", "name"}, columnsUp)
+	require.NoError(t, revertPlanErr)
+	assert.Equal(t, testMigrations[1:], reverting)
+	require.NoError(t, downErr)
+	assert.Equal(t, testMigrations[1:], reverted)
+	assert.Equal(t, []string{"id"}, columnsDown)
+	require.NoError(t, statusErr)
+	require.Len(t, statuses, 2)
+	assert.False(t, statuses[0].AppliedAt.IsZero())
+	assert.True(t, statuses[1].AppliedAt.IsZero())
+}
+
+// TestMigrator_FailedMigration tests that a failing migration is rolled back and not recorded
+func TestMigrator_FailedMigration(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	db := openTestDB(t)
+	broken := append(testMigrations[:1:1], Migration{Version: "2", Name: "broken", Up: "ALTER TABLE widgets ADD COLUMN name TEXT; SELECT * FROM missing;"})
+	migrator := New(db, "sqlite3", broken, time.Second, nil)
+
+	// Act
+	applied, err := migrator.Up(ctx, false)
+	pending, pendingErr := migrator.Pending(ctx)
+
+	// Assert
+	assert.ErrorContains(t, err, "failed to apply migration 2_broken")
+	assert.Equal(t, broken[:1], applied)
+	require.NoError(t, pendingErr)
+	assert.Equal(t, broken[1:], pending)
+	assert.Equal(t, []string{"id"}, tableColumns(t, db))
+}
+
+// TestMigrator_Baseline tests that baselined migrations are recorded without running
+func TestMigrator_Baseline(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	db := openTestDB(t)
+	_, err := db.Exec("CREATE TABLE widgets (id INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+	migrator := New(db, "sqlite3", testMigrations, time.Second, nil)
+
+	// Act
+	_, unknownErr := migrator.Baseline(ctx, "9")
+	recorded, err := migrator.Baseline(ctx, "1")
+	verifyErr := migrator.Ensure(ctx, false)
+	applyErr := migrator.Ensure(ctx, true)
+
+	// Assert
+	assert.Error(t, unknownErr)
+	require.NoError(t, err)
+	assert.Equal(t, testMigrations[:1], recorded)
+	assert.ErrorContains(t, verifyErr, "1 migrations are pending, starting with 2_widget_name")
+	require.NoError(t, applyErr)
+	assert.Equal(t, []string{"id", "name"}, tableColumns(t, db))
+}
+
+// TestMigrator_Lock tests that a migration waits for the lock and gives up after the lock timeout
+func TestMigrator_Lock(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	db := openTestDB(t)
+	migrator := New(db, "sqlite3", testMigrations, 100*time.Millisecond, nil)
+	_, err := db.Exec("CREATE TABLE schema_migrations_lock (id INTEGER NOT NULL PRIMARY KEY, expires_at INTEGER NOT NULL)")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO schema_migrations_lock (id, expires_at) VALUES (1, ?)", time.Now().Add(time.Hour).Unix())
+	require.NoError(t, err)
+
+	// Act
+	_, lockedErr := migrator.Up(ctx, false)
+	_, err = db.Exec("DELETE FROM schema_migrations_lock")
+	require.NoError(t, err)
+	applied, unlockedErr := migrator.Up(ctx, false)
+
+	// Assert
+	assert.ErrorIs(t, lockedErr, ErrLocked)
+	require.NoError(t, unlockedErr)
+	assert.Len(t, applied, 2)
+}
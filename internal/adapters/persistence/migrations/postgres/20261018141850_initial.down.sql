@@ -0,0 +1,58 @@
+-- reverse: create index "productmedia_file_id" to table: "product_media"
+DROP INDEX "productmedia_file_id";
+-- reverse: create index "productmedia_product_id_file_id" to table: "product_media"
+DROP INDEX "productmedia_product_id_file_id";
+-- reverse: create index "productmedia_tenant_id" to table: "product_media"
+DROP INDEX "productmedia_tenant_id";
+-- reverse: create "product_media" table
+DROP TABLE "product_media";
+-- reverse: create index "product_tenant_id_slug" to table: "products"
+DROP INDEX "product_tenant_id_slug";
+-- reverse: create index "product_tenant_id_sku" to table: "products"
+DROP INDEX "product_tenant_id_sku";
+-- reverse: create index "product_tenant_id" to table: "products"
+DROP INDEX "product_tenant_id";
+-- reverse: create "products" table
+DROP TABLE "products";
+-- reverse: create index "category_tenant_id_name" to table: "categories"
+DROP INDEX "category_tenant_id_name";
+-- reverse: create index "category_tenant_id" to table: "categories"
+DROP INDEX "category_tenant_id";
+-- reverse: create "categories" table
+DROP TABLE "categories";
+-- reverse: create index "brand_tenant_id_name" to table: "brands"
+DROP INDEX "brand_tenant_id_name";
+-- reverse: create index "brand_tenant_id" to table: "brands"
+DROP INDEX "brand_tenant_id";
+-- reverse: create "brands" table
+DROP TABLE "brands";
+-- reverse: create index "file_tenant_id_created_at" to table: "files"
+DROP INDEX "file_tenant_id_created_at";
+-- reverse: create index "file_tenant_id_bucket_key" to table: "files"
+DROP INDEX "file_tenant_id_bucket_key";
+-- reverse: create index "file_tenant_id_bucket_file_name" to table: "files"
+DROP INDEX "file_tenant_id_bucket_file_name";
+-- reverse: create index "file_tenant_id" to table: "files"
+DROP INDEX "file_tenant_id";
+-- reverse: create "files" table
+DROP TABLE "files";
+-- reverse: create index "users_email_key" to table: "users"
+DROP INDEX "users_email_key";
+-- reverse: create "users" table
+DROP TABLE "users";
+-- reverse: create index "resumableupload_tenant_id_expires_at" to table: "resumable_uploads"
+DROP INDEX "resumableupload_tenant_id_expires_at";
+-- reverse: create index "resumableupload_tenant_id" to table: "resumable_uploads"
+DROP INDEX "resumableupload_tenant_id";
+-- reverse: create "resumable_uploads" table
+DROP TABLE "resumable_uploads";
+-- reverse: create index "contentobject_tenant_id_bucket_key" to table: "content_objects"
+DROP INDEX "contentobject_tenant_id_bucket_key";
+-- reverse: create index "contentobject_tenant_id" to table: "content_objects"
+DROP INDEX "contentobject_tenant_id";
+-- reverse: create "content_objects" table
+DROP TABLE "content_objects";
+-- reverse: create index "blob_bucket_key" to table: "blobs"
+DROP INDEX "blob_bucket_key";
+-- reverse: create "blobs" table
+DROP TABLE "blobs";
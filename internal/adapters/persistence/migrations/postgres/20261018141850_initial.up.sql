@@ -0,0 +1,58 @@
+-- create "blobs" table
+CREATE TABLE "blobs" ("id" bigint NOT NULL GENERATED BY DEFAULT AS IDENTITY, "bucket" character varying NOT NULL, "key" character varying NOT NULL, "content" bytea NOT NULL, "content_type" character varying NOT NULL, "size" bigint NOT NULL, "sha256" character varying NOT NULL, "created_at" timestamptz NOT NULL, "updated_at" timestamptz NOT NULL, PRIMARY KEY ("id"));
+-- create index "blob_bucket_key" to table: "blobs"
+CREATE UNIQUE INDEX "blob_bucket_key" ON "blobs" ("bucket", "key");
+-- create "content_objects" table
+CREATE TABLE "content_objects" ("id" bigint NOT NULL GENERATED BY DEFAULT AS IDENTITY, "tenant_id" character varying NOT NULL DEFAULT 'default', "bucket" character varying NOT NULL, "key" character varying NOT NULL, "refs" bigint NOT NULL, "created_at" timestamptz NOT NULL, "updated_at" timestamptz NOT NULL, PRIMARY KEY ("id"));
+-- create index "contentobject_tenant_id" to table: "content_objects"
+CREATE INDEX "contentobject_tenant_id" ON "content_objects" ("tenant_id");
+-- create index "contentobject_tenant_id_bucket_key" to table: "content_objects"
+CREATE UNIQUE INDEX "contentobject_tenant_id_bucket_key" ON "content_objects" ("tenant_id", "bucket", "key");
+-- create "resumable_uploads" table
+CREATE TABLE "resumable_uploads" ("id" uuid NOT NULL, "tenant_id" character varying NOT NULL DEFAULT 'default', "bucket" character varying NOT NULL, "file_name" character varying NOT NULL, "content_type" character varying NULL, "sha256" character varying NULL, "upload_length" bigint NOT NULL, "upload_offset" bigint NOT NULL DEFAULT 0, "metadata" jsonb NULL, "parts" jsonb NULL, "expires_at" timestamptz NOT NULL, "created_at" timestamptz NOT NULL, "updated_at" timestamptz NOT NULL, PRIMARY KEY ("id"));
+-- create index "resumableupload_tenant_id" to table: "resumable_uploads"
+CREATE INDEX "resumableupload_tenant_id" ON "resumable_uploads" ("tenant_id");
+-- create index "resumableupload_tenant_id_expires_at" to table: "resumable_uploads"
+CREATE INDEX "resumableupload_tenant_id_expires_at" ON "resumable_uploads" ("tenant_id", "expires_at");
+-- create "users" table
+CREATE TABLE "users" ("id" bigint NOT NULL GENERATED BY DEFAULT AS IDENTITY, "age" bigint NOT NULL, "name" character varying NOT NULL DEFAULT 'unknown', "email" character varying NULL, "password_hash" character varying NULL, "role" character varying NOT NULL DEFAULT 'viewer', "tenant_id" character varying NOT NULL DEFAULT 'default', "totp_secret" character varying NULL, "totp_enabled" boolean NOT NULL DEFAULT false, "totp_last_step" bigint NOT NULL DEFAULT 0, "recovery_codes" jsonb NULL, "created_at" timestamptz NOT NULL, "updated_at" timestamptz NOT NULL, PRIMARY KEY ("id"));
+-- create index "users_email_key" to table: "users"
+CREATE UNIQUE INDEX "users_email_key" ON "users" ("email");
+-- create "files" table
+CREATE TABLE "files" ("id" uuid NOT NULL, "tenant_id" character varying NOT NULL DEFAULT 'default', "bucket" character varying NOT NULL, "key" character varying NOT NULL, "file_name" character varying NOT NULL, "size" bigint NOT NULL, "content_type" character varying NOT NULL, "sha256" character varying NULL, "width" bigint NULL, "height" bigint NULL, "blurhash" character varying NULL, "dominant_color" character varying NULL, "derivatives" jsonb NULL, "created_at" timestamptz NOT NULL, "updated_at" timestamptz NOT NULL, "uploader_id" bigint NULL, PRIMARY KEY ("id"), CONSTRAINT "files_users_files" FOREIGN KEY ("uploader_id") REFERENCES "users" ("id") ON DELETE SET NULL);
+-- create index "file_tenant_id" to table: "files"
+CREATE INDEX "file_tenant_id" ON "files" ("tenant_id");
+-- create index "file_tenant_id_bucket_file_name" to table: "files"
+CREATE UNIQUE INDEX "file_tenant_id_bucket_file_name" ON "files" ("tenant_id", "bucket", "file_name");
+-- create index "file_tenant_id_bucket_key" to table: "files"
+CREATE INDEX "file_tenant_id_bucket_key" ON "files" ("tenant_id", "bucket", "key");
+-- create index "file_tenant_id_created_at" to table: "files"
+CREATE INDEX "file_tenant_id_created_at" ON "files" ("tenant_id", "created_at");
+-- create "brands" table
+CREATE TABLE "brands" ("id" uuid NOT NULL, "tenant_id" character varying NOT NULL DEFAULT 'default', "name" character varying NOT NULL, "created_at" timestamptz NOT NULL, "updated_at" timestamptz NOT NULL, PRIMARY KEY ("id"));
+-- create index "brand_tenant_id" to table: "brands"
+CREATE INDEX "brand_tenant_id" ON "brands" ("tenant_id");
+-- create index "brand_tenant_id_name" to table: "brands"
+CREATE UNIQUE INDEX "brand_tenant_id_name" ON "brands" ("tenant_id", "name");
+-- create "categories" table
+CREATE TABLE "categories" ("id" uuid NOT NULL, "tenant_id" character varying NOT NULL DEFAULT 'default', "name" character varying NOT NULL, "created_at" timestamptz NOT NULL, "updated_at" timestamptz NOT NULL, "parent_id" uuid NULL, PRIMARY KEY ("id"), CONSTRAINT "categories_categories_children" FOREIGN KEY ("parent_id") REFERENCES "categories" ("id") ON DELETE SET NULL);
+-- create index "category_tenant_id" to table: "categories"
+CREATE INDEX "category_tenant_id" ON "categories" ("tenant_id");
+-- create index "category_tenant_id_name" to table: "categories"
+CREATE UNIQUE INDEX "category_tenant_id_name" ON "categories" ("tenant_id", "name");
+-- create "products" table
+CREATE TABLE "products" ("id" bigint NOT NULL GENERATED BY DEFAULT AS IDENTITY, "tenant_id" character varying NOT NULL DEFAULT 'default', "sku" character varying NOT NULL, "slug" character varying NOT NULL, "name" character varying NOT NULL, "price" double precision NOT NULL, "description" text NULL, "weight" bigint NOT NULL DEFAULT 0, "length" bigint NOT NULL DEFAULT 0, "width" bigint NOT NULL DEFAULT 0, "height" bigint NOT NULL DEFAULT 0, "image_urls" jsonb NULL, "status" character varying NOT NULL DEFAULT 'draft', "created_at" timestamptz NOT NULL, "updated_at" timestamptz NOT NULL, "brand_id" uuid NULL, "category_id" uuid NULL, PRIMARY KEY ("id"), CONSTRAINT "products_brands_products" FOREIGN KEY ("brand_id") REFERENCES "brands" ("id") ON DELETE SET NULL, CONSTRAINT "products_categories_products" FOREIGN KEY ("category_id") REFERENCES "categories" ("id") ON DELETE SET NULL);
+-- create index "product_tenant_id" to table: "products"
+CREATE INDEX "product_tenant_id" ON "products" ("tenant_id");
+-- create index "product_tenant_id_sku" to table: "products"
+CREATE UNIQUE INDEX "product_tenant_id_sku" ON "products" ("tenant_id", "sku");
+-- create index "product_tenant_id_slug" to table: "products"
+CREATE UNIQUE INDEX "product_tenant_id_slug" ON "products" ("tenant_id", "slug");
+-- create "product_media" table
+CREATE TABLE "product_media" ("id" uuid NOT NULL, "tenant_id" character varying NOT NULL DEFAULT 'default', "position" bigint NOT NULL DEFAULT 0, "alt_text" character varying NULL, "is_primary" boolean NOT NULL DEFAULT false, "media_type" character varying NOT NULL, "created_at" timestamptz NOT NULL, "updated_at" timestamptz NOT NULL, "file_id" uuid NOT NULL, "product_id" bigint NOT NULL, PRIMARY KEY ("id"), CONSTRAINT "product_media_files_product_media" FOREIGN KEY ("file_id") REFERENCES "files" ("id") ON DELETE RESTRICT, CONSTRAINT "product_media_products_media" FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE);
+-- create index "productmedia_tenant_id" to table: "product_media"
+CREATE INDEX "productmedia_tenant_id" ON "product_media" ("tenant_id");
+-- create index "productmedia_product_id_file_id" to table: "product_media"
+CREATE UNIQUE INDEX "productmedia_product_id_file_id" ON "product_media" ("product_id", "file_id");
+-- create index "productmedia_file_id" to table: "product_media"
+CREATE INDEX "productmedia_file_id" ON "product_media" ("file_id");
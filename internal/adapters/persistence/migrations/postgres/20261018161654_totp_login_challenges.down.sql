@@ -0,0 +1,2 @@
+-- reverse: modify "users" table
+ALTER TABLE "users" DROP COLUMN "totp_locked_until", DROP COLUMN "totp_failed_attempts", DROP COLUMN "totp_challenge_id";
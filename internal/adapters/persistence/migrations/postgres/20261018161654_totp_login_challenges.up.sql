@@ -0,0 +1,2 @@
+-- modify "users" table
+ALTER TABLE "users" ADD COLUMN "totp_challenge_id" character varying NULL, ADD COLUMN "totp_failed_attempts" bigint NOT NULL DEFAULT 0, ADD COLUMN "totp_locked_until" timestamptz NULL;
@@ -0,0 +1,58 @@
+-- create "blobs" table
+CREATE TABLE `blobs` (`id` integer NOT NULL PRIMARY KEY AUTOINCREMENT, `bucket` text NOT NULL, `key` text NOT NULL, `content` blob NOT NULL, `content_type` text NOT NULL, `size` integer NOT NULL, `sha256` text NOT NULL, `created_at` datetime NOT NULL, `updated_at` datetime NOT NULL);
+-- create index "blob_bucket_key" to table: "blobs"
+CREATE UNIQUE INDEX `blob_bucket_key` ON `blobs` (`bucket`, `key`);
+-- create "brands" table
+CREATE TABLE `brands` (`id` uuid NOT NULL, `tenant_id` text NOT NULL DEFAULT ('default'), `name` text NOT NULL, `created_at` datetime NOT NULL, `updated_at` datetime NOT NULL, PRIMARY KEY (`id`));
+-- create index "brand_tenant_id" to table: "brands"
+CREATE INDEX `brand_tenant_id` ON `brands` (`tenant_id`);
+-- create index "brand_tenant_id_name" to table: "brands"
+CREATE UNIQUE INDEX `brand_tenant_id_name` ON `brands` (`tenant_id`, `name`);
+-- create "categories" table
+CREATE TABLE `categories` (`id` uuid NOT NULL, `tenant_id` text NOT NULL DEFAULT ('default'), `name` text NOT NULL, `created_at` datetime NOT NULL, `updated_at` datetime NOT NULL, `parent_id` uuid NULL, PRIMARY KEY (`id`), CONSTRAINT `categories_categories_children` FOREIGN KEY (`parent_id`) REFERENCES `categories` (`id`) ON DELETE SET NULL);
+-- create index "category_tenant_id" to table: "categories"
+CREATE INDEX `category_tenant_id` ON `categories` (`tenant_id`);
+-- create index "category_tenant_id_name" to table: "categories"
+CREATE UNIQUE INDEX `category_tenant_id_name` ON `categories` (`tenant_id`, `name`);
+-- create "content_objects" table
+CREATE TABLE `content_objects` (`id` integer NOT NULL PRIMARY KEY AUTOINCREMENT, `tenant_id` text NOT NULL DEFAULT ('default'), `bucket` text NOT NULL, `key` text NOT NULL, `refs` integer NOT NULL, `created_at` datetime NOT NULL, `updated_at` datetime NOT NULL);
+-- create index "contentobject_tenant_id" to table: "content_objects"
+CREATE INDEX `contentobject_tenant_id` ON `content_objects` (`tenant_id`);
+-- create index "contentobject_tenant_id_bucket_key" to table: "content_objects"
+CREATE UNIQUE INDEX `contentobject_tenant_id_bucket_key` ON `content_objects` (`tenant_id`, `bucket`, `key`);
+-- create "files" table
+CREATE TABLE `files` (`id` uuid NOT NULL, `tenant_id` text NOT NULL DEFAULT ('default'), `bucket` text NOT NULL, `key` text NOT NULL, `file_name` text NOT NULL, `size` integer NOT NULL, `content_type` text NOT NULL, `sha256` text NULL, `width` integer NULL, `height` integer NULL, `blurhash` text NULL, `dominant_color` text NULL, `derivatives` json NULL, `created_at` datetime NOT NULL, `updated_at` datetime NOT NULL, `uploader_id` integer NULL, PRIMARY KEY (`id`), CONSTRAINT `files_users_files` FOREIGN KEY (`uploader_id`) REFERENCES `users` (`id`) ON DELETE SET NULL);
+-- create index "file_tenant_id" to table: "files"
+CREATE INDEX `file_tenant_id` ON `files` (`tenant_id`);
+-- create index "file_tenant_id_bucket_file_name" to table: "files"
+CREATE UNIQUE INDEX `file_tenant_id_bucket_file_name` ON `files` (`tenant_id`, `bucket`, `file_name`);
+-- create index "file_tenant_id_bucket_key" to table: "files"
+CREATE INDEX `file_tenant_id_bucket_key` ON `files` (`tenant_id`, `bucket`, `key`);
+-- create index "file_tenant_id_created_at" to table: "files"
+CREATE INDEX `file_tenant_id_created_at` ON `files` (`tenant_id`, `created_at`);
+-- create "products" table
+CREATE TABLE `products` (`id` integer NOT NULL PRIMARY KEY AUTOINCREMENT, `tenant_id` text NOT NULL DEFAULT ('default'), `sku` text NOT NULL, `slug` text NOT NULL, `name` text NOT NULL, `price` real NOT NULL, `description` text NULL, `weight` integer NOT NULL DEFAULT (0), `length` integer NOT NULL DEFAULT (0), `width` integer NOT NULL DEFAULT (0), `height` integer NOT NULL DEFAULT (0), `image_urls` json NULL, `status` text NOT NULL DEFAULT ('draft'), `created_at` datetime NOT NULL, `updated_at` datetime NOT NULL, `brand_id` uuid NULL, `category_id` uuid NULL, CONSTRAINT `products_brands_products` FOREIGN KEY (`brand_id`) REFERENCES `brands` (`id`) ON DELETE SET NULL, CONSTRAINT `products_categories_products` FOREIGN KEY (`category_id`) REFERENCES `categories` (`id`) ON DELETE SET NULL);
+-- create index "product_tenant_id" to table: "products"
+CREATE INDEX `product_tenant_id` ON `products` (`tenant_id`);
+-- create index "product_tenant_id_sku" to table: "products"
+CREATE UNIQUE INDEX `product_tenant_id_sku` ON `products` (`tenant_id`, `sku`);
+-- create index "product_tenant_id_slug" to table: "products"
+CREATE UNIQUE INDEX `product_tenant_id_slug` ON `products` (`tenant_id`, `slug`);
+-- create "product_media" table
+CREATE TABLE `product_media` (`id` uuid NOT NULL, `tenant_id` text NOT NULL DEFAULT ('default'), `position` integer NOT NULL DEFAULT (0), `alt_text` text NULL, `is_primary` bool NOT NULL DEFAULT (false), `media_type` text NOT NULL, `created_at` datetime NOT NULL, `updated_at` datetime NOT NULL, `file_id` uuid NOT NULL, `product_id` integer NOT NULL, PRIMARY KEY (`id`), CONSTRAINT `product_media_files_product_media` FOREIGN KEY (`file_id`) REFERENCES `files` (`id`) ON DELETE RESTRICT, CONSTRAINT `product_media_products_media` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE);
+-- create index "productmedia_tenant_id" to table: "product_media"
+CREATE INDEX `productmedia_tenant_id` ON `product_media` (`tenant_id`);
+-- create index "productmedia_product_id_file_id" to table: "product_media"
+CREATE UNIQUE INDEX `productmedia_product_id_file_id` ON `product_media` (`product_id`, `file_id`);
+-- create index "productmedia_file_id" to table: "product_media"
+CREATE INDEX `productmedia_file_id` ON `product_media` (`file_id`);
+-- create "resumable_uploads" table
+CREATE TABLE `resumable_uploads` (`id` uuid NOT NULL, `tenant_id` text NOT NULL DEFAULT ('default'), `bucket` text NOT NULL, `file_name` text NOT NULL, `content_type` text NULL, `sha256` text NULL, `upload_length` integer NOT NULL, `upload_offset` integer NOT NULL DEFAULT (0), `metadata` json NULL, `parts` json NULL, `expires_at` datetime NOT NULL, `created_at` datetime NOT NULL, `updated_at` datetime NOT NULL, PRIMARY KEY (`id`));
+-- create index "resumableupload_tenant_id" to table: "resumable_uploads"
+CREATE INDEX `resumableupload_tenant_id` ON `resumable_uploads` (`tenant_id`);
+-- create index "resumableupload_tenant_id_expires_at" to table: "resumable_uploads"
+CREATE INDEX `resumableupload_tenant_id_expires_at` ON `resumable_uploads` (`tenant_id`, `expires_at`);
+-- create "users" table
+CREATE TABLE `users` (`id` integer NOT NULL PRIMARY KEY AUTOINCREMENT, `age` integer NOT NULL, `name` text NOT NULL DEFAULT ('unknown'), `email` text NULL, `password_hash` text NULL, `role` text NOT NULL DEFAULT ('viewer'), `tenant_id` text NOT NULL DEFAULT ('default'), `totp_secret` text NULL, `totp_enabled` bool NOT NULL DEFAULT (false), `totp_last_step` integer NOT NULL DEFAULT (0), `recovery_codes` json NULL, `created_at` datetime NOT NULL, `updated_at` datetime NOT NULL);
+-- create index "users_email_key" to table: "users"
+CREATE UNIQUE INDEX `users_email_key` ON `users` (`email`);
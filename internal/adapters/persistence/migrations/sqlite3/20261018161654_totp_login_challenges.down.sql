@@ -0,0 +1,6 @@
+-- reverse: add column "totp_locked_until" to table: "users"
+ALTER TABLE `users` DROP COLUMN `totp_locked_until`;
+-- reverse: add column "totp_failed_attempts" to table: "users"
+ALTER TABLE `users` DROP COLUMN `totp_failed_attempts`;
+-- reverse: add column "totp_challenge_id" to table: "users"
+ALTER TABLE `users` DROP COLUMN `totp_challenge_id`;
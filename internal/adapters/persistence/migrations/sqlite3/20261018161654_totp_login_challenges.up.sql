@@ -0,0 +1,6 @@
+-- add column "totp_challenge_id" to table: "users"
+ALTER TABLE `users` ADD COLUMN `totp_challenge_id` text NULL;
+-- add column "totp_failed_attempts" to table: "users"
+ALTER TABLE `users` ADD COLUMN `totp_failed_attempts` integer NOT NULL DEFAULT (0);
+-- add column "totp_locked_until" to table: "users"
+ALTER TABLE `users` ADD COLUMN `totp_locked_until` datetime NULL;
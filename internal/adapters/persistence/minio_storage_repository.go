@@ -2,28 +2,40 @@ package persistence
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"net/http"
+	"strconv"
 	"time"
 
 	"example.com/go-yippi/internal/domain/entities"
-	"github.com/google/uuid"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
 	"github.com/minio/minio-go/v7"
 )
 
 // MinIOStorageRepository implements StorageRepository using MinIO
 type MinIOStorageRepository struct {
-	client   *minio.Client
-	endpoint string
-	useSSL   bool
+	client *minio.Client
+	// presignClient signs direct upload and download URLs; it is configured with the endpoint
+	// clients reach storage on, which the signature covers
+	presignClient *minio.Client
+	endpoint      string
+	useSSL        bool
 }
 
-// NewMinIOStorageRepository creates a new MinIO storage repository
-func NewMinIOStorageRepository(client *minio.Client, endpoint string, useSSL bool) *MinIOStorageRepository {
+// NewMinIOStorageRepository creates a new MinIO storage repository; presignClient may be nil
+// if clients reach storage on the same endpoint as the API
+func NewMinIOStorageRepository(client, presignClient *minio.Client, endpoint string, useSSL bool) *MinIOStorageRepository {
+	if presignClient == nil {
+		presignClient = client
+	}
 	return &MinIOStorageRepository{
-		client:   client,
-		endpoint: endpoint,
-		useSSL:   useSSL,
+		client:        client,
+		presignClient: presignClient,
+		endpoint:      endpoint,
+		useSSL:        useSSL,
 	}
 }
 
@@ -47,9 +59,9 @@ func (r *MinIOStorageRepository) Store(ctx context.Context, bucket, fileName str
 
 	// Create metadata
 	metadata := &entities.FileMetadata{
-		ID:          uuid.New().String(),
 		FileName:    fileName,
 		Bucket:      bucket,
+		Key:         fileName,
 		Size:        info.Size,
 		ContentType: contentType,
 		URL:         url,
@@ -69,30 +81,141 @@ func (r *MinIOStorageRepository) Remove(ctx context.Context, bucket, fileName st
 	return nil
 }
 
+// RemovePrefix deletes every file in MinIO whose name starts with prefix
+func (r *MinIOStorageRepository) RemovePrefix(ctx context.Context, bucket, prefix string) error {
+	// Stop the listing if the loop ends early
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for object := range r.client.ListObjects(ctx, bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if object.Err != nil {
+			return fmt.Errorf("failed to list files in MinIO: %w", object.Err)
+		}
+		if err := r.Remove(ctx, bucket, object.Key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Copy copies a file within a bucket on the server; files above 5 GiB cannot be copied in one request
+func (r *MinIOStorageRepository) Copy(ctx context.Context, bucket, src, dst string) error {
+	_, err := r.client.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: bucket, Object: dst},
+		minio.CopySrcOptions{Bucket: bucket, Object: src},
+	)
+	if err != nil {
+		if isNoSuchKey(err) {
+			return domainErrors.NewNotFoundError("File", src)
+		}
+		return fmt.Errorf("failed to copy file in MinIO: %w", err)
+	}
+	return nil
+}
+
+// minComposePartSize is the smallest part S3 composes other than the last
+const minComposePartSize = 5 << 20
+
+// maxComposeParts is the most parts S3 composes into one object
+const maxComposeParts = 10000
+
+// Compose concatenates the parts on the server with a multipart copy. S3 only composes parts of at least
+// 5 MiB but the last; parts cut short, e.g. by an interrupted chunk, are streamed through the service instead.
+func (r *MinIOStorageRepository) Compose(ctx context.Context, bucket, fileName string, parts []entities.UploadPart, contentType string) (*entities.FileMetadata, error) {
+	composable := len(parts) > 0 && len(parts) <= maxComposeParts
+	for _, part := range parts[:max(len(parts)-1, 0)] {
+		composable = composable && part.Size >= minComposePartSize
+	}
+	if !composable {
+		return composeByCopy(ctx, r, bucket, fileName, parts, contentType)
+	}
+
+	sources := make([]minio.CopySrcOptions, len(parts))
+	for i, part := range parts {
+		sources[i] = minio.CopySrcOptions{Bucket: bucket, Object: part.Name}
+	}
+	// The content type is replaced as metadata, so that it is set whether the parts are copied as one object
+	// or in a multipart upload
+	info, err := r.client.ComposeObject(ctx, minio.CopyDestOptions{
+		Bucket:          bucket,
+		Object:          fileName,
+		ReplaceMetadata: true,
+		UserMetadata:    map[string]string{"Content-Type": contentType},
+	}, sources...)
+	if err != nil {
+		if isNoSuchKey(err) {
+			return nil, domainErrors.NewNotFoundError("File", fileName)
+		}
+		return nil, fmt.Errorf("failed to compose file in MinIO: %w", err)
+	}
+
+	url, err := r.GetURL(ctx, bucket, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.FileMetadata{
+		FileName:    fileName,
+		Bucket:      bucket,
+		Key:         fileName,
+		Size:        info.Size,
+		ContentType: contentType,
+		URL:         url,
+		UploadedAt:  time.Now(),
+	}, nil
+}
+
+// List lists the objects whose key starts with prefix
+func (r *MinIOStorageRepository) List(ctx context.Context, bucket, prefix string, fn func(*entities.FileMetadata) error) error {
+	// Stop the listing if the loop ends early
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for object := range r.client.ListObjects(ctx, bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if object.Err != nil {
+			return fmt.Errorf("failed to list files in MinIO: %w", object.Err)
+		}
+		err := fn(&entities.FileMetadata{
+			FileName:   object.Key,
+			Bucket:     bucket,
+			Key:        object.Key,
+			Size:       object.Size,
+			UploadedAt: object.LastModified,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // GetURL generates a relative URL for the file that will be proxied through the API
 func (r *MinIOStorageRepository) GetURL(ctx context.Context, bucket, fileName string) (string, error) {
-	// Return relative URL that points to our download endpoint
-	// This ensures all requests go through our service instead of directly to MinIO
-	url := fmt.Sprintf("/files/download?bucket=%s&file_name=%s", bucket, fileName)
-	return url, nil
+	return downloadURL(bucket, fileName), nil
 }
 
-// GetFile retrieves a file from MinIO and returns its content, size, and content type
-func (r *MinIOStorageRepository) GetFile(ctx context.Context, bucket, fileName string) (io.ReadCloser, int64, string, error) {
-	// Get file object from MinIO
-	object, err := r.client.GetObject(ctx, bucket, fileName, minio.GetObjectOptions{})
-	if err != nil {
-		return nil, 0, "", fmt.Errorf("failed to get file from MinIO: %w", err)
+// GetFile retrieves a file, or a range of it, from MinIO and returns its content, size, and content type
+func (r *MinIOStorageRepository) GetFile(ctx context.Context, bucket, fileName string, rng *entities.ByteRange) (io.ReadCloser, int64, string, error) {
+	opts := minio.GetObjectOptions{}
+	if rng != nil {
+		if err := opts.SetRange(rng.Start, rng.End); err != nil {
+			return nil, 0, "", err
+		}
 	}
 
-	// Get file stats to retrieve size and content type
-	stat, err := object.Stat()
+	// The core API sends a single GET; minio.Object drops the range once it has been stat'ed
+	object, info, _, err := minio.Core{Client: r.client}.GetObject(ctx, bucket, fileName, opts)
 	if err != nil {
-		object.Close()
-		return nil, 0, "", fmt.Errorf("failed to get file stats from MinIO: %w", err)
+		if isNoSuchKey(err) {
+			return nil, 0, "", domainErrors.NewNotFoundError("File", fileName)
+		}
+		return nil, 0, "", fmt.Errorf("failed to get file from MinIO: %w", err)
 	}
 
-	return object, stat.Size, stat.ContentType, nil
+	// The size is that of the returned content, i.e. of the range
+	return object, info.Size, info.ContentType, nil
 }
 
 // EnsureBucket creates a bucket if it doesn't exist
@@ -111,3 +234,87 @@ func (r *MinIOStorageRepository) EnsureBucket(ctx context.Context, bucket string
 
 	return nil
 }
+
+// Stat returns the metadata of a file in MinIO
+func (r *MinIOStorageRepository) Stat(ctx context.Context, bucket, fileName string) (*entities.FileMetadata, error) {
+	info, err := r.client.StatObject(ctx, bucket, fileName, minio.StatObjectOptions{Checksum: true})
+	if err != nil {
+		if isNoSuchKey(err) {
+			return nil, domainErrors.NewNotFoundError("File", fileName)
+		}
+		return nil, fmt.Errorf("failed to get file stats from MinIO: %w", err)
+	}
+
+	url, err := r.GetURL(ctx, bucket, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	// The checksum is only known if the upload declared it
+	var checksum string
+	if sum, err := base64.StdEncoding.DecodeString(info.ChecksumSHA256); err == nil && len(sum) > 0 {
+		checksum = hex.EncodeToString(sum)
+	}
+
+	return &entities.FileMetadata{
+		FileName:    fileName,
+		Bucket:      bucket,
+		Key:         fileName,
+		Size:        info.Size,
+		ContentType: info.ContentType,
+		SHA256:      checksum,
+		URL:         url,
+		UploadedAt:  info.LastModified,
+	}, nil
+}
+
+// PresignUpload generates a presigned PUT URL with the content type, length and checksum as signed headers
+func (r *MinIOStorageRepository) PresignUpload(ctx context.Context, bucket, fileName, contentType string, size int64, sha256 string, expiry time.Duration) (*entities.PresignedRequest, error) {
+	headers := http.Header{}
+	headers.Set("Content-Type", contentType)
+	headers.Set("Content-Length", strconv.FormatInt(size, 10))
+	if sha256 != "" {
+		sum, err := hex.DecodeString(sha256)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SHA-256 %q: %w", sha256, err)
+		}
+		// Storage verifies the checksum of the content
+		headers.Set("X-Amz-Checksum-Sha256", base64.StdEncoding.EncodeToString(sum))
+	}
+
+	u, err := r.presignClient.PresignHeader(ctx, http.MethodPut, bucket, fileName, expiry, nil, headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign upload to MinIO: %w", err)
+	}
+
+	signed := make(map[string]string, len(headers))
+	for name := range headers {
+		signed[name] = headers.Get(name)
+	}
+
+	return &entities.PresignedRequest{
+		Method:    http.MethodPut,
+		URL:       u.String(),
+		Headers:   signed,
+		ExpiresAt: time.Now().Add(expiry),
+	}, nil
+}
+
+// PresignDownload generates a presigned GET URL
+func (r *MinIOStorageRepository) PresignDownload(ctx context.Context, bucket, fileName string, expiry time.Duration) (*entities.PresignedRequest, error) {
+	u, err := r.presignClient.PresignedGetObject(ctx, bucket, fileName, expiry, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign download from MinIO: %w", err)
+	}
+
+	return &entities.PresignedRequest{
+		Method:    http.MethodGet,
+		URL:       u.String(),
+		ExpiresAt: time.Now().Add(expiry),
+	}, nil
+}
+
+// isNoSuchKey reports whether MinIO reported a missing object
+func isNoSuchKey(err error) bool {
+	return minio.ToErrorResponse(err).Code == "NoSuchKey"
+}
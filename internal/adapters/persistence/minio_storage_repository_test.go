@@ -0,0 +1,276 @@
+package persistence
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/go-yippi/internal/adapters/persistence/s3test"
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStorage(t *testing.T) (*MinIOStorageRepository, *s3test.Server) {
+	t.Helper()
+
+	server := s3test.NewServer(t)
+	repo := NewMinIOStorageRepository(server.Client(t), nil, server.Endpoint, false)
+	require.NoError(t, repo.EnsureBucket(context.Background(), "uploads"))
+	return repo, server
+}
+
+// sendPresigned sends a presigned request with the signed headers, overridden by headers
+func sendPresigned(t *testing.T, req *entities.PresignedRequest, body string, headers map[string]string) *http.Response {
+	t.Helper()
+
+	httpReq, err := http.NewRequest(req.Method, req.URL, strings.NewReader(body))
+	require.NoError(t, err)
+	for name, value := range req.Headers {
+		httpReq.Header.Set(name, value)
+	}
+	for name, value := range headers {
+		httpReq.Header.Set(name, value)
+	}
+	if req.Method == http.MethodGet {
+		httpReq.Body = nil
+	}
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// TestMinIOStorage_StoreAndStat tests the round trip of a file through the S3 API
+func TestMinIOStorage_StoreAndStat(t *testing.T) {
+	// Arrange
+	repo, _ := newTestStorage(t)
+	ctx := context.Background()
+
+	// Act
+	stored, err := repo.Store(ctx, "uploads", "docs/a.txt", strings.NewReader("hello"), 5, "text/plain")
+	require.NoError(t, err)
+	stat, statErr := repo.Stat(ctx, "uploads", "docs/a.txt")
+	reader, size, contentType, getErr := repo.GetFile(ctx, "uploads", "docs/a.txt", nil)
+
+	// Assert
+	assert.Equal(t, int64(5), stored.Size)
+	require.NoError(t, statErr)
+	assert.Equal(t, int64(5), stat.Size)
+	assert.Equal(t, "text/plain", stat.ContentType)
+	require.NoError(t, getErr)
+	defer reader.Close()
+	content, _ := io.ReadAll(reader)
+	assert.Equal(t, "hello", string(content))
+	assert.Equal(t, int64(5), size)
+	assert.Equal(t, "text/plain", contentType)
+}
+
+// TestMinIOStorage_GetFileRange tests that only the requested range of a file is returned
+func TestMinIOStorage_GetFileRange(t *testing.T) {
+	// Arrange
+	repo, _ := newTestStorage(t)
+	ctx := context.Background()
+	_, err := repo.Store(ctx, "uploads", "docs/a.txt", strings.NewReader("hello world"), 11, "text/plain")
+	require.NoError(t, err)
+
+	// Act
+	reader, size, contentType, getErr := repo.GetFile(ctx, "uploads", "docs/a.txt", &entities.ByteRange{Start: 6, End: 10})
+
+	// Assert
+	require.NoError(t, getErr)
+	defer reader.Close()
+	content, _ := io.ReadAll(reader)
+	assert.Equal(t, "world", string(content))
+	assert.Equal(t, int64(5), size)
+	assert.Equal(t, "text/plain", contentType)
+}
+
+// TestMinIOStorage_StatNotFound tests that missing objects are reported as not found
+func TestMinIOStorage_StatNotFound(t *testing.T) {
+	// Arrange
+	repo, _ := newTestStorage(t)
+
+	// Act
+	_, err := repo.Stat(context.Background(), "uploads", "missing.txt")
+	_, _, _, getErr := repo.GetFile(context.Background(), "uploads", "missing.txt", nil)
+
+	// Assert
+	assert.True(t, errors.Is(err, domainErrors.ErrNotFound))
+	assert.True(t, errors.Is(getErr, domainErrors.ErrNotFound))
+}
+
+// TestMinIOStorage_RemovePrefix tests that only the files under the prefix are removed
+func TestMinIOStorage_RemovePrefix(t *testing.T) {
+	// Arrange
+	repo, server := newTestStorage(t)
+	ctx := context.Background()
+	server.PutObject("uploads", "variants/acme/1/a.webp", "image/webp", []byte("a"))
+	server.PutObject("uploads", "variants/acme/1/b.jpeg", "image/jpeg", []byte("b"))
+	server.PutObject("uploads", "variants/acme/10/a.webp", "image/webp", []byte("c"))
+
+	// Act
+	err := repo.RemovePrefix(ctx, "uploads", "variants/acme/1/")
+	emptyErr := repo.RemovePrefix(ctx, "uploads", "variants/other/")
+
+	// Assert
+	require.NoError(t, err)
+	require.NoError(t, emptyErr)
+	_, ok := server.Object("uploads", "variants/acme/1/a.webp")
+	assert.False(t, ok)
+	_, ok = server.Object("uploads", "variants/acme/1/b.jpeg")
+	assert.False(t, ok)
+	_, ok = server.Object("uploads", "variants/acme/10/a.webp")
+	assert.True(t, ok)
+}
+
+// TestMinIOStorage_PresignUpload tests that presigned uploads are only accepted with the signed constraints
+func TestMinIOStorage_PresignUpload(t *testing.T) {
+	body := "hello world"
+	sum := sha256.Sum256([]byte(body))
+	checksum := hex.EncodeToString(sum[:])
+
+	cases := []struct {
+		name    string
+		body    string
+		headers map[string]string
+		status  int
+	}{
+		{name: "matching", body: body, status: http.StatusOK},
+		{name: "other content type", body: body, headers: map[string]string{"Content-Type": "text/html"}, status: http.StatusForbidden},
+		{name: "other size", body: body + "!", status: http.StatusForbidden},
+		{name: "other content", body: "HELLO WORLD", status: http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		// Arrange
+		repo, server := newTestStorage(t)
+		presigned, err := repo.PresignUpload(context.Background(), "uploads", "a.txt", "text/plain", int64(len(body)), checksum, time.Minute)
+		require.NoError(t, err, tc.name)
+
+		// Act
+		resp := sendPresigned(t, presigned, tc.body, tc.headers)
+
+		// Assert
+		assert.Equal(t, tc.status, resp.StatusCode, tc.name)
+		_, stored := server.Object("uploads", "a.txt")
+		assert.Equal(t, tc.status == http.StatusOK, stored, tc.name)
+	}
+}
+
+// TestMinIOStorage_PresignUploadExpires tests that presigned uploads are rejected after they expire
+func TestMinIOStorage_PresignUploadExpires(t *testing.T) {
+	// Arrange
+	repo, server := newTestStorage(t)
+	presigned, err := repo.PresignUpload(context.Background(), "uploads", "a.txt", "text/plain", 5, "", time.Minute)
+	require.NoError(t, err)
+	server.Now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+
+	// Act
+	resp := sendPresigned(t, presigned, "hello", nil)
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+// TestMinIOStorage_PresignedChecksumIsReported tests that Stat reports the checksum declared by a presigned upload
+func TestMinIOStorage_PresignedChecksumIsReported(t *testing.T) {
+	// Arrange
+	repo, _ := newTestStorage(t)
+	ctx := context.Background()
+	sum := sha256.Sum256([]byte("hello"))
+	checksum := hex.EncodeToString(sum[:])
+	presigned, err := repo.PresignUpload(ctx, "uploads", "a.txt", "text/plain", 5, checksum, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, sendPresigned(t, presigned, "hello", nil).StatusCode)
+
+	// Act
+	stat, err := repo.Stat(ctx, "uploads", "a.txt")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, checksum, stat.SHA256)
+}
+
+// TestMinIOStorage_PresignDownload tests that presigned downloads return the file
+func TestMinIOStorage_PresignDownload(t *testing.T) {
+	// Arrange
+	repo, server := newTestStorage(t)
+	server.PutObject("uploads", "a.txt", "text/plain", []byte("hello"))
+
+	// Act
+	presigned, err := repo.PresignDownload(context.Background(), "uploads", "a.txt", time.Minute)
+	require.NoError(t, err)
+	resp := sendPresigned(t, presigned, "", nil)
+
+	// Assert
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	content, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "hello", string(content))
+	assert.Equal(t, http.MethodGet, presigned.Method)
+}
+
+// TestMinIOStorage_ComposeOnServer tests that parts of at least 5 MiB are composed with a multipart copy,
+// without their content passing through the service
+func TestMinIOStorage_ComposeOnServer(t *testing.T) {
+	// Arrange
+	repo, server := newTestStorage(t)
+	ctx := context.Background()
+	first, second := strings.Repeat("a", minComposePartSize), strings.Repeat("b", minComposePartSize)
+	server.PutObject("uploads", "resumable/u/0", "application/octet-stream", []byte(first))
+	server.PutObject("uploads", "resumable/u/1", "application/octet-stream", []byte(second))
+	server.PutObject("uploads", "resumable/u/2", "application/octet-stream", []byte("end"))
+	parts := []entities.UploadPart{
+		{Name: "resumable/u/0", Size: minComposePartSize},
+		{Name: "resumable/u/1", Offset: minComposePartSize, Size: minComposePartSize},
+		{Name: "resumable/u/2", Offset: 2 * minComposePartSize, Size: 3},
+	}
+
+	// Act
+	composed, err := repo.Compose(ctx, "uploads", "docs/big.bin", parts, "application/pdf")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int64(2*minComposePartSize+3), composed.Size)
+	obj, ok := server.Object("uploads", "docs/big.bin")
+	require.True(t, ok)
+	assert.Equal(t, first+second+"end", string(obj.Data))
+	assert.Equal(t, "application/pdf", obj.ContentType)
+	assert.Contains(t, server.Requests(), "POST /uploads/docs/big.bin?uploads=")
+	for _, request := range server.Requests() {
+		assert.False(t, strings.HasPrefix(request, "GET /uploads/resumable/"), request)
+	}
+}
+
+// TestMinIOStorage_ComposeShortParts tests that parts S3 cannot compose, as one but the last is below
+// 5 MiB, are streamed into the file
+func TestMinIOStorage_ComposeShortParts(t *testing.T) {
+	// Arrange
+	repo, server := newTestStorage(t)
+	ctx := context.Background()
+	server.PutObject("uploads", "resumable/u/0", "application/octet-stream", []byte("interrupted "))
+	server.PutObject("uploads", "resumable/u/12", "application/octet-stream", []byte("chunk"))
+	parts := []entities.UploadPart{
+		{Name: "resumable/u/0", Size: 12},
+		{Name: "resumable/u/12", Offset: 12, Size: 5},
+	}
+
+	// Act
+	composed, err := repo.Compose(ctx, "uploads", "docs/short.txt", parts, "text/plain")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, int64(17), composed.Size)
+	obj, ok := server.Object("uploads", "docs/short.txt")
+	require.True(t, ok)
+	assert.Equal(t, "interrupted chunk", string(obj.Data))
+	assert.Equal(t, "text/plain", obj.ContentType)
+}
@@ -0,0 +1,114 @@
+package persistence
+
+import (
+	"context"
+	stdsql "database/sql"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"entgo.io/ent/dialect"
+	entsql "entgo.io/ent/dialect/sql"
+	"example.com/go-yippi/internal/adapters/persistence/db/ent"
+)
+
+// QueryObserver is told about every statement run through a client opened with OpenObserved
+type QueryObserver interface {
+	// StartQuery is called as a statement of a repository method, e.g. ProductRepository.Create, starts;
+	// statements run outside the repositories, such as migrations, are run for "other". The statement runs
+	// with the returned context, and done is called with its error once it has run. Query holds
+	// placeholders, not the values of its arguments.
+	StartQuery(ctx context.Context, method, query string) (context.Context, func(err error))
+}
+
+// methodPrefix starts the function names of methods on pointers of this package
+var methodPrefix = reflect.TypeOf(observedDriver{}).PkgPath() + ".(*"
+
+// OpenObserved opens an Ent client like Open whose statements are reported to the observers, in order. It
+// also returns the connection pool of the client, e.g. to report its stats.
+func OpenObserved(driver, dsn string, observers ...QueryObserver) (*ent.Client, *stdsql.DB, error) {
+	drv, err := entsql.Open(driver, dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var clientDriver dialect.Driver = drv
+	if len(observers) > 0 {
+		clientDriver = &observedDriver{Driver: drv, observers: observers}
+	}
+	client := ent.NewClient(ent.Driver(clientDriver))
+	ScopeToTenant(client)
+	return client, drv.DB(), nil
+}
+
+// observedDriver reports the statements of a driver
+type observedDriver struct {
+	dialect.Driver
+	observers []QueryObserver
+}
+
+func (d *observedDriver) Exec(ctx context.Context, query string, args, v any) error {
+	return observe(ctx, d.observers, query, func(ctx context.Context) error { return d.Driver.Exec(ctx, query, args, v) })
+}
+
+// Query is observed until the rows are returned, not while they are read
+func (d *observedDriver) Query(ctx context.Context, query string, args, v any) error {
+	return observe(ctx, d.observers, query, func(ctx context.Context) error { return d.Driver.Query(ctx, query, args, v) })
+}
+
+func (d *observedDriver) Tx(ctx context.Context) (dialect.Tx, error) {
+	tx, err := d.Driver.Tx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &observedTx{Tx: tx, observers: d.observers}, nil
+}
+
+// observedTx reports the statements of a transaction
+type observedTx struct {
+	dialect.Tx
+	observers []QueryObserver
+}
+
+func (t *observedTx) Exec(ctx context.Context, query string, args, v any) error {
+	return observe(ctx, t.observers, query, func(ctx context.Context) error { return t.Tx.Exec(ctx, query, args, v) })
+}
+
+func (t *observedTx) Query(ctx context.Context, query string, args, v any) error {
+	return observe(ctx, t.observers, query, func(ctx context.Context) error { return t.Tx.Query(ctx, query, args, v) })
+}
+
+// observe runs a statement and reports it with the repository method that ran it
+func observe(ctx context.Context, observers []QueryObserver, query string, statement func(context.Context) error) error {
+	method := repositoryMethod()
+	dones := make([]func(error), len(observers))
+	for i, observer := range observers {
+		ctx, dones[i] = observer.StartQuery(ctx, method, query)
+	}
+
+	err := statement(ctx)
+	for i := len(dones) - 1; i >= 0; i-- {
+		dones[i](err)
+	}
+	return err
+}
+
+// repositoryMethod names the innermost repository method on the call stack, taking it from the name of its
+// function, e.g. example.com/.../persistence.(*ProductRepositoryImpl).Create.func1
+func repositoryMethod() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if name, ok := strings.CutPrefix(frame.Function, methodPrefix); ok {
+			typeName, method, _ := strings.Cut(name, ").")
+			if strings.HasSuffix(strings.TrimSuffix(typeName, "Impl"), "Repository") {
+				method, _, _ = strings.Cut(method, ".")
+				return strings.TrimSuffix(typeName, "Impl") + "." + method
+			}
+		}
+		if !more {
+			return "other"
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package persistence
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"example.com/go-yippi/internal/adapters/persistence/db/ent/migrate"
+	"example.com/go-yippi/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingObserver collects the methods and errors of the statements it is told about
+type recordingObserver struct {
+	mu      sync.Mutex
+	methods []string
+	errors  map[string]int
+}
+
+func (o *recordingObserver) StartQuery(ctx context.Context, method, query string) (context.Context, func(err error)) {
+	return ctx, func(err error) {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		o.methods = append(o.methods, method)
+		if err != nil {
+			o.errors[method]++
+		}
+	}
+}
+
+// TestOpenObserved tests that statements are reported with the repository method that ran them, including
+// statements run in transactions and outside the repositories
+func TestOpenObserved(t *testing.T) {
+	// Arrange
+	observer := &recordingObserver{errors: map[string]int{}}
+	client, db, err := OpenObserved("sqlite3", "file:"+t.Name()+"?mode=memory&cache=shared&_fk=1", observer)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	require.NoError(t, client.Schema.Create(context.Background(), migrate.WithDropIndex(true)))
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	brands := NewBrandRepository(client)
+	contents := NewContentObjectRepository(client)
+
+	// Act
+	require.NoError(t, brands.Create(ctx, &entities.Brand{Name: "Acme"}))
+	dupErr := brands.Create(ctx, &entities.Brand{Name: "Acme"})
+	require.NoError(t, contents.Acquire(ctx, "media", "content/acme/ab/abc"))
+	require.NoError(t, contents.Release(ctx, "media", "content/acme/ab/abc", func() error { return nil }))
+
+	// Assert
+	assert.Error(t, dupErr)
+	assert.NotNil(t, db)
+	assert.Contains(t, observer.methods, "other")
+	assert.Contains(t, observer.methods, "BrandRepository.Create")
+	assert.Contains(t, observer.methods, "ContentObjectRepository.Acquire")
+	assert.Contains(t, observer.methods, "ContentObjectRepository.Release")
+	assert.Equal(t, map[string]int{"BrandRepository.Create": 1}, observer.errors)
+}
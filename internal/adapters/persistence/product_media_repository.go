@@ -0,0 +1,184 @@
+package persistence
+
+import (
+	"context"
+
+	"example.com/go-yippi/internal/adapters/persistence/db/ent"
+	"example.com/go-yippi/internal/adapters/persistence/db/ent/productmedia"
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/google/uuid"
+)
+
+// ProductMediaRepositoryImpl implements the ProductMediaRepository interface using Ent
+type ProductMediaRepositoryImpl struct {
+	client *ent.Client
+	files  *FileRepositoryImpl
+}
+
+func NewProductMediaRepository(client *ent.Client) *ProductMediaRepositoryImpl {
+	return &ProductMediaRepositoryImpl{client: client, files: NewFileRepository(client)}
+}
+
+func (r *ProductMediaRepositoryImpl) Create(ctx context.Context, m *entities.ProductMedia) error {
+	created, err := r.client.ProductMedia.
+		Create().
+		SetProductID(m.ProductID).
+		SetFileID(m.FileID).
+		SetPosition(m.Position).
+		SetAltText(m.AltText).
+		SetIsPrimary(m.IsPrimary).
+		SetMediaType(productmedia.MediaType(m.Type)).
+		Save(ctx)
+	if err != nil {
+		return mapWriteError("ProductMedia", err, r.writeFields(m))
+	}
+
+	m.ID = created.ID
+	m.CreatedAt = created.CreatedAt
+	m.UpdatedAt = created.UpdatedAt
+	return nil
+}
+
+// CreateBulk inserts the media in batches of one transaction
+func (r *ProductMediaRepositoryImpl) CreateBulk(ctx context.Context, media []*entities.ProductMedia) error {
+	if len(media) == 0 {
+		return nil
+	}
+
+	tx, err := r.client.Tx(ctx)
+	if err != nil {
+		return err
+	}
+	for start := 0; start < len(media); start += bulkCreateBatchSize {
+		batch := media[start:min(start+bulkCreateBatchSize, len(media))]
+		builders := make([]*ent.ProductMediaCreate, len(batch))
+		for i, m := range batch {
+			builders[i] = tx.ProductMedia.Create().
+				SetProductID(m.ProductID).
+				SetFileID(m.FileID).
+				SetPosition(m.Position).
+				SetAltText(m.AltText).
+				SetIsPrimary(m.IsPrimary).
+				SetMediaType(productmedia.MediaType(m.Type))
+		}
+		created, err := tx.ProductMedia.CreateBulk(builders...).Save(ctx)
+		if err != nil {
+			tx.Rollback()
+			return mapWriteError("ProductMedia", err, map[string]any{})
+		}
+		for i, c := range created {
+			batch[i].ID = c.ID
+			batch[i].CreatedAt = c.CreatedAt
+			batch[i].UpdatedAt = c.UpdatedAt
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *ProductMediaRepositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*entities.ProductMedia, error) {
+	found, err := r.client.ProductMedia.
+		Query().
+		Where(productmedia.IDEQ(id)).
+		WithFile().
+		Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, domainErrors.NewNotFoundError("ProductMedia", id)
+		}
+		return nil, err
+	}
+
+	return r.toEntity(found), nil
+}
+
+func (r *ProductMediaRepositoryImpl) ListByProduct(ctx context.Context, productID int) ([]*entities.ProductMedia, error) {
+	return r.ListByProducts(ctx, []int{productID})
+}
+
+func (r *ProductMediaRepositoryImpl) ListByProducts(ctx context.Context, productIDs []int) ([]*entities.ProductMedia, error) {
+	list, err := r.client.ProductMedia.
+		Query().
+		Where(productmedia.ProductIDIn(productIDs...)).
+		WithFile().
+		Order(productmedia.ByProductID(), productmedia.ByPosition()).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	media := make([]*entities.ProductMedia, 0, len(list))
+	for _, m := range list {
+		media = append(media, r.toEntity(m))
+	}
+
+	return media, nil
+}
+
+func (r *ProductMediaRepositoryImpl) Update(ctx context.Context, m *entities.ProductMedia) error {
+	updated, err := r.client.ProductMedia.
+		UpdateOneID(m.ID).
+		SetPosition(m.Position).
+		SetAltText(m.AltText).
+		SetIsPrimary(m.IsPrimary).
+		SetMediaType(productmedia.MediaType(m.Type)).
+		Save(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return domainErrors.NewNotFoundError("ProductMedia", m.ID)
+		}
+		return mapWriteError("ProductMedia", err, r.writeFields(m))
+	}
+
+	m.UpdatedAt = updated.UpdatedAt
+	return nil
+}
+
+func (r *ProductMediaRepositoryImpl) Delete(ctx context.Context, id uuid.UUID) error {
+	err := r.client.ProductMedia.DeleteOneID(id).Exec(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return domainErrors.NewNotFoundError("ProductMedia", id)
+		}
+		return mapWriteError("ProductMedia", err, nil)
+	}
+	return nil
+}
+
+func (r *ProductMediaRepositoryImpl) CountByFile(ctx context.Context, fileID uuid.UUID) (int, error) {
+	return r.client.ProductMedia.
+		Query().
+		Where(productmedia.FileIDEQ(fileID)).
+		Count(ctx)
+}
+
+func (r *ProductMediaRepositoryImpl) DeleteByFile(ctx context.Context, fileID uuid.UUID) error {
+	_, err := r.client.ProductMedia.
+		Delete().
+		Where(productmedia.FileIDEQ(fileID)).
+		Exec(ctx)
+	return err
+}
+
+// writeFields returns the constrained fields of a product media for mapping write errors
+func (r *ProductMediaRepositoryImpl) writeFields(m *entities.ProductMedia) map[string]any {
+	return map[string]any{"product_id": m.ProductID, "file_id": m.FileID}
+}
+
+func (r *ProductMediaRepositoryImpl) toEntity(m *ent.ProductMedia) *entities.ProductMedia {
+	media := &entities.ProductMedia{
+		ID:        m.ID,
+		ProductID: m.ProductID,
+		FileID:    m.FileID,
+		Position:  m.Position,
+		AltText:   m.AltText,
+		IsPrimary: m.IsPrimary,
+		Type:      entities.MediaType(m.MediaType),
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+	}
+	if m.Edges.File != nil {
+		media.File = r.files.toEntity(m.Edges.File)
+	}
+	return media
+}
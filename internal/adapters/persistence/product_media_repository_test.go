@@ -0,0 +1,101 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProductMediaRepository_Gallery tests that galleries are listed in order with their files,
+// keep files they use from being deleted and are removed with their product
+func TestProductMediaRepository_Gallery(t *testing.T) {
+	// Arrange
+	client, db := newTestDatabase(t)
+	products := NewProductRepository(client, db)
+	files := NewFileRepository(client)
+	repo := NewProductMediaRepository(client)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	product := &entities.Product{SKU: "SKU-1", Slug: "shirt", Name: "Shirt", Price: 10, Status: entities.ProductStatusDraft}
+	require.NoError(t, products.Create(ctx, product))
+	createTestFiles(t, files, ctx,
+		&entities.FileMetadata{Key: "front.png", ContentType: "image/png"},
+		&entities.FileMetadata{Key: "back.png", ContentType: "image/png"},
+	)
+	front, err := files.GetByName(ctx, "uploads", "front.png")
+	require.NoError(t, err)
+	back, err := files.GetByName(ctx, "uploads", "back.png")
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Create(ctx, &entities.ProductMedia{ProductID: product.ID, FileID: back.ID, Position: 1, Type: entities.MediaTypeImage}))
+	require.NoError(t, repo.Create(ctx, &entities.ProductMedia{ProductID: product.ID, FileID: front.ID, Position: 0, Type: entities.MediaTypeImage, IsPrimary: true}))
+
+	// Act
+	gallery, listErr := repo.ListByProduct(ctx, product.ID)
+	count, countErr := repo.CountByFile(ctx, front.ID)
+	deleteFileErr := files.Delete(ctx, front.ID)
+	deleteProductErr := products.Delete(ctx, product.ID)
+	remaining, remainingErr := repo.CountByFile(ctx, front.ID)
+
+	// Assert
+	require.NoError(t, listErr)
+	require.Len(t, gallery, 2)
+	assert.Equal(t, front.ID, gallery[0].FileID)
+	assert.Equal(t, "front.png", gallery[0].File.FileName)
+	assert.True(t, gallery[0].IsPrimary)
+	assert.Equal(t, back.ID, gallery[1].FileID)
+	require.NoError(t, countErr)
+	assert.Equal(t, 1, count)
+	var validationErr *domainErrors.ValidationError
+	require.True(t, errors.As(deleteFileErr, &validationErr))
+	assert.Equal(t, "id.in_use", validationErr.Errors[0].Code)
+	require.NoError(t, deleteProductErr)
+	require.NoError(t, remainingErr)
+	assert.Zero(t, remaining)
+}
+
+// TestProductMediaRepository_CreateBulk tests that media are created at once, all or none
+func TestProductMediaRepository_CreateBulk(t *testing.T) {
+	// Arrange
+	client, db := newTestDatabase(t)
+	products := NewProductRepository(client, db)
+	files := NewFileRepository(client)
+	repo := NewProductMediaRepository(client)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	product := &entities.Product{SKU: "SKU-1", Slug: "shirt", Name: "Shirt", Price: 10, Status: entities.ProductStatusDraft}
+	require.NoError(t, products.Create(ctx, product))
+	createTestFiles(t, files, ctx,
+		&entities.FileMetadata{Key: "front.png", ContentType: "image/png"},
+		&entities.FileMetadata{Key: "back.png", ContentType: "image/png"},
+	)
+	front, err := files.GetByName(ctx, "uploads", "front.png")
+	require.NoError(t, err)
+	back, err := files.GetByName(ctx, "uploads", "back.png")
+	require.NoError(t, err)
+	media := []*entities.ProductMedia{
+		{ProductID: product.ID, FileID: front.ID, Position: 0, Type: entities.MediaTypeImage, IsPrimary: true},
+		{ProductID: product.ID, FileID: back.ID, Position: 1, Type: entities.MediaTypeImage},
+	}
+
+	// Act
+	createErr := repo.CreateBulk(ctx, media)
+	duplicateErr := repo.CreateBulk(ctx, []*entities.ProductMedia{
+		{ProductID: product.ID, FileID: front.ID, Position: 2, Type: entities.MediaTypeImage},
+	})
+	gallery, listErr := repo.ListByProduct(ctx, product.ID)
+
+	// Assert
+	require.NoError(t, createErr)
+	assert.NotZero(t, media[1].ID)
+	assert.True(t, errors.Is(duplicateErr, domainErrors.ErrDuplicateEntry))
+	require.NoError(t, listErr)
+	require.Len(t, gallery, 2)
+	assert.Equal(t, media[0].ID, gallery[0].ID)
+	assert.Equal(t, back.ID, gallery[1].FileID)
+}
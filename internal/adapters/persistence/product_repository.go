@@ -2,20 +2,40 @@ package persistence
 
 import (
 	"context"
+	stdsql "database/sql"
+	"encoding/json"
+	"errors"
+	"time"
 
 	"example.com/go-yippi/internal/adapters/persistence/db/ent"
 	"example.com/go-yippi/internal/adapters/persistence/db/ent/product"
 	"example.com/go-yippi/internal/domain/entities"
 	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// bulkCreateBatchSize is the number of products inserted per statement by CreateBulk when rows cannot be
+// copied, which keeps the statements below the bind variable limit of SQLite
+const bulkCreateBatchSize = 500
+
+// productCopyColumns are the columns of the products copied by CreateBulk on PostgreSQL
+var productCopyColumns = []string{
+	tenantIDColumn, product.FieldSku, product.FieldSlug, product.FieldName, product.FieldPrice, product.FieldDescription,
+	product.FieldWeight, product.FieldLength, product.FieldWidth, product.FieldHeight, product.FieldImageUrls,
+	product.FieldStatus, product.FieldCategoryID, product.FieldBrandID, product.FieldCreatedAt, product.FieldUpdatedAt,
+}
+
 // ProductRepositoryImpl implements the ProductRepository interface using Ent
 type ProductRepositoryImpl struct {
 	client *ent.Client
+	db     *stdsql.DB
 }
 
-func NewProductRepository(client *ent.Client) *ProductRepositoryImpl {
-	return &ProductRepositoryImpl{client: client}
+// NewProductRepository returns a product repository on client; db is the connection pool of client, through
+// which bulk creates are copied on PostgreSQL
+func NewProductRepository(client *ent.Client, db *stdsql.DB) *ProductRepositoryImpl {
+	return &ProductRepositoryImpl{client: client, db: db}
 }
 
 func (r *ProductRepositoryImpl) Create(ctx context.Context, prod *entities.Product) error {
@@ -50,10 +70,7 @@ func (r *ProductRepositoryImpl) Create(ctx context.Context, prod *entities.Produ
 		SetStatus(product.Status(prod.Status)).
 		Save(ctx)
 	if err != nil {
-		if ent.IsConstraintError(err) {
-			return domainErrors.NewDuplicateError("Product", "sku or slug", prod.SKU)
-		}
-		return err
+		return mapWriteError("Product", err, r.writeFields(prod))
 	}
 
 	prod.ID = created.ID
@@ -62,6 +79,140 @@ func (r *ProductRepositoryImpl) Create(ctx context.Context, prod *entities.Produ
 	return nil
 }
 
+// CreateBulk creates the products in one transaction. On PostgreSQL, the rows are copied with COPY FROM,
+// which bypasses Ent and leaves the IDs of the products unset; elsewhere they are inserted in batches.
+func (r *ProductRepositoryImpl) CreateBulk(ctx context.Context, products []*entities.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+	if _, ok := r.db.Driver().(*pq.Driver); ok {
+		return r.copyProducts(ctx, products)
+	}
+
+	tx, err := r.client.Tx(ctx)
+	if err != nil {
+		return err
+	}
+	for start := 0; start < len(products); start += bulkCreateBatchSize {
+		batch := products[start:min(start+bulkCreateBatchSize, len(products))]
+		builders := make([]*ent.ProductCreate, len(batch))
+		for i, prod := range batch {
+			builders[i] = tx.Product.Create().
+				SetSku(prod.SKU).
+				SetSlug(prod.Slug).
+				SetName(prod.Name).
+				SetPrice(prod.Price).
+				SetDescription(prod.Description).
+				SetWeight(prod.Weight).
+				SetLength(prod.Length).
+				SetWidth(prod.Width).
+				SetHeight(prod.Height).
+				SetStatus(product.Status(prod.Status)).
+				SetNillableCategoryID(prod.CategoryID).
+				SetNillableBrandID(prod.BrandID)
+			if prod.ImageURLs != nil {
+				builders[i].SetImageUrls(prod.ImageURLs)
+			}
+			if !prod.CreatedAt.IsZero() {
+				builders[i].SetCreatedAt(prod.CreatedAt)
+			}
+			if !prod.UpdatedAt.IsZero() {
+				builders[i].SetUpdatedAt(prod.UpdatedAt)
+			}
+		}
+		created, err := tx.Product.CreateBulk(builders...).Save(ctx)
+		if err != nil {
+			tx.Rollback()
+			// The values are not known per product, but foreign key violations are reported as such
+			return mapWriteError("Product", err, map[string]any{})
+		}
+		for i, c := range created {
+			batch[i].ID = c.ID
+			batch[i].CreatedAt = c.CreatedAt
+			batch[i].UpdatedAt = c.UpdatedAt
+		}
+	}
+	return tx.Commit()
+}
+
+// copyProducts streams the products into the table of the tenant in the context with COPY FROM. Ent hooks
+// do not run, so the tenant and timestamps are set here.
+func (r *ProductRepositoryImpl) copyProducts(ctx context.Context, products []*entities.Product) error {
+	tenantID, ok := entities.TenantFromContext(ctx)
+	if !ok {
+		return domainErrors.ErrTenantRequired
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(product.Table, productCopyColumns...))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, prod := range products {
+		var imageURLs any
+		if prod.ImageURLs != nil {
+			encoded, err := json.Marshal(prod.ImageURLs)
+			if err != nil {
+				return err
+			}
+			imageURLs = string(encoded)
+		}
+		if prod.CreatedAt.IsZero() {
+			prod.CreatedAt = now
+		}
+		if prod.UpdatedAt.IsZero() {
+			prod.UpdatedAt = prod.CreatedAt
+		}
+		if _, err := stmt.ExecContext(ctx, tenantID, prod.SKU, prod.Slug, prod.Name, prod.Price, prod.Description,
+			prod.Weight, prod.Length, prod.Width, prod.Height, imageURLs, string(prod.Status),
+			nullableUUID(prod.CategoryID), nullableUUID(prod.BrandID), prod.CreatedAt, prod.UpdatedAt); err != nil {
+			return mapCopyError(err)
+		}
+	}
+	// Executing the statement without arguments flushes the rows
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return mapCopyError(err)
+	}
+	if err := stmt.Close(); err != nil {
+		return mapCopyError(err)
+	}
+	return tx.Commit()
+}
+
+// mapCopyError translates constraint violations of copied products, which Ent does not wrap, into domain errors
+func mapCopyError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		if v, ok := parsePostgresViolation(pqErr); ok {
+			return v.domainError("Product", map[string]any{})
+		}
+	}
+	return err
+}
+
+// nullableUUID returns the value of id for a statement argument, nil if it is unset
+func nullableUUID(id *uuid.UUID) any {
+	if id == nil {
+		return nil
+	}
+	return id.String()
+}
+
+// uuidOrNil returns id, or uuid.Nil if it is unset
+func uuidOrNil(id *uuid.UUID) uuid.UUID {
+	if id == nil {
+		return uuid.Nil
+	}
+	return *id
+}
+
 func (r *ProductRepositoryImpl) GetByID(ctx context.Context, id int) (*entities.Product, error) {
 	found, err := r.client.Product.Get(ctx, id)
 	if err != nil {
@@ -89,6 +240,25 @@ func (r *ProductRepositoryImpl) GetBySKU(ctx context.Context, sku string) (*enti
 	return r.toEntity(found), nil
 }
 
+// IDsBySKU looks the SKUs up in batches, so that the statements stay below the bind variable limit of SQLite
+func (r *ProductRepositoryImpl) IDsBySKU(ctx context.Context, skus []string) (map[string]int, error) {
+	ids := make(map[string]int, len(skus))
+	for start := 0; start < len(skus); start += bulkCreateBatchSize {
+		found, err := r.client.Product.
+			Query().
+			Where(product.SkuIn(skus[start:min(start+bulkCreateBatchSize, len(skus))]...)).
+			Select(product.FieldID, product.FieldSku).
+			All(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range found {
+			ids[p.Sku] = p.ID
+		}
+	}
+	return ids, nil
+}
+
 func (r *ProductRepositoryImpl) GetBySlug(ctx context.Context, slug string) (*entities.Product, error) {
 	found, err := r.client.Product.
 		Query().
@@ -135,6 +305,24 @@ func (r *ProductRepositoryImpl) ListByStatus(ctx context.Context, status entitie
 	return products, nil
 }
 
+func (r *ProductRepositoryImpl) ImageURLs(ctx context.Context) ([]string, error) {
+	list, err := r.client.Product.
+		Query().
+		Where(product.ImageUrlsNotNil()).
+		Select(product.FieldImageUrls).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var urls []string
+	for _, p := range list {
+		urls = append(urls, p.ImageUrls...)
+	}
+
+	return urls, nil
+}
+
 func (r *ProductRepositoryImpl) Update(ctx context.Context, prod *entities.Product) error {
 	builder := r.client.Product.
 		UpdateOneID(prod.ID).
@@ -174,10 +362,7 @@ func (r *ProductRepositoryImpl) Update(ctx context.Context, prod *entities.Produ
 		if ent.IsNotFound(err) {
 			return domainErrors.NewNotFoundError("Product", prod.ID)
 		}
-		if ent.IsConstraintError(err) {
-			return domainErrors.NewDuplicateError("Product", "sku or slug", prod.SKU)
-		}
-		return err
+		return mapWriteError("Product", err, r.writeFields(prod))
 	}
 	return nil
 }
@@ -188,14 +373,70 @@ func (r *ProductRepositoryImpl) Delete(ctx context.Context, id int) error {
 		if ent.IsNotFound(err) {
 			return domainErrors.NewNotFoundError("Product", id)
 		}
-		return err
+		return mapWriteError("Product", err, nil)
 	}
 	return nil
 }
 
+// Stats counts the products of the tenant by status, category and brand
+func (r *ProductRepositoryImpl) Stats(ctx context.Context) (*entities.ProductStats, error) {
+	stats := &entities.ProductStats{
+		ByStatus:   map[entities.ProductStatus]int{},
+		ByCategory: map[uuid.UUID]int{},
+		ByBrand:    map[uuid.UUID]int{},
+	}
+
+	var byStatus []struct {
+		Status product.Status `json:"status"`
+		Count  int            `json:"count"`
+	}
+	if err := r.client.Product.Query().GroupBy(product.FieldStatus).Aggregate(ent.Count()).Scan(ctx, &byStatus); err != nil {
+		return nil, err
+	}
+	for _, group := range byStatus {
+		stats.ByStatus[entities.ProductStatus(group.Status)] = group.Count
+		stats.Total += group.Count
+	}
+
+	var byCategory []struct {
+		CategoryID *uuid.UUID `json:"category_id"`
+		Count      int        `json:"count"`
+	}
+	if err := r.client.Product.Query().GroupBy(product.FieldCategoryID).Aggregate(ent.Count()).Scan(ctx, &byCategory); err != nil {
+		return nil, err
+	}
+	for _, group := range byCategory {
+		stats.ByCategory[uuidOrNil(group.CategoryID)] = group.Count
+	}
+
+	var byBrand []struct {
+		BrandID *uuid.UUID `json:"brand_id"`
+		Count   int        `json:"count"`
+	}
+	if err := r.client.Product.Query().GroupBy(product.FieldBrandID).Aggregate(ent.Count()).Scan(ctx, &byBrand); err != nil {
+		return nil, err
+	}
+	for _, group := range byBrand {
+		stats.ByBrand[uuidOrNil(group.BrandID)] = group.Count
+	}
+
+	return stats, nil
+}
+
+// writeFields returns the constrained fields of a product for mapping write errors
+func (r *ProductRepositoryImpl) writeFields(prod *entities.Product) map[string]any {
+	return map[string]any{
+		"sku":         prod.SKU,
+		"slug":        prod.Slug,
+		"name":        prod.Name,
+		"category_id": prod.CategoryID,
+		"brand_id":    prod.BrandID,
+	}
+}
+
 // toEntity converts Ent Product to domain entity
 func (r *ProductRepositoryImpl) toEntity(p *ent.Product) *entities.Product {
-	product := &entities.Product{
+	return &entities.Product{
 		ID:          p.ID,
 		SKU:         p.Sku,
 		Slug:        p.Slug,
@@ -208,23 +449,10 @@ func (r *ProductRepositoryImpl) toEntity(p *ent.Product) *entities.Product {
 		Height:      p.Height,
 		ImageURLs:   p.ImageUrls,
 		Status:      entities.ProductStatus(p.Status),
-		CreatedAt:   p.CreatedAt,
-		UpdatedAt:   p.UpdatedAt,
+		// The foreign keys are read from the row; querying the edges would need the tenant of the caller
+		CategoryID: p.CategoryID,
+		BrandID:    p.BrandID,
+		CreatedAt:  p.CreatedAt,
+		UpdatedAt:  p.UpdatedAt,
 	}
-
-	// Set category ID if it exists
-	if p.Edges.Category != nil {
-		product.CategoryID = &p.Edges.Category.ID
-	} else if categoryID, exists := p.QueryCategory().OnlyID(context.Background()); exists == nil {
-		product.CategoryID = &categoryID
-	}
-
-	// Set brand ID if it exists
-	if p.Edges.Brand != nil {
-		product.BrandID = &p.Edges.Brand.ID
-	} else if brandID, exists := p.QueryBrand().OnlyID(context.Background()); exists == nil {
-		product.BrandID = &brandID
-	}
-
-	return product
 }
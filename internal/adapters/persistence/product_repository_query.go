@@ -53,13 +53,8 @@ func (r *ProductRepositoryImpl) Query(ctx context.Context, params *entities.Quer
 	// Fetch limit + 1 to determine if there's a next page
 	query = query.Limit(limit + 1)
 
-
-	fmt.Println("cursor")
-	fmt.Println(cursor)
-
 	// Apply cursor pagination
 	if cursor != nil {
-		fmt.Println("masuk donks")
 		query = r.applyCursor(query, cursor, params.Pagination.Direction, params.Sort)
 	}
 
@@ -145,6 +140,24 @@ func (r *ProductRepositoryImpl) buildSingleFilterPredicate(filter entities.Filte
 
 // buildIntFilter builds predicates for integer fields
 func (r *ProductRepositoryImpl) buildIntFilter(filter entities.Filter, fieldFunc func(int) predicate.Product) (predicate.Product, error) {
+	if filter.Operator == entities.OpIn {
+		vals, ok := filter.Value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("in operator requires array value")
+		}
+		intVals := make([]any, len(vals))
+		for i, v := range vals {
+			f, ok := v.(float64)
+			if !ok {
+				return nil, fmt.Errorf("invalid value in array: %T", v)
+			}
+			intVals[i] = int(f)
+		}
+		return func(s *sql.Selector) {
+			s.Where(sql.In(filter.Field, intVals...))
+		}, nil
+	}
+
 	val, ok := filter.Value.(float64) // JSON numbers are float64
 	if !ok {
 		return nil, fmt.Errorf("invalid value type for int filter: %T", filter.Value)
@@ -172,20 +185,6 @@ func (r *ProductRepositoryImpl) buildIntFilter(filter entities.Filter, fieldFunc
 		return func(s *sql.Selector) {
 			s.Where(sql.LTE(filter.Field, intVal))
 		}, nil
-	case entities.OpIn:
-		vals, ok := filter.Value.([]interface{})
-		if !ok {
-			return nil, fmt.Errorf("in operator requires array value")
-		}
-		intVals := make([]int, len(vals))
-		for i, v := range vals {
-			f, ok := v.(float64)
-			if !ok {
-				return nil, fmt.Errorf("invalid value in array: %T", v)
-			}
-			intVals[i] = int(f)
-		}
-		return product.IDIn(intVals...), nil
 	default:
 		return nil, fmt.Errorf("unsupported operator %s for int field", filter.Operator)
 	}
@@ -218,6 +217,36 @@ func (r *ProductRepositoryImpl) buildFloatFilter(filter entities.Filter, fieldFu
 
 // buildStringFilter builds predicates for string fields
 func (r *ProductRepositoryImpl) buildStringFilter(filter entities.Filter, fieldFunc func(string) predicate.Product) (predicate.Product, error) {
+	if filter.Operator == entities.OpIn {
+		vals, ok := filter.Value.([]interface{})
+		if !ok {
+			// Try comma-separated string
+			val, ok := filter.Value.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid value type for string filter: %T", filter.Value)
+			}
+			strVals := strings.Split(val, ",")
+			anyVals := make([]any, len(strVals))
+			for i, v := range strVals {
+				anyVals[i] = strings.TrimSpace(v)
+			}
+			return func(s *sql.Selector) {
+				s.Where(sql.In(filter.Field, anyVals...))
+			}, nil
+		}
+		anyVals := make([]any, len(vals))
+		for i, v := range vals {
+			str, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid value in array: %T", v)
+			}
+			anyVals[i] = str
+		}
+		return func(s *sql.Selector) {
+			s.Where(sql.In(filter.Field, anyVals...))
+		}, nil
+	}
+
 	val, ok := filter.Value.(string)
 	if !ok {
 		return nil, fmt.Errorf("invalid value type for string filter: %T", filter.Value)
@@ -244,30 +273,6 @@ func (r *ProductRepositoryImpl) buildStringFilter(filter entities.Filter, fieldF
 		return func(s *sql.Selector) {
 			s.Where(sql.HasSuffix(filter.Field, val))
 		}, nil
-	case entities.OpIn:
-		vals, ok := filter.Value.([]interface{})
-		if !ok {
-			// Try comma-separated string
-			strVals := strings.Split(val, ",")
-			anyVals := make([]any, len(strVals))
-			for i, v := range strVals {
-				anyVals[i] = strings.TrimSpace(v)
-			}
-			return func(s *sql.Selector) {
-				s.Where(sql.In(filter.Field, anyVals...))
-			}, nil
-		}
-		anyVals := make([]any, len(vals))
-		for i, v := range vals {
-			str, ok := v.(string)
-			if !ok {
-				return nil, fmt.Errorf("invalid value in array: %T", v)
-			}
-			anyVals[i] = str
-		}
-		return func(s *sql.Selector) {
-			s.Where(sql.In(filter.Field, anyVals...))
-		}, nil
 	default:
 		return nil, fmt.Errorf("unsupported operator %s for string field", filter.Operator)
 	}
@@ -0,0 +1,120 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestProductRepository_CreateBulk tests that bulk creates span batches, keep given timestamps and fill in IDs
+func TestProductRepository_CreateBulk(t *testing.T) {
+	// Arrange
+	client, db := newTestDatabase(t)
+	repo := NewProductRepository(client, db)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	products := make([]*entities.Product, bulkCreateBatchSize+1)
+	for i := range products {
+		products[i] = &entities.Product{SKU: fmt.Sprintf("SKU-%d", i), Slug: fmt.Sprintf("shirt-%d", i), Name: "Shirt", Price: 10, Status: entities.ProductStatusDraft, CreatedAt: createdAt}
+	}
+
+	// Act
+	err := repo.CreateBulk(ctx, products)
+
+	// Assert
+	require.NoError(t, err)
+	last := products[len(products)-1]
+	require.NotZero(t, last.ID)
+	found, err := repo.GetByID(ctx, last.ID)
+	require.NoError(t, err)
+	assert.Equal(t, last.SKU, found.SKU)
+	assert.True(t, createdAt.Equal(found.CreatedAt))
+}
+
+// TestProductRepository_CreateBulkAllOrNone tests that a failing product leaves the others uncreated
+func TestProductRepository_CreateBulkAllOrNone(t *testing.T) {
+	// Arrange
+	client, db := newTestDatabase(t)
+	repo := NewProductRepository(client, db)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	products := []*entities.Product{
+		{SKU: "SKU-1", Slug: "shirt", Name: "Shirt", Price: 10, Status: entities.ProductStatusDraft},
+		{SKU: "SKU-1", Slug: "other", Name: "Other", Price: 10, Status: entities.ProductStatusDraft},
+	}
+
+	// Act
+	err := repo.CreateBulk(ctx, products)
+
+	// Assert
+	assert.ErrorIs(t, err, domainErrors.ErrDuplicateEntry)
+	stats, err := repo.Stats(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, stats.Total)
+}
+
+// TestProductRepository_CreateBulkMissingReference tests that a reference to a missing row is reported as such
+func TestProductRepository_CreateBulkMissingReference(t *testing.T) {
+	// Arrange
+	client, db := newTestDatabase(t)
+	repo := NewProductRepository(client, db)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	missing := uuid.New()
+	products := []*entities.Product{
+		{SKU: "SKU-1", Slug: "shirt", Name: "Shirt", Price: 10, Status: entities.ProductStatusDraft},
+		{SKU: "SKU-2", Slug: "socks", Name: "Socks", Price: 5, Status: entities.ProductStatusDraft, BrandID: &missing},
+	}
+
+	// Act
+	err := repo.CreateBulk(ctx, products)
+
+	// Assert
+	var validationErr *domainErrors.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.True(t, strings.HasSuffix(validationErr.Errors[0].Code, ".not_found"), validationErr.Errors[0].Code)
+	stats, err := repo.Stats(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, stats.Total)
+}
+
+// TestProductRepository_Stats tests that products are counted by status, category and brand within the tenant
+func TestProductRepository_Stats(t *testing.T) {
+	// Arrange
+	client, db := newTestDatabase(t)
+	repo := NewProductRepository(client, db)
+	categories := NewCategoryRepository(client)
+	brands := NewBrandRepository(client)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	other := entities.ContextWithTenant(context.Background(), "other")
+
+	category := &entities.Category{Name: "Apparel"}
+	require.NoError(t, categories.Create(ctx, category))
+	brand := &entities.Brand{Name: "Acme"}
+	require.NoError(t, brands.Create(ctx, brand))
+	require.NoError(t, repo.CreateBulk(ctx, []*entities.Product{
+		{SKU: "SKU-1", Slug: "shirt", Name: "Shirt", Price: 10, Status: entities.ProductStatusPublished, CategoryID: &category.ID, BrandID: &brand.ID},
+		{SKU: "SKU-2", Slug: "socks", Name: "Socks", Price: 5, Status: entities.ProductStatusPublished, CategoryID: &category.ID},
+		{SKU: "SKU-3", Slug: "hat", Name: "Hat", Price: 15, Status: entities.ProductStatusDraft},
+	}))
+	require.NoError(t, repo.Create(other, &entities.Product{SKU: "SKU-1", Slug: "shirt", Name: "Shirt", Price: 10, Status: entities.ProductStatusArchived}))
+
+	// Act
+	stats, err := repo.Stats(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, &entities.ProductStats{
+		Total:      3,
+		ByStatus:   map[entities.ProductStatus]int{entities.ProductStatusPublished: 2, entities.ProductStatusDraft: 1},
+		ByCategory: map[uuid.UUID]int{category.ID: 2, uuid.Nil: 1},
+		ByBrand:    map[uuid.UUID]int{brand.ID: 1, uuid.Nil: 2},
+	}, stats)
+}
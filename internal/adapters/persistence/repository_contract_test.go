@@ -0,0 +1,32 @@
+package persistence
+
+import (
+	"testing"
+
+	"example.com/go-yippi/internal/adapters/persistence/repositorytest"
+	"example.com/go-yippi/internal/domain/entities"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRepositoryContract runs the repository contract tests against the Ent repositories
+func TestRepositoryContract(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repositories {
+		client, db := newTestDatabase(t)
+		files := NewFileRepository(client)
+		return repositorytest.Repositories{
+			Products:   NewProductRepository(client, db),
+			Categories: NewCategoryRepository(client),
+			Brands:     NewBrandRepository(client),
+			Media:      NewProductMediaRepository(client),
+			Users:      NewUserRepository(client),
+			NewFile: func(t *testing.T) uuid.UUID {
+				ctx := entities.ContextWithTenant(t.Context(), repositorytest.Tenant)
+				file := &entities.FileMetadata{Key: uuid.NewString()}
+				createTestFiles(t, files, ctx, file)
+				require.NotEqual(t, uuid.Nil, file.ID)
+				return file.ID
+			},
+		}
+	})
+}
@@ -0,0 +1,320 @@
+package repositorytest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// categoryNames returns the names of categories
+func categoryNames(categories []*entities.Category) []string {
+	names := make([]string, len(categories))
+	for i, c := range categories {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// testCategoryCRUD tests the round trip of categories
+func testCategoryCRUD(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := tenantCtx()
+	root := createCategory(t, repos, "Electronics", nil)
+	child := createCategory(t, repos, "Phones", &root.ID)
+
+	// Act
+	byID, byIDErr := repos.Categories.GetByID(ctx, child.ID)
+	byName, byNameErr := repos.Categories.GetByName(ctx, "Electronics")
+	child.Name = "Smartphones"
+	child.ParentID = nil
+	updateErr := repos.Categories.Update(ctx, child)
+	updated, _ := repos.Categories.GetByID(ctx, child.ID)
+	deleteErr := repos.Categories.Delete(ctx, root.ID)
+	list, listErr := repos.Categories.List(ctx)
+
+	// Assert
+	require.NoError(t, byIDErr)
+	assert.Equal(t, "Phones", byID.Name)
+	assert.Equal(t, &root.ID, byID.ParentID)
+	assert.False(t, byID.CreatedAt.IsZero())
+	require.NoError(t, byNameErr)
+	assert.Equal(t, root.ID, byName.ID)
+	assert.Nil(t, byName.ParentID)
+	require.NoError(t, updateErr)
+	assert.Equal(t, "Smartphones", updated.Name)
+	assert.Nil(t, updated.ParentID)
+	require.NoError(t, deleteErr)
+	require.NoError(t, listErr)
+	assert.Equal(t, []string{"Smartphones"}, categoryNames(list))
+
+	_, err := repos.Categories.GetByID(ctx, root.ID)
+	assertNotFound(t, err, "Category")
+	_, err = repos.Categories.GetByName(ctx, "Electronics")
+	assertNotFound(t, err, "Category")
+	assertNotFound(t, repos.Categories.Update(ctx, root), "Category")
+	assertNotFound(t, repos.Categories.Delete(ctx, root.ID), "Category")
+}
+
+// testCategoryTree tests listing categories by parent and collecting the descendants of categories
+func testCategoryTree(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := tenantCtx()
+	electronics := createCategory(t, repos, "Electronics", nil)
+	books := createCategory(t, repos, "Books", nil)
+	phones := createCategory(t, repos, "Phones", &electronics.ID)
+	laptops := createCategory(t, repos, "Laptops", &electronics.ID)
+	smartphones := createCategory(t, repos, "Smartphones", &phones.ID)
+	require.NoError(t, repos.Categories.Create(otherCtx(), &entities.Category{Name: "Hidden"}))
+
+	// Act
+	roots, rootsErr := repos.Categories.ListByParentID(ctx, nil)
+	children, childrenErr := repos.Categories.ListByParentID(ctx, &electronics.ID)
+	descendants, descendantsErr := repos.Categories.GetDescendantIDs(ctx, []uuid.UUID{electronics.ID})
+	several, severalErr := repos.Categories.GetDescendantIDs(ctx, []uuid.UUID{phones.ID, books.ID})
+	none, noneErr := repos.Categories.GetDescendantIDs(ctx, nil)
+
+	// Assert
+	require.NoError(t, rootsErr)
+	assert.ElementsMatch(t, []string{"Electronics", "Books"}, categoryNames(roots))
+	require.NoError(t, childrenErr)
+	assert.ElementsMatch(t, []string{"Phones", "Laptops"}, categoryNames(children))
+	require.NoError(t, descendantsErr)
+	assert.ElementsMatch(t, []uuid.UUID{electronics.ID, phones.ID, laptops.ID, smartphones.ID}, descendants)
+	require.NoError(t, severalErr)
+	assert.ElementsMatch(t, []uuid.UUID{phones.ID, smartphones.ID, books.ID}, several)
+	require.NoError(t, noneErr)
+	assert.Empty(t, none)
+}
+
+// testCategoryConstraints tests that names are unique within a tenant, parents exist and tenants are required
+func testCategoryConstraints(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := tenantCtx()
+	phones := createCategory(t, repos, "Phones", nil)
+	laptops := createCategory(t, repos, "Laptops", nil)
+	missing := uuid.New()
+
+	// Act
+	duplicateErr := repos.Categories.Create(ctx, &entities.Category{Name: "Phones"})
+	renameErr := repos.Categories.Update(ctx, &entities.Category{ID: laptops.ID, Name: "Phones"})
+	otherErr := repos.Categories.Create(otherCtx(), &entities.Category{Name: "Phones"})
+	parentErr := repos.Categories.Create(ctx, &entities.Category{Name: "Orphans", ParentID: &missing})
+	emptyErr := repos.Categories.Create(ctx, &entities.Category{Name: ""})
+	_, hiddenErr := repos.Categories.GetByID(otherCtx(), phones.ID)
+	_, tenantErr := repos.Categories.List(context.Background())
+
+	// Assert
+	assertDuplicate(t, duplicateErr, "Category", "name")
+	assertDuplicate(t, renameErr, "Category", "name")
+	assert.NoError(t, otherErr)
+	assertValidation(t, parentErr, "parent_id.not_found")
+	assertValidation(t, emptyErr, "name.invalid")
+	assertNotFound(t, hiddenErr, "Category")
+	assert.ErrorIs(t, tenantErr, domainErrors.ErrTenantRequired)
+}
+
+// testCategoryDeleteClearsReferences tests that deleting a category makes its children roots and leaves
+// its products without a category
+func testCategoryDeleteClearsReferences(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := tenantCtx()
+	electronics := createCategory(t, repos, "Electronics", nil)
+	phones := createCategory(t, repos, "Phones", &electronics.ID)
+	prod := newProduct("1")
+	prod.CategoryID = &electronics.ID
+	require.NoError(t, repos.Products.Create(ctx, prod))
+
+	// Act
+	err := repos.Categories.Delete(ctx, electronics.ID)
+
+	// Assert
+	require.NoError(t, err)
+	child, err := repos.Categories.GetByID(ctx, phones.ID)
+	require.NoError(t, err)
+	assert.Nil(t, child.ParentID)
+	found, err := repos.Products.GetByID(ctx, prod.ID)
+	require.NoError(t, err)
+	assert.Nil(t, found.CategoryID)
+}
+
+// testBrandCRUD tests the round trip of brands
+func testBrandCRUD(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := tenantCtx()
+	acme := createBrand(t, repos, "Acme")
+	createBrand(t, repos, "Globex")
+
+	// Act
+	byID, byIDErr := repos.Brands.GetByID(ctx, acme.ID)
+	byName, byNameErr := repos.Brands.GetByName(ctx, "Globex")
+	acme.Name = "Acme Corp"
+	updateErr := repos.Brands.Update(ctx, acme)
+	updated, _ := repos.Brands.GetByID(ctx, acme.ID)
+	list, listErr := repos.Brands.List(ctx)
+	deleteErr := repos.Brands.Delete(ctx, acme.ID)
+
+	// Assert
+	require.NoError(t, byIDErr)
+	assert.Equal(t, "Acme", byID.Name)
+	assert.False(t, byID.CreatedAt.IsZero())
+	require.NoError(t, byNameErr)
+	assert.Equal(t, "Globex", byName.Name)
+	require.NoError(t, updateErr)
+	assert.Equal(t, "Acme Corp", updated.Name)
+	assert.False(t, acme.UpdatedAt.Before(byID.UpdatedAt))
+	require.NoError(t, listErr)
+	names := make([]string, len(list))
+	for i, b := range list {
+		names[i] = b.Name
+	}
+	assert.ElementsMatch(t, []string{"Acme Corp", "Globex"}, names)
+	require.NoError(t, deleteErr)
+
+	_, err := repos.Brands.GetByID(ctx, acme.ID)
+	assertNotFound(t, err, "Brand")
+	_, err = repos.Brands.GetByName(ctx, "Acme Corp")
+	assertNotFound(t, err, "Brand")
+	assertNotFound(t, repos.Brands.Update(ctx, acme), "Brand")
+	assertNotFound(t, repos.Brands.Delete(ctx, acme.ID), "Brand")
+}
+
+// testBrandConstraints tests that names are valid and unique within a tenant, and that tenants are required
+func testBrandConstraints(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := tenantCtx()
+	acme := createBrand(t, repos, "Acme")
+	globex := createBrand(t, repos, "Globex")
+
+	// Act
+	duplicateErr := repos.Brands.Create(ctx, &entities.Brand{Name: "Acme"})
+	renameErr := repos.Brands.Update(ctx, &entities.Brand{ID: globex.ID, Name: "Acme"})
+	otherErr := repos.Brands.Create(otherCtx(), &entities.Brand{Name: "Acme"})
+	emptyErr := repos.Brands.Create(ctx, &entities.Brand{Name: ""})
+	longErr := repos.Brands.Create(ctx, &entities.Brand{Name: strings.Repeat("a", 256)})
+	_, hiddenErr := repos.Brands.GetByID(otherCtx(), acme.ID)
+	tenantErr := repos.Brands.Create(context.Background(), &entities.Brand{Name: "Initech"})
+
+	// Assert
+	assertDuplicate(t, duplicateErr, "Brand", "name")
+	assertDuplicate(t, renameErr, "Brand", "name")
+	assert.NoError(t, otherErr)
+	assertValidation(t, emptyErr, "name.invalid")
+	assertValidation(t, longErr, "name.invalid")
+	assertNotFound(t, hiddenErr, "Brand")
+	assert.ErrorIs(t, tenantErr, domainErrors.ErrTenantRequired)
+}
+
+// testBrandDeleteClearsReferences tests that deleting a brand leaves its products without a brand
+func testBrandDeleteClearsReferences(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := tenantCtx()
+	brand := createBrand(t, repos, "Acme")
+	prod := newProduct("1")
+	prod.BrandID = &brand.ID
+	require.NoError(t, repos.Products.Create(ctx, prod))
+
+	// Act
+	err := repos.Brands.Delete(ctx, brand.ID)
+
+	// Assert
+	require.NoError(t, err)
+	found, err := repos.Products.GetByID(ctx, prod.ID)
+	require.NoError(t, err)
+	assert.Nil(t, found.BrandID)
+}
+
+// testUserCRUD tests the round trip of users, which belong to a tenant without being scoped to one
+func testUserCRUD(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := context.Background()
+	viewer := &entities.User{Name: "Vera", Age: 30, Email: "vera@example.com", PasswordHash: "hash"}
+	admin := &entities.User{Name: "Ada", Age: 40, Email: "ada@example.com", Role: entities.RoleAdmin, TenantID: Tenant}
+
+	// Act
+	viewerErr := repos.Users.Create(ctx, viewer)
+	adminErr := repos.Users.Create(ctx, admin)
+	byEmail, byEmailErr := repos.Users.GetByEmail(ctx, "ada@example.com")
+	list, listErr := repos.Users.List(ctx)
+	tenantList, tenantListErr := repos.Users.ListByTenant(ctx, Tenant)
+
+	update := *admin
+	update.Name = "Ada L."
+	update.Role = ""
+	update.TenantID = OtherTenant
+	update.RecoveryCodes = []string{"code"}
+	update.TOTPChallengeID = "challenge"
+	update.TOTPFailedAttempts = 2
+	update.TOTPLockedUntil = time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	updateErr := repos.Users.Update(ctx, &update)
+	updated, _ := repos.Users.GetByID(ctx, admin.ID)
+	deleteErr := repos.Users.Delete(ctx, viewer.ID)
+
+	// Assert
+	require.NoError(t, viewerErr)
+	assert.NotZero(t, viewer.ID)
+	assert.Equal(t, entities.RoleViewer, viewer.Role)
+	assert.Equal(t, entities.DefaultTenantID, viewer.TenantID)
+	require.NoError(t, adminErr)
+	assert.Equal(t, Tenant, admin.TenantID)
+	require.NoError(t, byEmailErr)
+	assert.Equal(t, admin.ID, byEmail.ID)
+	assert.Equal(t, entities.RoleAdmin, byEmail.Role)
+	require.NoError(t, listErr)
+	assert.Len(t, list, 2)
+	require.NoError(t, tenantListErr)
+	require.Len(t, tenantList, 1)
+	assert.Equal(t, admin.ID, tenantList[0].ID)
+
+	require.NoError(t, updateErr)
+	assert.Equal(t, "Ada L.", updated.Name)
+	assert.Equal(t, entities.RoleAdmin, updated.Role)
+	assert.Equal(t, Tenant, updated.TenantID)
+	assert.Equal(t, []string{"code"}, updated.RecoveryCodes)
+	assert.Equal(t, "challenge", updated.TOTPChallengeID)
+	assert.Equal(t, 2, updated.TOTPFailedAttempts)
+	assert.True(t, update.TOTPLockedUntil.Equal(updated.TOTPLockedUntil))
+	require.NoError(t, deleteErr)
+
+	_, err := repos.Users.GetByID(ctx, viewer.ID)
+	assertNotFound(t, err, "User")
+	_, err = repos.Users.GetByEmail(ctx, "vera@example.com")
+	assertNotFound(t, err, "User")
+	assertNotFound(t, repos.Users.Update(ctx, viewer), "User")
+	assertNotFound(t, repos.Users.Delete(ctx, viewer.ID), "User")
+}
+
+// testUserConstraints tests that emails are unique, users may have none, and invalid fields are rejected
+func testUserConstraints(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := context.Background()
+	first := &entities.User{Name: "First", Age: 30, Email: "taken@example.com"}
+	require.NoError(t, repos.Users.Create(ctx, first))
+	second := &entities.User{Name: "Second", Age: 30, Email: "second@example.com"}
+	require.NoError(t, repos.Users.Create(ctx, second))
+
+	// Act
+	duplicateErr := repos.Users.Create(ctx, &entities.User{Name: "Copy", Age: 30, Email: "taken@example.com"})
+	second.Email = "taken@example.com"
+	updateErr := repos.Users.Update(ctx, second)
+	noEmailErr := repos.Users.Create(ctx, &entities.User{Name: "Anonymous", Age: 30})
+	noEmailAgainErr := repos.Users.Create(ctx, &entities.User{Name: "Anonymous", Age: 30})
+	_, emptyEmailErr := repos.Users.GetByEmail(ctx, "")
+	ageErr := repos.Users.Create(ctx, &entities.User{Name: "Newborn", Age: 0})
+	roleErr := repos.Users.Create(ctx, &entities.User{Name: "Root", Age: 30, Role: "root"})
+
+	// Assert
+	assertDuplicate(t, duplicateErr, "User", "email")
+	assertDuplicate(t, updateErr, "User", "email")
+	assert.NoError(t, noEmailErr)
+	assert.NoError(t, noEmailAgainErr)
+	assertNotFound(t, emptyEmailErr, "User")
+	assertValidation(t, ageErr, "age.invalid")
+	assertValidation(t, roleErr, "role.invalid")
+}
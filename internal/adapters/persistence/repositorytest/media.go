@@ -0,0 +1,153 @@
+package repositorytest
+
+import (
+	"testing"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMedia returns an image of the product's gallery at position
+func newMedia(productID int, fileID uuid.UUID, position int) *entities.ProductMedia {
+	return &entities.ProductMedia{ProductID: productID, FileID: fileID, Position: position, Type: entities.MediaTypeImage}
+}
+
+// mediaFileIDs returns the file IDs of media
+func mediaFileIDs(media []*entities.ProductMedia) []uuid.UUID {
+	ids := make([]uuid.UUID, len(media))
+	for i, m := range media {
+		ids[i] = m.FileID
+	}
+	return ids
+}
+
+// testMediaGallery tests that galleries are listed by position, that media can be updated and deleted,
+// and that they are counted and deleted by file
+func testMediaGallery(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := tenantCtx()
+	prod := newProduct("1")
+	require.NoError(t, repos.Products.Create(ctx, prod))
+	front, back, manual := repos.NewFile(t), repos.NewFile(t), repos.NewFile(t)
+	backMedia := newMedia(prod.ID, back, 1)
+	frontMedia := newMedia(prod.ID, front, 0)
+	manualMedia := newMedia(prod.ID, manual, 2)
+	for _, m := range []*entities.ProductMedia{backMedia, frontMedia, manualMedia} {
+		require.NoError(t, repos.Media.Create(ctx, m))
+	}
+
+	// Act
+	gallery, listErr := repos.Media.ListByProduct(ctx, prod.ID)
+	frontMedia.IsPrimary, frontMedia.AltText, frontMedia.Position = true, "Front", 3
+	updateErr := repos.Media.Update(ctx, frontMedia)
+	updated, getErr := repos.Media.GetByID(ctx, frontMedia.ID)
+	count, countErr := repos.Media.CountByFile(ctx, back)
+	deleteByFileErr := repos.Media.DeleteByFile(ctx, back)
+	deleteErr := repos.Media.Delete(ctx, manualMedia.ID)
+	deleteAgainErr := repos.Media.Delete(ctx, manualMedia.ID)
+	remaining, remainingErr := repos.Media.ListByProducts(ctx, []int{prod.ID})
+
+	// Assert
+	require.NoError(t, listErr)
+	assert.Equal(t, []uuid.UUID{front, back, manual}, mediaFileIDs(gallery))
+	assert.NotEqual(t, uuid.Nil, gallery[0].ID)
+	require.NoError(t, updateErr)
+	require.NoError(t, getErr)
+	assert.True(t, updated.IsPrimary)
+	assert.Equal(t, "Front", updated.AltText)
+	assert.Equal(t, 3, updated.Position)
+	require.NoError(t, countErr)
+	assert.Equal(t, 1, count)
+	require.NoError(t, deleteByFileErr)
+	require.NoError(t, deleteErr)
+	assertNotFound(t, deleteAgainErr, "ProductMedia")
+	require.NoError(t, remainingErr)
+	assert.Equal(t, []uuid.UUID{front}, mediaFileIDs(remaining))
+}
+
+// testMediaConstraints tests that media are validated, attach a file once per product, reference an
+// existing product and go with their product
+func testMediaConstraints(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := tenantCtx()
+	prod := newProduct("1")
+	require.NoError(t, repos.Products.Create(ctx, prod))
+	file := repos.NewFile(t)
+	require.NoError(t, repos.Media.Create(ctx, newMedia(prod.ID, file, 0)))
+	negative := newMedia(prod.ID, repos.NewFile(t), -1)
+
+	// Act
+	negativeErr := repos.Media.Create(ctx, negative)
+	duplicateErr := repos.Media.Create(ctx, newMedia(prod.ID, file, 1))
+	missingErr := repos.Media.Create(ctx, newMedia(prod.ID+1000, file, 0))
+	deleteErr := repos.Products.Delete(ctx, prod.ID)
+	count, countErr := repos.Media.CountByFile(ctx, file)
+
+	// Assert
+	assertValidation(t, negativeErr, "position.invalid")
+	assert.ErrorIs(t, duplicateErr, domainErrors.ErrDuplicateEntry)
+	assert.ErrorIs(t, missingErr, domainErrors.ErrInvalidInput)
+	require.NoError(t, deleteErr)
+	require.NoError(t, countErr)
+	assert.Zero(t, count)
+}
+
+// testMediaCreateBulkAllOrNone tests that a batch with an invalid media creates none of them
+func testMediaCreateBulkAllOrNone(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := tenantCtx()
+	prod := newProduct("1")
+	require.NoError(t, repos.Products.Create(ctx, prod))
+	front, back := repos.NewFile(t), repos.NewFile(t)
+	valid := []*entities.ProductMedia{newMedia(prod.ID, front, 0), newMedia(prod.ID, back, 1)}
+
+	// Act
+	err := repos.Media.CreateBulk(ctx, []*entities.ProductMedia{newMedia(prod.ID, front, 0), newMedia(prod.ID, front, 1)})
+	empty, emptyErr := repos.Media.ListByProduct(ctx, prod.ID)
+	validErr := repos.Media.CreateBulk(ctx, valid)
+	gallery, galleryErr := repos.Media.ListByProduct(ctx, prod.ID)
+
+	// Assert
+	assert.ErrorIs(t, err, domainErrors.ErrDuplicateEntry)
+	require.NoError(t, emptyErr)
+	assert.Empty(t, empty)
+	require.NoError(t, validErr)
+	assert.NotEqual(t, uuid.Nil, valid[1].ID)
+	require.NoError(t, galleryErr)
+	assert.Equal(t, []uuid.UUID{front, back}, mediaFileIDs(gallery))
+}
+
+// testMediaTenantIsolation tests that media are only visible to and writable by their tenant
+func testMediaTenantIsolation(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := tenantCtx()
+	prod := newProduct("1")
+	require.NoError(t, repos.Products.Create(ctx, prod))
+	file := repos.NewFile(t)
+	media := newMedia(prod.ID, file, 0)
+	require.NoError(t, repos.Media.Create(ctx, media))
+
+	// Act
+	_, getErr := repos.Media.GetByID(otherCtx(), media.ID)
+	gallery, listErr := repos.Media.ListByProduct(otherCtx(), prod.ID)
+	count, countErr := repos.Media.CountByFile(otherCtx(), file)
+	deleteErr := repos.Media.Delete(otherCtx(), media.ID)
+	deleteByFileErr := repos.Media.DeleteByFile(otherCtx(), file)
+	_, tenantErr := repos.Media.ListByProduct(t.Context(), prod.ID)
+
+	// Assert
+	assertNotFound(t, getErr, "ProductMedia")
+	require.NoError(t, listErr)
+	assert.Empty(t, gallery)
+	require.NoError(t, countErr)
+	assert.Zero(t, count)
+	assertNotFound(t, deleteErr, "ProductMedia")
+	require.NoError(t, deleteByFileErr)
+	kept, err := repos.Media.GetByID(ctx, media.ID)
+	require.NoError(t, err)
+	assert.Equal(t, file, kept.FileID)
+	assert.ErrorIs(t, tenantErr, domainErrors.ErrTenantRequired)
+}
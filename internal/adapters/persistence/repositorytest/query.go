@@ -0,0 +1,306 @@
+package repositorytest
+
+import (
+	"testing"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// queryEpoch is the time the products of the query tests were created after
+var queryEpoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// queryCatalog is what the query tests search: six products created an hour apart, so that the default
+// order, newest first, is C-2, C-1, B-2, B-1, A-2, A-1
+type queryCatalog struct {
+	phones, tablets, watches uuid.UUID
+	brandX, brandY           uuid.UUID
+}
+
+// seedQueryCatalog creates the products of the query tests, and a product of OtherTenant that must never
+// be found
+func seedQueryCatalog(t *testing.T, repos Repositories) *queryCatalog {
+	t.Helper()
+
+	c := &queryCatalog{
+		phones:  createCategory(t, repos, "Phones", nil).ID,
+		tablets: createCategory(t, repos, "Tablets", nil).ID,
+		watches: createCategory(t, repos, "Watches", nil).ID,
+		brandX:  createBrand(t, repos, "Brand X").ID,
+		brandY:  createBrand(t, repos, "Brand Y").ID,
+	}
+	rows := []struct {
+		code     string
+		name     string
+		price    float64
+		weight   int
+		status   entities.ProductStatus
+		category *uuid.UUID
+		brand    *uuid.UUID
+	}{
+		{"A-1", "Alpha Phone", 100, 200, entities.ProductStatusPublished, &c.phones, &c.brandX},
+		{"A-2", "Alpha Tablet", 250, 500, entities.ProductStatusDraft, &c.tablets, &c.brandY},
+		{"B-1", "Beta Phone", 150, 200, entities.ProductStatusPublished, &c.phones, &c.brandY},
+		{"B-2", "Beta_Laptop 100%", 900, 1500, entities.ProductStatusArchived, nil, &c.brandX},
+		{"C-1", "Gamma Watch", 50, 50, entities.ProductStatusPublished, &c.watches, nil},
+		{"C-2", "gamma watch mini", 60, 40, entities.ProductStatusDraft, &c.watches, nil},
+	}
+
+	products := make([]*entities.Product, len(rows))
+	for i, row := range rows {
+		createdAt := queryEpoch.Add(time.Duration(i+1) * time.Hour)
+		products[i] = &entities.Product{
+			SKU:        row.code,
+			Slug:       "product-" + row.code,
+			Name:       row.name,
+			Price:      row.price,
+			Weight:     row.weight,
+			Status:     row.status,
+			CategoryID: row.category,
+			BrandID:    row.brand,
+			CreatedAt:  createdAt,
+			UpdatedAt:  createdAt,
+		}
+	}
+	require.NoError(t, repos.Products.CreateBulk(tenantCtx(), products))
+
+	hidden := newProduct("A-1")
+	hidden.SKU = "A-1"
+	require.NoError(t, repos.Products.Create(otherCtx(), hidden))
+	return c
+}
+
+// querySKUs runs a query in Tenant and returns the SKUs found, in order
+func querySKUs(t *testing.T, repos Repositories, params *entities.QueryParams) []string {
+	t.Helper()
+
+	result, err := repos.Products.Query(tenantCtx(), params)
+	require.NoError(t, err)
+	return skusOf(result.Products)
+}
+
+// skusOf returns the SKUs of products, in order
+func skusOf(products []*entities.Product) []string {
+	skus := make([]string, len(products))
+	for i, p := range products {
+		skus[i] = p.SKU
+	}
+	return skus
+}
+
+// at returns the time of the query tests an offset after their epoch, as a filter value
+func at(offset time.Duration) string {
+	return queryEpoch.Add(offset).Format(time.RFC3339)
+}
+
+// testQueryFilters tests every filter operator of every field type
+func testQueryFilters(t *testing.T, repos Repositories) {
+	c := seedQueryCatalog(t, repos)
+	filter := func(field string, op entities.FilterOperator, value any) []entities.Filter {
+		return []entities.Filter{{Field: field, Operator: op, Value: value}}
+	}
+
+	tests := []struct {
+		name    string
+		filters []entities.Filter
+		want    []string
+	}{
+		{"int eq", filter("weight", entities.OpEqual, 200.0), []string{"B-1", "A-1"}},
+		{"int ne", filter("weight", entities.OpNotEqual, 200.0), []string{"C-2", "C-1", "B-2", "A-2"}},
+		{"int gt", filter("weight", entities.OpGreaterThan, 200.0), []string{"B-2", "A-2"}},
+		{"int gte", filter("weight", entities.OpGreaterThanOrEqual, 500.0), []string{"B-2", "A-2"}},
+		{"int lt", filter("weight", entities.OpLessThan, 50.0), []string{"C-2"}},
+		{"int lte", filter("weight", entities.OpLessThanOrEqual, 200.0), []string{"C-2", "C-1", "B-1", "A-1"}},
+		{"int in", filter("weight", entities.OpIn, []interface{}{200.0, 50.0}), []string{"C-1", "B-1", "A-1"}},
+		{"price eq", filter("price", entities.OpEqual, 250.0), []string{"A-2"}},
+		{"price ne", filter("price", entities.OpNotEqual, 250.0), []string{"C-2", "C-1", "B-2", "B-1", "A-1"}},
+		{"price gt", filter("price", entities.OpGreaterThan, 150.0), []string{"B-2", "A-2"}},
+		{"price gte", filter("price", entities.OpGreaterThanOrEqual, 150.0), []string{"B-2", "B-1", "A-2"}},
+		{"price lt", filter("price", entities.OpLessThan, 100.0), []string{"C-2", "C-1"}},
+		{"price lte", filter("price", entities.OpLessThanOrEqual, 100.0), []string{"C-2", "C-1", "A-1"}},
+		{"string eq", filter("sku", entities.OpEqual, "A-1"), []string{"A-1"}},
+		{"string ne", filter("sku", entities.OpNotEqual, "A-1"), []string{"C-2", "C-1", "B-2", "B-1", "A-2"}},
+		{"string in array", filter("sku", entities.OpIn, []interface{}{"A-1", "C-2", "Z-9"}), []string{"C-2", "A-1"}},
+		{"string in list", filter("sku", entities.OpIn, "A-2, B-1"), []string{"B-1", "A-2"}},
+		{"like", filter("name", entities.OpLike, "%Phone"), []string{"B-1", "A-1"}},
+		{"like wildcards", filter("name", entities.OpLike, "Beta_%"), []string{"B-2", "B-1"}},
+		{"ilike", filter("name", entities.OpILike, "GAMMA%"), []string{"C-2", "C-1"}},
+		{"starts", filter("name", entities.OpStartsWith, "Alpha"), []string{"A-2", "A-1"}},
+		{"starts literally", filter("name", entities.OpStartsWith, "Beta_"), []string{"B-2"}},
+		{"ends literally", filter("name", entities.OpEndsWith, "100%"), []string{"B-2"}},
+		{"slug", filter("slug", entities.OpStartsWith, "product-C"), []string{"C-2", "C-1"}},
+		{"status eq", filter("status", entities.OpEqual, "published"), []string{"C-1", "B-1", "A-1"}},
+		{"status ne", filter("status", entities.OpNotEqual, "draft"), []string{"C-1", "B-2", "B-1", "A-1"}},
+		{"status in list", filter("status", entities.OpIn, "draft,archived"), []string{"C-2", "B-2", "A-2"}},
+		{"status in array", filter("status", entities.OpIn, []interface{}{"archived"}), []string{"B-2"}},
+		{"category eq", filter("category_id", entities.OpEqual, c.phones.String()), []string{"B-1", "A-1"}},
+		{"category ne skips none", filter("category_id", entities.OpNotEqual, c.phones.String()), []string{"C-2", "C-1", "A-2"}},
+		{"category in skips invalid", filter("category_id", entities.OpIn, []interface{}{c.phones.String(), c.tablets.String(), "nope"}), []string{"B-1", "A-2", "A-1"}},
+		{"category in list", filter("category_id", entities.OpIn, c.watches.String()+","+c.tablets.String()), []string{"C-2", "C-1", "A-2"}},
+		{"brand eq", filter("brand_id", entities.OpEqual, c.brandX.String()), []string{"B-2", "A-1"}},
+		{"brand ne skips none", filter("brand_id", entities.OpNotEqual, c.brandX.String()), []string{"B-1", "A-2"}},
+		{"brand in", filter("brand_id", entities.OpIn, []interface{}{c.brandY.String()}), []string{"B-1", "A-2"}},
+		{"created eq", filter("created_at", entities.OpEqual, at(3*time.Hour)), []string{"B-1"}},
+		{"created ne", filter("created_at", entities.OpNotEqual, at(3*time.Hour)), []string{"C-2", "C-1", "B-2", "A-2", "A-1"}},
+		{"created gt", filter("created_at", entities.OpGreaterThan, at(4*time.Hour)), []string{"C-2", "C-1"}},
+		{"created gte", filter("created_at", entities.OpGreaterThanOrEqual, at(5*time.Hour)), []string{"C-2", "C-1"}},
+		{"created lt", filter("created_at", entities.OpLessThan, at(2*time.Hour)), []string{"A-1"}},
+		{"created lte", filter("created_at", entities.OpLessThanOrEqual, at(2*time.Hour)), []string{"A-2", "A-1"}},
+		{"updated lt", filter("updated_at", entities.OpLessThan, at(2*time.Hour)), []string{"A-1"}},
+		{"combined", []entities.Filter{
+			{Field: "status", Operator: entities.OpEqual, Value: "published"},
+			{Field: "price", Operator: entities.OpGreaterThan, Value: 60.0},
+		}, []string{"B-1", "A-1"}},
+		{"no match", filter("sku", entities.OpEqual, "Z-9"), []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			skus := querySKUs(t, repos, &entities.QueryParams{Filters: tt.filters})
+
+			// Assert
+			assert.Equal(t, tt.want, skus)
+		})
+	}
+}
+
+// testQueryInvalidFilters tests that filters that cannot be applied fail the query
+func testQueryInvalidFilters(t *testing.T, repos Repositories) {
+	seedQueryCatalog(t, repos)
+
+	tests := []struct {
+		name   string
+		filter entities.Filter
+	}{
+		{"unknown field", entities.Filter{Field: "color", Operator: entities.OpEqual, Value: "red"}},
+		{"unsupported operator", entities.Filter{Field: "sku", Operator: entities.OpNotIn, Value: []interface{}{"A-1"}}},
+		{"operator of another type", entities.Filter{Field: "weight", Operator: entities.OpLike, Value: 1.0}},
+		{"value of another type", entities.Filter{Field: "weight", Operator: entities.OpEqual, Value: "heavy"}},
+		{"int in without array", entities.Filter{Field: "weight", Operator: entities.OpIn, Value: "1,2"}},
+		{"invalid UUID", entities.Filter{Field: "category_id", Operator: entities.OpEqual, Value: "nope"}},
+		{"no valid UUID", entities.Filter{Field: "brand_id", Operator: entities.OpIn, Value: "nope, neither"}},
+		{"invalid time", entities.Filter{Field: "created_at", Operator: entities.OpGreaterThan, Value: "yesterday"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			result, err := repos.Products.Query(tenantCtx(), &entities.QueryParams{Filters: []entities.Filter{tt.filter}})
+
+			// Assert
+			assert.Error(t, err)
+			assert.Nil(t, result)
+		})
+	}
+}
+
+// testQuerySort tests sorting by one or more fields, with ties broken by descending ID
+func testQuerySort(t *testing.T, repos Repositories) {
+	seedQueryCatalog(t, repos)
+
+	tests := []struct {
+		name string
+		sort []entities.SortParam
+		want []string
+	}{
+		{"default", nil, []string{"C-2", "C-1", "B-2", "B-1", "A-2", "A-1"}},
+		{"price asc", []entities.SortParam{{Field: "price", Order: entities.SortAsc}}, []string{"C-1", "C-2", "A-1", "B-1", "A-2", "B-2"}},
+		{"weight desc with ties", []entities.SortParam{{Field: "weight", Order: entities.SortDesc}}, []string{"B-2", "A-2", "B-1", "A-1", "C-1", "C-2"}},
+		{"name asc bytewise", []entities.SortParam{{Field: "name", Order: entities.SortAsc}}, []string{"A-1", "A-2", "B-1", "B-2", "C-1", "C-2"}},
+		{"created asc", []entities.SortParam{{Field: "created_at", Order: entities.SortAsc}}, []string{"A-1", "A-2", "B-1", "B-2", "C-1", "C-2"}},
+		{"status then price", []entities.SortParam{
+			{Field: "status", Order: entities.SortAsc},
+			{Field: "price", Order: entities.SortDesc},
+		}, []string{"B-2", "A-2", "C-2", "B-1", "A-1", "C-1"}},
+		{"unknown field", []entities.SortParam{{Field: "color", Order: entities.SortAsc}}, []string{"C-2", "C-1", "B-2", "B-1", "A-2", "A-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			skus := querySKUs(t, repos, &entities.QueryParams{Sort: tt.sort})
+
+			// Assert
+			assert.Equal(t, tt.want, skus)
+		})
+	}
+}
+
+// testQueryPagination tests paging forward through the products with cursors and back to the first page
+func testQueryPagination(t *testing.T, repos Repositories) {
+	// Arrange
+	seedQueryCatalog(t, repos)
+	ctx := tenantCtx()
+	page := func(cursor *string, direction string) *entities.QueryResult {
+		result, err := repos.Products.Query(ctx, &entities.QueryParams{
+			Pagination: &entities.PaginationParams{Cursor: cursor, Limit: 2, Direction: direction},
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	// Act
+	first := page(nil, "forward")
+	second := page(&first.PageInfo.NextCursor, "forward")
+	third := page(&second.PageInfo.NextCursor, "forward")
+	back := page(&second.PageInfo.PreviousCursor, "backward")
+	all, allErr := repos.Products.Query(ctx, &entities.QueryParams{})
+	invalid := "not a cursor"
+	_, invalidErr := repos.Products.Query(ctx, &entities.QueryParams{
+		Pagination: &entities.PaginationParams{Cursor: &invalid, Limit: 2},
+	})
+
+	// Assert
+	assert.Equal(t, []string{"C-2", "C-1"}, skusOf(first.Products))
+	assert.True(t, first.PageInfo.HasNextPage)
+	assert.False(t, first.PageInfo.HasPreviousPage)
+	assert.NotEmpty(t, first.PageInfo.NextCursor)
+	assert.Empty(t, first.PageInfo.PreviousCursor)
+
+	assert.Equal(t, []string{"B-2", "B-1"}, skusOf(second.Products))
+	assert.True(t, second.PageInfo.HasNextPage)
+	assert.True(t, second.PageInfo.HasPreviousPage)
+	assert.NotEmpty(t, second.PageInfo.PreviousCursor)
+
+	assert.Equal(t, []string{"A-2", "A-1"}, skusOf(third.Products))
+	assert.False(t, third.PageInfo.HasNextPage)
+	assert.True(t, third.PageInfo.HasPreviousPage)
+	assert.Empty(t, third.PageInfo.NextCursor)
+
+	assert.Equal(t, []string{"C-2", "C-1"}, skusOf(back.Products))
+	assert.False(t, back.PageInfo.HasPreviousPage)
+
+	require.NoError(t, allErr)
+	assert.Len(t, all.Products, 6)
+	assert.False(t, all.PageInfo.HasNextPage)
+	assert.ErrorContains(t, invalidErr, "invalid cursor")
+}
+
+// testQueryPaginationWithFilter tests that cursors page through the products matching the filters only
+func testQueryPaginationWithFilter(t *testing.T, repos Repositories) {
+	// Arrange
+	seedQueryCatalog(t, repos)
+	params := &entities.QueryParams{
+		Filters:    []entities.Filter{{Field: "status", Operator: entities.OpEqual, Value: "published"}},
+		Pagination: &entities.PaginationParams{Limit: 1, Direction: "forward"},
+	}
+
+	// Act
+	var skus []string
+	for pages := 0; pages < 5; pages++ {
+		result, err := repos.Products.Query(tenantCtx(), params)
+		require.NoError(t, err)
+		skus = append(skus, skusOf(result.Products)...)
+		if !result.PageInfo.HasNextPage {
+			break
+		}
+		params.Pagination.Cursor = &result.PageInfo.NextCursor
+	}
+
+	// Assert
+	assert.Equal(t, []string{"C-1", "B-1", "A-1"}, skus)
+}
@@ -0,0 +1,582 @@
+// Package repositorytest provides the contract tests every implementation of the catalog, product media
+// and user repository ports must pass.
+//
+// The tests describe the behaviour the services rely on: tenant scoping, the domain errors of violated
+// constraints, references that are cleared when their target is deleted, and the filter, sort and cursor
+// semantics of product queries. Running them against the Ent adapters and the in-memory ones keeps the two
+// interchangeable, so that service tests on the in-memory adapters tell how the services behave on a
+// database.
+//
+// Text matching with like, starts and ends is only tested with values whose case matches, since SQLite
+// compares case-insensitively there.
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"example.com/go-yippi/internal/domain/ports"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// Tenant is the tenant the tests work in
+	Tenant = "contract"
+	// OtherTenant is a tenant whose data must stay out of sight of Tenant
+	OtherTenant = "contract-other"
+)
+
+// Repositories are the repositories of one implementation; the catalog repositories share their data, so
+// that products can reference categories and brands and media can reference products
+type Repositories struct {
+	Products   ports.ProductRepository
+	Categories ports.CategoryRepository
+	Brands     ports.BrandRepository
+	Media      ports.ProductMediaRepository
+	Users      ports.UserRepository
+	// NewFile returns the ID of a new file of Tenant for media to reference
+	NewFile func(t *testing.T) uuid.UUID
+}
+
+// Run runs the contract tests against repositories created by newRepos, which is called once per test
+// and must return repositories without data
+func Run(t *testing.T, newRepos func(t *testing.T) Repositories) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repos Repositories)
+	}{
+		{"ProductCreateAndGet", testProductCreateAndGet},
+		{"ProductUpdate", testProductUpdate},
+		{"ProductDelete", testProductDelete},
+		{"ProductNotFound", testProductNotFound},
+		{"ProductDuplicates", testProductDuplicates},
+		{"ProductValidation", testProductValidation},
+		{"ProductMissingReference", testProductMissingReference},
+		{"ProductTenantIsolation", testProductTenantIsolation},
+		{"ProductTenantRequired", testProductTenantRequired},
+		{"ProductCreateBulk", testProductCreateBulk},
+		{"ProductCreateBulkAllOrNone", testProductCreateBulkAllOrNone},
+		{"ProductListByStatus", testProductListByStatus},
+		{"ProductStats", testProductStats},
+		{"ProductImageURLs", testProductImageURLs},
+		{"ProductIDsBySKU", testProductIDsBySKU},
+		{"QueryFilters", testQueryFilters},
+		{"QueryInvalidFilters", testQueryInvalidFilters},
+		{"QuerySort", testQuerySort},
+		{"QueryPagination", testQueryPagination},
+		{"QueryPaginationWithFilter", testQueryPaginationWithFilter},
+		{"CategoryCRUD", testCategoryCRUD},
+		{"CategoryTree", testCategoryTree},
+		{"CategoryConstraints", testCategoryConstraints},
+		{"CategoryDeleteClearsReferences", testCategoryDeleteClearsReferences},
+		{"BrandCRUD", testBrandCRUD},
+		{"BrandConstraints", testBrandConstraints},
+		{"BrandDeleteClearsReferences", testBrandDeleteClearsReferences},
+		{"MediaGallery", testMediaGallery},
+		{"MediaConstraints", testMediaConstraints},
+		{"MediaCreateBulkAllOrNone", testMediaCreateBulkAllOrNone},
+		{"MediaTenantIsolation", testMediaTenantIsolation},
+		{"UserCRUD", testUserCRUD},
+		{"UserConstraints", testUserConstraints},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newRepos(t))
+		})
+	}
+}
+
+// tenantCtx returns a context scoped to Tenant
+func tenantCtx() context.Context {
+	return entities.ContextWithTenant(context.Background(), Tenant)
+}
+
+// otherCtx returns a context scoped to OtherTenant
+func otherCtx() context.Context {
+	return entities.ContextWithTenant(context.Background(), OtherTenant)
+}
+
+// newProduct returns a valid draft product identified by code
+func newProduct(code string) *entities.Product {
+	return &entities.Product{
+		SKU:         "SKU-" + code,
+		Slug:        "product-" + strings.ToLower(code),
+		Name:        "Product " + code,
+		Price:       10,
+		Description: "Description of " + code,
+		Status:      entities.ProductStatusDraft,
+	}
+}
+
+// createCategory creates a category of Tenant, failing the test if it cannot
+func createCategory(t *testing.T, repos Repositories, name string, parentID *uuid.UUID) *entities.Category {
+	t.Helper()
+
+	category := &entities.Category{Name: name, ParentID: parentID}
+	require.NoError(t, repos.Categories.Create(tenantCtx(), category))
+	return category
+}
+
+// createBrand creates a brand of Tenant, failing the test if it cannot
+func createBrand(t *testing.T, repos Repositories, name string) *entities.Brand {
+	t.Helper()
+
+	brand := &entities.Brand{Name: name}
+	require.NoError(t, repos.Brands.Create(tenantCtx(), brand))
+	return brand
+}
+
+// assertValidation asserts that err is a validation error of a single field with the given code
+func assertValidation(t *testing.T, err error, code string) {
+	t.Helper()
+
+	var verr *domainErrors.ValidationError
+	require.True(t, errors.As(err, &verr), "expected a validation error, got %v", err)
+	require.Len(t, verr.Errors, 1)
+	assert.Equal(t, code, verr.Errors[0].Code)
+}
+
+// assertDuplicate asserts that err is a duplicate of a field of resource
+func assertDuplicate(t *testing.T, err error, resource, field string) {
+	t.Helper()
+
+	var derr *domainErrors.DuplicateError
+	require.True(t, errors.As(err, &derr), "expected a duplicate error, got %v", err)
+	assert.Equal(t, resource, derr.Resource)
+	assert.Equal(t, field, derr.Field)
+}
+
+// assertNotFound asserts that err is a not-found error of resource
+func assertNotFound(t *testing.T, err error, resource string) {
+	t.Helper()
+
+	var nerr *domainErrors.NotFoundError
+	require.True(t, errors.As(err, &nerr), "expected a not-found error, got %v", err)
+	assert.Equal(t, resource, nerr.Resource)
+}
+
+// testProductCreateAndGet tests that a created product can be read back by ID, SKU and slug
+func testProductCreateAndGet(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := tenantCtx()
+	category := createCategory(t, repos, "Phones", nil)
+	brand := createBrand(t, repos, "Acme")
+	prod := newProduct("1")
+	prod.Weight, prod.Length, prod.Width, prod.Height = 200, 15, 7, 1
+	prod.ImageURLs = []string{"/files/download?bucket=images&file_name=a.png"}
+	prod.Status = entities.ProductStatusPublished
+	prod.CategoryID = &category.ID
+	prod.BrandID = &brand.ID
+	before := time.Now().Add(-time.Minute)
+
+	// Act
+	err := repos.Products.Create(ctx, prod)
+	byID, byIDErr := repos.Products.GetByID(ctx, prod.ID)
+	bySKU, bySKUErr := repos.Products.GetBySKU(ctx, "SKU-1")
+	bySlug, bySlugErr := repos.Products.GetBySlug(ctx, "product-1")
+
+	// Assert
+	require.NoError(t, err)
+	assert.NotZero(t, prod.ID)
+	assert.True(t, prod.CreatedAt.After(before))
+	require.NoError(t, byIDErr)
+	require.NoError(t, bySKUErr)
+	require.NoError(t, bySlugErr)
+	for _, found := range []*entities.Product{byID, bySKU, bySlug} {
+		assert.Equal(t, prod.ID, found.ID)
+		assert.Equal(t, "SKU-1", found.SKU)
+		assert.Equal(t, "product-1", found.Slug)
+		assert.Equal(t, "Product 1", found.Name)
+		assert.Equal(t, 10.0, found.Price)
+		assert.Equal(t, "Description of 1", found.Description)
+		assert.Equal(t, []int{200, 15, 7, 1}, []int{found.Weight, found.Length, found.Width, found.Height})
+		assert.Equal(t, prod.ImageURLs, found.ImageURLs)
+		assert.Equal(t, entities.ProductStatusPublished, found.Status)
+		assert.Equal(t, &category.ID, found.CategoryID)
+		assert.Equal(t, &brand.ID, found.BrandID)
+		assert.True(t, prod.CreatedAt.Equal(found.CreatedAt))
+	}
+}
+
+// testProductUpdate tests that an update replaces the fields of a product, keeping its images if none are given
+func testProductUpdate(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := tenantCtx()
+	category := createCategory(t, repos, "Phones", nil)
+	prod := newProduct("1")
+	prod.ImageURLs = []string{"a.png"}
+	prod.CategoryID = &category.ID
+	require.NoError(t, repos.Products.Create(ctx, prod))
+	created, err := repos.Products.GetByID(ctx, prod.ID)
+	require.NoError(t, err)
+
+	// Act
+	update := newProduct("2")
+	update.ID = prod.ID
+	update.Price = 12.5
+	update.Status = entities.ProductStatusArchived
+	err = repos.Products.Update(ctx, update)
+	found, getErr := repos.Products.GetByID(ctx, prod.ID)
+
+	// Assert
+	require.NoError(t, err)
+	require.NoError(t, getErr)
+	assert.Equal(t, "SKU-2", found.SKU)
+	assert.Equal(t, "product-2", found.Slug)
+	assert.Equal(t, 12.5, found.Price)
+	assert.Equal(t, entities.ProductStatusArchived, found.Status)
+	assert.Equal(t, []string{"a.png"}, found.ImageURLs)
+	assert.Nil(t, found.CategoryID)
+	assert.True(t, created.CreatedAt.Equal(found.CreatedAt))
+	assert.False(t, found.UpdatedAt.Before(created.UpdatedAt))
+}
+
+// testProductDelete tests that a deleted product is gone
+func testProductDelete(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := tenantCtx()
+	prod := newProduct("1")
+	require.NoError(t, repos.Products.Create(ctx, prod))
+
+	// Act
+	err := repos.Products.Delete(ctx, prod.ID)
+	_, getErr := repos.Products.GetByID(ctx, prod.ID)
+	againErr := repos.Products.Delete(ctx, prod.ID)
+
+	// Assert
+	require.NoError(t, err)
+	assertNotFound(t, getErr, "Product")
+	assertNotFound(t, againErr, "Product")
+}
+
+// testProductNotFound tests that missing products are reported as not found
+func testProductNotFound(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := tenantCtx()
+	missing := newProduct("1")
+	missing.ID = 4242
+
+	// Act
+	_, byIDErr := repos.Products.GetByID(ctx, 4242)
+	_, bySKUErr := repos.Products.GetBySKU(ctx, "SKU-1")
+	_, bySlugErr := repos.Products.GetBySlug(ctx, "product-1")
+	updateErr := repos.Products.Update(ctx, missing)
+	deleteErr := repos.Products.Delete(ctx, 4242)
+
+	// Assert
+	for _, err := range []error{byIDErr, bySKUErr, bySlugErr, updateErr, deleteErr} {
+		assertNotFound(t, err, "Product")
+	}
+}
+
+// testProductDuplicates tests that SKUs and slugs are unique within a tenant only
+func testProductDuplicates(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := tenantCtx()
+	require.NoError(t, repos.Products.Create(ctx, newProduct("1")))
+	second := newProduct("2")
+	require.NoError(t, repos.Products.Create(ctx, second))
+
+	sameSKU := newProduct("3")
+	sameSKU.SKU = "SKU-1"
+	sameSlug := newProduct("4")
+	sameSlug.Slug = "product-1"
+	takenSKU := newProduct("2")
+	takenSKU.ID = second.ID
+	takenSKU.SKU = "SKU-1"
+	unchanged := newProduct("2")
+	unchanged.ID = second.ID
+
+	// Act
+	skuErr := repos.Products.Create(ctx, sameSKU)
+	slugErr := repos.Products.Create(ctx, sameSlug)
+	updateErr := repos.Products.Update(ctx, takenSKU)
+	unchangedErr := repos.Products.Update(ctx, unchanged)
+	otherErr := repos.Products.Create(otherCtx(), newProduct("1"))
+
+	// Assert
+	assertDuplicate(t, skuErr, "Product", "sku")
+	assertDuplicate(t, slugErr, "Product", "slug")
+	assertDuplicate(t, updateErr, "Product", "sku")
+	assert.ErrorIs(t, skuErr, domainErrors.ErrDuplicateEntry)
+	assert.NoError(t, unchangedErr)
+	assert.NoError(t, otherErr)
+}
+
+// testProductValidation tests that invalid field values are rejected with the field at fault
+func testProductValidation(t *testing.T, repos Repositories) {
+	tests := []struct {
+		name   string
+		modify func(p *entities.Product)
+		code   string
+	}{
+		{"empty SKU", func(p *entities.Product) { p.SKU = "" }, "sku.invalid"},
+		{"empty slug", func(p *entities.Product) { p.Slug = "" }, "slug.invalid"},
+		{"empty name", func(p *entities.Product) { p.Name = "" }, "name.invalid"},
+		{"zero price", func(p *entities.Product) { p.Price = 0 }, "price.invalid"},
+		{"negative weight", func(p *entities.Product) { p.Weight = -1 }, "weight.invalid"},
+		{"negative height", func(p *entities.Product) { p.Height = -1 }, "height.invalid"},
+		{"unknown status", func(p *entities.Product) { p.Status = "sold" }, "status.invalid"},
+	}
+
+	ctx := tenantCtx()
+	existing := newProduct("0")
+	require.NoError(t, repos.Products.Create(ctx, existing))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			created := newProduct("1")
+			tt.modify(created)
+			updated := newProduct("0")
+			updated.ID = existing.ID
+			tt.modify(updated)
+
+			// Act
+			createErr := repos.Products.Create(ctx, created)
+			updateErr := repos.Products.Update(ctx, updated)
+
+			// Assert
+			assertValidation(t, createErr, tt.code)
+			assertValidation(t, updateErr, tt.code)
+			assert.ErrorIs(t, createErr, domainErrors.ErrInvalidInput)
+		})
+	}
+}
+
+// testProductMissingReference tests that products cannot reference categories or brands that do not exist
+func testProductMissingReference(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := tenantCtx()
+	missing := uuid.New()
+	withCategory := newProduct("1")
+	withCategory.CategoryID = &missing
+	withBrand := newProduct("2")
+	withBrand.BrandID = &missing
+	existing := newProduct("3")
+	require.NoError(t, repos.Products.Create(ctx, existing))
+	existing.CategoryID = &missing
+
+	// Act
+	categoryErr := repos.Products.Create(ctx, withCategory)
+	brandErr := repos.Products.Create(ctx, withBrand)
+	updateErr := repos.Products.Update(ctx, existing)
+
+	// Assert
+	assertValidation(t, categoryErr, "category_id.not_found")
+	assertValidation(t, brandErr, "brand_id.not_found")
+	assertValidation(t, updateErr, "category_id.not_found")
+	_, err := repos.Products.GetBySKU(ctx, "SKU-1")
+	assertNotFound(t, err, "Product")
+}
+
+// testProductTenantIsolation tests that products are only visible to and writable by their tenant
+func testProductTenantIsolation(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := tenantCtx()
+	prod := newProduct("1")
+	require.NoError(t, repos.Products.Create(ctx, prod))
+	update := newProduct("1")
+	update.ID = prod.ID
+	update.Name = "Taken over"
+
+	// Act
+	_, getErr := repos.Products.GetByID(otherCtx(), prod.ID)
+	_, bySKUErr := repos.Products.GetBySKU(otherCtx(), "SKU-1")
+	list, listErr := repos.Products.List(otherCtx())
+	result, queryErr := repos.Products.Query(otherCtx(), &entities.QueryParams{})
+	updateErr := repos.Products.Update(otherCtx(), update)
+	deleteErr := repos.Products.Delete(otherCtx(), prod.ID)
+
+	// Assert
+	assertNotFound(t, getErr, "Product")
+	assertNotFound(t, bySKUErr, "Product")
+	require.NoError(t, listErr)
+	assert.Empty(t, list)
+	require.NoError(t, queryErr)
+	assert.Empty(t, result.Products)
+	assertNotFound(t, updateErr, "Product")
+	assertNotFound(t, deleteErr, "Product")
+	found, err := repos.Products.GetByID(ctx, prod.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Product 1", found.Name)
+}
+
+// testProductTenantRequired tests that products cannot be read or written without a tenant
+func testProductTenantRequired(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := context.Background()
+
+	// Act
+	createErr := repos.Products.Create(ctx, newProduct("1"))
+	bulkErr := repos.Products.CreateBulk(ctx, []*entities.Product{newProduct("2")})
+	_, getErr := repos.Products.GetByID(ctx, 1)
+	_, listErr := repos.Products.List(ctx)
+	_, queryErr := repos.Products.Query(ctx, &entities.QueryParams{})
+	_, statsErr := repos.Products.Stats(ctx)
+
+	// Assert
+	for _, err := range []error{createErr, bulkErr, getErr, listErr, queryErr, statsErr} {
+		assert.ErrorIs(t, err, domainErrors.ErrTenantRequired)
+	}
+}
+
+// testProductCreateBulk tests that bulk creates keep the timestamps they are given
+func testProductCreateBulk(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := tenantCtx()
+	createdAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	first := newProduct("1")
+	first.CreatedAt = createdAt
+	first.UpdatedAt = createdAt.Add(time.Hour)
+	second := newProduct("2")
+
+	// Act
+	err := repos.Products.CreateBulk(ctx, []*entities.Product{first, second})
+	emptyErr := repos.Products.CreateBulk(ctx, nil)
+
+	// Assert
+	require.NoError(t, err)
+	assert.NoError(t, emptyErr)
+	found, err := repos.Products.GetBySKU(ctx, "SKU-1")
+	require.NoError(t, err)
+	assert.True(t, createdAt.Equal(found.CreatedAt))
+	assert.True(t, createdAt.Add(time.Hour).Equal(found.UpdatedAt))
+	found, err = repos.Products.GetBySKU(ctx, "SKU-2")
+	require.NoError(t, err)
+	assert.False(t, found.CreatedAt.IsZero())
+	list, err := repos.Products.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, list, 2)
+}
+
+// testProductCreateBulkAllOrNone tests that a bulk create with a bad product creates none of them
+func testProductCreateBulkAllOrNone(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := tenantCtx()
+	require.NoError(t, repos.Products.Create(ctx, newProduct("0")))
+	missing := uuid.New()
+	withMissingCategory := newProduct("3")
+	withMissingCategory.CategoryID = &missing
+	invalid := newProduct("3")
+	invalid.Price = -1
+	batchDuplicate := newProduct("3")
+	batchDuplicate.SKU = "SKU-1"
+	existingDuplicate := newProduct("3")
+	existingDuplicate.SKU = "SKU-0"
+
+	// Act
+	batchDuplicateErr := repos.Products.CreateBulk(ctx, []*entities.Product{newProduct("1"), newProduct("2"), batchDuplicate})
+	existingDuplicateErr := repos.Products.CreateBulk(ctx, []*entities.Product{newProduct("1"), existingDuplicate})
+	referenceErr := repos.Products.CreateBulk(ctx, []*entities.Product{newProduct("1"), withMissingCategory})
+	invalidErr := repos.Products.CreateBulk(ctx, []*entities.Product{newProduct("1"), invalid})
+
+	// Assert
+	assertDuplicate(t, batchDuplicateErr, "Product", "sku")
+	assertDuplicate(t, existingDuplicateErr, "Product", "sku")
+	var verr *domainErrors.ValidationError
+	require.True(t, errors.As(referenceErr, &verr), "expected a validation error, got %v", referenceErr)
+	assert.True(t, strings.HasSuffix(verr.Errors[0].Code, ".not_found"), verr.Errors[0].Code)
+	assertValidation(t, invalidErr, "price.invalid")
+	list, err := repos.Products.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "SKU-0", list[0].SKU)
+}
+
+// testProductListByStatus tests that products are listed by status
+func testProductListByStatus(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := tenantCtx()
+	for i, status := range []entities.ProductStatus{entities.ProductStatusDraft, entities.ProductStatusPublished, entities.ProductStatusPublished} {
+		prod := newProduct(string(rune('1' + i)))
+		prod.Status = status
+		require.NoError(t, repos.Products.Create(ctx, prod))
+	}
+
+	// Act
+	published, err := repos.Products.ListByStatus(ctx, entities.ProductStatusPublished)
+	archived, archivedErr := repos.Products.ListByStatus(ctx, entities.ProductStatusArchived)
+
+	// Assert
+	require.NoError(t, err)
+	skus := make([]string, len(published))
+	for i, p := range published {
+		skus[i] = p.SKU
+	}
+	assert.ElementsMatch(t, []string{"SKU-2", "SKU-3"}, skus)
+	require.NoError(t, archivedErr)
+	assert.Empty(t, archived)
+}
+
+// testProductImageURLs tests that the image URLs of all products of the tenant are returned
+func testProductImageURLs(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := tenantCtx()
+	withImages := newProduct("1")
+	withImages.ImageURLs = []string{"/files/download?file_name=a.png", "https://cdn.example.com/b.png"}
+	require.NoError(t, repos.Products.Create(ctx, withImages))
+	require.NoError(t, repos.Products.Create(ctx, newProduct("2")))
+	other := newProduct("3")
+	other.ImageURLs = []string{"/files/download?file_name=other.png"}
+	require.NoError(t, repos.Products.Create(otherCtx(), other))
+
+	// Act
+	urls, err := repos.Products.ImageURLs(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.ElementsMatch(t, withImages.ImageURLs, urls)
+}
+
+// testProductIDsBySKU tests that the IDs of the products of the tenant are keyed by SKU, leaving out
+// unknown SKUs
+func testProductIDsBySKU(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := tenantCtx()
+	first, second := newProduct("1"), newProduct("2")
+	require.NoError(t, repos.Products.Create(ctx, first))
+	require.NoError(t, repos.Products.Create(ctx, second))
+	require.NoError(t, repos.Products.Create(otherCtx(), newProduct("3")))
+
+	// Act
+	ids, err := repos.Products.IDsBySKU(ctx, []string{"SKU-1", "SKU-2", "SKU-3", "SKU-4"})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"SKU-1": first.ID, "SKU-2": second.ID}, ids)
+}
+
+// testProductStats tests that products are counted by status, category and brand, with uuid.Nil for none
+func testProductStats(t *testing.T, repos Repositories) {
+	// Arrange
+	ctx := tenantCtx()
+	category := createCategory(t, repos, "Phones", nil)
+	brand := createBrand(t, repos, "Acme")
+	first := newProduct("1")
+	first.CategoryID = &category.ID
+	first.BrandID = &brand.ID
+	second := newProduct("2")
+	second.CategoryID = &category.ID
+	second.Status = entities.ProductStatusPublished
+	require.NoError(t, repos.Products.CreateBulk(ctx, []*entities.Product{first, second, newProduct("3")}))
+	require.NoError(t, repos.Products.Create(otherCtx(), newProduct("1")))
+
+	// Act
+	stats, err := repos.Products.Stats(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Total)
+	assert.Equal(t, map[entities.ProductStatus]int{entities.ProductStatusDraft: 2, entities.ProductStatusPublished: 1}, stats.ByStatus)
+	assert.Equal(t, map[uuid.UUID]int{category.ID: 2, uuid.Nil: 1}, stats.ByCategory)
+	assert.Equal(t, map[uuid.UUID]int{brand.ID: 1, uuid.Nil: 2}, stats.ByBrand)
+}
@@ -0,0 +1,126 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"example.com/go-yippi/internal/adapters/persistence/db/ent"
+	"example.com/go-yippi/internal/adapters/persistence/db/ent/resumableupload"
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/google/uuid"
+)
+
+// ResumableUploadRepositoryImpl implements the ResumableUploadRepository interface using Ent
+type ResumableUploadRepositoryImpl struct {
+	client *ent.Client
+}
+
+func NewResumableUploadRepository(client *ent.Client) *ResumableUploadRepositoryImpl {
+	return &ResumableUploadRepositoryImpl{client: client}
+}
+
+func (r *ResumableUploadRepositoryImpl) Create(ctx context.Context, u *entities.ResumableUpload) error {
+	created, err := r.client.ResumableUpload.
+		Create().
+		SetBucket(u.Bucket).
+		SetFileName(u.FileName).
+		SetContentType(u.ContentType).
+		SetSha256(u.SHA256).
+		SetUploadLength(u.Length).
+		SetMetadata(u.Metadata).
+		SetExpiresAt(u.ExpiresAt).
+		Save(ctx)
+	if err != nil {
+		return mapWriteError("Upload", err, nil)
+	}
+
+	u.ID = created.ID
+	u.Offset = created.UploadOffset
+	u.CreatedAt = created.CreatedAt
+	u.UpdatedAt = created.UpdatedAt
+	return nil
+}
+
+func (r *ResumableUploadRepositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*entities.ResumableUpload, error) {
+	found, err := r.client.ResumableUpload.Get(ctx, id)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, domainErrors.NewNotFoundError("Upload", id)
+		}
+		return nil, err
+	}
+
+	return r.toEntity(found), nil
+}
+
+func (r *ResumableUploadRepositoryImpl) Delete(ctx context.Context, id uuid.UUID) error {
+	err := r.client.ResumableUpload.DeleteOneID(id).Exec(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return domainErrors.NewNotFoundError("Upload", id)
+		}
+		return err
+	}
+	return nil
+}
+
+// AppendPart moves the offset of an upload past a part, provided it is still at the part's offset,
+// so that of two chunks sent for the same offset only one is recorded
+func (r *ResumableUploadRepositoryImpl) AppendPart(ctx context.Context, id uuid.UUID, part entities.UploadPart, expiresAt time.Time) error {
+	updated, err := r.client.ResumableUpload.
+		Update().
+		Where(resumableupload.ID(id), resumableupload.UploadOffset(part.Offset)).
+		SetUploadOffset(part.Offset + part.Size).
+		AppendParts([]entities.UploadPart{part}).
+		SetExpiresAt(expiresAt).
+		Save(ctx)
+	if err != nil {
+		return err
+	}
+	if updated > 0 {
+		return nil
+	}
+
+	// Either the upload is gone or it moved on
+	current, err := r.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return domainErrors.NewUploadOffsetError(current.Offset)
+}
+
+// ListExpired returns the uploads that expired before the given time, oldest first
+func (r *ResumableUploadRepositoryImpl) ListExpired(ctx context.Context, before time.Time) ([]*entities.ResumableUpload, error) {
+	found, err := r.client.ResumableUpload.
+		Query().
+		Where(resumableupload.ExpiresAtLT(before)).
+		Order(ent.Asc(resumableupload.FieldExpiresAt)).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	uploads := make([]*entities.ResumableUpload, len(found))
+	for i, u := range found {
+		uploads[i] = r.toEntity(u)
+	}
+	return uploads, nil
+}
+
+func (r *ResumableUploadRepositoryImpl) toEntity(u *ent.ResumableUpload) *entities.ResumableUpload {
+	return &entities.ResumableUpload{
+		ID:          u.ID,
+		Bucket:      u.Bucket,
+		FileName:    u.FileName,
+		ContentType: u.ContentType,
+		SHA256:      u.Sha256,
+		Length:      u.UploadLength,
+		Offset:      u.UploadOffset,
+		Metadata:    u.Metadata,
+		Parts:       u.Parts,
+		ExpiresAt:   u.ExpiresAt,
+		CreatedAt:   u.CreatedAt,
+		UpdatedAt:   u.UpdatedAt,
+	}
+}
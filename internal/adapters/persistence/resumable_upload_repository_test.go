@@ -0,0 +1,72 @@
+package persistence
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestResumableUploadRepository_AppendPart tests that parts are only appended at the upload's offset
+func TestResumableUploadRepository_AppendPart(t *testing.T) {
+	// Arrange
+	repo := NewResumableUploadRepository(newTestClient(t))
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	upload := &entities.ResumableUpload{
+		Bucket:    "uploads",
+		FileName:  "videos/intro.mp4",
+		Length:    10,
+		Metadata:  map[string]string{"filename": "videos/intro.mp4"},
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	require.NoError(t, repo.Create(ctx, upload))
+	expiresAt := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+
+	// Act
+	err := repo.AppendPart(ctx, upload.ID, entities.UploadPart{Name: "a", Offset: 0, Size: 4}, expiresAt)
+	staleErr := repo.AppendPart(ctx, upload.ID, entities.UploadPart{Name: "b", Offset: 0, Size: 4}, expiresAt)
+	nextErr := repo.AppendPart(ctx, upload.ID, entities.UploadPart{Name: "c", Offset: 4, Size: 2}, expiresAt)
+	found, getErr := repo.GetByID(ctx, upload.ID)
+
+	// Assert
+	require.NoError(t, err)
+	var offsetErr *domainErrors.UploadOffsetError
+	require.ErrorAs(t, staleErr, &offsetErr)
+	assert.Equal(t, int64(4), offsetErr.Offset)
+	require.NoError(t, nextErr)
+	require.NoError(t, getErr)
+	assert.Equal(t, int64(6), found.Offset)
+	assert.Equal(t, []entities.UploadPart{{Name: "a", Offset: 0, Size: 4}, {Name: "c", Offset: 4, Size: 2}}, found.Parts)
+	assert.True(t, found.ExpiresAt.Equal(expiresAt))
+	assert.Equal(t, upload.Metadata, found.Metadata)
+}
+
+// TestResumableUploadRepository_ListExpired tests that only the tenant's expired uploads are listed
+func TestResumableUploadRepository_ListExpired(t *testing.T) {
+	// Arrange
+	repo := NewResumableUploadRepository(newTestClient(t))
+	tenantA := entities.ContextWithTenant(context.Background(), "tenant-a")
+	tenantB := entities.ContextWithTenant(context.Background(), "tenant-b")
+	now := time.Now()
+
+	expired := &entities.ResumableUpload{Bucket: "uploads", FileName: "old.bin", Length: 1, ExpiresAt: now.Add(-time.Minute)}
+	current := &entities.ResumableUpload{Bucket: "uploads", FileName: "new.bin", Length: 1, ExpiresAt: now.Add(time.Minute)}
+	otherTenant := &entities.ResumableUpload{Bucket: "uploads", FileName: "old.bin", Length: 1, ExpiresAt: now.Add(-time.Minute)}
+	require.NoError(t, repo.Create(tenantA, expired))
+	require.NoError(t, repo.Create(tenantA, current))
+	require.NoError(t, repo.Create(tenantB, otherTenant))
+
+	// Act
+	found, err := repo.ListExpired(tenantA, now)
+	_, otherErr := repo.GetByID(tenantA, otherTenant.ID)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, expired.ID, found[0].ID)
+	assert.ErrorIs(t, otherErr, domainErrors.ErrNotFound)
+}
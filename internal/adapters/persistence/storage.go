@@ -0,0 +1,124 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"example.com/go-yippi/internal/adapters/persistence/db/ent"
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"example.com/go-yippi/internal/domain/ports"
+	"example.com/go-yippi/internal/infrastructure/config"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// errPresignNotSupported is returned by storage backends that clients cannot reach directly
+var errPresignNotSupported = fmt.Errorf("direct uploads and downloads are %w by this storage backend; transfer files through the API", domainErrors.ErrNotSupported)
+
+// downloadURL returns a relative URL for a file that will be proxied through the API, so that all
+// downloads go through the service instead of directly to storage
+func downloadURL(bucket, fileName string) string {
+	return fmt.Sprintf("/files/download?bucket=%s&file_name=%s", bucket, fileName)
+}
+
+// readCloser reads from one source and closes another, e.g. a range of an open file
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// composeByCopy composes a file by streaming its parts through the repository, for backends that keep
+// files locally or cannot compose the parts on the server
+func composeByCopy(ctx context.Context, repo ports.StorageRepository, bucket, fileName string, parts []entities.UploadPart, contentType string) (*entities.FileMetadata, error) {
+	var size int64
+	for _, part := range parts {
+		size += part.Size
+	}
+
+	content := &partsReader{ctx: ctx, repo: repo, bucket: bucket, parts: parts}
+	defer content.Close()
+	return repo.Store(ctx, bucket, fileName, content, size, contentType)
+}
+
+// partsReader reads parts in order, opening each part as it is reached
+type partsReader struct {
+	ctx     context.Context
+	repo    ports.StorageRepository
+	bucket  string
+	parts   []entities.UploadPart
+	current io.ReadCloser
+}
+
+func (r *partsReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.parts) == 0 {
+				return 0, io.EOF
+			}
+			reader, _, _, err := r.repo.GetFile(r.ctx, r.bucket, r.parts[0].Name, nil)
+			if err != nil {
+				return 0, err
+			}
+			r.current, r.parts = reader, r.parts[1:]
+		}
+
+		n, err := r.current.Read(p)
+		if errors.Is(err, io.EOF) {
+			r.current.Close()
+			r.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+// Close closes the part being read, if any
+func (r *partsReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+	return r.current.Close()
+}
+
+// OpenStorage creates the storage repository of the backend selected by STORAGE_BACKEND; client is used by
+// the database backend
+func OpenStorage(cfg *config.Config, client *ent.Client) (ports.StorageRepository, error) {
+	switch cfg.Storage.Backend {
+	case "minio":
+		minioClient, err := minio.New(cfg.MinIO.Endpoint, &minio.Options{
+			Creds:  credentials.NewStaticV4(cfg.MinIO.AccessKeyID, cfg.MinIO.SecretAccessKey, ""),
+			Secure: cfg.MinIO.UseSSL,
+			Region: cfg.MinIO.Region,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize MinIO client: %w", err)
+		}
+
+		// Presigned URLs are signed for the host clients connect to
+		var presignClient *minio.Client
+		if cfg.MinIO.PublicEndpoint != "" {
+			presignClient, err = minio.New(cfg.MinIO.PublicEndpoint, &minio.Options{
+				Creds:  credentials.NewStaticV4(cfg.MinIO.AccessKeyID, cfg.MinIO.SecretAccessKey, ""),
+				Secure: cfg.MinIO.UseSSL,
+				Region: cfg.MinIO.Region,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to initialize MinIO presign client: %w", err)
+			}
+		}
+
+		return NewMinIOStorageRepository(minioClient, presignClient, cfg.MinIO.Endpoint, cfg.MinIO.UseSSL), nil
+	case "filesystem":
+		return NewFilesystemStorageRepository(cfg.Storage.Path)
+	case "database":
+		return NewDatabaseStorageRepository(client), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q: expected minio, filesystem or database", cfg.Storage.Backend)
+	}
+}
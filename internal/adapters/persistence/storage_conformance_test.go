@@ -0,0 +1,34 @@
+package persistence
+
+import (
+	"testing"
+
+	"example.com/go-yippi/internal/adapters/persistence/s3test"
+	"example.com/go-yippi/internal/adapters/persistence/storagetest"
+	"example.com/go-yippi/internal/domain/ports"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStorageConformance runs the storage conformance tests against every storage backend
+func TestStorageConformance(t *testing.T) {
+	t.Run("MinIO", func(t *testing.T) {
+		storagetest.Run(t, func(t *testing.T) ports.StorageRepository {
+			server := s3test.NewServer(t)
+			return NewMinIOStorageRepository(server.Client(t), nil, server.Endpoint, false)
+		})
+	})
+
+	t.Run("Filesystem", func(t *testing.T) {
+		storagetest.Run(t, func(t *testing.T) ports.StorageRepository {
+			repo, err := NewFilesystemStorageRepository(t.TempDir())
+			require.NoError(t, err)
+			return repo
+		})
+	})
+
+	t.Run("Database", func(t *testing.T) {
+		storagetest.Run(t, func(t *testing.T) ports.StorageRepository {
+			return NewDatabaseStorageRepository(newTestClient(t))
+		})
+	})
+}
@@ -0,0 +1,339 @@
+// Package storagetest provides the conformance tests every ports.StorageRepository adapter must pass.
+//
+// The tests describe the behaviour the storage and image services rely on, so that backends can be
+// swapped through configuration without the services noticing.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"example.com/go-yippi/internal/domain/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Bucket is the bucket the tests use; it does not exist until the tests ensure it
+const Bucket = "conformance"
+
+// helloSHA256 is the hex-encoded SHA-256 of "hello"
+const helloSHA256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+// Run runs the conformance tests against repositories created by newRepo, which is called once per test
+func Run(t *testing.T, newRepo func(t *testing.T) ports.StorageRepository) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repo ports.StorageRepository)
+	}{
+		{"StoreAndGet", testStoreAndGet},
+		{"GetRange", testGetRange},
+		{"Missing", testMissing},
+		{"Overwrite", testOverwrite},
+		{"Remove", testRemove},
+		{"RemovePrefix", testRemovePrefix},
+		{"Copy", testCopy},
+		{"Compose", testCompose},
+		{"List", testList},
+		{"EnsureBucket", testEnsureBucket},
+		{"GetURL", testGetURL},
+		{"Presign", testPresign},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newRepo(t)
+			require.NoError(t, repo.EnsureBucket(context.Background(), Bucket))
+			tt.test(t, repo)
+		})
+	}
+}
+
+// store stores a text file, failing the test if it cannot
+func store(t *testing.T, repo ports.StorageRepository, fileName, content string) {
+	t.Helper()
+
+	_, err := repo.Store(context.Background(), Bucket, fileName, strings.NewReader(content), int64(len(content)), "text/plain")
+	require.NoError(t, err)
+}
+
+// read reads a file, or a range of it, failing the test if it cannot
+func read(t *testing.T, repo ports.StorageRepository, fileName string, rng *entities.ByteRange) (string, int64, string) {
+	t.Helper()
+
+	reader, size, contentType, err := repo.GetFile(context.Background(), Bucket, fileName, rng)
+	require.NoError(t, err)
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return string(content), size, contentType
+}
+
+// testStoreAndGet tests the round trip of a file and its metadata
+func testStoreAndGet(t *testing.T, repo ports.StorageRepository) {
+	// Arrange
+	ctx := context.Background()
+	before := time.Now().Add(-time.Minute)
+
+	// Act
+	stored, err := repo.Store(ctx, Bucket, "tenants/a/docs/hello.txt", strings.NewReader("hello"), 5, "text/plain")
+	require.NoError(t, err)
+	stat, statErr := repo.Stat(ctx, Bucket, "tenants/a/docs/hello.txt")
+	content, size, contentType := read(t, repo, "tenants/a/docs/hello.txt", nil)
+
+	// Assert
+	assert.Equal(t, Bucket, stored.Bucket)
+	assert.Equal(t, "tenants/a/docs/hello.txt", stored.Key)
+	assert.Equal(t, int64(5), stored.Size)
+	assert.Equal(t, "text/plain", stored.ContentType)
+	require.NoError(t, statErr)
+	assert.Equal(t, int64(5), stat.Size)
+	assert.Equal(t, "text/plain", stat.ContentType)
+	assert.True(t, stat.UploadedAt.After(before))
+	// Backends that cannot tell the checksum leave it empty
+	if stat.SHA256 != "" {
+		assert.Equal(t, helloSHA256, stat.SHA256)
+	}
+	assert.Equal(t, "hello", content)
+	assert.Equal(t, int64(5), size)
+	assert.Equal(t, "text/plain", contentType)
+}
+
+// testGetRange tests that only the requested range of a file is returned
+func testGetRange(t *testing.T, repo ports.StorageRepository) {
+	// Arrange
+	store(t, repo, "digits.txt", "0123456789")
+
+	// Act
+	middle, middleSize, _ := read(t, repo, "digits.txt", &entities.ByteRange{Start: 2, End: 5})
+	last, lastSize, _ := read(t, repo, "digits.txt", &entities.ByteRange{Start: 9, End: 9})
+
+	// Assert
+	assert.Equal(t, "2345", middle)
+	assert.Equal(t, int64(4), middleSize)
+	assert.Equal(t, "9", last)
+	assert.Equal(t, int64(1), lastSize)
+}
+
+// testMissing tests that missing files are reported as not found
+func testMissing(t *testing.T, repo ports.StorageRepository) {
+	// Arrange
+	ctx := context.Background()
+
+	// Act
+	_, statErr := repo.Stat(ctx, Bucket, "missing.txt")
+	_, _, _, getErr := repo.GetFile(ctx, Bucket, "missing.txt", nil)
+
+	// Assert
+	assert.ErrorIs(t, statErr, domainErrors.ErrNotFound)
+	assert.ErrorIs(t, getErr, domainErrors.ErrNotFound)
+}
+
+// testOverwrite tests that storing a file again replaces its content and metadata
+func testOverwrite(t *testing.T, repo ports.StorageRepository) {
+	// Arrange
+	ctx := context.Background()
+	store(t, repo, "note.txt", "first version")
+
+	// Act
+	_, err := repo.Store(ctx, Bucket, "note.txt", strings.NewReader(`{"v":2}`), 7, "application/json")
+	require.NoError(t, err)
+	stat, statErr := repo.Stat(ctx, Bucket, "note.txt")
+	content, _, contentType := read(t, repo, "note.txt", nil)
+
+	// Assert
+	require.NoError(t, statErr)
+	assert.Equal(t, int64(7), stat.Size)
+	assert.Equal(t, "application/json", stat.ContentType)
+	assert.Equal(t, `{"v":2}`, content)
+	assert.Equal(t, "application/json", contentType)
+}
+
+// testRemove tests that removed files are gone and that removing a missing file succeeds
+func testRemove(t *testing.T, repo ports.StorageRepository) {
+	// Arrange
+	ctx := context.Background()
+	store(t, repo, "dir/a.txt", "a")
+	store(t, repo, "dir/b.txt", "b")
+
+	// Act
+	err := repo.Remove(ctx, Bucket, "dir/a.txt")
+	missingErr := repo.Remove(ctx, Bucket, "dir/a.txt")
+	_, statErr := repo.Stat(ctx, Bucket, "dir/a.txt")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, missingErr)
+	assert.ErrorIs(t, statErr, domainErrors.ErrNotFound)
+	content, _, _ := read(t, repo, "dir/b.txt", nil)
+	assert.Equal(t, "b", content)
+}
+
+// testRemovePrefix tests that only the files under a prefix are removed
+func testRemovePrefix(t *testing.T, repo ports.StorageRepository) {
+	// Arrange
+	ctx := context.Background()
+	store(t, repo, "variants/t/1/thumb.jpg", "1")
+	store(t, repo, "variants/t/1/large/hero.jpg", "2")
+	store(t, repo, "variants/t/10/thumb.jpg", "3")
+	store(t, repo, "tenants/t/photo.jpg", "4")
+
+	// Act
+	err := repo.RemovePrefix(ctx, Bucket, "variants/t/1/")
+	emptyErr := repo.RemovePrefix(ctx, Bucket, "variants/none/")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, emptyErr)
+	for _, removed := range []string{"variants/t/1/thumb.jpg", "variants/t/1/large/hero.jpg"} {
+		_, statErr := repo.Stat(ctx, Bucket, removed)
+		assert.ErrorIs(t, statErr, domainErrors.ErrNotFound, removed)
+	}
+	for _, kept := range []string{"variants/t/10/thumb.jpg", "tenants/t/photo.jpg"} {
+		_, statErr := repo.Stat(ctx, Bucket, kept)
+		assert.NoError(t, statErr, kept)
+	}
+}
+
+// testCopy tests that a copy has the content and type of its source and replaces its destination
+func testCopy(t *testing.T, repo ports.StorageRepository) {
+	// Arrange
+	ctx := context.Background()
+	_, err := repo.Store(ctx, Bucket, "uploads/t/source", strings.NewReader(`{"v":1}`), 7, "application/json")
+	require.NoError(t, err)
+	store(t, repo, "content/t/copy", "old")
+
+	// Act
+	err = repo.Copy(ctx, Bucket, "uploads/t/source", "content/t/copy")
+	missingErr := repo.Copy(ctx, Bucket, "uploads/t/missing", "content/t/other")
+
+	// Assert
+	require.NoError(t, err)
+	content, size, contentType := read(t, repo, "content/t/copy", nil)
+	assert.Equal(t, `{"v":1}`, content)
+	assert.Equal(t, int64(7), size)
+	assert.Equal(t, "application/json", contentType)
+	source, _, _ := read(t, repo, "uploads/t/source", nil)
+	assert.Equal(t, `{"v":1}`, source)
+	assert.ErrorIs(t, missingErr, domainErrors.ErrNotFound)
+	_, statErr := repo.Stat(ctx, Bucket, "content/t/other")
+	assert.ErrorIs(t, statErr, domainErrors.ErrNotFound)
+}
+
+// testCompose tests that composed files have the content of their parts in order and the given type,
+// and replace their destination
+func testCompose(t *testing.T, repo ports.StorageRepository) {
+	// Arrange
+	ctx := context.Background()
+	store(t, repo, "resumable/t/u/0", "hello")
+	store(t, repo, "resumable/t/u/5", ", ")
+	store(t, repo, "resumable/t/u/7", "world")
+	store(t, repo, "uploads/t/composed", "old")
+	parts := []entities.UploadPart{
+		{Name: "resumable/t/u/0", Offset: 0, Size: 5},
+		{Name: "resumable/t/u/5", Offset: 5, Size: 2},
+		{Name: "resumable/t/u/7", Offset: 7, Size: 5},
+	}
+
+	// Act
+	composed, err := repo.Compose(ctx, Bucket, "uploads/t/composed", parts, "text/markdown")
+	_, missingErr := repo.Compose(ctx, Bucket, "uploads/t/other", []entities.UploadPart{{Name: "resumable/t/u/missing", Size: 1}}, "text/plain")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "uploads/t/composed", composed.Key)
+	assert.Equal(t, int64(12), composed.Size)
+	content, size, contentType := read(t, repo, "uploads/t/composed", nil)
+	assert.Equal(t, "hello, world", content)
+	assert.Equal(t, int64(12), size)
+	assert.Equal(t, "text/markdown", contentType)
+	part, _, _ := read(t, repo, "resumable/t/u/5", nil)
+	assert.Equal(t, ", ", part)
+	assert.Error(t, missingErr)
+}
+
+// testList tests that listing returns every file under a prefix with its size
+func testList(t *testing.T, repo ports.StorageRepository) {
+	// Arrange
+	ctx := context.Background()
+	before := time.Now().Add(-time.Minute)
+	store(t, repo, "tenants/t/a.txt", "a")
+	store(t, repo, "tenants/t/docs/b.txt", "bb")
+	store(t, repo, "tenants/u/c.txt", "ccc")
+
+	// Act
+	listed := map[string]int64{}
+	err := repo.List(ctx, Bucket, "tenants/t/", func(file *entities.FileMetadata) error {
+		assert.True(t, file.UploadedAt.After(before), file.Key)
+		listed[file.Key] = file.Size
+		return nil
+	})
+	var all int
+	allErr := repo.List(ctx, Bucket, "", func(*entities.FileMetadata) error {
+		all++
+		return nil
+	})
+	stop := errors.New("stop")
+	stopErr := repo.List(ctx, Bucket, "", func(*entities.FileMetadata) error { return stop })
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"tenants/t/a.txt": 1, "tenants/t/docs/b.txt": 2}, listed)
+	require.NoError(t, allErr)
+	assert.Equal(t, 3, all)
+	assert.ErrorIs(t, stopErr, stop)
+}
+
+// testEnsureBucket tests that ensuring an existing bucket keeps its files
+func testEnsureBucket(t *testing.T, repo ports.StorageRepository) {
+	// Arrange
+	store(t, repo, "kept.txt", "kept")
+
+	// Act
+	err := repo.EnsureBucket(context.Background(), Bucket)
+
+	// Assert
+	assert.NoError(t, err)
+	content, _, _ := read(t, repo, "kept.txt", nil)
+	assert.Equal(t, "kept", content)
+}
+
+// testGetURL tests that file URLs are proxied through the API
+func testGetURL(t *testing.T, repo ports.StorageRepository) {
+	// Act
+	url, err := repo.GetURL(context.Background(), Bucket, "docs/a.txt")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "/files/download?bucket="+Bucket+"&file_name=docs/a.txt", url)
+}
+
+// testPresign tests that presigned requests expire as asked, for backends that support them
+func testPresign(t *testing.T, repo ports.StorageRepository) {
+	// Arrange
+	ctx := context.Background()
+	store(t, repo, "presigned.txt", "hello")
+	before := time.Now()
+
+	// Act
+	upload, uploadErr := repo.PresignUpload(ctx, Bucket, "upload.txt", "text/plain", 5, helloSHA256, time.Minute)
+	download, downloadErr := repo.PresignDownload(ctx, Bucket, "presigned.txt", time.Minute)
+
+	// Assert
+	if errors.Is(uploadErr, domainErrors.ErrNotSupported) && errors.Is(downloadErr, domainErrors.ErrNotSupported) {
+		t.Skip("backend does not support presigned requests")
+	}
+	require.NoError(t, uploadErr)
+	require.NoError(t, downloadErr)
+	assert.Equal(t, "PUT", upload.Method)
+	assert.NotEmpty(t, upload.URL)
+	assert.WithinDuration(t, before.Add(time.Minute), upload.ExpiresAt, 5*time.Second)
+	assert.Equal(t, "GET", download.Method)
+	assert.NotEmpty(t, download.URL)
+	assert.WithinDuration(t, before.Add(time.Minute), download.ExpiresAt, 5*time.Second)
+}
@@ -0,0 +1,55 @@
+package persistence
+
+import (
+	"context"
+
+	"entgo.io/ent/dialect/sql"
+	"example.com/go-yippi/internal/adapters/persistence/db/ent"
+	"example.com/go-yippi/internal/adapters/persistence/db/ent/intercept"
+	_ "example.com/go-yippi/internal/adapters/persistence/db/ent/runtime" // registers schema hooks
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+)
+
+// tenantIDColumn is the column holding the owning tenant of scoped types
+const tenantIDColumn = "tenant_id"
+
+// tenantScopedTypes are the Ent types that carry a tenant_id column (see schema.TenantMixin)
+var tenantScopedTypes = map[string]bool{
+	ent.TypeProduct:         true,
+	ent.TypeCategory:        true,
+	ent.TypeBrand:           true,
+	ent.TypeFile:            true,
+	ent.TypeProductMedia:    true,
+	ent.TypeResumableUpload: true,
+	ent.TypeContentObject:   true,
+}
+
+// Open opens an Ent client with tenant scoping installed; see OpenObserved for one that reports its statements
+func Open(driver, dsn string) (*ent.Client, error) {
+	client, err := ent.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	ScopeToTenant(client)
+	return client, nil
+}
+
+// ScopeToTenant installs an interceptor that filters every query on tenant data,
+// including edge loads and the product query engine, by the tenant in the context
+func ScopeToTenant(client *ent.Client) {
+	client.Intercept(intercept.TraverseFunc(func(ctx context.Context, q intercept.Query) error {
+		if !tenantScopedTypes[q.Type()] {
+			return nil
+		}
+
+		tenantID, ok := entities.TenantFromContext(ctx)
+		if !ok {
+			return domainErrors.ErrTenantRequired
+		}
+
+		q.WhereP(sql.FieldEQ(tenantIDColumn, tenantID))
+		return nil
+	}))
+}
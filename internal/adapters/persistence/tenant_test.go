@@ -0,0 +1,165 @@
+package persistence
+
+import (
+	"context"
+	stdsql "database/sql"
+	"testing"
+
+	entsql "entgo.io/ent/dialect/sql"
+	"example.com/go-yippi/internal/adapters/persistence/db/ent"
+	"example.com/go-yippi/internal/adapters/persistence/db/ent/enttest"
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// newTestClient opens a tenant-scoped in-memory SQLite database with the schema migrated
+func newTestClient(t *testing.T) *ent.Client {
+	client, _ := newTestDatabase(t)
+	return client
+}
+
+// newTestDatabase opens a database like newTestClient and also returns its connection pool
+func newTestDatabase(t *testing.T) (*ent.Client, *stdsql.DB) {
+	drv, err := entsql.Open("sqlite3", "file:"+t.Name()+"?mode=memory&cache=shared&_fk=1")
+	require.NoError(t, err)
+	client := enttest.NewClient(t, enttest.WithOptions(ent.Driver(drv)))
+	t.Cleanup(func() { client.Close() })
+	ScopeToTenant(client)
+	return client, drv.DB()
+}
+
+// TestTenantIsolation_Products tests that products are only visible to and writable by their tenant
+func TestTenantIsolation_Products(t *testing.T) {
+	// Arrange
+	client, db := newTestDatabase(t)
+	repo := NewProductRepository(client, db)
+	tenantA := entities.ContextWithTenant(context.Background(), "tenant-a")
+	tenantB := entities.ContextWithTenant(context.Background(), "tenant-b")
+
+	prodA := &entities.Product{SKU: "SKU-1", Slug: "shirt", Name: "Shirt", Price: 10, Status: entities.ProductStatusDraft}
+	require.NoError(t, repo.Create(tenantA, prodA))
+
+	// Act
+	_, getErr := repo.GetByID(tenantB, prodA.ID)
+	listB, listErr := repo.List(tenantB)
+	result, queryErr := repo.Query(tenantB, &entities.QueryParams{})
+	deleteErr := repo.Delete(tenantB, prodA.ID)
+	prodA.Name = "Hijacked"
+	updateErr := repo.Update(tenantB, prodA)
+
+	// Assert
+	assert.ErrorIs(t, getErr, domainErrors.ErrNotFound)
+	require.NoError(t, listErr)
+	assert.Empty(t, listB)
+	require.NoError(t, queryErr)
+	assert.Empty(t, result.Products)
+	assert.ErrorIs(t, deleteErr, domainErrors.ErrNotFound)
+	assert.ErrorIs(t, updateErr, domainErrors.ErrNotFound)
+
+	found, err := repo.GetByID(tenantA, prodA.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Shirt", found.Name)
+}
+
+// TestTenantIsolation_PerTenantUniqueness tests that SKUs, slugs and names are unique per tenant only
+func TestTenantIsolation_PerTenantUniqueness(t *testing.T) {
+	// Arrange
+	client, db := newTestDatabase(t)
+	products := NewProductRepository(client, db)
+	brands := NewBrandRepository(client)
+	categories := NewCategoryRepository(client)
+	tenantA := entities.ContextWithTenant(context.Background(), "tenant-a")
+	tenantB := entities.ContextWithTenant(context.Background(), "tenant-b")
+
+	newProduct := func() *entities.Product {
+		return &entities.Product{SKU: "SKU-1", Slug: "shirt", Name: "Shirt", Price: 10, Status: entities.ProductStatusDraft}
+	}
+
+	// Act & Assert
+	require.NoError(t, products.Create(tenantA, newProduct()))
+	require.NoError(t, products.Create(tenantB, newProduct()))
+	assert.ErrorIs(t, products.Create(tenantA, newProduct()), domainErrors.ErrDuplicateEntry)
+
+	require.NoError(t, brands.Create(tenantA, &entities.Brand{Name: "Acme"}))
+	require.NoError(t, brands.Create(tenantB, &entities.Brand{Name: "Acme"}))
+	assert.ErrorIs(t, brands.Create(tenantA, &entities.Brand{Name: "Acme"}), domainErrors.ErrDuplicateEntry)
+
+	require.NoError(t, categories.Create(tenantA, &entities.Category{Name: "Apparel"}))
+	require.NoError(t, categories.Create(tenantB, &entities.Category{Name: "Apparel"}))
+	assert.ErrorIs(t, categories.Create(tenantA, &entities.Category{Name: "Apparel"}), domainErrors.ErrDuplicateEntry)
+}
+
+// TestTenantIsolation_CategoryDescendants tests that descendant lookups stay within the tenant
+func TestTenantIsolation_CategoryDescendants(t *testing.T) {
+	// Arrange
+	repo := NewCategoryRepository(newTestClient(t))
+	tenantA := entities.ContextWithTenant(context.Background(), "tenant-a")
+	tenantB := entities.ContextWithTenant(context.Background(), "tenant-b")
+
+	root := &entities.Category{Name: "Root"}
+	require.NoError(t, repo.Create(tenantA, root))
+	child := &entities.Category{Name: "Child", ParentID: &root.ID}
+	require.NoError(t, repo.Create(tenantA, child))
+
+	// Act
+	idsA, errA := repo.GetDescendantIDs(tenantA, []uuid.UUID{root.ID})
+	idsB, errB := repo.GetDescendantIDs(tenantB, []uuid.UUID{root.ID})
+
+	// Assert
+	require.NoError(t, errA)
+	assert.ElementsMatch(t, []uuid.UUID{root.ID, child.ID}, idsA)
+	require.NoError(t, errB)
+	assert.Equal(t, []uuid.UUID{root.ID}, idsB, "only the requested ID, no foreign descendants")
+}
+
+// TestTenantIsolation_RequiresTenant tests that operations without a tenant in the context fail
+func TestTenantIsolation_RequiresTenant(t *testing.T) {
+	// Arrange
+	repo := NewBrandRepository(newTestClient(t))
+	ctx := context.Background()
+
+	// Act
+	createErr := repo.Create(ctx, &entities.Brand{Name: "Acme"})
+	_, listErr := repo.List(ctx)
+
+	// Assert
+	assert.ErrorIs(t, createErr, domainErrors.ErrTenantRequired)
+	assert.ErrorIs(t, listErr, domainErrors.ErrTenantRequired)
+}
+
+// TestTenantIsolation_ProductReferences tests that products read in a tenant keep their category and brand
+func TestTenantIsolation_ProductReferences(t *testing.T) {
+	// Arrange
+	client, db := newTestDatabase(t)
+	repo := NewProductRepository(client, db)
+	ctx := entities.ContextWithTenant(context.Background(), "tenant-a")
+
+	category := &entities.Category{Name: "Shirts"}
+	require.NoError(t, NewCategoryRepository(client).Create(ctx, category))
+	brand := &entities.Brand{Name: "Acme"}
+	require.NoError(t, NewBrandRepository(client).Create(ctx, brand))
+	prod := &entities.Product{SKU: "SKU-1", Slug: "shirt", Name: "Shirt", Price: 10, Status: entities.ProductStatusDraft, CategoryID: &category.ID, BrandID: &brand.ID}
+	require.NoError(t, repo.Create(ctx, prod))
+
+	// Act
+	byID, byIDErr := repo.GetByID(ctx, prod.ID)
+	bySKU, bySKUErr := repo.GetBySKU(ctx, "SKU-1")
+	bySlug, bySlugErr := repo.GetBySlug(ctx, "shirt")
+	list, listErr := repo.List(ctx)
+
+	// Assert
+	require.NoError(t, byIDErr)
+	require.NoError(t, bySKUErr)
+	require.NoError(t, bySlugErr)
+	require.NoError(t, listErr)
+	require.Len(t, list, 1)
+	for _, found := range []*entities.Product{byID, bySKU, bySlug, list[0]} {
+		assert.Equal(t, &category.ID, found.CategoryID)
+		assert.Equal(t, &brand.ID, found.BrandID)
+	}
+}
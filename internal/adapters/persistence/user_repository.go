@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"example.com/go-yippi/internal/adapters/persistence/db/ent"
+	"example.com/go-yippi/internal/adapters/persistence/db/ent/user"
 	"example.com/go-yippi/internal/domain/entities"
 	domainErrors "example.com/go-yippi/internal/domain/errors"
 )
@@ -17,17 +18,46 @@ func NewUserRepository(client *ent.Client) *UserRepositoryImpl {
 	return &UserRepositoryImpl{client: client}
 }
 
-func (r *UserRepositoryImpl) Create(ctx context.Context, user *entities.User) error {
-	created, err := r.client.User.
+func (r *UserRepositoryImpl) Create(ctx context.Context, u *entities.User) error {
+	builder := r.client.User.
 		Create().
-		SetName(user.Name).
-		SetAge(user.Age).
-		Save(ctx)
+		SetName(u.Name).
+		SetAge(u.Age).
+		SetPasswordHash(u.PasswordHash).
+		SetTotpSecret(u.TOTPSecret).
+		SetTotpEnabled(u.TOTPEnabled).
+		SetTotpLastStep(u.TOTPLastStep).
+		SetRecoveryCodes(u.RecoveryCodes).
+		SetTotpChallengeID(u.TOTPChallengeID).
+		SetTotpFailedAttempts(u.TOTPFailedAttempts)
+
+	if !u.TOTPLockedUntil.IsZero() {
+		builder = builder.SetTotpLockedUntil(u.TOTPLockedUntil)
+	}
+
+	// Set email if provided (stored as NULL otherwise so it stays unique)
+	if u.Email != "" {
+		builder = builder.SetEmail(u.Email)
+	}
+
+	// Set role if provided (defaults to viewer)
+	if u.Role != "" {
+		builder = builder.SetRole(user.Role(u.Role))
+	}
+
+	// Set tenant if provided (defaults to the default tenant)
+	if u.TenantID != "" {
+		builder = builder.SetTenantID(u.TenantID)
+	}
+
+	created, err := builder.Save(ctx)
 	if err != nil {
-		return err
+		return mapWriteError("User", err, r.writeFields(u))
 	}
 
-	user.ID = created.ID
+	u.ID = created.ID
+	u.Role = entities.Role(created.Role)
+	u.TenantID = created.TenantID
 	return nil
 }
 
@@ -40,11 +70,22 @@ func (r *UserRepositoryImpl) GetByID(ctx context.Context, id int) (*entities.Use
 		return nil, err
 	}
 
-	return &entities.User{
-		ID:    found.ID,
-		Name:  found.Name,
-		Age: found.Age,
-	}, nil
+	return r.toEntity(found), nil
+}
+
+func (r *UserRepositoryImpl) GetByEmail(ctx context.Context, email string) (*entities.User, error) {
+	found, err := r.client.User.
+		Query().
+		Where(user.EmailEQ(email)).
+		Only(ctx)
+	if err != nil {
+		if ent.IsNotFound(err) {
+			return nil, domainErrors.NewNotFoundError("User", email)
+		}
+		return nil, err
+	}
+
+	return r.toEntity(found), nil
 }
 
 func (r *UserRepositoryImpl) List(ctx context.Context) ([]*entities.User, error) {
@@ -55,27 +96,64 @@ func (r *UserRepositoryImpl) List(ctx context.Context) ([]*entities.User, error)
 
 	users := make([]*entities.User, 0, len(list))
 	for _, u := range list {
-		users = append(users, &entities.User{
-			ID:    u.ID,
-			Name:  u.Name,
-			Age: u.Age,
-		})
+		users = append(users, r.toEntity(u))
+	}
+
+	return users, nil
+}
+
+func (r *UserRepositoryImpl) ListByTenant(ctx context.Context, tenantID string) ([]*entities.User, error) {
+	list, err := r.client.User.Query().Where(user.TenantID(tenantID)).All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*entities.User, 0, len(list))
+	for _, u := range list {
+		users = append(users, r.toEntity(u))
 	}
 
 	return users, nil
 }
 
-func (r *UserRepositoryImpl) Update(ctx context.Context, user *entities.User) error {
-	_, err := r.client.User.
-		UpdateOneID(user.ID).
-		SetName(user.Name).
-		SetAge(user.Age).
-		Save(ctx)
+func (r *UserRepositoryImpl) Update(ctx context.Context, u *entities.User) error {
+	builder := r.client.User.
+		UpdateOneID(u.ID).
+		SetName(u.Name).
+		SetAge(u.Age).
+		SetPasswordHash(u.PasswordHash).
+		SetTotpSecret(u.TOTPSecret).
+		SetTotpEnabled(u.TOTPEnabled).
+		SetTotpLastStep(u.TOTPLastStep).
+		SetRecoveryCodes(u.RecoveryCodes).
+		SetTotpChallengeID(u.TOTPChallengeID).
+		SetTotpFailedAttempts(u.TOTPFailedAttempts)
+
+	// Set or clear the 2FA lockout
+	if !u.TOTPLockedUntil.IsZero() {
+		builder = builder.SetTotpLockedUntil(u.TOTPLockedUntil)
+	} else {
+		builder = builder.ClearTotpLockedUntil()
+	}
+
+	// Set or clear email
+	if u.Email != "" {
+		builder = builder.SetEmail(u.Email)
+	} else {
+		builder = builder.ClearEmail()
+	}
+
+	// Set role if provided
+	if u.Role != "" {
+		builder = builder.SetRole(user.Role(u.Role))
+	}
+
+	_, err := builder.Save(ctx)
 	if err != nil {
 		if ent.IsNotFound(err) {
-			return domainErrors.NewNotFoundError("User", user.ID)
+			return domainErrors.NewNotFoundError("User", u.ID)
 		}
-		return err
+		return mapWriteError("User", err, r.writeFields(u))
 	}
 	return nil
 }
@@ -86,7 +164,42 @@ func (r *UserRepositoryImpl) Delete(ctx context.Context, id int) error {
 		if ent.IsNotFound(err) {
 			return domainErrors.NewNotFoundError("User", id)
 		}
-		return err
+		return mapWriteError("User", err, nil)
 	}
 	return nil
 }
+
+// writeFields returns the constrained fields of a user for mapping write errors
+func (r *UserRepositoryImpl) writeFields(u *entities.User) map[string]any {
+	return map[string]any{"email": u.Email, "name": u.Name}
+}
+
+// toEntity converts Ent User to domain entity
+func (r *UserRepositoryImpl) toEntity(u *ent.User) *entities.User {
+	usr := &entities.User{
+		ID:            u.ID,
+		Name:          u.Name,
+		Age:           u.Age,
+		Role:          entities.Role(u.Role),
+		TenantID:      u.TenantID,
+		PasswordHash:  u.PasswordHash,
+		TOTPSecret:    u.TotpSecret,
+		TOTPEnabled:   u.TotpEnabled,
+		TOTPLastStep:  u.TotpLastStep,
+		RecoveryCodes: u.RecoveryCodes,
+
+		TOTPChallengeID:    u.TotpChallengeID,
+		TOTPFailedAttempts: u.TotpFailedAttempts,
+	}
+
+	if u.TotpLockedUntil != nil {
+		usr.TOTPLockedUntil = *u.TotpLockedUntil
+	}
+
+	// Set email if it exists
+	if u.Email != nil {
+		usr.Email = *u.Email
+	}
+
+	return usr
+}
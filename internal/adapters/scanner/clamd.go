@@ -0,0 +1,112 @@
+// Package scanner scans uploaded files for malware, with ClamAV's clamd or, for development and tests,
+// a fake that recognises test signatures.
+package scanner
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+)
+
+// clamdChunkSize is the size of the chunks content is streamed to clamd in
+const clamdChunkSize = 64 << 10
+
+// ClamdScanner implements FileScanner with the INSTREAM command of a clamd daemon
+type ClamdScanner struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamdScanner creates a scanner for the clamd at address, either tcp://host:port, unix:///path/to/socket
+// or host:port. A scan fails if it takes longer than timeout.
+func NewClamdScanner(address string, timeout time.Duration) (*ClamdScanner, error) {
+	network, addr := "tcp", address
+	if scheme, rest, ok := strings.Cut(address, "://"); ok {
+		network, addr = scheme, rest
+	}
+	if network != "tcp" && network != "unix" || addr == "" {
+		return nil, fmt.Errorf("invalid clamd address %q: expected tcp://host:port or unix:///path", address)
+	}
+	return &ClamdScanner{network: network, address: addr, timeout: timeout}, nil
+}
+
+// Scan streams the content to clamd and returns its verdict
+func (s *ClamdScanner) Scan(ctx context.Context, content io.Reader) (*entities.ScanResult, error) {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, s.network, s.address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if err := s.stream(conn, content); err != nil {
+		return nil, err
+	}
+
+	reply, err := io.ReadAll(conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+	return parseClamdReply(string(reply))
+}
+
+// stream sends the content as length-prefixed chunks terminated by an empty chunk
+func (s *ClamdScanner) stream(conn net.Conn, content io.Reader) error {
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return fmt.Errorf("failed to send to clamd: %w", err)
+	}
+
+	buf := make([]byte, 4+clamdChunkSize)
+	for {
+		n, err := io.ReadFull(content, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf, uint32(n))
+			if _, err := conn.Write(buf[:4+n]); err != nil {
+				return fmt.Errorf("failed to send to clamd: %w", err)
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read content: %w", err)
+		}
+	}
+
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return fmt.Errorf("failed to send to clamd: %w", err)
+	}
+	return nil
+}
+
+// parseClamdReply parses replies of the form "stream: OK", "stream: <threat> FOUND" or "<message> ERROR"
+func parseClamdReply(reply string) (*entities.ScanResult, error) {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	verdict := strings.TrimPrefix(reply, "stream: ")
+
+	switch {
+	case verdict == "OK":
+		return &entities.ScanResult{}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return &entities.ScanResult{Infected: true, Threat: strings.TrimSuffix(verdict, " FOUND")}, nil
+	default:
+		return nil, fmt.Errorf("clamd failed to scan: %s", reply)
+	}
+}
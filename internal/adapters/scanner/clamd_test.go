@@ -0,0 +1,128 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveClamd accepts INSTREAM commands like clamd and replies with reply(content)
+func serveClamd(t *testing.T, reply func(content []byte) string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				command := make([]byte, len("zINSTREAM\x00"))
+				if _, err := io.ReadFull(conn, command); err != nil || string(command) != "zINSTREAM\x00" {
+					conn.Write([]byte("UNKNOWN COMMAND\x00"))
+					return
+				}
+
+				var content bytes.Buffer
+				for {
+					var size uint32
+					if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+						return
+					}
+					if size == 0 {
+						break
+					}
+					if _, err := io.CopyN(&content, conn, int64(size)); err != nil {
+						return
+					}
+				}
+				conn.Write([]byte(reply(content.Bytes()) + "\x00"))
+			}()
+		}
+	}()
+
+	return "tcp://" + listener.Addr().String()
+}
+
+// TestClamdScanner_Scan tests the verdicts of clamd
+func TestClamdScanner_Scan(t *testing.T) {
+	// Arrange
+	address := serveClamd(t, func(content []byte) string {
+		switch {
+		case bytes.Contains(content, []byte(EICAR)):
+			return "stream: Eicar-Test-Signature FOUND"
+		case len(content) > 100_000:
+			return "INSTREAM size limit exceeded. ERROR"
+		}
+		return "stream: OK"
+	})
+	scanner, err := NewClamdScanner(address, 5*time.Second)
+	require.NoError(t, err)
+
+	// Act
+	clean, cleanErr := scanner.Scan(context.Background(), strings.NewReader("hello world"))
+	infected, infectedErr := scanner.Scan(context.Background(), strings.NewReader("prefix "+EICAR))
+	_, tooLargeErr := scanner.Scan(context.Background(), bytes.NewReader(make([]byte, 200_000)))
+
+	// Assert
+	require.NoError(t, cleanErr)
+	assert.False(t, clean.Infected)
+	require.NoError(t, infectedErr)
+	assert.True(t, infected.Infected)
+	assert.Equal(t, "Eicar-Test-Signature", infected.Threat)
+	assert.ErrorContains(t, tooLargeErr, "size limit exceeded")
+}
+
+// TestClamdScanner_Unreachable tests that scans fail when clamd cannot be reached
+func TestClamdScanner_Unreachable(t *testing.T) {
+	// Arrange
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	listener.Close()
+	scanner, err := NewClamdScanner(address, time.Second)
+	require.NoError(t, err)
+
+	// Act
+	_, err = scanner.Scan(context.Background(), strings.NewReader("hello"))
+
+	// Assert
+	assert.ErrorContains(t, err, "failed to connect to clamd")
+}
+
+// TestNewClamdScanner_InvalidAddress tests that only TCP and Unix socket addresses are accepted
+func TestNewClamdScanner_InvalidAddress(t *testing.T) {
+	_, err := NewClamdScanner("http://localhost:3310", time.Second)
+	assert.Error(t, err)
+
+	_, err = NewClamdScanner("unix:///var/run/clamav/clamd.ctl", time.Second)
+	assert.NoError(t, err)
+}
+
+// TestFakeScanner tests that the fake detects the EICAR test file and added signatures
+func TestFakeScanner(t *testing.T) {
+	// Arrange
+	scanner := NewFakeScanner()
+	scanner.AddSignature("Test-Macro", []byte("AutoOpen"))
+
+	// Act
+	clean, _ := scanner.Scan(context.Background(), strings.NewReader("hello world"))
+	eicar, _ := scanner.Scan(context.Background(), strings.NewReader(EICAR))
+	macro, _ := scanner.Scan(context.Background(), strings.NewReader("Sub AutoOpen()"))
+
+	// Assert
+	assert.False(t, clean.Infected)
+	assert.Equal(t, "Eicar-Test-Signature", eicar.Threat)
+	assert.Equal(t, "Test-Macro", macro.Threat)
+}
@@ -0,0 +1,44 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"example.com/go-yippi/internal/domain/entities"
+)
+
+// EICAR is the EICAR anti-malware test file, which every scanner reports as infected
+const EICAR = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// FakeScanner implements FileScanner without a scanning engine: content containing one of its signatures
+// is infected. It lets development setups and tests exercise quarantine without running clamd.
+type FakeScanner struct {
+	signatures map[string][]byte
+}
+
+// NewFakeScanner creates a fake scanner that detects the EICAR test file
+func NewFakeScanner() *FakeScanner {
+	return &FakeScanner{signatures: map[string][]byte{"Eicar-Test-Signature": []byte(EICAR)}}
+}
+
+// AddSignature makes the scanner report content containing signature as the threat name
+func (s *FakeScanner) AddSignature(name string, signature []byte) {
+	s.signatures[name] = signature
+}
+
+// Scan reads the content and looks for the signatures in it
+func (s *FakeScanner) Scan(ctx context.Context, content io.Reader) (*entities.ScanResult, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read content: %w", err)
+	}
+
+	for name, signature := range s.signatures {
+		if bytes.Contains(data, signature) {
+			return &entities.ScanResult{Infected: true, Threat: name}, nil
+		}
+	}
+	return &entities.ScanResult{}, nil
+}
@@ -0,0 +1,110 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+)
+
+// ErrInvalidToken indicates that a token is malformed, tampered with, expired or of the wrong type
+var ErrInvalidToken = errors.New("invalid token")
+
+// tokenPayload is the JSON payload of a signed token
+type tokenPayload struct {
+	ID        string `json:"jti,omitempty"`
+	Issuer    string `json:"iss"`
+	Subject   int    `json:"sub"`
+	Type      string `json:"typ"`
+	TwoFactor bool   `json:"mfa,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// HMACTokenManager issues compact HMAC-SHA256 signed tokens (payload.signature, base64url)
+type HMACTokenManager struct {
+	secret []byte
+	issuer string
+	now    func() time.Time
+}
+
+// NewHMACTokenManager creates a new token manager signing with the given secret
+func NewHMACTokenManager(secret, issuer string) *HMACTokenManager {
+	return &HMACTokenManager{
+		secret: []byte(secret),
+		issuer: issuer,
+		now:    time.Now,
+	}
+}
+
+// Issue signs the claims into a token
+func (m *HMACTokenManager) Issue(claims entities.TokenClaims) (string, error) {
+	payload, err := json.Marshal(tokenPayload{
+		ID:        claims.ID,
+		Issuer:    m.issuer,
+		Subject:   claims.Subject,
+		Type:      string(claims.Type),
+		TwoFactor: claims.TwoFactor,
+		IssuedAt:  claims.IssuedAt.Unix(),
+		ExpiresAt: claims.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal token payload: %w", err)
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + m.sign(encoded), nil
+}
+
+// Verify checks the signature, issuer, expiry and type of the token
+func (m *HMACTokenManager) Verify(token string, tokenType entities.TokenType) (*entities.TokenClaims, error) {
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found {
+		return nil, ErrInvalidToken
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(m.sign(encoded))) {
+		return nil, ErrInvalidToken
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var payload tokenPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if payload.Issuer != m.issuer || payload.Type != string(tokenType) {
+		return nil, ErrInvalidToken
+	}
+
+	expiresAt := time.Unix(payload.ExpiresAt, 0)
+	if !m.now().Before(expiresAt) {
+		return nil, fmt.Errorf("%w: token expired", ErrInvalidToken)
+	}
+
+	return &entities.TokenClaims{
+		ID:        payload.ID,
+		Subject:   payload.Subject,
+		Type:      entities.TokenType(payload.Type),
+		TwoFactor: payload.TwoFactor,
+		IssuedAt:  time.Unix(payload.IssuedAt, 0),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// sign returns the base64url HMAC-SHA256 signature of the encoded payload
+func (m *HMACTokenManager) sign(encoded string) string {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
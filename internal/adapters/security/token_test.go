@@ -0,0 +1,92 @@
+package security
+
+import (
+	"testing"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClaims returns claims issued at the given time with a one hour lifetime
+func newTestClaims(now time.Time, tokenType entities.TokenType) entities.TokenClaims {
+	return entities.TokenClaims{
+		ID:        "token-id",
+		Subject:   42,
+		Type:      tokenType,
+		TwoFactor: true,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(time.Hour),
+	}
+}
+
+// TestHMACTokenManager_RoundTrip tests that an issued token verifies back to its claims
+func TestHMACTokenManager_RoundTrip(t *testing.T) {
+	// Arrange
+	manager := NewHMACTokenManager("secret", "go-yippi")
+	now := time.Now().Truncate(time.Second)
+
+	// Act
+	token, err := manager.Issue(newTestClaims(now, entities.TokenTypeAccess))
+	require.NoError(t, err)
+	claims, err := manager.Verify(token, entities.TokenTypeAccess)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "token-id", claims.ID)
+	assert.Equal(t, 42, claims.Subject)
+	assert.Equal(t, entities.TokenTypeAccess, claims.Type)
+	assert.True(t, claims.TwoFactor)
+	assert.True(t, claims.ExpiresAt.Equal(now.Add(time.Hour)))
+}
+
+// TestHMACTokenManager_RejectsInvalidTokens tests tampering, wrong secret, wrong type and expiry
+func TestHMACTokenManager_RejectsInvalidTokens(t *testing.T) {
+	// Arrange
+	manager := NewHMACTokenManager("secret", "go-yippi")
+	now := time.Now()
+	token, err := manager.Issue(newTestClaims(now, entities.TokenTypeChallenge))
+	require.NoError(t, err)
+
+	otherSecret := NewHMACTokenManager("other-secret", "go-yippi")
+	otherIssuer := NewHMACTokenManager("secret", "other-issuer")
+
+	expired := NewHMACTokenManager("secret", "go-yippi")
+	expired.now = func() time.Time { return now.Add(2 * time.Hour) }
+
+	tampered := []byte(token)
+	tampered[0] ^= 0x01
+
+	cases := map[string]func() error{
+		"tampered payload": func() error {
+			_, err := manager.Verify(string(tampered), entities.TokenTypeChallenge)
+			return err
+		},
+		"missing signature": func() error {
+			_, err := manager.Verify("payload-only", entities.TokenTypeChallenge)
+			return err
+		},
+		"wrong secret": func() error {
+			_, err := otherSecret.Verify(token, entities.TokenTypeChallenge)
+			return err
+		},
+		"wrong issuer": func() error {
+			_, err := otherIssuer.Verify(token, entities.TokenTypeChallenge)
+			return err
+		},
+		"wrong type": func() error {
+			_, err := manager.Verify(token, entities.TokenTypeAccess)
+			return err
+		},
+		"expired": func() error {
+			_, err := expired.Verify(token, entities.TokenTypeChallenge)
+			return err
+		},
+	}
+
+	for name, verify := range cases {
+		// Act & Assert
+		assert.ErrorIs(t, verify(), ErrInvalidToken, name)
+	}
+}
@@ -0,0 +1,95 @@
+package security
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfc6238Secret is the SHA1 test key from RFC 6238 Appendix B
+const rfc6238Secret = "12345678901234567890"
+
+// TestHOTP_RFC6238Vectors tests the HOTP core against the RFC 6238 SHA1 test vectors
+func TestHOTP_RFC6238Vectors(t *testing.T) {
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, v := range vectors {
+		// Act
+		code := hotp([]byte(rfc6238Secret), uint64(v.unix/totpPeriod), 8)
+
+		// Assert
+		assert.Equal(t, v.code, code, "T=%d", v.unix)
+	}
+}
+
+// TestTOTP_ValidateWithinSkew tests that codes from adjacent steps are accepted and the step is returned
+func TestTOTP_ValidateWithinSkew(t *testing.T) {
+	// Arrange
+	provider := NewTOTP("go-yippi")
+	secret := totpEncoding.EncodeToString([]byte(rfc6238Secret))
+	at := time.Unix(1111111111, 0)
+	step := at.Unix() / totpPeriod
+	previous := hotp([]byte(rfc6238Secret), uint64(step-1), totpDigits)
+	tooOld := hotp([]byte(rfc6238Secret), uint64(step-2), totpDigits)
+
+	// Act
+	matched, ok := provider.Validate(secret, previous, at)
+	_, okTooOld := provider.Validate(secret, tooOld, at)
+
+	// Assert
+	assert.True(t, ok)
+	assert.Equal(t, step-1, matched)
+	assert.False(t, okTooOld)
+}
+
+// TestTOTP_ValidateRejectsMalformedCodes tests that codes of the wrong length are rejected
+func TestTOTP_ValidateRejectsMalformedCodes(t *testing.T) {
+	// Arrange
+	provider := NewTOTP("go-yippi")
+	secret := totpEncoding.EncodeToString([]byte(rfc6238Secret))
+
+	// Act & Assert
+	for _, code := range []string{"", "12345", "1234567", "abcdef"} {
+		_, ok := provider.Validate(secret, code, time.Unix(59, 0))
+		assert.False(t, ok, "code %q", code)
+	}
+}
+
+// TestTOTP_GenerateSecretAndURI tests that generated secrets decode and the URI carries the parameters
+func TestTOTP_GenerateSecretAndURI(t *testing.T) {
+	// Arrange
+	provider := NewTOTP("go-yippi")
+
+	// Act
+	secret, err := provider.GenerateSecret()
+	require.NoError(t, err)
+	uri := provider.URI(secret, "admin@example.com")
+
+	// Assert
+	key, err := totpEncoding.DecodeString(secret)
+	require.NoError(t, err)
+	assert.Len(t, key, totpSecretSize)
+
+	require.True(t, strings.HasPrefix(uri, "otpauth://totp/"))
+	parsed, err := url.Parse(uri)
+	require.NoError(t, err)
+	assert.Equal(t, "/go-yippi:admin@example.com", parsed.Path)
+	assert.Equal(t, secret, parsed.Query().Get("secret"))
+	assert.Equal(t, "go-yippi", parsed.Query().Get("issuer"))
+	assert.Equal(t, "6", parsed.Query().Get("digits"))
+	assert.Equal(t, "30", parsed.Query().Get("period"))
+}
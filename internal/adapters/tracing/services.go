@@ -0,0 +1,367 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"example.com/go-yippi/internal/domain/entities"
+	"example.com/go-yippi/internal/domain/ports"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// call runs a service method that returns a result in a span
+func call[T any](t *Tracer, ctx context.Context, name string, fn func(context.Context) (T, error), attrs ...attribute.KeyValue) (T, error) {
+	ctx, span := t.start(ctx, name, attrs...)
+	result, err := fn(ctx)
+	end(span, err)
+	return result, err
+}
+
+// run runs a service method in a span
+func run(t *Tracer, ctx context.Context, name string, fn func(context.Context) error, attrs ...attribute.KeyValue) error {
+	ctx, span := t.start(ctx, name, attrs...)
+	err := fn(ctx)
+	end(span, err)
+	return err
+}
+
+func productID(id int) attribute.KeyValue {
+	return attribute.Int("product.id", id)
+}
+
+func categoryID(id uuid.UUID) attribute.KeyValue {
+	return attribute.String("category.id", id.String())
+}
+
+func brandID(id uuid.UUID) attribute.KeyValue {
+	return attribute.String("brand.id", id.String())
+}
+
+func fileID(id uuid.UUID) attribute.KeyValue {
+	return attribute.String("file.id", id.String())
+}
+
+func fileAt(bucket, fileName string) []attribute.KeyValue {
+	return []attribute.KeyValue{attribute.String("file.bucket", bucket), attribute.String("file.name", fileName)}
+}
+
+// queryAttributes records the filters, sort and page of a product query
+func queryAttributes(params *entities.QueryParams) []attribute.KeyValue {
+	if params == nil {
+		return nil
+	}
+	filters := make([]string, 0, len(params.Filters))
+	for _, filter := range params.Filters {
+		filters = append(filters, fmt.Sprintf("%s %s %v", filter.Field, filter.Operator, filter.Value))
+	}
+	sort := make([]string, 0, len(params.Sort))
+	for _, param := range params.Sort {
+		sort = append(sort, param.Field+" "+string(param.Order))
+	}
+	attrs := []attribute.KeyValue{
+		attribute.StringSlice("query.filters", filters),
+		attribute.StringSlice("query.sort", sort),
+	}
+	if page := params.Pagination; page != nil {
+		attrs = append(attrs,
+			attribute.Int("query.limit", page.Limit),
+			attribute.String("query.direction", page.Direction),
+			attribute.Bool("query.cursor", page.Cursor != nil),
+		)
+	}
+	return attrs
+}
+
+// productService traces the methods of a product service
+type productService struct {
+	s ports.ProductService
+	t *Tracer
+}
+
+// Products wraps a product service so that each call gets a span; queries record their filters, sort
+// and page
+func (t *Tracer) Products(service ports.ProductService) ports.ProductService {
+	return &productService{s: service, t: t}
+}
+
+func (p *productService) CreateProduct(ctx context.Context, product *entities.Product) error {
+	return run(p.t, ctx, "ProductService.CreateProduct", func(ctx context.Context) error {
+		return p.s.CreateProduct(ctx, product)
+	})
+}
+
+func (p *productService) GetProduct(ctx context.Context, id int) (*entities.Product, error) {
+	return call(p.t, ctx, "ProductService.GetProduct", func(ctx context.Context) (*entities.Product, error) {
+		return p.s.GetProduct(ctx, id)
+	}, productID(id))
+}
+
+func (p *productService) GetProductBySKU(ctx context.Context, sku string) (*entities.Product, error) {
+	return call(p.t, ctx, "ProductService.GetProductBySKU", func(ctx context.Context) (*entities.Product, error) {
+		return p.s.GetProductBySKU(ctx, sku)
+	}, attribute.String("product.sku", sku))
+}
+
+func (p *productService) GetProductBySlug(ctx context.Context, slug string) (*entities.Product, error) {
+	return call(p.t, ctx, "ProductService.GetProductBySlug", func(ctx context.Context) (*entities.Product, error) {
+		return p.s.GetProductBySlug(ctx, slug)
+	}, attribute.String("product.slug", slug))
+}
+
+func (p *productService) ListProducts(ctx context.Context) ([]*entities.Product, error) {
+	return call(p.t, ctx, "ProductService.ListProducts", p.s.ListProducts)
+}
+
+func (p *productService) ListPublishedProducts(ctx context.Context) ([]*entities.Product, error) {
+	return call(p.t, ctx, "ProductService.ListPublishedProducts", p.s.ListPublishedProducts)
+}
+
+func (p *productService) ListProductsByStatus(ctx context.Context, status entities.ProductStatus) ([]*entities.Product, error) {
+	return call(p.t, ctx, "ProductService.ListProductsByStatus", func(ctx context.Context) ([]*entities.Product, error) {
+		return p.s.ListProductsByStatus(ctx, status)
+	}, attribute.String("product.status", string(status)))
+}
+
+func (p *productService) UpdateProduct(ctx context.Context, product *entities.Product) error {
+	return run(p.t, ctx, "ProductService.UpdateProduct", func(ctx context.Context) error {
+		return p.s.UpdateProduct(ctx, product)
+	}, productID(product.ID))
+}
+
+func (p *productService) DeleteProduct(ctx context.Context, id int) error {
+	return run(p.t, ctx, "ProductService.DeleteProduct", func(ctx context.Context) error {
+		return p.s.DeleteProduct(ctx, id)
+	}, productID(id))
+}
+
+func (p *productService) PublishProduct(ctx context.Context, id int) error {
+	return run(p.t, ctx, "ProductService.PublishProduct", func(ctx context.Context) error {
+		return p.s.PublishProduct(ctx, id)
+	}, productID(id))
+}
+
+func (p *productService) ArchiveProduct(ctx context.Context, id int) error {
+	return run(p.t, ctx, "ProductService.ArchiveProduct", func(ctx context.Context) error {
+		return p.s.ArchiveProduct(ctx, id)
+	}, productID(id))
+}
+
+func (p *productService) QueryProducts(ctx context.Context, params *entities.QueryParams) (*entities.QueryResult, error) {
+	return call(p.t, ctx, "ProductService.QueryProducts", func(ctx context.Context) (*entities.QueryResult, error) {
+		return p.s.QueryProducts(ctx, params)
+	}, queryAttributes(params)...)
+}
+
+func (p *productService) ImportProducts(ctx context.Context, products []*entities.Product) error {
+	return run(p.t, ctx, "ProductService.ImportProducts", func(ctx context.Context) error {
+		return p.s.ImportProducts(ctx, products)
+	}, attribute.Int("products.count", len(products)))
+}
+
+func (p *productService) ProductStats(ctx context.Context) (*entities.ProductStats, error) {
+	return call(p.t, ctx, "ProductService.ProductStats", p.s.ProductStats)
+}
+
+func (p *productService) AttachMedia(ctx context.Context, id int, media *entities.ProductMedia) error {
+	return run(p.t, ctx, "ProductService.AttachMedia", func(ctx context.Context) error {
+		return p.s.AttachMedia(ctx, id, media)
+	}, productID(id))
+}
+
+func (p *productService) UpdateMedia(ctx context.Context, id int, media *entities.ProductMedia) error {
+	return run(p.t, ctx, "ProductService.UpdateMedia", func(ctx context.Context) error {
+		return p.s.UpdateMedia(ctx, id, media)
+	}, productID(id))
+}
+
+func (p *productService) ReorderMedia(ctx context.Context, id int, mediaIDs []uuid.UUID) ([]*entities.ProductMedia, error) {
+	return call(p.t, ctx, "ProductService.ReorderMedia", func(ctx context.Context) ([]*entities.ProductMedia, error) {
+		return p.s.ReorderMedia(ctx, id, mediaIDs)
+	}, productID(id))
+}
+
+func (p *productService) DetachMedia(ctx context.Context, id int, mediaID uuid.UUID) error {
+	return run(p.t, ctx, "ProductService.DetachMedia", func(ctx context.Context) error {
+		return p.s.DetachMedia(ctx, id, mediaID)
+	}, productID(id), attribute.String("media.id", mediaID.String()))
+}
+
+// categoryService traces the methods of a category service
+type categoryService struct {
+	s ports.CategoryService
+	t *Tracer
+}
+
+// Categories wraps a category service so that each call gets a span
+func (t *Tracer) Categories(service ports.CategoryService) ports.CategoryService {
+	return &categoryService{s: service, t: t}
+}
+
+func (c *categoryService) CreateCategory(ctx context.Context, category *entities.Category) error {
+	return run(c.t, ctx, "CategoryService.CreateCategory", func(ctx context.Context) error {
+		return c.s.CreateCategory(ctx, category)
+	})
+}
+
+func (c *categoryService) GetCategory(ctx context.Context, id uuid.UUID) (*entities.Category, error) {
+	return call(c.t, ctx, "CategoryService.GetCategory", func(ctx context.Context) (*entities.Category, error) {
+		return c.s.GetCategory(ctx, id)
+	}, categoryID(id))
+}
+
+func (c *categoryService) GetCategoryByName(ctx context.Context, name string) (*entities.Category, error) {
+	return call(c.t, ctx, "CategoryService.GetCategoryByName", func(ctx context.Context) (*entities.Category, error) {
+		return c.s.GetCategoryByName(ctx, name)
+	})
+}
+
+func (c *categoryService) ListCategories(ctx context.Context) ([]*entities.Category, error) {
+	return call(c.t, ctx, "CategoryService.ListCategories", c.s.ListCategories)
+}
+
+func (c *categoryService) ListCategoriesByParentID(ctx context.Context, parentID *uuid.UUID) ([]*entities.Category, error) {
+	var attrs []attribute.KeyValue
+	if parentID != nil {
+		attrs = append(attrs, attribute.String("category.parent_id", parentID.String()))
+	}
+	return call(c.t, ctx, "CategoryService.ListCategoriesByParentID", func(ctx context.Context) ([]*entities.Category, error) {
+		return c.s.ListCategoriesByParentID(ctx, parentID)
+	}, attrs...)
+}
+
+func (c *categoryService) UpdateCategory(ctx context.Context, category *entities.Category) error {
+	return run(c.t, ctx, "CategoryService.UpdateCategory", func(ctx context.Context) error {
+		return c.s.UpdateCategory(ctx, category)
+	}, categoryID(category.ID))
+}
+
+func (c *categoryService) DeleteCategory(ctx context.Context, id uuid.UUID) error {
+	return run(c.t, ctx, "CategoryService.DeleteCategory", func(ctx context.Context) error {
+		return c.s.DeleteCategory(ctx, id)
+	}, categoryID(id))
+}
+
+// brandService traces the methods of a brand service
+type brandService struct {
+	s ports.BrandService
+	t *Tracer
+}
+
+// Brands wraps a brand service so that each call gets a span
+func (t *Tracer) Brands(service ports.BrandService) ports.BrandService {
+	return &brandService{s: service, t: t}
+}
+
+func (b *brandService) CreateBrand(ctx context.Context, brand *entities.Brand) error {
+	return run(b.t, ctx, "BrandService.CreateBrand", func(ctx context.Context) error {
+		return b.s.CreateBrand(ctx, brand)
+	})
+}
+
+func (b *brandService) GetBrand(ctx context.Context, id uuid.UUID) (*entities.Brand, error) {
+	return call(b.t, ctx, "BrandService.GetBrand", func(ctx context.Context) (*entities.Brand, error) {
+		return b.s.GetBrand(ctx, id)
+	}, brandID(id))
+}
+
+func (b *brandService) GetBrandByName(ctx context.Context, name string) (*entities.Brand, error) {
+	return call(b.t, ctx, "BrandService.GetBrandByName", func(ctx context.Context) (*entities.Brand, error) {
+		return b.s.GetBrandByName(ctx, name)
+	})
+}
+
+func (b *brandService) ListBrands(ctx context.Context) ([]*entities.Brand, error) {
+	return call(b.t, ctx, "BrandService.ListBrands", b.s.ListBrands)
+}
+
+func (b *brandService) UpdateBrand(ctx context.Context, brand *entities.Brand) error {
+	return run(b.t, ctx, "BrandService.UpdateBrand", func(ctx context.Context) error {
+		return b.s.UpdateBrand(ctx, brand)
+	}, brandID(brand.ID))
+}
+
+func (b *brandService) DeleteBrand(ctx context.Context, id uuid.UUID) error {
+	return run(b.t, ctx, "BrandService.DeleteBrand", func(ctx context.Context) error {
+		return b.s.DeleteBrand(ctx, id)
+	}, brandID(id))
+}
+
+// storageService traces the methods of a storage service
+type storageService struct {
+	s ports.StorageService
+	t *Tracer
+}
+
+// Files wraps a storage service so that each call gets a span
+func (t *Tracer) Files(service ports.StorageService) ports.StorageService {
+	return &storageService{s: service, t: t}
+}
+
+func (s *storageService) UploadFile(ctx context.Context, upload *entities.FileUpload) (*entities.FileMetadata, error) {
+	attrs := append(fileAt(upload.Bucket, upload.FileName), attribute.Int64("file.size", upload.Size))
+	return call(s.t, ctx, "StorageService.UploadFile", func(ctx context.Context) (*entities.FileMetadata, error) {
+		return s.s.UploadFile(ctx, upload)
+	}, attrs...)
+}
+
+func (s *storageService) ComposeFile(ctx context.Context, upload *entities.FileUpload, parts []entities.UploadPart) (*entities.FileMetadata, error) {
+	attrs := append(fileAt(upload.Bucket, upload.FileName), attribute.Int("file.parts", len(parts)))
+	return call(s.t, ctx, "StorageService.ComposeFile", func(ctx context.Context) (*entities.FileMetadata, error) {
+		return s.s.ComposeFile(ctx, upload, parts)
+	}, attrs...)
+}
+
+func (s *storageService) DeleteFile(ctx context.Context, bucket, fileName string) error {
+	return run(s.t, ctx, "StorageService.DeleteFile", func(ctx context.Context) error {
+		return s.s.DeleteFile(ctx, bucket, fileName)
+	}, fileAt(bucket, fileName)...)
+}
+
+func (s *storageService) GetFileURL(ctx context.Context, bucket, fileName string) (string, error) {
+	return call(s.t, ctx, "StorageService.GetFileURL", func(ctx context.Context) (string, error) {
+		return s.s.GetFileURL(ctx, bucket, fileName)
+	}, fileAt(bucket, fileName)...)
+}
+
+func (s *storageService) DownloadFile(ctx context.Context, bucket, fileName string, opts entities.DownloadOptions) (*entities.FileDownload, error) {
+	return call(s.t, ctx, "StorageService.DownloadFile", func(ctx context.Context) (*entities.FileDownload, error) {
+		return s.s.DownloadFile(ctx, bucket, fileName, opts)
+	}, fileAt(bucket, fileName)...)
+}
+
+func (s *storageService) CreateUploadSlot(ctx context.Context, slot *entities.UploadSlot) error {
+	return run(s.t, ctx, "StorageService.CreateUploadSlot", func(ctx context.Context) error {
+		return s.s.CreateUploadSlot(ctx, slot)
+	}, fileAt(slot.Bucket, slot.FileName)...)
+}
+
+func (s *storageService) ConfirmUpload(ctx context.Context, bucket, fileName string) (*entities.FileMetadata, error) {
+	return call(s.t, ctx, "StorageService.ConfirmUpload", func(ctx context.Context) (*entities.FileMetadata, error) {
+		return s.s.ConfirmUpload(ctx, bucket, fileName)
+	}, fileAt(bucket, fileName)...)
+}
+
+func (s *storageService) PresignDownload(ctx context.Context, bucket, fileName string) (*entities.PresignedRequest, error) {
+	return call(s.t, ctx, "StorageService.PresignDownload", func(ctx context.Context) (*entities.PresignedRequest, error) {
+		return s.s.PresignDownload(ctx, bucket, fileName)
+	}, fileAt(bucket, fileName)...)
+}
+
+func (s *storageService) GetFile(ctx context.Context, id uuid.UUID) (*entities.FileMetadata, error) {
+	return call(s.t, ctx, "StorageService.GetFile", func(ctx context.Context) (*entities.FileMetadata, error) {
+		return s.s.GetFile(ctx, id)
+	}, fileID(id))
+}
+
+func (s *storageService) ListFiles(ctx context.Context, query *entities.FileQuery) (*entities.FileQueryResult, error) {
+	return call(s.t, ctx, "StorageService.ListFiles", func(ctx context.Context) (*entities.FileQueryResult, error) {
+		return s.s.ListFiles(ctx, query)
+	})
+}
+
+func (s *storageService) DeleteFileByID(ctx context.Context, id uuid.UUID) error {
+	return run(s.t, ctx, "StorageService.DeleteFileByID", func(ctx context.Context) error {
+		return s.s.DeleteFileByID(ctx, id)
+	}, fileID(id))
+}
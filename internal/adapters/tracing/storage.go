@@ -0,0 +1,115 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	"example.com/go-yippi/internal/domain/ports"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// storageRepository traces the calls of a storage repository
+type storageRepository struct {
+	repo    ports.StorageRepository
+	backend string
+	t       *Tracer
+}
+
+// Storage wraps a storage repository of a backend, e.g. minio, so that each call gets a span with the bucket
+// and key it addresses. GetFile is traced until the content is opened, not while it is read.
+func (t *Tracer) Storage(repo ports.StorageRepository, backend string) ports.StorageRepository {
+	return &storageRepository{repo: repo, backend: backend, t: t}
+}
+
+// start starts the span of a call on a bucket
+func (s *storageRepository) start(ctx context.Context, method, bucket string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("storage.backend", s.backend), attribute.String("storage.bucket", bucket))
+	return s.t.start(ctx, "StorageRepository."+method, attrs...)
+}
+
+func (s *storageRepository) Store(ctx context.Context, bucket, fileName string, reader io.Reader, size int64, contentType string) (*entities.FileMetadata, error) {
+	ctx, span := s.start(ctx, "Store", bucket, attribute.String("storage.key", fileName), attribute.Int64("storage.size", size))
+	metadata, err := s.repo.Store(ctx, bucket, fileName, reader, size, contentType)
+	end(span, err)
+	return metadata, err
+}
+
+func (s *storageRepository) Remove(ctx context.Context, bucket, fileName string) error {
+	ctx, span := s.start(ctx, "Remove", bucket, attribute.String("storage.key", fileName))
+	err := s.repo.Remove(ctx, bucket, fileName)
+	end(span, err)
+	return err
+}
+
+func (s *storageRepository) RemovePrefix(ctx context.Context, bucket, prefix string) error {
+	ctx, span := s.start(ctx, "RemovePrefix", bucket, attribute.String("storage.prefix", prefix))
+	err := s.repo.RemovePrefix(ctx, bucket, prefix)
+	end(span, err)
+	return err
+}
+
+func (s *storageRepository) Copy(ctx context.Context, bucket, src, dst string) error {
+	ctx, span := s.start(ctx, "Copy", bucket, attribute.String("storage.source_key", src), attribute.String("storage.key", dst))
+	err := s.repo.Copy(ctx, bucket, src, dst)
+	end(span, err)
+	return err
+}
+
+func (s *storageRepository) Compose(ctx context.Context, bucket, fileName string, parts []entities.UploadPart, contentType string) (*entities.FileMetadata, error) {
+	ctx, span := s.start(ctx, "Compose", bucket, attribute.String("storage.key", fileName), attribute.Int("storage.parts", len(parts)))
+	metadata, err := s.repo.Compose(ctx, bucket, fileName, parts, contentType)
+	end(span, err)
+	return metadata, err
+}
+
+func (s *storageRepository) List(ctx context.Context, bucket, prefix string, fn func(*entities.FileMetadata) error) error {
+	ctx, span := s.start(ctx, "List", bucket, attribute.String("storage.prefix", prefix))
+	err := s.repo.List(ctx, bucket, prefix, fn)
+	end(span, err)
+	return err
+}
+
+func (s *storageRepository) GetURL(ctx context.Context, bucket, fileName string) (string, error) {
+	ctx, span := s.start(ctx, "GetURL", bucket, attribute.String("storage.key", fileName))
+	url, err := s.repo.GetURL(ctx, bucket, fileName)
+	end(span, err)
+	return url, err
+}
+
+func (s *storageRepository) GetFile(ctx context.Context, bucket, fileName string, rng *entities.ByteRange) (io.ReadCloser, int64, string, error) {
+	ctx, span := s.start(ctx, "GetFile", bucket, attribute.String("storage.key", fileName), attribute.Bool("storage.range", rng != nil))
+	content, size, contentType, err := s.repo.GetFile(ctx, bucket, fileName, rng)
+	end(span, err)
+	return content, size, contentType, err
+}
+
+func (s *storageRepository) EnsureBucket(ctx context.Context, bucket string) error {
+	ctx, span := s.start(ctx, "EnsureBucket", bucket)
+	err := s.repo.EnsureBucket(ctx, bucket)
+	end(span, err)
+	return err
+}
+
+func (s *storageRepository) Stat(ctx context.Context, bucket, fileName string) (*entities.FileMetadata, error) {
+	ctx, span := s.start(ctx, "Stat", bucket, attribute.String("storage.key", fileName))
+	metadata, err := s.repo.Stat(ctx, bucket, fileName)
+	end(span, err)
+	return metadata, err
+}
+
+func (s *storageRepository) PresignUpload(ctx context.Context, bucket, fileName, contentType string, size int64, sha256 string, expiry time.Duration) (*entities.PresignedRequest, error) {
+	ctx, span := s.start(ctx, "PresignUpload", bucket, attribute.String("storage.key", fileName))
+	request, err := s.repo.PresignUpload(ctx, bucket, fileName, contentType, size, sha256, expiry)
+	end(span, err)
+	return request, err
+}
+
+func (s *storageRepository) PresignDownload(ctx context.Context, bucket, fileName string, expiry time.Duration) (*entities.PresignedRequest, error) {
+	ctx, span := s.start(ctx, "PresignDownload", bucket, attribute.String("storage.key", fileName))
+	request, err := s.repo.PresignDownload(ctx, bucket, fileName, expiry)
+	end(span, err)
+	return request, err
+}
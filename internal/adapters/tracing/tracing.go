@@ -0,0 +1,204 @@
+// Package tracing traces requests with OpenTelemetry: a span for each operation, service call, storage call
+// and SQL statement. Trace context is propagated with W3C trace context and baggage headers.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"example.com/go-yippi/internal/infrastructure/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer the spans are created with
+const instrumentationName = "example.com/go-yippi"
+
+// maxQueryLength is the longest SQL recorded on a span; longer statements are cut
+const maxQueryLength = 2048
+
+var (
+	// sqlStringLiteral and sqlNumberLiteral match literals in SQL, which may hold data; placeholders such as
+	// $1 are matched with their $ so that they can be kept
+	sqlStringLiteral = regexp.MustCompile(`'(?:[^']|'')*'`)
+	sqlNumberLiteral = regexp.MustCompile(`\$?\b\d+(?:\.\d+)?\b`)
+)
+
+// Tracer creates the spans of the API
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	shutdown   func(context.Context) error
+}
+
+// New creates a tracer that exports spans as configured; it returns nil if the exporter is "none".
+// The tracer also becomes the global tracer provider and propagator, for libraries that use them.
+func New(ctx context.Context, cfg config.TracingConfig) (*Tracer, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "none":
+		return nil, nil
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case "otlp":
+		var opts []otlptracehttp.Option
+		if cfg.OTLPEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q: expected none, stdout or otlp", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracing exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe service: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	propagator := propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagator)
+
+	t := newTracer(provider, propagator)
+	t.shutdown = provider.Shutdown
+	return t, nil
+}
+
+// newTracer creates a tracer on a provider
+func newTracer(provider trace.TracerProvider, propagator propagation.TextMapPropagator) *Tracer {
+	return &Tracer{
+		tracer:     provider.Tracer(instrumentationName),
+		propagator: propagator,
+		shutdown:   func(context.Context) error { return nil },
+	}
+}
+
+// Shutdown exports the spans that are still buffered and stops exporting
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	return t.shutdown(ctx)
+}
+
+// StartRequest starts the span of a request to an operation, continuing the trace of the caller if the
+// headers carry one; done ends it with the status the request was answered with
+func (t *Tracer) StartRequest(ctx context.Context, operationID, method, route string, header func(string) string) (context.Context, func(status int)) {
+	ctx = t.propagator.Extract(ctx, headerCarrier(header))
+	ctx, span := t.tracer.Start(ctx, method+" "+route,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(method),
+			semconv.HTTPRoute(route),
+			attribute.String("operation.id", operationID),
+		),
+	)
+
+	return ctx, func(status int) {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		// Client errors are the client's; only server errors fail the span
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		span.End()
+	}
+}
+
+// start starts the span of a call within the API
+func (t *Tracer) start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return t.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// end ends a span with the error of its call; not finding something is an answer, not a failure
+func end(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, domainErrors.ErrNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// QueryTracer traces the SQL statements of a database
+type QueryTracer struct {
+	t      *Tracer
+	system attribute.KeyValue
+}
+
+// Queries returns a tracer of the statements run on a database of the driver, e.g. postgres
+func (t *Tracer) Queries(driver string) *QueryTracer {
+	system := semconv.DBSystemNameKey.String(driver)
+	switch driver {
+	case "postgres", "pgx":
+		system = semconv.DBSystemNamePostgreSQL
+	case "sqlite3":
+		system = semconv.DBSystemNameSQLite
+	case "mysql":
+		system = semconv.DBSystemNameMySQL
+	}
+	return &QueryTracer{t: t, system: system}
+}
+
+// StartQuery starts the span of a statement, named after the repository method it runs for. The SQL is
+// recorded without its literals.
+func (q *QueryTracer) StartQuery(ctx context.Context, method, query string) (context.Context, func(err error)) {
+	operation, _, _ := strings.Cut(strings.TrimSpace(query), " ")
+	ctx, span := q.t.tracer.Start(ctx, method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			q.system,
+			semconv.DBOperationName(strings.ToUpper(operation)),
+			semconv.DBQueryText(sanitizeSQL(query)),
+		),
+	)
+	return ctx, func(err error) { end(span, err) }
+}
+
+// sanitizeSQL replaces the literals of a statement with ?, keeping placeholders, and collapses whitespace.
+// Repositories pass values as arguments, so this only matters for statements written out by hand.
+func sanitizeSQL(query string) string {
+	query = sqlStringLiteral.ReplaceAllString(query, "'?'")
+	query = sqlNumberLiteral.ReplaceAllStringFunc(query, func(literal string) string {
+		if strings.HasPrefix(literal, "$") {
+			return literal
+		}
+		return "?"
+	})
+	query = strings.Join(strings.Fields(query), " ")
+	if len(query) > maxQueryLength {
+		query = query[:maxQueryLength] + "..."
+	}
+	return query
+}
+
+// headerCarrier reads propagated context from request headers
+type headerCarrier func(string) string
+
+func (c headerCarrier) Get(key string) string {
+	return c(key)
+}
+
+// Set is not needed to extract context
+func (c headerCarrier) Set(key, value string) {}
+
+// Keys is not needed by the propagators in use
+func (c headerCarrier) Keys() []string {
+	return nil
+}
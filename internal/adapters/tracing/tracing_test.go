@@ -0,0 +1,150 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"example.com/go-yippi/internal/domain/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newTestTracer creates a tracer whose ended spans are kept by the returned recorder
+func newTestTracer() (*Tracer, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	return newTracer(provider, propagation.TraceContext{}), recorder
+}
+
+// attributes returns the attributes of a span by key
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+// stubProductService queries products by running a statement
+type stubProductService struct {
+	ports.ProductService
+	queries *QueryTracer
+}
+
+func (s *stubProductService) QueryProducts(ctx context.Context, params *entities.QueryParams) (*entities.QueryResult, error) {
+	_, done := s.queries.StartQuery(ctx, "ProductRepository.Query", "SELECT * FROM products WHERE price > $1")
+	done(nil)
+	return &entities.QueryResult{}, nil
+}
+
+func (s *stubProductService) GetProduct(ctx context.Context, id int) (*entities.Product, error) {
+	return nil, domainErrors.NewNotFoundError("Product", id)
+}
+
+func (s *stubProductService) PublishProduct(ctx context.Context, id int) error {
+	return errors.New("database unavailable")
+}
+
+// TestTracer_StartRequest tests that requests continue the trace of their caller and that only server
+// errors fail their span
+func TestTracer_StartRequest(t *testing.T) {
+	// Arrange
+	tracer, recorder := newTestTracer()
+	headers := http.Header{}
+	headers.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	// Act
+	_, done := tracer.StartRequest(context.Background(), "list-products", "GET", "/products", headers.Get)
+	done(404)
+	_, done = tracer.StartRequest(context.Background(), "create-product", "POST", "/products", func(string) string { return "" })
+	done(500)
+
+	// Assert
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "GET /products", spans[0].Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+	assert.Equal(t, trace.SpanKindServer, spans[0].SpanKind())
+	assert.Equal(t, "list-products", attributes(spans[0])["operation.id"].AsString())
+	assert.Equal(t, int64(404), attributes(spans[0])["http.response.status_code"].AsInt64())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.False(t, spans[1].Parent().IsValid())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
+
+// TestTracer_Products tests that service calls get spans with the statements they run below them, that
+// queries record their parameters and that not finding something is no failure
+func TestTracer_Products(t *testing.T) {
+	// Arrange
+	tracer, recorder := newTestTracer()
+	products := tracer.Products(&stubProductService{queries: tracer.Queries("postgres")})
+	limit := 20
+	params := &entities.QueryParams{
+		Filters:    []entities.Filter{{Field: "category_id", Operator: entities.OpIn, Value: []string{"a", "b"}}},
+		Sort:       []entities.SortParam{{Field: "price", Order: entities.SortDesc}},
+		Pagination: &entities.PaginationParams{Limit: limit, Direction: "forward"},
+	}
+
+	// Act
+	_, queryErr := products.QueryProducts(context.Background(), params)
+	_, getErr := products.GetProduct(context.Background(), 7)
+	publishErr := products.PublishProduct(context.Background(), 7)
+
+	// Assert
+	require.NoError(t, queryErr)
+	require.Error(t, getErr)
+	require.Error(t, publishErr)
+	spans := recorder.Ended()
+	require.Len(t, spans, 4)
+
+	statement, query := spans[0], spans[1]
+	assert.Equal(t, "ProductRepository.Query", statement.Name())
+	assert.Equal(t, query.SpanContext().SpanID(), statement.Parent().SpanID())
+	assert.Equal(t, "postgresql", attributes(statement)["db.system.name"].AsString())
+	assert.Equal(t, "SELECT", attributes(statement)["db.operation.name"].AsString())
+	assert.Equal(t, "SELECT * FROM products WHERE price > $1", attributes(statement)["db.query.text"].AsString())
+
+	assert.Equal(t, "ProductService.QueryProducts", query.Name())
+	assert.Equal(t, []string{"category_id in [a b]"}, attributes(query)["query.filters"].AsStringSlice())
+	assert.Equal(t, []string{"price desc"}, attributes(query)["query.sort"].AsStringSlice())
+	assert.Equal(t, int64(limit), attributes(query)["query.limit"].AsInt64())
+
+	assert.Equal(t, codes.Unset, spans[2].Status().Code)
+	assert.Equal(t, int64(7), attributes(spans[2])["product.id"].AsInt64())
+	assert.Equal(t, codes.Error, spans[3].Status().Code)
+}
+
+// TestSanitizeSQL tests that literals are removed from statements while placeholders are kept
+func TestSanitizeSQL(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{name: "placeholders", query: "SELECT id FROM products WHERE sku = $1 LIMIT $2", want: "SELECT id FROM products WHERE sku = $1 LIMIT $2"},
+		{name: "string literal", query: "SELECT id FROM users WHERE email = 'a@b.c' AND name = 'O''Brien'", want: "SELECT id FROM users WHERE email = '?' AND name = '?'"},
+		{name: "number literal", query: "SELECT id FROM t1 WHERE price > 10.5 LIMIT 20", want: "SELECT id FROM t1 WHERE price > ? LIMIT ?"},
+		{name: "whitespace", query: "SELECT id\n\tFROM   products", want: "SELECT id FROM products"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got := sanitizeSQL(tt.query)
+
+			// Assert
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,434 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"example.com/go-yippi/internal/domain/ports"
+	"github.com/google/uuid"
+)
+
+const (
+	recoveryCodeCount    = 10
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz023456789" // 32 characters without look-alikes
+)
+
+// AuthPolicy holds the settings that drive authentication
+type AuthPolicy struct {
+	AccessTokenTTL    time.Duration
+	ChallengeTokenTTL time.Duration
+	// MaxTOTPAttempts is the number of second factors rejected at login before challenges are refused for TOTPLockout
+	MaxTOTPAttempts int
+	TOTPLockout     time.Duration
+	// SensitiveRoles must have 2FA enabled (and verified at login) before their permissions take effect
+	SensitiveRoles []entities.Role
+}
+
+// AuthService handles authentication, TOTP two-factor enrolment and login step-up
+type AuthService struct {
+	userRepo ports.UserRepository
+	hasher   ports.PasswordHasher
+	tokens   ports.TokenManager
+	totp     ports.TOTPProvider
+	policy   AuthPolicy
+	now      func() time.Time
+}
+
+func NewAuthService(userRepo ports.UserRepository, hasher ports.PasswordHasher, tokens ports.TokenManager, totp ports.TOTPProvider, policy AuthPolicy) *AuthService {
+	return &AuthService{
+		userRepo: userRepo,
+		hasher:   hasher,
+		tokens:   tokens,
+		totp:     totp,
+		policy:   policy,
+		now:      time.Now,
+	}
+}
+
+// Login verifies the email and password and returns an access token, or a challenge token if 2FA is enabled
+func (s *AuthService) Login(ctx context.Context, email, password string) (*entities.LoginResult, error) {
+	if strings.TrimSpace(email) == "" {
+		return nil, domainErrors.NewValidationError("email", "required", "Email is required")
+	}
+	if password == "" {
+		return nil, domainErrors.NewValidationError("password", "required", "Password is required")
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, strings.TrimSpace(email))
+	if err != nil {
+		if errors.Is(err, domainErrors.ErrNotFound) {
+			return nil, errInvalidCredentials
+		}
+		return nil, err
+	}
+
+	if !user.CanLogin() || s.hasher.Compare(user.PasswordHash, password) != nil {
+		return nil, errInvalidCredentials
+	}
+
+	// Step-up required: hand out a short-lived challenge instead of an access token
+	if user.TOTPEnabled {
+		if s.now().Before(user.TOTPLockedUntil) {
+			return nil, errTOTPLocked
+		}
+
+		// Only the latest challenge may be answered, so that a leaked one cannot be replayed
+		user.TOTPChallengeID = uuid.NewString()
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return nil, err
+		}
+
+		challenge, err := s.issue(user.ID, user.TOTPChallengeID, entities.TokenTypeChallenge, false, s.policy.ChallengeTokenTTL)
+		if err != nil {
+			return nil, err
+		}
+		return &entities.LoginResult{ChallengeToken: challenge, TwoFactorRequired: true}, nil
+	}
+
+	access, err := s.issue(user.ID, "", entities.TokenTypeAccess, false, s.policy.AccessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &entities.LoginResult{AccessToken: access}, nil
+}
+
+// VerifyLoginChallenge completes a login with a TOTP code or a recovery code
+func (s *AuthService) VerifyLoginChallenge(ctx context.Context, challengeToken, code string) (*entities.AuthToken, error) {
+	claims, err := s.tokens.Verify(challengeToken, entities.TokenTypeChallenge)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid or expired challenge", domainErrors.ErrUnauthorized)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.Subject)
+	if err != nil {
+		if errors.Is(err, domainErrors.ErrNotFound) {
+			return nil, fmt.Errorf("%w: invalid or expired challenge", domainErrors.ErrUnauthorized)
+		}
+		return nil, err
+	}
+
+	if !user.TOTPEnabled {
+		return nil, fmt.Errorf("%w: two-factor authentication is not enabled", domainErrors.ErrUnauthorized)
+	}
+
+	// A challenge is answered once; answered, superseded and locked out challenges are void
+	if claims.ID == "" || subtle.ConstantTimeCompare([]byte(claims.ID), []byte(user.TOTPChallengeID)) != 1 {
+		return nil, fmt.Errorf("%w: invalid or expired challenge", domainErrors.ErrUnauthorized)
+	}
+	if s.now().Before(user.TOTPLockedUntil) {
+		return nil, errTOTPLocked
+	}
+
+	if err := s.verifySecondFactor(user, code, true); err != nil {
+		if !errors.Is(err, domainErrors.ErrUnauthorized) {
+			return nil, err
+		}
+
+		// Count the rejected code; too many void the challenge and lock login challenges for a while
+		user.TOTPFailedAttempts++
+		if user.TOTPFailedAttempts >= s.policy.MaxTOTPAttempts {
+			user.TOTPChallengeID = ""
+			user.TOTPFailedAttempts = 0
+			user.TOTPLockedUntil = s.now().Add(s.policy.TOTPLockout)
+			err = errTOTPLocked
+		}
+		if updateErr := s.userRepo.Update(ctx, user); updateErr != nil {
+			return nil, updateErr
+		}
+		return nil, err
+	}
+
+	// Persist the consumed step or recovery code, and the answered challenge
+	user.TOTPChallengeID = ""
+	user.TOTPFailedAttempts = 0
+	user.TOTPLockedUntil = time.Time{}
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return s.issue(user.ID, "", entities.TokenTypeAccess, true, s.policy.AccessTokenTTL)
+}
+
+// Authenticate resolves an access token into the principal and its effective permissions
+func (s *AuthService) Authenticate(ctx context.Context, accessToken string) (*entities.Principal, error) {
+	claims, err := s.tokens.Verify(accessToken, entities.TokenTypeAccess)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid or expired token", domainErrors.ErrUnauthorized)
+	}
+
+	// Reload the user so role changes and 2FA state apply immediately
+	user, err := s.userRepo.GetByID(ctx, claims.Subject)
+	if err != nil {
+		if errors.Is(err, domainErrors.ErrNotFound) {
+			return nil, fmt.Errorf("%w: user no longer exists", domainErrors.ErrUnauthorized)
+		}
+		return nil, err
+	}
+
+	principal := &entities.Principal{
+		UserID:            user.ID,
+		Email:             user.Email,
+		Role:              user.Role,
+		TenantID:          user.TenantID,
+		TwoFactorVerified: claims.TwoFactor && user.TOTPEnabled,
+		Permissions:       user.Role.Permissions(),
+	}
+
+	// Business rule: sensitive roles get no permissions until the session is 2FA-verified
+	if s.isSensitive(user.Role) && !principal.TwoFactorVerified {
+		principal.TwoFactorRequired = true
+		principal.Permissions = nil
+	}
+
+	return principal, nil
+}
+
+// EnrollTOTP starts TOTP enrolment by generating a new secret for the user
+func (s *AuthService) EnrollTOTP(ctx context.Context, userID int) (*entities.TOTPEnrollment, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, domainErrors.NewValidationError("totp", "already_enabled", "Two-factor authentication is already enabled")
+	}
+
+	secret, err := s.totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	// Store the pending secret; it only takes effect once activated with a valid code
+	user.TOTPSecret = secret
+	user.TOTPLastStep = 0
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	accountName := user.Email
+	if accountName == "" {
+		accountName = user.Name
+	}
+
+	return &entities.TOTPEnrollment{
+		Secret: secret,
+		URI:    s.totp.URI(secret, accountName),
+	}, nil
+}
+
+// ActivateTOTP confirms enrolment with a valid code, enables 2FA and issues recovery codes
+func (s *AuthService) ActivateTOTP(ctx context.Context, userID int, code string) (*entities.TOTPActivation, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, domainErrors.NewValidationError("totp", "already_enabled", "Two-factor authentication is already enabled")
+	}
+	if user.TOTPSecret == "" {
+		return nil, domainErrors.NewValidationError("totp", "not_enrolled", "Enrolment has not been started")
+	}
+
+	if err := s.verifySecondFactor(user, code, false); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	user.TOTPEnabled = true
+	user.RecoveryCodes = hashes
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	// The current session has just proven the second factor, so upgrade it
+	access, err := s.issue(user.ID, "", entities.TokenTypeAccess, true, s.policy.AccessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.TOTPActivation{RecoveryCodes: codes, AccessToken: access}, nil
+}
+
+// DisableTOTP turns off 2FA after verifying a TOTP or recovery code
+func (s *AuthService) DisableTOTP(ctx context.Context, userID int, code string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.TOTPEnabled {
+		return domainErrors.NewValidationError("totp", "not_enabled", "Two-factor authentication is not enabled")
+	}
+
+	if err := s.verifySecondFactor(user, code, true); err != nil {
+		return err
+	}
+
+	user.TOTPEnabled = false
+	user.TOTPSecret = ""
+	user.TOTPLastStep = 0
+	user.RecoveryCodes = nil
+	user.TOTPChallengeID = ""
+	user.TOTPFailedAttempts = 0
+	user.TOTPLockedUntil = time.Time{}
+	return s.userRepo.Update(ctx, user)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after verifying a TOTP code
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.TOTPEnabled {
+		return nil, domainErrors.NewValidationError("totp", "not_enabled", "Two-factor authentication is not enabled")
+	}
+
+	if err := s.verifySecondFactor(user, code, false); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	user.RecoveryCodes = hashes
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// BootstrapAdmin creates an admin user with the given credentials if no user with that email exists
+func (s *AuthService) BootstrapAdmin(ctx context.Context, email, password string) error {
+	_, err := s.userRepo.GetByEmail(ctx, email)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, domainErrors.ErrNotFound) {
+		return err
+	}
+
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	return s.userRepo.Create(ctx, &entities.User{
+		Name:         "admin",
+		Age:          1,
+		Email:        email,
+		Role:         entities.RoleAdmin,
+		TenantID:     entities.DefaultTenantID,
+		PasswordHash: hash,
+	})
+}
+
+// verifySecondFactor checks a TOTP code (rejecting replays) or, if allowed, consumes a recovery code.
+// It records the accepted step or consumed code on the user; callers persist the user.
+func (s *AuthService) verifySecondFactor(user *entities.User, code string, allowRecovery bool) error {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return domainErrors.NewValidationError("code", "required", "Code is required")
+	}
+
+	if step, ok := s.totp.Validate(user.TOTPSecret, code, s.now()); ok {
+		if step <= user.TOTPLastStep {
+			return fmt.Errorf("%w: code has already been used", domainErrors.ErrUnauthorized)
+		}
+		user.TOTPLastStep = step
+		return nil
+	}
+
+	if allowRecovery {
+		hashed := hashRecoveryCode(code)
+		for i, stored := range user.RecoveryCodes {
+			if subtle.ConstantTimeCompare([]byte(stored), []byte(hashed)) == 1 {
+				// Recovery codes are single use
+				user.RecoveryCodes = append(user.RecoveryCodes[:i:i], user.RecoveryCodes[i+1:]...)
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("%w: invalid two-factor code", domainErrors.ErrUnauthorized)
+}
+
+// issue signs a token of the given type and ID for the user
+func (s *AuthService) issue(userID int, id string, tokenType entities.TokenType, twoFactor bool, ttl time.Duration) (*entities.AuthToken, error) {
+	now := s.now()
+	claims := entities.TokenClaims{
+		ID:        id,
+		Subject:   userID,
+		Type:      tokenType,
+		TwoFactor: twoFactor,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	token, err := s.tokens.Issue(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.AuthToken{Token: token, ExpiresAt: claims.ExpiresAt}, nil
+}
+
+// isSensitive checks if the role is configured as sensitive
+func (s *AuthService) isSensitive(role entities.Role) bool {
+	for _, sensitive := range s.policy.SensitiveRoles {
+		if sensitive == role {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	errInvalidCredentials = fmt.Errorf("%w: invalid email or password", domainErrors.ErrUnauthorized)
+	errTOTPLocked         = fmt.Errorf("%w: too many invalid two-factor codes, try again later", domainErrors.ErrUnauthorized)
+)
+
+// generateRecoveryCodes returns new recovery codes (xxxxx-xxxxx) and their hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+
+	for i := range codes {
+		raw := make([]byte, 10)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		for j, b := range raw {
+			raw[j] = recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)]
+		}
+		codes[i] = string(raw[:5]) + "-" + string(raw[5:])
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode hashes a recovery code for storage; codes are high-entropy so SHA-256 suffices
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(code))))
+	return hex.EncodeToString(sum[:])
+}
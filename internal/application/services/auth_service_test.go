@@ -0,0 +1,507 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockUserRepository is a mock implementation of ports.UserRepository
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Create(ctx context.Context, user *entities.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id int) (*entities.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*entities.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.User), args.Error(1)
+}
+
+func (m *MockUserRepository) List(ctx context.Context) ([]*entities.User, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.User), args.Error(1)
+}
+
+func (m *MockUserRepository) ListByTenant(ctx context.Context, tenantID string) ([]*entities.User, error) {
+	args := m.Called(ctx, tenantID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, user *entities.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// MockPasswordHasher is a mock implementation of ports.PasswordHasher
+type MockPasswordHasher struct {
+	mock.Mock
+}
+
+func (m *MockPasswordHasher) Hash(password string) (string, error) {
+	args := m.Called(password)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPasswordHasher) Compare(hash, password string) error {
+	args := m.Called(hash, password)
+	return args.Error(0)
+}
+
+// MockTokenManager is a mock implementation of ports.TokenManager
+type MockTokenManager struct {
+	mock.Mock
+}
+
+func (m *MockTokenManager) Issue(claims entities.TokenClaims) (string, error) {
+	args := m.Called(claims)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTokenManager) Verify(token string, tokenType entities.TokenType) (*entities.TokenClaims, error) {
+	args := m.Called(token, tokenType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.TokenClaims), args.Error(1)
+}
+
+// MockTOTPProvider is a mock implementation of ports.TOTPProvider
+type MockTOTPProvider struct {
+	mock.Mock
+}
+
+func (m *MockTOTPProvider) GenerateSecret() (string, error) {
+	args := m.Called()
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTOTPProvider) URI(secret, accountName string) string {
+	args := m.Called(secret, accountName)
+	return args.String(0)
+}
+
+func (m *MockTOTPProvider) Validate(secret, code string, at time.Time) (int64, bool) {
+	args := m.Called(secret, code, at)
+	return args.Get(0).(int64), args.Bool(1)
+}
+
+// authServiceMocks groups the mocks used by an AuthService under test
+type authServiceMocks struct {
+	repo   *MockUserRepository
+	hasher *MockPasswordHasher
+	tokens *MockTokenManager
+	totp   *MockTOTPProvider
+}
+
+// newTestAuthService creates an AuthService with mocks, a fixed clock and admin as the sensitive role
+func newTestAuthService() (*AuthService, *authServiceMocks) {
+	mocks := &authServiceMocks{
+		repo:   new(MockUserRepository),
+		hasher: new(MockPasswordHasher),
+		tokens: new(MockTokenManager),
+		totp:   new(MockTOTPProvider),
+	}
+	service := NewAuthService(mocks.repo, mocks.hasher, mocks.tokens, mocks.totp, AuthPolicy{
+		AccessTokenTTL:    time.Hour,
+		ChallengeTokenTTL: 5 * time.Minute,
+		MaxTOTPAttempts:   3,
+		TOTPLockout:       15 * time.Minute,
+		SensitiveRoles:    []entities.Role{entities.RoleAdmin},
+	})
+	service.now = func() time.Time { return time.Unix(1_700_000_000, 0) }
+	return service, mocks
+}
+
+// TestLogin_WithoutTwoFactor tests that login returns an access token when 2FA is disabled
+func TestLogin_WithoutTwoFactor(t *testing.T) {
+	// Arrange
+	service, mocks := newTestAuthService()
+	ctx := context.Background()
+	user := &entities.User{ID: 1, Email: "editor@example.com", Role: entities.RoleEditor, PasswordHash: "hash"}
+
+	mocks.repo.On("GetByEmail", ctx, "editor@example.com").Return(user, nil)
+	mocks.hasher.On("Compare", "hash", "secret-password").Return(nil)
+	mocks.tokens.On("Issue", mock.MatchedBy(func(c entities.TokenClaims) bool {
+		return c.Subject == 1 && c.Type == entities.TokenTypeAccess && !c.TwoFactor
+	})).Return("access-token", nil)
+
+	// Act
+	result, err := service.Login(ctx, "editor@example.com", "secret-password")
+
+	// Assert
+	require.NoError(t, err)
+	assert.False(t, result.TwoFactorRequired)
+	require.NotNil(t, result.AccessToken)
+	assert.Equal(t, "access-token", result.AccessToken.Token)
+	assert.Nil(t, result.ChallengeToken)
+	mocks.tokens.AssertExpectations(t)
+}
+
+// TestLogin_WithTwoFactorReturnsChallenge tests that login requires a step-up when 2FA is enabled
+func TestLogin_WithTwoFactorReturnsChallenge(t *testing.T) {
+	// Arrange
+	service, mocks := newTestAuthService()
+	ctx := context.Background()
+	user := &entities.User{ID: 1, Email: "admin@example.com", Role: entities.RoleAdmin, PasswordHash: "hash", TOTPEnabled: true}
+
+	mocks.repo.On("GetByEmail", ctx, "admin@example.com").Return(user, nil)
+	mocks.hasher.On("Compare", "hash", "secret-password").Return(nil)
+	mocks.repo.On("Update", ctx, mock.MatchedBy(func(u *entities.User) bool {
+		return u.TOTPChallengeID != ""
+	})).Return(nil)
+	mocks.tokens.On("Issue", mock.MatchedBy(func(c entities.TokenClaims) bool {
+		return c.Type == entities.TokenTypeChallenge && c.ExpiresAt.Sub(c.IssuedAt) == 5*time.Minute &&
+			c.ID == user.TOTPChallengeID
+	})).Return("challenge-token", nil)
+
+	// Act
+	result, err := service.Login(ctx, "admin@example.com", "secret-password")
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, result.TwoFactorRequired)
+	assert.Nil(t, result.AccessToken)
+	require.NotNil(t, result.ChallengeToken)
+	assert.Equal(t, "challenge-token", result.ChallengeToken.Token)
+	mocks.repo.AssertExpectations(t)
+}
+
+// TestLogin_LockedOut tests that no challenge is handed out while 2FA is locked after invalid codes
+func TestLogin_LockedOut(t *testing.T) {
+	// Arrange
+	service, mocks := newTestAuthService()
+	ctx := context.Background()
+	user := &entities.User{
+		ID: 1, Email: "admin@example.com", PasswordHash: "hash", TOTPEnabled: true,
+		TOTPLockedUntil: service.now().Add(time.Minute),
+	}
+
+	mocks.repo.On("GetByEmail", ctx, "admin@example.com").Return(user, nil)
+	mocks.hasher.On("Compare", "hash", "secret-password").Return(nil)
+
+	// Act
+	result, err := service.Login(ctx, "admin@example.com", "secret-password")
+
+	// Assert
+	assert.ErrorIs(t, err, domainErrors.ErrUnauthorized)
+	assert.Nil(t, result)
+	mocks.tokens.AssertNotCalled(t, "Issue", mock.Anything)
+}
+
+// TestLogin_InvalidCredentials tests that unknown emails and wrong passwords are indistinguishable
+func TestLogin_InvalidCredentials(t *testing.T) {
+	// Arrange
+	service, mocks := newTestAuthService()
+	ctx := context.Background()
+	user := &entities.User{ID: 1, Email: "admin@example.com", PasswordHash: "hash"}
+
+	mocks.repo.On("GetByEmail", ctx, "unknown@example.com").Return(nil, domainErrors.NewNotFoundError("User", "unknown@example.com"))
+	mocks.repo.On("GetByEmail", ctx, "admin@example.com").Return(user, nil)
+	mocks.hasher.On("Compare", "hash", "wrong-password").Return(errors.New("mismatch"))
+
+	// Act
+	_, errUnknown := service.Login(ctx, "unknown@example.com", "wrong-password")
+	_, errWrong := service.Login(ctx, "admin@example.com", "wrong-password")
+
+	// Assert
+	assert.ErrorIs(t, errUnknown, domainErrors.ErrUnauthorized)
+	assert.ErrorIs(t, errWrong, domainErrors.ErrUnauthorized)
+	assert.Equal(t, errUnknown.Error(), errWrong.Error())
+	mocks.tokens.AssertNotCalled(t, "Issue", mock.Anything)
+}
+
+// TestVerifyLoginChallenge_WithTOTPCode tests that a valid code upgrades the challenge to a 2FA access token
+func TestVerifyLoginChallenge_WithTOTPCode(t *testing.T) {
+	// Arrange
+	service, mocks := newTestAuthService()
+	ctx := context.Background()
+	user := &entities.User{
+		ID: 1, Role: entities.RoleAdmin, TOTPEnabled: true, TOTPSecret: "SECRET", TOTPLastStep: 10,
+		TOTPChallengeID: "challenge-id", TOTPFailedAttempts: 2,
+	}
+
+	mocks.tokens.On("Verify", "challenge-token", entities.TokenTypeChallenge).Return(&entities.TokenClaims{ID: "challenge-id", Subject: 1}, nil)
+	mocks.repo.On("GetByID", ctx, 1).Return(user, nil)
+	mocks.totp.On("Validate", "SECRET", "123456", mock.Anything).Return(int64(11), true)
+	mocks.repo.On("Update", ctx, mock.MatchedBy(func(u *entities.User) bool {
+		return u.TOTPLastStep == 11 && u.TOTPChallengeID == "" && u.TOTPFailedAttempts == 0
+	})).Return(nil)
+	mocks.tokens.On("Issue", mock.MatchedBy(func(c entities.TokenClaims) bool {
+		return c.Type == entities.TokenTypeAccess && c.TwoFactor
+	})).Return("access-token", nil)
+
+	// Act
+	token, err := service.VerifyLoginChallenge(ctx, "challenge-token", "123456")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "access-token", token.Token)
+	mocks.repo.AssertExpectations(t)
+}
+
+// TestVerifyLoginChallenge_RejectsReplayedCode tests that a TOTP step cannot be used twice
+func TestVerifyLoginChallenge_RejectsReplayedCode(t *testing.T) {
+	// Arrange
+	service, mocks := newTestAuthService()
+	ctx := context.Background()
+	user := &entities.User{ID: 1, TOTPEnabled: true, TOTPSecret: "SECRET", TOTPLastStep: 11, TOTPChallengeID: "challenge-id"}
+
+	mocks.tokens.On("Verify", "challenge-token", entities.TokenTypeChallenge).Return(&entities.TokenClaims{ID: "challenge-id", Subject: 1}, nil)
+	mocks.repo.On("GetByID", ctx, 1).Return(user, nil)
+	mocks.totp.On("Validate", "SECRET", "123456", mock.Anything).Return(int64(11), true)
+	mocks.repo.On("Update", ctx, mock.MatchedBy(func(u *entities.User) bool {
+		return u.TOTPLastStep == 11 && u.TOTPFailedAttempts == 1 && u.TOTPChallengeID == "challenge-id"
+	})).Return(nil)
+
+	// Act
+	token, err := service.VerifyLoginChallenge(ctx, "challenge-token", "123456")
+
+	// Assert
+	assert.ErrorIs(t, err, domainErrors.ErrUnauthorized)
+	assert.Nil(t, token)
+	mocks.repo.AssertExpectations(t)
+}
+
+// TestVerifyLoginChallenge_RejectsAnsweredChallenge tests that a challenge other than the pending one is refused
+func TestVerifyLoginChallenge_RejectsAnsweredChallenge(t *testing.T) {
+	// Arrange
+	service, mocks := newTestAuthService()
+	ctx := context.Background()
+	user := &entities.User{ID: 1, TOTPEnabled: true, TOTPSecret: "SECRET"}
+
+	mocks.tokens.On("Verify", "challenge-token", entities.TokenTypeChallenge).Return(&entities.TokenClaims{ID: "challenge-id", Subject: 1}, nil)
+	mocks.repo.On("GetByID", ctx, 1).Return(user, nil)
+
+	// Act
+	token, err := service.VerifyLoginChallenge(ctx, "challenge-token", "123456")
+
+	// Assert
+	assert.ErrorIs(t, err, domainErrors.ErrUnauthorized)
+	assert.Nil(t, token)
+	mocks.totp.AssertNotCalled(t, "Validate", mock.Anything, mock.Anything, mock.Anything)
+	mocks.repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+// TestVerifyLoginChallenge_LocksAfterMaxAttempts tests that the last allowed invalid code voids the challenge and locks 2FA
+func TestVerifyLoginChallenge_LocksAfterMaxAttempts(t *testing.T) {
+	// Arrange
+	service, mocks := newTestAuthService()
+	ctx := context.Background()
+	user := &entities.User{ID: 1, TOTPEnabled: true, TOTPSecret: "SECRET", TOTPChallengeID: "challenge-id", TOTPFailedAttempts: 2}
+
+	mocks.tokens.On("Verify", "challenge-token", entities.TokenTypeChallenge).Return(&entities.TokenClaims{ID: "challenge-id", Subject: 1}, nil)
+	mocks.repo.On("GetByID", ctx, 1).Return(user, nil)
+	mocks.totp.On("Validate", "SECRET", "000000", mock.Anything).Return(int64(0), false)
+	mocks.repo.On("Update", ctx, mock.MatchedBy(func(u *entities.User) bool {
+		return u.TOTPChallengeID == "" && u.TOTPFailedAttempts == 0 &&
+			u.TOTPLockedUntil.Equal(service.now().Add(15*time.Minute))
+	})).Return(nil)
+
+	// Act
+	token, err := service.VerifyLoginChallenge(ctx, "challenge-token", "000000")
+
+	// Assert
+	assert.ErrorIs(t, err, domainErrors.ErrUnauthorized)
+	assert.ErrorContains(t, err, "too many")
+	assert.Nil(t, token)
+	mocks.repo.AssertExpectations(t)
+}
+
+// TestVerifyLoginChallenge_ConsumesRecoveryCode tests that a recovery code works once and is removed
+func TestVerifyLoginChallenge_ConsumesRecoveryCode(t *testing.T) {
+	// Arrange
+	service, mocks := newTestAuthService()
+	ctx := context.Background()
+	user := &entities.User{
+		ID:            1,
+		TOTPEnabled:   true,
+		TOTPSecret:    "SECRET",
+		RecoveryCodes: []string{hashRecoveryCode("aaaaa-bbbbb"), hashRecoveryCode("ccccc-ddddd")},
+
+		TOTPChallengeID: "challenge-id",
+	}
+
+	mocks.tokens.On("Verify", "challenge-token", entities.TokenTypeChallenge).Return(&entities.TokenClaims{ID: "challenge-id", Subject: 1}, nil)
+	mocks.repo.On("GetByID", ctx, 1).Return(user, nil)
+	mocks.totp.On("Validate", "SECRET", "aaaaa-bbbbb", mock.Anything).Return(int64(0), false)
+	mocks.repo.On("Update", ctx, mock.MatchedBy(func(u *entities.User) bool {
+		return len(u.RecoveryCodes) == 1 && u.RecoveryCodes[0] == hashRecoveryCode("ccccc-ddddd")
+	})).Return(nil)
+	mocks.tokens.On("Issue", mock.Anything).Return("access-token", nil)
+
+	// Act
+	token, err := service.VerifyLoginChallenge(ctx, "challenge-token", "aaaaa-bbbbb")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "access-token", token.Token)
+	mocks.repo.AssertExpectations(t)
+}
+
+// TestAuthenticate_SensitiveRoleWithoutTwoFactor tests that sensitive roles get no permissions without 2FA
+func TestAuthenticate_SensitiveRoleWithoutTwoFactor(t *testing.T) {
+	// Arrange
+	service, mocks := newTestAuthService()
+	ctx := context.Background()
+	user := &entities.User{ID: 1, Role: entities.RoleAdmin}
+
+	mocks.tokens.On("Verify", "access-token", entities.TokenTypeAccess).Return(&entities.TokenClaims{Subject: 1}, nil)
+	mocks.repo.On("GetByID", ctx, 1).Return(user, nil)
+
+	// Act
+	principal, err := service.Authenticate(ctx, "access-token")
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, principal.TwoFactorRequired)
+	assert.False(t, principal.Can(entities.PermissionCatalogDelete))
+}
+
+// TestAuthenticate_SensitiveRoleWithTwoFactor tests that a 2FA-verified admin session has full permissions
+func TestAuthenticate_SensitiveRoleWithTwoFactor(t *testing.T) {
+	// Arrange
+	service, mocks := newTestAuthService()
+	ctx := context.Background()
+	user := &entities.User{ID: 1, Role: entities.RoleAdmin, TOTPEnabled: true}
+
+	mocks.tokens.On("Verify", "access-token", entities.TokenTypeAccess).Return(&entities.TokenClaims{Subject: 1, TwoFactor: true}, nil)
+	mocks.repo.On("GetByID", ctx, 1).Return(user, nil)
+
+	// Act
+	principal, err := service.Authenticate(ctx, "access-token")
+
+	// Assert
+	require.NoError(t, err)
+	assert.False(t, principal.TwoFactorRequired)
+	assert.True(t, principal.TwoFactorVerified)
+	assert.True(t, principal.Can(entities.PermissionCatalogDelete))
+	assert.True(t, principal.Can(entities.PermissionCatalogPublish))
+}
+
+// TestAuthenticate_NonSensitiveRole tests that non-sensitive roles keep their permissions without 2FA
+func TestAuthenticate_NonSensitiveRole(t *testing.T) {
+	// Arrange
+	service, mocks := newTestAuthService()
+	ctx := context.Background()
+	user := &entities.User{ID: 2, Role: entities.RoleEditor}
+
+	mocks.tokens.On("Verify", "access-token", entities.TokenTypeAccess).Return(&entities.TokenClaims{Subject: 2}, nil)
+	mocks.repo.On("GetByID", ctx, 2).Return(user, nil)
+
+	// Act
+	principal, err := service.Authenticate(ctx, "access-token")
+
+	// Assert
+	require.NoError(t, err)
+	assert.False(t, principal.TwoFactorRequired)
+	assert.True(t, principal.Can(entities.PermissionCatalogWrite))
+	assert.False(t, principal.Can(entities.PermissionCatalogDelete))
+}
+
+// TestEnrollAndActivateTOTP tests the enrolment flow from secret generation to recovery codes
+func TestEnrollAndActivateTOTP(t *testing.T) {
+	// Arrange
+	service, mocks := newTestAuthService()
+	ctx := context.Background()
+	user := &entities.User{ID: 1, Name: "admin", Email: "admin@example.com", Role: entities.RoleAdmin}
+
+	mocks.repo.On("GetByID", ctx, 1).Return(user, nil)
+	mocks.repo.On("Update", ctx, user).Return(nil)
+	mocks.totp.On("GenerateSecret").Return("SECRET", nil)
+	mocks.totp.On("URI", "SECRET", "admin@example.com").Return("otpauth://totp/go-yippi:admin%40example.com?secret=SECRET")
+	mocks.totp.On("Validate", "SECRET", "123456", mock.Anything).Return(int64(42), true)
+	mocks.tokens.On("Issue", mock.MatchedBy(func(c entities.TokenClaims) bool {
+		return c.Type == entities.TokenTypeAccess && c.TwoFactor
+	})).Return("access-token", nil)
+
+	// Act
+	enrollment, enrollErr := service.EnrollTOTP(ctx, 1)
+	activation, activateErr := service.ActivateTOTP(ctx, 1, "123456")
+
+	// Assert
+	require.NoError(t, enrollErr)
+	assert.Equal(t, "SECRET", enrollment.Secret)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/")
+
+	require.NoError(t, activateErr)
+	assert.True(t, user.TOTPEnabled)
+	assert.Equal(t, int64(42), user.TOTPLastStep)
+	assert.Len(t, activation.RecoveryCodes, recoveryCodeCount)
+	assert.Len(t, user.RecoveryCodes, recoveryCodeCount)
+	assert.NotContains(t, user.RecoveryCodes, activation.RecoveryCodes[0], "recovery codes must be stored hashed")
+	assert.Equal(t, "access-token", activation.AccessToken.Token)
+}
+
+// TestActivateTOTP_InvalidCode tests that activation fails without a valid code
+func TestActivateTOTP_InvalidCode(t *testing.T) {
+	// Arrange
+	service, mocks := newTestAuthService()
+	ctx := context.Background()
+	user := &entities.User{ID: 1, TOTPSecret: "SECRET"}
+
+	mocks.repo.On("GetByID", ctx, 1).Return(user, nil)
+	mocks.totp.On("Validate", "SECRET", "000000", mock.Anything).Return(int64(0), false)
+
+	// Act
+	activation, err := service.ActivateTOTP(ctx, 1, "000000")
+
+	// Assert
+	assert.ErrorIs(t, err, domainErrors.ErrUnauthorized)
+	assert.Nil(t, activation)
+	assert.False(t, user.TOTPEnabled)
+	mocks.repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+// TestDisableTOTP_ClearsSecretAndRecoveryCodes tests that disabling 2FA removes all 2FA state
+func TestDisableTOTP_ClearsSecretAndRecoveryCodes(t *testing.T) {
+	// Arrange
+	service, mocks := newTestAuthService()
+	ctx := context.Background()
+	user := &entities.User{ID: 1, TOTPEnabled: true, TOTPSecret: "SECRET", RecoveryCodes: []string{"hash"}}
+
+	mocks.repo.On("GetByID", ctx, 1).Return(user, nil)
+	mocks.totp.On("Validate", "SECRET", "123456", mock.Anything).Return(int64(7), true)
+	mocks.repo.On("Update", ctx, mock.MatchedBy(func(u *entities.User) bool {
+		return !u.TOTPEnabled && u.TOTPSecret == "" && u.RecoveryCodes == nil
+	})).Return(nil)
+
+	// Act
+	err := service.DisableTOTP(ctx, 1, "123456")
+
+	// Assert
+	require.NoError(t, err)
+	mocks.repo.AssertExpectations(t)
+}
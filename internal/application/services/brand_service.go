@@ -22,12 +22,12 @@ func NewBrandService(repo ports.BrandRepository) *BrandService {
 func (s *BrandService) CreateBrand(ctx context.Context, brand *entities.Brand) error {
 	// Validate required fields
 	if strings.TrimSpace(brand.Name) == "" {
-		return domainErrors.NewValidationError("name", "Name is required")
+		return domainErrors.NewValidationError("name", "required", "Name is required")
 	}
 
 	// Validate name length (must not exceed 255 characters)
 	if len(brand.Name) > 255 {
-		return domainErrors.NewValidationError("name", "Name must not exceed 255 characters")
+		return domainErrors.NewValidationError("name", "too_long", "Name must not exceed 255 characters")
 	}
 
 	return s.repo.Create(ctx, brand)
@@ -39,7 +39,7 @@ func (s *BrandService) GetBrand(ctx context.Context, id uuid.UUID) (*entities.Br
 
 func (s *BrandService) GetBrandByName(ctx context.Context, name string) (*entities.Brand, error) {
 	if strings.TrimSpace(name) == "" {
-		return nil, domainErrors.NewValidationError("name", "Name is required")
+		return nil, domainErrors.NewValidationError("name", "required", "Name is required")
 	}
 	return s.repo.GetByName(ctx, name)
 }
@@ -51,12 +51,12 @@ func (s *BrandService) ListBrands(ctx context.Context) ([]*entities.Brand, error
 func (s *BrandService) UpdateBrand(ctx context.Context, brand *entities.Brand) error {
 	// Validate required fields
 	if strings.TrimSpace(brand.Name) == "" {
-		return domainErrors.NewValidationError("name", "Name is required")
+		return domainErrors.NewValidationError("name", "required", "Name is required")
 	}
 
 	// Validate name length (must not exceed 255 characters)
 	if len(brand.Name) > 255 {
-		return domainErrors.NewValidationError("name", "Name must not exceed 255 characters")
+		return domainErrors.NewValidationError("name", "too_long", "Name must not exceed 255 characters")
 	}
 
 	return s.repo.Update(ctx, brand)
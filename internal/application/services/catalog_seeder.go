@@ -0,0 +1,462 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log/slog"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"example.com/go-yippi/internal/domain/ports"
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultSeedBatchSize is the number of products seeded at once when the options do not say
+	defaultSeedBatchSize = 1000
+	// maxSeedCategories bounds the category tree, whose size grows exponentially with its depth
+	maxSeedCategories = 10000
+)
+
+// seedRoots are the root categories a seeded catalog draws from, with what their products are called
+var seedRoots = []struct{ name, product string }{
+	{"Laptops", "Laptop"}, {"Phones", "Smartphone"}, {"Tablets", "Tablet"}, {"Monitors", "Monitor"},
+	{"Keyboards", "Keyboard"}, {"Mice", "Mouse"}, {"Headsets", "Headset"}, {"Speakers", "Speaker"},
+	{"Cameras", "Camera"}, {"Printers", "Printer"}, {"Routers", "Router"}, {"Chargers", "Charger"},
+	{"Cables", "Cable"}, {"Adapters", "Adapter"}, {"Stands", "Stand"}, {"Cases", "Case"},
+	{"Drives", "Drive"}, {"Memory", "Memory Module"}, {"Processors", "Processor"}, {"Webcams", "Webcam"},
+}
+
+// seedLines name the children of a seeded category after its own name, e.g. "Laptops Pro"
+var seedLines = []string{"Pro", "Lite", "Mini", "Max", "Plus", "Air", "Ultra", "Studio", "Go", "Classic"}
+
+// seedBrandNames are the brands a seeded catalog draws from
+var seedBrandNames = []string{
+	"TechPro", "SmartDevice", "ProGear", "EliteMax", "PrimeTech", "UltraCore", "MegaByte", "PowerEdge",
+	"SwiftTech", "NexGen", "BrightWave", "CoreLink", "Vertex", "Lumina", "Quantum", "Stellar",
+}
+
+// seedWords make up the descriptions of seeded products
+var seedWords = strings.Fields(`compact durable wireless portable premium lightweight fast quiet reliable
+	ergonomic sleek modern powerful efficient versatile smart design battery performance display sound
+	connectivity storage everyday travel office gaming home professional build quality warranty`)
+
+// seedStatuses weighs the statuses of seeded products: 70% published, 20% draft, 10% archived
+var seedStatuses = []struct {
+	status entities.ProductStatus
+	below  float64
+}{
+	{entities.ProductStatusPublished, 0.7},
+	{entities.ProductStatusDraft, 0.9},
+	{entities.ProductStatusArchived, 1},
+}
+
+// seedLeaf is a category products are seeded into, with what its products are called
+type seedLeaf struct {
+	category *entities.Category
+	product  string
+}
+
+// CatalogSeeder generates realistic catalogs through the services, so that the generated data passes the
+// rules of the API: a category tree, brands, placeholder images in storage and products spread over the
+// leaf categories and the brands with a skewed popularity, as real catalogs are.
+//
+// Generation is repeatable. Categories, brands and images are looked up by name and created if missing,
+// and products are numbered after those already in the catalog, each generated from the seed and its
+// number alone, so the same options produce the same products whatever the batch size.
+type CatalogSeeder struct {
+	products   ports.ProductService
+	categories ports.CategoryService
+	brands     ports.BrandService
+	storage    ports.StorageService
+	logger     *slog.Logger
+}
+
+// NewCatalogSeeder creates a catalog seeder; storage may be nil when no placeholder images are seeded.
+// A nil logger logs with slog.Default.
+func NewCatalogSeeder(products ports.ProductService, categories ports.CategoryService, brands ports.BrandService, storage ports.StorageService, logger *slog.Logger) *CatalogSeeder {
+	return &CatalogSeeder{
+		products:   products,
+		categories: categories,
+		brands:     brands,
+		storage:    storage,
+		logger:     orDefaultLogger(logger),
+	}
+}
+
+// Seed creates the categories, brands and placeholder images of the options that do not exist yet and
+// adds products to the catalog, logging the progress about every percent
+func (s *CatalogSeeder) Seed(ctx context.Context, opts entities.SeedOptions) (*entities.SeededCatalog, error) {
+	if err := s.validateOptions(&opts); err != nil {
+		return nil, err
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	catalog := &entities.SeededCatalog{}
+	leaves, err := s.ensureCategoryTree(ctx, opts, catalog)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create categories: %w", err)
+	}
+	if err := s.ensureBrands(ctx, opts, catalog); err != nil {
+		return nil, fmt.Errorf("failed to create brands: %w", err)
+	}
+	if err := s.ensureImages(ctx, opts, catalog); err != nil {
+		return nil, fmt.Errorf("failed to store placeholder images: %w", err)
+	}
+
+	// Continue numbering after the products already in the catalog
+	stats, err := s.products.ProductStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count products: %w", err)
+	}
+	if stats.Total > 0 && opts.Products > 0 {
+		s.logger.InfoContext(ctx, "continuing after existing products", "existing", stats.Total, "next_sku", seedSKU(stats.Total+1))
+	}
+
+	// Popularity ranks are shuffled once, so that the most popular category is not always the first.
+	// Products are generated from stream 1 onwards.
+	ranks := rand.New(rand.NewPCG(opts.Seed, 0))
+	leaves = shuffled(ranks, leaves)
+	brands := shuffled(ranks, catalog.Brands)
+
+	start := time.Now()
+	progressEvery := max(opts.Products/opts.BatchSize/100, 1) // batches between progress reports
+	for done, batchNum := 0, 1; done < opts.Products; batchNum++ {
+		batch := make([]*entities.Product, min(opts.BatchSize, opts.Products-done))
+		for i := range batch {
+			batch[i] = seedProduct(opts, stats.Total+done+i+1, leaves, brands, catalog.Images, now)
+		}
+		if err := s.products.ImportProducts(ctx, batch); err != nil {
+			return nil, fmt.Errorf("failed to create products %s to %s: %w", batch[0].SKU, batch[len(batch)-1].SKU, err)
+		}
+		if opts.KeepProducts {
+			catalog.Products = append(catalog.Products, batch...)
+		}
+		done += len(batch)
+
+		if batchNum%progressEvery == 0 || done == opts.Products {
+			elapsed := time.Since(start)
+			rate := float64(done) / elapsed.Seconds()
+			s.logger.InfoContext(ctx, "seeding products",
+				"done", done,
+				"total", opts.Products,
+				"percent", fmt.Sprintf("%.1f", float64(done)/float64(opts.Products)*100),
+				"rate_per_second", int(rate),
+				"elapsed", elapsed.Round(time.Second).String(),
+				"eta", time.Duration(float64(opts.Products-done)/rate*float64(time.Second)).Round(time.Second).String(),
+			)
+		}
+	}
+
+	catalog.Added = opts.Products
+	catalog.Total = stats.Total + opts.Products
+	return catalog, nil
+}
+
+// validateOptions checks the options and fills in the default batch size
+func (s *CatalogSeeder) validateOptions(opts *entities.SeedOptions) error {
+	verr := &domainErrors.ValidationError{}
+	for _, count := range []struct {
+		field string
+		value int
+	}{
+		{"category_depth", opts.CategoryDepth},
+		{"category_fan_out", opts.CategoryFanOut},
+		{"brands", opts.Brands},
+		{"products", opts.Products},
+		{"images", opts.Images},
+		{"min_images", opts.MinImages},
+		{"batch_size", opts.BatchSize},
+	} {
+		if count.value < 0 {
+			verr.Add(count.field, "negative", "Must not be negative")
+		}
+	}
+	if opts.Skew != 0 && opts.Skew <= 1 {
+		verr.Add("skew", "out_of_range", "Skew must be above 1, or 0 to spread products evenly")
+	}
+	if opts.CategoryDepth > 0 && opts.CategoryFanOut < 1 {
+		verr.Add("category_fan_out", "required", "A category tree needs a fan-out of at least 1")
+	} else if opts.CategoryDepth > 0 && treeSize(opts.CategoryDepth, opts.CategoryFanOut) > maxSeedCategories {
+		verr.Add("category_depth", "too_large", fmt.Sprintf("The category tree must not exceed %d categories", maxSeedCategories))
+	}
+	if opts.Images > 0 && opts.MinImages > opts.Images {
+		verr.Add("min_images", "too_many", "Products cannot have more images than there are placeholders, as a gallery holds a file once")
+	}
+	if opts.Images > 0 && s.storage == nil {
+		verr.Add("images", "unsupported", "Placeholder images need storage")
+	}
+	if err := verr.ErrOrNil(); err != nil {
+		return err
+	}
+
+	if opts.BatchSize == 0 {
+		opts.BatchSize = defaultSeedBatchSize
+	}
+	return nil
+}
+
+// ensureCategoryTree gets or creates the category tree of the options level by level and returns its leaves
+func (s *CatalogSeeder) ensureCategoryTree(ctx context.Context, opts entities.SeedOptions, catalog *entities.SeededCatalog) ([]seedLeaf, error) {
+	if opts.CategoryDepth == 0 {
+		return nil, nil
+	}
+
+	level := make([]seedLeaf, 0, opts.CategoryFanOut)
+	for i := range opts.CategoryFanOut {
+		root := seedRoots[i%len(seedRoots)]
+		category, err := s.ensureCategory(ctx, numbered(root.name, i/len(seedRoots)), nil)
+		if err != nil {
+			return nil, err
+		}
+		level = append(level, seedLeaf{category: category, product: root.product})
+	}
+	catalog.Categories = append(catalog.Categories, categoriesOf(level)...)
+
+	for range opts.CategoryDepth - 1 {
+		next := make([]seedLeaf, 0, len(level)*opts.CategoryFanOut)
+		for _, parent := range level {
+			for i := range opts.CategoryFanOut {
+				name := parent.category.Name + " " + numbered(seedLines[i%len(seedLines)], i/len(seedLines))
+				category, err := s.ensureCategory(ctx, name, &parent.category.ID)
+				if err != nil {
+					return nil, err
+				}
+				next = append(next, seedLeaf{category: category, product: parent.product})
+			}
+		}
+		catalog.Categories = append(catalog.Categories, categoriesOf(next)...)
+		level = next
+	}
+
+	catalog.Leaves = categoriesOf(level)
+	return level, nil
+}
+
+// ensureCategory returns the category with a name, creating it under parentID if it does not exist
+func (s *CatalogSeeder) ensureCategory(ctx context.Context, name string, parentID *uuid.UUID) (*entities.Category, error) {
+	category, err := s.categories.GetCategoryByName(ctx, name)
+	if errors.Is(err, domainErrors.ErrNotFound) {
+		category = &entities.Category{Name: name, ParentID: parentID}
+		err = s.categories.CreateCategory(ctx, category)
+	}
+	return category, err
+}
+
+// ensureBrands gets or creates the brands of the options
+func (s *CatalogSeeder) ensureBrands(ctx context.Context, opts entities.SeedOptions, catalog *entities.SeededCatalog) error {
+	for i := range opts.Brands {
+		name := numbered(seedBrandNames[i%len(seedBrandNames)], i/len(seedBrandNames))
+		brand, err := s.brands.GetBrandByName(ctx, name)
+		if errors.Is(err, domainErrors.ErrNotFound) {
+			brand = &entities.Brand{Name: name}
+			err = s.brands.CreateBrand(ctx, brand)
+		}
+		if err != nil {
+			return err
+		}
+		catalog.Brands = append(catalog.Brands, brand)
+	}
+	return nil
+}
+
+// ensureImages stores the placeholder images of the options. Uploading replaces a placeholder stored
+// before with the same content, so seeding again keeps its record.
+func (s *CatalogSeeder) ensureImages(ctx context.Context, opts entities.SeedOptions, catalog *entities.SeededCatalog) error {
+	for i := range opts.Images {
+		content, err := placeholderImage(opts.Seed, i)
+		if err != nil {
+			return err
+		}
+		file, err := s.storage.UploadFile(ctx, &entities.FileUpload{
+			Bucket:      opts.ImageBucket,
+			FileName:    fmt.Sprintf("seed/placeholder-%03d.png", i+1),
+			Content:     bytes.NewReader(content),
+			Size:        int64(len(content)),
+			ContentType: "image/png",
+		})
+		if err != nil {
+			return err
+		}
+		catalog.Images = append(catalog.Images, file)
+	}
+	return nil
+}
+
+// placeholderImage renders the placeholder image with index i: a diagonal gradient between two colours
+// drawn from the seed
+func placeholderImage(seed uint64, i int) ([]byte, error) {
+	rng := rand.New(rand.NewPCG(seed, ^uint64(i)))
+	from := color.RGBA{uint8(rng.IntN(256)), uint8(rng.IntN(256)), uint8(rng.IntN(256)), 255}
+	to := color.RGBA{uint8(rng.IntN(256)), uint8(rng.IntN(256)), uint8(rng.IntN(256)), 255}
+
+	const width, height = 320, 240
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			t := float64(x+y) / float64(width+height-2)
+			img.SetRGBA(x, y, color.RGBA{
+				R: uint8(float64(from.R) + t*(float64(to.R)-float64(from.R))),
+				G: uint8(float64(from.G) + t*(float64(to.G)-float64(from.G))),
+				B: uint8(float64(from.B) + t*(float64(to.B)-float64(from.B))),
+				A: 255,
+			})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// seedProduct generates the product with number num. Its randomness is seeded with the seed and the number,
+// so a product comes out the same whatever the batch size; its timestamps fall within the year before now.
+// Leaves and brands are ordered by popularity. The placeholder images drawn are set as the product's media,
+// which count towards publishing it and are attached once it is created; without placeholders, products
+// link to made-up image URLs instead.
+func seedProduct(opts entities.SeedOptions, num int, leaves []seedLeaf, brands []*entities.Brand, images []*entities.FileMetadata, now time.Time) *entities.Product {
+	rng := rand.New(rand.NewPCG(opts.Seed, uint64(num)))
+	product := &entities.Product{
+		SKU:         seedSKU(num),
+		Price:       float64(rng.IntN(4950)+50) + float64(rng.IntN(100))/100,
+		Description: seedDescription(rng),
+		Weight:      rng.IntN(4900) + 100, // grams
+		Length:      rng.IntN(40) + 10,    // cm
+		Width:       rng.IntN(30) + 10,
+		Height:      rng.IntN(25) + 5,
+		CreatedAt:   now.Add(-time.Duration(rng.IntN(365*24)) * time.Hour),
+	}
+	product.UpdatedAt = product.CreatedAt.Add(time.Duration(rng.IntN(100*24)) * time.Hour)
+	if product.UpdatedAt.After(now) {
+		product.UpdatedAt = now
+	}
+
+	draw := rng.Float64()
+	for _, weighted := range seedStatuses {
+		if draw < weighted.below {
+			product.Status = weighted.status
+			break
+		}
+	}
+
+	kind := "Gadget"
+	if len(leaves) > 0 {
+		leaf := leaves[popular(rng, opts.Skew, len(leaves))]
+		product.CategoryID = &leaf.category.ID
+		kind = leaf.product
+	}
+	brand := "Generic"
+	if len(brands) > 0 {
+		chosen := brands[popular(rng, opts.Skew, len(brands))]
+		product.BrandID = &chosen.ID
+		brand = chosen.Name
+	}
+	product.Name = fmt.Sprintf("%s %s %s", brand, kind, seedCode(rng, 3))
+	product.Slug = fmt.Sprintf("%s-%d", entities.GenerateSlug(product.Name), num)
+
+	// Products share the placeholders, starting at different ones so that they do not all look alike
+	count := max(opts.MinImages, rng.IntN(3)+1)
+	first := rng.IntN(max(len(images), 1))
+	if len(images) > 0 {
+		count = min(count, len(images))
+	}
+	for i := range count {
+		if len(images) > 0 {
+			product.Media = append(product.Media, &entities.ProductMedia{
+				FileID:    images[(first+i)%len(images)].ID,
+				Position:  i,
+				IsPrimary: i == 0,
+				Type:      entities.MediaTypeImage,
+			})
+		} else {
+			product.ImageURLs = append(product.ImageURLs, fmt.Sprintf("https://images.example.com/products/%s/%d.jpg", strings.ToLower(product.SKU), i+1))
+		}
+	}
+	return product
+}
+
+// popular draws one of n items ordered by popularity, following a Zipf distribution with exponent skew,
+// or evenly if skew is 0
+func popular(rng *rand.Rand, skew float64, n int) int {
+	if skew == 0 || n == 1 {
+		return rng.IntN(n)
+	}
+	return int(rand.NewZipf(rng, skew, 1, uint64(n-1)).Uint64())
+}
+
+// shuffled returns a shuffled copy of items
+func shuffled[T any](rng *rand.Rand, items []T) []T {
+	out := make([]T, len(items))
+	for i, j := range rng.Perm(len(items)) {
+		out[i] = items[j]
+	}
+	return out
+}
+
+// categoriesOf returns the categories of seeded leaves
+func categoriesOf(leaves []seedLeaf) []*entities.Category {
+	categories := make([]*entities.Category, len(leaves))
+	for i, leaf := range leaves {
+		categories[i] = leaf.category
+	}
+	return categories
+}
+
+// treeSize returns the number of categories in a tree of depth levels with fanOut children per category
+func treeSize(depth, fanOut int) int {
+	size, level := 0, 1
+	for range depth {
+		level *= fanOut
+		size += level
+		if size > maxSeedCategories {
+			break
+		}
+	}
+	return size
+}
+
+// numbered tells apart the names of later rounds through a list, e.g. "Laptops 2"
+func numbered(name string, round int) string {
+	if round == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s %d", name, round+1)
+}
+
+// seedSKU returns the SKU of the seeded product with number num
+func seedSKU(num int) string {
+	return fmt.Sprintf("SKU-%08d", num)
+}
+
+// seedDescription returns a sentence of 8 to 15 words
+func seedDescription(rng *rand.Rand) string {
+	words := make([]string, 8+rng.IntN(8))
+	for i := range words {
+		words[i] = seedWords[rng.IntN(len(seedWords))]
+	}
+	words[0] = strings.ToUpper(words[0][:1]) + words[0][1:]
+	return strings.Join(words, " ") + "."
+}
+
+// seedCode returns a model code of n letters and digits
+func seedCode(rng *rand.Rand, n int) string {
+	const charset = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	code := make([]byte, n)
+	for i := range code {
+		code[i] = charset[rng.IntN(len(charset))]
+	}
+	return string(code)
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCatalogSeeder_InvalidOptions tests that options are rejected before anything is seeded
+func TestCatalogSeeder_InvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts entities.SeedOptions
+		code string
+	}{
+		{"negative products", entities.SeedOptions{Products: -1}, "products.negative"},
+		{"skew of 1", entities.SeedOptions{Skew: 1}, "skew.out_of_range"},
+		{"tree without fan-out", entities.SeedOptions{CategoryDepth: 2}, "category_fan_out.required"},
+		{"tree too large", entities.SeedOptions{CategoryDepth: 5, CategoryFanOut: 10}, "category_depth.too_large"},
+		{"images without storage", entities.SeedOptions{Images: 1}, "images.unsupported"},
+		{"more images than placeholders", entities.SeedOptions{Images: 2, MinImages: 3}, "min_images.too_many"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			seeder := NewCatalogSeeder(nil, nil, nil, nil, nil)
+
+			// Act
+			catalog, err := seeder.Seed(context.Background(), tt.opts)
+
+			// Assert
+			assert.Nil(t, catalog)
+			var verr *domainErrors.ValidationError
+			require.True(t, errors.As(err, &verr))
+			assert.Equal(t, tt.code, verr.Errors[0].Code)
+		})
+	}
+}
+
+// TestSeedProduct_Repeatable tests that a product only depends on the seed and its number
+func TestSeedProduct_Repeatable(t *testing.T) {
+	// Arrange
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	leaves := []seedLeaf{{category: &entities.Category{ID: uuid.New(), Name: "Laptops Pro"}, product: "Laptop"}}
+	brands := []*entities.Brand{{ID: uuid.New(), Name: "TechPro"}, {ID: uuid.New(), Name: "Vertex"}}
+	opts := entities.SeedOptions{Seed: 7, Skew: 2, MinImages: 4}
+
+	// Act
+	first := seedProduct(opts, 42, leaves, brands, nil, now)
+	second := seedProduct(opts, 42, leaves, brands, nil, now)
+	other := seedProduct(entities.SeedOptions{Seed: 8, Skew: 2, MinImages: 4}, 42, leaves, brands, nil, now)
+
+	// Assert
+	assert.Equal(t, first, second)
+	assert.Equal(t, "SKU-00000042", first.SKU)
+	assert.NotEqual(t, first.Name, other.Name)
+	assert.Equal(t, leaves[0].category.ID, *first.CategoryID)
+	assert.Len(t, first.ImageURLs, 4)
+	assert.False(t, first.CreatedAt.After(now))
+	assert.False(t, first.UpdatedAt.Before(first.CreatedAt))
+}
+
+// TestSeedProduct_PlaceholderMedia tests that the placeholders drawn for a product become its media, in order
+// with the first one primary, rather than image URLs
+func TestSeedProduct_PlaceholderMedia(t *testing.T) {
+	// Arrange
+	images := []*entities.FileMetadata{{ID: uuid.New()}, {ID: uuid.New()}, {ID: uuid.New()}}
+	opts := entities.SeedOptions{Seed: 7, MinImages: 3}
+
+	// Act
+	product := seedProduct(opts, 42, nil, nil, images, time.Now())
+
+	// Assert
+	assert.Empty(t, product.ImageURLs)
+	require.Len(t, product.Media, 3)
+	files := map[uuid.UUID]bool{}
+	for i, media := range product.Media {
+		assert.Equal(t, i, media.Position)
+		assert.Equal(t, i == 0, media.IsPrimary)
+		assert.Equal(t, entities.MediaTypeImage, media.Type)
+		files[media.FileID] = true
+	}
+	assert.Len(t, files, 3)
+}
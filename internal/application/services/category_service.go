@@ -22,14 +22,14 @@ func NewCategoryService(repo ports.CategoryRepository) *CategoryService {
 func (s *CategoryService) CreateCategory(ctx context.Context, category *entities.Category) error {
 	// Validate required fields
 	if strings.TrimSpace(category.Name) == "" {
-		return domainErrors.NewValidationError("name", "Name is required")
+		return domainErrors.NewValidationError("name", "required", "Name is required")
 	}
 
 	// Validate parent exists if provided
 	if category.ParentID != nil {
 		_, err := s.repo.GetByID(ctx, *category.ParentID)
 		if err != nil {
-			return domainErrors.NewValidationError("parent_id", "Parent category does not exist")
+			return domainErrors.NewValidationError("parent_id", "not_found", "Parent category does not exist")
 		}
 	}
 
@@ -42,7 +42,7 @@ func (s *CategoryService) GetCategory(ctx context.Context, id uuid.UUID) (*entit
 
 func (s *CategoryService) GetCategoryByName(ctx context.Context, name string) (*entities.Category, error) {
 	if strings.TrimSpace(name) == "" {
-		return nil, domainErrors.NewValidationError("name", "Name is required")
+		return nil, domainErrors.NewValidationError("name", "required", "Name is required")
 	}
 	return s.repo.GetByName(ctx, name)
 }
@@ -56,7 +56,7 @@ func (s *CategoryService) ListCategoriesByParentID(ctx context.Context, parentID
 	if parentID != nil {
 		_, err := s.repo.GetByID(ctx, *parentID)
 		if err != nil {
-			return nil, domainErrors.NewValidationError("parent_id", "Parent category does not exist")
+			return nil, domainErrors.NewValidationError("parent_id", "not_found", "Parent category does not exist")
 		}
 	}
 
@@ -66,17 +66,17 @@ func (s *CategoryService) ListCategoriesByParentID(ctx context.Context, parentID
 func (s *CategoryService) UpdateCategory(ctx context.Context, category *entities.Category) error {
 	// Validate required fields
 	if strings.TrimSpace(category.Name) == "" {
-		return domainErrors.NewValidationError("name", "Name is required")
+		return domainErrors.NewValidationError("name", "required", "Name is required")
 	}
 
 	// Validate parent exists if provided and not the same as category ID
 	if category.ParentID != nil {
 		if *category.ParentID == category.ID {
-			return domainErrors.NewValidationError("parent_id", "Category cannot be its own parent")
+			return domainErrors.NewValidationError("parent_id", "self_reference", "Category cannot be its own parent")
 		}
 		_, err := s.repo.GetByID(ctx, *category.ParentID)
 		if err != nil {
-			return domainErrors.NewValidationError("parent_id", "Parent category does not exist")
+			return domainErrors.NewValidationError("parent_id", "not_found", "Parent category does not exist")
 		}
 	}
 
@@ -97,7 +97,7 @@ func (s *CategoryService) DeleteCategory(ctx context.Context, id uuid.UUID) erro
 	}
 
 	if len(children) > 0 {
-		return domainErrors.NewValidationError("category", "Cannot delete category with children")
+		return domainErrors.NewValidationError("category", "has_children", "Cannot delete category with children")
 	}
 
 	return s.repo.Delete(ctx, id)
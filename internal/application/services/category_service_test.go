@@ -4,121 +4,70 @@ import (
 	"context"
 	"testing"
 
+	"example.com/go-yippi/internal/adapters/memory"
 	"example.com/go-yippi/internal/domain/entities"
-	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
-// MockCategoryRepository is a mock implementation of ports.CategoryRepository
-type MockCategoryRepository struct {
-	mock.Mock
-}
-
-func (m *MockCategoryRepository) Create(ctx context.Context, category *entities.Category) error {
-	args := m.Called(ctx, category)
-	return args.Error(0)
-}
-
-func (m *MockCategoryRepository) GetByID(ctx context.Context, id int) (*entities.Category, error) {
-	args := m.Called(ctx, id)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*entities.Category), args.Error(1)
-}
-
-func (m *MockCategoryRepository) GetByName(ctx context.Context, name string) (*entities.Category, error) {
-	args := m.Called(ctx, name)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*entities.Category), args.Error(1)
-}
-
-func (m *MockCategoryRepository) List(ctx context.Context) ([]*entities.Category, error) {
-	args := m.Called(ctx)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).([]*entities.Category), args.Error(1)
-}
-
-func (m *MockCategoryRepository) ListByParentID(ctx context.Context, parentID *int) ([]*entities.Category, error) {
-	args := m.Called(ctx, parentID)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).([]*entities.Category), args.Error(1)
-}
-
-func (m *MockCategoryRepository) Update(ctx context.Context, category *entities.Category) error {
-	args := m.Called(ctx, category)
-	return args.Error(0)
-}
-
-func (m *MockCategoryRepository) Delete(ctx context.Context, id int) error {
-	args := m.Called(ctx, id)
-	return args.Error(0)
+// newCategoryService creates a category service on an in-memory repository
+func newCategoryService() (*CategoryService, *memory.CategoryRepository) {
+	repo := memory.NewCategoryRepository(memory.NewStore())
+	return NewCategoryService(repo), repo
 }
 
 // TestCreateCategory_Success tests successful category creation
 func TestCreateCategory_Success(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockCategoryRepository)
-	service := NewCategoryService(mockRepo)
-	ctx := context.Background()
+	service, repo := newCategoryService()
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
 
 	category := &entities.Category{
 		Name: "Electronics",
 	}
 
-	mockRepo.On("Create", ctx, category).Return(nil)
-
 	// Act
 	err := service.CreateCategory(ctx, category)
 
 	// Assert
 	require.NoError(t, err)
-	mockRepo.AssertExpectations(t)
+	stored, err := repo.GetByID(ctx, category.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Electronics", stored.Name)
 }
 
 // TestCreateCategory_WithParent tests successful category creation with parent
 func TestCreateCategory_WithParent(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockCategoryRepository)
-	service := NewCategoryService(mockRepo)
-	ctx := context.Background()
-
-	parentID := 1
-	category := &entities.Category{
-		Name:     "Laptops",
-		ParentID: &parentID,
-	}
+	service, repo := newCategoryService()
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
 
 	parentCategory := &entities.Category{
-		ID:   1,
 		Name: "Electronics",
 	}
+	require.NoError(t, repo.Create(ctx, parentCategory))
 
-	mockRepo.On("GetByID", ctx, parentID).Return(parentCategory, nil)
-	mockRepo.On("Create", ctx, category).Return(nil)
+	category := &entities.Category{
+		Name:     "Laptops",
+		ParentID: &parentCategory.ID,
+	}
 
 	// Act
 	err := service.CreateCategory(ctx, category)
 
 	// Assert
 	require.NoError(t, err)
-	mockRepo.AssertExpectations(t)
+	stored, err := repo.GetByID(ctx, category.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &parentCategory.ID, stored.ParentID)
 }
 
 // TestCreateCategory_ValidationError tests validation errors
 func TestCreateCategory_ValidationError(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockCategoryRepository)
-	service := NewCategoryService(mockRepo)
-	ctx := context.Background()
+	service, repo := newCategoryService()
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
 
 	category := &entities.Category{
 		Name: "", // Empty name
@@ -130,68 +79,63 @@ func TestCreateCategory_ValidationError(t *testing.T) {
 	// Assert
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "Name is required")
-	mockRepo.AssertExpectations(t)
+	assertNoCategories(t, repo, ctx)
 }
 
 // TestCreateCategory_InvalidParent tests creation with non-existent parent
 func TestCreateCategory_InvalidParent(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockCategoryRepository)
-	service := NewCategoryService(mockRepo)
-	ctx := context.Background()
+	service, repo := newCategoryService()
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
 
-	parentID := 999
+	parentID := uuid.New()
 	category := &entities.Category{
 		Name:     "Laptops",
 		ParentID: &parentID,
 	}
 
-	mockRepo.On("GetByID", ctx, parentID).Return(nil, domainErrors.NewNotFoundError("Category", parentID))
-
 	// Act
 	err := service.CreateCategory(ctx, category)
 
 	// Assert
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "Parent category does not exist")
-	mockRepo.AssertExpectations(t)
+	assertNoCategories(t, repo, ctx)
 }
 
 // TestUpdateCategory_Success tests successful category update
 func TestUpdateCategory_Success(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockCategoryRepository)
-	service := NewCategoryService(mockRepo)
-	ctx := context.Background()
+	service, repo := newCategoryService()
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
 
 	category := &entities.Category{
-		ID:   1,
-		Name: "Updated Electronics",
+		Name: "Electronics",
 	}
-
-	mockRepo.On("Update", ctx, category).Return(nil)
+	require.NoError(t, repo.Create(ctx, category))
+	category.Name = "Updated Electronics"
 
 	// Act
 	err := service.UpdateCategory(ctx, category)
 
 	// Assert
 	require.NoError(t, err)
-	mockRepo.AssertExpectations(t)
+	stored, err := repo.GetByID(ctx, category.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Updated Electronics", stored.Name)
 }
 
 // TestUpdateCategory_SelfParent tests preventing category from being its own parent
 func TestUpdateCategory_SelfParent(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockCategoryRepository)
-	service := NewCategoryService(mockRepo)
-	ctx := context.Background()
+	service, repo := newCategoryService()
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
 
-	categoryID := 1
 	category := &entities.Category{
-		ID:       categoryID,
-		Name:     "Electronics",
-		ParentID: &categoryID, // Same as ID
+		Name: "Electronics",
 	}
+	require.NoError(t, repo.Create(ctx, category))
+	category.ParentID = &category.ID // Same as ID
 
 	// Act
 	err := service.UpdateCategory(ctx, category)
@@ -199,76 +143,65 @@ func TestUpdateCategory_SelfParent(t *testing.T) {
 	// Assert
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "Category cannot be its own parent")
-	mockRepo.AssertExpectations(t)
+	stored, err := repo.GetByID(ctx, category.ID)
+	require.NoError(t, err)
+	assert.Nil(t, stored.ParentID)
 }
 
 // TestDeleteCategory_Success tests successful category deletion
 func TestDeleteCategory_Success(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockCategoryRepository)
-	service := NewCategoryService(mockRepo)
-	ctx := context.Background()
+	service, repo := newCategoryService()
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
 
-	categoryID := 1
 	category := &entities.Category{
-		ID:   categoryID,
 		Name: "Electronics",
 	}
-
-	mockRepo.On("GetByID", ctx, categoryID).Return(category, nil)
-	mockRepo.On("ListByParentID", ctx, &categoryID).Return([]*entities.Category{}, nil)
-	mockRepo.On("Delete", ctx, categoryID).Return(nil)
+	require.NoError(t, repo.Create(ctx, category))
 
 	// Act
-	err := service.DeleteCategory(ctx, categoryID)
+	err := service.DeleteCategory(ctx, category.ID)
 
 	// Assert
 	require.NoError(t, err)
-	mockRepo.AssertExpectations(t)
+	assertNoCategories(t, repo, ctx)
 }
 
 // TestDeleteCategory_WithChildren tests preventing deletion of category with children
 func TestDeleteCategory_WithChildren(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockCategoryRepository)
-	service := NewCategoryService(mockRepo)
-	ctx := context.Background()
+	service, repo := newCategoryService()
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
 
-	categoryID := 1
 	category := &entities.Category{
-		ID:   categoryID,
 		Name: "Electronics",
 	}
-
-	children := []*entities.Category{
-		{ID: 2, Name: "Laptops", ParentID: &categoryID},
-	}
-
-	mockRepo.On("GetByID", ctx, categoryID).Return(category, nil)
-	mockRepo.On("ListByParentID", ctx, &categoryID).Return(children, nil)
+	require.NoError(t, repo.Create(ctx, category))
+	require.NoError(t, repo.Create(ctx, &entities.Category{Name: "Laptops", ParentID: &category.ID}))
 
 	// Act
-	err := service.DeleteCategory(ctx, categoryID)
+	err := service.DeleteCategory(ctx, category.ID)
 
 	// Assert
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "Cannot delete category with children")
-	mockRepo.AssertExpectations(t)
+	_, err = repo.GetByID(ctx, category.ID)
+	assert.NoError(t, err)
 }
 
 // TestListCategories_Success tests successful listing of categories
 func TestListCategories_Success(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockCategoryRepository)
-	service := NewCategoryService(mockRepo)
-	ctx := context.Background()
+	service, repo := newCategoryService()
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
 
 	expectedCategories := []*entities.Category{
-		{ID: 1, Name: "Electronics"},
-		{ID: 2, Name: "Books"},
+		{Name: "Electronics"},
+		{Name: "Books"},
+	}
+	for _, category := range expectedCategories {
+		require.NoError(t, repo.Create(ctx, category))
 	}
-
-	mockRepo.On("List", ctx).Return(expectedCategories, nil)
 
 	// Act
 	categories, err := service.ListCategories(ctx)
@@ -276,35 +209,41 @@ func TestListCategories_Success(t *testing.T) {
 	// Assert
 	require.NoError(t, err)
 	assert.Equal(t, expectedCategories, categories)
-	mockRepo.AssertExpectations(t)
 }
 
 // TestListCategoriesByParentID_Success tests successful listing of categories by parent
 func TestListCategoriesByParentID_Success(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockCategoryRepository)
-	service := NewCategoryService(mockRepo)
-	ctx := context.Background()
+	service, repo := newCategoryService()
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
 
-	parentID := 1
 	parentCategory := &entities.Category{
-		ID:   parentID,
 		Name: "Electronics",
 	}
+	require.NoError(t, repo.Create(ctx, parentCategory))
 
 	expectedCategories := []*entities.Category{
-		{ID: 2, Name: "Laptops", ParentID: &parentID},
-		{ID: 3, Name: "Phones", ParentID: &parentID},
+		{Name: "Laptops", ParentID: &parentCategory.ID},
+		{Name: "Phones", ParentID: &parentCategory.ID},
 	}
-
-	mockRepo.On("GetByID", ctx, parentID).Return(parentCategory, nil)
-	mockRepo.On("ListByParentID", ctx, &parentID).Return(expectedCategories, nil)
+	for _, category := range expectedCategories {
+		require.NoError(t, repo.Create(ctx, category))
+	}
+	require.NoError(t, repo.Create(ctx, &entities.Category{Name: "Books"}))
 
 	// Act
-	categories, err := service.ListCategoriesByParentID(ctx, &parentID)
+	categories, err := service.ListCategoriesByParentID(ctx, &parentCategory.ID)
 
 	// Assert
 	require.NoError(t, err)
 	assert.Equal(t, expectedCategories, categories)
-	mockRepo.AssertExpectations(t)
+}
+
+// assertNoCategories asserts that the tenant has no categories
+func assertNoCategories(t *testing.T, repo *memory.CategoryRepository, ctx context.Context) {
+	t.Helper()
+
+	categories, err := repo.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, categories)
 }
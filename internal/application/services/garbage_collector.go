@@ -0,0 +1,297 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"example.com/go-yippi/internal/domain/ports"
+	"github.com/google/uuid"
+)
+
+// GarbageCollector removes stored objects that nothing needs anymore. It knows the layout the services store
+// objects in, <kind>/<tenant>/..., and checks each object against what owns objects of its kind:
+//
+//   - tenants/ and content/ objects hold files and are orphaned once no file record points to them
+//   - variants/<tenant>/<file ID>/ objects are orphaned once their file is deleted
+//   - resumable/<tenant>/<upload ID>/ objects are orphaned once their upload is gone
+//   - uploads/ objects are staged uploads, which are only kept until they are recorded
+//
+// Objects outside that layout are left alone, and so are objects modified within the grace period, which
+// covers uploads that are stored but not recorded yet.
+type GarbageCollector struct {
+	repo     ports.StorageRepository
+	files    ports.FileRepository
+	media    ports.ProductMediaRepository
+	products ports.ProductRepository
+	uploads  ports.ResumableUploadRepository
+	contents ports.ContentObjectRepository
+	storage  ports.StorageService
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+// NewGarbageCollector creates a garbage collector; files that no product references, neither as media nor
+// through its image URLs, are deleted through storage. A nil logger logs with slog.Default.
+func NewGarbageCollector(repo ports.StorageRepository, files ports.FileRepository, media ports.ProductMediaRepository, products ports.ProductRepository, uploads ports.ResumableUploadRepository, contents ports.ContentObjectRepository, storage ports.StorageService, logger *slog.Logger) *GarbageCollector {
+	return &GarbageCollector{
+		repo:     repo,
+		files:    files,
+		media:    media,
+		products: products,
+		uploads:  uploads,
+		contents: contents,
+		storage:  storage,
+		logger:   orDefaultLogger(logger),
+		now:      time.Now,
+	}
+}
+
+// Collect lists the buckets of the options, reports the orphans among their objects and, unless it is a dry
+// run, deletes them. Orphans that cannot be deleted are counted and logged; the run goes on without them.
+func (c *GarbageCollector) Collect(ctx context.Context, opts entities.GCOptions) (*entities.GCReport, error) {
+	report := &entities.GCReport{DryRun: opts.DryRun}
+	cutoff := c.now().Add(-opts.GracePeriod)
+	linked := map[string]*linkedImages{}
+
+	for _, bucket := range opts.Buckets {
+		// Objects are deleted once the listing is done, as some backends cannot list what changes meanwhile
+		var orphans []entities.Orphan
+		owners := map[string]bool{}
+		err := c.repo.List(ctx, bucket, "", func(object *entities.FileMetadata) error {
+			report.Scanned++
+			if !object.UploadedAt.Before(cutoff) {
+				report.Recent++
+				return nil
+			}
+
+			found, known, err := c.inspect(ctx, bucket, object, cutoff, opts, owners, linked)
+			if err != nil {
+				return err
+			}
+			if !known {
+				report.Ignored++
+			}
+			orphans = append(orphans, found...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, orphan := range orphans {
+			if !opts.DryRun {
+				deleted, err := c.delete(ctx, orphan, cutoff)
+				if err != nil {
+					c.logger.ErrorContext(ctx, "failed to delete orphaned object", "bucket", orphan.Bucket, "key", orphan.Key, "error", err)
+					report.Failed++
+				} else if !deleted {
+					// Taken into use, or deleted, since it was listed
+					report.Recent++
+					continue
+				}
+				orphan.Deleted = deleted
+			}
+			if orphan.Deleted {
+				report.Deleted++
+				report.FreedBytes += orphan.Size
+			}
+			report.Orphans = append(report.Orphans, orphan)
+		}
+	}
+
+	return report, nil
+}
+
+// inspect returns the orphans an object makes up and whether it lies in the layout the services store
+// objects in. owners caches whether the owner of variants and parts, named by their prefix, still exists;
+// linked caches the images the products of each tenant link through their image URLs.
+func (c *GarbageCollector) inspect(ctx context.Context, bucket string, object *entities.FileMetadata, cutoff time.Time, opts entities.GCOptions, owners map[string]bool, linked map[string]*linkedImages) ([]entities.Orphan, bool, error) {
+	kind, tenantID, rest, ok := splitObjectKey(object.Key)
+	if !ok {
+		return nil, false, nil
+	}
+	ctx = entities.ContextWithTenant(ctx, tenantID)
+	orphan := entities.Orphan{Bucket: bucket, Key: object.Key, Size: object.Size, LastModified: object.UploadedAt}
+
+	switch kind {
+	case "tenants", "content":
+		files, err := c.files.ListByKey(ctx, bucket, object.Key)
+		if err != nil {
+			return nil, true, err
+		}
+		if len(files) == 0 {
+			orphan.Reason = entities.OrphanUnrecorded
+			return []entities.Orphan{orphan}, true, nil
+		}
+		if !opts.UnreferencedFiles {
+			return nil, true, nil
+		}
+		images, cached := linked[tenantID]
+		if !cached {
+			urls, err := c.products.ImageURLs(ctx)
+			if err != nil {
+				return nil, true, err
+			}
+			images = parseImageURLs(urls)
+			linked[tenantID] = images
+		}
+		return c.unreferenced(ctx, files, cutoff, images)
+
+	case "variants", "resumable":
+		ownerID, _, _ := strings.Cut(rest, "/")
+		id, err := uuid.Parse(ownerID)
+		if err != nil {
+			return nil, false, nil
+		}
+		prefix := kind + "/" + tenantID + "/" + ownerID
+		exists, cached := owners[prefix]
+		if !cached {
+			exists, err = c.ownerExists(ctx, kind, id)
+			if err != nil {
+				return nil, true, err
+			}
+			owners[prefix] = exists
+		}
+		if exists {
+			return nil, true, nil
+		}
+		orphan.Reason = entities.OrphanStaleVariant
+		if kind == "resumable" {
+			orphan.Reason = entities.OrphanAbandonedPart
+		}
+		return []entities.Orphan{orphan}, true, nil
+
+	case "uploads":
+		orphan.Reason = entities.OrphanStaging
+		return []entities.Orphan{orphan}, true, nil
+	}
+
+	return nil, false, nil
+}
+
+// unreferenced returns the files of an object that no product references and that were not stored within
+// the grace period; files linked in images are referenced as much as product media are
+func (c *GarbageCollector) unreferenced(ctx context.Context, files []*entities.FileMetadata, cutoff time.Time, images *linkedImages) ([]entities.Orphan, bool, error) {
+	var orphans []entities.Orphan
+	for _, file := range files {
+		if !fileModified(file).Before(cutoff) || images.links(file) {
+			continue
+		}
+		count, err := c.media.CountByFile(ctx, file.ID)
+		if err != nil {
+			return nil, true, err
+		}
+		if count > 0 {
+			continue
+		}
+		id := file.ID
+		orphans = append(orphans, entities.Orphan{
+			Bucket:       file.Bucket,
+			Key:          file.Key,
+			Size:         file.Size,
+			LastModified: fileModified(file),
+			Reason:       entities.OrphanUnreferenced,
+			FileID:       &id,
+		})
+	}
+	return orphans, true, nil
+}
+
+// linkedImages holds the files that image URLs link, by ID and by bucket and name; names linked without a
+// bucket are held under the empty bucket, as the download route then falls back to the default bucket
+type linkedImages struct {
+	ids   map[uuid.UUID]bool
+	names map[string]map[string]bool
+}
+
+// parseImageURLs collects the files that image URLs link through the file routes of the API, i.e.
+// /files/download?bucket=<bucket>&file_name=<name> and /files/<ID>; other URLs link no stored file
+func parseImageURLs(urls []string) *linkedImages {
+	images := &linkedImages{ids: map[uuid.UUID]bool{}, names: map[string]map[string]bool{}}
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil {
+			continue
+		}
+		rest, ok := strings.CutPrefix(path.Clean(u.Path), "/files/")
+		if !ok {
+			continue
+		}
+		if rest == "download" {
+			name := u.Query().Get("file_name")
+			if name == "" {
+				continue
+			}
+			bucket := u.Query().Get("bucket")
+			if images.names[bucket] == nil {
+				images.names[bucket] = map[string]bool{}
+			}
+			images.names[bucket][name] = true
+			continue
+		}
+		id, _, _ := strings.Cut(rest, "/")
+		if fileID, err := uuid.Parse(id); err == nil {
+			images.ids[fileID] = true
+		}
+	}
+	return images
+}
+
+// links reports whether an image URL links a file
+func (l *linkedImages) links(file *entities.FileMetadata) bool {
+	return l.ids[file.ID] || l.names[file.Bucket][file.FileName] || l.names[""][file.FileName]
+}
+
+// ownerExists reports whether the file of variants or the upload of parts exists
+func (c *GarbageCollector) ownerExists(ctx context.Context, kind string, id uuid.UUID) (bool, error) {
+	var err error
+	if kind == "variants" {
+		_, err = c.files.GetByID(ctx, id)
+	} else {
+		_, err = c.uploads.GetByID(ctx, id)
+	}
+	if errors.Is(err, domainErrors.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// delete deletes an orphan and reports whether it did; content objects are kept if they were acquired since
+// the cutoff
+func (c *GarbageCollector) delete(ctx context.Context, orphan entities.Orphan, cutoff time.Time) (bool, error) {
+	_, tenantID, _, _ := splitObjectKey(orphan.Key)
+	ctx = entities.ContextWithTenant(ctx, tenantID)
+
+	if orphan.FileID != nil {
+		err := c.storage.DeleteFileByID(ctx, *orphan.FileID)
+		if errors.Is(err, domainErrors.ErrNotFound) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	remove := func() error {
+		return c.repo.Remove(ctx, orphan.Bucket, orphan.Key)
+	}
+	if isContentKey(orphan.Key) {
+		return c.contents.Delete(ctx, orphan.Bucket, orphan.Key, cutoff, remove)
+	}
+	return true, remove()
+}
+
+// splitObjectKey splits an object key into its kind, tenant and the rest, e.g. variants, acme and
+// <file ID>/thumb.webp; ok is false for keys of another layout
+func splitObjectKey(key string) (kind, tenantID, rest string, ok bool) {
+	parts := strings.SplitN(key, "/", 3)
+	if len(parts) < 3 || parts[1] == "" || parts[2] == "" {
+		return "", "", "", false
+	}
+	return parts[0], parts[1], parts[2], true
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"example.com/go-yippi/internal/adapters/memory"
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"example.com/go-yippi/internal/domain/ports"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// gcMocks holds the dependencies of a garbage collector under test
+type gcMocks struct {
+	repo     *MockStorageRepository
+	files    *MockFileRepository
+	media    *MockProductMediaRepository
+	products *countingProductRepository
+	uploads  *MockResumableUploadRepository
+	contents *MockContentObjectRepository
+	storage  *MockStorageService
+}
+
+// newTestGarbageCollector creates a garbage collector on mocks whose clock stands at now
+func newTestGarbageCollector(now time.Time) (*GarbageCollector, *gcMocks) {
+	m := &gcMocks{
+		repo:     new(MockStorageRepository),
+		files:    new(MockFileRepository),
+		media:    new(MockProductMediaRepository),
+		products: &countingProductRepository{ProductRepository: memory.NewProductRepository(memory.NewStore())},
+		uploads:  new(MockResumableUploadRepository),
+		contents: new(MockContentObjectRepository),
+		storage:  new(MockStorageService),
+	}
+	collector := NewGarbageCollector(m.repo, m.files, m.media, m.products, m.uploads, m.contents, m.storage, nil)
+	collector.now = func() time.Time { return now }
+	return collector, m
+}
+
+// countingProductRepository counts the loads of image URLs of an in-memory product repository
+type countingProductRepository struct {
+	ports.ProductRepository
+	imageURLLoads int
+}
+
+func (r *countingProductRepository) ImageURLs(ctx context.Context) ([]string, error) {
+	r.imageURLLoads++
+	return r.ProductRepository.ImageURLs(ctx)
+}
+
+// inTenant matches contexts of a tenant
+func inTenant(tenantID string) any {
+	return mock.MatchedBy(func(ctx context.Context) bool {
+		got, _ := entities.TenantFromContext(ctx)
+		return got == tenantID
+	})
+}
+
+// TestGarbageCollector_Collect tests that each kind of object is checked against its owner and that
+// orphans are deleted
+func TestGarbageCollector_Collect(t *testing.T) {
+	// Arrange
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	collector, m := newTestGarbageCollector(now)
+	old := now.Add(-48 * time.Hour)
+	deletedFile, liveUpload := uuid.New(), uuid.New()
+	object := func(key string, size int64, modified time.Time) *entities.FileMetadata {
+		return &entities.FileMetadata{Bucket: "media", Key: key, FileName: key, Size: size, UploadedAt: modified}
+	}
+
+	m.repo.On("List", mock.Anything, "media", "").Return([]*entities.FileMetadata{
+		object("tenants/acme/logo.png", 10, old),
+		object("tenants/acme/lost.png", 20, old),
+		object("content/acme/ab/abc", 30, old),
+		object("variants/acme/"+deletedFile.String()+"/thumb.webp", 4, old),
+		object("variants/acme/"+deletedFile.String()+"/large.webp", 6, old),
+		object("resumable/acme/"+liveUpload.String()+"/0", 100, old),
+		object("uploads/acme/staged", 40, old),
+		object("tenants/acme/new.png", 50, now.Add(-time.Minute)),
+		object("legacy.txt", 60, old),
+	}, nil)
+	m.files.On("ListByKey", inTenant("acme"), "media", "tenants/acme/logo.png").Return([]*entities.FileMetadata{{ID: uuid.New()}}, nil)
+	m.files.On("ListByKey", inTenant("acme"), "media", "tenants/acme/lost.png").Return([]*entities.FileMetadata{}, nil)
+	m.files.On("ListByKey", inTenant("acme"), "media", "content/acme/ab/abc").Return([]*entities.FileMetadata{}, nil)
+	m.files.On("GetByID", inTenant("acme"), deletedFile).Return(nil, domainErrors.NewNotFoundError("File", deletedFile)).Once()
+	m.uploads.On("GetByID", inTenant("acme"), liveUpload).Return(&entities.ResumableUpload{ID: liveUpload}, nil)
+	for _, key := range []string{"tenants/acme/lost.png", "content/acme/ab/abc", "variants/acme/" + deletedFile.String() + "/thumb.webp", "variants/acme/" + deletedFile.String() + "/large.webp", "uploads/acme/staged"} {
+		m.repo.On("Remove", inTenant("acme"), "media", key).Return(nil)
+	}
+	m.contents.On("Delete", inTenant("acme"), "media", "content/acme/ab/abc", now.Add(-24*time.Hour)).Return(true, nil)
+
+	// Act
+	report, err := collector.Collect(context.Background(), entities.GCOptions{Buckets: []string{"media"}, GracePeriod: 24 * time.Hour})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 9, report.Scanned)
+	assert.Equal(t, 1, report.Recent)
+	assert.Equal(t, 1, report.Ignored)
+	reasons := map[string]entities.OrphanReason{}
+	for _, orphan := range report.Orphans {
+		assert.True(t, orphan.Deleted, orphan.Key)
+		reasons[orphan.Key] = orphan.Reason
+	}
+	assert.Equal(t, map[string]entities.OrphanReason{
+		"tenants/acme/lost.png": entities.OrphanUnrecorded,
+		"content/acme/ab/abc":   entities.OrphanUnrecorded,
+		"variants/acme/" + deletedFile.String() + "/thumb.webp": entities.OrphanStaleVariant,
+		"variants/acme/" + deletedFile.String() + "/large.webp": entities.OrphanStaleVariant,
+		"uploads/acme/staged": entities.OrphanStaging,
+	}, reasons)
+	assert.Equal(t, 5, report.Deleted)
+	assert.Equal(t, int64(100), report.FreedBytes)
+	assert.Zero(t, report.Failed)
+	m.repo.AssertExpectations(t)
+	m.files.AssertExpectations(t)
+	m.contents.AssertExpectations(t)
+}
+
+// TestGarbageCollector_DryRun tests that a dry run reports orphans without deleting them
+func TestGarbageCollector_DryRun(t *testing.T) {
+	// Arrange
+	now := time.Now()
+	collector, m := newTestGarbageCollector(now)
+	m.repo.On("List", mock.Anything, "media", "").Return([]*entities.FileMetadata{
+		{Key: "tenants/acme/lost.png", Size: 20, UploadedAt: now.Add(-48 * time.Hour)},
+	}, nil)
+	m.files.On("ListByKey", mock.Anything, "media", "tenants/acme/lost.png").Return([]*entities.FileMetadata{}, nil)
+
+	// Act
+	report, err := collector.Collect(context.Background(), entities.GCOptions{Buckets: []string{"media"}, GracePeriod: time.Hour, DryRun: true})
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	require.Len(t, report.Orphans, 1)
+	assert.False(t, report.Orphans[0].Deleted)
+	assert.Zero(t, report.Deleted)
+	assert.Zero(t, report.FreedBytes)
+	m.repo.AssertNotCalled(t, "Remove", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestGarbageCollector_UnreferencedFiles tests that files no product references are deleted with their
+// records when asked to, and that failures do not stop the run
+func TestGarbageCollector_UnreferencedFiles(t *testing.T) {
+	// Arrange
+	now := time.Now()
+	collector, m := newTestGarbageCollector(now)
+	old := now.Add(-48 * time.Hour)
+	shown := &entities.FileMetadata{ID: uuid.New(), Bucket: "media", Key: "content/acme/ab/abc", Size: 30, UploadedAt: old}
+	unused := &entities.FileMetadata{ID: uuid.New(), Bucket: "media", Key: "content/acme/ab/abc", Size: 30, UploadedAt: old}
+	fresh := &entities.FileMetadata{ID: uuid.New(), Bucket: "media", Key: "content/acme/ab/abc", Size: 30, UploadedAt: now}
+	failing := &entities.FileMetadata{ID: uuid.New(), Bucket: "media", Key: "tenants/acme/old.pdf", Size: 5, UploadedAt: old}
+	storageErr := errors.New("storage unavailable")
+
+	m.repo.On("List", mock.Anything, "media", "").Return([]*entities.FileMetadata{
+		{Key: "content/acme/ab/abc", Size: 30, UploadedAt: old},
+		{Key: "tenants/acme/old.pdf", Size: 5, UploadedAt: old},
+	}, nil)
+	m.files.On("ListByKey", mock.Anything, "media", "content/acme/ab/abc").Return([]*entities.FileMetadata{shown, unused, fresh}, nil)
+	m.files.On("ListByKey", mock.Anything, "media", "tenants/acme/old.pdf").Return([]*entities.FileMetadata{failing}, nil)
+	m.media.On("CountByFile", mock.Anything, shown.ID).Return(1, nil)
+	m.media.On("CountByFile", mock.Anything, unused.ID).Return(0, nil)
+	m.media.On("CountByFile", mock.Anything, failing.ID).Return(0, nil)
+	m.storage.On("DeleteFileByID", inTenant("acme"), unused.ID).Return(nil)
+	m.storage.On("DeleteFileByID", inTenant("acme"), failing.ID).Return(storageErr)
+
+	// Act
+	report, err := collector.Collect(context.Background(), entities.GCOptions{Buckets: []string{"media"}, GracePeriod: time.Hour, UnreferencedFiles: true})
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, report.Orphans, 2)
+	assert.Equal(t, unused.ID, *report.Orphans[0].FileID)
+	assert.Equal(t, entities.OrphanUnreferenced, report.Orphans[0].Reason)
+	assert.True(t, report.Orphans[0].Deleted)
+	assert.False(t, report.Orphans[1].Deleted)
+	assert.Equal(t, 1, report.Deleted)
+	assert.Equal(t, 1, report.Failed)
+	m.storage.AssertExpectations(t)
+	m.media.AssertNotCalled(t, "CountByFile", mock.Anything, fresh.ID)
+}
+
+// TestGarbageCollector_UnreferencedKeepsImageURLs tests that files products link through their image URLs
+// are not collected as unreferenced, and that the image URLs of a tenant are loaded once
+func TestGarbageCollector_UnreferencedKeepsImageURLs(t *testing.T) {
+	// Arrange
+	now := time.Now()
+	collector, m := newTestGarbageCollector(now)
+	old := now.Add(-48 * time.Hour)
+	byName := &entities.FileMetadata{ID: uuid.New(), Bucket: "media", FileName: "shoe.png", Key: "tenants/acme/shoe.png", UploadedAt: old}
+	byDefault := &entities.FileMetadata{ID: uuid.New(), Bucket: "media", FileName: "hat.png", Key: "tenants/acme/hat.png", UploadedAt: old}
+	byID := &entities.FileMetadata{ID: uuid.New(), Bucket: "media", FileName: "bag.png", Key: "tenants/acme/bag.png", UploadedAt: old}
+	otherBucket := &entities.FileMetadata{ID: uuid.New(), Bucket: "media", FileName: "sock.png", Key: "tenants/acme/sock.png", UploadedAt: old}
+	files := []*entities.FileMetadata{byName, byDefault, byID, otherBucket}
+
+	objects := make([]*entities.FileMetadata, 0, len(files))
+	for _, file := range files {
+		objects = append(objects, &entities.FileMetadata{Key: file.Key, UploadedAt: old})
+		m.files.On("ListByKey", mock.Anything, "media", file.Key).Return([]*entities.FileMetadata{file}, nil)
+		m.media.On("CountByFile", mock.Anything, file.ID).Return(0, nil)
+	}
+	m.repo.On("List", mock.Anything, "media", "").Return(objects, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	require.NoError(t, m.products.Create(ctx, &entities.Product{
+		SKU: "SHOE-001", Slug: "shoe", Name: "Shoe", Price: 10, Status: entities.ProductStatusDraft,
+		ImageURLs: []string{"/files/download?bucket=media&file_name=shoe.png", "https://shop.example.com/files/download?file_name=hat.png"},
+	}))
+	require.NoError(t, m.products.Create(ctx, &entities.Product{
+		SKU: "BAG-001", Slug: "bag", Name: "Bag", Price: 20, Status: entities.ProductStatusDraft,
+		ImageURLs: []string{"/files/" + byID.ID.String(), "/files/download?bucket=archive&file_name=sock.png", "https://images.example.com/products/bag.png"},
+	}))
+	m.storage.On("DeleteFileByID", inTenant("acme"), otherBucket.ID).Return(nil)
+
+	// Act
+	report, err := collector.Collect(context.Background(), entities.GCOptions{Buckets: []string{"media"}, GracePeriod: time.Hour, UnreferencedFiles: true})
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, report.Orphans, 1)
+	assert.Equal(t, otherBucket.ID, *report.Orphans[0].FileID)
+	assert.Equal(t, 1, m.products.imageURLLoads)
+	m.storage.AssertNumberOfCalls(t, "DeleteFileByID", 1)
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	"example.com/go-yippi/internal/domain/ports"
+)
+
+// HealthPolicy holds the settings of readiness checks
+type HealthPolicy struct {
+	// Timeout is how long a single check may take before its dependency counts as down
+	Timeout time.Duration
+	// CacheTTL is how long a report is reused, so that frequent probes do not load the dependencies
+	CacheTTL time.Duration
+}
+
+// HealthService is a registry of health checks. Checks run concurrently, each with its own timeout, and
+// the report is cached for a while. Checks that change status are logged.
+type HealthService struct {
+	policy   HealthPolicy
+	logger   *slog.Logger
+	now      func() time.Time
+	mu       sync.Mutex
+	checkers []ports.HealthChecker
+	report   *entities.HealthReport
+	expires  time.Time
+}
+
+// NewHealthService creates a health service; a nil logger logs with slog.Default
+func NewHealthService(policy HealthPolicy, logger *slog.Logger) *HealthService {
+	return &HealthService{
+		policy: policy,
+		logger: orDefaultLogger(logger),
+		now:    time.Now,
+	}
+}
+
+// Register adds a check to the readiness report
+func (s *HealthService) Register(checker ports.HealthChecker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkers = append(s.checkers, checker)
+	s.report = nil
+}
+
+// Ready returns the cached report if it is fresh and checks the dependencies otherwise. Requests arriving
+// while the checks run wait for their report. The checks do not stop if the request that runs them is
+// cancelled, so that its report can be cached.
+func (s *HealthService) Ready(ctx context.Context) *entities.HealthReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.report != nil && s.now().Before(s.expires) {
+		return s.report
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	results := make([]entities.HealthCheckResult, len(s.checkers))
+	var wg sync.WaitGroup
+	for i, checker := range s.checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = s.check(ctx, checker)
+		}()
+	}
+	wg.Wait()
+
+	report := &entities.HealthReport{Status: entities.HealthUp, Checks: results}
+	for i, result := range results {
+		if result.Status == entities.HealthDown {
+			report.Status = entities.HealthDown
+		}
+		s.logChange(ctx, result, i)
+	}
+
+	s.report = report
+	s.expires = s.now().Add(s.policy.CacheTTL)
+	return report
+}
+
+// check runs a single check within the timeout
+func (s *HealthService) check(ctx context.Context, checker ports.HealthChecker) entities.HealthCheckResult {
+	if s.policy.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.policy.Timeout)
+		defer cancel()
+	}
+
+	start := s.now()
+	err := checker.Check(ctx)
+	result := entities.HealthCheckResult{
+		Name:      checker.Name(),
+		Status:    entities.HealthUp,
+		Latency:   s.now().Sub(start),
+		CheckedAt: start,
+	}
+	if err != nil {
+		result.Status = entities.HealthDown
+		result.Error = err.Error()
+		result.TimedOut = errors.Is(err, context.DeadlineExceeded)
+	}
+	return result
+}
+
+// logChange logs a check whose status differs from the previous report, or that failed in the first one.
+// Failing checks are logged again when their error changes, as the log is the only place it is shown.
+func (s *HealthService) logChange(ctx context.Context, result entities.HealthCheckResult, i int) {
+	previous := entities.HealthCheckResult{Status: entities.HealthUp}
+	if s.report != nil && i < len(s.report.Checks) {
+		previous = s.report.Checks[i]
+	}
+	switch {
+	case result.Status == entities.HealthDown && previous.Status == entities.HealthUp:
+		s.logger.WarnContext(ctx, "health check failed", "check", result.Name, "error", result.Error)
+	case result.Status == entities.HealthDown && result.Error != previous.Error:
+		s.logger.WarnContext(ctx, "health check still failing", "check", result.Name, "error", result.Error)
+	case result.Status == entities.HealthUp && previous.Status == entities.HealthDown:
+		s.logger.InfoContext(ctx, "health check recovered", "check", result.Name)
+	}
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubChecker counts its checks and fails with err, or blocks until its context is done
+type stubChecker struct {
+	name   string
+	err    error
+	blocks bool
+	calls  int
+}
+
+func (c *stubChecker) Name() string {
+	return c.name
+}
+
+func (c *stubChecker) Check(ctx context.Context) error {
+	c.calls++
+	if c.blocks {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return c.err
+}
+
+// TestHealthService_Ready tests that the API is down if any check fails or times out, and that each check
+// reports its own status
+func TestHealthService_Ready(t *testing.T) {
+	// Arrange
+	service := NewHealthService(HealthPolicy{Timeout: 10 * time.Millisecond}, nil)
+	service.Register(&stubChecker{name: "database"})
+	service.Register(&stubChecker{name: "storage", err: errors.New("bucket not found")})
+	service.Register(&stubChecker{name: "migrations", blocks: true})
+
+	// Act
+	report := service.Ready(context.Background())
+
+	// Assert
+	assert.Equal(t, entities.HealthDown, report.Status)
+	require.Len(t, report.Checks, 3)
+	assert.Equal(t, "database", report.Checks[0].Name)
+	assert.Equal(t, entities.HealthUp, report.Checks[0].Status)
+	assert.Equal(t, entities.HealthDown, report.Checks[1].Status)
+	assert.Equal(t, "bucket not found", report.Checks[1].Error)
+	assert.False(t, report.Checks[1].TimedOut)
+	assert.Equal(t, entities.HealthDown, report.Checks[2].Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks[2].Error)
+	assert.True(t, report.Checks[2].TimedOut)
+}
+
+// TestHealthService_Cache tests that reports are reused until they expire, and that cancelling the request
+// that runs the checks does not fail them
+func TestHealthService_Cache(t *testing.T) {
+	// Arrange
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	checker := &stubChecker{name: "database"}
+	service := NewHealthService(HealthPolicy{CacheTTL: 5 * time.Second}, nil)
+	service.now = func() time.Time { return now }
+	service.Register(checker)
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Act
+	first := service.Ready(cancelled)
+	now = now.Add(4 * time.Second)
+	cached := service.Ready(context.Background())
+	now = now.Add(2 * time.Second)
+	fresh := service.Ready(context.Background())
+
+	// Assert
+	assert.Equal(t, entities.HealthUp, first.Status)
+	assert.Same(t, first, cached)
+	assert.NotSame(t, first, fresh)
+	assert.Equal(t, 2, checker.calls)
+}
+
+// TestHealthService_LogsErrors tests that the error of a failing check is logged when it fails and again
+// when the error changes, but not on every report
+func TestHealthService_LogsErrors(t *testing.T) {
+	// Arrange
+	var logs bytes.Buffer
+	checker := &stubChecker{name: "storage", err: errors.New("bucket not found")}
+	service := NewHealthService(HealthPolicy{}, slog.New(slog.NewJSONHandler(&logs, nil)))
+	service.Register(checker)
+
+	// Act
+	service.Ready(context.Background())
+	service.Ready(context.Background())
+	checker.err = errors.New("access denied")
+	service.Ready(context.Background())
+
+	// Assert
+	assert.Equal(t, 1, strings.Count(logs.String(), `"error":"bucket not found"`))
+	assert.Contains(t, logs.String(), `"msg":"health check still failing","check":"storage","error":"access denied"`)
+}
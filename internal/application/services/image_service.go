@@ -0,0 +1,379 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"example.com/go-yippi/internal/domain/ports"
+	"github.com/google/uuid"
+)
+
+// maxTransformSize is the largest width or height of a transform
+const maxTransformSize = 4096
+
+// ImageDerivativeSpec names a transform that is rendered for every uploaded image
+type ImageDerivativeSpec struct {
+	Name      string
+	Transform entities.ImageTransform
+}
+
+// ParseImageDerivatives parses derivative sizes of the form name=WIDTHxHEIGHT[:fit], e.g. "thumb=200x200:cover",
+// and returns a spec for each size in each format. A width or height of 0 is derived from the aspect ratio.
+func ParseImageDerivatives(sizes, formats []string) ([]ImageDerivativeSpec, error) {
+	var specs []ImageDerivativeSpec
+	for _, size := range sizes {
+		name, dimensions, ok := strings.Cut(size, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid image derivative %q: expected name=WIDTHxHEIGHT[:fit]", size)
+		}
+		dimensions, fit, _ := strings.Cut(dimensions, ":")
+		w, h, ok := strings.Cut(dimensions, "x")
+		width, widthErr := strconv.Atoi(strings.TrimSpace(w))
+		height, heightErr := strconv.Atoi(strings.TrimSpace(h))
+		if !ok || widthErr != nil || heightErr != nil {
+			return nil, fmt.Errorf("invalid image derivative %q: expected name=WIDTHxHEIGHT[:fit]", size)
+		}
+
+		transform := entities.ImageTransform{Width: width, Height: height, Fit: entities.ImageFit(strings.TrimSpace(fit))}
+		if transform.Fit == "" {
+			transform.Fit = entities.ImageFitContain
+		}
+		for _, format := range formats {
+			transform.Format = entities.ImageFormat(strings.ToLower(strings.TrimSpace(format)))
+			if err := validateTransform(transform); err != nil {
+				return nil, fmt.Errorf("invalid image derivative %q: %w", size, err)
+			}
+			specs = append(specs, ImageDerivativeSpec{Name: strings.TrimSpace(name), Transform: transform})
+		}
+	}
+	return specs, nil
+}
+
+// imageJob is an uploaded image waiting to be processed
+type imageJob struct {
+	tenantID  string
+	requestID string
+	fileID    uuid.UUID
+}
+
+// ImageService renders the derivatives of uploaded images in the background and resizes images on demand.
+// Derivatives and transforms are variants of a file, stored next to it under variants/<tenant>/<file ID>/
+// and named after their transform, so derivatives double as pre-rendered transforms.
+type ImageService struct {
+	repo        ports.StorageRepository
+	files       ports.FileRepository
+	processor   ports.ImageProcessor
+	derivatives []ImageDerivativeSpec
+	// mu guards sending on jobs against closing it
+	mu      sync.Mutex
+	closed  bool
+	jobs    chan imageJob
+	workers sync.WaitGroup
+	// renders holds a slot per on-demand transform being rendered, as many as there are workers
+	renders chan struct{}
+	// base is the context of jobs, cancelled when Close gives up on the queue
+	base   context.Context
+	cancel context.CancelFunc
+	logger *slog.Logger
+}
+
+// errImageServiceClosed is returned for images stored once the service is closed
+var errImageServiceClosed = errors.New("image processing is shut down")
+
+// NewImageService creates an image service and starts its workers. Up to queueSize uploaded images wait
+// to be processed; further images are not processed, though their transforms are still rendered on demand.
+// A nil logger logs with slog.Default.
+func NewImageService(repo ports.StorageRepository, files ports.FileRepository, processor ports.ImageProcessor, derivatives []ImageDerivativeSpec, workers, queueSize int, logger *slog.Logger) *ImageService {
+	s := &ImageService{
+		repo:        repo,
+		files:       files,
+		processor:   processor,
+		derivatives: derivatives,
+		jobs:        make(chan imageJob, queueSize),
+		renders:     make(chan struct{}, max(workers, 1)),
+		logger:      orDefaultLogger(logger),
+	}
+	s.base, s.cancel = context.WithCancel(context.Background())
+
+	for i := 0; i < workers; i++ {
+		s.workers.Add(1)
+		go s.work()
+	}
+
+	return s
+}
+
+// Close stops accepting images and waits for the queued ones to be processed until ctx is done. The images
+// still queued then are dropped, and those in process are cancelled; their transforms are rendered on demand.
+func (s *ImageService) Close(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.jobs)
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		dropped := len(s.jobs)
+		s.cancel()
+		return fmt.Errorf("dropped %d queued images: %w", dropped, ctx.Err())
+	}
+}
+
+// Process discards the variants of a file whose content was stored and queues it for processing if it is an image
+func (s *ImageService) Process(ctx context.Context, file *entities.FileMetadata) error {
+	// Variants of replaced content are stale
+	err := s.RemoveVariants(ctx, file)
+	if err != nil {
+		return err
+	}
+
+	if !s.processor.Supports(file.ContentType) {
+		return nil
+	}
+
+	tenantID, _ := entities.TenantFromContext(ctx)
+	requestID, _ := entities.RequestIDFromContext(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errImageServiceClosed
+	}
+	select {
+	case s.jobs <- imageJob{tenantID: tenantID, requestID: requestID, fileID: file.ID}:
+		return nil
+	default:
+		return errors.New("image processing queue is full")
+	}
+}
+
+// Transform returns a resized and re-encoded version of an image file. Results are cached in storage.
+// The fit defaults to contain and the format to the one closest to the original.
+func (s *ImageService) Transform(ctx context.Context, id uuid.UUID, transform entities.ImageTransform) (io.ReadCloser, int64, string, error) {
+	file, err := s.files.GetByID(ctx, id)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	if !s.processor.Supports(file.ContentType) {
+		return nil, 0, "", domainErrors.NewValidationError("id", "not_an_image", "file is not an image that can be transformed")
+	}
+
+	if transform.Fit == "" {
+		transform.Fit = entities.ImageFitContain
+	}
+	if transform.Format == "" {
+		transform.Format = entities.ImageFormatFor(file.ContentType)
+	}
+	if err := validateTransform(transform); err != nil {
+		return nil, 0, "", err
+	}
+
+	variant, err := s.variantName(ctx, file, transform)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	reader, size, contentType, err := s.repo.GetFile(ctx, file.Bucket, variant, nil)
+	if err == nil {
+		return reader, size, contentType, nil
+	}
+	if !errors.Is(err, domainErrors.ErrNotFound) {
+		return nil, 0, "", err
+	}
+
+	buf, err := s.render(ctx, file, transform)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	// The result is served even if it cannot be cached
+	_, err = s.repo.Store(ctx, file.Bucket, variant, bytes.NewReader(buf.Bytes()), int64(buf.Len()), transform.Format.ContentType())
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to cache transform", "transform", transform.Name(), "file_id", file.ID, "error", err)
+	}
+
+	return io.NopCloser(buf), int64(buf.Len()), transform.Format.ContentType(), nil
+}
+
+// render decodes a file and writes its transform. Renders wait for one of the slots shared with the
+// other requests, so that a burst of uncached transforms takes no more memory than the workers do.
+func (s *ImageService) render(ctx context.Context, file *entities.FileMetadata, transform entities.ImageTransform) (*bytes.Buffer, error) {
+	select {
+	case s.renders <- struct{}{}:
+		defer func() { <-s.renders }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	img, err := s.decode(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if _, _, err := s.processor.Transform(img, transform, &buf); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+// RemoveVariants deletes the derivatives and cached transforms of a file
+func (s *ImageService) RemoveVariants(ctx context.Context, file *entities.FileMetadata) error {
+	prefix, err := s.variantPrefix(ctx, file)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.RemovePrefix(ctx, file.Bucket, prefix)
+}
+
+func (s *ImageService) work() {
+	defer s.workers.Done()
+
+	for job := range s.jobs {
+		if s.base.Err() != nil {
+			// Dropped by Close
+			continue
+		}
+		// Jobs keep the request ID of their upload, so that their failures can be traced back to it
+		ctx := entities.ContextWithTenant(s.base, job.tenantID)
+		if job.requestID != "" {
+			ctx = entities.ContextWithRequestID(ctx, job.requestID)
+		}
+		if err := s.processFile(ctx, job.fileID); err != nil {
+			s.logger.ErrorContext(ctx, "failed to process image", "file_id", job.fileID, "error", err)
+		}
+	}
+}
+
+// processFile analyses an image, renders its derivatives and records both with the file
+func (s *ImageService) processFile(ctx context.Context, id uuid.UUID) error {
+	file, err := s.files.GetByID(ctx, id)
+	if errors.Is(err, domainErrors.ErrNotFound) {
+		// Deleted while it was queued
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	img, err := s.decode(ctx, file)
+	if err != nil {
+		return err
+	}
+
+	info := s.processor.Analyze(img)
+	for _, spec := range s.derivatives {
+		derivative, err := s.renderDerivative(ctx, file, img, spec)
+		if err != nil {
+			return err
+		}
+		info.Derivatives = append(info.Derivatives, *derivative)
+	}
+
+	file.Image = info
+	err = s.files.UpdateImage(ctx, file)
+	if errors.Is(err, domainErrors.ErrNotFound) {
+		// Deleted or replaced while it was processed; a replacement is processed on its own
+		return nil
+	}
+	return err
+}
+
+// renderDerivative renders a derivative of an image and stores it as a variant of the file
+func (s *ImageService) renderDerivative(ctx context.Context, file *entities.FileMetadata, img image.Image, spec ImageDerivativeSpec) (*entities.ImageDerivative, error) {
+	var buf bytes.Buffer
+	width, height, err := s.processor.Transform(img, spec.Transform, &buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render derivative %s: %w", spec.Name, err)
+	}
+
+	variant, err := s.variantName(ctx, file, spec.Transform)
+	if err != nil {
+		return nil, err
+	}
+	size := int64(buf.Len())
+	_, err = s.repo.Store(ctx, file.Bucket, variant, &buf, size, spec.Transform.Format.ContentType())
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.ImageDerivative{
+		Name:      spec.Name,
+		Transform: spec.Transform,
+		Width:     width,
+		Height:    height,
+		Size:      size,
+	}, nil
+}
+
+// decode reads and decodes the content of an image file
+func (s *ImageService) decode(ctx context.Context, file *entities.FileMetadata) (image.Image, error) {
+	reader, _, _, err := s.repo.GetFile(ctx, file.Bucket, file.Key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	img, err := s.processor.Decode(reader)
+	if err != nil {
+		return nil, domainErrors.NewValidationError("id", "invalid_image", fmt.Sprintf("file could not be decoded as an image: %v", err))
+	}
+	return img, nil
+}
+
+// variantPrefix returns the prefix of the variants of a file, variants/<tenant>/<file ID>/. It lies outside
+// the tenants/ prefix of uploaded files, so clients cannot overwrite variants.
+func (s *ImageService) variantPrefix(ctx context.Context, file *entities.FileMetadata) (string, error) {
+	tenantID, ok := entities.TenantFromContext(ctx)
+	if !ok {
+		return "", domainErrors.ErrTenantRequired
+	}
+	return "variants/" + tenantID + "/" + file.ID.String() + "/", nil
+}
+
+// variantName returns the object name of a variant of a file
+func (s *ImageService) variantName(ctx context.Context, file *entities.FileMetadata, transform entities.ImageTransform) (string, error) {
+	prefix, err := s.variantPrefix(ctx, file)
+	if err != nil {
+		return "", err
+	}
+	return prefix + transform.Name(), nil
+}
+
+// validateTransform checks the size, fit and format of a transform
+func validateTransform(transform entities.ImageTransform) error {
+	validationErr := &domainErrors.ValidationError{}
+	if transform.Width < 0 || transform.Width > maxTransformSize {
+		validationErr.Add("w", "out_of_range", fmt.Sprintf("width must be between 0 and %d", maxTransformSize))
+	}
+	if transform.Height < 0 || transform.Height > maxTransformSize {
+		validationErr.Add("h", "out_of_range", fmt.Sprintf("height must be between 0 and %d", maxTransformSize))
+	}
+	if !transform.Fit.IsValid() {
+		validationErr.Add("fit", "invalid", "fit must be contain, cover or fill")
+	}
+	if !transform.Format.IsValid() {
+		validationErr.Add("format", "invalid", "format must be jpeg, png or webp")
+	}
+	return validationErr.ErrOrNil()
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"image"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockImageProcessor is a mock implementation of ports.ImageProcessor
+type MockImageProcessor struct {
+	mock.Mock
+}
+
+func (m *MockImageProcessor) Supports(contentType string) bool {
+	return strings.HasPrefix(contentType, "image/")
+}
+
+func (m *MockImageProcessor) Decode(r io.Reader) (image.Image, error) {
+	args := m.Called(r)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(image.Image), args.Error(1)
+}
+
+func (m *MockImageProcessor) Analyze(img image.Image) *entities.ImageInfo {
+	args := m.Called(img)
+	return args.Get(0).(*entities.ImageInfo)
+}
+
+func (m *MockImageProcessor) Transform(img image.Image, transform entities.ImageTransform, w io.Writer) (int, int, error) {
+	args := m.Called(img, transform, w)
+	_, _ = io.WriteString(w, transform.Name())
+	return args.Int(0), args.Int(1), args.Error(2)
+}
+
+// TestParseImageDerivatives tests that each size is rendered in each format
+func TestParseImageDerivatives(t *testing.T) {
+	// Act
+	specs, err := ParseImageDerivatives([]string{"thumb=200x200:cover", " large = 1600x0 "}, []string{"webp", "JPEG"})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []ImageDerivativeSpec{
+		{Name: "thumb", Transform: entities.ImageTransform{Width: 200, Height: 200, Fit: entities.ImageFitCover, Format: entities.ImageFormatWebP}},
+		{Name: "thumb", Transform: entities.ImageTransform{Width: 200, Height: 200, Fit: entities.ImageFitCover, Format: entities.ImageFormatJPEG}},
+		{Name: "large", Transform: entities.ImageTransform{Width: 1600, Height: 0, Fit: entities.ImageFitContain, Format: entities.ImageFormatWebP}},
+		{Name: "large", Transform: entities.ImageTransform{Width: 1600, Height: 0, Fit: entities.ImageFitContain, Format: entities.ImageFormatJPEG}},
+	}, specs)
+}
+
+// TestParseImageDerivatives_Invalid tests that malformed sizes, fits and formats are rejected
+func TestParseImageDerivatives_Invalid(t *testing.T) {
+	cases := []struct {
+		name    string
+		sizes   []string
+		formats []string
+	}{
+		{name: "missing name", sizes: []string{"200x200"}, formats: []string{"webp"}},
+		{name: "missing height", sizes: []string{"thumb=200"}, formats: []string{"webp"}},
+		{name: "too large", sizes: []string{"huge=10000x10000"}, formats: []string{"webp"}},
+		{name: "unknown fit", sizes: []string{"thumb=200x200:stretch"}, formats: []string{"webp"}},
+		{name: "unknown format", sizes: []string{"thumb=200x200"}, formats: []string{"avif"}},
+	}
+
+	for _, tc := range cases {
+		_, err := ParseImageDerivatives(tc.sizes, tc.formats)
+		assert.Error(t, err, tc.name)
+	}
+}
+
+// TestImageService_Transform_Cached tests that a cached transform is served without decoding the image
+func TestImageService_Transform_Cached(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	processor := new(MockImageProcessor)
+	service := NewImageService(mockRepo, mockFiles, processor, nil, 0, 1, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	file := &entities.FileMetadata{ID: uuid.New(), Bucket: "default-bucket", Key: "tenants/acme/logo.png", ContentType: "image/png"}
+
+	mockFiles.On("GetByID", ctx, file.ID).Return(file, nil)
+	mockRepo.On("GetFile", ctx, "default-bucket", "variants/acme/"+file.ID.String()+"/100x0_contain.png", (*entities.ByteRange)(nil)).
+		Return(io.NopCloser(strings.NewReader("cached")), int64(6), "image/png", nil)
+
+	// Act: fit and format default to contain and the format of the original
+	reader, size, contentType, err := service.Transform(ctx, file.ID, entities.ImageTransform{Width: 100})
+
+	// Assert
+	require.NoError(t, err)
+	data, _ := io.ReadAll(reader)
+	assert.Equal(t, "cached", string(data))
+	assert.Equal(t, int64(6), size)
+	assert.Equal(t, "image/png", contentType)
+	mockRepo.AssertExpectations(t)
+	processor.AssertNotCalled(t, "Decode", mock.Anything)
+}
+
+// TestImageService_Transform_RendersAndCaches tests that a missing transform is rendered and stored
+func TestImageService_Transform_RendersAndCaches(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	processor := new(MockImageProcessor)
+	service := NewImageService(mockRepo, mockFiles, processor, nil, 0, 1, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	file := &entities.FileMetadata{ID: uuid.New(), Bucket: "default-bucket", Key: "tenants/acme/photo.jpg", ContentType: "image/jpeg"}
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	transform := entities.ImageTransform{Width: 50, Height: 50, Fit: entities.ImageFitCover, Format: entities.ImageFormatWebP}
+	variant := "variants/acme/" + file.ID.String() + "/50x50_cover.webp"
+
+	mockFiles.On("GetByID", ctx, file.ID).Return(file, nil)
+	mockRepo.On("GetFile", ctx, "default-bucket", variant, (*entities.ByteRange)(nil)).Return(nil, int64(0), "", domainErrors.NewNotFoundError("file", variant))
+	mockRepo.On("GetFile", ctx, "default-bucket", file.Key, (*entities.ByteRange)(nil)).Return(io.NopCloser(strings.NewReader("jpeg")), int64(4), "image/jpeg", nil)
+	processor.On("Decode", mock.Anything).Return(img, nil)
+	processor.On("Transform", img, transform, mock.Anything).Return(50, 50, nil)
+	mockRepo.On("Store", ctx, "default-bucket", variant, mock.Anything, int64(len(transform.Name())), "image/webp").Return(&entities.FileMetadata{}, nil)
+
+	// Act
+	reader, size, contentType, err := service.Transform(ctx, file.ID, transform)
+
+	// Assert
+	require.NoError(t, err)
+	data, _ := io.ReadAll(reader)
+	assert.Equal(t, transform.Name(), string(data))
+	assert.Equal(t, int64(len(data)), size)
+	assert.Equal(t, "image/webp", contentType)
+	mockRepo.AssertExpectations(t)
+	processor.AssertExpectations(t)
+}
+
+// TestImageService_Transform_WaitsForRender tests that uncached transforms wait for a free render slot,
+// giving up when the request is cancelled
+func TestImageService_Transform_WaitsForRender(t *testing.T) {
+	// Arrange: the only slot is taken
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	processor := new(MockImageProcessor)
+	service := NewImageService(mockRepo, mockFiles, processor, nil, 0, 1, nil)
+	service.renders <- struct{}{}
+	ctx, cancel := context.WithTimeout(entities.ContextWithTenant(context.Background(), "acme"), 10*time.Millisecond)
+	defer cancel()
+	file := &entities.FileMetadata{ID: uuid.New(), Bucket: "default-bucket", Key: "tenants/acme/photo.jpg", ContentType: "image/jpeg"}
+	variant := "variants/acme/" + file.ID.String() + "/50x0_contain.jpeg"
+
+	mockFiles.On("GetByID", ctx, file.ID).Return(file, nil)
+	mockRepo.On("GetFile", ctx, "default-bucket", variant, (*entities.ByteRange)(nil)).Return(nil, int64(0), "", domainErrors.NewNotFoundError("file", variant))
+
+	// Act
+	_, _, _, err := service.Transform(ctx, file.ID, entities.ImageTransform{Width: 50})
+
+	// Assert
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	processor.AssertNotCalled(t, "Decode", mock.Anything)
+}
+
+// TestImageService_Transform_Validation tests that files that are not images and invalid transforms are rejected
+func TestImageService_Transform_Validation(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	service := NewImageService(mockRepo, mockFiles, new(MockImageProcessor), nil, 0, 1, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	pdf := &entities.FileMetadata{ID: uuid.New(), Bucket: "default-bucket", Key: "tenants/acme/spec.pdf", ContentType: "application/pdf"}
+	photo := &entities.FileMetadata{ID: uuid.New(), Bucket: "default-bucket", Key: "tenants/acme/photo.jpg", ContentType: "image/jpeg"}
+
+	mockFiles.On("GetByID", ctx, pdf.ID).Return(pdf, nil)
+	mockFiles.On("GetByID", ctx, photo.ID).Return(photo, nil)
+
+	// Act
+	_, _, _, pdfErr := service.Transform(ctx, pdf.ID, entities.ImageTransform{Width: 100})
+	_, _, _, sizeErr := service.Transform(ctx, photo.ID, entities.ImageTransform{Width: 5000, Fit: "stretch"})
+
+	// Assert
+	var validationErr *domainErrors.ValidationError
+	require.True(t, errors.As(pdfErr, &validationErr))
+	assert.Equal(t, "id.not_an_image", validationErr.Errors[0].Code)
+	require.True(t, errors.As(sizeErr, &validationErr))
+	require.Len(t, validationErr.Errors, 2)
+	assert.Equal(t, "w.out_of_range", validationErr.Errors[0].Code)
+	assert.Equal(t, "fit.invalid", validationErr.Errors[1].Code)
+	mockRepo.AssertNotCalled(t, "GetFile", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestImageService_Process tests that stored images have their stale variants removed, their derivatives
+// rendered and their image info recorded
+func TestImageService_Process(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	processor := new(MockImageProcessor)
+	thumb := ImageDerivativeSpec{Name: "thumb", Transform: entities.ImageTransform{Width: 20, Height: 20, Fit: entities.ImageFitCover, Format: entities.ImageFormatWebP}}
+	service := NewImageService(mockRepo, mockFiles, processor, []ImageDerivativeSpec{thumb}, 1, 1, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	file := &entities.FileMetadata{ID: uuid.New(), Bucket: "default-bucket", Key: "tenants/acme/photo.jpg", ContentType: "image/jpeg", SHA256: "abc"}
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 30))
+	prefix := "variants/acme/" + file.ID.String() + "/"
+
+	mockRepo.On("RemovePrefix", ctx, "default-bucket", prefix).Return(nil)
+	mockFiles.On("GetByID", mock.Anything, file.ID).Return(file, nil)
+	mockRepo.On("GetFile", mock.Anything, "default-bucket", file.Key, (*entities.ByteRange)(nil)).Return(io.NopCloser(strings.NewReader("jpeg")), int64(4), "image/jpeg", nil)
+	processor.On("Decode", mock.Anything).Return(img, nil)
+	processor.On("Analyze", img).Return(&entities.ImageInfo{Width: 40, Height: 30, BlurHash: "LKO2?U", DominantColor: "#ff0000"})
+	processor.On("Transform", img, thumb.Transform, mock.Anything).Return(20, 20, nil)
+	mockRepo.On("Store", mock.Anything, "default-bucket", prefix+"20x20_cover.webp", mock.Anything, int64(len(thumb.Transform.Name())), "image/webp").Return(&entities.FileMetadata{}, nil)
+	mockFiles.On("UpdateImage", mock.Anything, mock.MatchedBy(func(f *entities.FileMetadata) bool {
+		return f.Image != nil && f.Image.Width == 40 && len(f.Image.Derivatives) == 1 &&
+			f.Image.Derivatives[0].Name == "thumb" && f.Image.Derivatives[0].Width == 20
+	})).Return(nil)
+
+	// Act
+	err := service.Process(ctx, file)
+	closeErr := service.Close(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	require.NoError(t, closeErr)
+	mockRepo.AssertExpectations(t)
+	mockFiles.AssertExpectations(t)
+	processor.AssertExpectations(t)
+}
+
+// TestImageService_Process_NotAnImage tests that the variants of other files are removed without processing them
+func TestImageService_Process_NotAnImage(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	service := NewImageService(mockRepo, mockFiles, new(MockImageProcessor), nil, 0, 1, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	file := &entities.FileMetadata{ID: uuid.New(), Bucket: "default-bucket", Key: "tenants/acme/spec.pdf", ContentType: "application/pdf"}
+
+	mockRepo.On("RemovePrefix", ctx, "default-bucket", "variants/acme/"+file.ID.String()+"/").Return(nil)
+
+	// Act
+	err := service.Process(ctx, file)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Empty(t, service.jobs)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestImageService_Process_QueueFull tests that images beyond the queue size are reported
+func TestImageService_Process_QueueFull(t *testing.T) {
+	// Arrange: no workers drain the queue
+	mockRepo := new(MockStorageRepository)
+	service := NewImageService(mockRepo, new(MockFileRepository), new(MockImageProcessor), nil, 0, 1, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	mockRepo.On("RemovePrefix", ctx, "default-bucket", mock.Anything).Return(nil)
+
+	// Act
+	firstErr := service.Process(ctx, &entities.FileMetadata{ID: uuid.New(), Bucket: "default-bucket", ContentType: "image/png"})
+	secondErr := service.Process(ctx, &entities.FileMetadata{ID: uuid.New(), Bucket: "default-bucket", ContentType: "image/png"})
+
+	// Assert
+	assert.NoError(t, firstErr)
+	assert.Error(t, secondErr)
+}
+
+// TestImageService_Process_AfterClose tests that images stored once the service is closed are reported
+// instead of queued
+func TestImageService_Process_AfterClose(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	service := NewImageService(mockRepo, new(MockFileRepository), new(MockImageProcessor), nil, 1, 1, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	mockRepo.On("RemovePrefix", ctx, "default-bucket", mock.Anything).Return(nil)
+	require.NoError(t, service.Close(context.Background()))
+
+	// Act
+	err := service.Process(ctx, &entities.FileMetadata{ID: uuid.New(), Bucket: "default-bucket", ContentType: "image/png"})
+
+	// Assert
+	assert.ErrorIs(t, err, errImageServiceClosed)
+	assert.NoError(t, service.Close(context.Background()))
+}
+
+// TestImageService_Close_Deadline tests that closing gives up on the queue when its context is done, and
+// that the images still queued are dropped
+func TestImageService_Close_Deadline(t *testing.T) {
+	// Arrange: the only worker is stuck on the first image
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	service := NewImageService(mockRepo, mockFiles, new(MockImageProcessor), nil, 1, 2, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	started, release := make(chan struct{}), make(chan struct{})
+	first, second := uuid.New(), uuid.New()
+	mockRepo.On("RemovePrefix", ctx, "default-bucket", mock.Anything).Return(nil)
+	mockFiles.On("GetByID", mock.Anything, first).Run(func(mock.Arguments) {
+		close(started)
+		<-release
+	}).Return(nil, domainErrors.NewNotFoundError("File", first))
+	require.NoError(t, service.Process(ctx, &entities.FileMetadata{ID: first, Bucket: "default-bucket", ContentType: "image/png"}))
+	<-started
+	require.NoError(t, service.Process(ctx, &entities.FileMetadata{ID: second, Bucket: "default-bucket", ContentType: "image/png"}))
+	closeCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// Act
+	err := service.Close(closeCtx)
+	close(release)
+	service.workers.Wait()
+
+	// Assert
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "dropped 1 queued images")
+	mockFiles.AssertNotCalled(t, "GetByID", mock.Anything, second)
+}
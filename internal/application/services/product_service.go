@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -11,132 +12,284 @@ import (
 	"github.com/google/uuid"
 )
 
+// ProductPolicy holds the configurable catalog rules applied to products
+type ProductPolicy struct {
+	// LeafCategoriesOnly restricts products to categories without children
+	LeafCategoriesOnly bool
+	// PublishRequiresCategory requires published products to be assigned to a category
+	PublishRequiresCategory bool
+	// PublishMinImages is the number of images a product needs before it can be published
+	PublishMinImages int
+}
+
+// maxAltTextLength is the longest alt text a product media item can have
+const maxAltTextLength = 255
+
 // ProductService handles business logic for products
 type ProductService struct {
 	repo         ports.ProductRepository
 	categoryRepo ports.CategoryRepository
+	brandRepo    ports.BrandRepository
+	mediaRepo    ports.ProductMediaRepository
+	storage      ports.StorageService
+	policy       ProductPolicy
 }
 
-func NewProductService(repo ports.ProductRepository, categoryRepo ports.CategoryRepository) *ProductService {
+func NewProductService(repo ports.ProductRepository, categoryRepo ports.CategoryRepository, brandRepo ports.BrandRepository, mediaRepo ports.ProductMediaRepository, storage ports.StorageService, policy ProductPolicy) *ProductService {
 	return &ProductService{
 		repo:         repo,
 		categoryRepo: categoryRepo,
+		brandRepo:    brandRepo,
+		mediaRepo:    mediaRepo,
+		storage:      storage,
+		policy:       policy,
 	}
 }
 
 func (s *ProductService) CreateProduct(ctx context.Context, product *entities.Product) error {
-	// Validate required fields
-	if strings.TrimSpace(product.SKU) == "" {
-		return domainErrors.NewValidationError("sku", "SKU is required")
+	if err := s.validateProduct(ctx, product); err != nil {
+		return err
 	}
-	if strings.TrimSpace(product.Name) == "" {
-		return domainErrors.NewValidationError("name", "Name is required")
+
+	return s.repo.Create(ctx, product)
+}
+
+// ImportProducts creates products in bulk, e.g. to seed a catalog. Every product is validated as by
+// CreateProduct before any is created; the errors of the product at index i are reported for fields
+// prefixed with "products[i].". Each category, brand and media file is looked up once. The galleries
+// of the products are created after them, in one bulk insert.
+func (s *ProductService) ImportProducts(ctx context.Context, products []*entities.Product) error {
+	categoryErrors := map[uuid.UUID][]domainErrors.FieldError{}
+	brandErrors := map[uuid.UUID][]domainErrors.FieldError{}
+	files := map[uuid.UUID]*entities.FileMetadata{} // nil for files that do not exist
+	verr := &domainErrors.ValidationError{}
+	for i, product := range products {
+		productErr := &domainErrors.ValidationError{}
+		if err := s.validateImportMedia(ctx, product, files, productErr); err != nil {
+			return err
+		}
+		s.validateFields(product, productErr)
+
+		if id := product.CategoryID; id != nil {
+			if _, ok := categoryErrors[*id]; !ok {
+				referenceErr := &domainErrors.ValidationError{}
+				if err := s.validateReferences(ctx, &entities.Product{CategoryID: id}, referenceErr); err != nil {
+					return err
+				}
+				categoryErrors[*id] = referenceErr.Errors
+			}
+			productErr.Errors = append(productErr.Errors, categoryErrors[*id]...)
+		}
+		if id := product.BrandID; id != nil {
+			if _, ok := brandErrors[*id]; !ok {
+				referenceErr := &domainErrors.ValidationError{}
+				if err := s.validateReferences(ctx, &entities.Product{BrandID: id}, referenceErr); err != nil {
+					return err
+				}
+				brandErrors[*id] = referenceErr.Errors
+			}
+			productErr.Errors = append(productErr.Errors, brandErrors[*id]...)
+		}
+
+		prefix := fmt.Sprintf("products[%d].", i)
+		for _, fieldErr := range productErr.Errors {
+			verr.Errors = append(verr.Errors, domainErrors.FieldError{
+				Field:   prefix + fieldErr.Field,
+				Code:    prefix + fieldErr.Code,
+				Message: fieldErr.Message,
+			})
+		}
 	}
-	if product.Price <= 0 {
-		return domainErrors.NewValidationError("price", "Price must be greater than 0")
+	if err := verr.ErrOrNil(); err != nil {
+		return err
 	}
 
-	// Auto-generate slug from name if not provided
-	if strings.TrimSpace(product.Slug) == "" {
-		product.Slug = entities.GenerateSlug(product.Name)
+	if err := s.repo.CreateBulk(ctx, products); err != nil {
+		return err
 	}
+	return s.importMedia(ctx, products)
+}
 
-	// Set default status to draft if not provided or empty
-	if product.Status == "" {
-		product.Status = entities.ProductStatusDraft
-	}
+// validateImportMedia checks the gallery of an imported product like AttachMedia, looking up each file
+// in files or adding it there. The gallery is numbered in order, with the first item primary unless
+// another one is.
+func (s *ProductService) validateImportMedia(ctx context.Context, product *entities.Product, files map[uuid.UUID]*entities.FileMetadata, verr *domainErrors.ValidationError) error {
+	seen := make(map[uuid.UUID]bool, len(product.Media))
+	primary := -1
+	for i, media := range product.Media {
+		mediaErr := &domainErrors.ValidationError{}
+		file, ok := files[media.FileID]
+		if !ok {
+			var err error
+			file, err = s.storage.GetFile(ctx, media.FileID)
+			switch {
+			case errors.Is(err, domainErrors.ErrNotFound):
+				file = nil
+			case err != nil:
+				return err
+			}
+			files[media.FileID] = file
+		}
+		if file == nil {
+			mediaErr.Add("file_id", "not_found", "File does not exist")
+		} else if media.Type == "" {
+			media.Type = entities.MediaTypeFor(file.ContentType)
+		}
+		if seen[media.FileID] {
+			mediaErr.Add("file_id", "duplicate", "A gallery holds a file once")
+		}
+		seen[media.FileID] = true
+		if media.Type != "" && !media.Type.IsValid() {
+			mediaErr.Add("media_type", "invalid", "Media type must be image, video or document")
+		}
+		validateAltText(media.AltText, mediaErr)
+
+		prefix := fmt.Sprintf("media[%d].", i)
+		for _, fieldErr := range mediaErr.Errors {
+			verr.Errors = append(verr.Errors, domainErrors.FieldError{
+				Field:   prefix + fieldErr.Field,
+				Code:    prefix + fieldErr.Code,
+				Message: fieldErr.Message,
+			})
+		}
 
-	// Validate status
-	if !product.IsValid() {
-		return domainErrors.NewValidationError("status", "Invalid product status")
+		media.File = file
+		media.Position = i
+		if media.IsPrimary && primary < 0 {
+			primary = i
+		}
+	}
+	for i, media := range product.Media {
+		media.IsPrimary = i == max(primary, 0)
 	}
+	return nil
+}
 
-	// Validate dimensions for courier calculation (if provided)
-	if product.Weight < 0 {
-		return domainErrors.NewValidationError("weight", "Weight cannot be negative")
+// importMedia creates the galleries of imported products. Products copied in bulk come back without
+// their IDs, which are then looked up by SKU all at once.
+func (s *ProductService) importMedia(ctx context.Context, products []*entities.Product) error {
+	var media []*entities.ProductMedia
+	var unknown []string
+	for _, product := range products {
+		media = append(media, product.Media...)
+		if len(product.Media) > 0 && product.ID == 0 {
+			unknown = append(unknown, product.SKU)
+		}
 	}
-	if product.Length < 0 {
-		return domainErrors.NewValidationError("length", "Length cannot be negative")
+	if len(media) == 0 {
+		return nil
 	}
-	if product.Width < 0 {
-		return domainErrors.NewValidationError("width", "Width cannot be negative")
+
+	if len(unknown) > 0 {
+		ids, err := s.repo.IDsBySKU(ctx, unknown)
+		if err != nil {
+			return err
+		}
+		for _, product := range products {
+			if product.ID == 0 {
+				product.ID = ids[product.SKU]
+			}
+		}
 	}
-	if product.Height < 0 {
-		return domainErrors.NewValidationError("height", "Height cannot be negative")
+	for _, product := range products {
+		for _, m := range product.Media {
+			m.ProductID = product.ID
+		}
 	}
+	return s.mediaRepo.CreateBulk(ctx, media)
+}
 
-	return s.repo.Create(ctx, product)
+// ProductStats counts the products of the catalog by status, category and brand
+func (s *ProductService) ProductStats(ctx context.Context) (*entities.ProductStats, error) {
+	return s.repo.Stats(ctx)
 }
 
 func (s *ProductService) GetProduct(ctx context.Context, id int) (*entities.Product, error) {
-	return s.repo.GetByID(ctx, id)
+	product, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.loadMedia(ctx, product); err != nil {
+		return nil, err
+	}
+	return product, nil
 }
 
 func (s *ProductService) GetProductBySKU(ctx context.Context, sku string) (*entities.Product, error) {
 	if strings.TrimSpace(sku) == "" {
-		return nil, domainErrors.NewValidationError("sku", "SKU is required")
+		return nil, domainErrors.NewValidationError("sku", "required", "SKU is required")
+	}
+	product, err := s.repo.GetBySKU(ctx, sku)
+	if err != nil {
+		return nil, err
 	}
-	return s.repo.GetBySKU(ctx, sku)
+
+	if err := s.loadMedia(ctx, product); err != nil {
+		return nil, err
+	}
+	return product, nil
 }
 
 func (s *ProductService) GetProductBySlug(ctx context.Context, slug string) (*entities.Product, error) {
 	if strings.TrimSpace(slug) == "" {
-		return nil, domainErrors.NewValidationError("slug", "Slug is required")
+		return nil, domainErrors.NewValidationError("slug", "required", "Slug is required")
+	}
+	product, err := s.repo.GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
 	}
-	return s.repo.GetBySlug(ctx, slug)
-}
 
-func (s *ProductService) ListProducts(ctx context.Context) ([]*entities.Product, error) {
-	return s.repo.List(ctx)
+	if err := s.loadMedia(ctx, product); err != nil {
+		return nil, err
+	}
+	return product, nil
 }
 
-func (s *ProductService) ListPublishedProducts(ctx context.Context) ([]*entities.Product, error) {
-	return s.repo.ListByStatus(ctx, entities.ProductStatusPublished)
-}
+func (s *ProductService) ListProducts(ctx context.Context) ([]*entities.Product, error) {
+	products, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-func (s *ProductService) ListProductsByStatus(ctx context.Context, status entities.ProductStatus) ([]*entities.Product, error) {
-	return s.repo.ListByStatus(ctx, status)
+	if err := s.loadMedia(ctx, products...); err != nil {
+		return nil, err
+	}
+	return products, nil
 }
 
-func (s *ProductService) UpdateProduct(ctx context.Context, product *entities.Product) error {
-	// Validate required fields
-	if strings.TrimSpace(product.SKU) == "" {
-		return domainErrors.NewValidationError("sku", "SKU is required")
-	}
-	if strings.TrimSpace(product.Name) == "" {
-		return domainErrors.NewValidationError("name", "Name is required")
-	}
-	if product.Price <= 0 {
-		return domainErrors.NewValidationError("price", "Price must be greater than 0")
+func (s *ProductService) ListPublishedProducts(ctx context.Context) ([]*entities.Product, error) {
+	products, err := s.repo.ListByStatus(ctx, entities.ProductStatusPublished)
+	if err != nil {
+		return nil, err
 	}
 
-	// Auto-generate slug from name if not provided
-	if strings.TrimSpace(product.Slug) == "" {
-		product.Slug = entities.GenerateSlug(product.Name)
+	if err := s.loadMedia(ctx, products...); err != nil {
+		return nil, err
 	}
+	return products, nil
+}
 
-	// Set default status to draft if not provided or empty
-	if product.Status == "" {
-		product.Status = entities.ProductStatusDraft
+func (s *ProductService) ListProductsByStatus(ctx context.Context, status entities.ProductStatus) ([]*entities.Product, error) {
+	products, err := s.repo.ListByStatus(ctx, status)
+	if err != nil {
+		return nil, err
 	}
 
-	// Validate status
-	if !product.IsValid() {
-		return domainErrors.NewValidationError("status", "Invalid product status")
+	if err := s.loadMedia(ctx, products...); err != nil {
+		return nil, err
 	}
+	return products, nil
+}
 
-	// Validate dimensions for courier calculation (if provided)
-	if product.Weight < 0 {
-		return domainErrors.NewValidationError("weight", "Weight cannot be negative")
-	}
-	if product.Length < 0 {
-		return domainErrors.NewValidationError("length", "Length cannot be negative")
-	}
-	if product.Width < 0 {
-		return domainErrors.NewValidationError("width", "Width cannot be negative")
+func (s *ProductService) UpdateProduct(ctx context.Context, product *entities.Product) error {
+	// The gallery counts towards the publishing rules
+	if err := s.loadMedia(ctx, product); err != nil {
+		return err
 	}
-	if product.Height < 0 {
-		return domainErrors.NewValidationError("height", "Height cannot be negative")
+
+	if err := s.validateProduct(ctx, product); err != nil {
+		return err
 	}
 
 	return s.repo.Update(ctx, product)
@@ -154,7 +307,17 @@ func (s *ProductService) PublishProduct(ctx context.Context, id int) error {
 
 	// Business rule: can only publish draft products
 	if product.Status != entities.ProductStatusDraft {
-		return domainErrors.NewValidationError("status", "Only draft products can be published")
+		return domainErrors.NewValidationError("status", "not_draft", "Only draft products can be published")
+	}
+
+	if err := s.loadMedia(ctx, product); err != nil {
+		return err
+	}
+
+	verr := &domainErrors.ValidationError{}
+	s.validatePublishable(product, verr)
+	if err := verr.ErrOrNil(); err != nil {
+		return err
 	}
 
 	product.Status = entities.ProductStatusPublished
@@ -190,13 +353,13 @@ func (s *ProductService) QueryProducts(ctx context.Context, params *entities.Que
 			params.Pagination.Direction = "forward"
 		}
 		if params.Pagination.Direction != "forward" && params.Pagination.Direction != "backward" {
-			return nil, domainErrors.NewValidationError("direction", "Direction must be 'forward' or 'backward'")
+			return nil, domainErrors.NewValidationError("direction", "invalid", "Direction must be 'forward' or 'backward'")
 		}
 	}
 
 	// Validate filters (max 10 filters)
 	if len(params.Filters) > 10 {
-		return nil, domainErrors.NewValidationError("filters", "Maximum 10 filters allowed")
+		return nil, domainErrors.NewValidationError("filters", "too_many", "Maximum 10 filters allowed")
 	}
 
 	for _, filter := range params.Filters {
@@ -207,7 +370,7 @@ func (s *ProductService) QueryProducts(ctx context.Context, params *entities.Que
 
 	// Validate sort params (max 3 sorts)
 	if len(params.Sort) > 3 {
-		return nil, domainErrors.NewValidationError("sort", "Maximum 3 sort fields allowed")
+		return nil, domainErrors.NewValidationError("sort", "too_many", "Maximum 3 sort fields allowed")
 	}
 
 	for _, sort := range params.Sort {
@@ -216,8 +379,6 @@ func (s *ProductService) QueryProducts(ctx context.Context, params *entities.Que
 		}
 	}
 
-	fmt.Println(params.Filters)
-
 	// Expand category_id filters to include descendants
 	for i, filter := range params.Filters {
 		if filter.Field == "category_id" && (filter.Operator == entities.OpIn || filter.Operator == entities.OpEqual) {
@@ -230,11 +391,11 @@ func (s *ProductService) QueryProducts(ctx context.Context, params *entities.Que
 				case string:
 					parsedID, err := uuid.Parse(v)
 					if err != nil {
-						return nil, domainErrors.NewValidationError("category_id", "Invalid UUID format")
+						return nil, domainErrors.NewValidationError("category_id", "invalid_uuid", "Invalid UUID format")
 					}
 					categoryIDs = []uuid.UUID{parsedID}
 				default:
-					return nil, domainErrors.NewValidationError("category_id", "Invalid category_id value type (expected string UUID)")
+					return nil, domainErrors.NewValidationError("category_id", "invalid_type", "Invalid category_id value type (expected string UUID)")
 				}
 			} else if filter.Operator == entities.OpIn {
 				// Multiple IDs: convert array to UUID slice
@@ -246,15 +407,15 @@ func (s *ProductService) QueryProducts(ctx context.Context, params *entities.Que
 						case string:
 							parsedID, err := uuid.Parse(idVal)
 							if err != nil {
-								return nil, domainErrors.NewValidationError("category_id", "Invalid UUID format in array")
+								return nil, domainErrors.NewValidationError("category_id", "invalid_uuid", "Invalid UUID format in array")
 							}
 							categoryIDs = append(categoryIDs, parsedID)
 						default:
-							return nil, domainErrors.NewValidationError("category_id", "Invalid category_id array value type (expected string UUID)")
+							return nil, domainErrors.NewValidationError("category_id", "invalid_type", "Invalid category_id array value type (expected string UUID)")
 						}
 					}
 				default:
-					return nil, domainErrors.NewValidationError("category_id", "Invalid category_id value type for 'in' operator")
+					return nil, domainErrors.NewValidationError("category_id", "invalid_type", "Invalid category_id value type for 'in' operator")
 				}
 			}
 
@@ -262,7 +423,7 @@ func (s *ProductService) QueryProducts(ctx context.Context, params *entities.Que
 			if len(categoryIDs) > 0 {
 				expandedIDs, err := s.categoryRepo.GetDescendantIDs(ctx, categoryIDs)
 				if err != nil {
-					return nil, domainErrors.NewValidationError("category_id", "Failed to expand category IDs")
+					return nil, domainErrors.NewValidationError("category_id", "expansion_failed", "Failed to expand category IDs")
 				}
 
 				// Update the filter with expanded IDs (convert UUID back to string)
@@ -282,19 +443,377 @@ func (s *ProductService) QueryProducts(ctx context.Context, params *entities.Que
 		}
 	}
 
-	return s.repo.Query(ctx, params)
+	result, err := s.repo.Query(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.loadMedia(ctx, result.Products...); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// AttachMedia adds a stored file to the end of the product's gallery. The media type defaults to the one
+// of the file's content type, and the first media of a product becomes its primary one.
+func (s *ProductService) AttachMedia(ctx context.Context, productID int, media *entities.ProductMedia) error {
+	if _, err := s.repo.GetByID(ctx, productID); err != nil {
+		return err
+	}
+
+	gallery, err := s.mediaRepo.ListByProduct(ctx, productID)
+	if err != nil {
+		return err
+	}
+
+	verr := &domainErrors.ValidationError{}
+	file, err := s.storage.GetFile(ctx, media.FileID)
+	switch {
+	case errors.Is(err, domainErrors.ErrNotFound):
+		verr.Add("file_id", "not_found", "File does not exist")
+	case err != nil:
+		return err
+	default:
+		if media.Type == "" {
+			media.Type = entities.MediaTypeFor(file.ContentType)
+		}
+	}
+	if media.Type != "" && !media.Type.IsValid() {
+		verr.Add("media_type", "invalid", "Media type must be image, video or document")
+	}
+	validateAltText(media.AltText, verr)
+	if err := verr.ErrOrNil(); err != nil {
+		return err
+	}
+
+	for _, m := range gallery {
+		if m.FileID == media.FileID {
+			return domainErrors.NewDuplicateError("ProductMedia", "file_id", media.FileID)
+		}
+	}
+
+	media.ProductID = productID
+	media.Position = len(gallery)
+	media.IsPrimary = media.IsPrimary || len(gallery) == 0
+	if err := s.mediaRepo.Create(ctx, media); err != nil {
+		return err
+	}
+
+	media.File = file
+	if media.IsPrimary {
+		return s.demoteOthers(ctx, gallery, media.ID)
+	}
+	return nil
+}
+
+// UpdateMedia changes the alt text and primary flag of a gallery item
+func (s *ProductService) UpdateMedia(ctx context.Context, productID int, media *entities.ProductMedia) error {
+	gallery, err := s.mediaRepo.ListByProduct(ctx, productID)
+	if err != nil {
+		return err
+	}
+
+	existing := findMedia(gallery, media.ID)
+	if existing == nil {
+		return domainErrors.NewNotFoundError("ProductMedia", media.ID)
+	}
+
+	verr := &domainErrors.ValidationError{}
+	validateAltText(media.AltText, verr)
+	if err := verr.ErrOrNil(); err != nil {
+		return err
+	}
+
+	existing.AltText = media.AltText
+	existing.IsPrimary = media.IsPrimary
+	if err := s.mediaRepo.Update(ctx, existing); err != nil {
+		return err
+	}
+
+	*media = *existing
+	if err := s.loadFileURL(ctx, media); err != nil {
+		return err
+	}
+	if media.IsPrimary {
+		return s.demoteOthers(ctx, gallery, media.ID)
+	}
+	return nil
+}
+
+// ReorderMedia puts the product's gallery in the given order, which must list every item exactly once
+func (s *ProductService) ReorderMedia(ctx context.Context, productID int, mediaIDs []uuid.UUID) ([]*entities.ProductMedia, error) {
+	gallery, err := s.mediaRepo.ListByProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	ordered := make([]*entities.ProductMedia, 0, len(mediaIDs))
+	seen := make(map[uuid.UUID]bool, len(mediaIDs))
+	for _, id := range mediaIDs {
+		media := findMedia(gallery, id)
+		if media == nil || seen[id] {
+			break
+		}
+		seen[id] = true
+		ordered = append(ordered, media)
+	}
+	if len(ordered) != len(mediaIDs) || len(ordered) != len(gallery) {
+		return nil, domainErrors.NewValidationError("media_ids", "mismatch", "media_ids must list every media item of the product exactly once")
+	}
+
+	if err := s.renumber(ctx, ordered); err != nil {
+		return nil, err
+	}
+
+	for _, media := range ordered {
+		if err := s.loadFileURL(ctx, media); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// DetachMedia removes an item from the product's gallery; the file itself is kept. If the primary media
+// is removed, the first remaining one takes its place.
+func (s *ProductService) DetachMedia(ctx context.Context, productID int, mediaID uuid.UUID) error {
+	gallery, err := s.mediaRepo.ListByProduct(ctx, productID)
+	if err != nil {
+		return err
+	}
+
+	media := findMedia(gallery, mediaID)
+	if media == nil {
+		return domainErrors.NewNotFoundError("ProductMedia", mediaID)
+	}
+
+	if err := s.mediaRepo.Delete(ctx, mediaID); err != nil {
+		return err
+	}
+
+	remaining := make([]*entities.ProductMedia, 0, len(gallery)-1)
+	for _, m := range gallery {
+		if m.ID != mediaID {
+			remaining = append(remaining, m)
+		}
+	}
+	if media.IsPrimary && len(remaining) > 0 {
+		remaining[0].IsPrimary = true
+		if err := s.mediaRepo.Update(ctx, remaining[0]); err != nil {
+			return err
+		}
+	}
+
+	return s.renumber(ctx, remaining)
+}
+
+// loadMedia sets the gallery of the products, with the URLs of their files
+func (s *ProductService) loadMedia(ctx context.Context, products ...*entities.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(products))
+	byID := make(map[int]*entities.Product, len(products))
+	for i, product := range products {
+		ids[i] = product.ID
+		byID[product.ID] = product
+		product.Media = []*entities.ProductMedia{}
+	}
+
+	gallery, err := s.mediaRepo.ListByProducts(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, media := range gallery {
+		if err := s.loadFileURL(ctx, media); err != nil {
+			return err
+		}
+		if product, ok := byID[media.ProductID]; ok {
+			product.Media = append(product.Media, media)
+		}
+	}
+	return nil
+}
+
+// loadFileURL sets the URL of the media's file
+func (s *ProductService) loadFileURL(ctx context.Context, media *entities.ProductMedia) error {
+	if media.File == nil {
+		return nil
+	}
+
+	url, err := s.storage.GetFileURL(ctx, media.File.Bucket, media.File.FileName)
+	if err != nil {
+		return err
+	}
+	media.File.URL = url
+	return nil
+}
+
+// demoteOthers clears the primary flag of the gallery items other than primaryID
+func (s *ProductService) demoteOthers(ctx context.Context, gallery []*entities.ProductMedia, primaryID uuid.UUID) error {
+	for _, media := range gallery {
+		if media.ID != primaryID && media.IsPrimary {
+			media.IsPrimary = false
+			if err := s.mediaRepo.Update(ctx, media); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// renumber stores the positions of the gallery items in the given order
+func (s *ProductService) renumber(ctx context.Context, gallery []*entities.ProductMedia) error {
+	for i, media := range gallery {
+		if media.Position == i {
+			continue
+		}
+		media.Position = i
+		if err := s.mediaRepo.Update(ctx, media); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findMedia returns the gallery item with the ID, or nil
+func findMedia(gallery []*entities.ProductMedia, id uuid.UUID) *entities.ProductMedia {
+	for _, media := range gallery {
+		if media.ID == id {
+			return media
+		}
+	}
+	return nil
+}
+
+// validateAltText checks the alt text of a product media item
+func validateAltText(altText string, verr *domainErrors.ValidationError) {
+	if len(altText) > maxAltTextLength {
+		verr.Add("alt_text", "too_long", fmt.Sprintf("Alt text must be at most %d characters", maxAltTextLength))
+	}
+}
+
+// validateProduct applies defaults and reports every invalid field of the product at once,
+// including references to categories and brands that do not exist
+func (s *ProductService) validateProduct(ctx context.Context, product *entities.Product) error {
+	verr := &domainErrors.ValidationError{}
+	s.validateFields(product, verr)
+	if err := s.validateReferences(ctx, product, verr); err != nil {
+		return err
+	}
+
+	return verr.ErrOrNil()
+}
+
+// validateFields applies defaults and checks the fields of the product, leaving out its references
+func (s *ProductService) validateFields(product *entities.Product, verr *domainErrors.ValidationError) {
+	// Validate required fields
+	if strings.TrimSpace(product.SKU) == "" {
+		verr.Add("sku", "required", "SKU is required")
+	}
+	if strings.TrimSpace(product.Name) == "" {
+		verr.Add("name", "required", "Name is required")
+	}
+	if product.Price <= 0 {
+		verr.Add("price", "must_be_positive", "Price must be greater than 0")
+	}
+
+	// Auto-generate slug from name if not provided
+	if strings.TrimSpace(product.Slug) == "" {
+		product.Slug = entities.GenerateSlug(product.Name)
+	}
+
+	// Set default status to draft if not provided or empty
+	if product.Status == "" {
+		product.Status = entities.ProductStatusDraft
+	}
+
+	// Validate status
+	if !product.IsValid() {
+		verr.Add("status", "invalid", "Invalid product status")
+	}
+
+	// Validate dimensions for courier calculation (if provided)
+	if product.Weight < 0 {
+		verr.Add("weight", "must_not_be_negative", "Weight cannot be negative")
+	}
+	if product.Length < 0 {
+		verr.Add("length", "must_not_be_negative", "Length cannot be negative")
+	}
+	if product.Width < 0 {
+		verr.Add("width", "must_not_be_negative", "Width cannot be negative")
+	}
+	if product.Height < 0 {
+		verr.Add("height", "must_not_be_negative", "Height cannot be negative")
+	}
+
+	// Products created or updated as published must satisfy the publishing rules
+	if product.Status == entities.ProductStatusPublished {
+		s.validatePublishable(product, verr)
+	}
+}
+
+// validateReferences checks that the product's category and brand exist and that the category is allowed
+func (s *ProductService) validateReferences(ctx context.Context, product *entities.Product, verr *domainErrors.ValidationError) error {
+	if product.CategoryID != nil {
+		_, err := s.categoryRepo.GetByID(ctx, *product.CategoryID)
+		switch {
+		case errors.Is(err, domainErrors.ErrNotFound):
+			verr.Add("category_id", "not_found", "Category does not exist")
+		case err != nil:
+			return err
+		case s.policy.LeafCategoriesOnly:
+			children, err := s.categoryRepo.ListByParentID(ctx, product.CategoryID)
+			if err != nil {
+				return err
+			}
+			if len(children) > 0 {
+				verr.Add("category_id", "not_leaf", "Products can only be assigned to categories without subcategories")
+			}
+		}
+	}
+
+	if product.BrandID != nil {
+		_, err := s.brandRepo.GetByID(ctx, *product.BrandID)
+		switch {
+		case errors.Is(err, domainErrors.ErrNotFound):
+			verr.Add("brand_id", "not_found", "Brand does not exist")
+		case err != nil:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validatePublishable checks the rules a product must satisfy to be published
+func (s *ProductService) validatePublishable(product *entities.Product, verr *domainErrors.ValidationError) {
+	if s.policy.PublishRequiresCategory && product.CategoryID == nil {
+		verr.Add("category_id", "required_to_publish", "A category is required to publish a product")
+	}
+	// Image URLs and image media both count
+	images := len(product.ImageURLs)
+	for _, media := range product.Media {
+		if media.Type == entities.MediaTypeImage {
+			images++
+		}
+	}
+	if images < s.policy.PublishMinImages {
+		verr.Add("image_urls", "too_few_to_publish", fmt.Sprintf("At least %d image(s) are required to publish a product", s.policy.PublishMinImages))
+	}
 }
 
 // validateFilter validates a single filter
 func (s *ProductService) validateFilter(filter entities.Filter) error {
 	// Validate field name
 	if !s.isValidFilterField(filter.Field) {
-		return domainErrors.NewValidationError("filter.field", "Invalid filter field: "+filter.Field)
+		return domainErrors.NewValidationError("filter.field", "unsupported", "Invalid filter field: "+filter.Field)
 	}
 
 	// Validate operator for field type
 	if !s.isValidOperatorForField(filter.Field, filter.Operator) {
-		return domainErrors.NewValidationError("filter.operator", "Invalid operator "+string(filter.Operator)+" for field "+filter.Field)
+		return domainErrors.NewValidationError("filter.operator", "unsupported", "Invalid operator "+string(filter.Operator)+" for field "+filter.Field)
 	}
 
 	return nil
@@ -303,11 +822,11 @@ func (s *ProductService) validateFilter(filter entities.Filter) error {
 // validateSort validates a single sort parameter
 func (s *ProductService) validateSort(sort entities.SortParam) error {
 	if !s.isValidSortField(sort.Field) {
-		return domainErrors.NewValidationError("sort.field", "Invalid sort field: "+sort.Field)
+		return domainErrors.NewValidationError("sort.field", "unsupported", "Invalid sort field: "+sort.Field)
 	}
 
 	if sort.Order != entities.SortAsc && sort.Order != entities.SortDesc {
-		return domainErrors.NewValidationError("sort.order", "Sort order must be 'asc' or 'desc'")
+		return domainErrors.NewValidationError("sort.order", "invalid", "Sort order must be 'asc' or 'desc'")
 	}
 
 	return nil
@@ -3,90 +3,190 @@ package services
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 
+	"example.com/go-yippi/internal/adapters/memory"
 	"example.com/go-yippi/internal/domain/entities"
 	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"example.com/go-yippi/internal/domain/ports"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
-// MockProductRepository is a mock implementation of ports.ProductRepository
-type MockProductRepository struct {
+// MockStorageService is a mock implementation of ports.StorageService
+type MockStorageService struct {
 	mock.Mock
 }
 
-func (m *MockProductRepository) Create(ctx context.Context, product *entities.Product) error {
-	args := m.Called(ctx, product)
+func (m *MockStorageService) UploadFile(ctx context.Context, upload *entities.FileUpload) (*entities.FileMetadata, error) {
+	args := m.Called(ctx, upload)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.FileMetadata), args.Error(1)
+}
+
+func (m *MockStorageService) DeleteFile(ctx context.Context, bucket, fileName string) error {
+	args := m.Called(ctx, bucket, fileName)
 	return args.Error(0)
 }
 
-func (m *MockProductRepository) GetByID(ctx context.Context, id int) (*entities.Product, error) {
-	args := m.Called(ctx, id)
+func (m *MockStorageService) GetFileURL(ctx context.Context, bucket, fileName string) (string, error) {
+	args := m.Called(ctx, bucket, fileName)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockStorageService) DownloadFile(ctx context.Context, bucket, fileName string, opts entities.DownloadOptions) (*entities.FileDownload, error) {
+	args := m.Called(ctx, bucket, fileName, opts)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*entities.Product), args.Error(1)
+	return args.Get(0).(*entities.FileDownload), args.Error(1)
+}
+
+func (m *MockStorageService) CreateUploadSlot(ctx context.Context, slot *entities.UploadSlot) error {
+	args := m.Called(ctx, slot)
+	return args.Error(0)
 }
 
-func (m *MockProductRepository) GetBySKU(ctx context.Context, sku string) (*entities.Product, error) {
-	args := m.Called(ctx, sku)
+func (m *MockStorageService) ComposeFile(ctx context.Context, upload *entities.FileUpload, parts []entities.UploadPart) (*entities.FileMetadata, error) {
+	args := m.Called(ctx, upload, parts)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*entities.Product), args.Error(1)
+	return args.Get(0).(*entities.FileMetadata), args.Error(1)
 }
 
-func (m *MockProductRepository) GetBySlug(ctx context.Context, slug string) (*entities.Product, error) {
-	args := m.Called(ctx, slug)
+func (m *MockStorageService) ConfirmUpload(ctx context.Context, bucket, fileName string) (*entities.FileMetadata, error) {
+	args := m.Called(ctx, bucket, fileName)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*entities.Product), args.Error(1)
+	return args.Get(0).(*entities.FileMetadata), args.Error(1)
 }
 
-func (m *MockProductRepository) Update(ctx context.Context, product *entities.Product) error {
-	args := m.Called(ctx, product)
-	return args.Error(0)
+func (m *MockStorageService) PresignDownload(ctx context.Context, bucket, fileName string) (*entities.PresignedRequest, error) {
+	args := m.Called(ctx, bucket, fileName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.PresignedRequest), args.Error(1)
 }
 
-func (m *MockProductRepository) Delete(ctx context.Context, id int) error {
+func (m *MockStorageService) GetFile(ctx context.Context, id uuid.UUID) (*entities.FileMetadata, error) {
 	args := m.Called(ctx, id)
-	return args.Error(0)
-}
-
-func (m *MockProductRepository) Query(ctx context.Context, params *entities.QueryParams) (*entities.QueryResult, error) {
-	args := m.Called(ctx, params)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*entities.QueryResult), args.Error(1)
+	return args.Get(0).(*entities.FileMetadata), args.Error(1)
 }
 
-func (m *MockProductRepository) List(ctx context.Context) ([]*entities.Product, error) {
-	args := m.Called(ctx)
+func (m *MockStorageService) ListFiles(ctx context.Context, query *entities.FileQuery) (*entities.FileQueryResult, error) {
+	args := m.Called(ctx, query)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]*entities.Product), args.Error(1)
+	return args.Get(0).(*entities.FileQueryResult), args.Error(1)
 }
 
-func (m *MockProductRepository) ListByStatus(ctx context.Context, status entities.ProductStatus) ([]*entities.Product, error) {
-	args := m.Called(ctx, status)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
+func (m *MockStorageService) DeleteFileByID(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// productRepos are the in-memory repositories of a product service, on one store
+type productRepos struct {
+	products   *memory.ProductRepository
+	categories *memory.CategoryRepository
+	brands     *memory.BrandRepository
+	media      *memory.ProductMediaRepository
+}
+
+// newProductService creates a product service on in-memory repositories and a mock storage service
+func newProductService(policy ProductPolicy) (*ProductService, *productRepos, *MockStorageService) {
+	store := memory.NewStore()
+	repos := &productRepos{
+		products:   memory.NewProductRepository(store),
+		categories: memory.NewCategoryRepository(store),
+		brands:     memory.NewBrandRepository(store),
+		media:      memory.NewProductMediaRepository(store),
 	}
-	return args.Get(0).([]*entities.Product), args.Error(1)
+	storage := new(MockStorageService)
+	return NewProductService(repos.products, repos.categories, repos.brands, repos.media, storage, policy), repos, storage
+}
+
+// storeProduct stores a draft product with the SKU, as if it had been created earlier
+func storeProduct(t *testing.T, ctx context.Context, repos *productRepos, sku string) *entities.Product {
+	t.Helper()
+
+	product := &entities.Product{SKU: sku, Slug: strings.ToLower(sku), Name: "Test Product", Price: 99.99, Status: entities.ProductStatusDraft}
+	require.NoError(t, repos.products.Create(ctx, product))
+	return product
+}
+
+// storeMedia stores an image in the gallery of a product
+func storeMedia(t *testing.T, ctx context.Context, repos *productRepos, media *entities.ProductMedia) *entities.ProductMedia {
+	t.Helper()
+
+	media.FileID = uuid.New()
+	if media.Type == "" {
+		media.Type = entities.MediaTypeImage
+	}
+	require.NoError(t, repos.media.Create(ctx, media))
+	return media
+}
+
+// storedProduct reads a product back from the repository
+func storedProduct(t *testing.T, ctx context.Context, repos *productRepos, sku string) *entities.Product {
+	t.Helper()
+
+	product, err := repos.products.GetBySKU(ctx, sku)
+	require.NoError(t, err)
+	return product
+}
+
+// assertNoProducts asserts that the tenant has no products
+func assertNoProducts(t *testing.T, ctx context.Context, repos *productRepos) {
+	t.Helper()
+
+	products, err := repos.products.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, products)
+}
+
+// failingProductRepository fails to create products, like a database that went away
+type failingProductRepository struct {
+	ports.ProductRepository
+	err error
+}
+
+func (r *failingProductRepository) Create(ctx context.Context, product *entities.Product) error {
+	return r.err
+}
+
+// copyingProductRepository creates products in bulk without reporting their IDs, like COPY FROM on PostgreSQL
+type copyingProductRepository struct {
+	ports.ProductRepository
+}
+
+func (r *copyingProductRepository) CreateBulk(ctx context.Context, products []*entities.Product) error {
+	if err := r.ProductRepository.CreateBulk(ctx, products); err != nil {
+		return err
+	}
+	for _, product := range products {
+		product.ID = 0
+	}
+	return nil
 }
 
 // TestCreateProduct_Success tests successful product creation with all required fields
 func TestCreateProduct_Success(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockProductRepository)
-	mockCategoryRepo := new(MockCategoryRepository)
-	service := NewProductService(mockRepo, mockCategoryRepo)
-	ctx := context.Background()
+	service, repos, _ := newProductService(ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
 
 	product := &entities.Product{
 		SKU:         "TEST-001",
@@ -95,15 +195,6 @@ func TestCreateProduct_Success(t *testing.T) {
 		Description: "A test product",
 	}
 
-	mockRepo.On("Create", ctx, mock.MatchedBy(func(p *entities.Product) bool {
-		// Verify that the service sets default status and slug
-		return p.SKU == "TEST-001" &&
-			p.Name == "Test Product" &&
-			p.Price == 99.99 &&
-			p.Status == entities.ProductStatusDraft &&
-			p.Slug == "test-product"
-	})).Return(nil)
-
 	// Act
 	err := service.CreateProduct(ctx, product)
 
@@ -111,16 +202,18 @@ func TestCreateProduct_Success(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, entities.ProductStatusDraft, product.Status, "Status should default to draft")
 	assert.Equal(t, "test-product", product.Slug, "Slug should be auto-generated")
-	mockRepo.AssertExpectations(t)
+	stored := storedProduct(t, ctx, repos, "TEST-001")
+	assert.Equal(t, "Test Product", stored.Name)
+	assert.Equal(t, 99.99, stored.Price)
+	assert.Equal(t, entities.ProductStatusDraft, stored.Status)
+	assert.Equal(t, "test-product", stored.Slug)
 }
 
 // TestCreateProduct_WithCustomSlug tests product creation with a custom slug
 func TestCreateProduct_WithCustomSlug(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockProductRepository)
-	mockCategoryRepo := new(MockCategoryRepository)
-	service := NewProductService(mockRepo, mockCategoryRepo)
-	ctx := context.Background()
+	service, repos, _ := newProductService(ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
 
 	product := &entities.Product{
 		SKU:   "TEST-002",
@@ -129,60 +222,50 @@ func TestCreateProduct_WithCustomSlug(t *testing.T) {
 		Price: 49.99,
 	}
 
-	mockRepo.On("Create", ctx, mock.MatchedBy(func(p *entities.Product) bool {
-		return p.Slug == "custom-slug"
-	})).Return(nil)
-
 	// Act
 	err := service.CreateProduct(ctx, product)
 
 	// Assert
 	require.NoError(t, err)
 	assert.Equal(t, "custom-slug", product.Slug, "Custom slug should be preserved")
-	mockRepo.AssertExpectations(t)
+	assert.Equal(t, "custom-slug", storedProduct(t, ctx, repos, "TEST-002").Slug)
 }
 
 // TestCreateProduct_WithOptionalFields tests product creation with optional dimension fields
 func TestCreateProduct_WithOptionalFields(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockProductRepository)
-	mockCategoryRepo := new(MockCategoryRepository)
-	service := NewProductService(mockRepo, mockCategoryRepo)
-	ctx := context.Background()
+	service, repos, _ := newProductService(ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
 
 	product := &entities.Product{
 		SKU:    "TEST-003",
 		Name:   "Test Product with Dimensions",
 		Price:  199.99,
-		Weight: 500,  // grams
-		Length: 20,   // cm
-		Width:  15,   // cm
-		Height: 10,   // cm
+		Weight: 500, // grams
+		Length: 20,  // cm
+		Width:  15,  // cm
+		Height: 10,  // cm
 		Status: entities.ProductStatusPublished,
 	}
 
-	mockRepo.On("Create", ctx, product).Return(nil)
-
 	// Act
 	err := service.CreateProduct(ctx, product)
 
 	// Assert
 	require.NoError(t, err)
-	assert.Equal(t, 500, product.Weight)
-	assert.Equal(t, 20, product.Length)
-	assert.Equal(t, 15, product.Width)
-	assert.Equal(t, 10, product.Height)
-	assert.Equal(t, entities.ProductStatusPublished, product.Status)
-	mockRepo.AssertExpectations(t)
+	stored := storedProduct(t, ctx, repos, "TEST-003")
+	assert.Equal(t, 500, stored.Weight)
+	assert.Equal(t, 20, stored.Length)
+	assert.Equal(t, 15, stored.Width)
+	assert.Equal(t, 10, stored.Height)
+	assert.Equal(t, entities.ProductStatusPublished, stored.Status)
 }
 
 // TestCreateProduct_EmptySKU tests validation error when SKU is empty
 func TestCreateProduct_EmptySKU(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockProductRepository)
-	mockCategoryRepo := new(MockCategoryRepository)
-	service := NewProductService(mockRepo, mockCategoryRepo)
-	ctx := context.Background()
+	service, repos, _ := newProductService(ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
 
 	product := &entities.Product{
 		SKU:   "",
@@ -197,16 +280,14 @@ func TestCreateProduct_EmptySKU(t *testing.T) {
 	require.Error(t, err)
 	assert.True(t, errors.Is(err, domainErrors.ErrInvalidInput), "Should return validation error")
 	assert.Contains(t, err.Error(), "SKU is required")
-	mockRepo.AssertNotCalled(t, "Create")
+	assertNoProducts(t, ctx, repos)
 }
 
 // TestCreateProduct_WhitespaceSKU tests validation error when SKU contains only whitespace
 func TestCreateProduct_WhitespaceSKU(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockProductRepository)
-	mockCategoryRepo := new(MockCategoryRepository)
-	service := NewProductService(mockRepo, mockCategoryRepo)
-	ctx := context.Background()
+	service, repos, _ := newProductService(ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
 
 	product := &entities.Product{
 		SKU:   "   ",
@@ -221,16 +302,14 @@ func TestCreateProduct_WhitespaceSKU(t *testing.T) {
 	require.Error(t, err)
 	assert.True(t, errors.Is(err, domainErrors.ErrInvalidInput))
 	assert.Contains(t, err.Error(), "SKU is required")
-	mockRepo.AssertNotCalled(t, "Create")
+	assertNoProducts(t, ctx, repos)
 }
 
 // TestCreateProduct_EmptyName tests validation error when name is empty
 func TestCreateProduct_EmptyName(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockProductRepository)
-	mockCategoryRepo := new(MockCategoryRepository)
-	service := NewProductService(mockRepo, mockCategoryRepo)
-	ctx := context.Background()
+	service, repos, _ := newProductService(ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
 
 	product := &entities.Product{
 		SKU:   "TEST-004",
@@ -245,16 +324,14 @@ func TestCreateProduct_EmptyName(t *testing.T) {
 	require.Error(t, err)
 	assert.True(t, errors.Is(err, domainErrors.ErrInvalidInput))
 	assert.Contains(t, err.Error(), "Name is required")
-	mockRepo.AssertNotCalled(t, "Create")
+	assertNoProducts(t, ctx, repos)
 }
 
 // TestCreateProduct_ZeroPrice tests validation error when price is zero
 func TestCreateProduct_ZeroPrice(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockProductRepository)
-	mockCategoryRepo := new(MockCategoryRepository)
-	service := NewProductService(mockRepo, mockCategoryRepo)
-	ctx := context.Background()
+	service, repos, _ := newProductService(ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
 
 	product := &entities.Product{
 		SKU:   "TEST-005",
@@ -269,16 +346,14 @@ func TestCreateProduct_ZeroPrice(t *testing.T) {
 	require.Error(t, err)
 	assert.True(t, errors.Is(err, domainErrors.ErrInvalidInput))
 	assert.Contains(t, err.Error(), "Price must be greater than 0")
-	mockRepo.AssertNotCalled(t, "Create")
+	assertNoProducts(t, ctx, repos)
 }
 
 // TestCreateProduct_NegativePrice tests validation error when price is negative
 func TestCreateProduct_NegativePrice(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockProductRepository)
-	mockCategoryRepo := new(MockCategoryRepository)
-	service := NewProductService(mockRepo, mockCategoryRepo)
-	ctx := context.Background()
+	service, repos, _ := newProductService(ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
 
 	product := &entities.Product{
 		SKU:   "TEST-006",
@@ -293,16 +368,33 @@ func TestCreateProduct_NegativePrice(t *testing.T) {
 	require.Error(t, err)
 	assert.True(t, errors.Is(err, domainErrors.ErrInvalidInput))
 	assert.Contains(t, err.Error(), "Price must be greater than 0")
-	mockRepo.AssertNotCalled(t, "Create")
+	assertNoProducts(t, ctx, repos)
+}
+
+// TestCreateProduct_MultipleFieldErrors tests that all invalid fields are reported at once
+func TestCreateProduct_MultipleFieldErrors(t *testing.T) {
+	// Arrange
+	service, repos, _ := newProductService(ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	product := &entities.Product{
+		Price:  0,
+		Weight: -1,
+	}
+
+	// Act
+	err := service.CreateProduct(ctx, product)
+
+	// Assert
+	assert.ElementsMatch(t, []string{"sku.required", "name.required", "price.must_be_positive", "weight.must_not_be_negative"}, fieldErrorCodes(t, err))
+	assertNoProducts(t, ctx, repos)
 }
 
 // TestCreateProduct_NegativeWeight tests validation error when weight is negative
 func TestCreateProduct_NegativeWeight(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockProductRepository)
-	mockCategoryRepo := new(MockCategoryRepository)
-	service := NewProductService(mockRepo, mockCategoryRepo)
-	ctx := context.Background()
+	service, repos, _ := newProductService(ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
 
 	product := &entities.Product{
 		SKU:    "TEST-007",
@@ -318,16 +410,14 @@ func TestCreateProduct_NegativeWeight(t *testing.T) {
 	require.Error(t, err)
 	assert.True(t, errors.Is(err, domainErrors.ErrInvalidInput))
 	assert.Contains(t, err.Error(), "Weight cannot be negative")
-	mockRepo.AssertNotCalled(t, "Create")
+	assertNoProducts(t, ctx, repos)
 }
 
 // TestCreateProduct_NegativeLength tests validation error when length is negative
 func TestCreateProduct_NegativeLength(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockProductRepository)
-	mockCategoryRepo := new(MockCategoryRepository)
-	service := NewProductService(mockRepo, mockCategoryRepo)
-	ctx := context.Background()
+	service, repos, _ := newProductService(ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
 
 	product := &entities.Product{
 		SKU:    "TEST-008",
@@ -343,16 +433,14 @@ func TestCreateProduct_NegativeLength(t *testing.T) {
 	require.Error(t, err)
 	assert.True(t, errors.Is(err, domainErrors.ErrInvalidInput))
 	assert.Contains(t, err.Error(), "Length cannot be negative")
-	mockRepo.AssertNotCalled(t, "Create")
+	assertNoProducts(t, ctx, repos)
 }
 
 // TestCreateProduct_NegativeWidth tests validation error when width is negative
 func TestCreateProduct_NegativeWidth(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockProductRepository)
-	mockCategoryRepo := new(MockCategoryRepository)
-	service := NewProductService(mockRepo, mockCategoryRepo)
-	ctx := context.Background()
+	service, repos, _ := newProductService(ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
 
 	product := &entities.Product{
 		SKU:   "TEST-009",
@@ -368,16 +456,14 @@ func TestCreateProduct_NegativeWidth(t *testing.T) {
 	require.Error(t, err)
 	assert.True(t, errors.Is(err, domainErrors.ErrInvalidInput))
 	assert.Contains(t, err.Error(), "Width cannot be negative")
-	mockRepo.AssertNotCalled(t, "Create")
+	assertNoProducts(t, ctx, repos)
 }
 
 // TestCreateProduct_NegativeHeight tests validation error when height is negative
 func TestCreateProduct_NegativeHeight(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockProductRepository)
-	mockCategoryRepo := new(MockCategoryRepository)
-	service := NewProductService(mockRepo, mockCategoryRepo)
-	ctx := context.Background()
+	service, repos, _ := newProductService(ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
 
 	product := &entities.Product{
 		SKU:    "TEST-010",
@@ -393,16 +479,14 @@ func TestCreateProduct_NegativeHeight(t *testing.T) {
 	require.Error(t, err)
 	assert.True(t, errors.Is(err, domainErrors.ErrInvalidInput))
 	assert.Contains(t, err.Error(), "Height cannot be negative")
-	mockRepo.AssertNotCalled(t, "Create")
+	assertNoProducts(t, ctx, repos)
 }
 
 // TestCreateProduct_InvalidStatus tests validation error when status is invalid
 func TestCreateProduct_InvalidStatus(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockProductRepository)
-	mockCategoryRepo := new(MockCategoryRepository)
-	service := NewProductService(mockRepo, mockCategoryRepo)
-	ctx := context.Background()
+	service, repos, _ := newProductService(ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
 
 	product := &entities.Product{
 		SKU:    "TEST-011",
@@ -418,16 +502,15 @@ func TestCreateProduct_InvalidStatus(t *testing.T) {
 	require.Error(t, err)
 	assert.True(t, errors.Is(err, domainErrors.ErrInvalidInput))
 	assert.Contains(t, err.Error(), "Invalid product status")
-	mockRepo.AssertNotCalled(t, "Create")
+	assertNoProducts(t, ctx, repos)
 }
 
 // TestCreateProduct_RepositoryDuplicateError tests handling of duplicate entry from repository
 func TestCreateProduct_RepositoryDuplicateError(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockProductRepository)
-	mockCategoryRepo := new(MockCategoryRepository)
-	service := NewProductService(mockRepo, mockCategoryRepo)
-	ctx := context.Background()
+	service, repos, _ := newProductService(ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	storeProduct(t, ctx, repos, "TEST-DUPLICATE")
 
 	product := &entities.Product{
 		SKU:   "TEST-DUPLICATE",
@@ -435,25 +518,21 @@ func TestCreateProduct_RepositoryDuplicateError(t *testing.T) {
 		Price: 99.99,
 	}
 
-	duplicateErr := domainErrors.NewDuplicateError("Product", "sku", "TEST-DUPLICATE")
-	mockRepo.On("Create", ctx, mock.Anything).Return(duplicateErr)
-
 	// Act
 	err := service.CreateProduct(ctx, product)
 
 	// Assert
 	require.Error(t, err)
 	assert.True(t, errors.Is(err, domainErrors.ErrDuplicateEntry))
-	mockRepo.AssertExpectations(t)
 }
 
 // TestCreateProduct_RepositoryGenericError tests handling of generic repository error
 func TestCreateProduct_RepositoryGenericError(t *testing.T) {
 	// Arrange
-	mockRepo := new(MockProductRepository)
-	mockCategoryRepo := new(MockCategoryRepository)
-	service := NewProductService(mockRepo, mockCategoryRepo)
-	ctx := context.Background()
+	_, repos, storage := newProductService(ProductPolicy{})
+	failing := &failingProductRepository{ProductRepository: repos.products, err: errors.New("database connection failed")}
+	service := NewProductService(failing, repos.categories, repos.brands, repos.media, storage, ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
 
 	product := &entities.Product{
 		SKU:   "TEST-012",
@@ -461,14 +540,364 @@ func TestCreateProduct_RepositoryGenericError(t *testing.T) {
 		Price: 99.99,
 	}
 
-	genericErr := errors.New("database connection failed")
-	mockRepo.On("Create", ctx, mock.Anything).Return(genericErr)
-
 	// Act
 	err := service.CreateProduct(ctx, product)
 
 	// Assert
 	require.Error(t, err)
 	assert.Equal(t, "database connection failed", err.Error())
-	mockRepo.AssertExpectations(t)
+}
+
+// fieldErrorCodes returns the codes of the field errors in err
+func fieldErrorCodes(t *testing.T, err error) []string {
+	var validationErr *domainErrors.ValidationError
+	require.True(t, errors.As(err, &validationErr), "Should return validation error")
+	codes := make([]string, 0, len(validationErr.Errors))
+	for _, fe := range validationErr.Errors {
+		codes = append(codes, fe.Code)
+	}
+	return codes
+}
+
+// TestCreateProduct_UnknownReferences tests that missing categories and brands are reported as field errors
+func TestCreateProduct_UnknownReferences(t *testing.T) {
+	// Arrange
+	service, repos, _ := newProductService(ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	categoryID := uuid.New()
+	brandID := uuid.New()
+	product := &entities.Product{
+		SKU:        "TEST-013",
+		Name:       "Test Product",
+		Price:      99.99,
+		CategoryID: &categoryID,
+		BrandID:    &brandID,
+	}
+
+	// Act
+	err := service.CreateProduct(ctx, product)
+
+	// Assert
+	assert.ElementsMatch(t, []string{"category_id.not_found", "brand_id.not_found"}, fieldErrorCodes(t, err))
+	assertNoProducts(t, ctx, repos)
+}
+
+// TestUpdateProduct_LeafCategoriesOnly tests that products cannot be assigned to categories with subcategories
+func TestUpdateProduct_LeafCategoriesOnly(t *testing.T) {
+	// Arrange
+	service, repos, _ := newProductService(ProductPolicy{LeafCategoriesOnly: true})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	apparel := &entities.Category{Name: "Apparel"}
+	require.NoError(t, repos.categories.Create(ctx, apparel))
+	require.NoError(t, repos.categories.Create(ctx, &entities.Category{Name: "Shirts", ParentID: &apparel.ID}))
+	product := storeProduct(t, ctx, repos, "TEST-014")
+	product.CategoryID = &apparel.ID
+
+	// Act
+	err := service.UpdateProduct(ctx, product)
+
+	// Assert
+	assert.Equal(t, []string{"category_id.not_leaf"}, fieldErrorCodes(t, err))
+	assert.Nil(t, storedProduct(t, ctx, repos, "TEST-014").CategoryID)
+}
+
+// TestPublishProduct_PublishRules tests that publishing enforces the category and image requirements
+func TestPublishProduct_PublishRules(t *testing.T) {
+	// Arrange
+	service, repos, _ := newProductService(ProductPolicy{PublishRequiresCategory: true, PublishMinImages: 1})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	product := storeProduct(t, ctx, repos, "TEST-015")
+
+	// Act
+	err := service.PublishProduct(ctx, product.ID)
+
+	// Assert
+	assert.ElementsMatch(t, []string{"category_id.required_to_publish", "image_urls.too_few_to_publish"}, fieldErrorCodes(t, err))
+	assert.Equal(t, entities.ProductStatusDraft, storedProduct(t, ctx, repos, "TEST-015").Status)
+}
+
+// TestPublishProduct_PublishRulesSatisfied tests that products meeting the publish rules are published
+func TestPublishProduct_PublishRulesSatisfied(t *testing.T) {
+	// Arrange
+	service, repos, _ := newProductService(ProductPolicy{PublishRequiresCategory: true, PublishMinImages: 1})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	category := &entities.Category{Name: "Shirts"}
+	require.NoError(t, repos.categories.Create(ctx, category))
+	product := &entities.Product{
+		SKU:        "TEST-016",
+		Slug:       "test-016",
+		Name:       "Test Product",
+		Price:      99.99,
+		Status:     entities.ProductStatusDraft,
+		CategoryID: &category.ID,
+		ImageURLs:  []string{"https://cdn.example.com/shirt.png"},
+	}
+	require.NoError(t, repos.products.Create(ctx, product))
+
+	// Act
+	err := service.PublishProduct(ctx, product.ID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, entities.ProductStatusPublished, storedProduct(t, ctx, repos, "TEST-016").Status)
+}
+
+// TestGetProduct_RendersMediaURLs tests that the gallery is loaded with URLs from the storage service
+func TestGetProduct_RendersMediaURLs(t *testing.T) {
+	// Arrange
+	service, repos, storage := newProductService(ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	product := storeProduct(t, ctx, repos, "TEST-017")
+	storeMedia(t, ctx, repos, &entities.ProductMedia{
+		ProductID: product.ID,
+		File:      &entities.FileMetadata{Bucket: "uploads", FileName: "shirt.png"},
+	})
+	storage.On("GetFileURL", ctx, "uploads", "shirt.png").Return("/files/download?file_name=shirt.png", nil)
+
+	// Act
+	result, err := service.GetProduct(ctx, product.ID)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, result.Media, 1)
+	assert.Equal(t, "/files/download?file_name=shirt.png", result.Media[0].File.URL)
+}
+
+// TestAttachMedia_AppendsToGallery tests that media are appended with the type of their file
+func TestAttachMedia_AppendsToGallery(t *testing.T) {
+	// Arrange
+	service, repos, storage := newProductService(ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	product := storeProduct(t, ctx, repos, "TEST-018")
+	existing := storeMedia(t, ctx, repos, &entities.ProductMedia{ProductID: product.ID, IsPrimary: true})
+	file := &entities.FileMetadata{ID: uuid.New(), ContentType: "video/mp4", URL: "/files/download?file_name=demo.mp4"}
+	media := &entities.ProductMedia{FileID: file.ID, IsPrimary: true}
+	storage.On("GetFile", ctx, file.ID).Return(file, nil)
+
+	// Act
+	err := service.AttachMedia(ctx, product.ID, media)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 1, media.Position)
+	assert.Equal(t, entities.MediaTypeVideo, media.Type)
+	assert.True(t, media.IsPrimary)
+	assert.Equal(t, file.URL, media.File.URL)
+	gallery, err := repos.media.ListByProduct(ctx, product.ID)
+	require.NoError(t, err)
+	require.Len(t, gallery, 2)
+	assert.Equal(t, existing.ID, gallery[0].ID)
+	assert.False(t, gallery[0].IsPrimary, "The previous primary media should be demoted")
+	assert.Equal(t, media.ID, gallery[1].ID)
+	assert.True(t, gallery[1].IsPrimary)
+}
+
+// TestAttachMedia_Invalid tests that unknown files and duplicate attachments are rejected
+func TestAttachMedia_Invalid(t *testing.T) {
+	// Arrange
+	service, repos, storage := newProductService(ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	product := storeProduct(t, ctx, repos, "TEST-019")
+	attached := storeMedia(t, ctx, repos, &entities.ProductMedia{ProductID: product.ID})
+	missingID := uuid.New()
+	storage.On("GetFile", ctx, missingID).Return(nil, domainErrors.NewNotFoundError("File", missingID))
+	storage.On("GetFile", ctx, attached.FileID).Return(&entities.FileMetadata{ID: attached.FileID, ContentType: "image/png"}, nil)
+
+	// Act
+	missingErr := service.AttachMedia(ctx, product.ID, &entities.ProductMedia{FileID: missingID})
+	duplicateErr := service.AttachMedia(ctx, product.ID, &entities.ProductMedia{FileID: attached.FileID})
+
+	// Assert
+	assert.Equal(t, []string{"file_id.not_found"}, fieldErrorCodes(t, missingErr))
+	assert.True(t, errors.Is(duplicateErr, domainErrors.ErrDuplicateEntry))
+	gallery, err := repos.media.ListByProduct(ctx, product.ID)
+	require.NoError(t, err)
+	assert.Len(t, gallery, 1)
+}
+
+// TestReorderMedia tests that the gallery is renumbered in the given order, which must be complete
+func TestReorderMedia(t *testing.T) {
+	// Arrange
+	service, repos, _ := newProductService(ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	product := storeProduct(t, ctx, repos, "TEST-020")
+	first := storeMedia(t, ctx, repos, &entities.ProductMedia{ProductID: product.ID, Position: 0})
+	second := storeMedia(t, ctx, repos, &entities.ProductMedia{ProductID: product.ID, Position: 1})
+
+	// Act
+	_, incompleteErr := service.ReorderMedia(ctx, product.ID, []uuid.UUID{second.ID})
+	_, repeatedErr := service.ReorderMedia(ctx, product.ID, []uuid.UUID{second.ID, second.ID})
+	gallery, err := service.ReorderMedia(ctx, product.ID, []uuid.UUID{second.ID, first.ID})
+
+	// Assert
+	assert.Equal(t, []string{"media_ids.mismatch"}, fieldErrorCodes(t, incompleteErr))
+	assert.Equal(t, []string{"media_ids.mismatch"}, fieldErrorCodes(t, repeatedErr))
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{second.ID, first.ID}, mediaIDs(gallery))
+	stored, err := repos.media.ListByProduct(ctx, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{second.ID, first.ID}, mediaIDs(stored))
+	assert.Equal(t, []int{0, 1}, []int{stored[0].Position, stored[1].Position})
+}
+
+// TestDetachMedia_PromotesNextPrimary tests that removing the primary media makes the next one primary
+func TestDetachMedia_PromotesNextPrimary(t *testing.T) {
+	// Arrange
+	service, repos, _ := newProductService(ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	product := storeProduct(t, ctx, repos, "TEST-021")
+	primary := storeMedia(t, ctx, repos, &entities.ProductMedia{ProductID: product.ID, Position: 0, IsPrimary: true})
+	next := storeMedia(t, ctx, repos, &entities.ProductMedia{ProductID: product.ID, Position: 1})
+
+	// Act
+	err := service.DetachMedia(ctx, product.ID, primary.ID)
+	missingErr := service.DetachMedia(ctx, product.ID, uuid.New())
+
+	// Assert
+	require.NoError(t, err)
+	gallery, listErr := repos.media.ListByProduct(ctx, product.ID)
+	require.NoError(t, listErr)
+	require.Len(t, gallery, 1)
+	assert.Equal(t, next.ID, gallery[0].ID)
+	assert.True(t, gallery[0].IsPrimary)
+	assert.Equal(t, 0, gallery[0].Position)
+	assert.True(t, errors.Is(missingErr, domainErrors.ErrNotFound))
+}
+
+// TestPublishProduct_CountsImageMedia tests that image media count towards the publishing image requirement
+func TestPublishProduct_CountsImageMedia(t *testing.T) {
+	// Arrange
+	service, repos, storage := newProductService(ProductPolicy{PublishMinImages: 1})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	product := storeProduct(t, ctx, repos, "TEST-022")
+	storeMedia(t, ctx, repos, &entities.ProductMedia{ProductID: product.ID, File: &entities.FileMetadata{Bucket: "uploads", FileName: "shirt.png"}})
+	storage.On("GetFileURL", ctx, "uploads", "shirt.png").Return("/files/download?file_name=shirt.png", nil)
+
+	// Act
+	err := service.PublishProduct(ctx, product.ID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, entities.ProductStatusPublished, storedProduct(t, ctx, repos, "TEST-022").Status)
+}
+
+// TestImportProducts_Success tests that valid products are created at once with the defaults of new products
+func TestImportProducts_Success(t *testing.T) {
+	// Arrange
+	service, repos, _ := newProductService(ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	category := &entities.Category{Name: "Shirts"}
+	require.NoError(t, repos.categories.Create(ctx, category))
+	products := []*entities.Product{
+		{SKU: "TEST-023", Name: "First Product", Price: 10, CategoryID: &category.ID},
+		{SKU: "TEST-024", Name: "Second Product", Price: 20, CategoryID: &category.ID},
+	}
+
+	// Act
+	err := service.ImportProducts(ctx, products)
+
+	// Assert
+	require.NoError(t, err)
+	stored := storedProduct(t, ctx, repos, "TEST-024")
+	assert.Equal(t, "second-product", stored.Slug)
+	assert.Equal(t, entities.ProductStatusDraft, stored.Status)
+	assert.Equal(t, &category.ID, stored.CategoryID)
+	assert.Equal(t, "first-product", storedProduct(t, ctx, repos, "TEST-023").Slug)
+}
+
+// TestImportProducts_Media tests that the galleries of imported products are created, looking up each file
+// once and the IDs of copied products by SKU
+func TestImportProducts_Media(t *testing.T) {
+	// Arrange
+	_, repos, storage := newProductService(ProductPolicy{})
+	service := NewProductService(&copyingProductRepository{repos.products}, repos.categories, repos.brands, repos.media, storage, ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	front, back := uuid.New(), uuid.New()
+	products := []*entities.Product{
+		{SKU: "TEST-025", Name: "First Product", Price: 10, Media: []*entities.ProductMedia{{FileID: front}, {FileID: back, IsPrimary: true}}},
+		{SKU: "TEST-026", Name: "Second Product", Price: 20, Media: []*entities.ProductMedia{{FileID: back}}},
+		{SKU: "TEST-027", Name: "Third Product", Price: 30},
+	}
+	storage.On("GetFile", ctx, front).Return(&entities.FileMetadata{ID: front, ContentType: "image/png"}, nil).Once()
+	storage.On("GetFile", ctx, back).Return(&entities.FileMetadata{ID: back, ContentType: "image/png"}, nil).Once()
+
+	// Act
+	err := service.ImportProducts(ctx, products)
+
+	// Assert
+	require.NoError(t, err)
+	first, err := repos.media.ListByProduct(ctx, storedProduct(t, ctx, repos, "TEST-025").ID)
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	assert.Equal(t, []uuid.UUID{front, back}, []uuid.UUID{first[0].FileID, first[1].FileID})
+	assert.Equal(t, []bool{false, true}, []bool{first[0].IsPrimary, first[1].IsPrimary})
+	second, err := repos.media.ListByProduct(ctx, storedProduct(t, ctx, repos, "TEST-026").ID)
+	require.NoError(t, err)
+	require.Len(t, second, 1)
+	assert.True(t, second[0].IsPrimary)
+	assert.Equal(t, entities.MediaTypeImage, second[0].Type)
+	storage.AssertExpectations(t)
+}
+
+// TestImportProducts_InvalidMedia tests that media with missing or repeated files are reported per product
+func TestImportProducts_InvalidMedia(t *testing.T) {
+	// Arrange
+	service, repos, storage := newProductService(ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	missing, image := uuid.New(), uuid.New()
+	products := []*entities.Product{
+		{SKU: "TEST-028", Name: "First Product", Price: 10, Media: []*entities.ProductMedia{{FileID: missing}}},
+		{SKU: "TEST-029", Name: "Second Product", Price: 20, Media: []*entities.ProductMedia{{FileID: image}, {FileID: image}}},
+	}
+	storage.On("GetFile", ctx, missing).Return(nil, domainErrors.NewNotFoundError("File", missing))
+	storage.On("GetFile", ctx, image).Return(&entities.FileMetadata{ID: image, ContentType: "image/png"}, nil)
+
+	// Act
+	err := service.ImportProducts(ctx, products)
+
+	// Assert
+	assert.Equal(t, []string{"products[0].media[0].file_id.not_found", "products[1].media[1].file_id.duplicate"}, fieldErrorCodes(t, err))
+	assertNoProducts(t, ctx, repos)
+}
+
+// TestImportProducts_Invalid tests that the errors of every invalid product are reported and nothing is created
+func TestImportProducts_Invalid(t *testing.T) {
+	// Arrange
+	service, repos, _ := newProductService(ProductPolicy{})
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	brandID := uuid.New()
+	products := []*entities.Product{
+		{SKU: "TEST-030", Name: "Valid Product", Price: 10},
+		{SKU: "TEST-031", Name: "Free Product", Price: 0, BrandID: &brandID},
+	}
+
+	// Act
+	err := service.ImportProducts(ctx, products)
+
+	// Assert
+	assert.Equal(t, []string{"products[1].price.must_be_positive", "products[1].brand_id.not_found"}, fieldErrorCodes(t, err))
+	assertNoProducts(t, ctx, repos)
+}
+
+// mediaIDs returns the IDs of media
+func mediaIDs(media []*entities.ProductMedia) []uuid.UUID {
+	ids := make([]uuid.UUID, len(media))
+	for i, m := range media {
+		ids[i] = m.ID
+	}
+	return ids
 }
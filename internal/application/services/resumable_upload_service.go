@@ -0,0 +1,255 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"example.com/go-yippi/internal/domain/ports"
+	"github.com/google/uuid"
+)
+
+// defaultPartSize is the part size of policies that do not set one
+const defaultPartSize = 8 << 20
+
+// ResumableUploadPolicy holds the settings of resumable uploads
+type ResumableUploadPolicy struct {
+	// PartSize is the largest part chunks are stored in, and what an interrupted chunk loses at most
+	PartSize int64
+	// Expiry is how long an upload is kept after it was created or last received content
+	Expiry time.Duration
+}
+
+// ResumableUploadService receives files in chunks over several requests (the tus protocol). Chunks are cut
+// into parts that are stored as they arrive, under resumable/<tenant>/<upload ID>/, so a chunk that is
+// interrupted keeps what was received. Completed uploads are composed from their parts by the storage
+// service, which checks and records them like any other upload.
+type ResumableUploadService struct {
+	repo          ports.StorageRepository
+	uploads       ports.ResumableUploadRepository
+	storage       ports.StorageService
+	defaultBucket string
+	limits        UploadLimits
+	policy        ResumableUploadPolicy
+	logger        *slog.Logger
+	now           func() time.Time
+}
+
+// NewResumableUploadService creates a new resumable upload service; a nil logger logs with slog.Default
+func NewResumableUploadService(repo ports.StorageRepository, uploads ports.ResumableUploadRepository, storage ports.StorageService, defaultBucket string, limits UploadLimits, policy ResumableUploadPolicy, logger *slog.Logger) *ResumableUploadService {
+	if policy.PartSize <= 0 {
+		policy.PartSize = defaultPartSize
+	}
+	return &ResumableUploadService{
+		repo:          repo,
+		uploads:       uploads,
+		storage:       storage,
+		defaultBucket: defaultBucket,
+		limits:        limits,
+		policy:        policy,
+		logger:        orDefaultLogger(logger),
+		now:           time.Now,
+	}
+}
+
+// CreateUpload validates a new upload and records it. Expired uploads of the tenant are discarded first.
+func (s *ResumableUploadService) CreateUpload(ctx context.Context, upload *entities.ResumableUpload) error {
+	// Use default bucket if not specified
+	if upload.Bucket == "" {
+		upload.Bucket = s.defaultBucket
+	}
+
+	_, err := objectName(ctx, upload.FileName)
+	if err != nil {
+		return err
+	}
+
+	validationErr := &domainErrors.ValidationError{}
+	if upload.Length <= 0 {
+		validationErr.Add("upload_length", "must_be_positive", "upload length must be greater than 0")
+	} else if maxSize := s.limits.MaxSize(upload.Bucket, upload.ContentType); maxSize > 0 && upload.Length > maxSize {
+		validationErr.Add("upload_length", "too_large", fmt.Sprintf("file exceeds the maximum size of %d bytes", maxSize))
+	}
+	if upload.SHA256 != "" && !isSHA256(upload.SHA256) {
+		validationErr.Add("sha256", "invalid", "sha256 must be a hex-encoded SHA-256 checksum")
+	}
+	if err := validationErr.ErrOrNil(); err != nil {
+		return err
+	}
+
+	s.purgeExpired(ctx)
+
+	err = s.repo.EnsureBucket(ctx, upload.Bucket)
+	if err != nil {
+		return err
+	}
+
+	upload.ExpiresAt = s.now().Add(s.policy.Expiry)
+	return s.uploads.Create(ctx, upload)
+}
+
+// GetUpload returns an upload; expired uploads are discarded and not found
+func (s *ResumableUploadService) GetUpload(ctx context.Context, id uuid.UUID) (*entities.ResumableUpload, error) {
+	upload, err := s.uploads.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !upload.ExpiresAt.After(s.now()) {
+		if err := s.discard(ctx, upload); err != nil {
+			s.logger.WarnContext(ctx, "failed to discard expired upload", "upload_id", upload.ID, "error", err)
+		}
+		return nil, domainErrors.NewNotFoundError("Upload", id)
+	}
+
+	return upload, nil
+}
+
+// WriteChunk stores content sent for an offset of an upload, which must be the offset the upload is at.
+// If the content is cut off, what was received is kept and the error is returned. Once the upload has
+// all its bytes it is stored as a file, which is returned, and the upload is discarded.
+func (s *ResumableUploadService) WriteChunk(ctx context.Context, id uuid.UUID, offset int64, content io.Reader) (*entities.ResumableUpload, *entities.FileMetadata, error) {
+	upload, err := s.GetUpload(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if offset != upload.Offset {
+		return nil, nil, domainErrors.NewUploadOffsetError(upload.Offset)
+	}
+
+	buf := make([]byte, min(s.policy.PartSize, upload.Length-upload.Offset))
+	for upload.Offset < upload.Length {
+		n, readErr := io.ReadFull(content, buf[:min(int64(len(buf)), upload.Length-upload.Offset)])
+		if n > 0 {
+			if err := s.storePart(ctx, upload, buf[:n]); err != nil {
+				return nil, nil, err
+			}
+		}
+		if errors.Is(readErr, io.EOF) || errors.Is(readErr, io.ErrUnexpectedEOF) {
+			break
+		}
+		if readErr != nil {
+			return nil, nil, readErr
+		}
+	}
+
+	if upload.Offset < upload.Length {
+		return upload, nil, nil
+	}
+
+	// Bytes past the declared length are not part of the file
+	if n, _ := io.ReadFull(content, make([]byte, 1)); n > 0 {
+		return nil, nil, domainErrors.NewValidationError("content", "exceeds_length", fmt.Sprintf("content exceeds the upload length of %d bytes", upload.Length))
+	}
+
+	file, err := s.complete(ctx, upload)
+	if err != nil {
+		return nil, nil, err
+	}
+	return upload, file, nil
+}
+
+// TerminateUpload discards an upload and the content received for it
+func (s *ResumableUploadService) TerminateUpload(ctx context.Context, id uuid.UUID) error {
+	upload, err := s.uploads.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return s.discard(ctx, upload)
+}
+
+// storePart stores a part at the upload's offset and records it
+func (s *ResumableUploadService) storePart(ctx context.Context, upload *entities.ResumableUpload, data []byte) error {
+	prefix, err := partPrefix(ctx, upload)
+	if err != nil {
+		return err
+	}
+
+	// Parts are named uniquely, so a concurrent chunk for the same offset cannot overwrite a recorded part
+	part := entities.UploadPart{
+		Name:   fmt.Sprintf("%s%020d-%s", prefix, upload.Offset, uuid.NewString()),
+		Offset: upload.Offset,
+		Size:   int64(len(data)),
+	}
+	_, err = s.repo.Store(ctx, upload.Bucket, part.Name, bytes.NewReader(data), part.Size, "application/octet-stream")
+	if err != nil {
+		return err
+	}
+
+	expiresAt := s.now().Add(s.policy.Expiry)
+	err = s.uploads.AppendPart(ctx, upload.ID, part, expiresAt)
+	if err != nil {
+		if removeErr := s.repo.Remove(ctx, upload.Bucket, part.Name); removeErr != nil {
+			s.logger.WarnContext(ctx, "failed to remove unrecorded part", "part", part.Name, "error", removeErr)
+		}
+		return err
+	}
+
+	upload.Offset += part.Size
+	upload.Parts = append(upload.Parts, part)
+	upload.ExpiresAt = expiresAt
+	return nil
+}
+
+// complete stores a complete upload as a file and discards the upload. An upload the storage service
+// rejects, e.g. for a checksum mismatch, is discarded too, as its content cannot change anymore.
+func (s *ResumableUploadService) complete(ctx context.Context, upload *entities.ResumableUpload) (*entities.FileMetadata, error) {
+	file, err := s.storage.ComposeFile(ctx, &entities.FileUpload{
+		Bucket:      upload.Bucket,
+		FileName:    upload.FileName,
+		Size:        upload.Length,
+		ContentType: upload.ContentType,
+		SHA256:      upload.SHA256,
+	}, upload.Parts)
+
+	if discardErr := s.discard(ctx, upload); discardErr != nil {
+		s.logger.WarnContext(ctx, "failed to discard completed upload", "upload_id", upload.ID, "error", discardErr)
+	}
+	return file, err
+}
+
+// discard deletes the record of an upload and then its parts, so that chunks still arriving fail to be recorded
+func (s *ResumableUploadService) discard(ctx context.Context, upload *entities.ResumableUpload) error {
+	err := s.uploads.Delete(ctx, upload.ID)
+	if err != nil && !errors.Is(err, domainErrors.ErrNotFound) {
+		return err
+	}
+
+	prefix, err := partPrefix(ctx, upload)
+	if err != nil {
+		return err
+	}
+	return s.repo.RemovePrefix(ctx, upload.Bucket, prefix)
+}
+
+// purgeExpired discards the tenant's uploads that expired, as clients abandon uploads without terminating them
+func (s *ResumableUploadService) purgeExpired(ctx context.Context) {
+	expired, err := s.uploads.ListExpired(ctx, s.now())
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to list expired uploads", "error", err)
+		return
+	}
+
+	for _, upload := range expired {
+		if err := s.discard(ctx, upload); err != nil {
+			s.logger.WarnContext(ctx, "failed to discard expired upload", "upload_id", upload.ID, "error", err)
+		}
+	}
+}
+
+// partPrefix returns the prefix of the parts of an upload, resumable/<tenant>/<upload ID>/. It lies outside
+// the tenants/ prefix of uploaded files, so parts are not mistaken for files.
+func partPrefix(ctx context.Context, upload *entities.ResumableUpload) (string, error) {
+	tenantID, ok := entities.TenantFromContext(ctx)
+	if !ok {
+		return "", domainErrors.ErrTenantRequired
+	}
+	return "resumable/" + tenantID + "/" + upload.ID.String() + "/", nil
+}
@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockResumableUploadRepository is a mock implementation of ports.ResumableUploadRepository
+type MockResumableUploadRepository struct {
+	mock.Mock
+}
+
+func (m *MockResumableUploadRepository) Create(ctx context.Context, upload *entities.ResumableUpload) error {
+	args := m.Called(ctx, upload)
+	return args.Error(0)
+}
+
+func (m *MockResumableUploadRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.ResumableUpload, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.ResumableUpload), args.Error(1)
+}
+
+func (m *MockResumableUploadRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockResumableUploadRepository) AppendPart(ctx context.Context, id uuid.UUID, part entities.UploadPart, expiresAt time.Time) error {
+	args := m.Called(ctx, id, part, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockResumableUploadRepository) ListExpired(ctx context.Context, before time.Time) ([]*entities.ResumableUpload, error) {
+	args := m.Called(ctx, before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.ResumableUpload), args.Error(1)
+}
+
+// resumableUploadMocks holds the mocks of a resumable upload service
+type resumableUploadMocks struct {
+	repo    *MockStorageRepository
+	uploads *MockResumableUploadRepository
+	storage *MockStorageService
+	// parts holds the content of stored parts by name
+	parts map[string][]byte
+}
+
+// newResumableUploadService creates a service with 4-byte parts whose storage keeps parts in memory
+func newResumableUploadService(now time.Time) (*ResumableUploadService, *resumableUploadMocks) {
+	mocks := &resumableUploadMocks{
+		repo:    new(MockStorageRepository),
+		uploads: new(MockResumableUploadRepository),
+		storage: new(MockStorageService),
+		parts:   map[string][]byte{},
+	}
+	mocks.repo.On("Store", mock.Anything, "uploads", mock.Anything, mock.Anything, mock.Anything, "application/octet-stream").
+		Return(func(ctx context.Context, bucket, fileName string, reader io.Reader, size int64, contentType string) (*entities.FileMetadata, error) {
+			data, err := io.ReadAll(reader)
+			mocks.parts[fileName] = data
+			return &entities.FileMetadata{Key: fileName, Size: int64(len(data))}, err
+		}).
+		Maybe()
+
+	service := NewResumableUploadService(mocks.repo, mocks.uploads, mocks.storage, "uploads", UploadLimits{Default: 100}, ResumableUploadPolicy{
+		PartSize: 4,
+		Expiry:   time.Hour,
+	}, nil)
+	service.now = func() time.Time { return now }
+	return service, mocks
+}
+
+// failingReader returns its content and then fails, like a dropped connection
+type failingReader struct {
+	content io.Reader
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	n, err := r.content.Read(p)
+	if errors.Is(err, io.EOF) {
+		return n, errors.New("connection reset")
+	}
+	return n, err
+}
+
+// TestCreateResumableUpload tests that uploads are validated, expire after the policy's expiry and purge expired uploads
+func TestCreateResumableUpload(t *testing.T) {
+	// Arrange
+	now := time.Unix(1_700_000_000, 0)
+	service, mocks := newResumableUploadService(now)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	expired := &entities.ResumableUpload{ID: uuid.New(), Bucket: "uploads"}
+
+	mocks.uploads.On("ListExpired", ctx, now).Return([]*entities.ResumableUpload{expired}, nil)
+	mocks.uploads.On("Delete", ctx, expired.ID).Return(nil)
+	mocks.repo.On("RemovePrefix", ctx, "uploads", "resumable/acme/"+expired.ID.String()+"/").Return(nil)
+	mocks.repo.On("EnsureBucket", ctx, "uploads").Return(nil)
+	mocks.uploads.On("Create", ctx, mock.AnythingOfType("*entities.ResumableUpload")).Return(nil)
+
+	upload := &entities.ResumableUpload{FileName: "videos/intro.mp4", Length: 10}
+
+	// Act
+	err := service.CreateUpload(ctx, upload)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "uploads", upload.Bucket)
+	assert.Equal(t, now.Add(time.Hour), upload.ExpiresAt)
+	mocks.uploads.AssertExpectations(t)
+	mocks.repo.AssertExpectations(t)
+}
+
+// TestCreateResumableUpload_Validation tests that invalid lengths and checksums are rejected before anything is stored
+func TestCreateResumableUpload_Validation(t *testing.T) {
+	// Arrange
+	service, mocks := newResumableUploadService(time.Now())
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	// Act
+	errEmpty := service.CreateUpload(ctx, &entities.ResumableUpload{FileName: "a.bin"})
+	errTooLarge := service.CreateUpload(ctx, &entities.ResumableUpload{FileName: "a.bin", Length: 101})
+	errChecksum := service.CreateUpload(ctx, &entities.ResumableUpload{FileName: "a.bin", Length: 10, SHA256: "abc"})
+	errName := service.CreateUpload(ctx, &entities.ResumableUpload{FileName: "../a.bin", Length: 10})
+
+	// Assert
+	var validationErr *domainErrors.ValidationError
+	require.ErrorAs(t, errEmpty, &validationErr)
+	assert.Equal(t, "upload_length.must_be_positive", validationErr.Errors[0].Code)
+	require.ErrorAs(t, errTooLarge, &validationErr)
+	assert.Equal(t, "upload_length.too_large", validationErr.Errors[0].Code)
+	require.ErrorAs(t, errChecksum, &validationErr)
+	assert.Equal(t, "sha256.invalid", validationErr.Errors[0].Code)
+	assert.ErrorIs(t, errName, domainErrors.ErrInvalidInput)
+	mocks.uploads.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// TestWriteChunk_CompletesUpload tests that chunks are stored in parts and the complete upload is stored as a file
+func TestWriteChunk_CompletesUpload(t *testing.T) {
+	// Arrange
+	now := time.Unix(1_700_000_000, 0)
+	service, mocks := newResumableUploadService(now)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	upload := &entities.ResumableUpload{ID: uuid.New(), Bucket: "uploads", FileName: "videos/intro.mp4", SHA256: "abc", Length: 10, ExpiresAt: now.Add(time.Minute)}
+	stored := &entities.FileMetadata{ID: uuid.New(), FileName: "videos/intro.mp4"}
+	var parts []entities.UploadPart
+
+	mocks.uploads.On("GetByID", ctx, upload.ID).Return(upload, nil)
+	mocks.uploads.On("AppendPart", ctx, upload.ID, mock.AnythingOfType("entities.UploadPart"), now.Add(time.Hour)).Return(nil)
+	mocks.storage.On("ComposeFile", ctx, mock.AnythingOfType("*entities.FileUpload"), mock.Anything).
+		Run(func(args mock.Arguments) {
+			parts = args.Get(2).([]entities.UploadPart)
+		}).
+		Return(stored, nil)
+	mocks.uploads.On("Delete", ctx, upload.ID).Return(nil)
+	mocks.repo.On("RemovePrefix", ctx, "uploads", "resumable/acme/"+upload.ID.String()+"/").Return(nil)
+
+	// Act
+	first, firstFile, firstErr := service.WriteChunk(ctx, upload.ID, 0, strings.NewReader("012345"))
+	require.NoError(t, firstErr)
+	firstOffset := first.Offset
+	second, secondFile, secondErr := service.WriteChunk(ctx, upload.ID, 6, strings.NewReader("6789"))
+
+	// Assert
+	assert.Nil(t, firstFile)
+	assert.Equal(t, int64(6), firstOffset)
+	require.NoError(t, secondErr)
+	assert.Equal(t, stored, secondFile)
+	assert.Equal(t, int64(10), second.Offset)
+	// 4-byte parts: 0123, 45, 6789
+	assert.Len(t, upload.Parts, 3)
+	var content string
+	for _, part := range parts {
+		content += string(mocks.parts[part.Name])
+	}
+	assert.Equal(t, "0123456789", content)
+	mocks.storage.AssertCalled(t, "ComposeFile", ctx, mock.MatchedBy(func(u *entities.FileUpload) bool {
+		return u.Bucket == "uploads" && u.FileName == "videos/intro.mp4" && u.Size == 10 && u.SHA256 == "abc" && u.Content == nil
+	}), upload.Parts)
+	mocks.uploads.AssertCalled(t, "Delete", ctx, upload.ID)
+}
+
+// TestWriteChunk_OffsetMismatch tests that a chunk for another offset is rejected with the upload's offset
+func TestWriteChunk_OffsetMismatch(t *testing.T) {
+	// Arrange
+	now := time.Now()
+	service, mocks := newResumableUploadService(now)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	upload := &entities.ResumableUpload{ID: uuid.New(), Bucket: "uploads", Length: 10, Offset: 4, ExpiresAt: now.Add(time.Minute)}
+	mocks.uploads.On("GetByID", ctx, upload.ID).Return(upload, nil)
+
+	// Act
+	_, _, err := service.WriteChunk(ctx, upload.ID, 0, strings.NewReader("0123"))
+
+	// Assert
+	var offsetErr *domainErrors.UploadOffsetError
+	require.ErrorAs(t, err, &offsetErr)
+	assert.Equal(t, int64(4), offsetErr.Offset)
+	mocks.repo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestWriteChunk_Interrupted tests that the bytes of an interrupted chunk are kept, including those of an incomplete part
+func TestWriteChunk_Interrupted(t *testing.T) {
+	// Arrange
+	now := time.Now()
+	service, mocks := newResumableUploadService(now)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	upload := &entities.ResumableUpload{ID: uuid.New(), Bucket: "uploads", Length: 10, ExpiresAt: now.Add(time.Minute)}
+	mocks.uploads.On("GetByID", ctx, upload.ID).Return(upload, nil)
+	mocks.uploads.On("AppendPart", ctx, upload.ID, mock.AnythingOfType("entities.UploadPart"), mock.Anything).Return(nil)
+
+	// Act
+	_, _, err := service.WriteChunk(ctx, upload.ID, 0, &failingReader{content: strings.NewReader("01234")})
+
+	// Assert
+	assert.Error(t, err)
+	assert.Equal(t, int64(5), upload.Offset)
+	require.Len(t, upload.Parts, 2)
+	assert.Equal(t, entities.UploadPart{Name: upload.Parts[1].Name, Offset: 4, Size: 1}, upload.Parts[1])
+	assert.Equal(t, "4", string(mocks.parts[upload.Parts[1].Name]))
+	mocks.storage.AssertNotCalled(t, "ComposeFile", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestWriteChunk_ConcurrentChunk tests that a part that loses the race for an offset is removed
+func TestWriteChunk_ConcurrentChunk(t *testing.T) {
+	// Arrange
+	now := time.Now()
+	service, mocks := newResumableUploadService(now)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	upload := &entities.ResumableUpload{ID: uuid.New(), Bucket: "uploads", Length: 10, ExpiresAt: now.Add(time.Minute)}
+	mocks.uploads.On("GetByID", ctx, upload.ID).Return(upload, nil)
+	mocks.uploads.On("AppendPart", ctx, upload.ID, mock.AnythingOfType("entities.UploadPart"), mock.Anything).Return(domainErrors.NewUploadOffsetError(4))
+	mocks.repo.On("Remove", ctx, "uploads", mock.Anything).Return(nil)
+
+	// Act
+	_, _, err := service.WriteChunk(ctx, upload.ID, 0, strings.NewReader("0123"))
+
+	// Assert
+	assert.ErrorIs(t, err, domainErrors.ErrConflict)
+	mocks.repo.AssertNumberOfCalls(t, "Remove", 1)
+}
+
+// TestGetResumableUpload_Expired tests that expired uploads are discarded and not found
+func TestGetResumableUpload_Expired(t *testing.T) {
+	// Arrange
+	now := time.Now()
+	service, mocks := newResumableUploadService(now)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	upload := &entities.ResumableUpload{ID: uuid.New(), Bucket: "uploads", Length: 10, ExpiresAt: now.Add(-time.Second)}
+	mocks.uploads.On("GetByID", ctx, upload.ID).Return(upload, nil)
+	mocks.uploads.On("Delete", ctx, upload.ID).Return(nil)
+	mocks.repo.On("RemovePrefix", ctx, "uploads", "resumable/acme/"+upload.ID.String()+"/").Return(nil)
+
+	// Act
+	_, err := service.GetUpload(ctx, upload.ID)
+
+	// Assert
+	assert.ErrorIs(t, err, domainErrors.ErrNotFound)
+	mocks.uploads.AssertExpectations(t)
+	mocks.repo.AssertExpectations(t)
+}
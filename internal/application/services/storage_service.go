@@ -1,76 +1,310 @@
 package services
 
 import (
+	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"io"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"example.com/go-yippi/internal/domain/entities"
 	domainErrors "example.com/go-yippi/internal/domain/errors"
 	"example.com/go-yippi/internal/domain/ports"
+	"github.com/google/uuid"
 )
 
-// StorageService implements business logic for file storage operations
+const (
+	// sniffLen is the number of leading bytes used to detect the content type (see http.DetectContentType)
+	sniffLen = 512
+	// maxObjectNameLen is the longest object name S3 accepts, in bytes
+	maxObjectNameLen = 1024
+)
+
+// UploadLimits holds the maximum upload sizes in bytes; zero means unlimited.
+// A content type limit takes precedence over a bucket limit, which takes precedence over the default.
+type UploadLimits struct {
+	Default int64
+	// ByBucket maps bucket names to their limit
+	ByBucket map[string]int64
+	// ByContentType maps content types ("image/png") or type wildcards ("image/*") to their limit
+	ByContentType map[string]int64
+}
+
+// MaxSize returns the limit for an upload of contentType to bucket
+func (l UploadLimits) MaxSize(bucket, contentType string) int64 {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+	if limit, ok := l.ByContentType[mediaType]; ok {
+		return limit
+	}
+	if major, _, ok := strings.Cut(mediaType, "/"); ok {
+		if limit, ok := l.ByContentType[major+"/*"]; ok {
+			return limit
+		}
+	}
+	if limit, ok := l.ByBucket[bucket]; ok {
+		return limit
+	}
+	return l.Default
+}
+
+// InUsePolicy decides what happens when a file that products show in their gallery is deleted
+type InUsePolicy string
+
+const (
+	// InUseBlock rejects the deletion until the file is detached from every product
+	InUseBlock InUsePolicy = "block"
+	// InUseCascade detaches the file from the products along with the deletion
+	InUseCascade InUsePolicy = "cascade"
+)
+
+// StorageService implements business logic for file storage operations.
+// Stored objects are checked against the upload policy, scanned for malware, recorded in the file catalog,
+// which is kept in step with storage, and handed to the image pipeline.
+//
+// With deduplication, the content of uploads is stored once per tenant under its SHA-256,
+// content/<tenant>/<ab>/<sha256>, and the files with that content share the object. It is counted how many
+// files do, so the object is removed with the last of them.
 type StorageService struct {
 	repo          ports.StorageRepository
+	files         ports.FileRepository
+	media         ports.ProductMediaRepository
+	contents      ports.ContentObjectRepository
+	images        ports.ImageService
+	scanner       ports.FileScanner
 	defaultBucket string
+	limits        UploadLimits
+	policy        UploadPolicy
+	presignExpiry time.Duration
+	inUse         InUsePolicy
+	dedup         bool
+	logger        *slog.Logger
 }
 
-// NewStorageService creates a new storage service
-func NewStorageService(repo ports.StorageRepository, defaultBucket string) *StorageService {
+// StorageOptions configures a storage service
+type StorageOptions struct {
+	// DefaultBucket is where uploads go that do not name a bucket
+	DefaultBucket string
+	// Limits are the maximum upload sizes
+	Limits UploadLimits
+	// Policy decides which content may be stored
+	Policy UploadPolicy
+	// PresignExpiry is how long direct upload and download URLs are valid
+	PresignExpiry time.Duration
+	// InUse decides what happens when a file shown in product galleries is deleted; empty means InUseBlock
+	InUse InUsePolicy
+	// Dedup stores the content of uploads once per tenant
+	Dedup bool
+}
+
+// NewStorageService creates a new storage service. The scanner may be nil to store files unscanned. Shared
+// objects are released through contents even if deduplication is off, so it can be turned off without leaking
+// them. A nil logger logs with slog.Default.
+func NewStorageService(repo ports.StorageRepository, files ports.FileRepository, media ports.ProductMediaRepository, contents ports.ContentObjectRepository, images ports.ImageService, scanner ports.FileScanner, opts StorageOptions, logger *slog.Logger) *StorageService {
 	return &StorageService{
 		repo:          repo,
-		defaultBucket: defaultBucket,
+		files:         files,
+		media:         media,
+		contents:      contents,
+		images:        images,
+		scanner:       scanner,
+		defaultBucket: opts.DefaultBucket,
+		limits:        opts.Limits,
+		policy:        opts.Policy,
+		presignExpiry: opts.PresignExpiry,
+		inUse:         opts.InUse,
+		dedup:         opts.Dedup,
+		logger:        orDefaultLogger(logger),
 	}
 }
 
-// UploadFile uploads a file to the specified bucket with the given filename
-func (s *StorageService) UploadFile(ctx context.Context, bucket, fileName string, reader io.Reader, size int64, contentType string) (*entities.FileMetadata, error) {
-	// Use default bucket if not specified
-	if bucket == "" {
-		bucket = s.defaultBucket
+// UploadFile streams a file to storage. The content type is detected from the first bytes and checked
+// against the declared one and the upload policy, the size limit for the bucket and content type is
+// enforced while streaming, and the SHA-256 of the content is computed on the fly and verified against
+// the expected checksum, if any. The content is staged under uploads/<tenant>/ while it is checked and
+// scanned, and only replaces a file of the same name once it is recorded, so a rejected upload leaves the
+// file as it was. With deduplication, the staged content is moved to its content object instead.
+func (s *StorageService) UploadFile(ctx context.Context, upload *entities.FileUpload) (*entities.FileMetadata, error) {
+	bucket, err := s.resolveBucket(upload.Bucket)
+	if err != nil {
+		return nil, err
 	}
 
-	// Validate filename
-	if fileName == "" {
-		return nil, domainErrors.NewValidationError("file_name", "filename is required")
+	objectName, err := objectName(ctx, upload.FileName)
+	if err != nil {
+		return nil, err
+	}
+	staged, err := stagingName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Peek at the beginning of the content to reject empty files and detect the content type
+	content := bufio.NewReaderSize(upload.Content, sniffLen)
+	head, err := content.Peek(sniffLen)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if len(head) == 0 {
+		return nil, domainErrors.NewValidationError("file", "empty", "file is empty")
+	}
+
+	contentType, err := s.policy.Check(bucket, upload.ContentType, head)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reject uploads that declare a size above the limit before storing anything
+	maxSize := s.limits.MaxSize(bucket, contentType)
+	if maxSize > 0 && upload.Size > maxSize {
+		return nil, fileTooLargeError(maxSize)
 	}
 
 	// Ensure bucket exists
-	err := s.repo.EnsureBucket(ctx, bucket)
+	err = s.repo.EnsureBucket(ctx, bucket)
 	if err != nil {
 		return nil, err
 	}
 
-	// Store file using repository
-	metadata, err := s.repo.Store(ctx, bucket, fileName, reader, size, contentType)
+	// Hash and count the content as it is streamed to the repository
+	hash := sha256.New()
+	reader := &limitedReader{r: io.TeeReader(content, hash), max: maxSize}
+
+	metadata, err := s.repo.Store(ctx, bucket, staged, reader, upload.Size, contentType)
+	if reader.exceeded {
+		// The stream was cut off; drop whatever was stored
+		s.discard(ctx, bucket, staged, err)
+		return nil, fileTooLargeError(maxSize)
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	if upload.SHA256 != "" && !strings.EqualFold(upload.SHA256, checksum) {
+		s.discard(ctx, bucket, staged, nil)
+		return nil, domainErrors.NewValidationError("content_digest", "mismatch", "content digest does not match the uploaded file")
+	}
+
+	// Report the name the client knows the file by
+	metadata.FileName = upload.FileName
+	metadata.Bucket = bucket
+	metadata.Key = staged
+	metadata.SHA256 = checksum
+
+	err = s.scan(ctx, metadata)
+	if err != nil {
+		s.discard(ctx, bucket, staged, nil)
+		return nil, err
+	}
+
+	if s.dedup {
+		err = s.deduplicate(ctx, metadata)
+		if err != nil {
+			return nil, err
+		}
+		staged = ""
+	} else {
+		metadata.Key = objectName
+	}
+
+	err = s.record(ctx, metadata, staged)
+	if err != nil {
+		if staged != "" {
+			s.discard(ctx, bucket, staged, nil)
+		} else {
+			s.release(ctx, metadata.Bucket, metadata.Key)
+		}
+		return nil, err
+	}
+	s.process(ctx, metadata)
+
 	return metadata, nil
 }
 
 // DeleteFile deletes a file from the specified bucket
 func (s *StorageService) DeleteFile(ctx context.Context, bucket, fileName string) error {
-	// Use default bucket if not specified
-	if bucket == "" {
-		bucket = s.defaultBucket
+	bucket, err := s.resolveBucket(bucket)
+	if err != nil {
+		return err
 	}
 
-	// Delete file using repository
-	err := s.repo.Remove(ctx, bucket, fileName)
+	objectName, err := objectName(ctx, fileName)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	file, err := s.files.GetByName(ctx, bucket, fileName)
+	if errors.Is(err, domainErrors.ErrNotFound) {
+		// Objects stored before the catalog existed have no record
+		return s.repo.Remove(ctx, bucket, objectName)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.deleteRecorded(ctx, file)
+}
+
+// GetFile returns a file from the catalog
+func (s *StorageService) GetFile(ctx context.Context, id uuid.UUID) (*entities.FileMetadata, error) {
+	file, err := s.files.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.setURL(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// ListFiles returns a page of the file catalog, newest first
+func (s *StorageService) ListFiles(ctx context.Context, query *entities.FileQuery) (*entities.FileQueryResult, error) {
+	result, err := s.files.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range result.Files {
+		if err := s.setURL(ctx, file); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// DeleteFileByID deletes a file and its record
+func (s *StorageService) DeleteFileByID(ctx context.Context, id uuid.UUID) error {
+	file, err := s.files.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return s.deleteRecorded(ctx, file)
 }
 
 // GetFileURL generates a public URL for the file
 func (s *StorageService) GetFileURL(ctx context.Context, bucket, fileName string) (string, error) {
-	// Use default bucket if not specified
-	if bucket == "" {
-		bucket = s.defaultBucket
+	bucket, err := s.resolveBucket(bucket)
+	if err != nil {
+		return "", err
+	}
+
+	// Validate the name before handing out a URL for it
+	if _, err := objectName(ctx, fileName); err != nil {
+		return "", err
 	}
 
 	// Get URL from repository
@@ -82,23 +316,718 @@ func (s *StorageService) GetFileURL(ctx context.Context, bucket, fileName string
 	return url, nil
 }
 
-// DownloadFile retrieves a file from storage
-func (s *StorageService) DownloadFile(ctx context.Context, bucket, fileName string) (io.ReadCloser, int64, string, error) {
-	// Use default bucket if not specified
-	if bucket == "" {
-		bucket = s.defaultBucket
+// DownloadFile retrieves a file, or a range of it, from storage. The conditions of the options are checked
+// against the file's record first, so the content of a file the client already has is not fetched.
+func (s *StorageService) DownloadFile(ctx context.Context, bucket, fileName string, opts entities.DownloadOptions) (*entities.FileDownload, error) {
+	bucket, err := s.resolveBucket(bucket)
+	if err != nil {
+		return nil, err
 	}
 
 	// Validate filename
 	if fileName == "" {
-		return nil, 0, "", domainErrors.NewValidationError("file_name", "filename is required")
+		return nil, domainErrors.NewValidationError("file_name", "required", "filename is required")
+	}
+
+	objectName, err := objectName(ctx, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := s.lookup(ctx, bucket, fileName, objectName)
+	if err != nil {
+		return nil, err
+	}
+
+	download := &entities.FileDownload{
+		File:         file,
+		ETag:         fileETag(file),
+		LastModified: fileModified(file),
+	}
+	if notModified(opts, download) {
+		download.NotModified = true
+		return download, nil
+	}
+
+	if opts.Range != nil {
+		rng, ok := opts.Range.Resolve(file.Size)
+		if !ok {
+			return nil, domainErrors.NewRangeNotSatisfiableError(file.Size)
+		}
+		download.Range = &rng
 	}
 
 	// Get file from repository
-	reader, size, contentType, err := s.repo.GetFile(ctx, bucket, fileName)
+	reader, size, _, err := s.repo.GetFile(ctx, bucket, file.Key, download.Range)
+	if err != nil {
+		return nil, err
+	}
+	download.Content = reader
+	download.Size = size
+
+	return download, nil
+}
+
+// CreateUploadSlot presigns a direct upload to storage. The client declares the content type, size and
+// optionally the checksum up front; they are checked against the upload limits and signed into the URL.
+// The upload is staged under uploads/<tenant>/ until it is confirmed.
+func (s *StorageService) CreateUploadSlot(ctx context.Context, slot *entities.UploadSlot) error {
+	bucket, err := s.resolveBucket(slot.Bucket)
+	if err != nil {
+		return err
+	}
+	slot.Bucket = bucket
+
+	staged, err := directStagingName(ctx, slot.FileName)
+	if err != nil {
+		return err
+	}
+
+	validationErr := &domainErrors.ValidationError{}
+	if slot.ContentType == "" {
+		validationErr.Add("content_type", "required", "content type is required")
+	} else if !s.policy.Allows(slot.Bucket, slot.ContentType) {
+		validationErr.Add("content_type", "not_allowed", fmt.Sprintf("%s files are not allowed in bucket %s", mediaType(slot.ContentType), slot.Bucket))
+	}
+	if slot.Size <= 0 {
+		validationErr.Add("size", "must_be_positive", "size must be greater than 0")
+	} else if maxSize := s.limits.MaxSize(slot.Bucket, slot.ContentType); maxSize > 0 && slot.Size > maxSize {
+		validationErr.Add("size", "too_large", fmt.Sprintf("file exceeds the maximum size of %d bytes", maxSize))
+	}
+	if slot.SHA256 != "" && !isSHA256(slot.SHA256) {
+		validationErr.Add("sha256", "invalid", "sha256 must be a hex-encoded SHA-256 checksum")
+	}
+	if err := validationErr.ErrOrNil(); err != nil {
+		return err
+	}
+
+	err = s.repo.EnsureBucket(ctx, slot.Bucket)
+	if err != nil {
+		return err
+	}
+
+	upload, err := s.repo.PresignUpload(ctx, slot.Bucket, staged, slot.ContentType, slot.Size, strings.ToLower(slot.SHA256), s.presignExpiry)
+	if err != nil {
+		return err
+	}
+
+	slot.Upload = upload
+	return nil
+}
+
+// ConfirmUpload verifies that a direct upload to storage completed and returns the metadata of the stored file.
+// The content is checked like that of uploads through the API; files above the upload limits, whose content
+// is not of the declared type or not allowed, or that the scanner flags are removed. The staged upload only
+// replaces a file of the same name once it is recorded. With deduplication, files whose checksum storage
+// reports are moved to their content object.
+func (s *StorageService) ConfirmUpload(ctx context.Context, bucket, fileName string) (*entities.FileMetadata, error) {
+	bucket, err := s.resolveBucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	objectName, err := objectName(ctx, fileName)
+	if err != nil {
+		return nil, err
+	}
+	staged, err := directStagingName(ctx, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := s.repo.Stat(ctx, bucket, staged)
+	if err != nil {
+		return nil, err
+	}
+
+	// The signed slot already constrains the size; this guards against limits lowered since
+	if maxSize := s.limits.MaxSize(bucket, metadata.ContentType); maxSize > 0 && metadata.Size > maxSize {
+		s.discard(ctx, bucket, staged, nil)
+		return nil, fileTooLargeError(maxSize)
+	}
+
+	// Report the name the client knows the file by
+	metadata.FileName = fileName
+	metadata.Bucket = bucket
+	metadata.Key = staged
+
+	err = s.checkStored(ctx, metadata)
+	if err != nil {
+		s.discard(ctx, bucket, staged, nil)
+		return nil, err
+	}
+
+	err = s.scan(ctx, metadata)
+	if err != nil {
+		s.discard(ctx, bucket, staged, nil)
+		return nil, err
+	}
+
+	// Storage only knows the checksum of uploads that were signed with one
+	if s.dedup && metadata.SHA256 != "" {
+		err = s.deduplicate(ctx, metadata)
+		if err != nil {
+			return nil, err
+		}
+		staged = ""
+	} else {
+		metadata.Key = objectName
+	}
+
+	err = s.record(ctx, metadata, staged)
+	if err != nil {
+		if staged != "" {
+			s.discard(ctx, bucket, staged, nil)
+		} else {
+			s.release(ctx, metadata.Bucket, metadata.Key)
+		}
+		return nil, err
+	}
+	s.process(ctx, metadata)
+
+	return metadata, nil
+}
+
+// ComposeFile stores a file made of parts already in storage, such as the chunks of a resumable upload; the
+// content of the upload is not read. Storage concatenates the parts under uploads/<tenant>/, on the server
+// where it can, and the result is checked like a direct upload before it replaces a file of the same name.
+// Storage cannot tell the checksum of the result, so it is computed only to verify the expected one or to
+// deduplicate the content. The parts are left to the caller.
+func (s *StorageService) ComposeFile(ctx context.Context, upload *entities.FileUpload, parts []entities.UploadPart) (*entities.FileMetadata, error) {
+	bucket, err := s.resolveBucket(upload.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	objectName, err := objectName(ctx, upload.FileName)
+	if err != nil {
+		return nil, err
+	}
+	staged, err := stagingName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := s.repo.Compose(ctx, bucket, staged, parts, upload.ContentType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Report the name the client knows the file by
+	metadata.FileName = upload.FileName
+	metadata.Bucket = bucket
+	metadata.Key = staged
+
+	err = s.checkStored(ctx, metadata)
+	if err != nil {
+		s.discard(ctx, bucket, staged, nil)
+		return nil, err
+	}
+
+	if maxSize := s.limits.MaxSize(bucket, metadata.ContentType); maxSize > 0 && metadata.Size > maxSize {
+		s.discard(ctx, bucket, staged, nil)
+		return nil, fileTooLargeError(maxSize)
+	}
+
+	if upload.SHA256 != "" || s.dedup {
+		metadata.SHA256, err = s.checksum(ctx, metadata)
+		if err != nil {
+			s.discard(ctx, bucket, staged, nil)
+			return nil, err
+		}
+	}
+	if upload.SHA256 != "" && !strings.EqualFold(upload.SHA256, metadata.SHA256) {
+		s.discard(ctx, bucket, staged, nil)
+		return nil, domainErrors.NewValidationError("content_digest", "mismatch", "content digest does not match the uploaded file")
+	}
+
+	err = s.scan(ctx, metadata)
+	if err != nil {
+		s.discard(ctx, bucket, staged, nil)
+		return nil, err
+	}
+
+	if s.dedup {
+		err = s.deduplicate(ctx, metadata)
+		if err != nil {
+			return nil, err
+		}
+		staged = ""
+	} else {
+		metadata.Key = objectName
+	}
+
+	err = s.record(ctx, metadata, staged)
 	if err != nil {
-		return nil, 0, "", err
+		if staged != "" {
+			s.discard(ctx, bucket, staged, nil)
+		} else {
+			s.release(ctx, metadata.Bucket, metadata.Key)
+		}
+		return nil, err
+	}
+	s.process(ctx, metadata)
+
+	return metadata, nil
+}
+
+// PresignDownload presigns a direct download of a file from storage
+func (s *StorageService) PresignDownload(ctx context.Context, bucket, fileName string) (*entities.PresignedRequest, error) {
+	bucket, err := s.resolveBucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	objectName, err := objectName(ctx, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Do not hand out URLs for files that do not exist
+	file, err := s.lookup(ctx, bucket, fileName, objectName)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.PresignDownload(ctx, bucket, file.Key, s.presignExpiry)
+}
+
+// lookup returns the record of a file, or the metadata of its object if it has none
+func (s *StorageService) lookup(ctx context.Context, bucket, fileName, objectName string) (*entities.FileMetadata, error) {
+	file, err := s.files.GetByName(ctx, bucket, fileName)
+	if errors.Is(err, domainErrors.ErrNotFound) {
+		// Objects stored before the catalog existed have no record
+		return s.repo.Stat(ctx, bucket, objectName)
+	}
+	return file, err
+}
+
+// record adds a stored object to the catalog, or updates the record if the object replaced another file of
+// the same name, and attributes it to the authenticated user. Content staged elsewhere is moved to the key
+// of the record once it is written, and the record rolled back if it cannot be; the caller removes the staged
+// object if record fails. The replaced file's object is removed if the new content is stored elsewhere,
+// and its reference dropped if it is a content object.
+func (s *StorageService) record(ctx context.Context, metadata *entities.FileMetadata, staged string) error {
+	if principal, ok := entities.PrincipalFromContext(ctx); ok {
+		metadata.UploaderID = &principal.UserID
+	}
+
+	existing, err := s.files.GetByName(ctx, metadata.Bucket, metadata.FileName)
+	if errors.Is(err, domainErrors.ErrNotFound) {
+		err = s.files.Create(ctx, metadata)
+	} else if err == nil {
+		metadata.ID = existing.ID
+		metadata.UploadedAt = existing.UploadedAt
+		err = s.files.Update(ctx, metadata)
+	}
+	if err != nil {
+		return err
+	}
+
+	if staged != "" {
+		if err := s.repo.Copy(ctx, metadata.Bucket, staged, metadata.Key); err != nil {
+			s.unrecord(ctx, metadata, existing)
+			return err
+		}
+		s.discard(ctx, metadata.Bucket, staged, nil)
+	}
+
+	if existing != nil && (existing.Key != metadata.Key || isContentKey(existing.Key)) {
+		if err := s.removeObject(ctx, existing.Bucket, existing.Key); err != nil {
+			// The collector removes the object once it is unreferenced
+			s.logger.WarnContext(ctx, "failed to remove replaced object", "bucket", existing.Bucket, "key", existing.Key, "error", err)
+		}
+	}
+
+	return s.setURL(ctx, metadata)
+}
+
+// unrecord rolls back the record of a file whose content could not be stored: the replaced record is
+// restored, or the new one deleted
+func (s *StorageService) unrecord(ctx context.Context, metadata, existing *entities.FileMetadata) {
+	var err error
+	if existing != nil {
+		err = s.files.Update(ctx, existing)
+	} else {
+		err = s.files.Delete(ctx, metadata.ID)
+	}
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to roll back record of file that could not be stored", "file_id", metadata.ID, "error", err)
+	}
+}
+
+// deduplicate moves the content of a stored file to its content object, copying it there unless the tenant
+// stored the same content before, and counts the file as a reference to the object
+func (s *StorageService) deduplicate(ctx context.Context, file *entities.FileMetadata) error {
+	key, err := contentKey(ctx, file.SHA256)
+	if err != nil {
+		return err
+	}
+
+	// Once acquired, the object is not removed until the reference is released
+	err = s.contents.Acquire(ctx, file.Bucket, key)
+	if err != nil {
+		s.discard(ctx, file.Bucket, file.Key, nil)
+		return err
+	}
+
+	_, err = s.repo.Stat(ctx, file.Bucket, key)
+	if errors.Is(err, domainErrors.ErrNotFound) {
+		err = s.repo.Copy(ctx, file.Bucket, file.Key, key)
+	}
+	if err != nil {
+		s.release(ctx, file.Bucket, key)
+		s.discard(ctx, file.Bucket, file.Key, nil)
+		return err
+	}
+
+	s.discard(ctx, file.Bucket, file.Key, nil)
+	file.Key = key
+	return nil
+}
+
+// deleteRecorded deletes the record of a file and then its object. If the object cannot be removed,
+// the record is restored, so the catalog never lists files that are gone or loses track of stored ones.
+func (s *StorageService) deleteRecorded(ctx context.Context, file *entities.FileMetadata) error {
+	err := s.releaseMedia(ctx, file)
+	if err != nil {
+		return err
+	}
+
+	err = s.files.Delete(ctx, file.ID)
+	if err != nil {
+		return err
+	}
+
+	err = s.removeObject(ctx, file.Bucket, file.Key)
+	if err != nil {
+		if restoreErr := s.files.Create(ctx, file); restoreErr != nil {
+			s.logger.ErrorContext(ctx, "failed to restore record of file after failed removal", "file_id", file.ID, "error", restoreErr)
+		}
+		return err
+	}
+
+	if err := s.images.RemoveVariants(ctx, file); err != nil {
+		s.logger.WarnContext(ctx, "failed to remove variants of deleted file", "file_id", file.ID, "error", err)
+	}
+
+	return nil
+}
+
+// process hands a recorded file to the image pipeline. The upload does not fail if it cannot be processed,
+// as its transforms are still rendered on demand.
+func (s *StorageService) process(ctx context.Context, file *entities.FileMetadata) {
+	if err := s.images.Process(ctx, file); err != nil {
+		s.logger.WarnContext(ctx, "failed to process file", "file_id", file.ID, "error", err)
+	}
+}
+
+// releaseMedia applies the in-use policy to a file that is about to be deleted
+func (s *StorageService) releaseMedia(ctx context.Context, file *entities.FileMetadata) error {
+	count, err := s.media.CountByFile(ctx, file.ID)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+
+	if s.inUse == InUseCascade {
+		return s.media.DeleteByFile(ctx, file.ID)
+	}
+	return domainErrors.NewValidationError("id", "in_use", fmt.Sprintf("File is used by %d product media item(s); detach it first", count))
+}
+
+// setURL sets the URL clients access a recorded file by
+func (s *StorageService) setURL(ctx context.Context, file *entities.FileMetadata) error {
+	url, err := s.repo.GetURL(ctx, file.Bucket, file.FileName)
+	if err != nil {
+		return err
+	}
+	file.URL = url
+	return nil
+}
+
+// resolveBucket returns the bucket a request names, or the default bucket if it names none. The quarantine
+// bucket cannot be named, so that flagged files are neither served nor replaced.
+func (s *StorageService) resolveBucket(bucket string) (string, error) {
+	if bucket == "" {
+		return s.defaultBucket, nil
+	}
+	if bucket == s.policy.QuarantineBucket {
+		return "", domainErrors.NewValidationError("bucket", "reserved", "bucket is reserved for quarantined files")
+	}
+	return bucket, nil
+}
+
+// checkStored applies the upload policy to a file stored without passing through the service, detecting
+// its type from its first bytes in storage
+func (s *StorageService) checkStored(ctx context.Context, file *entities.FileMetadata) error {
+	if file.Size == 0 {
+		return domainErrors.NewValidationError("file", "empty", "file is empty")
+	}
+
+	reader, _, _, err := s.repo.GetFile(ctx, file.Bucket, file.Key, &entities.ByteRange{Start: 0, End: min(file.Size, sniffLen) - 1})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	head, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	contentType, err := s.policy.Check(file.Bucket, file.ContentType, head)
+	if err != nil {
+		return err
+	}
+	file.ContentType = contentType
+	return nil
+}
+
+// checksum computes the SHA-256 of a stored file
+func (s *StorageService) checksum(ctx context.Context, file *entities.FileMetadata) (string, error) {
+	reader, _, _, err := s.repo.GetFile(ctx, file.Bucket, file.Key, nil)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// scan runs the scanner over a stored file that is not recorded yet. Flagged files are quarantined and
+// rejected; files that cannot be scanned are rejected, so that no unscanned file is served. The caller
+// removes the object of a rejected file.
+func (s *StorageService) scan(ctx context.Context, file *entities.FileMetadata) error {
+	if s.scanner == nil {
+		return nil
+	}
+
+	reader, _, _, err := s.repo.GetFile(ctx, file.Bucket, file.Key, nil)
+	if err != nil {
+		return err
+	}
+	result, err := s.scanner.Scan(ctx, reader)
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("failed to scan file: %w", err)
+	}
+	if !result.Infected {
+		return nil
 	}
 
-	return reader, size, contentType, nil
+	s.logger.WarnContext(ctx, "file was flagged by the scanner", "bucket", file.Bucket, "key", file.Key, "threat", result.Threat)
+	if err := s.quarantine(ctx, file); err != nil {
+		s.logger.ErrorContext(ctx, "failed to quarantine flagged file", "bucket", file.Bucket, "key", file.Key, "error", err)
+	}
+	return domainErrors.NewValidationError("file", "infected", fmt.Sprintf("file was flagged by the malware scanner as %s", result.Threat))
+}
+
+// quarantine copies a flagged file to the quarantine bucket, under the time it was flagged, its bucket and
+// the object name of the file, e.g. 20240501T120000Z/media/tenants/acme/invoice.pdf, so files flagged
+// repeatedly are all kept
+func (s *StorageService) quarantine(ctx context.Context, file *entities.FileMetadata) error {
+	if s.policy.QuarantineBucket == "" {
+		return nil
+	}
+
+	// Staged files are quarantined under the name they were uploaded as
+	key, err := objectName(ctx, file.FileName)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.EnsureBucket(ctx, s.policy.QuarantineBucket)
+	if err != nil {
+		return err
+	}
+
+	reader, size, contentType, err := s.repo.GetFile(ctx, file.Bucket, file.Key, nil)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	name := time.Now().UTC().Format("20060102T150405Z") + "/" + file.Bucket + "/" + key
+	_, err = s.repo.Store(ctx, s.policy.QuarantineBucket, name, reader, size, contentType)
+	return err
+}
+
+// removeObject removes the object of a file that is no longer recorded. Content objects are only removed
+// with their last reference.
+func (s *StorageService) removeObject(ctx context.Context, bucket, key string) error {
+	if !isContentKey(key) {
+		return s.repo.Remove(ctx, bucket, key)
+	}
+	return s.contents.Release(ctx, bucket, key, func() error {
+		return s.repo.Remove(ctx, bucket, key)
+	})
+}
+
+// release removes the object of a file that could not be recorded
+func (s *StorageService) release(ctx context.Context, bucket, key string) {
+	if err := s.removeObject(ctx, bucket, key); err != nil {
+		s.logger.WarnContext(ctx, "failed to remove rejected upload", "bucket", bucket, "key", key, "error", err)
+	}
+}
+
+// discard removes an object whose upload was rejected; storeErr is the error of the upload, if any
+func (s *StorageService) discard(ctx context.Context, bucket, objectName string, storeErr error) {
+	if storeErr != nil {
+		// Nothing was stored
+		return
+	}
+	if err := s.repo.Remove(ctx, bucket, objectName); err != nil {
+		s.logger.WarnContext(ctx, "failed to remove rejected upload", "bucket", bucket, "key", objectName, "error", err)
+	}
+}
+
+// isSHA256 reports whether s is a hex-encoded SHA-256 checksum
+func isSHA256(s string) bool {
+	sum, err := hex.DecodeString(s)
+	return err == nil && len(sum) == sha256.Size
+}
+
+// fileTooLargeError reports an upload above the size limit
+func fileTooLargeError(maxSize int64) error {
+	return domainErrors.NewValidationError("file", "too_large", fmt.Sprintf("file exceeds the maximum size of %d bytes", maxSize))
+}
+
+// errUploadTooLarge aborts the stream of an upload above its size limit
+var errUploadTooLarge = errors.New("upload exceeds the maximum size")
+
+// limitedReader fails once more than max bytes (if max > 0) have been read
+type limitedReader struct {
+	r        io.Reader
+	max      int64
+	n        int64
+	exceeded bool
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n += int64(n)
+	if l.max > 0 && l.n > l.max {
+		l.exceeded = true
+		return n, errUploadTooLarge
+	}
+	return n, err
+}
+
+// objectName namespaces a filename under the tenant of the request (tenants/<tenant>/<filename>)
+func objectName(ctx context.Context, fileName string) (string, error) {
+	tenantID, ok := entities.TenantFromContext(ctx)
+	if !ok {
+		return "", domainErrors.ErrTenantRequired
+	}
+
+	if fileName == "" {
+		return "", domainErrors.NewValidationError("file_name", "required", "filename is required")
+	}
+
+	// Reject names that could escape the tenant prefix
+	if strings.HasPrefix(fileName, "/") {
+		return "", domainErrors.NewValidationError("file_name", "not_relative", "filename must be relative")
+	}
+	for _, segment := range strings.Split(fileName, "/") {
+		if segment == ".." || segment == "." || segment == "" {
+			return "", domainErrors.NewValidationError("file_name", "invalid_segment", "filename must not contain empty, '.' or '..' segments")
+		}
+	}
+
+	// Backslashes separate paths on some systems, and control characters end up in headers and logs
+	if !utf8.ValidString(fileName) || strings.ContainsFunc(fileName, func(r rune) bool { return r == '\\' || unicode.IsControl(r) }) {
+		return "", domainErrors.NewValidationError("file_name", "invalid_character", "filename must be UTF-8 without backslashes or control characters")
+	}
+
+	prefix := "tenants/" + tenantID + "/"
+	if len(prefix)+len(fileName) > maxObjectNameLen {
+		return "", domainErrors.NewValidationError("file_name", "too_long", fmt.Sprintf("filename must be at most %d bytes", maxObjectNameLen-len(prefix)))
+	}
+	return prefix + fileName, nil
+}
+
+// stagingName returns a unique object name under uploads/<tenant>/ to store an upload at until it is
+// checked and recorded
+func stagingName(ctx context.Context) (string, error) {
+	tenantID, ok := entities.TenantFromContext(ctx)
+	if !ok {
+		return "", domainErrors.ErrTenantRequired
+	}
+	return "uploads/" + tenantID + "/" + uuid.NewString(), nil
+}
+
+// directStagingName returns the object name under uploads/<tenant>/ a direct upload of a file is staged at
+// until it is confirmed. It is derived from the file name, which is validated first, so the confirmation
+// finds it; hashing the name keeps it within the length limit.
+func directStagingName(ctx context.Context, fileName string) (string, error) {
+	if _, err := objectName(ctx, fileName); err != nil {
+		return "", err
+	}
+	tenantID, _ := entities.TenantFromContext(ctx)
+	sum := sha256.Sum256([]byte(fileName))
+	return "uploads/" + tenantID + "/direct-" + hex.EncodeToString(sum[:]), nil
+}
+
+// contentKey returns the name of the content object of a checksum, content/<tenant>/<ab>/<checksum>; the
+// first two digits spread the objects over directories on backends that have them
+func contentKey(ctx context.Context, checksum string) (string, error) {
+	tenantID, ok := entities.TenantFromContext(ctx)
+	if !ok {
+		return "", domainErrors.ErrTenantRequired
+	}
+	checksum = strings.ToLower(checksum)
+	return "content/" + tenantID + "/" + checksum[:2] + "/" + checksum, nil
+}
+
+// isContentKey reports whether an object is a content object that files may share
+func isContentKey(key string) bool {
+	return strings.HasPrefix(key, "content/")
+}
+
+// fileETag returns the entity tag of a file's content: its checksum, or a weak tag derived from its size
+// and modification time for files stored without one
+func fileETag(file *entities.FileMetadata) string {
+	if file.SHA256 != "" {
+		return `"` + file.SHA256 + `"`
+	}
+	return fmt.Sprintf(`W/"%x-%x"`, file.Size, fileModified(file).Unix())
+}
+
+// fileModified returns when a file's content was last stored
+func fileModified(file *entities.FileMetadata) time.Time {
+	if file.UpdatedAt.IsZero() {
+		return file.UploadedAt
+	}
+	return file.UpdatedAt
+}
+
+// notModified evaluates If-None-Match or, without it, If-Modified-Since (RFC 9110, section 13.2.2)
+func notModified(opts entities.DownloadOptions, download *entities.FileDownload) bool {
+	if len(opts.IfNoneMatch) > 0 {
+		for _, tag := range opts.IfNoneMatch {
+			// Weak comparison: W/"x" matches "x"
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(download.ETag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	// HTTP dates have a resolution of one second
+	return !opts.IfModifiedSince.IsZero() && !download.LastModified.Truncate(time.Second).After(opts.IfModifiedSince)
+}
+
+// orDefaultLogger returns logger, or slog.Default if it is nil
+func orDefaultLogger(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.Default()
+	}
+	return logger
 }
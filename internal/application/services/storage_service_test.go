@@ -0,0 +1,1291 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/go-yippi/internal/adapters/memory"
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockStorageRepository is a mock implementation of ports.StorageRepository
+type MockStorageRepository struct {
+	mock.Mock
+}
+
+func (m *MockStorageRepository) Store(ctx context.Context, bucket, fileName string, reader io.Reader, size int64, contentType string) (*entities.FileMetadata, error) {
+	args := m.Called(ctx, bucket, fileName, reader, size, contentType)
+	if fn, ok := args.Get(0).(func(context.Context, string, string, io.Reader, int64, string) (*entities.FileMetadata, error)); ok {
+		return fn(ctx, bucket, fileName, reader, size, contentType)
+	}
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.FileMetadata), args.Error(1)
+}
+
+func (m *MockStorageRepository) Remove(ctx context.Context, bucket, fileName string) error {
+	args := m.Called(ctx, bucket, fileName)
+	return args.Error(0)
+}
+
+func (m *MockStorageRepository) RemovePrefix(ctx context.Context, bucket, prefix string) error {
+	args := m.Called(ctx, bucket, prefix)
+	return args.Error(0)
+}
+
+func (m *MockStorageRepository) Copy(ctx context.Context, bucket, src, dst string) error {
+	args := m.Called(ctx, bucket, src, dst)
+	return args.Error(0)
+}
+
+func (m *MockStorageRepository) Compose(ctx context.Context, bucket, fileName string, parts []entities.UploadPart, contentType string) (*entities.FileMetadata, error) {
+	args := m.Called(ctx, bucket, fileName, parts, contentType)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.FileMetadata), args.Error(1)
+}
+
+// List calls fn with the files the mock returns
+func (m *MockStorageRepository) List(ctx context.Context, bucket, prefix string, fn func(*entities.FileMetadata) error) error {
+	args := m.Called(ctx, bucket, prefix)
+	files, _ := args.Get(0).([]*entities.FileMetadata)
+	for _, file := range files {
+		if err := fn(file); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockStorageRepository) GetURL(ctx context.Context, bucket, fileName string) (string, error) {
+	args := m.Called(ctx, bucket, fileName)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockStorageRepository) GetFile(ctx context.Context, bucket, fileName string, rng *entities.ByteRange) (io.ReadCloser, int64, string, error) {
+	args := m.Called(ctx, bucket, fileName, rng)
+	if fn, ok := args.Get(0).(func(context.Context, string, string, *entities.ByteRange) (io.ReadCloser, int64, string, error)); ok {
+		return fn(ctx, bucket, fileName, rng)
+	}
+	if args.Get(0) == nil {
+		return nil, 0, "", args.Error(3)
+	}
+	return args.Get(0).(io.ReadCloser), args.Get(1).(int64), args.String(2), args.Error(3)
+}
+
+func (m *MockStorageRepository) EnsureBucket(ctx context.Context, bucket string) error {
+	args := m.Called(ctx, bucket)
+	return args.Error(0)
+}
+
+func (m *MockStorageRepository) Stat(ctx context.Context, bucket, fileName string) (*entities.FileMetadata, error) {
+	args := m.Called(ctx, bucket, fileName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.FileMetadata), args.Error(1)
+}
+
+func (m *MockStorageRepository) PresignUpload(ctx context.Context, bucket, fileName, contentType string, size int64, sha256 string, expiry time.Duration) (*entities.PresignedRequest, error) {
+	args := m.Called(ctx, bucket, fileName, contentType, size, sha256, expiry)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.PresignedRequest), args.Error(1)
+}
+
+func (m *MockStorageRepository) PresignDownload(ctx context.Context, bucket, fileName string, expiry time.Duration) (*entities.PresignedRequest, error) {
+	args := m.Called(ctx, bucket, fileName, expiry)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.PresignedRequest), args.Error(1)
+}
+
+// MockProductMediaRepository is a mock implementation of ports.ProductMediaRepository
+type MockProductMediaRepository struct {
+	mock.Mock
+}
+
+func (m *MockProductMediaRepository) Create(ctx context.Context, media *entities.ProductMedia) error {
+	args := m.Called(ctx, media)
+	return args.Error(0)
+}
+
+func (m *MockProductMediaRepository) CreateBulk(ctx context.Context, media []*entities.ProductMedia) error {
+	args := m.Called(ctx, media)
+	return args.Error(0)
+}
+
+func (m *MockProductMediaRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.ProductMedia, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.ProductMedia), args.Error(1)
+}
+
+func (m *MockProductMediaRepository) ListByProduct(ctx context.Context, productID int) ([]*entities.ProductMedia, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.ProductMedia), args.Error(1)
+}
+
+func (m *MockProductMediaRepository) ListByProducts(ctx context.Context, productIDs []int) ([]*entities.ProductMedia, error) {
+	args := m.Called(ctx, productIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.ProductMedia), args.Error(1)
+}
+
+func (m *MockProductMediaRepository) Update(ctx context.Context, media *entities.ProductMedia) error {
+	args := m.Called(ctx, media)
+	return args.Error(0)
+}
+
+func (m *MockProductMediaRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockProductMediaRepository) CountByFile(ctx context.Context, fileID uuid.UUID) (int, error) {
+	args := m.Called(ctx, fileID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockProductMediaRepository) DeleteByFile(ctx context.Context, fileID uuid.UUID) error {
+	args := m.Called(ctx, fileID)
+	return args.Error(0)
+}
+
+// MockFileRepository is a mock implementation of ports.FileRepository
+type MockFileRepository struct {
+	mock.Mock
+}
+
+func (m *MockFileRepository) Create(ctx context.Context, file *entities.FileMetadata) error {
+	args := m.Called(ctx, file)
+	return args.Error(0)
+}
+
+func (m *MockFileRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.FileMetadata, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.FileMetadata), args.Error(1)
+}
+
+func (m *MockFileRepository) GetByName(ctx context.Context, bucket, fileName string) (*entities.FileMetadata, error) {
+	args := m.Called(ctx, bucket, fileName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.FileMetadata), args.Error(1)
+}
+
+func (m *MockFileRepository) ListByKey(ctx context.Context, bucket, key string) ([]*entities.FileMetadata, error) {
+	args := m.Called(ctx, bucket, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*entities.FileMetadata), args.Error(1)
+}
+
+func (m *MockFileRepository) Update(ctx context.Context, file *entities.FileMetadata) error {
+	args := m.Called(ctx, file)
+	return args.Error(0)
+}
+
+func (m *MockFileRepository) UpdateImage(ctx context.Context, file *entities.FileMetadata) error {
+	args := m.Called(ctx, file)
+	return args.Error(0)
+}
+
+func (m *MockFileRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockFileRepository) Query(ctx context.Context, query *entities.FileQuery) (*entities.FileQueryResult, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.FileQueryResult), args.Error(1)
+}
+
+// MockContentObjectRepository is a mock implementation of ports.ContentObjectRepository
+type MockContentObjectRepository struct {
+	mock.Mock
+}
+
+func (m *MockContentObjectRepository) Acquire(ctx context.Context, bucket, key string) error {
+	args := m.Called(ctx, bucket, key)
+	return args.Error(0)
+}
+
+// Release calls remove if the mock is told the last reference is released
+func (m *MockContentObjectRepository) Release(ctx context.Context, bucket, key string, remove func() error) error {
+	args := m.Called(ctx, bucket, key)
+	if args.Bool(0) {
+		if err := remove(); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+// Delete calls remove if the mock is told the object is idle
+func (m *MockContentObjectRepository) Delete(ctx context.Context, bucket, key string, idleSince time.Time, remove func() error) (bool, error) {
+	args := m.Called(ctx, bucket, key, idleSince)
+	if args.Bool(0) {
+		if err := remove(); err != nil {
+			return false, err
+		}
+	}
+	return args.Bool(0), args.Error(1)
+}
+
+// MockFileScanner is a mock implementation of ports.FileScanner
+type MockFileScanner struct {
+	mock.Mock
+}
+
+func (m *MockFileScanner) Scan(ctx context.Context, content io.Reader) (*entities.ScanResult, error) {
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, err
+	}
+	args := m.Called(ctx, string(data))
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entities.ScanResult), args.Error(1)
+}
+
+// MockImageService is a mock implementation of ports.ImageService
+type MockImageService struct {
+	mock.Mock
+}
+
+func (m *MockImageService) Process(ctx context.Context, file *entities.FileMetadata) error {
+	args := m.Called(ctx, file)
+	return args.Error(0)
+}
+
+func (m *MockImageService) Transform(ctx context.Context, id uuid.UUID, transform entities.ImageTransform) (io.ReadCloser, int64, string, error) {
+	args := m.Called(ctx, id, transform)
+	if args.Get(0) == nil {
+		return nil, 0, "", args.Error(3)
+	}
+	return args.Get(0).(io.ReadCloser), args.Get(1).(int64), args.String(2), args.Error(3)
+}
+
+func (m *MockImageService) RemoveVariants(ctx context.Context, file *entities.FileMetadata) error {
+	args := m.Called(ctx, file)
+	return args.Error(0)
+}
+
+// newMockImages returns an image service that accepts every file
+func newMockImages() *MockImageService {
+	images := new(MockImageService)
+	images.On("Process", mock.Anything, mock.Anything).Return(nil).Maybe()
+	images.On("RemoveVariants", mock.Anything, mock.Anything).Return(nil).Maybe()
+	return images
+}
+
+// recordAsNew makes the catalog accept every stored file as a new record
+func recordAsNew(mockRepo *MockStorageRepository, mockFiles *MockFileRepository) {
+	mockFiles.On("GetByName", mock.Anything, mock.Anything, mock.Anything).Return(nil, domainErrors.NewNotFoundError("File", "key"))
+	mockFiles.On("Create", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("GetURL", mock.Anything, mock.Anything, mock.Anything).Return("/files/download", nil)
+}
+
+// storeReadingAll makes Store consume the content like a real backend and report its size; objectName is a
+// name or an argument matcher
+func storeReadingAll(mockRepo *MockStorageRepository, bucket string, objectName any, contentType string) *mock.Call {
+	return mockRepo.On("Store", mock.Anything, bucket, objectName, mock.Anything, mock.Anything, contentType).
+		Return(func(ctx context.Context, bucket, objectName string, reader io.Reader, size int64, contentType string) (*entities.FileMetadata, error) {
+			n, err := io.Copy(io.Discard, reader)
+			if err != nil {
+				return nil, err
+			}
+			return &entities.FileMetadata{FileName: objectName, Bucket: bucket, Size: n, ContentType: contentType}, nil
+		}, nil)
+}
+
+// isStaging matches the staging names of uploads of tenant acme
+var isStaging = mock.MatchedBy(func(name string) bool { return strings.HasPrefix(name, "uploads/acme/") })
+
+// promoteStaged expects a staged upload to be moved to key once it is recorded
+func promoteStaged(mockRepo *MockStorageRepository, bucket, key string) {
+	mockRepo.On("Copy", mock.Anything, bucket, isStaging, key).Return(nil)
+	mockRepo.On("Remove", mock.Anything, bucket, isStaging).Return(nil)
+}
+
+// TestUploadFile_NamespacedByTenant tests that uploads are stored under the tenant prefix
+func TestUploadFile_NamespacedByTenant(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	mockImages := new(MockImageService)
+	service := NewStorageService(mockRepo, mockFiles, new(MockProductMediaRepository), new(MockContentObjectRepository), mockImages, nil, StorageOptions{DefaultBucket: "default-bucket", PresignExpiry: time.Minute}, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	mockRepo.On("EnsureBucket", ctx, "default-bucket").Return(nil)
+	storeReadingAll(mockRepo, "default-bucket", isStaging, "image/png")
+	promoteStaged(mockRepo, "default-bucket", "tenants/acme/images/logo.png")
+
+	recordAsNew(mockRepo, mockFiles)
+	mockImages.On("Process", ctx, mock.MatchedBy(func(f *entities.FileMetadata) bool {
+		return f.Key == "tenants/acme/images/logo.png"
+	})).Return(nil)
+
+	// Act
+	metadata, err := service.UploadFile(ctx, &entities.FileUpload{
+		FileName:    "images/logo.png",
+		Content:     bytes.NewReader([]byte("\x89PNG\r\n\x1a\n")),
+		Size:        8,
+		ContentType: "image/png",
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "images/logo.png", metadata.FileName)
+	mockRepo.AssertExpectations(t)
+	mockImages.AssertExpectations(t)
+}
+
+// TestUploadFile_SniffsContentTypeAndComputesChecksum tests content type detection and the SHA-256 of the stream
+func TestUploadFile_SniffsContentTypeAndComputesChecksum(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	service := NewStorageService(mockRepo, mockFiles, new(MockProductMediaRepository), new(MockContentObjectRepository), newMockImages(), nil, StorageOptions{DefaultBucket: "default-bucket", PresignExpiry: time.Minute}, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	content := append([]byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A}, bytes.Repeat([]byte{0}, 2048)...)
+	sum := sha256.Sum256(content)
+
+	mockRepo.On("EnsureBucket", ctx, "default-bucket").Return(nil)
+	storeReadingAll(mockRepo, "default-bucket", isStaging, "image/png")
+	promoteStaged(mockRepo, "default-bucket", "tenants/acme/logo.png")
+
+	recordAsNew(mockRepo, mockFiles)
+
+	// Act
+	metadata, err := service.UploadFile(ctx, &entities.FileUpload{
+		FileName: "logo.png",
+		Content:  bytes.NewReader(content),
+		Size:     -1,
+		SHA256:   hex.EncodeToString(sum[:]),
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", metadata.ContentType)
+	assert.Equal(t, int64(len(content)), metadata.Size, "the whole stream including the sniffed prefix is stored")
+	assert.Equal(t, hex.EncodeToString(sum[:]), metadata.SHA256)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestUploadFile_DigestMismatch tests that uploads not matching the expected checksum are removed and rejected
+func TestUploadFile_DigestMismatch(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	service := NewStorageService(mockRepo, mockFiles, new(MockProductMediaRepository), new(MockContentObjectRepository), newMockImages(), nil, StorageOptions{DefaultBucket: "default-bucket", PresignExpiry: time.Minute}, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	sum := sha256.Sum256([]byte("other"))
+
+	mockRepo.On("EnsureBucket", ctx, "default-bucket").Return(nil)
+	storeReadingAll(mockRepo, "default-bucket", isStaging, "text/plain")
+	mockRepo.On("Remove", ctx, "default-bucket", isStaging).Return(nil)
+
+	// Act
+	_, err := service.UploadFile(ctx, &entities.FileUpload{
+		FileName:    "notes.txt",
+		Content:     bytes.NewReader([]byte("data")),
+		Size:        4,
+		ContentType: "text/plain",
+		SHA256:      hex.EncodeToString(sum[:]),
+	})
+
+	// Assert
+	var validationErr *domainErrors.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, "content_digest.mismatch", validationErr.Errors[0].Code)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestUploadFile_SizeLimits tests that declared and streamed sizes above the limit are rejected
+func TestUploadFile_SizeLimits(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	limits := UploadLimits{Default: 1 << 20, ByContentType: map[string]int64{"text/*": 8}}
+	service := NewStorageService(mockRepo, mockFiles, new(MockProductMediaRepository), new(MockContentObjectRepository), newMockImages(), nil, StorageOptions{DefaultBucket: "default-bucket", Limits: limits, PresignExpiry: time.Minute}, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	content := []byte("more than eight bytes")
+
+	mockRepo.On("EnsureBucket", ctx, "default-bucket").Return(nil)
+	storeReadingAll(mockRepo, "default-bucket", isStaging, "text/plain")
+
+	// Act
+	_, declaredErr := service.UploadFile(ctx, &entities.FileUpload{
+		FileName: "notes.txt", Content: bytes.NewReader(content), Size: int64(len(content)), ContentType: "text/plain",
+	})
+	_, streamedErr := service.UploadFile(ctx, &entities.FileUpload{
+		FileName: "notes.txt", Content: bytes.NewReader(content), Size: -1, ContentType: "text/plain",
+	})
+
+	// Assert
+	for _, err := range []error{declaredErr, streamedErr} {
+		var validationErr *domainErrors.ValidationError
+		require.True(t, errors.As(err, &validationErr))
+		assert.Equal(t, "file.too_large", validationErr.Errors[0].Code)
+	}
+	mockRepo.AssertNumberOfCalls(t, "Store", 1)
+	mockRepo.AssertNotCalled(t, "Remove", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestUploadLimits_MaxSize tests the precedence of content type, bucket and default limits
+func TestUploadLimits_MaxSize(t *testing.T) {
+	limits := UploadLimits{
+		Default:       100,
+		ByBucket:      map[string]int64{"avatars": 10},
+		ByContentType: map[string]int64{"video/*": 1000, "image/png": 20},
+	}
+
+	assert.Equal(t, int64(100), limits.MaxSize("media", "text/plain"))
+	assert.Equal(t, int64(10), limits.MaxSize("avatars", "image/jpeg"))
+	assert.Equal(t, int64(20), limits.MaxSize("avatars", "image/png"))
+	assert.Equal(t, int64(1000), limits.MaxSize("avatars", "video/mp4; codecs=avc1"))
+}
+
+// directStaged returns the staging name of a direct upload of a file of tenant acme
+func directStaged(fileName string) string {
+	sum := sha256.Sum256([]byte(fileName))
+	return "uploads/acme/direct-" + hex.EncodeToString(sum[:])
+}
+
+// TestCreateUploadSlot_Presigns tests that slots are presigned to a staging name of the tenant with the declared constraints
+func TestCreateUploadSlot_Presigns(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	service := NewStorageService(mockRepo, mockFiles, new(MockProductMediaRepository), new(MockContentObjectRepository), newMockImages(), nil, StorageOptions{DefaultBucket: "default-bucket", PresignExpiry: 15 * time.Minute}, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	checksum := strings.Repeat("AB", sha256.Size)
+	presigned := &entities.PresignedRequest{Method: "PUT", URL: "http://storage/signed"}
+
+	mockRepo.On("EnsureBucket", ctx, "default-bucket").Return(nil)
+	mockRepo.On("PresignUpload", ctx, "default-bucket", directStaged("video.mp4"), "video/mp4", int64(2048), strings.ToLower(checksum), 15*time.Minute).Return(presigned, nil)
+
+	slot := &entities.UploadSlot{FileName: "video.mp4", ContentType: "video/mp4", Size: 2048, SHA256: checksum}
+
+	// Act
+	err := service.CreateUploadSlot(ctx, slot)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "default-bucket", slot.Bucket)
+	assert.Same(t, presigned, slot.Upload)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestCreateUploadSlot_Validation tests that slots violating the upload limits are rejected before presigning
+func TestCreateUploadSlot_Validation(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	limits := UploadLimits{Default: 1 << 20, ByContentType: map[string]int64{"video/*": 1 << 30}}
+	service := NewStorageService(mockRepo, mockFiles, new(MockProductMediaRepository), new(MockContentObjectRepository), newMockImages(), nil, StorageOptions{DefaultBucket: "default-bucket", Limits: limits, PresignExpiry: time.Minute}, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	cases := []struct {
+		slot  *entities.UploadSlot
+		codes []string
+	}{
+		{&entities.UploadSlot{FileName: "a.bin", Size: 0}, []string{"content_type.required", "size.must_be_positive"}},
+		{&entities.UploadSlot{FileName: "a.pdf", ContentType: "application/pdf", Size: 2 << 20}, []string{"size.too_large"}},
+		{&entities.UploadSlot{FileName: "a.mp4", ContentType: "video/mp4", Size: 2 << 20, SHA256: "abc"}, []string{"sha256.invalid"}},
+	}
+
+	for _, tc := range cases {
+		// Act
+		err := service.CreateUploadSlot(ctx, tc.slot)
+
+		// Assert
+		var validationErr *domainErrors.ValidationError
+		require.True(t, errors.As(err, &validationErr), tc.slot.FileName)
+		var codes []string
+		for _, fe := range validationErr.Errors {
+			codes = append(codes, fe.Code)
+		}
+		assert.Equal(t, tc.codes, codes, tc.slot.FileName)
+	}
+	mockRepo.AssertNotCalled(t, "PresignUpload", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestConfirmUpload_RecordsStoredFile tests that a confirmed upload reports the metadata of the stored object
+func TestConfirmUpload_RecordsStoredFile(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	service := NewStorageService(mockRepo, mockFiles, new(MockProductMediaRepository), new(MockContentObjectRepository), newMockImages(), nil, StorageOptions{DefaultBucket: "default-bucket", Limits: UploadLimits{Default: 1 << 20}, PresignExpiry: time.Minute}, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	mockRepo.On("Stat", ctx, "default-bucket", directStaged("a.pdf")).Return(&entities.FileMetadata{
+		FileName: directStaged("a.pdf"), Bucket: "default-bucket", Size: 1024, ContentType: "application/pdf",
+	}, nil)
+	mockRepo.On("GetFile", ctx, "default-bucket", directStaged("a.pdf"), &entities.ByteRange{Start: 0, End: 511}).
+		Return(io.NopCloser(strings.NewReader("%PDF-1.7\n")), int64(10), "application/pdf", nil)
+	promoteStaged(mockRepo, "default-bucket", "tenants/acme/a.pdf")
+
+	recordAsNew(mockRepo, mockFiles)
+
+	// Act
+	metadata, err := service.ConfirmUpload(ctx, "", "a.pdf")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "a.pdf", metadata.FileName)
+	assert.Equal(t, int64(1024), metadata.Size)
+	assert.Equal(t, "tenants/acme/a.pdf", metadata.Key)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestConfirmUpload_TooLarge tests that uploads above the current limits are removed on confirmation
+func TestConfirmUpload_TooLarge(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	service := NewStorageService(mockRepo, mockFiles, new(MockProductMediaRepository), new(MockContentObjectRepository), newMockImages(), nil, StorageOptions{DefaultBucket: "default-bucket", Limits: UploadLimits{Default: 512}, PresignExpiry: time.Minute}, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	mockRepo.On("Stat", ctx, "default-bucket", directStaged("a.pdf")).Return(&entities.FileMetadata{Size: 1024, ContentType: "application/pdf"}, nil)
+	mockRepo.On("Remove", ctx, "default-bucket", directStaged("a.pdf")).Return(nil)
+
+	// Act
+	_, err := service.ConfirmUpload(ctx, "", "a.pdf")
+
+	// Assert
+	var validationErr *domainErrors.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, "file.too_large", validationErr.Errors[0].Code)
+	mockRepo.AssertExpectations(t)
+}
+
+// composeStaged makes Compose report a staged file with the content and GetFile serve it, whole or in part
+func composeStaged(mockRepo *MockStorageRepository, bucket string, parts []entities.UploadPart, content string) {
+	mockRepo.On("Compose", mock.Anything, bucket, isStaging, parts, "application/pdf").
+		Return(&entities.FileMetadata{Size: int64(len(content)), ContentType: "application/pdf"}, nil)
+	mockRepo.On("GetFile", mock.Anything, bucket, isStaging, mock.Anything).
+		Return(func(ctx context.Context, bucket, fileName string, rng *entities.ByteRange) (io.ReadCloser, int64, string, error) {
+			data := content
+			if rng != nil {
+				data = content[rng.Start : rng.End+1]
+			}
+			return io.NopCloser(strings.NewReader(data)), int64(len(data)), "application/pdf", nil
+		})
+}
+
+// TestComposeFile_RecordsComposedFile tests that a file composed in storage is verified and recorded without being uploaded
+func TestComposeFile_RecordsComposedFile(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	service := NewStorageService(mockRepo, mockFiles, new(MockProductMediaRepository), new(MockContentObjectRepository), newMockImages(), nil, StorageOptions{DefaultBucket: "default-bucket", Limits: UploadLimits{Default: 1 << 20}, PresignExpiry: time.Minute}, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	content := "%PDF-1.7\n"
+	sum := sha256.Sum256([]byte(content))
+	parts := []entities.UploadPart{{Name: "resumable/acme/1/0", Size: 5}, {Name: "resumable/acme/1/5", Offset: 5, Size: 5}}
+
+	composeStaged(mockRepo, "default-bucket", parts, content)
+	promoteStaged(mockRepo, "default-bucket", "tenants/acme/a.pdf")
+	recordAsNew(mockRepo, mockFiles)
+
+	// Act
+	metadata, err := service.ComposeFile(ctx, &entities.FileUpload{
+		FileName: "a.pdf", Size: 10, ContentType: "application/pdf", SHA256: hex.EncodeToString(sum[:]),
+	}, parts)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "a.pdf", metadata.FileName)
+	assert.Equal(t, "tenants/acme/a.pdf", metadata.Key)
+	assert.Equal(t, hex.EncodeToString(sum[:]), metadata.SHA256)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestComposeFile_ChecksumMismatch tests that a composed file whose checksum does not match is removed
+func TestComposeFile_ChecksumMismatch(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	service := NewStorageService(mockRepo, mockFiles, new(MockProductMediaRepository), new(MockContentObjectRepository), newMockImages(), nil, StorageOptions{DefaultBucket: "default-bucket", Limits: UploadLimits{Default: 1 << 20}, PresignExpiry: time.Minute}, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	parts := []entities.UploadPart{{Name: "resumable/acme/1/0", Size: 10}}
+
+	composeStaged(mockRepo, "default-bucket", parts, "%PDF-1.7\n")
+	mockRepo.On("Remove", ctx, "default-bucket", isStaging).Return(nil)
+
+	// Act
+	_, err := service.ComposeFile(ctx, &entities.FileUpload{
+		FileName: "a.pdf", Size: 10, ContentType: "application/pdf", SHA256: strings.Repeat("0", 64),
+	}, parts)
+
+	// Assert
+	var validationErr *domainErrors.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, "content_digest.mismatch", validationErr.Errors[0].Code)
+	mockRepo.AssertExpectations(t)
+	mockFiles.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// TestDeleteFile_NamespacedByTenant tests that deletes only address the tenant's objects and remove their record
+func TestDeleteFile_NamespacedByTenant(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	mockMedia := new(MockProductMediaRepository)
+	mockImages := new(MockImageService)
+	service := NewStorageService(mockRepo, mockFiles, mockMedia, new(MockContentObjectRepository), mockImages, nil, StorageOptions{DefaultBucket: "default-bucket", PresignExpiry: time.Minute}, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	file := &entities.FileMetadata{ID: uuid.New(), Bucket: "default-bucket", Key: "tenants/acme/logo.png"}
+
+	mockFiles.On("GetByName", ctx, "default-bucket", "logo.png").Return(file, nil)
+	mockMedia.On("CountByFile", ctx, file.ID).Return(0, nil)
+	mockFiles.On("Delete", ctx, file.ID).Return(nil)
+	mockRepo.On("Remove", ctx, "default-bucket", "tenants/acme/logo.png").Return(nil)
+	mockImages.On("RemoveVariants", ctx, file).Return(nil)
+
+	// Act
+	err := service.DeleteFile(ctx, "", "logo.png")
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockFiles.AssertExpectations(t)
+	mockImages.AssertExpectations(t)
+}
+
+// TestDeleteFileByID_RestoresRecordWhenRemovalFails tests that the record is kept if the object cannot be removed
+func TestDeleteFileByID_RestoresRecordWhenRemovalFails(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	mockMedia := new(MockProductMediaRepository)
+	service := NewStorageService(mockRepo, mockFiles, mockMedia, new(MockContentObjectRepository), newMockImages(), nil, StorageOptions{DefaultBucket: "default-bucket", PresignExpiry: time.Minute}, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	file := &entities.FileMetadata{ID: uuid.New(), Bucket: "default-bucket", Key: "tenants/acme/logo.png"}
+	storageErr := errors.New("storage unavailable")
+
+	mockFiles.On("GetByID", ctx, file.ID).Return(file, nil)
+	mockMedia.On("CountByFile", ctx, file.ID).Return(0, nil)
+	mockFiles.On("Delete", ctx, file.ID).Return(nil)
+	mockRepo.On("Remove", ctx, "default-bucket", "tenants/acme/logo.png").Return(storageErr)
+	mockFiles.On("Create", ctx, file).Return(nil)
+
+	// Act
+	err := service.DeleteFileByID(ctx, file.ID)
+
+	// Assert
+	assert.ErrorIs(t, err, storageErr)
+	mockRepo.AssertExpectations(t)
+	mockFiles.AssertExpectations(t)
+}
+
+// TestDeleteFileByID_InUse tests that deleting a file used by products is blocked or detaches it, depending on the policy
+func TestDeleteFileByID_InUse(t *testing.T) {
+	for _, policy := range []InUsePolicy{InUseBlock, InUseCascade} {
+		// Arrange
+		mockRepo := new(MockStorageRepository)
+		mockFiles := new(MockFileRepository)
+		mockMedia := new(MockProductMediaRepository)
+		service := NewStorageService(mockRepo, mockFiles, mockMedia, new(MockContentObjectRepository), newMockImages(), nil, StorageOptions{DefaultBucket: "default-bucket", PresignExpiry: time.Minute, InUse: policy}, nil)
+		ctx := entities.ContextWithTenant(context.Background(), "acme")
+		file := &entities.FileMetadata{ID: uuid.New(), Bucket: "default-bucket", Key: "tenants/acme/logo.png"}
+
+		mockFiles.On("GetByID", ctx, file.ID).Return(file, nil)
+		mockMedia.On("CountByFile", ctx, file.ID).Return(2, nil)
+		if policy == InUseCascade {
+			mockMedia.On("DeleteByFile", ctx, file.ID).Return(nil)
+			mockFiles.On("Delete", ctx, file.ID).Return(nil)
+			mockRepo.On("Remove", ctx, "default-bucket", "tenants/acme/logo.png").Return(nil)
+		}
+
+		// Act
+		err := service.DeleteFileByID(ctx, file.ID)
+
+		// Assert
+		if policy == InUseBlock {
+			var validationErr *domainErrors.ValidationError
+			require.True(t, errors.As(err, &validationErr))
+			assert.Equal(t, "id.in_use", validationErr.Errors[0].Code)
+			mockFiles.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+			mockRepo.AssertNotCalled(t, "Remove", mock.Anything, mock.Anything, mock.Anything)
+		} else {
+			require.NoError(t, err)
+		}
+		mockRepo.AssertExpectations(t)
+		mockFiles.AssertExpectations(t)
+		mockMedia.AssertExpectations(t)
+	}
+}
+
+// TestUploadFile_ReplacesRecord tests that uploading to an existing name updates its record instead of adding one
+func TestUploadFile_ReplacesRecord(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	service := NewStorageService(mockRepo, mockFiles, new(MockProductMediaRepository), new(MockContentObjectRepository), newMockImages(), nil, StorageOptions{DefaultBucket: "default-bucket", PresignExpiry: time.Minute}, nil)
+	ctx := entities.ContextWithPrincipal(entities.ContextWithTenant(context.Background(), "acme"), &entities.Principal{UserID: 7})
+	existing := &entities.FileMetadata{ID: uuid.New(), Bucket: "default-bucket", Key: "tenants/acme/notes.txt", FileName: "notes.txt", UploadedAt: time.Now().Add(-time.Hour)}
+
+	mockRepo.On("EnsureBucket", ctx, "default-bucket").Return(nil)
+	storeReadingAll(mockRepo, "default-bucket", isStaging, "text/plain")
+	promoteStaged(mockRepo, "default-bucket", "tenants/acme/notes.txt")
+	mockFiles.On("GetByName", ctx, "default-bucket", "notes.txt").Return(existing, nil)
+	mockFiles.On("Update", ctx, mock.AnythingOfType("*entities.FileMetadata")).Return(nil)
+	mockRepo.On("GetURL", ctx, "default-bucket", "notes.txt").Return("/files/download?file_name=notes.txt", nil)
+
+	// Act
+	metadata, err := service.UploadFile(ctx, &entities.FileUpload{
+		FileName: "notes.txt", Content: bytes.NewReader([]byte("data")), Size: 4, ContentType: "text/plain",
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, metadata.ID)
+	assert.Equal(t, existing.UploadedAt, metadata.UploadedAt)
+	assert.Equal(t, "tenants/acme/notes.txt", metadata.Key)
+	require.NotNil(t, metadata.UploaderID)
+	assert.Equal(t, 7, *metadata.UploaderID)
+	assert.Equal(t, "/files/download?file_name=notes.txt", metadata.URL)
+	mockFiles.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// TestUploadFile_DiscardsObjectWhenRecordFails tests that objects are not left behind without a record
+func TestUploadFile_DiscardsObjectWhenRecordFails(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	service := NewStorageService(mockRepo, mockFiles, new(MockProductMediaRepository), new(MockContentObjectRepository), newMockImages(), nil, StorageOptions{DefaultBucket: "default-bucket", PresignExpiry: time.Minute}, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	dbErr := errors.New("database unavailable")
+
+	mockRepo.On("EnsureBucket", ctx, "default-bucket").Return(nil)
+	storeReadingAll(mockRepo, "default-bucket", isStaging, "text/plain")
+	mockFiles.On("GetByName", ctx, "default-bucket", "notes.txt").Return(nil, dbErr)
+	mockRepo.On("Remove", ctx, "default-bucket", isStaging).Return(nil)
+
+	// Act
+	_, err := service.UploadFile(ctx, &entities.FileUpload{
+		FileName: "notes.txt", Content: bytes.NewReader([]byte("data")), Size: 4, ContentType: "text/plain",
+	})
+
+	// Assert
+	assert.ErrorIs(t, err, dbErr)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "Remove", mock.Anything, "default-bucket", "tenants/acme/notes.txt")
+}
+
+// TestUploadFile_RejectedReplacementKeepsFile tests that a re-upload that is rejected leaves the file it
+// would have replaced intact
+func TestUploadFile_RejectedReplacementKeepsFile(t *testing.T) {
+	// Arrange
+	repo := memory.NewStorageRepository()
+	mockFiles := new(MockFileRepository)
+	service := NewStorageService(repo, mockFiles, new(MockProductMediaRepository), new(MockContentObjectRepository), newMockImages(), nil, StorageOptions{DefaultBucket: "default-bucket", PresignExpiry: time.Minute}, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	require.NoError(t, repo.EnsureBucket(ctx, "default-bucket"))
+	_, err := repo.Store(ctx, "default-bucket", "tenants/acme/notes.txt", strings.NewReader("original"), 8, "text/plain")
+	require.NoError(t, err)
+	existing := &entities.FileMetadata{ID: uuid.New(), Bucket: "default-bucket", Key: "tenants/acme/notes.txt", FileName: "notes.txt", Size: 8}
+	mockFiles.On("GetByName", ctx, "default-bucket", "notes.txt").Return(existing, nil)
+	wrong := sha256.Sum256([]byte("other"))
+
+	// Act
+	_, uploadErr := service.UploadFile(ctx, &entities.FileUpload{
+		FileName: "notes.txt", Content: strings.NewReader("replacement"), Size: 11, ContentType: "text/plain",
+		SHA256: hex.EncodeToString(wrong[:]),
+	})
+	download, downloadErr := service.DownloadFile(ctx, "", "notes.txt", entities.DownloadOptions{})
+
+	// Assert
+	var validationErr *domainErrors.ValidationError
+	require.True(t, errors.As(uploadErr, &validationErr))
+	assert.Equal(t, "content_digest.mismatch", validationErr.Errors[0].Code)
+	require.NoError(t, downloadErr)
+	content, err := io.ReadAll(download.Content)
+	require.NoError(t, err)
+	assert.Equal(t, "original", string(content))
+	mockFiles.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+
+	var staged int
+	require.NoError(t, repo.List(ctx, "default-bucket", "uploads/", func(*entities.FileMetadata) error { staged++; return nil }))
+	assert.Zero(t, staged, "the rejected upload is not left staged")
+}
+
+// TestUploadFile_RollsBackRecordWhenPromotionFails tests that the replaced record is restored if the staged
+// upload cannot be moved to the file's key
+func TestUploadFile_RollsBackRecordWhenPromotionFails(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	service := NewStorageService(mockRepo, mockFiles, new(MockProductMediaRepository), new(MockContentObjectRepository), newMockImages(), nil, StorageOptions{DefaultBucket: "default-bucket", PresignExpiry: time.Minute}, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	existing := &entities.FileMetadata{ID: uuid.New(), Bucket: "default-bucket", Key: "tenants/acme/notes.txt", FileName: "notes.txt", Size: 8}
+	copyErr := errors.New("storage unavailable")
+
+	mockRepo.On("EnsureBucket", ctx, "default-bucket").Return(nil)
+	storeReadingAll(mockRepo, "default-bucket", isStaging, "text/plain")
+	mockFiles.On("GetByName", ctx, "default-bucket", "notes.txt").Return(existing, nil)
+	mockFiles.On("Update", ctx, mock.MatchedBy(func(f *entities.FileMetadata) bool { return f.Size == 4 })).Return(nil).Once()
+	mockRepo.On("Copy", ctx, "default-bucket", isStaging, "tenants/acme/notes.txt").Return(copyErr)
+	mockFiles.On("Update", ctx, existing).Return(nil).Once()
+	mockRepo.On("Remove", ctx, "default-bucket", isStaging).Return(nil)
+
+	// Act
+	_, err := service.UploadFile(ctx, &entities.FileUpload{
+		FileName: "notes.txt", Content: bytes.NewReader([]byte("data")), Size: 4, ContentType: "text/plain",
+	})
+
+	// Assert
+	assert.ErrorIs(t, err, copyErr)
+	mockRepo.AssertExpectations(t)
+	mockFiles.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "Remove", mock.Anything, "default-bucket", "tenants/acme/notes.txt")
+}
+
+// dataKey is the content object of "data" in tenant acme
+const dataKey = "content/acme/3a/3a6eb0790f39ac87c94f3856b2dd2c5d110e6811602261a9a923d3bb23adc8b7"
+
+// TestUploadFile_Deduplicates tests that uploads are staged and moved to their content object, which is
+// only copied if the tenant has not stored the content before
+func TestUploadFile_Deduplicates(t *testing.T) {
+	for _, stored := range []bool{false, true} {
+		// Arrange
+		mockRepo := new(MockStorageRepository)
+		mockFiles := new(MockFileRepository)
+		mockContents := new(MockContentObjectRepository)
+		service := NewStorageService(mockRepo, mockFiles, new(MockProductMediaRepository), mockContents, newMockImages(), nil, StorageOptions{DefaultBucket: "default-bucket", PresignExpiry: time.Minute, Dedup: true}, nil)
+		ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+		mockRepo.On("EnsureBucket", ctx, "default-bucket").Return(nil)
+		storeReadingAll(mockRepo, "default-bucket", isStaging, "text/plain")
+		mockContents.On("Acquire", ctx, "default-bucket", dataKey).Return(nil)
+		if stored {
+			mockRepo.On("Stat", ctx, "default-bucket", dataKey).Return(&entities.FileMetadata{Key: dataKey, Size: 4}, nil)
+		} else {
+			mockRepo.On("Stat", ctx, "default-bucket", dataKey).Return(nil, domainErrors.NewNotFoundError("File", dataKey))
+			mockRepo.On("Copy", ctx, "default-bucket", isStaging, dataKey).Return(nil)
+		}
+		mockRepo.On("Remove", ctx, "default-bucket", isStaging).Return(nil)
+		recordAsNew(mockRepo, mockFiles)
+
+		// Act
+		metadata, err := service.UploadFile(ctx, &entities.FileUpload{
+			FileName: "notes.txt", Content: bytes.NewReader([]byte("data")), Size: 4, ContentType: "text/plain",
+		})
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, "notes.txt", metadata.FileName)
+		assert.Equal(t, dataKey, metadata.Key)
+		mockRepo.AssertExpectations(t)
+		mockContents.AssertExpectations(t)
+		if stored {
+			mockRepo.AssertNotCalled(t, "Copy", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		}
+	}
+}
+
+// TestUploadFile_ReleasesReplacedContent tests that replacing a deduplicated file drops its reference to
+// the old content
+func TestUploadFile_ReleasesReplacedContent(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	mockContents := new(MockContentObjectRepository)
+	service := NewStorageService(mockRepo, mockFiles, new(MockProductMediaRepository), mockContents, newMockImages(), nil, StorageOptions{DefaultBucket: "default-bucket", PresignExpiry: time.Minute}, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	existing := &entities.FileMetadata{ID: uuid.New(), Bucket: "default-bucket", Key: dataKey, FileName: "notes.txt"}
+
+	mockRepo.On("EnsureBucket", ctx, "default-bucket").Return(nil)
+	storeReadingAll(mockRepo, "default-bucket", isStaging, "text/plain")
+	promoteStaged(mockRepo, "default-bucket", "tenants/acme/notes.txt")
+	mockFiles.On("GetByName", ctx, "default-bucket", "notes.txt").Return(existing, nil)
+	mockFiles.On("Update", ctx, mock.AnythingOfType("*entities.FileMetadata")).Return(nil)
+	mockContents.On("Release", ctx, "default-bucket", dataKey).Return(true, nil)
+	mockRepo.On("Remove", ctx, "default-bucket", dataKey).Return(nil)
+	mockRepo.On("GetURL", ctx, "default-bucket", "notes.txt").Return("/files/download", nil)
+
+	// Act
+	metadata, err := service.UploadFile(ctx, &entities.FileUpload{
+		FileName: "notes.txt", Content: bytes.NewReader([]byte("new data")), Size: 8, ContentType: "text/plain",
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "tenants/acme/notes.txt", metadata.Key)
+	mockRepo.AssertExpectations(t)
+	mockContents.AssertExpectations(t)
+}
+
+// TestDeleteFileByID_ReleasesContent tests that shared content is only removed with its last file
+func TestDeleteFileByID_ReleasesContent(t *testing.T) {
+	for _, last := range []bool{false, true} {
+		// Arrange
+		mockRepo := new(MockStorageRepository)
+		mockFiles := new(MockFileRepository)
+		mockMedia := new(MockProductMediaRepository)
+		mockContents := new(MockContentObjectRepository)
+		service := NewStorageService(mockRepo, mockFiles, mockMedia, mockContents, newMockImages(), nil, StorageOptions{DefaultBucket: "default-bucket", PresignExpiry: time.Minute, Dedup: true}, nil)
+		ctx := entities.ContextWithTenant(context.Background(), "acme")
+		file := &entities.FileMetadata{ID: uuid.New(), Bucket: "default-bucket", Key: dataKey, FileName: "notes.txt"}
+
+		mockFiles.On("GetByID", ctx, file.ID).Return(file, nil)
+		mockMedia.On("CountByFile", ctx, file.ID).Return(0, nil)
+		mockFiles.On("Delete", ctx, file.ID).Return(nil)
+		mockContents.On("Release", ctx, "default-bucket", dataKey).Return(last, nil)
+		if last {
+			mockRepo.On("Remove", ctx, "default-bucket", dataKey).Return(nil)
+		}
+
+		// Act
+		err := service.DeleteFileByID(ctx, file.ID)
+
+		// Assert
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockContents.AssertExpectations(t)
+		if !last {
+			mockRepo.AssertNotCalled(t, "Remove", mock.Anything, mock.Anything, mock.Anything)
+		}
+	}
+}
+
+// TestUploadFile_ChecksContentAgainstPolicy tests that the detected content type is checked against the
+// declared one and the types allowed in the bucket before anything is stored
+func TestUploadFile_ChecksContentAgainstPolicy(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	policy := UploadPolicy{AllowedByBucket: map[string][]string{"avatars": {"image/*"}}, Blocked: []string{"text/html"}, QuarantineBucket: "quarantine"}
+	service := NewStorageService(mockRepo, mockFiles, new(MockProductMediaRepository), new(MockContentObjectRepository), newMockImages(), nil, StorageOptions{DefaultBucket: "default-bucket", Policy: policy, PresignExpiry: time.Minute}, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	cases := []struct {
+		name   string
+		upload *entities.FileUpload
+		code   string
+	}{
+		{
+			name:   "declared type does not match content",
+			upload: &entities.FileUpload{FileName: "logo.png", Content: strings.NewReader("<html><script>alert(1)</script>"), Size: -1, ContentType: "image/png"},
+			code:   "content_type.mismatch",
+		},
+		{
+			name:   "blocked type detected",
+			upload: &entities.FileUpload{FileName: "page.txt", Content: strings.NewReader("<html><script>alert(1)</script>"), Size: -1},
+			code:   "content_type.not_allowed",
+		},
+		{
+			name:   "type not allowed in bucket",
+			upload: &entities.FileUpload{Bucket: "avatars", FileName: "notes.txt", Content: strings.NewReader("hello"), Size: -1},
+			code:   "content_type.not_allowed",
+		},
+		{
+			name:   "quarantine bucket",
+			upload: &entities.FileUpload{Bucket: "quarantine", FileName: "notes.txt", Content: strings.NewReader("hello"), Size: -1},
+			code:   "bucket.reserved",
+		},
+	}
+
+	for _, tc := range cases {
+		// Act
+		_, err := service.UploadFile(ctx, tc.upload)
+
+		// Assert
+		var validationErr *domainErrors.ValidationError
+		require.True(t, errors.As(err, &validationErr), tc.name)
+		assert.Equal(t, tc.code, validationErr.Errors[0].Code, tc.name)
+	}
+	mockRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestUploadFile_QuarantinesInfected tests that flagged files are moved to the quarantine bucket and not recorded
+func TestUploadFile_QuarantinesInfected(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	mockScanner := new(MockFileScanner)
+	policy := UploadPolicy{QuarantineBucket: "quarantine"}
+	service := NewStorageService(mockRepo, mockFiles, new(MockProductMediaRepository), new(MockContentObjectRepository), newMockImages(), mockScanner, StorageOptions{DefaultBucket: "default-bucket", Policy: policy, PresignExpiry: time.Minute}, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	mockRepo.On("EnsureBucket", ctx, "default-bucket").Return(nil)
+	mockRepo.On("EnsureBucket", ctx, "quarantine").Return(nil)
+	storeReadingAll(mockRepo, "default-bucket", isStaging, "text/plain; charset=utf-8")
+	mockRepo.On("GetFile", ctx, "default-bucket", isStaging, (*entities.ByteRange)(nil)).
+		Return(func(context.Context, string, string, *entities.ByteRange) (io.ReadCloser, int64, string, error) {
+			return io.NopCloser(strings.NewReader("malicious")), 9, "text/plain; charset=utf-8", nil
+		}, nil)
+	storeReadingAll(mockRepo, "quarantine", mock.Anything, "text/plain; charset=utf-8").
+		Run(func(args mock.Arguments) {
+			assert.Regexp(t, `^\d{8}T\d{6}Z/default-bucket/tenants/acme/invoice\.txt$`, args.String(2))
+		})
+	mockRepo.On("Remove", ctx, "default-bucket", isStaging).Return(nil)
+	mockScanner.On("Scan", ctx, "malicious").Return(&entities.ScanResult{Infected: true, Threat: "Eicar-Test-Signature"}, nil)
+
+	// Act
+	_, err := service.UploadFile(ctx, &entities.FileUpload{FileName: "invoice.txt", Content: strings.NewReader("malicious"), Size: 9})
+
+	// Assert
+	var validationErr *domainErrors.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, "file.infected", validationErr.Errors[0].Code)
+	mockRepo.AssertExpectations(t)
+	mockFiles.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// TestUploadFile_ScanFails tests that files that cannot be scanned are removed
+func TestUploadFile_ScanFails(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	mockScanner := new(MockFileScanner)
+	service := NewStorageService(mockRepo, mockFiles, new(MockProductMediaRepository), new(MockContentObjectRepository), newMockImages(), mockScanner, StorageOptions{DefaultBucket: "default-bucket", PresignExpiry: time.Minute}, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	mockRepo.On("EnsureBucket", ctx, "default-bucket").Return(nil)
+	storeReadingAll(mockRepo, "default-bucket", isStaging, "text/plain")
+	mockRepo.On("GetFile", ctx, "default-bucket", isStaging, (*entities.ByteRange)(nil)).
+		Return(io.NopCloser(strings.NewReader("hello")), int64(5), "text/plain", nil)
+	mockRepo.On("Remove", ctx, "default-bucket", isStaging).Return(nil)
+	mockScanner.On("Scan", ctx, "hello").Return(nil, errors.New("connection refused"))
+
+	// Act
+	_, err := service.UploadFile(ctx, &entities.FileUpload{FileName: "notes.txt", Content: strings.NewReader("hello"), Size: 5, ContentType: "text/plain"})
+
+	// Assert
+	assert.ErrorContains(t, err, "failed to scan file")
+	mockRepo.AssertExpectations(t)
+	mockFiles.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// TestUploadFile_FlaggedReplacementKeepsFile tests that replacing a file with one the scanner flags or cannot
+// scan leaves the file intact
+func TestUploadFile_FlaggedReplacementKeepsFile(t *testing.T) {
+	for _, infected := range []bool{true, false} {
+		// Arrange
+		repo := memory.NewStorageRepository()
+		mockFiles := new(MockFileRepository)
+		mockScanner := new(MockFileScanner)
+		policy := UploadPolicy{QuarantineBucket: "quarantine"}
+		service := NewStorageService(repo, mockFiles, new(MockProductMediaRepository), new(MockContentObjectRepository), newMockImages(), mockScanner, StorageOptions{DefaultBucket: "default-bucket", Policy: policy, PresignExpiry: time.Minute}, nil)
+		ctx := entities.ContextWithTenant(context.Background(), "acme")
+		require.NoError(t, repo.EnsureBucket(ctx, "default-bucket"))
+		_, err := repo.Store(ctx, "default-bucket", "tenants/acme/notes.txt", strings.NewReader("original"), 8, "text/plain")
+		require.NoError(t, err)
+		existing := &entities.FileMetadata{ID: uuid.New(), Bucket: "default-bucket", Key: "tenants/acme/notes.txt", FileName: "notes.txt", Size: 8}
+		mockFiles.On("GetByName", ctx, "default-bucket", "notes.txt").Return(existing, nil)
+		if infected {
+			mockScanner.On("Scan", ctx, "malicious").Return(&entities.ScanResult{Infected: true, Threat: "Eicar-Test-Signature"}, nil)
+		} else {
+			mockScanner.On("Scan", ctx, "malicious").Return(nil, errors.New("connection refused"))
+		}
+
+		// Act
+		_, uploadErr := service.UploadFile(ctx, &entities.FileUpload{
+			FileName: "notes.txt", Content: strings.NewReader("malicious"), Size: 9, ContentType: "text/plain",
+		})
+		download, downloadErr := service.DownloadFile(ctx, "", "notes.txt", entities.DownloadOptions{})
+
+		// Assert
+		require.Error(t, uploadErr)
+		require.NoError(t, downloadErr)
+		content, err := io.ReadAll(download.Content)
+		require.NoError(t, err)
+		assert.Equal(t, "original", string(content))
+		mockFiles.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	}
+}
+
+// TestStorageService_RejectsEscapingNames tests that filenames cannot leave the tenant prefix
+func TestStorageService_RejectsEscapingNames(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	service := NewStorageService(mockRepo, mockFiles, new(MockProductMediaRepository), new(MockContentObjectRepository), newMockImages(), nil, StorageOptions{DefaultBucket: "default-bucket", PresignExpiry: time.Minute}, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+	for _, name := range []string{"../other/logo.png", "/tenants/other/logo.png", "a/./b.png", "a//b.png", `..\\other\\logo.png`, "logo\r\n.png", strings.Repeat("a", 1024)} {
+		// Act
+		_, err := service.GetFileURL(ctx, "", name)
+
+		// Assert
+		assert.ErrorIs(t, err, domainErrors.ErrInvalidInput, name)
+	}
+	mockRepo.AssertNotCalled(t, "GetURL", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestStorageService_RequiresTenant tests that storage operations without a tenant fail
+func TestStorageService_RequiresTenant(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	service := NewStorageService(mockRepo, mockFiles, new(MockProductMediaRepository), new(MockContentObjectRepository), newMockImages(), nil, StorageOptions{DefaultBucket: "default-bucket", PresignExpiry: time.Minute}, nil)
+
+	// Act
+	_, err := service.DownloadFile(context.Background(), "", "logo.png", entities.DownloadOptions{})
+
+	// Assert
+	assert.ErrorIs(t, err, domainErrors.ErrTenantRequired)
+}
+
+// TestDownloadFile_Conditional tests that a current copy is reported as not modified without reading storage
+func TestDownloadFile_Conditional(t *testing.T) {
+	// Arrange
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 500, time.UTC)
+	file := &entities.FileMetadata{Bucket: "default-bucket", Key: "tenants/acme/logo.png", Size: 10, SHA256: "abc", UploadedAt: modified.Add(-time.Hour), UpdatedAt: modified}
+
+	cases := []struct {
+		name        string
+		opts        entities.DownloadOptions
+		notModified bool
+	}{
+		{name: "matching etag", opts: entities.DownloadOptions{IfNoneMatch: []string{`"xyz"`, `"abc"`}}, notModified: true},
+		{name: "weak etag", opts: entities.DownloadOptions{IfNoneMatch: []string{`W/"abc"`}}, notModified: true},
+		{name: "any etag", opts: entities.DownloadOptions{IfNoneMatch: []string{"*"}}, notModified: true},
+		{name: "other etag", opts: entities.DownloadOptions{IfNoneMatch: []string{`"xyz"`}}},
+		{name: "etag takes precedence over date", opts: entities.DownloadOptions{IfNoneMatch: []string{`"xyz"`}, IfModifiedSince: modified.Add(time.Hour)}},
+		{name: "not modified since", opts: entities.DownloadOptions{IfModifiedSince: modified.Truncate(time.Second)}, notModified: true},
+		{name: "modified since", opts: entities.DownloadOptions{IfModifiedSince: modified.Add(-time.Second)}},
+	}
+
+	for _, tc := range cases {
+		mockRepo := new(MockStorageRepository)
+		mockFiles := new(MockFileRepository)
+		service := NewStorageService(mockRepo, mockFiles, new(MockProductMediaRepository), new(MockContentObjectRepository), newMockImages(), nil, StorageOptions{DefaultBucket: "default-bucket", PresignExpiry: time.Minute}, nil)
+		ctx := entities.ContextWithTenant(context.Background(), "acme")
+
+		mockFiles.On("GetByName", ctx, "default-bucket", "logo.png").Return(file, nil)
+		mockRepo.On("GetFile", ctx, "default-bucket", "tenants/acme/logo.png", (*entities.ByteRange)(nil)).
+			Return(io.NopCloser(strings.NewReader("0123456789")), int64(10), "image/png", nil).Maybe()
+
+		// Act
+		download, err := service.DownloadFile(ctx, "", "logo.png", tc.opts)
+
+		// Assert
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, `"abc"`, download.ETag, tc.name)
+		assert.Equal(t, modified, download.LastModified, tc.name)
+		assert.Equal(t, tc.notModified, download.NotModified, tc.name)
+		if tc.notModified {
+			assert.Nil(t, download.Content, tc.name)
+			mockRepo.AssertNotCalled(t, "GetFile", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		} else {
+			assert.NotNil(t, download.Content, tc.name)
+		}
+	}
+}
+
+// TestDownloadFile_Range tests that ranges are resolved against the size of the file
+func TestDownloadFile_Range(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	service := NewStorageService(mockRepo, mockFiles, new(MockProductMediaRepository), new(MockContentObjectRepository), newMockImages(), nil, StorageOptions{DefaultBucket: "default-bucket", PresignExpiry: time.Minute}, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	file := &entities.FileMetadata{Bucket: "default-bucket", Key: "tenants/acme/video.mp4", Size: 1000, SHA256: "abc"}
+
+	mockFiles.On("GetByName", ctx, "default-bucket", "video.mp4").Return(file, nil)
+	mockRepo.On("GetFile", ctx, "default-bucket", "tenants/acme/video.mp4", &entities.ByteRange{Start: 800, End: 999}).
+		Return(io.NopCloser(strings.NewReader("tail")), int64(200), "video/mp4", nil)
+
+	// Act
+	download, err := service.DownloadFile(ctx, "", "video.mp4", entities.DownloadOptions{Range: &entities.RangeRequest{SuffixLength: 200}})
+	_, rangeErr := service.DownloadFile(ctx, "", "video.mp4", entities.DownloadOptions{Range: &entities.RangeRequest{Start: 1000, End: -1}})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, &entities.ByteRange{Start: 800, End: 999}, download.Range)
+	assert.Equal(t, int64(200), download.Size)
+	var notSatisfiable *domainErrors.RangeNotSatisfiableError
+	require.True(t, errors.As(rangeErr, &notSatisfiable))
+	assert.Equal(t, int64(1000), notSatisfiable.Size)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestDownloadFile_Unrecorded tests that objects without a record are served with a weak ETag
+func TestDownloadFile_Unrecorded(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockStorageRepository)
+	mockFiles := new(MockFileRepository)
+	service := NewStorageService(mockRepo, mockFiles, new(MockProductMediaRepository), new(MockContentObjectRepository), newMockImages(), nil, StorageOptions{DefaultBucket: "default-bucket", PresignExpiry: time.Minute}, nil)
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	stored := time.Unix(0x5f000000, 0)
+
+	mockFiles.On("GetByName", ctx, "default-bucket", "old.txt").Return(nil, domainErrors.NewNotFoundError("File", "old.txt"))
+	mockRepo.On("Stat", ctx, "default-bucket", "tenants/acme/old.txt").Return(&entities.FileMetadata{Bucket: "default-bucket", Key: "tenants/acme/old.txt", Size: 16, UploadedAt: stored}, nil)
+	mockRepo.On("GetFile", ctx, "default-bucket", "tenants/acme/old.txt", (*entities.ByteRange)(nil)).
+		Return(io.NopCloser(strings.NewReader("0123456789abcdef")), int64(16), "text/plain", nil)
+
+	// Act
+	download, err := service.DownloadFile(ctx, "", "old.txt", entities.DownloadOptions{})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, `W/"10-5f000000"`, download.ETag)
+	assert.Equal(t, stored, download.LastModified)
+	assert.Equal(t, int64(16), download.Size)
+}
+
+// TestRangeRequest_Resolve tests the byte ranges requests cover
+func TestRangeRequest_Resolve(t *testing.T) {
+	cases := []struct {
+		name  string
+		req   entities.RangeRequest
+		size  int64
+		want  entities.ByteRange
+		valid bool
+	}{
+		{name: "bounded", req: entities.RangeRequest{Start: 0, End: 9}, size: 100, want: entities.ByteRange{Start: 0, End: 9}, valid: true},
+		{name: "open", req: entities.RangeRequest{Start: 90, End: -1}, size: 100, want: entities.ByteRange{Start: 90, End: 99}, valid: true},
+		{name: "end beyond size", req: entities.RangeRequest{Start: 90, End: 500}, size: 100, want: entities.ByteRange{Start: 90, End: 99}, valid: true},
+		{name: "suffix", req: entities.RangeRequest{SuffixLength: 10}, size: 100, want: entities.ByteRange{Start: 90, End: 99}, valid: true},
+		{name: "suffix beyond size", req: entities.RangeRequest{SuffixLength: 500}, size: 100, want: entities.ByteRange{Start: 0, End: 99}, valid: true},
+		{name: "start beyond size", req: entities.RangeRequest{Start: 100, End: -1}, size: 100},
+		{name: "empty file", req: entities.RangeRequest{SuffixLength: 10}, size: 0},
+	}
+
+	for _, tc := range cases {
+		got, ok := tc.req.Resolve(tc.size)
+		assert.Equal(t, tc.valid, ok, tc.name)
+		if tc.valid {
+			assert.Equal(t, tc.want, got, tc.name)
+		}
+	}
+}
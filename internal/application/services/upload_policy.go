@@ -0,0 +1,106 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+)
+
+// UploadPolicy decides which content may be stored. The type of an upload is detected from its first bytes;
+// the declared type is only trusted where it refines what detection can tell. Patterns are content types
+// ("image/png"), type wildcards ("image/*") or "*/*".
+type UploadPolicy struct {
+	// Allowed lists the types accepted in buckets without their own list; empty accepts all types
+	Allowed []string
+	// AllowedByBucket maps bucket names to the types accepted in them
+	AllowedByBucket map[string][]string
+	// Blocked lists types rejected in every bucket, e.g. HTML and SVG, which browsers run scripts in
+	Blocked []string
+	// QuarantineBucket receives files the scanner flags; clients cannot address it. Flagged files are
+	// deleted if it is empty.
+	QuarantineBucket string
+}
+
+// Check returns the content type to store an upload to bucket as. The type is detected from head, the first
+// bytes of the content; a declared type must agree with it, and the result must be allowed in the bucket.
+func (p UploadPolicy) Check(bucket, declared string, head []byte) (string, error) {
+	detected := http.DetectContentType(head)
+	contentType := detected
+
+	if declared != "" {
+		declaredType, detectedType := mediaType(declared), mediaType(detected)
+		if declaredType != detectedType && !refines(declaredType, detectedType) {
+			return "", domainErrors.NewValidationError("content_type", "mismatch",
+				fmt.Sprintf("content is %s, not the declared %s", detectedType, declaredType))
+		}
+		contentType = declared
+	}
+
+	if !p.Allows(bucket, contentType) {
+		return "", domainErrors.NewValidationError("content_type", "not_allowed",
+			fmt.Sprintf("%s files are not allowed in bucket %s", mediaType(contentType), bucket))
+	}
+	return contentType, nil
+}
+
+// Allows reports whether files of contentType may be stored in bucket
+func (p UploadPolicy) Allows(bucket, contentType string) bool {
+	if matchesType(p.Blocked, contentType) {
+		return false
+	}
+	allowed, ok := p.AllowedByBucket[bucket]
+	if !ok {
+		if len(p.Allowed) == 0 {
+			return true
+		}
+		allowed = p.Allowed
+	}
+	return matchesType(allowed, contentType)
+}
+
+// textRefinements are the formats plain text may be declared as. Detection reports markup such as SVG or XSLT
+// without a doctype as plain text, so only formats that browsers do not run scripts in are listed.
+var textRefinements = map[string]bool{
+	"text/csv":                  true,
+	"text/tab-separated-values": true,
+	"text/markdown":             true,
+	"application/json":          true,
+}
+
+// refines reports whether a declared type may stand in for a detected one. http.DetectContentType knows a few
+// dozen formats and reports others by what they are built on, so a declared type may narrow such a generic
+// detection to a format of the same kind, e.g. plain text to CSV or a ZIP archive to a Word document.
+func refines(declared, detected string) bool {
+	switch detected {
+	case "application/octet-stream":
+		return !strings.HasPrefix(declared, "text/")
+	case "text/plain":
+		return textRefinements[declared]
+	case "application/zip":
+		return strings.HasSuffix(declared, "+zip") ||
+			strings.HasPrefix(declared, "application/vnd.openxmlformats-officedocument.") ||
+			strings.HasPrefix(declared, "application/vnd.oasis.opendocument.")
+	}
+	return false
+}
+
+// matchesType reports whether contentType matches one of the patterns
+func matchesType(patterns []string, contentType string) bool {
+	contentType = mediaType(contentType)
+	major, _, _ := strings.Cut(contentType, "/")
+	for _, pattern := range patterns {
+		pattern = mediaType(pattern)
+		if pattern == "*/*" || pattern == contentType || pattern == major+"/*" {
+			return true
+		}
+	}
+	return false
+}
+
+// mediaType returns the lower-case media type of a content type, without parameters
+func mediaType(contentType string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
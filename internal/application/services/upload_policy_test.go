@@ -0,0 +1,72 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUploadPolicy_Check tests content type detection and the declared types it accepts
+func TestUploadPolicy_Check(t *testing.T) {
+	policy := UploadPolicy{Blocked: []string{"text/html", "image/svg+xml"}}
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")
+	zip := []byte("PK\x03\x04\x14\x00\x06\x00")
+
+	cases := []struct {
+		name     string
+		declared string
+		head     []byte
+		want     string
+		code     string
+	}{
+		{name: "detected", head: png, want: "image/png"},
+		{name: "declared matches", declared: "IMAGE/PNG", head: png, want: "IMAGE/PNG"},
+		{name: "declared lies", declared: "image/jpeg", head: png, code: "content_type.mismatch"},
+		{name: "text refined", declared: "text/csv", head: []byte("id,name\n1,Tea"), want: "text/csv"},
+		{name: "json refined", declared: "application/json", head: []byte(`{"id": 1}`), want: "application/json"},
+		{name: "text is not an image", declared: "image/png", head: []byte("hello"), code: "content_type.mismatch"},
+		{name: "archive refined", declared: "application/vnd.openxmlformats-officedocument.wordprocessingml.document", head: zip, want: "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{name: "binary is not text", declared: "text/plain", head: []byte{0x00, 0x01, 0x02}, code: "content_type.mismatch"},
+		{name: "html detected", head: []byte("<!DOCTYPE html><script>"), code: "content_type.not_allowed"},
+		{name: "svg declared as text", declared: "image/svg+xml", head: []byte(`<svg onload="alert(1)">`), code: "content_type.mismatch"},
+		{name: "svg declared as xslt", declared: "text/xsl", head: []byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`), code: "content_type.mismatch"},
+		{name: "text declared as other text", declared: "text/javascript", head: []byte("alert(1)"), code: "content_type.mismatch"},
+	}
+
+	for _, tc := range cases {
+		// Act
+		contentType, err := policy.Check("media", tc.declared, tc.head)
+
+		// Assert
+		if tc.code == "" {
+			require.NoError(t, err, tc.name)
+			assert.Equal(t, tc.want, contentType, tc.name)
+			continue
+		}
+		var validationErr *domainErrors.ValidationError
+		require.True(t, errors.As(err, &validationErr), tc.name)
+		assert.Equal(t, tc.code, validationErr.Errors[0].Code, tc.name)
+	}
+}
+
+// TestUploadPolicy_Allows tests bucket lists, the default list and blocked types
+func TestUploadPolicy_Allows(t *testing.T) {
+	policy := UploadPolicy{
+		Allowed:         []string{"image/*", "application/pdf"},
+		AllowedByBucket: map[string][]string{"videos": {"video/mp4"}, "anything": {"*/*"}},
+		Blocked:         []string{"image/svg+xml"},
+	}
+
+	assert.True(t, policy.Allows("media", "image/png"))
+	assert.True(t, policy.Allows("media", "application/pdf; version=1.7"))
+	assert.False(t, policy.Allows("media", "text/plain"))
+	assert.False(t, policy.Allows("media", "image/svg+xml"))
+	assert.True(t, policy.Allows("videos", "video/mp4"))
+	assert.False(t, policy.Allows("videos", "image/png"))
+	assert.True(t, policy.Allows("anything", "text/plain"))
+	assert.False(t, policy.Allows("anything", "image/svg+xml"))
+	assert.True(t, UploadPolicy{}.Allows("media", "text/html"))
+}
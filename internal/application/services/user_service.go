@@ -2,36 +2,152 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"strings"
+
 	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
 	"example.com/go-yippi/internal/domain/ports"
 )
 
-// UserService handles business logic for users
+const minPasswordLength = 8
+
+// UserService handles business logic for users. Users are stored unscoped, as they log in before their
+// tenant is known; when ctx is scoped to a tenant, only the users of that tenant can be seen and changed.
 type UserService struct {
-	repo ports.UserRepository
+	repo   ports.UserRepository
+	hasher ports.PasswordHasher
 }
 
-func NewUserService(repo ports.UserRepository) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(repo ports.UserRepository, hasher ports.PasswordHasher) *UserService {
+	return &UserService{repo: repo, hasher: hasher}
 }
 
 func (s *UserService) CreateUser(ctx context.Context, user *entities.User) error {
-	// Add your business logic here
+	if err := s.validate(user); err != nil {
+		return err
+	}
+
+	// New users join the tenant of the request; they cannot be created in another one
+	if tenantID, ok := entities.TenantFromContext(ctx); ok {
+		if user.TenantID != "" && user.TenantID != tenantID {
+			return fmt.Errorf("%w: users can only be created in tenant %s", domainErrors.ErrForbidden, tenantID)
+		}
+		user.TenantID = tenantID
+	}
+
 	return s.repo.Create(ctx, user)
 }
 
 func (s *UserService) GetUser(ctx context.Context, id int) (*entities.User, error) {
-	return s.repo.GetByID(ctx, id)
+	return s.get(ctx, id)
+}
+
+// GetUserByEmail looks a user up by the email they log in with
+func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*entities.User, error) {
+	email = strings.TrimSpace(email)
+	user, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if !belongsToTenant(ctx, user) {
+		return nil, domainErrors.NewNotFoundError("User", email)
+	}
+	return user, nil
 }
 
 func (s *UserService) ListUsers(ctx context.Context) ([]*entities.User, error) {
+	if tenantID, ok := entities.TenantFromContext(ctx); ok {
+		return s.repo.ListByTenant(ctx, tenantID)
+	}
 	return s.repo.List(ctx)
 }
 
+// UpdateUser updates the profile fields, email and role while keeping credentials and 2FA state
 func (s *UserService) UpdateUser(ctx context.Context, user *entities.User) error {
-	return s.repo.Update(ctx, user)
+	if err := s.validate(user); err != nil {
+		return err
+	}
+
+	existing, err := s.get(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	existing.Name = user.Name
+	existing.Age = user.Age
+	existing.Email = user.Email
+	if user.Role != "" {
+		existing.Role = user.Role
+	}
+
+	if err := s.repo.Update(ctx, existing); err != nil {
+		return err
+	}
+
+	*user = *existing
+	return nil
 }
 
 func (s *UserService) DeleteUser(ctx context.Context, id int) error {
+	if _, err := s.get(ctx, id); err != nil {
+		return err
+	}
 	return s.repo.Delete(ctx, id)
 }
+
+// SetPassword hashes and stores a new password for the user
+func (s *UserService) SetPassword(ctx context.Context, id int, password string) error {
+	if len(password) < minPasswordLength {
+		return domainErrors.NewValidationError("password", "too_short", "Password must be at least 8 characters")
+	}
+
+	user, err := s.get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	user.PasswordHash = hash
+	return s.repo.Update(ctx, user)
+}
+
+// get returns a user, or a NotFoundError if it belongs to another tenant than the one ctx is scoped to
+func (s *UserService) get(ctx context.Context, id int) (*entities.User, error) {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !belongsToTenant(ctx, user) {
+		return nil, domainErrors.NewNotFoundError("User", id)
+	}
+	return user, nil
+}
+
+// belongsToTenant reports whether the user belongs to the tenant ctx is scoped to; without a tenant, all users do
+func belongsToTenant(ctx context.Context, user *entities.User) bool {
+	tenantID, ok := entities.TenantFromContext(ctx)
+	return !ok || user.TenantID == tenantID
+}
+
+// validate checks the email and role of a user
+func (s *UserService) validate(user *entities.User) error {
+	user.Email = strings.TrimSpace(user.Email)
+	if user.Email != "" && !strings.Contains(user.Email, "@") {
+		return domainErrors.NewValidationError("email", "invalid", "Email must be a valid email address")
+	}
+
+	if user.Role != "" && !user.Role.IsValid() {
+		return domainErrors.NewValidationError("role", "invalid", "Invalid role")
+	}
+
+	if user.TenantID != "" && !entities.IsValidTenantID(user.TenantID) {
+		return domainErrors.NewValidationError("tenant_id", "invalid", "Tenant ID must be a lowercase slug")
+	}
+
+	return nil
+}
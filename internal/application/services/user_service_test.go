@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"example.com/go-yippi/internal/domain/entities"
+	domainErrors "example.com/go-yippi/internal/domain/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUserService_CreateUser_JoinsTenantOfRequest tests that new users join the tenant of the request
+func TestUserService_CreateUser_JoinsTenantOfRequest(t *testing.T) {
+	// Arrange
+	repo := new(MockUserRepository)
+	service := NewUserService(repo, new(MockPasswordHasher))
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	user := &entities.User{Name: "Ada", Age: 30, Email: "ada@example.com", Role: entities.RoleAdmin}
+	repo.On("Create", ctx, user).Return(nil)
+
+	// Act
+	err := service.CreateUser(ctx, user)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "acme", user.TenantID)
+	repo.AssertExpectations(t)
+}
+
+// TestUserService_CreateUser_RejectsOtherTenant tests that users cannot be created in another tenant
+func TestUserService_CreateUser_RejectsOtherTenant(t *testing.T) {
+	// Arrange
+	repo := new(MockUserRepository)
+	service := NewUserService(repo, new(MockPasswordHasher))
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	user := &entities.User{Name: "Mallory", Age: 30, Email: "mallory@example.com", Role: entities.RoleAdmin, TenantID: "globex"}
+
+	// Act
+	err := service.CreateUser(ctx, user)
+
+	// Assert
+	assert.ErrorIs(t, err, domainErrors.ErrForbidden)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// TestUserService_CrossTenantAccess tests that the users of other tenants can be neither seen nor changed
+func TestUserService_CrossTenantAccess(t *testing.T) {
+	// Arrange
+	repo := new(MockUserRepository)
+	service := NewUserService(repo, new(MockPasswordHasher))
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	other := &entities.User{ID: 7, Name: "Grace", Age: 40, Email: "grace@globex.com", Role: entities.RoleAdmin, TenantID: "globex"}
+	repo.On("GetByID", ctx, 7).Return(other, nil)
+	repo.On("GetByEmail", ctx, "grace@globex.com").Return(other, nil)
+
+	// Act
+	_, getErr := service.GetUser(ctx, 7)
+	_, emailErr := service.GetUserByEmail(ctx, "grace@globex.com")
+	updateErr := service.UpdateUser(ctx, &entities.User{ID: 7, Name: "Hijacked", Age: 40, Role: entities.RoleViewer})
+	passwordErr := service.SetPassword(ctx, 7, "new-password")
+	deleteErr := service.DeleteUser(ctx, 7)
+
+	// Assert
+	for _, err := range []error{getErr, emailErr, updateErr, passwordErr, deleteErr} {
+		assert.ErrorIs(t, err, domainErrors.ErrNotFound)
+	}
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+// TestUserService_ListUsers tests that requests of a tenant list its users, and unscoped callers all users
+func TestUserService_ListUsers(t *testing.T) {
+	// Arrange
+	repo := new(MockUserRepository)
+	service := NewUserService(repo, new(MockPasswordHasher))
+	ctx := entities.ContextWithTenant(context.Background(), "acme")
+	acme := []*entities.User{{ID: 1, TenantID: "acme"}}
+	all := []*entities.User{{ID: 1, TenantID: "acme"}, {ID: 2, TenantID: "globex"}}
+	repo.On("ListByTenant", ctx, "acme").Return(acme, nil)
+	repo.On("List", context.Background()).Return(all, nil)
+
+	// Act
+	scoped, scopedErr := service.ListUsers(ctx)
+	unscoped, unscopedErr := service.ListUsers(context.Background())
+
+	// Assert
+	require.NoError(t, scopedErr)
+	assert.Equal(t, acme, scoped)
+	require.NoError(t, unscopedErr)
+	assert.Equal(t, all, unscoped)
+}
@@ -0,0 +1,140 @@
+package entities
+
+import (
+	"context"
+	"time"
+)
+
+// Role represents the role a user holds
+type Role string
+
+const (
+	RoleAdmin  Role = "admin"
+	RoleEditor Role = "editor"
+	RoleViewer Role = "viewer"
+)
+
+// Permission represents a single capability granted by a role
+type Permission string
+
+const (
+	PermissionCatalogWrite   Permission = "catalog:write"   // Create and update products, categories and brands
+	PermissionCatalogPublish Permission = "catalog:publish" // Publish and archive products
+	PermissionCatalogDelete  Permission = "catalog:delete"  // Delete products, categories and brands
+	PermissionFilesWrite     Permission = "files:write"     // Upload files
+	PermissionFilesDelete    Permission = "files:delete"    // Delete files
+	PermissionUsersManage    Permission = "users:manage"    // Manage users and their roles
+)
+
+// rolePermissions maps every role to the permissions it grants
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin: {
+		PermissionCatalogWrite, PermissionCatalogPublish, PermissionCatalogDelete,
+		PermissionFilesWrite, PermissionFilesDelete, PermissionUsersManage,
+	},
+	RoleEditor: {
+		PermissionCatalogWrite, PermissionFilesWrite,
+	},
+	RoleViewer: {},
+}
+
+// Roles returns the known roles, from the most to the least privileged
+func Roles() []Role {
+	return []Role{RoleAdmin, RoleEditor, RoleViewer}
+}
+
+// IsValid checks if the role is a known role
+func (r Role) IsValid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Permissions returns the permissions granted by the role
+func (r Role) Permissions() []Permission {
+	return rolePermissions[r]
+}
+
+// Principal represents the authenticated caller of a request
+type Principal struct {
+	UserID   int
+	Email    string
+	Role     Role
+	TenantID string
+
+	// TwoFactorVerified is true if the session was established with a second factor
+	TwoFactorVerified bool
+	// TwoFactorRequired is true if the role is sensitive and the session lacks a second factor,
+	// in which case Permissions is empty until the user enrols in (and logs in with) 2FA
+	TwoFactorRequired bool
+
+	// Permissions are the effective permissions of the session
+	Permissions []Permission
+}
+
+// Can checks if the principal holds the given permission
+func (p *Principal) Can(permission Permission) bool {
+	for _, granted := range p.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenType distinguishes full access tokens from short-lived login challenges
+type TokenType string
+
+const (
+	TokenTypeAccess    TokenType = "access"
+	TokenTypeChallenge TokenType = "challenge"
+)
+
+// TokenClaims holds the claims carried by a signed token
+type TokenClaims struct {
+	ID        string // unique ID of the token, by which challenges are answered only once
+	Subject   int
+	Type      TokenType
+	TwoFactor bool // true if the token was issued after a second factor was verified
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// AuthToken is a signed token together with its expiry
+type AuthToken struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// LoginResult is the outcome of the first authentication factor
+type LoginResult struct {
+	// AccessToken is set if no second factor is required
+	AccessToken *AuthToken
+	// ChallengeToken is set if the user must complete a TOTP step-up
+	ChallengeToken    *AuthToken
+	TwoFactorRequired bool
+}
+
+// TOTPEnrollment contains what an authenticator app needs to enrol
+type TOTPEnrollment struct {
+	Secret string
+	URI    string // otpauth:// URI, suitable as QR code payload
+}
+
+// TOTPActivation is the outcome of confirming a TOTP enrolment
+type TOTPActivation struct {
+	RecoveryCodes []string
+	AccessToken   *AuthToken
+}
+
+type principalContextKey struct{}
+
+// ContextWithPrincipal returns a copy of ctx carrying the principal
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal carried by ctx, if any
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
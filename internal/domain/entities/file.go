@@ -1,14 +1,139 @@
 package entities
 
-import "time"
+import (
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // FileMetadata represents metadata for a stored file
 type FileMetadata struct {
-	ID          string    `json:"id"`
+	ID          uuid.UUID `json:"id"`
 	FileName    string    `json:"file_name"`
 	Bucket      string    `json:"bucket"`
+	Key         string    `json:"key"` // object key in the bucket, including the tenant prefix
 	Size        int64     `json:"size"`
 	ContentType string    `json:"content_type"`
+	SHA256      string    `json:"sha256"` // hex-encoded SHA-256 of the content
+	UploaderID  *int      `json:"uploader_id"`
 	URL         string    `json:"url"`
 	UploadedAt  time.Time `json:"uploaded_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// Image is set once an image file has been processed
+	Image *ImageInfo `json:"image"`
+}
+
+// FileQuery filters and paginates the file catalog; zero values do not filter
+type FileQuery struct {
+	Bucket        string
+	ContentType   string // exact type ("image/png") or type wildcard ("image/*")
+	Name          string // case-insensitive substring of the file name
+	UploaderID    *int
+	UploadedAfter *time.Time
+	// UploadedBefore is exclusive
+	UploadedBefore *time.Time
+	Cursor         *string
+	Limit          int
+}
+
+// FileQueryResult is a page of the file catalog, newest first
+type FileQueryResult struct {
+	Files    []*FileMetadata
+	PageInfo PageInfo
+}
+
+// FileUpload describes a file to upload; Content is read once and streamed to storage
+type FileUpload struct {
+	Bucket      string    // empty for the default bucket
+	FileName    string    // name within the tenant's namespace
+	Content     io.Reader // file content
+	Size        int64     // declared size in bytes, -1 if unknown
+	ContentType string    // empty to detect from the content
+	SHA256      string    // expected hex-encoded SHA-256 of the content, empty to skip verification
+}
+
+// UploadSlot is a reserved location a client uploads a file to directly, without going through the API.
+// The upload is only accepted by storage if it matches the content type, size and checksum of the slot.
+type UploadSlot struct {
+	Bucket      string // empty for the default bucket
+	FileName    string // name within the tenant's namespace
+	ContentType string
+	Size        int64  // exact size in bytes
+	SHA256      string // expected hex-encoded SHA-256 of the content, empty to skip verification
+	Upload      *PresignedRequest
+}
+
+// ByteRange is an inclusive range of byte offsets in a file
+type ByteRange struct {
+	Start int64
+	End   int64
+}
+
+// Length returns the number of bytes in the range
+func (r ByteRange) Length() int64 {
+	return r.End - r.Start + 1
+}
+
+// RangeRequest asks for part of a file whose size may not be known yet: the bytes from Start to End
+// (inclusive, or to the end of the file if End is negative), or the last SuffixLength bytes if it is positive
+type RangeRequest struct {
+	Start        int64
+	End          int64
+	SuffixLength int64
+}
+
+// Resolve returns the bytes the request covers in a file of the given size, or false if it covers none
+func (r RangeRequest) Resolve(size int64) (ByteRange, bool) {
+	if r.SuffixLength > 0 {
+		if size == 0 {
+			return ByteRange{}, false
+		}
+		return ByteRange{Start: max(size-r.SuffixLength, 0), End: size - 1}, true
+	}
+
+	if r.Start >= size {
+		return ByteRange{}, false
+	}
+	end := r.End
+	if end < 0 || end >= size {
+		end = size - 1
+	}
+	return ByteRange{Start: r.Start, End: end}, true
+}
+
+// DownloadOptions make a download conditional or partial
+type DownloadOptions struct {
+	// IfNoneMatch lists entity tags the client has; the download is not modified if any matches
+	IfNoneMatch []string
+	// IfModifiedSince is the modification time of the client's copy; ignored if IfNoneMatch is set
+	IfModifiedSince time.Time
+	// Range asks for part of the file instead of all of it
+	Range *RangeRequest
+}
+
+// FileDownload is the content of a stored file, or of a range of it
+type FileDownload struct {
+	File         *FileMetadata
+	ETag         string // quoted entity tag of the content
+	LastModified time.Time
+	// NotModified is set if the client's copy is current; Content is nil then
+	NotModified bool
+	Content     io.ReadCloser
+	Size        int64      // bytes in Content
+	Range       *ByteRange // the part of the file in Content, nil for all of it
+}
+
+// PresignedRequest is a time-limited request a client sends to storage directly
+type PresignedRequest struct {
+	Method    string
+	URL       string
+	Headers   map[string]string // headers the client must send with exactly these values
+	ExpiresAt time.Time
+}
+
+// ScanResult is the verdict of a malware scan
+type ScanResult struct {
+	Infected bool
+	Threat   string // name of the detected threat, e.g. "Eicar-Test-Signature"
 }
@@ -0,0 +1,64 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrphanReason tells why a stored object is no longer needed
+type OrphanReason string
+
+const (
+	// OrphanUnrecorded is an uploaded or content object that no file record points to
+	OrphanUnrecorded OrphanReason = "unrecorded"
+	// OrphanStaleVariant is a derivative or cached transform of a deleted file
+	OrphanStaleVariant OrphanReason = "stale_variant"
+	// OrphanAbandonedPart is a part of a resumable upload that no longer exists
+	OrphanAbandonedPart OrphanReason = "abandoned_part"
+	// OrphanStaging is an upload left behind while it was moved to its content object
+	OrphanStaging OrphanReason = "staging"
+	// OrphanUnreferenced is a recorded file that no product references
+	OrphanUnreferenced OrphanReason = "unreferenced"
+)
+
+// GCOptions controls a garbage collection run
+type GCOptions struct {
+	Buckets []string
+	// GracePeriod protects objects modified more recently, such as uploads that are not recorded yet
+	GracePeriod time.Duration
+	// DryRun reports the orphans without deleting them
+	DryRun bool
+	// UnreferencedFiles also collects recorded files that no product references, with their records
+	UnreferencedFiles bool
+}
+
+// Orphan is a stored object, or a recorded file, that garbage collection found unneeded
+type Orphan struct {
+	Bucket       string
+	Key          string
+	Size         int64
+	LastModified time.Time
+	Reason       OrphanReason
+	// FileID is the record of unreferenced files
+	FileID *uuid.UUID
+	// Deleted is set once the orphan is gone; it stays unset in dry runs and when deletion fails
+	Deleted bool
+}
+
+// GCReport is the result of a garbage collection run
+type GCReport struct {
+	DryRun bool
+	// Scanned counts the objects listed
+	Scanned int
+	// Recent counts the objects left alone because they are within the grace period
+	Recent int
+	// Ignored counts the objects outside the layout the service stores files in
+	Ignored int
+	Orphans []Orphan
+	// Deleted counts the orphans deleted and FreedBytes their size
+	Deleted    int
+	FreedBytes int64
+	// Failed counts the orphans that could not be deleted
+	Failed int
+}
@@ -0,0 +1,27 @@
+package entities
+
+import "time"
+
+// HealthStatus tells whether a dependency, or the API as a whole, is usable
+type HealthStatus string
+
+const (
+	HealthUp   HealthStatus = "up"
+	HealthDown HealthStatus = "down"
+)
+
+// HealthCheckResult is the outcome of checking one dependency
+type HealthCheckResult struct {
+	Name      string
+	Status    HealthStatus
+	Latency   time.Duration
+	Error     string // why the check failed, if it did; for logs only, as it may name hosts or buckets
+	TimedOut  bool   // whether the check failed by running out of time
+	CheckedAt time.Time
+}
+
+// HealthReport sums up the checks of the dependencies; the API is up only if all of them are
+type HealthReport struct {
+	Status HealthStatus
+	Checks []HealthCheckResult
+}
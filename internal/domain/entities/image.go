@@ -0,0 +1,97 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ImageFit decides how an image is resized to the box of a transform
+type ImageFit string
+
+const (
+	// ImageFitContain scales the image to fit within the box, keeping its aspect ratio
+	ImageFitContain ImageFit = "contain"
+	// ImageFitCover scales the image to cover the box, keeping its aspect ratio, and crops the overflow around the centre
+	ImageFitCover ImageFit = "cover"
+	// ImageFitFill stretches the image to the box
+	ImageFitFill ImageFit = "fill"
+)
+
+// IsValid reports whether the fit is known
+func (f ImageFit) IsValid() bool {
+	switch f {
+	case ImageFitContain, ImageFitCover, ImageFitFill:
+		return true
+	}
+	return false
+}
+
+// ImageFormat is an encoding images are converted to
+type ImageFormat string
+
+const (
+	ImageFormatJPEG ImageFormat = "jpeg"
+	ImageFormatPNG  ImageFormat = "png"
+	// ImageFormatWebP is lossy WebP
+	ImageFormatWebP ImageFormat = "webp"
+)
+
+// IsValid reports whether the format is known
+func (f ImageFormat) IsValid() bool {
+	switch f {
+	case ImageFormatJPEG, ImageFormatPNG, ImageFormatWebP:
+		return true
+	}
+	return false
+}
+
+// ContentType returns the MIME type of the format
+func (f ImageFormat) ContentType() string {
+	return "image/" + string(f)
+}
+
+// ImageFormatFor returns the format that preserves an image of the content type best;
+// GIF images are converted to PNG and unknown types to JPEG
+func ImageFormatFor(contentType string) ImageFormat {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.ToLower(strings.TrimSpace(mediaType)) {
+	case "image/png", "image/gif":
+		return ImageFormatPNG
+	case "image/webp":
+		return ImageFormatWebP
+	}
+	return ImageFormatJPEG
+}
+
+// ImageTransform describes a resized and re-encoded version of an image. A zero width or height is
+// derived from the other one and the aspect ratio; images are never enlarged except to fill.
+type ImageTransform struct {
+	Width  int         `json:"width"`
+	Height int         `json:"height"`
+	Fit    ImageFit    `json:"fit"`
+	Format ImageFormat `json:"format"`
+}
+
+// Name identifies the transform, e.g. "200x200_cover.webp"; transforms of an image with the same name
+// produce the same result
+func (t ImageTransform) Name() string {
+	return fmt.Sprintf("%dx%d_%s.%s", t.Width, t.Height, t.Fit, t.Format)
+}
+
+// ImageInfo describes the content of an image file
+type ImageInfo struct {
+	Width         int    // with the orientation applied
+	Height        int    // with the orientation applied
+	BlurHash      string // compact placeholder of the image, see https://blurha.sh
+	DominantColor string // hex colour such as "#a0b1c2"
+	Derivatives   []ImageDerivative
+}
+
+// ImageDerivative is a version of an image rendered when it is uploaded, such as a thumbnail
+type ImageDerivative struct {
+	Name      string         `json:"name"`
+	Transform ImageTransform `json:"transform"`
+	Width     int            `json:"width"` // actual width of the rendered image
+	Height    int            `json:"height"`
+	Size      int64          `json:"size"`
+}
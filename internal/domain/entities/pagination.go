@@ -3,7 +3,8 @@ package entities
 // Cursor contains pagination metadata for cursor-based pagination
 type Cursor struct {
 	ID        int    `json:"id"`
-	CreatedAt string `json:"created_at"` // RFC3339 format
+	UUID      string `json:"uuid,omitempty"` // ID of types with UUID identifiers
+	CreatedAt string `json:"created_at"`     // RFC3339 format
 }
 
 // PageInfo contains pagination metadata in the response
@@ -33,10 +33,51 @@ type Product struct {
 	Status      ProductStatus
 	CategoryID  *uuid.UUID    // optional category reference
 	BrandID     *uuid.UUID    // optional brand association
+	Media       []*ProductMedia // gallery of stored files, in display order
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 }
 
+// MediaType is the kind of a product media file
+type MediaType string
+
+const (
+	MediaTypeImage    MediaType = "image"
+	MediaTypeVideo    MediaType = "video"
+	MediaTypeDocument MediaType = "document"
+)
+
+// MediaTypeFor returns the media type of a file with the given content type
+func MediaTypeFor(contentType string) MediaType {
+	switch {
+	case strings.HasPrefix(contentType, "image/"):
+		return MediaTypeImage
+	case strings.HasPrefix(contentType, "video/"):
+		return MediaTypeVideo
+	default:
+		return MediaTypeDocument
+	}
+}
+
+// IsValid checks if the media type is known
+func (t MediaType) IsValid() bool {
+	return t == MediaTypeImage || t == MediaTypeVideo || t == MediaTypeDocument
+}
+
+// ProductMedia links a stored file to a product's gallery
+type ProductMedia struct {
+	ID        uuid.UUID
+	ProductID int
+	FileID    uuid.UUID
+	Position  int // 0-based position in the gallery
+	AltText   string
+	IsPrimary bool
+	Type      MediaType
+	File      *FileMetadata // the linked file, with its URL when loaded through the product
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
 // IsValid checks if the product status is valid
 func (p *Product) IsValid() bool {
 	return p.Status == ProductStatusDraft ||
@@ -71,3 +112,11 @@ func GenerateSlug(s string) string {
 
 	return slug
 }
+
+// ProductStats counts the products of a catalog
+type ProductStats struct {
+	Total      int
+	ByStatus   map[ProductStatus]int
+	ByCategory map[uuid.UUID]int // products without a category are counted under uuid.Nil
+	ByBrand    map[uuid.UUID]int // products without a brand are counted under uuid.Nil
+}
@@ -0,0 +1,17 @@
+package entities
+
+import "context"
+
+type requestIDContextKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying the ID of the request it handles, so that log lines
+// and errors can be correlated with the request
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestIDFromContext returns the ID of the request ctx handles, if any
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	requestID, ok := ctx.Value(requestIDContextKey{}).(string)
+	return requestID, ok && requestID != ""
+}
@@ -0,0 +1,32 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ResumableUpload is a file uploaded in chunks over several requests, so that an interrupted upload
+// continues where it stopped. Received bytes are kept as parts in storage until the upload is complete.
+type ResumableUpload struct {
+	ID          uuid.UUID
+	Bucket      string // empty for the default bucket
+	FileName    string // name within the tenant's namespace
+	ContentType string // empty to detect from the content
+	SHA256      string // expected hex-encoded SHA-256 of the content, empty to skip verification
+	Length      int64  // total size in bytes
+	Offset      int64  // bytes received so far
+	// Metadata is the metadata the client sent with the upload, returned to it as is
+	Metadata  map[string]string
+	Parts     []UploadPart
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// UploadPart is a stored piece of a resumable upload
+type UploadPart struct {
+	Name   string `json:"name"`   // object name in the upload's bucket
+	Offset int64  `json:"offset"` // position of the first byte in the upload
+	Size   int64  `json:"size"`
+}
@@ -0,0 +1,78 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SeedOptions controls the generation of a catalog
+type SeedOptions struct {
+	// Seed makes the generation repeatable: the same options generate the same catalog
+	Seed uint64
+	// CategoryDepth is the number of levels of the category tree; 0 creates no categories
+	CategoryDepth int
+	// CategoryFanOut is the number of root categories and of children of every other category
+	CategoryFanOut int
+	Brands         int
+	Products       int
+	// Skew is the exponent of the Zipf distribution products are spread over categories and brands with;
+	// it must be above 1, and the higher it is the more a few of them dominate. 0 spreads products evenly.
+	Skew float64
+	// Images is the number of placeholder images stored and shared by the products; with none, products
+	// link to images outside of storage
+	Images int
+	// ImageBucket is the bucket placeholder images are stored in, empty for the default bucket
+	ImageBucket string
+	// MinImages is the number of images every product gets at least, so that it can be published
+	MinImages int
+	// BatchSize is the number of products created at once, 1000 if zero
+	BatchSize int
+	// KeepProducts returns the generated products with the catalog; large catalogs do without
+	KeepProducts bool
+	// Now is the time product timestamps lead up to, the current time if zero
+	Now time.Time
+}
+
+// SeededCatalog is what a seeding run created or found in place
+type SeededCatalog struct {
+	// Categories lists the category tree level by level, starting with the roots
+	Categories []*Category
+	// Leaves are the categories without children, which products are assigned to
+	Leaves []*Category
+	Brands []*Brand
+	Images []*FileMetadata
+	// Products are the products added, if kept
+	Products []*Product
+	// Added is the number of products added and Total the number of products in the catalog afterwards
+	Added int
+	Total int
+}
+
+// Subtree returns the ID of a seeded category and the IDs of its descendants
+func (c *SeededCatalog) Subtree(id uuid.UUID) []uuid.UUID {
+	ids := []uuid.UUID{id}
+	for i := 0; i < len(ids); i++ {
+		for _, category := range c.Categories {
+			if category.ParentID != nil && *category.ParentID == ids[i] {
+				ids = append(ids, category.ID)
+			}
+		}
+	}
+	return ids
+}
+
+// ProductsUnder returns the kept products assigned to a seeded category or to one of its descendants
+func (c *SeededCatalog) ProductsUnder(id uuid.UUID) []*Product {
+	subtree := make(map[uuid.UUID]bool)
+	for _, descendant := range c.Subtree(id) {
+		subtree[descendant] = true
+	}
+	var products []*Product
+	for _, product := range c.Products {
+		if product.CategoryID != nil && subtree[*product.CategoryID] {
+			products = append(products, product)
+		}
+	}
+	return products
+}
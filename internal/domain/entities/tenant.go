@@ -0,0 +1,30 @@
+package entities
+
+import (
+	"context"
+	"regexp"
+)
+
+// DefaultTenantID is the tenant that existing data and unscoped requests belong to
+const DefaultTenantID = "default"
+
+// tenantIDPattern restricts tenant IDs to lowercase slugs, since they are used in storage keys
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// IsValidTenantID checks if the tenant ID is a lowercase slug of at most 63 characters
+func IsValidTenantID(tenantID string) bool {
+	return tenantIDPattern.MatchString(tenantID)
+}
+
+type tenantContextKey struct{}
+
+// ContextWithTenant returns a copy of ctx scoped to the tenant
+func ContextWithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant ctx is scoped to, if any
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantContextKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
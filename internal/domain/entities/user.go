@@ -1,8 +1,38 @@
 package entities
 
+import "time"
+
 // User represents a domain entity
 type User struct {
 	ID    int
 	Name  string
-	Age int
+	Age   int
+	Email string
+	Role  Role
+
+	// TenantID is the tenant whose catalog the user works on
+	TenantID string
+
+	// PasswordHash is the hashed first-factor credential (empty if the user cannot log in)
+	PasswordHash string
+
+	// TOTPSecret is the base32 RFC 6238 shared secret, set as soon as enrolment starts
+	TOTPSecret string
+	// TOTPEnabled is true once the user has confirmed enrolment with a valid code
+	TOTPEnabled bool
+	// TOTPLastStep is the last accepted time step, used to reject replayed codes
+	TOTPLastStep int64
+	// RecoveryCodes holds hashes of the unused one-time recovery codes
+	RecoveryCodes []string
+	// TOTPChallengeID identifies the login challenge that may still be answered, once; empty if there is none
+	TOTPChallengeID string
+	// TOTPFailedAttempts counts the second factors rejected at login since the last accepted one
+	TOTPFailedAttempts int
+	// TOTPLockedUntil is the time until which login challenges are refused after too many rejected attempts
+	TOTPLockedUntil time.Time
+}
+
+// CanLogin checks if the user has credentials to authenticate with
+func (u *User) CanLogin() bool {
+	return u.Email != "" && u.PasswordHash != ""
 }
@@ -3,6 +3,7 @@ package errors
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 // Domain errors
@@ -24,6 +25,18 @@ var (
 
 	// ErrInternal indicates an internal server error
 	ErrInternal = errors.New("internal error")
+
+	// ErrTenantRequired indicates that an operation on tenant data was attempted without a tenant
+	ErrTenantRequired = errors.New("tenant required")
+
+	// ErrRangeNotSatisfiable indicates that a requested byte range lies outside the content
+	ErrRangeNotSatisfiable = errors.New("range not satisfiable")
+
+	// ErrNotSupported indicates that an adapter does not support an operation
+	ErrNotSupported = errors.New("not supported")
+
+	// ErrConflict indicates that a request conflicts with the current state of a resource
+	ErrConflict = errors.New("conflict")
 )
 
 // NotFoundError represents a resource not found error with additional context
@@ -48,26 +61,52 @@ func NewNotFoundError(resource string, id interface{}) error {
 	}
 }
 
-// ValidationError represents a validation error with field details
-type ValidationError struct {
+// FieldError describes why a single field is invalid
+type FieldError struct {
 	Field   string
+	Code    string // machine-readable, e.g. "price.must_be_positive"
 	Message string
 }
 
+// ValidationError represents a validation error with one or more field errors
+type ValidationError struct {
+	Errors []FieldError
+}
+
 func (e *ValidationError) Error() string {
-	return fmt.Sprintf("validation error on field '%s': %s", e.Field, e.Message)
+	parts := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		parts[i] = fmt.Sprintf("field '%s': %s", fe.Field, fe.Message)
+	}
+	return "validation error on " + strings.Join(parts, "; ")
 }
 
 func (e *ValidationError) Is(target error) bool {
 	return target == ErrInvalidInput
 }
 
-// NewValidationError creates a new ValidationError
-func NewValidationError(field, message string) error {
-	return &ValidationError{
+// Add records an error for the field; code is the reason, e.g. "must_be_positive"
+func (e *ValidationError) Add(field, code, message string) {
+	e.Errors = append(e.Errors, FieldError{
 		Field:   field,
+		Code:    field + "." + code,
 		Message: message,
+	})
+}
+
+// ErrOrNil returns the error if any field errors were added, nil otherwise
+func (e *ValidationError) ErrOrNil() error {
+	if len(e.Errors) == 0 {
+		return nil
 	}
+	return e
+}
+
+// NewValidationError creates a new ValidationError for a single field
+func NewValidationError(field, code, message string) error {
+	e := &ValidationError{}
+	e.Add(field, code, message)
+	return e
 }
 
 // DuplicateError represents a duplicate entry error
@@ -93,3 +132,39 @@ func NewDuplicateError(resource, field string, value interface{}) error {
 		Value:    value,
 	}
 }
+
+// RangeNotSatisfiableError represents a byte range that lies outside a file of the given size
+type RangeNotSatisfiableError struct {
+	Size int64
+}
+
+func (e *RangeNotSatisfiableError) Error() string {
+	return fmt.Sprintf("range not satisfiable for a file of %d bytes", e.Size)
+}
+
+func (e *RangeNotSatisfiableError) Is(target error) bool {
+	return target == ErrRangeNotSatisfiable
+}
+
+// NewRangeNotSatisfiableError creates a new RangeNotSatisfiableError
+func NewRangeNotSatisfiableError(size int64) error {
+	return &RangeNotSatisfiableError{Size: size}
+}
+
+// UploadOffsetError represents a chunk sent for an offset other than the one a resumable upload is at
+type UploadOffsetError struct {
+	Offset int64
+}
+
+func (e *UploadOffsetError) Error() string {
+	return fmt.Sprintf("upload is at offset %d", e.Offset)
+}
+
+func (e *UploadOffsetError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// NewUploadOffsetError creates a new UploadOffsetError
+func NewUploadOffsetError(offset int64) error {
+	return &UploadOffsetError{Offset: offset}
+}
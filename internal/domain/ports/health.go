@@ -0,0 +1,11 @@
+package ports
+
+import "context"
+
+// HealthChecker defines the interface for checking that a dependency of the API is usable
+type HealthChecker interface {
+	// Name identifies the check in health reports, e.g. "database"
+	Name() string
+	// Check returns an error if the dependency cannot be used; it should give up once ctx is done
+	Check(ctx context.Context) error
+}
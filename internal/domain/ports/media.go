@@ -0,0 +1,21 @@
+package ports
+
+import (
+	"image"
+	"io"
+
+	"example.com/go-yippi/internal/domain/entities"
+)
+
+// ImageProcessor defines the interface for decoding, analysing and re-encoding images
+type ImageProcessor interface {
+	// Supports reports whether images of the content type can be decoded
+	Supports(contentType string) bool
+	// Decode decodes an image with its EXIF orientation applied
+	Decode(r io.Reader) (image.Image, error)
+	// Analyze returns the dimensions, blurhash and dominant colour of an image
+	Analyze(img image.Image) *entities.ImageInfo
+	// Transform resizes an image and writes it in the format of the transform, without metadata such as EXIF;
+	// it returns the dimensions of the written image
+	Transform(img image.Image, transform entities.ImageTransform, w io.Writer) (int, int, error)
+}
@@ -3,16 +3,21 @@ package ports
 import (
 	"context"
 	"io"
+	"time"
 
 	"example.com/go-yippi/internal/domain/entities"
 	"github.com/google/uuid"
 )
 
-// UserRepository defines the interface for user data operations
+// UserRepository defines the interface for user data operations. Users are not scoped to the tenant in
+// the context; they belong to one, and callers filter by it.
 type UserRepository interface {
 	Create(ctx context.Context, user *entities.User) error
 	GetByID(ctx context.Context, id int) (*entities.User, error)
+	GetByEmail(ctx context.Context, email string) (*entities.User, error)
 	List(ctx context.Context) ([]*entities.User, error)
+	// ListByTenant lists the users that belong to a tenant
+	ListByTenant(ctx context.Context, tenantID string) ([]*entities.User, error)
 	Update(ctx context.Context, user *entities.User) error
 	Delete(ctx context.Context, id int) error
 }
@@ -26,8 +31,18 @@ type ProductRepository interface {
 	Update(ctx context.Context, product *entities.Product) error
 	Delete(ctx context.Context, id int) error
 
+	// CreateBulk creates many products at once, all or none. Where the database supports it, rows are
+	// streamed without returning them, so the IDs of the products are only filled in on other databases.
+	CreateBulk(ctx context.Context, products []*entities.Product) error
+	// IDsBySKU returns the IDs of the products with the SKUs, keyed by SKU; unknown SKUs are left out
+	IDsBySKU(ctx context.Context, skus []string) (map[string]int, error)
+
 	// Query performs a flexible query with filters, sorting, and pagination
 	Query(ctx context.Context, params *entities.QueryParams) (*entities.QueryResult, error)
+	// Stats counts the products by status, category and brand
+	Stats(ctx context.Context) (*entities.ProductStats, error)
+	// ImageURLs returns the legacy image URLs of all products, so that the files they link stay in use
+	ImageURLs(ctx context.Context) ([]string, error)
 
 	// Legacy methods (can be deprecated in favor of Query)
 	List(ctx context.Context) ([]*entities.Product, error)
@@ -56,6 +71,71 @@ type BrandRepository interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
+// FileRepository defines the interface for the catalog of stored files
+type FileRepository interface {
+	// Create records a file; a preset ID and upload time are kept
+	Create(ctx context.Context, file *entities.FileMetadata) error
+	GetByID(ctx context.Context, id uuid.UUID) (*entities.FileMetadata, error)
+	// GetByName returns the file a client addresses by name in a bucket
+	GetByName(ctx context.Context, bucket, fileName string) (*entities.FileMetadata, error)
+	// ListByKey returns the files stored in an object; content-addressed objects are shared by several files
+	ListByKey(ctx context.Context, bucket, key string) ([]*entities.FileMetadata, error)
+	Update(ctx context.Context, file *entities.FileMetadata) error
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// UpdateImage records the image info of a file without counting as a replacement. It returns a
+	// NotFoundError if the file was replaced by other content since it was read.
+	UpdateImage(ctx context.Context, file *entities.FileMetadata) error
+
+	// Query returns a page of files matching the query, newest first
+	Query(ctx context.Context, query *entities.FileQuery) (*entities.FileQueryResult, error)
+}
+
+// ProductMediaRepository defines the interface for product media gallery operations.
+// Listed media are ordered by position and include their file.
+type ProductMediaRepository interface {
+	Create(ctx context.Context, media *entities.ProductMedia) error
+	// CreateBulk creates many media at once, all or none
+	CreateBulk(ctx context.Context, media []*entities.ProductMedia) error
+	GetByID(ctx context.Context, id uuid.UUID) (*entities.ProductMedia, error)
+	ListByProduct(ctx context.Context, productID int) ([]*entities.ProductMedia, error)
+	ListByProducts(ctx context.Context, productIDs []int) ([]*entities.ProductMedia, error)
+	Update(ctx context.Context, media *entities.ProductMedia) error
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// CountByFile returns how many products use the file
+	CountByFile(ctx context.Context, fileID uuid.UUID) (int, error)
+	// DeleteByFile detaches the file from every product
+	DeleteByFile(ctx context.Context, fileID uuid.UUID) error
+}
+
+// ContentObjectRepository counts the references to content-addressed objects, which identical files share
+type ContentObjectRepository interface {
+	// Acquire adds a reference to an object. It waits for a concurrent release of the last reference to
+	// finish, so afterwards the object is either kept or gone for good.
+	Acquire(ctx context.Context, bucket, key string) error
+	// Release drops a reference to an object. When the last one is dropped, remove is called to delete the
+	// object before the count is, so that no reference is acquired meanwhile; the reference is kept if
+	// remove fails. Releasing an object without references does nothing.
+	Release(ctx context.Context, bucket, key string, remove func() error) error
+	// Delete removes an object whose references have not changed since idleSince, like Release removes one,
+	// and reports whether it did. It clears references leaked by uploads that failed before being recorded.
+	Delete(ctx context.Context, bucket, key string, idleSince time.Time, remove func() error) (bool, error)
+}
+
+// ResumableUploadRepository defines the interface for the state of resumable uploads
+type ResumableUploadRepository interface {
+	Create(ctx context.Context, upload *entities.ResumableUpload) error
+	GetByID(ctx context.Context, id uuid.UUID) (*entities.ResumableUpload, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// AppendPart records a stored part at the end of an upload and moves its expiry. It returns an
+	// UploadOffsetError if the upload is no longer at the part's offset, e.g. after a concurrent chunk.
+	AppendPart(ctx context.Context, id uuid.UUID, part entities.UploadPart, expiresAt time.Time) error
+	// ListExpired returns the uploads that expired before the given time
+	ListExpired(ctx context.Context, before time.Time) ([]*entities.ResumableUpload, error)
+}
+
 // StorageRepository defines the interface for file storage operations
 type StorageRepository interface {
 	// Store uploads a file to storage and returns metadata
@@ -64,12 +144,38 @@ type StorageRepository interface {
 	// Remove deletes a file from storage
 	Remove(ctx context.Context, bucket, fileName string) error
 
-	// GetURL generates a public URL for accessing the file
+	// RemovePrefix deletes every file whose name starts with prefix
+	RemovePrefix(ctx context.Context, bucket, prefix string) error
+
+	// Copy copies a file within a bucket, replacing the destination if it exists
+	Copy(ctx context.Context, bucket, src, dst string) error
+
+	// Compose concatenates the parts, in order, into a file of the content type, replacing it if it exists,
+	// and returns its metadata. Backends that can, compose the file without transferring the content; the
+	// parts are kept.
+	Compose(ctx context.Context, bucket, fileName string, parts []entities.UploadPart, contentType string) (*entities.FileMetadata, error)
+
+	// List calls fn with the metadata of every file whose name starts with prefix, in no particular order;
+	// the metadata has the name, size and modification time. Listing stops at the first error fn returns.
+	List(ctx context.Context, bucket, prefix string, fn func(*entities.FileMetadata) error) error
+
+	// GetURL generates a public URL for accessing the file; fileName is the name clients address the file by
 	GetURL(ctx context.Context, bucket, fileName string) (string, error)
 
-	// GetFile retrieves a file from storage and returns its content
-	GetFile(ctx context.Context, bucket, fileName string) (io.ReadCloser, int64, string, error)
+	// GetFile retrieves a file from storage and returns its content, the size of the content and the
+	// content type. If rng is not nil, only that range of the file is returned.
+	GetFile(ctx context.Context, bucket, fileName string, rng *entities.ByteRange) (io.ReadCloser, int64, string, error)
 
 	// EnsureBucket creates a bucket if it doesn't exist
 	EnsureBucket(ctx context.Context, bucket string) error
+
+	// Stat returns the metadata of a stored file, or a NotFoundError if it does not exist
+	Stat(ctx context.Context, bucket, fileName string) (*entities.FileMetadata, error)
+
+	// PresignUpload generates a PUT request that uploads a file directly to storage. The content type,
+	// size and SHA-256 (hex, if not empty) are signed, so storage rejects content that does not match.
+	PresignUpload(ctx context.Context, bucket, fileName, contentType string, size int64, sha256 string, expiry time.Duration) (*entities.PresignedRequest, error)
+
+	// PresignDownload generates a GET request that downloads a file directly from storage
+	PresignDownload(ctx context.Context, bucket, fileName string, expiry time.Duration) (*entities.PresignedRequest, error)
 }
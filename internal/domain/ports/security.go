@@ -0,0 +1,38 @@
+package ports
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"example.com/go-yippi/internal/domain/entities"
+)
+
+// PasswordHasher defines the interface for hashing and checking passwords
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Compare returns nil if the password matches the hash
+	Compare(hash, password string) error
+}
+
+// TokenManager defines the interface for issuing and verifying signed tokens
+type TokenManager interface {
+	Issue(claims entities.TokenClaims) (string, error)
+	// Verify checks the signature, expiry and type of a token and returns its claims
+	Verify(token string, tokenType entities.TokenType) (*entities.TokenClaims, error)
+}
+
+// TOTPProvider defines the interface for RFC 6238 time-based one-time passwords
+type TOTPProvider interface {
+	GenerateSecret() (string, error)
+	// URI builds the otpauth:// URI an authenticator app enrols from
+	URI(secret, accountName string) string
+	// Validate checks a code at the given time and returns the matching time step
+	Validate(secret, code string, at time.Time) (int64, bool)
+}
+
+// FileScanner defines the interface for scanning file content for malware
+type FileScanner interface {
+	// Scan reads the content to its end; an error means the content could not be scanned, not that it is infected
+	Scan(ctx context.Context, content io.Reader) (*entities.ScanResult, error)
+}
@@ -22,6 +22,18 @@ type ProductService interface {
 	PublishProduct(ctx context.Context, id int) error
 	ArchiveProduct(ctx context.Context, id int) error
 	QueryProducts(ctx context.Context, params *entities.QueryParams) (*entities.QueryResult, error)
+	// ImportProducts validates the products like CreateProduct and creates them all at once, or none,
+	// followed by their galleries
+	ImportProducts(ctx context.Context, products []*entities.Product) error
+	ProductStats(ctx context.Context) (*entities.ProductStats, error)
+
+	// AttachMedia adds a stored file to the end of the product's gallery
+	AttachMedia(ctx context.Context, productID int, media *entities.ProductMedia) error
+	// UpdateMedia changes the alt text and primary flag of a gallery item
+	UpdateMedia(ctx context.Context, productID int, media *entities.ProductMedia) error
+	// ReorderMedia puts the product's gallery in the given order, which must list every item once
+	ReorderMedia(ctx context.Context, productID int, mediaIDs []uuid.UUID) ([]*entities.ProductMedia, error)
+	DetachMedia(ctx context.Context, productID int, mediaID uuid.UUID) error
 }
 
 // CategoryService defines the interface for category business logic operations
@@ -47,8 +59,80 @@ type BrandService interface {
 
 // StorageService defines the interface for file storage operations
 type StorageService interface {
-	UploadFile(ctx context.Context, bucket, fileName string, reader io.Reader, size int64, contentType string) (*entities.FileMetadata, error)
+	UploadFile(ctx context.Context, upload *entities.FileUpload) (*entities.FileMetadata, error)
 	DeleteFile(ctx context.Context, bucket, fileName string) error
 	GetFileURL(ctx context.Context, bucket, fileName string) (string, error)
-	DownloadFile(ctx context.Context, bucket, fileName string) (io.ReadCloser, int64, string, error)
+	// DownloadFile retrieves a file, or the range of it the options ask for, unless the client's copy is current
+	DownloadFile(ctx context.Context, bucket, fileName string, opts entities.DownloadOptions) (*entities.FileDownload, error)
+
+	// CreateUploadSlot presigns a direct upload to storage and fills in slot.Upload
+	CreateUploadSlot(ctx context.Context, slot *entities.UploadSlot) error
+	// ConfirmUpload verifies that a direct upload completed and returns the metadata of the file
+	ConfirmUpload(ctx context.Context, bucket, fileName string) (*entities.FileMetadata, error)
+	// ComposeFile stores the upload as a file made of parts already in storage, in order, and returns its
+	// metadata; the content of the upload is not read
+	ComposeFile(ctx context.Context, upload *entities.FileUpload, parts []entities.UploadPart) (*entities.FileMetadata, error)
+	// PresignDownload presigns a direct download from storage
+	PresignDownload(ctx context.Context, bucket, fileName string) (*entities.PresignedRequest, error)
+
+	GetFile(ctx context.Context, id uuid.UUID) (*entities.FileMetadata, error)
+	ListFiles(ctx context.Context, query *entities.FileQuery) (*entities.FileQueryResult, error)
+	DeleteFileByID(ctx context.Context, id uuid.UUID) error
+}
+
+// ResumableUploadService defines the interface for files uploaded in chunks over several requests
+type ResumableUploadService interface {
+	// CreateUpload validates and records a new upload, filling in its ID and expiry
+	CreateUpload(ctx context.Context, upload *entities.ResumableUpload) error
+	GetUpload(ctx context.Context, id uuid.UUID) (*entities.ResumableUpload, error)
+	// WriteChunk appends content at offset, which must be the upload's offset. The file is returned once
+	// the upload is complete and has been stored.
+	WriteChunk(ctx context.Context, id uuid.UUID, offset int64, content io.Reader) (*entities.ResumableUpload, *entities.FileMetadata, error)
+	// TerminateUpload discards an upload and the content received for it
+	TerminateUpload(ctx context.Context, id uuid.UUID) error
+}
+
+// ImageService defines the interface for the derivatives and on-the-fly transforms of image files
+type ImageService interface {
+	// Process discards the derivatives and cached transforms of a file whose content was stored and,
+	// if it is an image, schedules its analysis and the rendering of its derivatives
+	Process(ctx context.Context, file *entities.FileMetadata) error
+	// Transform returns a resized and re-encoded version of an image file, with its content type
+	Transform(ctx context.Context, id uuid.UUID, transform entities.ImageTransform) (io.ReadCloser, int64, string, error)
+	// RemoveVariants deletes the derivatives and cached transforms of a file
+	RemoveVariants(ctx context.Context, file *entities.FileMetadata) error
+}
+
+// GarbageCollector defines the interface for removing stored objects that nothing needs anymore
+type GarbageCollector interface {
+	// Collect finds the orphaned objects in the buckets of the options and deletes them unless it is a dry run
+	Collect(ctx context.Context, opts entities.GCOptions) (*entities.GCReport, error)
+}
+
+// CatalogSeeder defines the interface for generating realistic catalogs, for development and tests
+type CatalogSeeder interface {
+	// Seed creates the categories, brands and placeholder images of the options that do not exist yet and
+	// adds products to the catalog
+	Seed(ctx context.Context, opts entities.SeedOptions) (*entities.SeededCatalog, error)
+}
+
+// HealthService defines the interface for reporting whether the API can serve requests
+type HealthService interface {
+	// Ready checks the dependencies of the API; results may be reused for a short while
+	Ready(ctx context.Context) *entities.HealthReport
+}
+
+// AuthService defines the interface for authentication and two-factor operations
+type AuthService interface {
+	// Login verifies the first factor and returns either an access token or a 2FA challenge
+	Login(ctx context.Context, email, password string) (*entities.LoginResult, error)
+	// VerifyLoginChallenge completes a login step-up with a TOTP or recovery code
+	VerifyLoginChallenge(ctx context.Context, challengeToken, code string) (*entities.AuthToken, error)
+	// Authenticate resolves an access token into the effective principal
+	Authenticate(ctx context.Context, accessToken string) (*entities.Principal, error)
+
+	EnrollTOTP(ctx context.Context, userID int) (*entities.TOTPEnrollment, error)
+	ActivateTOTP(ctx context.Context, userID int, code string) (*entities.TOTPActivation, error)
+	DisableTOTP(ctx context.Context, userID int, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error)
 }
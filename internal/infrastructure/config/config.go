@@ -1,68 +1,239 @@
 package config
 
-import "os"
+import (
+	"time"
+)
 
-// Config holds application configuration
+// Config holds application configuration. Every setting has a default, which a YAML file, the environment
+// and command-line flags override in that order; see Load.
+//
+// Settings are described by their tags: yaml names the key in the file, env the environment variable the
+// flag name is derived from, size marks byte sizes written with a KB, MB or GB suffix and secret marks
+// values that are redacted when printed and may be read from the file named by the variable with a _FILE
+// suffix.
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	MinIO    MinIOConfig
-	Storage  StorageConfig
+	Environment string `yaml:"environment" env:"APP_ENV"` // "development" or "production"
+
+	Server   ServerConfig   `yaml:"server"`
+	Database DatabaseConfig `yaml:"database"`
+	MinIO    MinIOConfig    `yaml:"minio"`
+	Storage  StorageConfig  `yaml:"storage"`
+	Auth     AuthConfig     `yaml:"auth"`
+	Tenant   TenantConfig   `yaml:"tenant"`
+	Catalog  CatalogConfig  `yaml:"catalog"`
+	Image    ImageConfig    `yaml:"image"`
+	Metrics  MetricsConfig  `yaml:"metrics"`
+	Tracing  TracingConfig  `yaml:"tracing"`
+	Log      LogConfig      `yaml:"log"`
+	Health   HealthConfig   `yaml:"health"`
 }
 
+const (
+	EnvironmentDevelopment = "development"
+	EnvironmentProduction  = "production"
+)
+
 type ServerConfig struct {
-	Port string
-	Host string
+	Port            string        `yaml:"port" env:"SERVER_PORT"`
+	Host            string        `yaml:"host" env:"SERVER_HOST"`
+	ReadTimeout     time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT"`         // longest reading a request may take, including streamed bodies; 0 for none
+	WriteTimeout    time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`       // longest writing a response may take, including downloads; 0 for none
+	IdleTimeout     time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`         // how long keep-alive connections wait for the next request
+	BodyLimit       int64         `yaml:"body_limit" env:"SERVER_BODY_LIMIT" size:"true"` // largest request body held in memory; larger uploads are streamed and bounded by the upload limits
+	Concurrency     int           `yaml:"concurrency" env:"SERVER_CONCURRENCY"`           // most connections served at once
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"` // how long shutdown may take, in-flight requests and queued images included
 }
 
 type DatabaseConfig struct {
-	Driver string
-	DSN    string
+	Driver               string        `yaml:"driver" env:"DB_DRIVER"` // "postgres" or "sqlite3"
+	DSN                  string        `yaml:"dsn" env:"DB_DSN" secret:"true"`
+	Migrations           string        `yaml:"migrations" env:"DB_MIGRATIONS"`                         // "apply" pending migrations on startup or only "verify" there are none
+	MigrationLockTimeout time.Duration `yaml:"migration_lock_timeout" env:"DB_MIGRATION_LOCK_TIMEOUT"` // how long to wait for another process migrating the database
 }
 
 type MinIOConfig struct {
-	Endpoint        string
-	AccessKeyID     string
-	SecretAccessKey string
-	UseSSL          bool
-	BucketName      string
+	Endpoint        string `yaml:"endpoint" env:"MINIO_ENDPOINT"`
+	AccessKeyID     string `yaml:"access_key" env:"MINIO_ACCESS_KEY" secret:"true"`
+	SecretAccessKey string `yaml:"secret_key" env:"MINIO_SECRET_KEY" secret:"true"`
+	UseSSL          bool   `yaml:"use_ssl" env:"MINIO_USE_SSL"`
+	BucketName      string `yaml:"bucket_name" env:"MINIO_BUCKET_NAME"`
+	Region          string `yaml:"region" env:"MINIO_REGION"`
+	PublicEndpoint  string `yaml:"public_endpoint" env:"MINIO_PUBLIC_ENDPOINT"` // endpoint clients use for presigned URLs, if it differs from Endpoint
 }
 
 type StorageConfig struct {
-	Backend string // "database" or "minio"
+	Backend             string              `yaml:"backend" env:"STORAGE_BACKEND"`                                         // "minio", "filesystem" or "database"
+	Path                string              `yaml:"path" env:"STORAGE_PATH"`                                               // root directory of the filesystem backend
+	MaxUploadSize       int64               `yaml:"max_upload_size" env:"UPLOAD_MAX_SIZE" size:"true"`                     // bytes, 0 for unlimited
+	MaxUploadSizeBucket map[string]int64    `yaml:"max_upload_size_by_bucket" env:"UPLOAD_MAX_SIZE_BY_BUCKET" size:"true"` // per-bucket limits
+	MaxUploadSizeType   map[string]int64    `yaml:"max_upload_size_by_type" env:"UPLOAD_MAX_SIZE_BY_TYPE" size:"true"`     // per-content-type limits ("image/png" or "image/*")
+	AllowedTypes        []string            `yaml:"allowed_types" env:"UPLOAD_ALLOWED_TYPES"`                              // content types accepted in buckets without their own list, empty for all
+	AllowedTypesBucket  map[string][]string `yaml:"allowed_types_by_bucket" env:"UPLOAD_ALLOWED_TYPES_BY_BUCKET"`          // per-bucket accepted content types
+	BlockedTypes        []string            `yaml:"blocked_types" env:"UPLOAD_BLOCKED_TYPES"`                              // content types rejected in every bucket
+	QuarantineBucket    string              `yaml:"quarantine_bucket" env:"UPLOAD_QUARANTINE_BUCKET"`                      // bucket flagged files are moved to, empty to delete them
+	Scanner             string              `yaml:"scanner" env:"UPLOAD_SCANNER"`                                          // "none", "clamd" or "fake"
+	ClamdAddress        string              `yaml:"clamd_address" env:"CLAMD_ADDRESS"`                                     // tcp://host:port or unix:///path of clamd
+	ScanTimeout         time.Duration       `yaml:"scan_timeout" env:"UPLOAD_SCAN_TIMEOUT"`                                // longest a scan of one file may take
+	PresignExpiry       time.Duration       `yaml:"presign_expiry" env:"UPLOAD_PRESIGN_EXPIRY"`                            // validity of direct upload and download URLs
+	ResumablePartSize   int64               `yaml:"resumable_part_size" env:"UPLOAD_RESUMABLE_PART_SIZE" size:"true"`      // bytes of resumable upload chunks stored at a time
+	ResumableExpiry     time.Duration       `yaml:"resumable_expiry" env:"UPLOAD_RESUMABLE_EXPIRY"`                        // how long resumable uploads are kept without receiving content
+	DeleteInUse         string              `yaml:"delete_in_use" env:"FILE_DELETE_IN_USE"`                                // "block" or "cascade" the deletion of files used by products
+	Dedup               bool                `yaml:"dedup" env:"STORAGE_DEDUP"`                                             // store identical uploads of a tenant once, keyed by SHA-256
+	GCGracePeriod       time.Duration       `yaml:"gc_grace_period" env:"GC_GRACE_PERIOD"`                                 // age objects must reach before garbage collection deletes them
+	CacheControl        string              `yaml:"cache_control" env:"FILE_CACHE_CONTROL"`                                // Cache-Control header of downloads
+	CacheControlBucket  map[string]string   `yaml:"cache_control_by_bucket" env:"FILE_CACHE_CONTROL_BY_BUCKET"`            // per-bucket Cache-Control headers
+}
+
+type AuthConfig struct {
+	TokenSecret            string        `yaml:"token_secret" env:"AUTH_TOKEN_SECRET" secret:"true"`
+	Issuer                 string        `yaml:"issuer" env:"AUTH_ISSUER"`
+	AccessTokenTTL         time.Duration `yaml:"access_token_ttl" env:"AUTH_ACCESS_TOKEN_TTL"`
+	ChallengeTokenTTL      time.Duration `yaml:"challenge_token_ttl" env:"AUTH_CHALLENGE_TOKEN_TTL"`
+	TOTPMaxAttempts        int           `yaml:"totp_max_attempts" env:"AUTH_TOTP_MAX_ATTEMPTS"` // second factors rejected at login before challenges are refused
+	TOTPLockout            time.Duration `yaml:"totp_lockout" env:"AUTH_TOTP_LOCKOUT"`           // how long challenges are refused after too many rejected second factors
+	SensitiveRoles         []string      `yaml:"sensitive_roles" env:"AUTH_SENSITIVE_ROLES"`     // roles that must have 2FA enabled before their permissions apply
+	BootstrapAdminEmail    string        `yaml:"bootstrap_admin_email" env:"AUTH_BOOTSTRAP_ADMIN_EMAIL"`
+	BootstrapAdminPassword string        `yaml:"bootstrap_admin_password" env:"AUTH_BOOTSTRAP_ADMIN_PASSWORD" secret:"true"`
+}
+
+type TenantConfig struct {
+	Header  string `yaml:"header" env:"TENANT_HEADER"`   // header naming the tenant of unauthenticated requests
+	Default string `yaml:"default" env:"TENANT_DEFAULT"` // tenant used when the request names none
+}
+
+type CatalogConfig struct {
+	LeafCategoriesOnly      bool `yaml:"leaf_categories_only" env:"CATALOG_LEAF_CATEGORIES_ONLY"`           // products may only be assigned to categories without subcategories
+	PublishRequiresCategory bool `yaml:"publish_requires_category" env:"CATALOG_PUBLISH_REQUIRES_CATEGORY"` // products need a category to be published
+	PublishMinImages        int  `yaml:"publish_min_images" env:"CATALOG_PUBLISH_MIN_IMAGES"`               // images a product needs to be published
+}
+
+type ImageConfig struct {
+	Derivatives []string `yaml:"derivatives" env:"IMAGE_DERIVATIVES"`    // sizes rendered for every uploaded image, as name=WIDTHxHEIGHT[:fit]
+	Formats     []string `yaml:"formats" env:"IMAGE_DERIVATIVE_FORMATS"` // formats each derivative is rendered in
+	Workers     int      `yaml:"workers" env:"IMAGE_WORKERS"`            // images processed concurrently, also bounding on-demand transforms
+	QueueSize   int      `yaml:"queue_size" env:"IMAGE_QUEUE_SIZE"`      // uploaded images waiting to be processed
+	JPEGQuality int      `yaml:"jpeg_quality" env:"IMAGE_JPEG_QUALITY"`  // quality of JPEG and WebP output, 1-100
+}
+
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled" env:"METRICS_ENABLED"` // serve Prometheus metrics
+	Path    string `yaml:"path" env:"METRICS_PATH"`       // path metrics are served on
+}
+
+type TracingConfig struct {
+	Exporter     string  `yaml:"exporter" env:"TRACING_EXPORTER"`           // "none", "stdout" or "otlp"
+	OTLPEndpoint string  `yaml:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT"` // OTLP/HTTP collector URL, e.g. http://localhost:4318; empty for the OTEL_EXPORTER_OTLP_* variables
+	ServiceName  string  `yaml:"service_name" env:"TRACING_SERVICE_NAME"`   // service.name of the spans
+	SampleRatio  float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`   // share of traces recorded, 0-1; traces the caller samples are always recorded
+}
+
+type LogConfig struct {
+	Format          string   `yaml:"format" env:"LOG_FORMAT"`                       // "text" or "json"
+	Level           string   `yaml:"level" env:"LOG_LEVEL"`                         // "debug", "info", "warn" or "error"
+	RedactKeys      []string `yaml:"redact_keys" env:"LOG_REDACT_KEYS"`             // attributes whose key contains one of these are logged as [REDACTED]
+	RequestIDHeader string   `yaml:"request_id_header" env:"LOG_REQUEST_ID_HEADER"` // header carrying the ID of a request, generated when the caller sends none
 }
 
-// Load loads configuration from environment or files
-func Load() *Config {
+type HealthConfig struct {
+	CheckTimeout time.Duration `yaml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT"` // longest a readiness check may take before its dependency counts as down
+	CacheTTL     time.Duration `yaml:"cache_ttl" env:"HEALTH_CACHE_TTL"`         // how long readiness results are reused
+}
+
+// Default returns the configuration used when nothing overrides it. Its credentials suit local development
+// only; Validate rejects them in production.
+func Default() *Config {
 	return &Config{
+		Environment: EnvironmentDevelopment,
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
-			Host: getEnv("SERVER_HOST", "0.0.0.0"),
+			Port: "8080",
+			Host: "0.0.0.0",
+			// Reads and writes are not limited by default, as uploads and downloads may take long
+			IdleTimeout:     2 * time.Minute,
+			BodyLimit:       4 << 20,
+			Concurrency:     256 * 1024,
+			ShutdownTimeout: 30 * time.Second,
 		},
 		Database: DatabaseConfig{
-			Driver: getEnv("DB_DRIVER", "postgres"),
-			DSN:    getEnv("DB_DSN", "host=localhost port=5432 user=admin dbname=go-test password=adminadmin sslmode=disable"),
+			Driver: "postgres",
+			DSN:    "host=localhost port=5432 user=admin dbname=go-test password=" + defaultDatabasePassword + " sslmode=disable",
+			// Migrations are applied on startup for convenience; deployments that migrate in a separate
+			// step verify instead
+			Migrations:           "apply",
+			MigrationLockTimeout: time.Minute,
 		},
 		MinIO: MinIOConfig{
-			Endpoint:        getEnv("MINIO_ENDPOINT", "localhost:9000"),
-			AccessKeyID:     getEnv("MINIO_ACCESS_KEY", "minioadmin"),
-			SecretAccessKey: getEnv("MINIO_SECRET_KEY", "minioadmin123"),
-			UseSSL:          getEnvBool("MINIO_USE_SSL", false),
-			BucketName:      getEnv("MINIO_BUCKET_NAME", "go-yippi"),
+			Endpoint:        "localhost:9000",
+			AccessKeyID:     defaultMinIOAccessKey,
+			SecretAccessKey: defaultMinIOSecretKey,
+			BucketName:      "go-yippi",
+			Region:          "us-east-1",
+		},
+		Storage: StorageConfig{
+			Backend:             "minio",
+			Path:                "./data/storage",
+			MaxUploadSize:       32 << 20,
+			MaxUploadSizeBucket: map[string]int64{},
+			MaxUploadSizeType:   map[string]int64{},
+			AllowedTypesBucket:  map[string][]string{},
+			BlockedTypes:        []string{"text/html", "application/xhtml+xml", "image/svg+xml", "text/xml", "application/xml", "text/javascript", "application/javascript"},
+			QuarantineBucket:    "quarantine",
+			Scanner:             "none",
+			ClamdAddress:        "tcp://localhost:3310",
+			ScanTimeout:         time.Minute,
+			PresignExpiry:       15 * time.Minute,
+			ResumablePartSize:   8 << 20,
+			ResumableExpiry:     24 * time.Hour,
+			DeleteInUse:         "block",
+			GCGracePeriod:       24 * time.Hour,
+			CacheControl:        "no-cache",
+			CacheControlBucket:  map[string]string{},
+		},
+		Auth: AuthConfig{
+			TokenSecret:       defaultTokenSecret,
+			Issuer:            "go-yippi",
+			AccessTokenTTL:    time.Hour,
+			ChallengeTokenTTL: 5 * time.Minute,
+			TOTPMaxAttempts:   5,
+			TOTPLockout:       15 * time.Minute,
+			SensitiveRoles:    []string{"admin"},
+		},
+		Tenant: TenantConfig{
+			Header:  "X-Tenant-ID",
+			Default: "default",
+		},
+		Image: ImageConfig{
+			Derivatives: []string{"thumb=200x200:cover", "medium=800x800", "large=1600x1600"},
+			Formats:     []string{"webp", "jpeg"},
+			Workers:     2,
+			QueueSize:   100,
+			JPEGQuality: 85,
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+			Path:    "/metrics",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "go-yippi",
+			SampleRatio: 1,
+		},
+		Log: LogConfig{
+			Format:          "text",
+			Level:           "info",
+			RedactKeys:      []string{"password", "secret", "token", "authorization", "cookie", "api_key", "access_key", "private_key", "otp", "dsn"},
+			RequestIDHeader: "X-Request-ID",
+		},
+		Health: HealthConfig{
+			CheckTimeout: 2 * time.Second,
+			CacheTTL:     5 * time.Second,
 		},
 	}
 }
 
-func getEnv(key, fallback string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
-	}
-	return fallback
-}
-
-func getEnvBool(key string, fallback bool) bool {
-	if v := os.Getenv(key); v != "" {
-		return v == "true" || v == "1" || v == "yes"
-	}
-	return fallback
-}
+// Development credentials of the default configuration, refused in production
+const (
+	defaultDatabasePassword = "adminadmin"
+	defaultMinIOAccessKey   = "minioadmin"
+	defaultMinIOSecretKey   = "minioadmin123"
+	defaultTokenSecret      = "change-me-in-production"
+)
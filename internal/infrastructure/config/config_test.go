@@ -0,0 +1,193 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func load(t *testing.T, args ...string) (*Config, error) {
+	t.Helper()
+	return Load(flag.NewFlagSet("test", flag.ContinueOnError), args)
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	// Act
+	cfg, err := load(t)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, Default(), cfg)
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestLoad_Layers(t *testing.T) {
+	// Arrange
+	path := writeFile(t, `
+server:
+  port: "9000"
+  host: 127.0.0.1
+  body_limit: 8MB
+storage:
+  max_upload_size_by_bucket:
+    avatars: 1MB
+  allowed_types_by_bucket:
+    avatars: [image/png, image/jpeg]
+  blocked_types: [text/html]
+  scan_timeout: 30s
+`)
+	t.Setenv("SERVER_PORT", "9001")
+	t.Setenv("UPLOAD_MAX_SIZE", "1GB")
+
+	// Act
+	cfg, err := load(t, "--config", path, "--server-port", "9002", "--upload-scan-timeout", "10s")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "9002", cfg.Server.Port)
+	assert.Equal(t, "127.0.0.1", cfg.Server.Host)
+	assert.Equal(t, int64(8<<20), cfg.Server.BodyLimit)
+	assert.Equal(t, int64(1<<30), cfg.Storage.MaxUploadSize)
+	assert.Equal(t, map[string]int64{"avatars": 1 << 20}, cfg.Storage.MaxUploadSizeBucket)
+	assert.Equal(t, map[string][]string{"avatars": {"image/png", "image/jpeg"}}, cfg.Storage.AllowedTypesBucket)
+	assert.Equal(t, []string{"text/html"}, cfg.Storage.BlockedTypes)
+	assert.Equal(t, 10*time.Second, cfg.Storage.ScanTimeout)
+}
+
+func TestLoad_ConfigFileFromEnvironment(t *testing.T) {
+	// Arrange
+	t.Setenv("CONFIG_FILE", writeFile(t, "log:\n  level: debug\n"))
+
+	// Act
+	cfg, err := load(t)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "debug", cfg.Log.Level)
+}
+
+func TestLoad_SecretFile(t *testing.T) {
+	// Arrange
+	secret := filepath.Join(t.TempDir(), "token_secret")
+	require.NoError(t, os.WriteFile(secret, []byte("from-file\n"), 0o600))
+	t.Setenv("AUTH_TOKEN_SECRET_FILE", secret)
+
+	// Act
+	cfg, err := load(t)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", cfg.Auth.TokenSecret)
+
+	t.Run("conflicts with the variable", func(t *testing.T) {
+		// Arrange
+		t.Setenv("AUTH_TOKEN_SECRET", "from-env")
+
+		// Act
+		_, err := load(t)
+
+		// Assert
+		assert.ErrorContains(t, err, "AUTH_TOKEN_SECRET and AUTH_TOKEN_SECRET_FILE are both set")
+	})
+}
+
+func TestLoad_Errors(t *testing.T) {
+	// Arrange
+	path := writeFile(t, "server:\n  port: \"9000\"\n  prot: \"9000\"\nimage:\n  workers: many\n")
+	t.Setenv("UPLOAD_MAX_SIZE", "lots")
+	t.Setenv("MINIO_USE_SSL", "maybe")
+
+	// Act
+	_, err := load(t, "--config", path, "--server-idle-timeout", "2")
+
+	// Assert
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "unknown setting server.prot")
+	assert.ErrorContains(t, err, `image.workers: invalid integer "many"`)
+	assert.ErrorContains(t, err, `UPLOAD_MAX_SIZE: invalid size "lots"`)
+	assert.ErrorContains(t, err, `MINIO_USE_SSL: invalid boolean "maybe"`)
+	assert.ErrorContains(t, err, `--server-idle-timeout: invalid duration "2"`)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		errors []string
+	}{
+		{
+			name:   "invalid settings",
+			modify: func(c *Config) { c.Server.Port = "http"; c.Storage.Backend = "s3"; c.Image.JPEGQuality = 0 },
+			errors: []string{"SERVER_PORT", "STORAGE_BACKEND", "IMAGE_JPEG_QUALITY"},
+		},
+		{
+			name:   "production with default credentials",
+			modify: func(c *Config) { c.Environment = EnvironmentProduction },
+			errors: []string{"AUTH_TOKEN_SECRET", "DB_DSN", "MINIO_ACCESS_KEY", "MINIO_SECRET_KEY"},
+		},
+		{
+			name: "production with own credentials",
+			modify: func(c *Config) {
+				c.Environment = EnvironmentProduction
+				c.Auth.TokenSecret = "a-long-random-secret"
+				c.Database.DSN = "host=db user=app password=s3cret"
+				c.Storage.Backend = "filesystem"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			cfg := Default()
+			tt.modify(cfg)
+
+			// Act
+			err := cfg.Validate()
+
+			// Assert
+			if len(tt.errors) == 0 {
+				assert.NoError(t, err)
+			}
+			for _, msg := range tt.errors {
+				assert.ErrorContains(t, err, msg)
+			}
+		})
+	}
+}
+
+func TestPrintRedacted(t *testing.T) {
+	// Arrange
+	cfg := Default()
+	cfg.Storage.MaxUploadSizeType = map[string]int64{"video/*": 1 << 30}
+	var out bytes.Buffer
+
+	// Act
+	err := cfg.PrintRedacted(&out)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "token_secret: '[REDACTED]'")
+	assert.NotContains(t, out.String(), defaultTokenSecret)
+	assert.NotContains(t, out.String(), defaultDatabasePassword)
+
+	// The output is a config file with the same settings, apart from the secrets
+	printed, err := load(t, "--config", writeFile(t, out.String()))
+	require.NoError(t, err)
+	assert.Equal(t, Redacted, printed.Auth.TokenSecret)
+	printed.Database, printed.MinIO.AccessKeyID, printed.MinIO.SecretAccessKey, printed.Auth.TokenSecret = cfg.Database, cfg.MinIO.AccessKeyID, cfg.MinIO.SecretAccessKey, cfg.Auth.TokenSecret
+	assert.Equal(t, cfg, printed)
+}
@@ -0,0 +1,444 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Redacted replaces secrets in printed configurations
+const Redacted = "[REDACTED]"
+
+// setting is a field of Config that the layers of Load can set
+type setting struct {
+	path   []string // keys of the field in the YAML file
+	env    string   // environment variable of the field
+	size   bool     // bytes written with an optional KB, MB or GB suffix
+	secret bool     // redacted when printed, may be read from the file named by the env variable with a _FILE suffix
+	value  reflect.Value
+}
+
+// flagName derives the flag of a setting from its variable, e.g. --upload-max-size from UPLOAD_MAX_SIZE
+func (s setting) flagName() string {
+	return strings.ToLower(strings.ReplaceAll(s.env, "_", "-"))
+}
+
+// settings lists the settings of c in the order of its fields
+func (c *Config) settings() []setting {
+	return collectSettings(reflect.ValueOf(c).Elem(), nil)
+}
+
+func collectSettings(v reflect.Value, path []string) []setting {
+	var settings []setting
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		fieldPath := append(append([]string(nil), path...), field.Tag.Get("yaml"))
+		env := field.Tag.Get("env")
+		if env == "" && field.Type.Kind() == reflect.Struct {
+			settings = append(settings, collectSettings(v.Field(i), fieldPath)...)
+			continue
+		}
+		settings = append(settings, setting{
+			path:   fieldPath,
+			env:    env,
+			size:   field.Tag.Get("size") == "true",
+			secret: field.Tag.Get("secret") == "true",
+			value:  v.Field(i),
+		})
+	}
+	return settings
+}
+
+// Load builds the configuration from layers, each overriding the previous ones:
+//
+//  1. the defaults of Default
+//  2. the YAML file named by the --config flag or the CONFIG_FILE variable
+//  3. the environment, ignoring empty variables; secrets may instead be read from the file named by their
+//     variable with a _FILE suffix, e.g. DB_DSN_FILE
+//  4. command-line flags named after the variables, e.g. --server-port for SERVER_PORT; secrets have no
+//     flags, as the arguments of a process are visible to other users
+//
+// The flags are registered on fs, which may hold flags of the command, and parsed from args. Load reports
+// every value it cannot parse; whether the result is usable is up to Validate.
+func Load(fs *flag.FlagSet, args []string) (*Config, error) {
+	cfg := Default()
+	settings := cfg.settings()
+
+	type override struct {
+		setting setting
+		value   string
+	}
+	var overrides []override
+	file := fs.String("config", os.Getenv("CONFIG_FILE"), "YAML configuration `file` (CONFIG_FILE)")
+	for _, s := range settings {
+		if s.secret {
+			continue
+		}
+		fs.Func(s.flagName(), "overrides "+s.env, func(value string) error {
+			overrides = append(overrides, override{setting: s, value: value})
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	if *file != "" {
+		if err := cfg.loadFile(settings, *file); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, s := range settings {
+		value, ok, err := lookupEnv(s)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			if err := s.set(value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", s.env, err))
+			}
+		}
+	}
+	for _, o := range overrides {
+		if err := o.setting.set(o.value); err != nil {
+			errs = append(errs, fmt.Errorf("--%s: %w", o.setting.flagName(), err))
+		}
+	}
+	return cfg, errors.Join(errs...)
+}
+
+// lookupEnv returns the value of the variable of s, or the content of the file its _FILE variable names
+func lookupEnv(s setting) (string, bool, error) {
+	value := os.Getenv(s.env)
+	if !s.secret {
+		return value, value != "", nil
+	}
+
+	path := os.Getenv(s.env + "_FILE")
+	if path == "" {
+		return value, value != "", nil
+	}
+	if value != "" {
+		return "", false, fmt.Errorf("%s and %s_FILE are both set", s.env, s.env)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("%s_FILE: %w", s.env, err)
+	}
+	return strings.TrimRight(string(content), "\r\n"), true, nil
+}
+
+// loadFile applies the settings of a YAML file, rejecting keys that name no setting
+func (c *Config) loadFile(settings []setting, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if len(root.Content) == 0 {
+		return nil
+	}
+
+	byPath := make(map[string]setting, len(settings))
+	sections := map[string]bool{}
+	for _, s := range settings {
+		byPath[strings.Join(s.path, ".")] = s
+		for i := 1; i < len(s.path); i++ {
+			sections[strings.Join(s.path[:i], ".")] = true
+		}
+	}
+
+	var errs []error
+	var walk func(node *yaml.Node, prefix string)
+	walk = func(node *yaml.Node, prefix string) {
+		if node.Kind != yaml.MappingNode {
+			errs = append(errs, fmt.Errorf("%s:%d: expected a mapping", path, node.Line))
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			name := prefix + key.Value
+			if s, ok := byPath[name]; ok {
+				if err := s.setNode(value); err != nil {
+					errs = append(errs, fmt.Errorf("%s:%d: %s: %w", path, key.Line, name, err))
+				}
+			} else if sections[name] {
+				walk(value, name+".")
+			} else {
+				errs = append(errs, fmt.Errorf("%s:%d: unknown setting %s", path, key.Line, name))
+			}
+		}
+	}
+	walk(root.Content[0], "")
+	return errors.Join(errs...)
+}
+
+// setNode sets s from a YAML value. Lists and maps may be written as YAML sequences and mappings or in the
+// format of the environment.
+func (s setting) setNode(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return s.set(node.Value)
+	case yaml.SequenceNode:
+		items, err := scalars(node)
+		if err != nil {
+			return err
+		}
+		return s.set(strings.Join(items, ","))
+	case yaml.MappingNode:
+		separator := ";"
+		if s.size {
+			separator = ","
+		}
+		pairs := make([]string, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value := node.Content[i+1]
+			items := []string{value.Value}
+			if value.Kind == yaml.SequenceNode {
+				var err error
+				if items, err = scalars(value); err != nil {
+					return err
+				}
+			}
+			pairs = append(pairs, node.Content[i].Value+"="+strings.Join(items, ","))
+		}
+		return s.set(strings.Join(pairs, separator))
+	default:
+		return fmt.Errorf("unsupported value")
+	}
+}
+
+func scalars(node *yaml.Node) ([]string, error) {
+	items := make([]string, 0, len(node.Content))
+	for _, item := range node.Content {
+		if item.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("expected a list of values")
+		}
+		items = append(items, item.Value)
+	}
+	return items, nil
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// set parses value in the format of the environment into the field of s
+func (s setting) set(value string) error {
+	v := s.value
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(value)
+	case v.Kind() == reflect.Bool:
+		b, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int64 && s.size:
+		n, err := parseSize(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case v.Kind() == reflect.Int || v.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		v.SetInt(n)
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Slice:
+		v.Set(reflect.ValueOf(parseList(value)))
+	case v.Kind() == reflect.Map && s.size:
+		sizes, err := parseSizeMap(value)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(sizes))
+	case v.Type() == reflect.TypeFor[map[string]string]():
+		v.Set(reflect.ValueOf(parseStringMap(value)))
+	case v.Type() == reflect.TypeFor[map[string][]string]():
+		v.Set(reflect.ValueOf(parseListMap(value)))
+	default:
+		panic("config: unsupported setting type " + v.Type().String())
+	}
+	return nil
+}
+
+func parseBool(v string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "true", "1", "yes":
+		return true, nil
+	case "false", "0", "no":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q", v)
+}
+
+// parseList reads a comma-separated list
+func parseList(v string) []string {
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// parseSize reads a size in bytes, with an optional KB, MB or GB suffix (powers of 1024)
+func parseSize(v string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(v))
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", v)
+	}
+	return n * multiplier, nil
+}
+
+var sizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}}
+
+// formatSize writes a size with the largest unit that divides it
+func formatSize(n int64) string {
+	for _, unit := range sizeUnits {
+		if n != 0 && n%unit.multiplier == 0 && unit.multiplier > 1 {
+			return strconv.FormatInt(n/unit.multiplier, 10) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// parseSizeMap reads a comma-separated list of key=size pairs, e.g. "avatars=1MB,video/*=1GB"
+func parseSizeMap(v string) (map[string]int64, error) {
+	sizes := map[string]int64{}
+	for _, item := range parseList(v) {
+		name, size, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid pair %q, expected key=size", item)
+		}
+		n, err := parseSize(size)
+		if err != nil {
+			return nil, err
+		}
+		sizes[strings.TrimSpace(name)] = n
+	}
+	return sizes, nil
+}
+
+// parseStringMap reads a semicolon-separated list of key=value pairs, so that values may contain commas,
+// e.g. "assets=public, max-age=86400;avatars=no-store"
+func parseStringMap(v string) map[string]string {
+	values := map[string]string{}
+	for _, item := range strings.Split(v, ";") {
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			continue
+		}
+		values[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return values
+}
+
+// parseListMap reads a semicolon-separated list of key=list pairs, e.g. "avatars=image/png,image/jpeg;videos=video/*"
+func parseListMap(v string) map[string][]string {
+	lists := map[string][]string{}
+	for name, value := range parseStringMap(v) {
+		lists[name] = parseList(value)
+	}
+	return lists
+}
+
+// PrintRedacted writes the configuration as a YAML file Load accepts, with secrets replaced by Redacted
+func (c *Config) PrintRedacted(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, s := range c.settings() {
+		parent := root
+		for _, key := range s.path[:len(s.path)-1] {
+			parent = childMapping(parent, key)
+		}
+
+		var value yaml.Node
+		if err := value.Encode(s.printable()); err != nil {
+			return err
+		}
+		parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: s.path[len(s.path)-1]}, &value)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// childMapping returns the mapping under key in parent, adding it if it is missing
+func childMapping(parent *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == key {
+			return parent.Content[i+1]
+		}
+	}
+	child := &yaml.Node{Kind: yaml.MappingNode}
+	parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, child)
+	return child
+}
+
+// printable returns the value of s as it is written in the file
+func (s setting) printable() any {
+	v := s.value
+	switch {
+	case s.secret && !v.IsZero():
+		return Redacted
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+	case v.Kind() == reflect.Int64 && s.size:
+		return formatSize(v.Int())
+	case v.Kind() == reflect.Map && s.size:
+		sizes := map[string]string{}
+		for name, n := range v.Interface().(map[string]int64) {
+			sizes[name] = formatSize(n)
+		}
+		return sizes
+	case v.Kind() == reflect.Slice && v.Len() == 0:
+		return []string{}
+	case v.Kind() == reflect.Map && v.Len() == 0:
+		return map[string]string{}
+	}
+	return v.Interface()
+}
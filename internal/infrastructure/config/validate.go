@@ -0,0 +1,91 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Validate reports every setting that is out of range or not one of its options. In production it also
+// refuses the development credentials of Default.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, env, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", env, fmt.Sprintf(format, args...)))
+		}
+	}
+	oneOf := func(value, env string, options ...string) {
+		check(slices.Contains(options, value), env, "%q is not one of %s", value, strings.Join(options, ", "))
+	}
+
+	oneOf(c.Environment, "APP_ENV", EnvironmentDevelopment, EnvironmentProduction)
+
+	port, err := strconv.Atoi(c.Server.Port)
+	check(err == nil && port > 0 && port <= 65535, "SERVER_PORT", "%q is not a port", c.Server.Port)
+	check(c.Server.ReadTimeout >= 0, "SERVER_READ_TIMEOUT", "must not be negative")
+	check(c.Server.WriteTimeout >= 0, "SERVER_WRITE_TIMEOUT", "must not be negative")
+	check(c.Server.IdleTimeout >= 0, "SERVER_IDLE_TIMEOUT", "must not be negative")
+	check(c.Server.BodyLimit > 0, "SERVER_BODY_LIMIT", "must be positive")
+	check(c.Server.Concurrency > 0, "SERVER_CONCURRENCY", "must be positive")
+	check(c.Server.ShutdownTimeout >= 0, "SERVER_SHUTDOWN_TIMEOUT", "must not be negative")
+
+	oneOf(c.Database.Driver, "DB_DRIVER", "postgres", "sqlite3")
+	check(c.Database.DSN != "", "DB_DSN", "must be set")
+	oneOf(c.Database.Migrations, "DB_MIGRATIONS", "apply", "verify")
+	check(c.Database.MigrationLockTimeout >= 0, "DB_MIGRATION_LOCK_TIMEOUT", "must not be negative")
+
+	oneOf(c.Storage.Backend, "STORAGE_BACKEND", "minio", "filesystem", "database")
+	if c.Storage.Backend == "minio" {
+		check(c.MinIO.Endpoint != "", "MINIO_ENDPOINT", "must be set")
+	}
+	if c.Storage.Backend == "filesystem" {
+		check(c.Storage.Path != "", "STORAGE_PATH", "must be set")
+	}
+	check(c.MinIO.BucketName != "", "MINIO_BUCKET_NAME", "must be set")
+	oneOf(c.Storage.Scanner, "UPLOAD_SCANNER", "none", "clamd", "fake")
+	oneOf(c.Storage.DeleteInUse, "FILE_DELETE_IN_USE", "block", "cascade")
+	check(c.Storage.ResumablePartSize > 0, "UPLOAD_RESUMABLE_PART_SIZE", "must be positive")
+	check(c.Storage.ScanTimeout > 0, "UPLOAD_SCAN_TIMEOUT", "must be positive")
+	check(c.Storage.PresignExpiry > 0, "UPLOAD_PRESIGN_EXPIRY", "must be positive")
+	check(c.Storage.ResumableExpiry > 0, "UPLOAD_RESUMABLE_EXPIRY", "must be positive")
+	check(c.Storage.GCGracePeriod >= 0, "GC_GRACE_PERIOD", "must not be negative")
+
+	check(c.Auth.TokenSecret != "", "AUTH_TOKEN_SECRET", "must be set")
+	check(c.Auth.AccessTokenTTL > 0, "AUTH_ACCESS_TOKEN_TTL", "must be positive")
+	check(c.Auth.ChallengeTokenTTL > 0, "AUTH_CHALLENGE_TOKEN_TTL", "must be positive")
+	check(c.Auth.TOTPMaxAttempts > 0, "AUTH_TOTP_MAX_ATTEMPTS", "must be positive")
+	check(c.Auth.TOTPLockout >= 0, "AUTH_TOTP_LOCKOUT", "must not be negative")
+	check((c.Auth.BootstrapAdminEmail == "") == (c.Auth.BootstrapAdminPassword == ""),
+		"AUTH_BOOTSTRAP_ADMIN_EMAIL", "must be set together with AUTH_BOOTSTRAP_ADMIN_PASSWORD")
+
+	check(c.Tenant.Header != "", "TENANT_HEADER", "must be set")
+	check(c.Catalog.PublishMinImages >= 0, "CATALOG_PUBLISH_MIN_IMAGES", "must not be negative")
+
+	check(c.Image.Workers > 0, "IMAGE_WORKERS", "must be positive")
+	check(c.Image.QueueSize >= 0, "IMAGE_QUEUE_SIZE", "must not be negative")
+	check(c.Image.JPEGQuality >= 1 && c.Image.JPEGQuality <= 100, "IMAGE_JPEG_QUALITY", "must be between 1 and 100")
+
+	check(strings.HasPrefix(c.Metrics.Path, "/"), "METRICS_PATH", "must start with /")
+	oneOf(c.Tracing.Exporter, "TRACING_EXPORTER", "none", "stdout", "otlp")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "TRACING_SAMPLE_RATIO", "must be between 0 and 1")
+	oneOf(c.Log.Format, "LOG_FORMAT", "text", "json")
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Log.Level)) == nil, "LOG_LEVEL", "%q is not one of debug, info, warn, error", c.Log.Level)
+	check(c.Health.CheckTimeout > 0, "HEALTH_CHECK_TIMEOUT", "must be positive")
+	check(c.Health.CacheTTL >= 0, "HEALTH_CACHE_TTL", "must not be negative")
+
+	if c.Environment == EnvironmentProduction {
+		const refused = "the development default must not be used in production"
+		check(c.Auth.TokenSecret != defaultTokenSecret, "AUTH_TOKEN_SECRET", refused)
+		check(!strings.Contains(c.Database.DSN, defaultDatabasePassword), "DB_DSN", refused)
+		if c.Storage.Backend == "minio" {
+			check(c.MinIO.AccessKeyID != defaultMinIOAccessKey, "MINIO_ACCESS_KEY", refused)
+			check(c.MinIO.SecretAccessKey != defaultMinIOSecretKey, "MINIO_SECRET_KEY", refused)
+		}
+	}
+	return errors.Join(errs...)
+}